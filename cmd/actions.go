@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var actionsCmd = &cobra.Command{
+	Use:   "actions",
+	Short: "Set up GitHub Actions for jip",
+}
+
+var actionsGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Print a workflow that keeps stacks in sync when pushed outside jip",
+	Long: `Generate prints a GitHub Actions workflow that runs jip reconcile whenever a
+jip branch is pushed or a PR is closed, so the stack navigation and bases of
+the other PRs of its stack stay in sync when someone pushes with git or
+merges on GitHub instead of using jip.
+
+Save it as .github/workflows/jip.yml (or pass --output). The push trigger is
+limited to the branch names jip generates, as configured by
+bookmark-template.`,
+	Args: cobra.NoArgs,
+	RunE: runActionsGenerate,
+}
+
+func init() {
+	rootCmd.AddCommand(actionsCmd)
+	actionsCmd.AddCommand(actionsGenerateCmd)
+	actionsGenerateCmd.Flags().StringP("output", "o", "", "Write the workflow to this file instead of standard output")
+	actionsGenerateCmd.Flags().String("jip-version", "", "jip version the workflow installs (default this jip's version, or latest)")
+}
+
+func runActionsGenerate(cmd *cobra.Command, args []string) error {
+	// The bookmark template narrows the push trigger; outside a repository
+	// the default names are assumed.
+	template := ""
+	if _, repoRoot, err := workspaceRunner(); err == nil {
+		cfg, err := config.Load(repoRoot)
+		if err != nil {
+			return err
+		}
+		template = cfg["bookmark-template"]
+	}
+	namer, err := jj.NewBookmarkNamer(template, 0, nil)
+	if err != nil {
+		return err
+	}
+
+	version, _ := cmd.Flags().GetString("jip-version")
+	if version == "" {
+		version = "latest"
+		if v := buildVersion(); v != "dev" {
+			version = "v" + v
+		}
+	}
+	workflow := reconcileWorkflow(workflowBranches(namer.Globs()), version)
+
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		_, err := fmt.Fprint(cmd.OutOrStdout(), workflow)
+		return err
+	}
+	if err := os.WriteFile(output, []byte(workflow), 0o644); err != nil {
+		return fmt.Errorf("writing workflow: %w", err)
+	}
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s.\n", output)
+	return nil
+}
+
+// workflowBranches translates jj glob patterns of bookmark names into
+// GitHub Actions branch filters. Without patterns every branch matches.
+func workflowBranches(globs []string) []string {
+	var branches []string
+	for _, g := range globs {
+		// Each glob is a fixed prefix followed by "*"; in Actions filters
+		// only "**" also matches "/".
+		prefix := strings.TrimSuffix(strings.TrimPrefix(g, "glob:"), "*")
+		branches = append(branches, prefix+"**")
+	}
+	return branches
+}
+
+// reconcileWorkflow renders the workflow running jip reconcile, installing
+// jip at version. It runs on pushes to branches (every branch when empty)
+// and on closed PRs. Event values reach the shell through environment
+// variables only, so branch names cannot inject commands.
+func reconcileWorkflow(branches []string, version string) string {
+	var b strings.Builder
+	b.WriteString(`# Generated by jip actions generate. Keeps the stack navigation and bases
+# of jip's stacked PRs in sync when their branches are pushed or their PRs
+# merged without jip.
+name: jip reconcile
+
+on:
+  push:
+`)
+	if len(branches) > 0 {
+		b.WriteString("    branches:\n")
+		for _, branch := range branches {
+			fmt.Fprintf(&b, "      - '%s'\n", strings.ReplaceAll(branch, "'", "''"))
+		}
+	}
+	fmt.Fprintf(&b, `  pull_request:
+    types: [closed]
+
+permissions:
+  pull-requests: write
+
+concurrency:
+  group: jip-reconcile-${{ github.repository }}
+  cancel-in-progress: false
+
+jobs:
+  reconcile:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/setup-go@v5
+        with:
+          go-version: stable
+      - name: Install jip
+        run: go install github.com/omarkohl/jip@%s
+      - name: Reconcile
+        env:
+          GITHUB_TOKEN: ${{ github.token }}
+          EVENT: ${{ github.event_name }}
+          PR: ${{ github.event.pull_request.number }}
+          BRANCH: ${{ github.ref_name }}
+        run: |
+          if [ "$EVENT" = pull_request ]; then
+            jip reconcile "$PR"
+          else
+            jip reconcile --branch "$BRANCH"
+          fi
+`, version)
+	return b.String()
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWorkflowBranches(t *testing.T) {
+	got := workflowBranches([]string{"glob:jip/*", "glob:alice/*"})
+	if want := []string{"jip/**", "alice/**"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := workflowBranches(nil); got != nil {
+		t.Errorf("no globs should not filter branches, got %v", got)
+	}
+}
+
+func TestReconcileWorkflow(t *testing.T) {
+	wf := reconcileWorkflow([]string{"jip/**"}, "v1.2.3")
+	for _, want := range []string{
+		"  push:\n    branches:\n      - 'jip/**'\n  pull_request:\n",
+		"go install github.com/omarkohl/jip@v1.2.3",
+		`jip reconcile --branch "$BRANCH"`,
+	} {
+		if !strings.Contains(wf, want) {
+			t.Errorf("workflow lacks %q:\n%s", want, wf)
+		}
+	}
+	// Event values must not be expanded into the script itself.
+	_, script, _ := strings.Cut(wf, "run: |")
+	if strings.Contains(script, "${{") {
+		t.Errorf("script interpolates expressions:\n%s", script)
+	}
+}
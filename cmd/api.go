@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	gh "github.com/omarkohl/jip/internal/github"
+	"github.com/spf13/cobra"
+)
+
+var apiCmd = &cobra.Command{
+	Use:   "api",
+	Short: "Make authenticated GitHub API requests",
+	Long: `Api sends GitHub API requests with jip's token, API host (--hostname,
+$GITHUB_API_URL) and retries, for scripts that should see GitHub the way jip
+does.
+
+Fields are given as -f key=value (a string) or -F key=value (true, false,
+null and numbers are converted). A value of @file reads the file, @- reads
+standard input. {owner} and {repo} in the path and in field values are
+replaced with the repository of --remote. The response is printed as is,
+with JSON indented; a non-2xx status makes jip exit with an error.`,
+}
+
+var apiRESTCmd = &cobra.Command{
+	Use:   "rest <path>",
+	Short: "Send a REST request",
+	Long: `Rest sends a request to a REST endpoint, e.g. jip api rest repos/{owner}/{repo}/pulls.
+
+Fields become query parameters for GET and a JSON body otherwise. The method
+defaults to GET, or POST when fields are given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAPIREST,
+}
+
+var apiGraphQLCmd = &cobra.Command{
+	Use:   "graphql",
+	Short: "Send a GraphQL query",
+	Long: `Graphql sends the query field as a GraphQL query and every other field as a
+variable, e.g. jip api graphql -f query=@query.graphql -F number=42.`,
+	Args: cobra.NoArgs,
+	RunE: runAPIGraphQL,
+}
+
+func init() {
+	rootCmd.AddCommand(apiCmd)
+	apiCmd.AddCommand(apiRESTCmd, apiGraphQLCmd)
+	apiCmd.PersistentFlags().StringArrayP("field", "f", nil, "Add a string field key=value (@file reads a file)")
+	apiCmd.PersistentFlags().StringArrayP("typed-field", "F", nil, "Add a field key=value, converting true, false, null and numbers")
+	apiCmd.PersistentFlags().String("remote", "origin", "Remote whose repository fills in {owner} and {repo}")
+	apiCmd.PersistentFlags().String("hostname", defaultHost, "GitHub host to send the request to (e.g. a GitHub Enterprise Server)")
+	apiRESTCmd.Flags().StringP("method", "X", "", "HTTP method (default GET, or POST with fields)")
+	apiRESTCmd.Flags().String("input", "", "File to send as the request body (- for standard input)")
+}
+
+func runAPIREST(cmd *cobra.Command, args []string) error {
+	client, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	fields, err := apiFields(cmd)
+	if err != nil {
+		return err
+	}
+	method, _ := cmd.Flags().GetString("method")
+	input, _ := cmd.Flags().GetString("input")
+	path := args[0]
+	if strings.Contains(path, "{owner}") || strings.Contains(path, "{repo}") {
+		if path, err = fillRepoPlaceholders(cmd, path); err != nil {
+			return err
+		}
+	}
+
+	method = strings.ToUpper(method)
+	if method == "" {
+		method = "GET"
+		if len(fields) > 0 || input != "" {
+			method = "POST"
+		}
+	}
+	var body []byte
+	switch {
+	case input != "":
+		if body, err = readAPIValue("@" + input); err != nil {
+			return err
+		}
+	case method == "GET" && len(fields) > 0:
+		q := url.Values{}
+		for k, v := range fields {
+			q.Set(k, fmt.Sprint(v))
+		}
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+		path += sep + q.Encode()
+	case len(fields) > 0:
+		if body, err = json.Marshal(fields); err != nil {
+			return err
+		}
+	}
+
+	resp, err := client.REST(method, path, body)
+	if err != nil {
+		return err
+	}
+	return printAPIResponse(cmd.OutOrStdout(), resp)
+}
+
+func runAPIGraphQL(cmd *cobra.Command, args []string) error {
+	client, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+	fields, err := apiFields(cmd)
+	if err != nil {
+		return err
+	}
+	query, ok := fields["query"].(string)
+	if !ok || query == "" {
+		return fmt.Errorf("no query — pass it with -f query=... or -f query=@file")
+	}
+	delete(fields, "query")
+
+	resp, err := client.GraphQL(query, fields)
+	if resp != nil {
+		if perr := printAPIResponse(cmd.OutOrStdout(), resp); err == nil {
+			err = perr
+		}
+	}
+	return err
+}
+
+func apiClient(cmd *cobra.Command) (*gh.APIClient, error) {
+	host, _ := cmd.Flags().GetString("hostname")
+	host = strings.ToLower(host)
+	token, _ := hostToken(host)
+	if token == "" {
+		return nil, notAuthenticated(host)
+	}
+	return gh.NewAPIClient(token, hostAPIURL(host)).WithContext(runContext), nil
+}
+
+// apiFields collects the -f and -F fields, with file contents read and
+// {owner} and {repo} filled in.
+func apiFields(cmd *cobra.Command) (map[string]any, error) {
+	raw, _ := cmd.Flags().GetStringArray("field")
+	typed, _ := cmd.Flags().GetStringArray("typed-field")
+	fields := make(map[string]any, len(raw)+len(typed))
+	for _, group := range []struct {
+		args  []string
+		typed bool
+	}{{raw, false}, {typed, true}} {
+		for _, arg := range group.args {
+			key, value, err := parseAPIField(arg, group.typed)
+			if err != nil {
+				return nil, err
+			}
+			if s, ok := value.(string); ok && (strings.Contains(s, "{owner}") || strings.Contains(s, "{repo}")) {
+				if value, err = fillRepoPlaceholders(cmd, s); err != nil {
+					return nil, err
+				}
+			}
+			fields[key] = value
+		}
+	}
+	return fields, nil
+}
+
+// parseAPIField splits a key=value field. A value of @file is replaced by the
+// file's contents; typed values are converted like gh api -F does.
+func parseAPIField(arg string, typed bool) (string, any, error) {
+	key, value, ok := strings.Cut(arg, "=")
+	if !ok || key == "" {
+		return "", nil, fmt.Errorf("invalid field %q: want key=value", arg)
+	}
+	if strings.HasPrefix(value, "@") {
+		data, err := readAPIValue(value)
+		if err != nil {
+			return "", nil, err
+		}
+		return key, string(data), nil
+	}
+	if !typed {
+		return key, value, nil
+	}
+	switch value {
+	case "true":
+		return key, true, nil
+	case "false":
+		return key, false, nil
+	case "null":
+		return key, nil, nil
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return key, n, nil
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return key, f, nil
+	}
+	return key, value, nil
+}
+
+// readAPIValue reads the file named by an @file value, or standard input for @-.
+func readAPIValue(value string) ([]byte, error) {
+	name := strings.TrimPrefix(value, "@")
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// fillRepoPlaceholders replaces {owner} and {repo} in s with the repository
+// of the --remote remote. The workspace is only needed when they are used.
+func fillRepoPlaceholders(cmd *cobra.Command, s string) (string, error) {
+	remote, _ := cmd.Flags().GetString("remote")
+	runner, _, err := workspaceRunner()
+	if err != nil {
+		return "", fmt.Errorf("filling in {owner} and {repo}: %w", err)
+	}
+	remotes, err := gitRemotes(runner)
+	if err != nil {
+		return "", err
+	}
+	remoteURL, ok := remotes[remote]
+	if !ok {
+		return "", fmt.Errorf("remote %q not found (available: %v)", remote, remotes)
+	}
+	owner, repo, err := gh.ParseRepoFromURL(remoteURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing remote URL: %w", err)
+	}
+	return strings.NewReplacer("{owner}", owner, "{repo}", repo).Replace(s), nil
+}
+
+// printAPIResponse writes the response body, indented when it is JSON, and
+// turns a non-2xx status into an error.
+func printAPIResponse(w io.Writer, resp *gh.APIResponse) error {
+	var out bytes.Buffer
+	if json.Indent(&out, resp.Body, "", "  ") == nil {
+		out.WriteByte('\n')
+	} else {
+		out.Reset()
+		out.Write(resp.Body)
+	}
+	if _, err := w.Write(out.Bytes()); err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("GitHub API returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gh "github.com/omarkohl/jip/internal/github"
+)
+
+func TestParseAPIField(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "q.graphql")
+	if err := os.WriteFile(file, []byte("{viewer{login}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		arg   string
+		typed bool
+		key   string
+		want  any
+	}{
+		{"title=a=b", false, "title", "a=b"},
+		{"number=42", false, "number", "42"},
+		{"number=42", true, "number", int64(42)},
+		{"ratio=0.5", true, "ratio", 0.5},
+		{"draft=true", true, "draft", true},
+		{"base=null", true, "base", nil},
+		{"name=main", true, "name", "main"},
+		{"query=@" + file, false, "query", "{viewer{login}}"},
+	}
+	for _, tt := range tests {
+		key, got, err := parseAPIField(tt.arg, tt.typed)
+		if err != nil || key != tt.key || got != tt.want {
+			t.Errorf("parseAPIField(%q, %v) = %q, %#v, %v; want %q, %#v", tt.arg, tt.typed, key, got, err, tt.key, tt.want)
+		}
+	}
+	for _, arg := range []string{"novalue", "=x", "q=@" + file + ".missing"} {
+		if _, _, err := parseAPIField(arg, false); err == nil {
+			t.Errorf("parseAPIField(%q): expected error", arg)
+		}
+	}
+}
+
+func TestPrintAPIResponse(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printAPIResponse(&buf, &gh.APIResponse{StatusCode: 200, Body: []byte(`{"a":1}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "{\n  \"a\": 1\n}\n" {
+		t.Errorf("JSON output = %q", buf.String())
+	}
+
+	buf.Reset()
+	err := printAPIResponse(&buf, &gh.APIResponse{StatusCode: 404, Body: []byte("not json")})
+	if err == nil || buf.String() != "not json" {
+		t.Errorf("got %q, %v; want the raw body and an error", buf.String(), err)
+	}
+}
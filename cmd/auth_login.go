@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/cli/oauth"
 	"github.com/omarkohl/jip/internal/auth"
+	gh "github.com/omarkohl/jip/internal/github"
 	"github.com/spf13/cobra"
 )
 
@@ -37,6 +39,7 @@ func runAuthLogin(cmd *cobra.Command, args []string) error {
 		ClientID:     oauthClientID,
 		ClientSecret: oauthClientSecret,
 		Scopes:       []string{"repo"},
+		HTTPClient:   &http.Client{Transport: gh.NewLoggingTransport(nil)},
 	}
 
 	token, err := flow.DetectFlow()
@@ -44,10 +47,11 @@ func runAuthLogin(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("OAuth device flow failed: %w", err)
 	}
 
-	if err := auth.SaveToken(defaultHost, token.Token); err != nil {
+	where, err := auth.SaveToken(defaultHost, token.Token)
+	if err != nil {
 		return fmt.Errorf("failed to save token: %w", err)
 	}
 
-	_, err = fmt.Fprintln(cmd.OutOrStdout(), "Authentication successful! Token saved.")
+	_, err = fmt.Fprintf(cmd.OutOrStdout(), "Authentication successful! Token saved in the %s.\n", where)
 	return err
 }
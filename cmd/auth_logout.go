@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/auth"
+	"github.com/spf13/cobra"
+)
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove the token stored by jip auth login",
+	Long: `Logout removes the token jip stored for a host from the OS keyring and from
+jip's config file, after asking for confirmation when run in a terminal
+(skip it with --yes). Tokens jip does not manage — GH_TOKEN and the other
+environment variables, or the gh CLI's own login — are left alone; logout
+reports when one of them still authenticates jip.`,
+	Args: cobra.NoArgs,
+	RunE: runAuthLogout,
+}
+
+func init() {
+	authCmd.AddCommand(authLogoutCmd)
+	authLogoutCmd.Flags().String("hostname", defaultHost, "Host to remove the token of")
+	authLogoutCmd.Flags().BoolP("yes", "y", false, "Remove the token without asking")
+}
+
+func runAuthLogout(cmd *cobra.Command, args []string) error {
+	host, _ := cmd.Flags().GetString("hostname")
+	yes, _ := cmd.Flags().GetBool("yes")
+	w := cmd.OutOrStdout()
+
+	var confirm func(host, source string) (bool, error)
+	if stdinIsTerminal() && !yes {
+		confirm = promptLogout(cmd.InOrStdin(), w)
+	}
+	return executeAuthLogout(host, confirm, w)
+}
+
+// executeAuthLogout removes the token jip stored for host once confirm, when
+// not nil, agrees.
+func executeAuthLogout(host string, confirm func(host, source string) (bool, error), w io.Writer) error {
+	if _, source := auth.StoredToken(host); source == "" {
+		_, _ = fmt.Fprintf(w, "No token stored by jip for %s.\n", host)
+	} else {
+		if confirm != nil {
+			ok, err := confirm(host, source)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				_, _ = fmt.Fprintln(w, "Kept the token.")
+				return nil
+			}
+		}
+		removed, err := auth.DeleteToken(host)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(w, "Removed the token for %s from the %s.\n", host, strings.Join(removed, " and the "))
+	}
+	if _, source := hostToken(host); source != "" {
+		_, _ = fmt.Fprintf(w, "jip is still authenticated with %s via %s, which jip does not manage.\n", host, source)
+	}
+	return nil
+}
+
+// promptLogout returns a confirm function for executeAuthLogout asking on w
+// and reading the answer from in. Anything but yes keeps the token.
+func promptLogout(in io.Reader, w io.Writer) func(host, source string) (bool, error) {
+	reader := bufio.NewReader(in)
+	return func(host, source string) (bool, error) {
+		_, _ = fmt.Fprintf(w, "Remove the token for %s from the %s? [y/N] ", host, source)
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return false, fmt.Errorf("reading the answer: %w", err)
+		}
+		answer := strings.ToLower(strings.TrimSpace(line))
+		return answer == "y" || answer == "yes", nil
+	}
+}
@@ -1,11 +1,14 @@
 package cmd
 
 import (
-	"context"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
+	"strings"
 
-	"github.com/google/go-github/v68/github"
-	"github.com/omarkohl/jip/internal/auth"
+	"github.com/omarkohl/jip/internal/forge"
+	gh "github.com/omarkohl/jip/internal/github"
 	"github.com/spf13/cobra"
 )
 
@@ -14,25 +17,187 @@ const defaultHost = "github.com"
 var authStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show current authentication status",
-	RunE:  runAuthStatus,
+	Long: `Status shows who jip is authenticated as, where the token comes from, and
+whether it may do what jip send does: push branches, create PRs and request
+reviewers.
+
+Classic and OAuth tokens report their scopes, so missing ones are named.
+Fine-grained and GitHub App tokens do not report their permissions; for them
+status checks what it can without changing anything — that the token sees
+the repository and its PRs — and lists the permissions jip needs.
+
+The repository is --repo, or the one of the origin remote when run in a jj
+repository. Without one, scopes are checked as for a public repository.`,
+	Args: cobra.NoArgs,
+	RunE: runAuthStatus,
 }
 
 func init() {
 	authCmd.AddCommand(authStatusCmd)
+	authStatusCmd.Flags().String("hostname", defaultHost, "Host to check the token of")
+	authStatusCmd.Flags().String("repo", "", "Repository as owner/name to check the token's access to (default the repository of the origin remote)")
 }
 
 func runAuthStatus(cmd *cobra.Command, args []string) error {
-	token, source := auth.ResolveToken(defaultHost)
-	if token == "" {
-		return fmt.Errorf("not authenticated. Run 'jip auth login' or 'gh auth login' or set GH_TOKEN")
+	host, _ := cmd.Flags().GetString("hostname")
+	repo, _ := cmd.Flags().GetString("repo")
+	if repo == "" {
+		repo = workspaceRepo(host)
+	}
+	var remoteURL string
+	if repo != "" {
+		remoteURL = "https://" + host + "/" + repo
+	}
+	client, source, err := githubClient(host, remoteURL)
+	if err != nil {
+		return err
 	}
 
-	client := github.NewClient(nil).WithAuthToken(token)
-	user, _, err := client.Users.Get(context.Background(), "")
+	info, err := client.TokenInfo()
 	if err != nil {
 		return fmt.Errorf("token invalid: %w", err)
 	}
+	w := cmd.OutOrStdout()
+	_, _ = fmt.Fprintf(w, "Authenticated as %s (via %s)\n", info.Login, source)
+	_, _ = fmt.Fprintf(w, "Token: %s", info.Kind)
+	if info.Expires != "" {
+		_, _ = fmt.Fprintf(w, ", expires %s", info.Expires)
+	}
+	_, _ = fmt.Fprintln(w)
+	if info.Scopes != nil {
+		_, _ = fmt.Fprintf(w, "Scopes: %s\n", scopeList(info.Scopes))
+	}
+
+	var access *repoAccess
+	if repo != "" {
+		access = &repoAccess{name: client.Owner() + "/" + client.Repo()}
+		access.repo, err = client.RepoInfo(client.Owner(), client.Repo())
+		switch {
+		case errors.Is(err, forge.ErrRepoNotAccessible):
+		case err != nil:
+			return err
+		default:
+			if access.canReadPRs, err = client.CanReadPRs(); err != nil {
+				return err
+			}
+		}
+		_, _ = fmt.Fprintf(w, "Repository: %s\n", access.name)
+	}
+
+	_, _ = fmt.Fprintln(w)
+	if !printTokenChecks(tokenChecks(info, access), w) {
+		return fmt.Errorf("the token lacks permissions jip needs")
+	}
+	if info.Scopes == nil {
+		_, _ = fmt.Fprintln(w, "\nGitHub does not report the permissions of this token. jip needs Contents and\nPull requests read and write, and Workflows to push changes to .github/workflows.")
+		if access == nil {
+			_, _ = fmt.Fprintln(w, "Pass --repo to check its access to a repository.")
+		}
+	}
+	return nil
+}
+
+// workspaceRepo returns the owner/name of the origin remote of the current
+// jj workspace when it is on host, or "" outside a workspace.
+func workspaceRepo(host string) string {
+	runner, _, err := workspaceRunner()
+	if err != nil {
+		return ""
+	}
+	remotes, err := gitRemotes(runner)
+	if err != nil {
+		return ""
+	}
+	url := remotes["origin"]
+	if remoteHost, err := gh.ParseHostFromURL(url); err != nil || remoteHost != host {
+		return ""
+	}
+	owner, repo, err := gh.ParseRepoFromURL(url)
+	if err != nil {
+		return ""
+	}
+	return owner + "/" + repo
+}
+
+// repoAccess is what a token may see of a repository: repo is nil when it
+// cannot see the repository at all.
+type repoAccess struct {
+	name       string
+	repo       *forge.RepoInfo
+	canReadPRs bool
+}
 
-	_, err = fmt.Fprintf(cmd.OutOrStdout(), "Authenticated as %s (via %s)\n", user.GetLogin(), source)
-	return err
+// tokenCheck is one thing jip does with a token and whether the token may:
+// level is "ok", "warn" for what only some stacks need, or "FAIL".
+type tokenCheck struct {
+	name, level, detail string
+}
+
+// tokenChecks checks a token against what jip send does, in the repository
+// of access when not nil. Classic and OAuth tokens are judged by their
+// scopes; of fine-grained and GitHub App tokens only the repository access
+// can be checked.
+func tokenChecks(info *gh.TokenInfo, access *repoAccess) []tokenCheck {
+	if access != nil && access.repo == nil {
+		return []tokenCheck{{"access", "FAIL", fmt.Sprintf("cannot see %s — check the name, and that the token was granted access to it", access.name)}}
+	}
+	var checks []tokenCheck
+	if info.Scopes != nil {
+		has := func(scopes ...string) bool {
+			return slices.ContainsFunc(scopes, func(s string) bool { return slices.Contains(info.Scopes, s) })
+		}
+		private := access != nil && access.repo.Private
+		core := tokenCheck{level: "ok"}
+		switch {
+		case has("repo"):
+		case has("public_repo") && private:
+			core = tokenCheck{level: "FAIL", detail: "needs the repo scope for private repositories"}
+		case has("public_repo") && access == nil:
+			core.detail = "public repositories only (the repo scope covers private ones)"
+		case !has("public_repo"):
+			core = tokenCheck{level: "FAIL", detail: "needs the repo or public_repo scope"}
+		}
+		for _, name := range []string{"push branches", "create PRs", "request reviewers"} {
+			core.name = name
+			checks = append(checks, core)
+		}
+		team := tokenCheck{"request team reviewers", "ok", ""}
+		if !has("read:org", "write:org", "admin:org") {
+			team.level, team.detail = "warn", "needs the read:org scope"
+		}
+		workflow := tokenCheck{"push workflow files", "ok", ""}
+		if !has("workflow") {
+			workflow.level, workflow.detail = "warn", "needs the workflow scope to push changes to .github/workflows"
+		}
+		checks = append(checks, team, workflow)
+	}
+	if access != nil {
+		if info.Scopes == nil {
+			read := tokenCheck{"read PRs", "ok", ""}
+			if !access.canReadPRs {
+				read.level, read.detail = "FAIL", "needs the Pull requests permission"
+			}
+			checks = append(checks, read)
+		}
+		if !access.repo.CanPush && info.Kind != gh.TokenApp {
+			checks = append(checks, tokenCheck{"push access", "warn", fmt.Sprintf("you cannot push to %s — fine when pushing to a fork with --upstream", access.name)})
+		}
+	}
+	return checks
+}
+
+// printTokenChecks prints checks and reports whether none failed.
+func printTokenChecks(checks []tokenCheck, w io.Writer) bool {
+	passed := true
+	for _, c := range checks {
+		if c.level == "FAIL" {
+			passed = false
+		}
+		line := fmt.Sprintf("  %-5s %s", c.level, c.name)
+		if c.detail != "" {
+			line += ": " + c.detail
+		}
+		_, _ = fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	return passed
 }
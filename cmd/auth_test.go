@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/auth"
+	"github.com/omarkohl/jip/internal/forge"
+	gh "github.com/omarkohl/jip/internal/github"
+)
+
+func TestExecuteAuthLogout(t *testing.T) {
+	isolateTokens(t)
+	if _, err := auth.SaveToken("github.com", "ghp_stored"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	decline := promptLogout(strings.NewReader("n\n"), &buf)
+	if err := executeAuthLogout("github.com", decline, &buf); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+	if token, _ := auth.StoredToken("github.com"); token != "ghp_stored" {
+		t.Fatalf("declined logout removed the token:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	accept := promptLogout(strings.NewReader("y\n"), &buf)
+	if err := executeAuthLogout("github.com", accept, &buf); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+	if token, _ := auth.StoredToken("github.com"); token != "" {
+		t.Errorf("token still stored after logout")
+	}
+	if !strings.Contains(buf.String(), "Removed the token for github.com from the OS keyring.") {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+
+	// With nothing stored there is nothing to confirm, but a token from the
+	// environment is pointed out.
+	t.Setenv("GH_TOKEN", "ghp_env")
+	buf.Reset()
+	if err := executeAuthLogout("github.com", nil, &buf); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+	for _, want := range []string{"No token stored by jip for github.com.", "still authenticated with github.com via GH_TOKEN"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestAuthToken(t *testing.T) {
+	isolateTokens(t)
+	var buf bytes.Buffer
+	authTokenCmd.SetOut(&buf)
+	t.Cleanup(func() { authTokenCmd.SetOut(nil) })
+
+	if err := runAuthToken(authTokenCmd, nil); err == nil {
+		t.Error("no error without a token")
+	}
+	if _, err := auth.SaveToken("github.com", "ghp_stored"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runAuthToken(authTokenCmd, nil); err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	if buf.String() != "ghp_stored\n" {
+		t.Errorf("output = %q, want only the token", buf.String())
+	}
+}
+
+func TestTokenChecks(t *testing.T) {
+	public := &repoAccess{name: "o/r", repo: &forge.RepoInfo{CanPush: true}, canReadPRs: true}
+	private := &repoAccess{name: "o/r", repo: &forge.RepoInfo{Private: true, CanPush: true}, canReadPRs: true}
+	for _, tt := range []struct {
+		name   string
+		info   gh.TokenInfo
+		access *repoAccess
+		want   []string // "level name" of each check
+	}{
+		{"full classic", gh.TokenInfo{Scopes: []string{"repo", "read:org", "workflow"}}, private,
+			[]string{"ok push branches", "ok create PRs", "ok request reviewers", "ok request team reviewers", "ok push workflow files"}},
+		{"public_repo on a private repository", gh.TokenInfo{Scopes: []string{"public_repo"}}, private,
+			[]string{"FAIL push branches", "FAIL create PRs", "FAIL request reviewers", "warn request team reviewers", "warn push workflow files"}},
+		{"public_repo on a public repository", gh.TokenInfo{Scopes: []string{"public_repo", "admin:org", "workflow"}}, public,
+			[]string{"ok push branches", "ok create PRs", "ok request reviewers", "ok request team reviewers", "ok push workflow files"}},
+		{"no scopes", gh.TokenInfo{Scopes: []string{}}, nil,
+			[]string{"FAIL push branches", "FAIL create PRs", "FAIL request reviewers", "warn request team reviewers", "warn push workflow files"}},
+		{"fine-grained without PR access", gh.TokenInfo{}, &repoAccess{name: "o/r", repo: &forge.RepoInfo{}},
+			[]string{"FAIL read PRs", "warn push access"}},
+		{"fine-grained without a repository", gh.TokenInfo{}, nil, nil},
+		{"repository not visible", gh.TokenInfo{}, &repoAccess{name: "o/r"}, []string{"FAIL access"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range tokenChecks(&tt.info, tt.access) {
+				got = append(got, c.level+" "+c.name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("checks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var authTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Print the token jip authenticates with",
+	Long: `Token prints the token jip would use for a host, found the same way as for
+every other command: GH_TOKEN or GITHUB_TOKEN, the gh CLI's login, then the
+token stored by jip auth login. Only the token is printed, so it can be piped
+to other tools:
+
+  curl -H "Authorization: Bearer $(jip auth token)" https://api.github.com/user`,
+	Args: cobra.NoArgs,
+	RunE: runAuthToken,
+}
+
+func init() {
+	authCmd.AddCommand(authTokenCmd)
+	authTokenCmd.Flags().String("hostname", defaultHost, "Host to print the token of")
+}
+
+func runAuthToken(cmd *cobra.Command, args []string) error {
+	host, _ := cmd.Flags().GetString("hostname")
+	token, _ := hostToken(host)
+	if token == "" {
+		return notAuthenticated(host)
+	}
+	_, err := fmt.Fprintln(cmd.OutOrStdout(), token)
+	return err
+}
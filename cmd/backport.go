@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var backportCmd = &cobra.Command{
+	Use:   "backport <revset> --to <branch>...",
+	Short: "Send copies of changes to other branches, e.g. release branches",
+	Long: `Backport copies the changes of the revset onto each --to branch (using
+jj duplicate) and sends the copies for each branch as a stack of PRs
+targeting that branch, like jip send with the same flags.
+
+The PRs of the copies are titled "[backport 1.2] <title>" for the branch
+release/1.2 (a PR-Title trailer of the copy says so), and link the PR of the
+change they copy. Backporting a change again sends its existing copy again
+instead of making another.
+
+A copy that conflicts with its branch is not sent: resolve the conflict and
+send it with jip send --base <branch>=<copy>.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBackport,
+}
+
+func init() {
+	rootCmd.AddCommand(backportCmd)
+	// Send's flags are added by send.go's init, which runs after this one.
+	backportCmd.Flags().StringArray("to", nil, "Branch to backport to (repeatable)")
+	_ = backportCmd.RegisterFlagCompletionFunc("to", completeJJBookmarks)
+}
+
+func runBackport(cmd *cobra.Command, args []string) error {
+	targets, _ := cmd.Flags().GetStringArray("to")
+	if len(targets) == 0 {
+		return fmt.Errorf("pass the branches to backport to with --to")
+	}
+	for _, name := range []string{"base", "revset-file", "resume"} {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s does not apply to backport", name)
+		}
+	}
+	w := cmd.OutOrStdout()
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		runner, _, err := workspaceRunner()
+		if err != nil {
+			return err
+		}
+		changes, err := backportChanges(runner, args[0])
+		if err != nil {
+			return err
+		}
+		for _, target := range targets {
+			_, _ = fmt.Fprintf(w, "Would copy onto %s and send:\n", target)
+			for i := len(changes) - 1; i >= 0; i-- {
+				_, _ = fmt.Fprintf(w, "  %s  %s\n", changes[i].Short(), backportTitle(target, &changes[i]))
+			}
+		}
+		_, _ = fmt.Fprintln(w, "\nDry run — nothing was copied or sent.")
+		return nil
+	}
+	return sendCommand(cmd, nil, func(runner jj.Runner, client forge.Service, opts *sendOpts) error {
+		return prepareBackport(runner, client, args[0], targets, opts, w)
+	})
+}
+
+// backportChanges returns the changes of revset, newest first. Merges are
+// refused: a copy has one parent.
+func backportChanges(runner jj.Runner, revset string) ([]jj.Change, error) {
+	data, err := runner.Log(revset)
+	if err != nil {
+		return nil, fmt.Errorf("listing the changes of %s: %w", revset, err)
+	}
+	changes, err := jj.ParseChanges(data)
+	if err != nil {
+		return nil, fmt.Errorf("listing the changes of %s: %w", revset, err)
+	}
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("%s contains no changes to backport", revset)
+	}
+	for _, c := range changes {
+		if len(c.ParentIDs) > 1 {
+			return nil, fmt.Errorf("%s is a merge — backport the changes it merges instead", c.Short())
+		}
+	}
+	return changes, nil
+}
+
+// backportTitle returns the PR title of the copy of c on target: the title of
+// c with the backport label, e.g. "[backport 1.2] " for release/1.2.
+func backportTitle(target string, c *jj.Change) string {
+	return backportLabel(target) + c.PRTitle()
+}
+
+// backportLabel returns the prefix of the PR titles of copies on target.
+func backportLabel(target string) string {
+	return fmt.Sprintf("[backport %s] ", path.Base(target))
+}
+
+// prepareBackport copies the changes of revset onto each of targets and
+// sends the copies to their target instead of the revsets of the send. A
+// change copied to a target by an earlier backport is not copied again: its
+// copy is sent once more.
+func prepareBackport(runner jj.Runner, client forge.Service, revset string, targets []string, opts *sendOpts, w io.Writer) error {
+	baseRemote := opts.remote
+	if opts.upstreamRemote != "" {
+		baseRemote = opts.upstreamRemote
+	}
+	if !opts.noFetch {
+		var patterns []string
+		for _, target := range targets {
+			patterns = append(patterns, "exact:"+target)
+		}
+		_, _ = fmt.Fprintf(w, "Fetching %s from %s...\n", strings.Join(targets, ", "), baseRemote)
+		if err := runner.GitFetch(baseRemote, patterns...); err != nil {
+			return fmt.Errorf("fetching %s: %w", baseRemote, err)
+		}
+	}
+	changes, err := backportChanges(runner, revset)
+	if err != nil {
+		return err
+	}
+	if opts.extras == nil {
+		opts.extras = &extraDescriptions{files: make(map[string]string)}
+	}
+	originals := originalPRs(runner, client, opts.state, changes, w)
+	var openPRs []*forge.PRInfo // listed when a copy is missing
+
+	opts.revsets = nil
+	for _, target := range targets {
+		dest := sendBase{branch: target}.revset(baseRemote)
+		if data, err := runner.Log(dest); err != nil || len(data) == 0 {
+			return fmt.Errorf("branch %s is not on %s", target, baseRemote)
+		}
+		existing, err := existingCopies(runner, dest, target)
+		if err != nil {
+			return err
+		}
+		// Oldest first, so each change is copied onto the copy of its parent.
+		copyOf := make(map[string]string, len(changes))
+		var copies []string
+		var conflicted []string
+		for i := len(changes) - 1; i >= 0; i-- {
+			c := &changes[i]
+			title := backportTitle(target, c)
+			dup := existing[title]
+			if dup != nil {
+				_, _ = fmt.Fprintf(w, "%s is already copied onto %s as %s.\n", c.Short(), target, dup.Short())
+			} else {
+				// A backport PR without a copy here was sent from elsewhere;
+				// a second copy would open a second PR.
+				if openPRs == nil {
+					if openPRs, err = client.ListOpenPRs(100); err != nil {
+						return fmt.Errorf("looking up backport PRs: %w", err)
+					}
+				}
+				for _, pr := range openPRs {
+					if pr.Title == title {
+						return fmt.Errorf("%s is already backported to %s in #%d, whose change is not in this repository — fetch its branch %s, or close #%d",
+							c.Short(), target, pr.Number, pr.HeadRefName, pr.Number)
+					}
+				}
+				onto := dest
+				if len(c.ParentIDs) == 1 && copyOf[c.ParentIDs[0]] != "" {
+					onto = copyOf[c.ParentIDs[0]]
+				}
+				if dup, err = duplicateOnto(runner, c, onto); err != nil {
+					return err
+				}
+				desc := jj.WithOverride(jj.StripTrailers(c.Description), "PR-Title", title)
+				if err := runner.Describe(dup.ChangeID, desc); err != nil {
+					return fmt.Errorf("describing the copy of %s: %w", c.Short(), err)
+				}
+			}
+			if n := originals[c.ChangeID]; n != 0 {
+				opts.extras.files[dup.ChangeID] = fmt.Sprintf("Backport of #%d to `%s`.", n, target)
+			}
+			if dup.Conflict {
+				conflicted = append(conflicted, dup.Short())
+			}
+			copyOf[c.ChangeID] = dup.ChangeID
+			copies = append(copies, dup.ChangeID)
+		}
+		_, _ = fmt.Fprintf(w, "Copied %d change(s) onto %s.\n", len(copies), target)
+		if len(conflicted) > 0 {
+			_, _ = fmt.Fprintf(w, "warning: the copies %s conflict with %s and are not sent — resolve the conflicts, then jip send --base %s=<copy>\n",
+				strings.Join(conflicted, ", "), target, target)
+		}
+		opts.otherBases = append(opts.otherBases, sendBase{branch: target, revsets: copies})
+	}
+	return nil
+}
+
+// duplicateOnto copies c onto the revision onto and returns the copy.
+func duplicateOnto(runner jj.Runner, c *jj.Change, onto string) (*jj.Change, error) {
+	children := fmt.Sprintf("children(%s)", onto)
+	data, err := runner.Log(children)
+	if err != nil {
+		return nil, fmt.Errorf("copying %s: %w", c.Short(), err)
+	}
+	before, err := jj.ParseChanges(data)
+	if err != nil {
+		return nil, fmt.Errorf("copying %s: %w", c.Short(), err)
+	}
+	if err := runner.Duplicate([]string{c.CommitID}, onto); err != nil {
+		return nil, fmt.Errorf("copying %s: %w", c.Short(), err)
+	}
+	if data, err = runner.Log(children); err != nil {
+		return nil, fmt.Errorf("copying %s: %w", c.Short(), err)
+	}
+	after, err := jj.ParseChanges(data)
+	if err != nil {
+		return nil, fmt.Errorf("copying %s: %w", c.Short(), err)
+	}
+	existed := make(map[string]bool, len(before))
+	for _, b := range before {
+		existed[b.ChangeID] = true
+	}
+	for i := range after {
+		if !existed[after[i].ChangeID] {
+			return &after[i], nil
+		}
+	}
+	return nil, fmt.Errorf("copying %s: jj duplicate created no change", c.Short())
+}
+
+// existingCopies returns the changes an earlier backport copied onto dest
+// for target, by PR title: those off dest whose PR-Title trailer carries
+// target's backport label.
+func existingCopies(runner jj.Runner, dest, target string) (map[string]*jj.Change, error) {
+	label := backportLabel(target)
+	data, err := runner.Log(fmt.Sprintf("~::%s & description(substring:%s)", dest, strconv.Quote("PR-Title: "+label)))
+	if err != nil {
+		return nil, fmt.Errorf("looking for earlier backports to %s: %w", target, err)
+	}
+	changes, err := jj.ParseChanges(data)
+	if err != nil {
+		return nil, fmt.Errorf("looking for earlier backports to %s: %w", target, err)
+	}
+	copies := make(map[string]*jj.Change, len(changes))
+	for i := range changes {
+		if title := changes[i].PRTitle(); strings.HasPrefix(title, label) {
+			copies[title] = &changes[i]
+		}
+	}
+	return copies, nil
+}
+
+// originalPRs returns the number of the PR of each of changes that has one:
+// the PR jip last sent it as, or else, for changes sent from elsewhere, the
+// open or merged PR of a bookmark on it. Lookup failures are reported on w
+// and leave the changes without a PR.
+func originalPRs(runner jj.Runner, client forge.Service, st *state.State, changes []jj.Change, w io.Writer) map[string]int {
+	prs := make(map[string]int, len(changes))
+	unknown := make(map[string]string) // commit ID → change ID
+	for _, c := range changes {
+		if st != nil {
+			if rec, ok := st.Change(c.ChangeID); ok && rec.PRNumber != 0 {
+				prs[c.ChangeID] = rec.PRNumber
+				continue
+			}
+		}
+		unknown[c.CommitID] = c.ChangeID
+	}
+	if len(unknown) == 0 {
+		return prs
+	}
+
+	data, err := runner.BookmarkList()
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "warning: could not look up the PRs of the backported changes: %v\n", err)
+		return prs
+	}
+	bookmarks, err := jj.ParseBookmarkList(data)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "warning: could not look up the PRs of the backported changes: %v\n", err)
+		return prs
+	}
+	changeOf := make(map[string]string) // branch → change ID
+	var branches []string
+	for _, b := range bookmarks {
+		id, ok := unknown[b.Target]
+		for _, rs := range b.Remotes {
+			if !ok {
+				id, ok = unknown[rs.Target]
+			}
+		}
+		if ok {
+			changeOf[b.Name] = id
+			branches = append(branches, b.Name)
+		}
+	}
+	if len(branches) == 0 {
+		return prs
+	}
+	open, err := client.LookupPRsByBranch(branches)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "warning: could not look up the PRs of the backported changes: %v\n", err)
+		return prs
+	}
+	closed, err := client.LookupClosedPRsByBranch(branches)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "warning: could not look up the PRs of the backported changes: %v\n", err)
+		return prs
+	}
+	for _, branch := range branches {
+		pr := open[branch]
+		if pr == nil {
+			pr = closed[branch]
+		}
+		if id := changeOf[branch]; pr != nil && prs[id] == 0 {
+			prs[id] = pr.Number
+		}
+	}
+	return prs
+}
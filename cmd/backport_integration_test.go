@@ -0,0 +1,121 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_Backport(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	jjRun(t, repoDir, "bookmark", "set", "release/1.2", "-r", "main")
+	jjRun(t, repoDir, "git", "push", "--bookmark", "release/1.2")
+
+	// A fix of two changes, sent to main first.
+	writeAndCommit(t, repoDir, "a.go", "package a", "fix: guard A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "fix: guard B")
+	st, err := state.Load(state.Path(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, state: st}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	original, _ := st.Change(getChangeID(t, repoDir, "@-"))
+
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, state: st}
+	buf.Reset()
+	if err := prepareBackport(runner, mock, "main..@-", []string{"release/1.2"}, &opts, &buf); err != nil {
+		t.Fatalf("backport failed: %v\n%s", err, buf.String())
+	}
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send of the backport failed: %v\n%s", err, buf.String())
+	}
+	t.Logf("Output:\n%s", buf.String())
+
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 4 {
+		t.Fatalf("got %d PRs, want 2 and their 2 backports", len(mock.PRs))
+	}
+	var backports int
+	for _, pr := range mock.PRs {
+		if !strings.HasPrefix(pr.Title, "[backport 1.2] ") {
+			continue
+		}
+		backports++
+		if pr.BaseRefName != "release/1.2" && !strings.HasPrefix(pr.BaseRefName, "jip/") {
+			t.Errorf("#%d targets %s", pr.Number, pr.BaseRefName)
+		}
+		if pr.Title == "[backport 1.2] fix: guard B" && !strings.Contains(pr.Body, fmt.Sprintf("Backport of #%d", original.PRNumber)) {
+			t.Errorf("#%d body lacks the link to #%d:\n%s", pr.Number, original.PRNumber, pr.Body)
+		}
+	}
+	if backports != 2 {
+		t.Errorf("got %d backport PRs, want 2", backports)
+	}
+}
+
+func TestIntegration_BackportAgain(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	jjRun(t, repoDir, "bookmark", "set", "release/1.2", "-r", "main")
+	jjRun(t, repoDir, "git", "push", "--bookmark", "release/1.2")
+	writeAndCommit(t, repoDir, "a.go", "package a", "fix: guard A")
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+
+	// Without local state, both runs find the original PR on the forge and
+	// the second reuses the copy of the first.
+	for run := 1; run <= 2; run++ {
+		opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}
+		buf.Reset()
+		if err := prepareBackport(runner, mock, "main..@-", []string{"release/1.2"}, &opts, &buf); err != nil {
+			t.Fatalf("backport %d failed: %v\n%s", run, err, buf.String())
+		}
+		if err := executeSend(runner, mock, opts, &buf); err != nil {
+			t.Fatalf("send of backport %d failed: %v\n%s", run, err, buf.String())
+		}
+	}
+	t.Logf("Output:\n%s", buf.String())
+	if !strings.Contains(buf.String(), "is already copied onto release/1.2") {
+		t.Errorf("second backport did not reuse the copy:\n%s", buf.String())
+	}
+	data, err := runner.Log(`description(substring:"PR-Title: [backport 1.2] ")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copies, _ := jj.ParseChanges(data); len(copies) != 1 {
+		t.Errorf("got %d copies, want 1", len(copies))
+	}
+
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 2 {
+		t.Fatalf("got %d PRs, want the original and its backport", len(mock.PRs))
+	}
+	for _, pr := range mock.PRs {
+		if strings.HasPrefix(pr.Title, "[backport 1.2] ") && !strings.Contains(pr.Body, "Backport of #1") {
+			t.Errorf("#%d body lacks the link to #1:\n%s", pr.Number, pr.Body)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestBackportTitle(t *testing.T) {
+	tests := []struct {
+		target string
+		desc   string
+		want   string
+	}{
+		{"release/1.2", "fix: crash on empty input", "[backport 1.2] fix: crash on empty input"},
+		{"stable", "fix: crash on empty input", "[backport stable] fix: crash on empty input"},
+		{"release/1.2", "fix: crash\n\nBody.\n\nPR-Title: Fix the crash", "[backport 1.2] Fix the crash"},
+	}
+	for _, tt := range tests {
+		c := &jj.Change{Description: tt.desc}
+		if got := backportTitle(tt.target, c); got != tt.want {
+			t.Errorf("backportTitle(%q, %q) = %q, want %q", tt.target, tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestBackportTakesSendFlags(t *testing.T) {
+	for _, name := range []string{"draft", "reviewer", "remote", "upstream", "to"} {
+		if backportCmd.Flags().Lookup(name) == nil {
+			t.Errorf("backport has no --%s flag", name)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+const (
+	// maxBlameReviewers caps the reviewers --assignees-from-blame adds to
+	// a PR.
+	maxBlameReviewers = 2
+
+	// maxBlameCommits caps the GitHub lookups per change: only the commits
+	// owning the most touched lines are considered.
+	maxBlameCommits = 10
+)
+
+// suggestBlameReviewers returns the blame-based reviewers for a new PR,
+// leaving out the PR author and the reviewers already requested. self caches
+// the authenticated login across PRs.
+func suggestBlameReviewers(runner jj.Runner, client forge.Service, change *jj.Change, self *string, requested []string) ([]string, error) {
+	if err := cacheLogin(client, self); err != nil {
+		return nil, err
+	}
+	return blameReviewers(runner, client, change, append([]string{*self}, requested...))
+}
+
+// cacheLogin looks up the authenticated login into self, unless it is
+// already known.
+func cacheLogin(client forge.Service, self *string) error {
+	if *self != "" {
+		return nil
+	}
+	login, err := client.GetAuthenticatedUser()
+	if err != nil {
+		return err
+	}
+	*self = login
+	return nil
+}
+
+// blameReviewers suggests reviewers for change: the most recent GitHub
+// authors of the lines it touches, without the logins in exclude (compared
+// case-insensitively).
+func blameReviewers(runner jj.Runner, client forge.Service, change *jj.Change, exclude []string) ([]string, error) {
+	counts, err := jj.BlameCommits(runner, change)
+	if err != nil {
+		return nil, err
+	}
+	commits := slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), strings.Compare(a, b))
+	})
+	if len(commits) > maxBlameCommits {
+		commits = commits[:maxBlameCommits]
+	}
+	if len(commits) == 0 {
+		return nil, nil
+	}
+	authors, err := client.CommitAuthors(commits)
+	if err != nil {
+		return nil, err
+	}
+	return rankBlameAuthors(authors, exclude), nil
+}
+
+// rankBlameAuthors returns up to maxBlameReviewers distinct logins, most
+// recent author first. Commits without a linked GitHub account are ignored.
+func rankBlameAuthors(authors map[string]forge.CommitAuthor, exclude []string) []string {
+	list := slices.Collect(maps.Values(authors))
+	slices.SortFunc(list, func(a, b forge.CommitAuthor) int {
+		return cmp.Or(b.Date.Compare(a.Date), strings.Compare(a.Login, b.Login))
+	})
+	var logins []string
+	for _, a := range list {
+		if a.Login == "" || len(logins) == maxBlameReviewers {
+			continue
+		}
+		skip := slices.ContainsFunc(exclude, func(e string) bool { return strings.EqualFold(e, a.Login) }) ||
+			slices.ContainsFunc(logins, func(l string) bool { return strings.EqualFold(l, a.Login) })
+		if !skip {
+			logins = append(logins, a.Login)
+		}
+	}
+	return logins
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/omarkohl/jip/internal/forge"
+)
+
+func TestRankBlameAuthors(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	authors := map[string]forge.CommitAuthor{
+		"c1": {Login: "alice", Date: day(1)},
+		"c2": {Login: "bob", Date: day(5)},
+		"c3": {Login: "Carol", Date: day(9)},
+		"c4": {Login: "", Date: day(10)}, // not linked to an account
+		"c5": {Login: "bob", Date: day(7)},
+		"c6": {Login: "me", Date: day(12)},
+	}
+	got := rankBlameAuthors(authors, []string{"ME"})
+	if want := []string{"Carol", "bob"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rankBlameAuthors = %v, want %v", got, want)
+	}
+
+	got = rankBlameAuthors(authors, []string{"me", "carol"})
+	if want := []string{"bob", "alice"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with carol excluded = %v, want %v", got, want)
+	}
+}
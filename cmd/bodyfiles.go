@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gh "github.com/omarkohl/jip/internal/github"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// bodyDir holds hand-written PR descriptions, relative to the repository
+// root: .jip/body/<change-id>.md, where the change ID may be shortened to any
+// prefix, e.g. the short ID jj shows.
+const bodyDir = ".jip/body"
+
+// extraDescriptions are the hand-written descriptions send appends below the
+// PR bodies it generates.
+type extraDescriptions struct {
+	files    map[string]string // contents of the files in bodyDir by change ID prefix
+	bodyFile *string           // --body-file for the top PR of each stack; nil for none
+}
+
+// loadExtraDescriptions reads the descriptions in dir (which need not exist)
+// and the --body-file bodyFile ("" for none, - for stdin).
+func loadExtraDescriptions(dir, bodyFile string, stdin io.Reader) (*extraDescriptions, error) {
+	x := &extraDescriptions{files: make(map[string]string)}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+	var prefixes []string
+	for _, e := range entries {
+		prefix, ok := strings.CutSuffix(e.Name(), ".md")
+		if !ok || prefix == "" || e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading PR description: %w", err)
+		}
+		x.files[prefix] = string(data)
+		prefixes = append(prefixes, prefix)
+	}
+	// Two files match the same change exactly when one name is a prefix of
+	// the other; sorted, such names are neighbours.
+	sort.Strings(prefixes)
+	for i := 1; i < len(prefixes); i++ {
+		if strings.HasPrefix(prefixes[i], prefixes[i-1]) {
+			return nil, fmt.Errorf("%s: %s.md and %s.md may describe the same change — use longer change ID prefixes", dir, prefixes[i-1], prefixes[i])
+		}
+	}
+
+	if bodyFile != "" {
+		var data []byte
+		if bodyFile == "-" {
+			data, err = io.ReadAll(stdin)
+		} else {
+			data, err = os.ReadFile(bodyFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading --body-file: %w", err)
+		}
+		body := string(data)
+		x.bodyFile = &body
+	}
+	return x, nil
+}
+
+// section returns the hand-written description for the PR of c, whose
+// current body is prBody: --body-file when top (the PR is the top of its
+// stack), else the file of c in bodyDir. Without either the PR keeps the
+// section it has; an empty file removes it.
+func (x *extraDescriptions) section(c *jj.Change, top bool, prBody string) string {
+	if x != nil {
+		if top && x.bodyFile != nil {
+			return *x.bodyFile
+		}
+		for prefix, body := range x.files {
+			if strings.HasPrefix(c.ChangeID, prefix) {
+				return body
+			}
+		}
+	}
+	return gh.ParseExtraDescription(prBody)
+}
+
+// stackTops returns the changes of states that no other change of states
+// builds on, by change ID.
+func stackTops(states []changeState) map[string]bool {
+	below := make(map[string]bool)
+	for _, s := range states {
+		for _, pid := range s.change.ParentIDs {
+			below[pid] = true
+		}
+	}
+	tops := make(map[string]bool)
+	for _, s := range states {
+		if !below[s.change.ChangeID] {
+			tops[s.change.ChangeID] = true
+		}
+	}
+	return tops
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gh "github.com/omarkohl/jip/internal/github"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestExtraDescriptions(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "body")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"kxqpmnzv.md": "Screenshots of kx.\n",
+		"yvwt.md":     "",
+		"notes.txt":   "not a description",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	x, err := loadExtraDescriptions(dir, "-", strings.NewReader("- [ ] manual test\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing := gh.WithExtraDescription("Generated.", "Kept.")
+	kx := &jj.Change{ChangeID: "kxqpmnzvlrstuwyz"}
+	yv := &jj.Change{ChangeID: "yvwtsrqp"}
+	other := &jj.Change{ChangeID: "zzzzzzzz"}
+	for _, tc := range []struct {
+		name   string
+		change *jj.Change
+		top    bool
+		want   string
+	}{
+		{"file of the change", kx, false, "Screenshots of kx.\n"},
+		{"body-file wins at the top", kx, true, "- [ ] manual test\n"},
+		{"empty file removes the section", yv, false, ""},
+		{"no file keeps the section", other, false, "Kept."},
+	} {
+		if got := x.section(tc.change, tc.top, existing); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+	var none *extraDescriptions
+	if got := none.section(kx, true, existing); got != "Kept." {
+		t.Errorf("without descriptions got %q, want the PR's own section", got)
+	}
+}
+
+func TestLoadExtraDescriptions_MissingDir(t *testing.T) {
+	x, err := loadExtraDescriptions(filepath.Join(t.TempDir(), "none"), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := x.section(&jj.Change{ChangeID: "kxqpmnzv"}, true, ""); got != "" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestLoadExtraDescriptions_AmbiguousPrefixes(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"kx.md", "kxqp.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := loadExtraDescriptions(dir, "", nil); err == nil || !strings.Contains(err.Error(), "kx.md and kxqp.md") {
+		t.Errorf("err = %v, want the ambiguous files named", err)
+	}
+}
+
+func TestStackTops(t *testing.T) {
+	states := []changeState{
+		{change: &jj.Change{ChangeID: "a"}},
+		{change: &jj.Change{ChangeID: "b", ParentIDs: []string{"a"}}},
+		{change: &jj.Change{ChangeID: "c", ParentIDs: []string{"a"}}},
+	}
+	tops := stackTops(states)
+	if tops["a"] || !tops["b"] || !tops["c"] {
+		t.Errorf("tops = %v, want b and c", tops)
+	}
+}
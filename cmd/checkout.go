@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	gh "github.com/omarkohl/jip/internal/github"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var checkoutCmd = &cobra.Command{
+	Use:     "checkout <pr-number>",
+	Aliases: []string{"co"},
+	Short:   "Check out a PR and the stack below it for review",
+	Long: `Checkout fetches the branch of a pull request and the branches of the open
+PRs it depends on, tracks them as bookmarks, and starts a new change on top
+of the PR (using jj new), so a reviewer can build, test and browse the stack
+locally.
+
+The PRs below are found in the stack list jip writes into PR bodies and by
+following each PR's base branch, so stacks sent without jip or retargeted on
+the forge are found too. Merged PRs are skipped.
+
+The branches are fetched from the upstream remote when --upstream names one,
+from the push remote otherwise. PRs from forks cannot be checked out: their
+branches are not on either remote.`,
+	Args:              cobra.ExactArgs(1),
+	RunE:              runCheckout,
+	ValidArgsFunction: completePRNumbers(false),
+}
+
+func init() {
+	rootCmd.AddCommand(checkoutCmd)
+	checkoutCmd.Flags().String("remote", "origin", "Remote to fetch the branches from")
+	checkoutCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (where PRs are opened)")
+	addNoLockFlag(checkoutCmd)
+
+	_ = checkoutCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = checkoutCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+}
+
+// checkoutOpts holds configuration for checkout.
+type checkoutOpts struct {
+	remote string // where the PR branches are fetched from
+	number int
+}
+
+func runCheckout(cmd *cobra.Command, args []string) error {
+	number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil || number <= 0 {
+		return fmt.Errorf("invalid PR number %q", args[0])
+	}
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+		return err
+	}
+
+	remote, _ := cmd.Flags().GetString("remote")
+	upstream, _ := cmd.Flags().GetString("upstream")
+	w := cmd.OutOrStdout()
+
+	rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], w)
+	if err != nil {
+		return err
+	}
+	if rc.upstreamRemote != "" {
+		remote = rc.upstreamRemote
+	}
+	release, err := lockRepo(cmd, repoRoot, w)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return executeCheckout(runner, rc.client, checkoutOpts{remote: remote, number: number}, w)
+}
+
+func executeCheckout(runner jj.Runner, client forge.Service, opts checkoutOpts, w io.Writer) error {
+	pr, err := client.GetPR(opts.number)
+	if err != nil {
+		return err
+	}
+	if pr.State != "OPEN" {
+		return fmt.Errorf("PR #%d is %s — only open PRs can be checked out", pr.Number, strings.ToLower(pr.State))
+	}
+	stack, err := reviewStack(client, pr)
+	if err != nil {
+		return err
+	}
+
+	branches := make([]string, len(stack))
+	scope := make([]string, len(stack))
+	for i, p := range stack {
+		branches[i] = p.HeadRefName
+		scope[i] = "exact:" + p.HeadRefName
+	}
+	_, _ = fmt.Fprintf(w, "Fetching %d branch(es) from %s...\n", len(branches), opts.remote)
+	if err := runner.GitFetch(opts.remote, scope...); err != nil {
+		return fmt.Errorf("fetching %s: %w", opts.remote, err)
+	}
+
+	data, err := runner.BookmarkList()
+	if err != nil {
+		return fmt.Errorf("listing bookmarks: %w", err)
+	}
+	bookmarks, err := jj.ParseBookmarkList(data)
+	if err != nil {
+		return fmt.Errorf("parsing bookmarks: %w", err)
+	}
+	remoteState := make(map[string]jj.RemoteBookmarkState)
+	for _, b := range bookmarks {
+		if rs, ok := b.Remotes[opts.remote]; ok {
+			remoteState[b.Name] = rs
+		}
+	}
+	var untracked []string
+	for i, branch := range branches {
+		rs, ok := remoteState[branch]
+		if !ok {
+			return fmt.Errorf("branch %s of PR #%d is not on %s — PRs from forks cannot be checked out", branch, stack[i].Number, opts.remote)
+		}
+		if !rs.Tracked {
+			untracked = append(untracked, branch)
+		}
+	}
+	if len(untracked) > 0 {
+		if err := runner.BookmarkTrack(untracked, opts.remote); err != nil {
+			return err
+		}
+	}
+
+	// Start from what the forge has, even if a local bookmark of the same
+	// name has moved.
+	top := stack[len(stack)-1]
+	if err := runner.New(remoteState[top.HeadRefName].Target); err != nil {
+		return fmt.Errorf("checking out #%d: %w", top.Number, err)
+	}
+
+	_, _ = fmt.Fprintf(w, "\nChecked out #%d and %d PR(s) below it, bottom first:\n\n", top.Number, len(stack)-1)
+	for _, p := range stack {
+		_, _ = fmt.Fprintf(w, "  #%-4d %s  (%s)\n", p.Number, p.Title, p.HeadRefName)
+	}
+	_, _ = fmt.Fprintf(w, "\nThe working copy is a new change on top of #%d.\n", top.Number)
+	return nil
+}
+
+// reviewStack returns the open PRs of the stack ending in pr, bottom first:
+// pr, the PR whose head branch is its base branch, that PR's own, and so on,
+// falling back to the PRs listed below pr in its stack block where the base
+// branches no longer chain (e.g. after retargeting onto trunk).
+func reviewStack(client forge.Service, pr *forge.PRInfo) ([]*forge.PRInfo, error) {
+	var listed []int
+	if block := gh.ParseStackBlock(pr.Body); block != nil && block.Current == pr.Number {
+		listed = block.PRs[:slices.Index(block.PRs, block.Current)]
+	}
+	stack := []*forge.PRInfo{pr}
+	seen := map[int]bool{pr.Number: true}
+	for top := pr; ; {
+		next, err := prBelow(client, top, listed, seen)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			break
+		}
+		stack = append(stack, next)
+		top = next
+	}
+	slices.Reverse(stack)
+	return stack, nil
+}
+
+// prBelow returns the open PR directly below pr, or nil at the bottom of the
+// stack: the PR of pr's base branch, or else the nearest PR of listed (bottom
+// first) not yet seen. Every PR it looks at is marked in seen.
+func prBelow(client forge.Service, pr *forge.PRInfo, listed []int, seen map[int]bool) (*forge.PRInfo, error) {
+	open, err := client.LookupPRsByBranch([]string{pr.BaseRefName})
+	if err != nil {
+		return nil, fmt.Errorf("looking up the PR of %s: %w", pr.BaseRefName, err)
+	}
+	if p := open[pr.BaseRefName]; p != nil && !seen[p.Number] {
+		seen[p.Number] = true
+		return p, nil
+	}
+	for i := len(listed) - 1; i >= 0; i-- {
+		n := listed[i]
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		p, err := client.GetPR(n)
+		if err != nil {
+			return nil, err
+		}
+		if p.State == "OPEN" {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
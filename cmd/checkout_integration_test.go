@@ -0,0 +1,72 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_Checkout(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, remoteDir := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+	topCommit := getCommitID(t, repoDir, "@-")
+
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	topPR := 0
+	mock.Lock()
+	for n := range mock.PRs {
+		topPR = max(topPR, n)
+	}
+	mock.Unlock()
+
+	// A reviewer clones the repository and checks out the top PR.
+	reviewDir := filepath.Join(t.TempDir(), "review")
+	if out, err := exec.Command("jj", "git", "clone", remoteDir, reviewDir).CombinedOutput(); err != nil {
+		t.Fatalf("jj git clone: %v\n%s", err, out)
+	}
+	jjRun(t, reviewDir, "config", "set", "--repo", "user.email", "reviewer@jip.dev")
+	jjRun(t, reviewDir, "config", "set", "--repo", "user.name", "Reviewer")
+	reviewRunner := jj.NewRunner(reviewDir)
+
+	buf.Reset()
+	if err := executeCheckout(reviewRunner, mock, checkoutOpts{remote: "origin", number: topPR}, &buf); err != nil {
+		t.Fatalf("checkout failed: %v\n%s", err, buf.String())
+	}
+	t.Logf("Output:\n%s", buf.String())
+
+	if got := getCommitID(t, reviewDir, "@-"); got != topCommit {
+		t.Errorf("working copy parent = %s, want the top of the stack %s", got, topCommit)
+	}
+	data, err := reviewRunner.BookmarkList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bookmarks, err := jj.ParseBookmarkList(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracked := 0
+	for _, b := range bookmarks {
+		if rs, ok := b.Remotes["origin"]; ok && rs.Tracked && b.Present && b.Name != "main" {
+			tracked++
+		}
+	}
+	if tracked != 2 {
+		t.Errorf("got %d tracked stack bookmarks, want 2: %+v", tracked, bookmarks)
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	gh "github.com/omarkohl/jip/internal/github"
+)
+
+func TestReviewStack(t *testing.T) {
+	fake := forgetest.New("owner", "repo")
+	// #2 was retargeted onto main, so only #3's stack block still lists #1
+	// below it.
+	fake.PRs[1] = &forge.PRInfo{Number: 1, State: "OPEN", HeadRefName: "a", BaseRefName: "main"}
+	fake.PRs[2] = &forge.PRInfo{Number: 2, State: "OPEN", HeadRefName: "b", BaseRefName: "main"}
+	fake.PRs[3] = &forge.PRInfo{Number: 3, State: "OPEN", HeadRefName: "c", BaseRefName: "b",
+		Body: gh.BuildStackBlock([]int{1, 2, 3}, 3, gh.StackFormat{})}
+
+	stackNumbers := func() []int {
+		stack, err := reviewStack(fake, fake.PRs[3])
+		if err != nil {
+			t.Fatalf("reviewStack: %v", err)
+		}
+		var nums []int
+		for _, p := range stack {
+			nums = append(nums, p.Number)
+		}
+		return nums
+	}
+	if got := stackNumbers(); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("stack = %v, want [1 2 3]", got)
+	}
+
+	fake.PRs[1].State = "MERGED"
+	if got := stackNumbers(); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Errorf("with #1 merged, stack = %v, want [2 3]", got)
+	}
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/forge"
+)
+
+// lookupChecks returns the CI status of each PR's head commit, keyed by PR
+// number. A failed lookup only costs the display, so it warns and returns
+// nil instead of failing the command.
+func lookupChecks(client forge.Service, numbers []int, w io.Writer) map[int]string {
+	if len(numbers) == 0 {
+		return nil
+	}
+	checks, err := client.PRChecks(numbers)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "warning: could not query CI checks: %v\n", err)
+		return nil
+	}
+	return checks
+}
+
+// checksLabel renders a PR's CI status for display.
+func checksLabel(state string) string {
+	if state == "" {
+		return "none"
+	}
+	return state
+}
+
+// lookupReviews returns the review feedback awaiting the author on each PR,
+// keyed by PR number. Like lookupChecks, a failed lookup only warns.
+func lookupReviews(client forge.Service, numbers []int, w io.Writer) map[int]forge.ReviewSummary {
+	if len(numbers) == 0 {
+		return nil
+	}
+	reviews, err := client.PRReviews(numbers)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "warning: could not query reviews: %v\n", err)
+		return nil
+	}
+	return reviews
+}
+
+// reviewsLabel renders the review feedback awaiting the author, or "" when
+// there is none.
+func reviewsLabel(s forge.ReviewSummary) string {
+	var parts []string
+	if s.UnresolvedThreads > 0 {
+		parts = append(parts, fmt.Sprintf("%d unresolved thread(s)", s.UnresolvedThreads))
+	}
+	if len(s.ChangesRequested) > 0 {
+		parts = append(parts, "changes requested by "+strings.Join(s.ChangesRequested, ", "))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"io"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+)
+
+func TestLookupChecks(t *testing.T) {
+	fake := forgetest.New("owner", "repo")
+	fake.Checks[1] = forge.ChecksPassing
+	fake.Checks[2] = forge.ChecksFailing
+
+	checks := lookupChecks(fake, []int{1, 2, 3}, io.Discard)
+	if checks[1] != forge.ChecksPassing || checks[2] != forge.ChecksFailing {
+		t.Errorf("checks = %v", checks)
+	}
+	if got := checksLabel(checks[3]); got != "none" {
+		t.Errorf("PR without checks labelled %q, want none", got)
+	}
+	if checks := lookupChecks(fake, nil, io.Discard); checks != nil {
+		t.Errorf("no PRs should not query checks, got %v", checks)
+	}
+}
+
+func TestReviewsLabel(t *testing.T) {
+	tests := []struct {
+		s    forge.ReviewSummary
+		want string
+	}{
+		{forge.ReviewSummary{}, ""},
+		{forge.ReviewSummary{UnresolvedThreads: 3}, "3 unresolved thread(s)"},
+		{forge.ReviewSummary{ChangesRequested: []string{"bob", "carol"}}, "changes requested by bob, carol"},
+		{forge.ReviewSummary{UnresolvedThreads: 1, ChangesRequested: []string{"bob"}}, "1 unresolved thread(s), changes requested by bob"},
+	}
+	for _, tt := range tests {
+		if got := reviewsLabel(tt.s); got != tt.want {
+			t.Errorf("reviewsLabel(%+v) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Exit codes of send --ci. Without --ci, every error exits with 1.
+const (
+	exitFailure = 1 // send failed and may not have sent anything
+	exitSkipped = 2 // send finished but skipped changes it should have sent
+)
+
+// skippedError is returned by send when it skipped changes for reasons that
+// need fixing (see skipReason.benign), after sending the rest.
+type skippedError struct {
+	n           int
+	nothingSent bool // every change was skipped
+}
+
+func (e *skippedError) Error() string {
+	if e.nothingSent {
+		return fmt.Sprintf("%d change(s) skipped — nothing to send", e.n)
+	}
+	return fmt.Sprintf("%d change(s) skipped", e.n)
+}
+
+// ciExitError gives an error of send the exit code --ci uses for it.
+func ciExitError(err error) error {
+	if err == nil {
+		return nil
+	}
+	code := exitFailure
+	var skipped *skippedError
+	if errors.As(err, &skipped) {
+		code = exitSkipped
+	}
+	return &ExitError{Err: err, Code: code}
+}
+
+// writeAnnotations writes the report as GitHub Actions workflow commands,
+// which the runner shows as annotations on the workflow run: a notice per
+// PR sent and per expected skip, a warning per large PR, and an error per
+// skip that needs fixing and for the error send failed with.
+func writeAnnotations(w io.Writer, r *sendReport) {
+	for _, p := range r.prs {
+		pr := p.changeID
+		if p.number != 0 {
+			pr = fmt.Sprintf("#%d", p.number)
+		}
+		message := p.title
+		if p.url != "" {
+			message += " — " + p.url
+		}
+		writeWorkflowCommand(w, "notice", fmt.Sprintf("%s %s", p.action, pr), message)
+		if p.large {
+			writeWorkflowCommand(w, "warning", "Large PR "+pr,
+				fmt.Sprintf("%s changes %d lines — consider splitting it into smaller changes", pr, p.stat.Lines()))
+		}
+	}
+	for _, s := range r.skipped {
+		level := "notice"
+		if !s.benign {
+			level = "error"
+		}
+		writeWorkflowCommand(w, level, "Skipped "+s.changeID, s.title+" — "+s.reason)
+	}
+	// A skip error only sums up the skips annotated above.
+	var skipped *skippedError
+	if r.err != nil && !errors.As(r.err, &skipped) {
+		writeWorkflowCommand(w, "error", "jip send failed", r.err.Error())
+	}
+}
+
+// writeWorkflowCommand writes a GitHub Actions workflow command such as
+// "::error title=...::message", escaping title and message.
+func writeWorkflowCommand(w io.Writer, command, title, message string) {
+	_, _ = fmt.Fprintf(w, "::%s title=%s::%s\n", command, escapeWorkflowProperty(title), escapeWorkflowData(message))
+}
+
+// escapeWorkflowData escapes the message of a workflow command, which ends
+// at the end of the line.
+func escapeWorkflowData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeWorkflowProperty escapes a property value of a workflow command,
+// which also ends at a comma or colon.
+func escapeWorkflowProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestWriteAnnotations(t *testing.T) {
+	r := &sendReport{
+		repo: "acme/widgets",
+		prs: []reportPR{
+			{number: 12, url: "https://github.com/acme/widgets/pull/12", action: "created", changeID: "abcdef", title: "feat: a"},
+			{number: 13, url: "https://github.com/acme/widgets/pull/13", action: "updated", changeID: "bcdefa", title: "feat: big",
+				stat: &jj.DiffStat{Files: 2, Additions: 900}, large: true},
+		},
+		skipped: []reportSkip{
+			{changeID: "zzzz", title: "wip", reason: "private (matches git.private-commits)", benign: true},
+			{changeID: "yyyy", title: "fix: c", reason: "change has conflicts — resolve before sending"},
+		},
+		err: &skippedError{n: 1},
+	}
+	var buf bytes.Buffer
+	writeAnnotations(&buf, r)
+	want := "::notice title=created #12::feat: a — https://github.com/acme/widgets/pull/12\n" +
+		"::notice title=updated #13::feat: big — https://github.com/acme/widgets/pull/13\n" +
+		"::warning title=Large PR #13::#13 changes 900 lines — consider splitting it into smaller changes\n" +
+		"::notice title=Skipped zzzz::wip — private (matches git.private-commits)\n" +
+		"::error title=Skipped yyyy::fix: c — change has conflicts — resolve before sending\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	writeAnnotations(&buf, &sendReport{err: errors.New("pushing: 100% broken\nretry later")})
+	if got, want := buf.String(), "::error title=jip send failed::pushing: 100%25 broken%0Aretry later\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEscapeWorkflowProperty(t *testing.T) {
+	if got, want := escapeWorkflowProperty("a: b, c"), "a%3A b%2C c"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCIExitError(t *testing.T) {
+	if err := ciExitError(nil); err != nil {
+		t.Errorf("ciExitError(nil) = %v", err)
+	}
+	skipped := fmt.Errorf("wrapped: %w", &skippedError{n: 2, nothingSent: true})
+	if code := ExitCode(ciExitError(skipped)); code != exitSkipped {
+		t.Errorf("skipped changes exit with %d, want %d", code, exitSkipped)
+	}
+	if code := ExitCode(ciExitError(errors.New("push rejected"))); code != exitFailure {
+		t.Errorf("failure exits with %d, want %d", code, exitFailure)
+	}
+	if !strings.Contains(ciExitError(skipped).Error(), "2 change(s) skipped — nothing to send") {
+		t.Errorf("unexpected message %q", ciExitError(skipped))
+	}
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// Modes of the --cleanup flag.
+const (
+	cleanupOff = "off" // leave the branches alone, without looking them up
+	cleanupAsk = "ask" // ask before deleting, or only report them without a terminal
+	cleanupYes = "yes" // delete them without asking
+)
+
+// resolveCleanup validates the --cleanup mode. A config file's cleanup = true
+// deletes without asking, and false turns cleanup off.
+func resolveCleanup(mode string) (string, error) {
+	switch mode {
+	case "", "false":
+		return cleanupOff, nil
+	case "true":
+		return cleanupYes, nil
+	case cleanupOff, cleanupAsk, cleanupYes:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid --cleanup value %q (valid: %s, %s, %s)", mode, cleanupYes, cleanupAsk, cleanupOff)
+}
+
+// staleBranch is a jip branch on the push remote whose PR is merged or
+// closed.
+type staleBranch struct {
+	bookmark jj.BookmarkInfo
+	pr       *forge.PRInfo
+}
+
+// findStaleBranches returns the jip branches (as named by namer) on remote
+// whose most recent PR is merged or closed, sorted by name. Branches pointing
+// at a change of dags are being sent and never stale.
+func findStaleBranches(runner jj.Runner, client forge.Service, dags []*jj.ChangeDAG, remote string, namer *jj.BookmarkNamer) ([]staleBranch, error) {
+	data, err := runner.BookmarkList()
+	if err != nil {
+		return nil, fmt.Errorf("listing bookmarks: %w", err)
+	}
+	bookmarks, err := jj.ParseBookmarkList(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing bookmarks: %w", err)
+	}
+	sending := make(map[string]bool)
+	for _, dag := range dags {
+		for _, c := range dag.Changes {
+			sending[c.CommitID] = true
+		}
+	}
+
+	byName := make(map[string]jj.BookmarkInfo)
+	var names []string
+	for _, b := range bookmarks {
+		rs, ok := b.Remotes[remote]
+		// Only tracked branches can be deleted by pushing the bookmark, and
+		// one moved locally since its last push holds new work.
+		if !namer.Owns(b.Name) || !ok || !rs.Tracked || b.Conflict || sending[b.Target] ||
+			(b.Present && b.Target != rs.Target) {
+			continue
+		}
+		byName[b.Name] = b
+		names = append(names, b.Name)
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	prs, err := client.LookupClosedPRsByBranch(names)
+	if err != nil {
+		return nil, fmt.Errorf("looking up PRs of jip branches: %w", err)
+	}
+	var stale []staleBranch
+	for _, name := range slices.Sorted(maps.Keys(prs)) {
+		stale = append(stale, staleBranch{bookmark: byName[name], pr: prs[name]})
+	}
+	return stale, nil
+}
+
+// cleanupStaleBranches finds the jip branches of merged or closed PRs on
+// opts.remote and deletes them, remotely and locally, with --cleanup=yes or
+// when the user confirms --cleanup=ask. Otherwise it only says how many there
+// are. Cleanup is housekeeping, so failures are warnings. It reports whether
+// it set out to delete bookmarks.
+func cleanupStaleBranches(runner jj.Runner, client forge.Service, dags []*jj.ChangeDAG, opts sendOpts, w io.Writer) bool {
+	stale, err := findStaleBranches(runner, client, dags, opts.remote, opts.namer)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "warning: could not check for stale jip branches: %v\n", err)
+		return false
+	}
+	if len(stale) == 0 {
+		return false
+	}
+	switch {
+	case opts.dryRun:
+		_, _ = fmt.Fprintf(w, "Would delete %d jip branch(es) of merged or closed PRs from %s:\n", len(stale), opts.remote)
+		printStaleBranches(stale, w)
+		return false
+	case opts.cleanup == cleanupYes:
+	case opts.confirmCleanup != nil:
+		ok, err := opts.confirmCleanup(stale)
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "warning: %v\n", err)
+			return false
+		}
+		if !ok {
+			return false
+		}
+	default:
+		_, _ = fmt.Fprintf(w, "%d jip branch(es) of merged or closed PRs remain on %s — run jip send --cleanup=yes to delete them.\n", len(stale), opts.remote)
+		return false
+	}
+
+	var local, push []string
+	for _, s := range stale {
+		if s.bookmark.Present {
+			local = append(local, s.bookmark.Name)
+		}
+		push = append(push, s.bookmark.Name)
+	}
+	if len(local) > 0 {
+		if err := runner.BookmarkDelete(local); err != nil {
+			_, _ = fmt.Fprintf(w, "warning: could not delete stale jip bookmarks: %v\n", err)
+			return true
+		}
+	}
+	_, _ = fmt.Fprintf(w, "Deleting %d jip branch(es) of merged or closed PRs from %s...\n", len(stale), opts.remote)
+	if err := runner.GitPush(push, opts.remote); err != nil {
+		_, _ = fmt.Fprintf(w, "warning: could not delete stale jip branches from %s: %v\n", opts.remote, err)
+		return true
+	}
+	printStaleBranches(stale, w)
+	return true
+}
+
+func printStaleBranches(stale []staleBranch, w io.Writer) {
+	for _, s := range stale {
+		_, _ = fmt.Fprintf(w, "  #%-4d %-6s  %s\n", s.pr.Number, strings.ToLower(s.pr.State), s.bookmark.Name)
+	}
+}
+
+// promptCleanup returns a confirmCleanup function asking on w and reading
+// the answer from reader. Anything but yes keeps the branches.
+func promptCleanup(reader *bufio.Reader, w io.Writer) func([]staleBranch) (bool, error) {
+	return func(stale []staleBranch) (bool, error) {
+		_, _ = fmt.Fprintf(w, "%d jip branch(es) belong to merged or closed PRs:\n", len(stale))
+		printStaleBranches(stale, w)
+		_, _ = fmt.Fprint(w, "Delete them from the remote and locally? [y/N] ")
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return false, fmt.Errorf("reading the answer: %w", err)
+		}
+		answer := strings.ToLower(strings.TrimSpace(line))
+		return answer == "y" || answer == "yes", nil
+	}
+}
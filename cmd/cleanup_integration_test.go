@@ -0,0 +1,67 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_SendCleansUpMergedBranches(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, remoteDir := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	changeA := getChangeID(t, repoDir, "@-")
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	bookmarkA := findBookmarkForChange(t, runner, changeA)
+	mock.Lock()
+	mock.PRs[1].State = "MERGED"
+	mock.Unlock()
+
+	// An unrelated stack: without --cleanup the merged branch is not even
+	// looked up.
+	jjRun(t, repoDir, "new", "main")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+	buf.Reset()
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	if strings.Contains(buf.String(), "merged or closed") || remoteBranch(t, remoteDir, bookmarkA) == "" {
+		t.Fatalf("expected the merged branch to be left alone:\n%s", buf.String())
+	}
+
+	// With --cleanup=ask and no terminal to ask on, it is only reported.
+	buf.Reset()
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, cleanup: cleanupAsk}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "--cleanup") || remoteBranch(t, remoteDir, bookmarkA) == "" {
+		t.Fatalf("expected only a hint about the merged branch:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, cleanup: cleanupYes}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	t.Logf("Output:\n%s", buf.String())
+	if got := remoteBranch(t, remoteDir, bookmarkA); got != "" {
+		t.Errorf("merged branch %s still on the remote at %s", bookmarkA, got)
+	}
+	if out := jjRun(t, repoDir, "bookmark", "list", bookmarkA); strings.TrimSpace(out) != "" {
+		t.Errorf("merged bookmark still listed locally:\n%s", out)
+	}
+	if !strings.Contains(buf.String(), bookmarkA) {
+		t.Errorf("output does not name the deleted branch:\n%s", buf.String())
+	}
+}
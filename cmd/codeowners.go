@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/forge"
+	gh "github.com/omarkohl/jip/internal/github"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// codeOwnersLookup fetches the upstream CODEOWNERS file once per send and
+// matches the files of each change against it.
+type codeOwnersLookup struct {
+	client  forge.Service
+	fetched bool
+	rules   *gh.CodeOwnerRules // nil when the repository has no CODEOWNERS
+}
+
+// suggestCodeOwnerReviewers returns the code owners to request on a new PR,
+// leaving out the PR author and the reviewers already requested. self caches
+// the authenticated login across PRs.
+func suggestCodeOwnerReviewers(runner jj.Runner, l *codeOwnersLookup, change *jj.Change, self *string, requested []string) ([]string, error) {
+	if err := cacheLogin(l.client, self); err != nil {
+		return nil, err
+	}
+	return l.reviewers(runner, change, append([]string{*self}, requested...))
+}
+
+// reviewers returns the code owners of the files change touches, in the
+// order their files appear in the diff, without the logins and teams in
+// exclude (compared case-insensitively).
+func (l *codeOwnersLookup) reviewers(runner jj.Runner, change *jj.Change, exclude []string) ([]string, error) {
+	if !l.fetched {
+		data, err := l.client.CodeOwners()
+		if err != nil {
+			return nil, err
+		}
+		l.fetched = true
+		if data != nil {
+			l.rules = gh.ParseCodeOwners(data)
+		}
+	}
+	if l.rules == nil {
+		return nil, nil
+	}
+	diff, err := runner.Diff(change.ChangeID)
+	if err != nil {
+		return nil, err
+	}
+	var owners []string
+	for _, path := range jj.ChangedFiles(diff) {
+		for _, o := range l.rules.Owners(path) {
+			same := func(e string) bool { return strings.EqualFold(e, o) }
+			if !slices.ContainsFunc(exclude, same) && !slices.ContainsFunc(owners, same) {
+				owners = append(owners, o)
+			}
+		}
+	}
+	return owners, nil
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// Modes of the --color flag.
+const (
+	colorAuto   = "auto"   // color on a terminal, unless NO_COLOR is set
+	colorAlways = "always" // color even when piped, and despite NO_COLOR
+	colorNever  = "never"
+)
+
+// colorFlag is the --color mode.
+var colorFlag string
+
+func checkColorMode(mode string) error {
+	switch mode {
+	case colorAuto, colorAlways, colorNever:
+		return nil
+	}
+	return fmt.Errorf("invalid --color value %q (valid: %s, %s, %s)", mode, colorAuto, colorAlways, colorNever)
+}
+
+// colors paints text with ANSI colors, or leaves it as is when off.
+type colors struct {
+	on bool
+}
+
+// colorsFor returns the colors for output to w under --color: with auto,
+// only on a terminal and when NO_COLOR (https://no-color.org) is not set.
+func colorsFor(w io.Writer) colors {
+	switch colorFlag {
+	case colorAlways:
+		return colors{on: true}
+	case colorNever:
+		return colors{}
+	}
+	return colors{on: os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" && isTerminal(w)}
+}
+
+func (c colors) paint(code, s string) string {
+	if !c.on || s == "" {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+func (c colors) green(s string) string  { return c.paint("32", s) }
+func (c colors) yellow(s string) string { return c.paint("33", s) }
+func (c colors) red(s string) string    { return c.paint("31", s) }
+func (c colors) dim(s string) string    { return c.paint("2", s) }
+
+// diffStat renders s like DiffStat.String, the additions green and the
+// deletions red.
+func (c colors) diffStat(s jj.DiffStat) string {
+	files := "files"
+	if s.Files == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("%s %s, %d %s", c.green(fmt.Sprintf("+%d", s.Additions)), c.red(fmt.Sprintf("−%d", s.Deletions)), s.Files, files)
+}
+
+// checks colors a padded checks label (see checksLabel) by its status.
+func (c colors) checks(label, status string) string {
+	switch status {
+	case "pass":
+		return c.green(label)
+	case "fail":
+		return c.red(label)
+	case "pending":
+		return c.yellow(label)
+	}
+	return label
+}
+
+// skipReason colors why a change was skipped: yellow when that is expected
+// (e.g. an up-to-date PR), red otherwise.
+func (c colors) skipReason(r skipReason) string {
+	if r.benign {
+		return c.yellow(r.reason)
+	}
+	return c.red(r.reason)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestColorsFor(t *testing.T) {
+	old := colorFlag
+	t.Cleanup(func() { colorFlag = old })
+	var buf bytes.Buffer
+
+	tests := []struct {
+		mode    string
+		noColor string
+		want    bool
+	}{
+		{colorAuto, "", false}, // not a terminal
+		{colorAlways, "", true},
+		{colorAlways, "1", true},
+		{colorNever, "", false},
+	}
+	for _, tt := range tests {
+		colorFlag = tt.mode
+		t.Setenv("NO_COLOR", tt.noColor)
+		if got := colorsFor(&buf).on; got != tt.want {
+			t.Errorf("colorsFor with --color=%s NO_COLOR=%q = %v, want %v", tt.mode, tt.noColor, got, tt.want)
+		}
+	}
+}
+
+func TestColorsDiffStat(t *testing.T) {
+	stat := jj.DiffStat{Files: 2, Additions: 10, Deletions: 3}
+	if got, want := (colors{}).diffStat(stat), stat.String(); got != want {
+		t.Errorf("uncolored diffStat = %q, want %q", got, want)
+	}
+	want := "\x1b[32m+10\x1b[0m \x1b[31m−3\x1b[0m, 2 files"
+	if got := (colors{on: true}).diffStat(stat); got != want {
+		t.Errorf("colored diffStat = %q, want %q", got, want)
+	}
+}
+
+func TestColorsSkipReason(t *testing.T) {
+	c := colors{on: true}
+	if got, want := c.skipReason(skipReason{reason: "up-to-date", benign: true}), "\x1b[33mup-to-date\x1b[0m"; got != want {
+		t.Errorf("benign skip = %q, want %q", got, want)
+	}
+	if got, want := c.skipReason(skipReason{reason: "conflict"}), "\x1b[31mconflict\x1b[0m"; got != want {
+		t.Errorf("skip = %q, want %q", got, want)
+	}
+	if err := checkColorMode("sometimes"); err == nil {
+		t.Error("checkColorMode(\"sometimes\") = nil, want an error")
+	}
+}
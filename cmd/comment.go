@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var commentCmd = &cobra.Command{
+	Use:   "comment [revset]",
+	Short: "Post a comment on the PR of a change",
+	Long: `Comment posts a comment on the open PR of each change in the revset, found
+the same way send finds it: through the change's bookmark on the push remote.
+
+Useful for scripting, e.g. posting benchmark results on every PR of a stack:
+
+  for c in $(jj log -r 'trunk()..@-' --no-graph -T 'change_id ++ "\n"'); do
+    jip comment "$c" -m "$(bench "$c")"
+  done
+
+Every change must have an open PR; nothing is posted otherwise.
+
+Default revset is @- (the last committed change).`,
+	Args:              cobra.MaximumNArgs(1),
+	RunE:              runComment,
+	ValidArgsFunction: completeJJRevsets,
+}
+
+func init() {
+	rootCmd.AddCommand(commentCmd)
+	commentCmd.Flags().StringP("message", "m", "", "Comment text (markdown)")
+	commentCmd.Flags().String("remote", "origin", "Push remote name")
+	commentCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (where PRs are opened)")
+	_ = commentCmd.MarkFlagRequired("message")
+
+	_ = commentCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = commentCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+}
+
+func runComment(cmd *cobra.Command, args []string) error {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+		return err
+	}
+
+	message, _ := cmd.Flags().GetString("message")
+	if strings.TrimSpace(message) == "" {
+		return fmt.Errorf("empty comment — pass the text with -m")
+	}
+	remote, _ := cmd.Flags().GetString("remote")
+	upstream, _ := cmd.Flags().GetString("upstream")
+	revset := "@-"
+	if len(args) > 0 {
+		revset = args[0]
+	}
+	w := cmd.OutOrStdout()
+
+	rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], w)
+	if err != nil {
+		return err
+	}
+	namer, err := configBookmarkNamer(cfg, rc.client.GetAuthenticatedUser)
+	if err != nil {
+		return err
+	}
+	return executeComment(runner, rc.client, namer, remote, revset, message, w)
+}
+
+func executeComment(runner jj.Runner, client forge.Service, namer *jj.BookmarkNamer, remote, revset, message string, w io.Writer) error {
+	data, err := runner.Log(revset)
+	if err != nil {
+		return fmt.Errorf("resolving %q: %w", revset, err)
+	}
+	changes, err := jj.ParseChanges(data)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return fmt.Errorf("%q resolved to no changes", revset)
+	}
+	dags, err := jj.BuildDAGs(changes)
+	if err != nil {
+		return err
+	}
+
+	var entries []stackEntry
+	for _, dag := range dags {
+		found, err := findStackPRs(runner, client, namer, dag, remote)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, found...)
+	}
+	for _, e := range entries {
+		if e.pr == nil {
+			return fmt.Errorf("change %s (%s) has no open PR — send it first", e.change.Short(), e.change.Title())
+		}
+	}
+
+	_, _ = fmt.Fprintln(w)
+	for _, e := range entries {
+		if err := client.CommentOnPR(e.pr.Number, message); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(w, "  #%-4d commented  %s\n", e.pr.Number, e.pr.URL)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_Comment(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@--"}}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	bookmarkA := findBookmarkForChange(t, runner, getChangeID(t, repoDir, "@--"))
+
+	buf.Reset()
+	if err := executeComment(runner, mock, defaultNamer(t), "origin", "@--", "benchmark: 42ms", &buf); err != nil {
+		t.Fatalf("comment failed: %v\n%s", err, buf.String())
+	}
+	t.Logf("Output:\n%s", buf.String())
+
+	mock.Lock()
+	var number int
+	for _, pr := range mock.PRs {
+		if pr.HeadRefName == bookmarkA {
+			number = pr.Number
+		}
+	}
+	comments := mock.Comments[number]
+	mock.Unlock()
+	if len(comments) == 0 || comments[len(comments)-1] != "benchmark: 42ms" {
+		t.Errorf("PR #%d comments = %q, want the benchmark comment last", number, comments)
+	}
+
+	// B was never sent: nothing is posted and the error names the change.
+	buf.Reset()
+	err := executeComment(runner, mock, defaultNamer(t), "origin", "@--|@-", "again", &buf)
+	if err == nil || !strings.Contains(err.Error(), "feat: add B") {
+		t.Fatalf("err = %v, want one naming the unsent change", err)
+	}
+	mock.Lock()
+	defer mock.Unlock()
+	for n, cs := range mock.Comments {
+		for _, c := range cs {
+			if c == "again" {
+				t.Errorf("PR #%d got a comment although a change had no PR", n)
+			}
+		}
+	}
+}
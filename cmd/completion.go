@@ -2,10 +2,18 @@ package cmd
 
 import (
 	"bufio"
+	"io"
 	"os"
 	"os/exec"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/omarkohl/jip/pkg/jj"
 	"github.com/spf13/cobra"
 )
 
@@ -27,7 +35,13 @@ func completeJJBookmarks(cmd *cobra.Command, args []string, toComplete string) (
 // to skip flag suggestions).
 func jjComplete(simArgs []string, filterPrefix string) ([]string, cobra.ShellCompDirective) {
 	cmdArgs := append([]string{"--"}, append([]string{"jj"}, simArgs...)...)
-	cmd := exec.Command("jj", cmdArgs...)
+	bin := "jj"
+	if cfg, err := config.Load(""); err == nil {
+		if path, err := jj.Locate(cfg["jj-bin"]); err == nil {
+			bin = path
+		}
+	}
+	cmd := exec.Command(bin, cmdArgs...)
 	cmd.Env = append(os.Environ(), "COMPLETE=fish")
 
 	out, err := cmd.Output()
@@ -50,3 +64,173 @@ func jjComplete(simArgs []string, filterPrefix string) ([]string, cobra.ShellCom
 
 	return completions, cobra.ShellCompDirectiveNoFileComp
 }
+
+// completeRemotes completes the names of the git remotes of the jj
+// workspace, described by their URLs.
+func completeRemotes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	runner, _, err := workspaceRunner()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	data, err := runner.GitRemoteList()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	remotes := jj.ParseRemoteList(data)
+	var completions []string
+	for name, url := range remotes {
+		completions = append(completions, name+"\t"+url)
+	}
+	sort.Strings(completions)
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeJipBranches completes the bookmarks jip manages: those the state
+// file records a send of, and those named by the bookmark template.
+func completeJipBranches(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	runner, root, err := workspaceRunner()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	data, err := runner.BookmarkList()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	bookmarks, err := jj.ParseBookmarkList(data)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	st, err := state.Load(state.Path(root))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	cfg, err := config.Load(root)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	namer, err := configBookmarkNamer(cfg, nil)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return jipBranches(bookmarks, st, namer), cobra.ShellCompDirectiveNoFileComp
+}
+
+// jipBranches returns the names of bookmarks that st records a send of or
+// that namer owns, sorted.
+func jipBranches(bookmarks []jj.BookmarkInfo, st *state.State, namer *jj.BookmarkNamer) []string {
+	sent := make(map[string]bool, len(st.Changes))
+	for _, rec := range st.Changes {
+		sent[rec.Bookmark] = true
+	}
+	var names []string
+	for _, b := range bookmarks {
+		if sent[b.Name] || namer.Owns(b.Name) {
+			names = append(names, b.Name)
+		}
+	}
+	sort.Strings(names)
+	return slices.Compact(names)
+}
+
+// completePRNumbers completes the numbers of the most recently updated open
+// PRs, described by their titles. Only the first argument is a PR number
+// unless the command takes several.
+func completePRNumbers(many bool) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 && !many {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		client, err := completionClient(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		completions, err := prCompletions(client, args)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// prCompletionLimit is how many open PRs PR number completion offers.
+const prCompletionLimit = 50
+
+// prCompletions returns "<number>\t<title>" for the open PRs not already
+// among args.
+func prCompletions(client forge.Service, args []string) ([]string, error) {
+	prs, err := client.ListOpenPRs(prCompletionLimit)
+	if err != nil {
+		return nil, err
+	}
+	var completions []string
+	for _, pr := range prs {
+		n := strconv.Itoa(pr.Number)
+		if slices.Contains(args, n) || slices.Contains(args, "#"+n) {
+			continue
+		}
+		completions = append(completions, n+"\t"+pr.Title)
+	}
+	return completions, nil
+}
+
+// completeReviewers completes the logins of the users who can review PRs.
+// --reviewer takes comma-separated lists, so the part after the last comma
+// is completed.
+func completeReviewers(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	client, err := completionClient(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	logins, err := client.Collaborators()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return reviewerCompletions(logins, toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// reviewerCompletions returns the completions of toComplete, a
+// comma-separated list of reviewers: each login not already listed, after
+// the listed ones.
+func reviewerCompletions(logins []string, toComplete string) []string {
+	var listed string
+	var done []string
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		listed = toComplete[:i+1]
+		done = strings.Split(toComplete[:i], ",")
+	}
+	var completions []string
+	for _, login := range logins {
+		if slices.Contains(done, login) {
+			continue
+		}
+		completions = append(completions, listed+login)
+	}
+	return completions
+}
+
+// completionClient returns the forge client of the repository cmd's --remote
+// and --upstream point at, without printing anything: completion output is
+// read by the shell.
+func completionClient(cmd *cobra.Command) (forge.Service, error) {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+		return nil, err
+	}
+	remote, _ := cmd.Flags().GetString("remote")
+	if remote == "" {
+		remote = "origin"
+	}
+	upstream, _ := cmd.Flags().GetString("upstream")
+	rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	return rc.client, nil
+}
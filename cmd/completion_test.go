@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestJipBranches(t *testing.T) {
+	st, err := state.Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.RecordSend("abc", "alice/fix-login", 3, "c1", time.Now())
+	bookmarks := []jj.BookmarkInfo{
+		{Name: "main"},
+		{Name: "jip/feat-b/bbbbbbbb"},
+		{Name: "alice/fix-login"},
+		{Name: "jip/feat-a/aaaaaaaa"},
+		{Name: "wip"},
+		{Name: "dev/feat-c-cccccccc"},
+	}
+	namer, err := jj.NewBookmarkNamer("dev/{{.Slug}}-{{.ShortID}}", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := jipBranches(bookmarks, st, namer)
+	want := []string{"alice/fix-login", "dev/feat-c-cccccccc", "jip/feat-a/aaaaaaaa", "jip/feat-b/bbbbbbbb"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("jipBranches = %q, want %q", got, want)
+	}
+}
+
+func TestPRCompletions(t *testing.T) {
+	fake := forgetest.New("owner", "repo")
+	fake.PRs[1] = &forge.PRInfo{Number: 1, Title: "feat: A", State: "OPEN"}
+	fake.PRs[2] = &forge.PRInfo{Number: 2, Title: "feat: B", State: "MERGED"}
+	fake.PRs[3] = &forge.PRInfo{Number: 3, Title: "feat: C", State: "OPEN"}
+
+	got, err := prCompletions(fake, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"3\tfeat: C", "1\tfeat: A"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("completions = %q, want %q", got, want)
+	}
+
+	got, err = prCompletions(fake, []string{"#3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1\tfeat: A"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("completions after #3 = %q, want %q", got, want)
+	}
+}
+
+func TestReviewerCompletions(t *testing.T) {
+	logins := []string{"alice", "bob", "carol"}
+	tests := []struct {
+		toComplete string
+		want       []string
+	}{
+		{"", []string{"alice", "bob", "carol"}},
+		{"b", []string{"alice", "bob", "carol"}},
+		{"alice,", []string{"alice,bob", "alice,carol"}},
+		{"alice,bob,c", []string{"alice,bob,carol"}},
+	}
+	for _, tt := range tests {
+		if got := reviewerCompletions(logins, tt.toComplete); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("reviewerCompletions(%q) = %q, want %q", tt.toComplete, got, tt.want)
+		}
+	}
+}
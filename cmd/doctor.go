@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/config"
+	gh "github.com/omarkohl/jip/internal/github"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that jip can work in this repository",
+	Long: `Doctor checks everything jip send relies on, in order: jj and its version,
+the jj repository and its git remotes, the GitHub token and its access to the
+repository, and the base branch. Each check prints ok or FAIL, and failures
+come with a hint on how to fix them. Checks that depend on a failed one are
+skipped.
+
+Doctor only reads: it neither fetches nor changes anything. The base branch is
+checked against the last fetched state of the remote.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringP("base", "b", "trunk()", "Base branch to check (defaults to the repo's trunk branch, usually main)")
+	doctorCmd.Flags().String("remote", "origin", "Push remote name")
+	doctorCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (where PRs are opened)")
+
+	_ = doctorCmd.RegisterFlagCompletionFunc("base", completeJJBookmarks)
+	_ = doctorCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = doctorCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+}
+
+// doctorReport prints check results and counts the failures.
+type doctorReport struct {
+	w      io.Writer
+	failed int
+}
+
+func (d *doctorReport) ok(name, detail string) {
+	_, _ = fmt.Fprintf(d.w, "  ok    %-10s %s\n", name, detail)
+}
+
+func (d *doctorReport) fail(name string, err error, hint string) {
+	d.failed++
+	_, _ = fmt.Fprintf(d.w, "  FAIL  %-10s %v\n", name, err)
+	if hint != "" {
+		_, _ = fmt.Fprintf(d.w, "        hint: %s\n", hint)
+	}
+}
+
+func (d *doctorReport) skip(name, reason string) {
+	_, _ = fmt.Fprintf(d.w, "  -     %-10s skipped: %s\n", name, reason)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	d := &doctorReport{w: cmd.OutOrStdout()}
+	runDoctorChecks(cmd, d)
+	if d.failed > 0 {
+		return fmt.Errorf("%d check(s) failed", d.failed)
+	}
+	_, _ = fmt.Fprintln(d.w, "\nAll checks passed.")
+	return nil
+}
+
+// runDoctorChecks runs the checks in dependency order and returns at the
+// first failure that makes the rest meaningless, reporting them as skipped.
+func runDoctorChecks(cmd *cobra.Command, d *doctorReport) {
+	skipRest := func(reason string, names ...string) {
+		for _, n := range names {
+			d.skip(n, reason)
+		}
+	}
+
+	// jj itself.
+	globalCfg, err := config.Load("")
+	if err != nil {
+		d.fail("config", err, "fix or remove the global config file")
+		skipRest("config unreadable", "jj", "repo", "remote", "auth", "access", "base")
+		return
+	}
+	v, err := jj.Probe(globalCfg["jj-bin"])
+	if err != nil {
+		d.fail("jj", err, errorHint(err))
+		skipRest("jj unusable", "repo", "remote", "auth", "access", "base")
+		return
+	}
+	d.ok("jj", fmt.Sprintf("jj %s (%s)", v, jj.Binary()))
+
+	// The repository and its configuration.
+	cwd, err := os.Getwd()
+	if err != nil {
+		d.fail("repo", err, "")
+		skipRest("no repository", "remote", "auth", "access", "base")
+		return
+	}
+	root, err := jj.WorkspaceRoot(cwd)
+	if err == nil && root == "" {
+		err = fmt.Errorf("%s: %w", cwd, jj.ErrNotARepo)
+	}
+	if err != nil {
+		d.fail("repo", err, errorHint(err))
+		skipRest("no repository", "remote", "auth", "access", "base")
+		return
+	}
+	runner := jj.NewRunnerContext(runContext, root)
+	cfg, err := config.Load(root)
+	if err == nil {
+		err = applySendConfig(cmd.Flags(), cfg)
+	}
+	if err != nil {
+		d.fail("repo", err, "fix the config files (see 'Configuration files' in the docs)")
+		skipRest("config unreadable", "remote", "auth", "access", "base")
+		return
+	}
+	d.ok("repo", root)
+
+	// Remotes.
+	base, _ := cmd.Flags().GetString("base")
+	remote, _ := cmd.Flags().GetString("remote")
+	upstream, _ := cmd.Flags().GetString("upstream")
+	remotes, err := gitRemotes(runner)
+	if err != nil {
+		d.fail("remote", err, "")
+		skipRest("no usable remote", "auth", "access", "base")
+		return
+	}
+	pushURL, ok := remotes[remote]
+	if !ok {
+		d.fail("remote", fmt.Errorf("remote %q not found (available: %s)", remote, strings.Join(slices.Sorted(maps.Keys(remotes)), ", ")),
+			"pass --remote or set remote in the config")
+		skipRest("no usable remote", "auth", "access", "base")
+		return
+	}
+	targetURL, baseRemote := pushURL, remote
+	upstreamURL, upstreamRemote, err := resolveUpstream(remotes, upstream)
+	if err != nil {
+		d.fail("remote", err, "pass an existing remote name or a URL to --upstream")
+		skipRest("no usable remote", "auth", "access", "base")
+		return
+	}
+	if upstreamURL != "" {
+		targetURL = upstreamURL
+	}
+	if upstreamRemote != "" {
+		baseRemote = upstreamRemote
+	}
+	owner, repo, err := gh.ParseRepoFromURL(targetURL)
+	if err == nil && targetURL != pushURL {
+		_, _, err = gh.ParseRepoFromURL(pushURL)
+	}
+	if err != nil {
+		d.fail("remote", err, "jip needs a GitHub remote (https://github.com/OWNER/REPO.git or git@github.com:OWNER/REPO.git)")
+		skipRest("no usable remote", "auth", "access", "base")
+		return
+	}
+	host, err := remoteHost(pushURL, targetURL)
+	if err != nil {
+		d.fail("remote", err, "use a push remote on the same host as --upstream")
+		skipRest("no usable remote", "auth", "access", "base")
+		return
+	}
+	if host == defaultHost {
+		d.ok("remote", fmt.Sprintf("%s → %s/%s", remote, owner, repo))
+	} else {
+		d.ok("remote", fmt.Sprintf("%s → %s/%s on %s", remote, owner, repo, host))
+	}
+
+	// Authentication and repository access.
+	app, appErr := githubApp()
+	token, _ := hostToken(host)
+	if appErr != nil {
+		d.fail("auth", appErr, "fix the GitHub App settings (see 'GitHub Apps' in the docs)")
+		skipRest("no client", "access")
+	} else if token == "" && app == nil {
+		hint := "run 'jip auth login' or 'gh auth login', or set GH_TOKEN"
+		if host != defaultHost {
+			hint = fmt.Sprintf("run 'gh auth login --hostname %s', or set GH_ENTERPRISE_TOKEN", host)
+		}
+		d.fail("auth", errors.New("not authenticated"), hint)
+		skipRest("not authenticated", "access")
+	} else if client, source, err := githubClient(host, targetURL); err != nil {
+		d.fail("auth", err, "")
+		skipRest("no client", "access")
+	} else if info, err := client.TokenInfo(); err != nil {
+		d.fail("auth", err, "the token may be expired or revoked — run 'jip auth login' again")
+		skipRest("token invalid", "access")
+	} else {
+		d.ok("auth", fmt.Sprintf("%s via %s", info.Login, source))
+		checkDoctorAccess(d, client, info)
+	}
+
+	// The base branch, as last fetched.
+	bookmarkData, err := runner.BookmarkList()
+	var bookmarks []jj.BookmarkInfo
+	if err == nil {
+		bookmarks, err = jj.ParseBookmarkList(bookmarkData)
+	}
+	var branch string
+	if err == nil {
+		branch, err = jj.ResolveBaseBranch(runner, base, bookmarks, baseRemote)
+	}
+	if err != nil {
+		d.fail("base", err, "run 'jj git fetch', or pass --base with a branch that exists on "+baseRemote)
+		return
+	}
+	d.ok("base", fmt.Sprintf("%s → %s on %s", base, branch, baseRemote))
+}
+
+// checkDoctorAccess checks that the token can see the repository PRs are
+// opened in and has the scopes jip needs there.
+func checkDoctorAccess(d *doctorReport, client *gh.Client, token *gh.TokenInfo) {
+	repo, err := client.RepoInfo(client.Owner(), client.Repo())
+	if err != nil {
+		d.fail("access", err, "check --remote/--upstream, and that the token was granted access to the repository")
+		return
+	}
+	if err := checkScopes(token.Scopes, repo.Private); err != nil {
+		d.fail("access", err, "create a token with the repo scope, or run 'jip auth login'")
+		return
+	}
+	detail := fmt.Sprintf("%s/%s", repo.Owner, repo.Name)
+	if !repo.CanPush && token.Kind != gh.TokenApp {
+		detail += " (no push access — fine when pushing to a fork with --upstream)"
+	}
+	if !strings.EqualFold(repo.Owner, client.Owner()) || !strings.EqualFold(repo.Name, client.Repo()) {
+		detail += fmt.Sprintf(" (moved from %s/%s — update the remote URL)", client.Owner(), client.Repo())
+	}
+	d.ok("access", detail)
+}
+
+// checkScopes reports whether a classic token's scopes allow opening PRs in
+// a repository. Fine-grained tokens (nil scopes) list their permissions per
+// repository, which the access check covers.
+func checkScopes(scopes []string, private bool) error {
+	if scopes == nil {
+		return nil
+	}
+	if slices.Contains(scopes, "repo") {
+		return nil
+	}
+	if private {
+		return fmt.Errorf("the token lacks the repo scope needed for private repositories (has: %s)", scopeList(scopes))
+	}
+	if !slices.Contains(scopes, "public_repo") {
+		return fmt.Errorf("the token lacks the repo or public_repo scope (has: %s)", scopeList(scopes))
+	}
+	return nil
+}
+
+func scopeList(scopes []string) string {
+	if len(scopes) == 0 {
+		return "none"
+	}
+	return strings.Join(scopes, ", ")
+}
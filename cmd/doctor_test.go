@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		private bool
+		wantErr bool
+	}{
+		{"fine-grained", nil, true, false},
+		{"repo on private", []string{"repo", "read:org"}, true, false},
+		{"public_repo on public", []string{"public_repo"}, false, false},
+		{"public_repo on private", []string{"public_repo"}, true, true},
+		{"no scopes", []string{}, false, true},
+		{"unrelated scopes", []string{"gist"}, false, true},
+	}
+	for _, tt := range tests {
+		err := checkScopes(tt.scopes, tt.private)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDoctorReport(t *testing.T) {
+	var buf bytes.Buffer
+	d := &doctorReport{w: &buf}
+	d.ok("jj", "jj 0.30.0 (/usr/bin/jj)")
+	d.fail("auth", errors.New("not authenticated"), "run 'jip auth login'")
+	d.skip("access", "not authenticated")
+
+	if d.failed != 1 {
+		t.Errorf("failed = %d, want 1", d.failed)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"ok    jj         jj 0.30.0",
+		"FAIL  auth       not authenticated",
+		"hint: run 'jip auth login'",
+		"access     skipped: not authenticated",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
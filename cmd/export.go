@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+const (
+	exportFormatPatchDir = "patchdir" // one file per patch, as git format-patch writes them
+	exportFormatMbox     = "mbox"     // all patches in a single mbox, as git format-patch --stdout
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [revsets...]",
+	Short: "Write a stack as a patch series for mailing lists or offline review",
+	Long: `Export writes the changes of a stack as a numbered series of patches in the
+format of git format-patch, preceded by a cover letter that lists the
+changes of the series and their size. git am applies the patches, and git
+send-email mails them.
+
+With --format=patchdir (the default), every patch is a file in the --output
+directory: 0000-cover-letter.patch, 0001-<title>.patch and so on. With
+--format=mbox, the series is a single mbox written to --output, or to
+standard output without it.
+
+jip's trailers are left out of the patches, and empty changes are skipped.
+The stack must be linear: a patch series has no merges.
+
+Default revset is @- (the stack up to the last committed change).`,
+	RunE:              runExport,
+	ValidArgsFunction: completeJJRevsets,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringP("base", "b", "trunk()", "Base branch (defaults to the repo's trunk branch, usually main)")
+	exportCmd.Flags().StringP("format", "f", exportFormatPatchDir, "Output format: patchdir (one file per patch) or mbox (a single file)")
+	exportCmd.Flags().StringP("output", "o", "", "Directory for patchdir (default: the current directory), file for mbox (default: standard output)")
+
+	_ = exportCmd.RegisterFlagCompletionFunc("base", completeJJBookmarks)
+	_ = exportCmd.RegisterFlagCompletionFunc("format",
+		cobra.FixedCompletions([]string{exportFormatPatchDir, exportFormatMbox}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	runner, _, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	base, _ := cmd.Flags().GetString("base")
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	if format != exportFormatPatchDir && format != exportFormatMbox {
+		return fmt.Errorf("invalid --format %q: use %s or %s", format, exportFormatPatchDir, exportFormatMbox)
+	}
+	revsets := args
+	if len(revsets) == 0 {
+		revsets = []string{"@-"}
+	}
+	// An mbox on standard output must not be mixed with the messages.
+	w := cmd.OutOrStdout()
+	if format == exportFormatMbox && output == "" {
+		w = cmd.ErrOrStderr()
+	}
+	return executeExport(runner, base, revsets, format, output, time.Now(), cmd.OutOrStdout(), w)
+}
+
+// patchFile is one message of an exported series.
+type patchFile struct {
+	name    string // file name for --format=patchdir
+	content string
+}
+
+// executeExport writes the stack of revsets on base as a patch series in
+// format to output (stdout for an mbox without output), dated now where the
+// cover letter needs a date. Messages go to w.
+func executeExport(runner jj.Runner, base string, revsets []string, format, output string, now time.Time, stdout, w io.Writer) error {
+	dags, err := jj.ResolveStacks(runner, revsets, base)
+	if err != nil {
+		return fmt.Errorf("resolving stacks: %w", err)
+	}
+	switch {
+	case len(dags) == 0:
+		_, _ = fmt.Fprintln(w, "No changes to export.")
+		return nil
+	case len(dags) > 1:
+		return fmt.Errorf("export writes one stack at a time, but the revsets resolve to %d stacks", len(dags))
+	}
+	dag := dags[0]
+	for _, c := range dag.Changes {
+		if len(c.ParentIDs) > 1 {
+			return fmt.Errorf("cannot export %s: it is a merge, and a patch series must be linear", c.Short())
+		}
+	}
+	if leaves := dag.LeafChanges(); len(leaves) != 1 {
+		return fmt.Errorf("export requires a linear stack (found %d tips)", len(leaves))
+	}
+
+	var changes []*jj.Change
+	diffs := make(map[string]string)
+	for _, c := range dag.Changes {
+		if c.Empty {
+			_, _ = fmt.Fprintf(w, "Skipping empty change %s.\n", c.Short())
+			continue
+		}
+		diff, err := runner.Diff(c.ChangeID)
+		if err != nil {
+			return fmt.Errorf("reading the diff of %s: %w", c.Short(), err)
+		}
+		changes = append(changes, c)
+		diffs[c.ChangeID] = diff
+	}
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintln(w, "No changes to export.")
+		return nil
+	}
+
+	branch := base
+	if data, err := runner.BookmarkList(); err == nil {
+		if bookmarks, err := jj.ParseBookmarkList(data); err == nil {
+			if b, err := jj.ResolveBaseBranch(runner, base, bookmarks, ""); err == nil {
+				branch = b
+			}
+		}
+	}
+	sender := changes[len(changes)-1].Author
+	if name, err := runner.ConfigGet("user.name"); err == nil && name != "" {
+		sender.Name = name
+	}
+	if email, err := runner.ConfigGet("user.email"); err == nil && email != "" {
+		sender.Email = email
+	}
+	sender.Timestamp = now
+	patches := patchSeries(changes, diffs, branch, sender)
+
+	switch format {
+	case exportFormatMbox:
+		var mbox strings.Builder
+		for _, p := range patches {
+			mbox.WriteString(p.content)
+		}
+		if output == "" {
+			_, err := io.WriteString(stdout, mbox.String())
+			return err
+		}
+		if err := os.WriteFile(output, []byte(mbox.String()), 0o644); err != nil {
+			return fmt.Errorf("writing %s: %w", output, err)
+		}
+		_, _ = fmt.Fprintf(w, "Wrote %d patch(es) and a cover letter to %s.\n", len(changes), output)
+	default:
+		if output == "" {
+			output = "."
+		}
+		if err := os.MkdirAll(output, 0o755); err != nil {
+			return fmt.Errorf("creating %s: %w", output, err)
+		}
+		for _, p := range patches {
+			path := filepath.Join(output, p.name)
+			if err := os.WriteFile(path, []byte(p.content), 0o644); err != nil {
+				return fmt.Errorf("writing %s: %w", path, err)
+			}
+			_, _ = fmt.Fprintln(w, path)
+		}
+	}
+	return nil
+}
+
+// patchSeries renders changes, bottom first, with their diffs by change ID
+// as a numbered series of patches on branch, preceded by a cover letter
+// from sender.
+func patchSeries(changes []*jj.Change, diffs map[string]string, branch string, sender jj.Signature) []patchFile {
+	n := len(changes)
+	var total jj.DiffStat
+	var list strings.Builder
+	files := make(map[string]bool)
+	for i, c := range changes {
+		stat := jj.ParseDiffStat(diffs[c.ChangeID])
+		total.Additions += stat.Additions
+		total.Deletions += stat.Deletions
+		for line := range strings.Lines(diffs[c.ChangeID]) {
+			if strings.HasPrefix(line, "diff --git ") {
+				files[line] = true
+			}
+		}
+		title, _ := patchMessage(c)
+		fmt.Fprintf(&list, "  %d/%d %s (%s)\n", i+1, n, title, stat)
+	}
+	total.Files = len(files)
+	tip, _ := patchMessage(changes[n-1])
+	cover := fmt.Sprintf("This series of %d patch(es) applies on top of %s:\n\n%s\n%s\n\n",
+		n, branch, list.String(), patchStat(total))
+	patches := []patchFile{{
+		name:    "0000-cover-letter.patch",
+		content: patchMessageText(strings.Repeat("0", 40), sender, fmt.Sprintf("[PATCH 0/%d] %s", n, tip), cover),
+	}}
+	for i, c := range changes {
+		title, body := patchMessage(c)
+		diff := diffs[c.ChangeID]
+		text := "---\n" + patchStat(jj.ParseDiffStat(diff)) + "\n\n" + diff
+		if body != "" {
+			text = body + "\n\n" + text
+		}
+		patches = append(patches, patchFile{
+			name:    fmt.Sprintf("%04d-%s.patch", i+1, patchSlug(title)),
+			content: patchMessageText(c.CommitID, c.Author, fmt.Sprintf("[PATCH %d/%d] %s", i+1, n, title), text),
+		})
+	}
+	return patches
+}
+
+// patchMessage returns the title and body of the patch of c: its
+// description without jip's trailers and the PR override trailers.
+func patchMessage(c *jj.Change) (title, body string) {
+	desc := strings.TrimSpace(jj.StripOverrides(jj.StripTrailers(c.Description)))
+	if desc == "" {
+		return "(no description set)", ""
+	}
+	title, body, _ = strings.Cut(desc, "\n")
+	return title, strings.TrimSpace(body)
+}
+
+// patchMessageText renders a message of git format-patch: the mbox From
+// line with commit, the mail headers, and text.
+func patchMessageText(commit string, author jj.Signature, subject, text string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From %s Mon Sep 17 00:00:00 2001\n", commit)
+	fmt.Fprintf(&b, "From: %s\n", (&mail.Address{Name: author.Name, Address: author.Email}).String())
+	fmt.Fprintf(&b, "Date: %s\n", author.Timestamp.Format("Mon, 2 Jan 2006 15:04:05 -0700"))
+	fmt.Fprintf(&b, "Subject: %s\n", mime.QEncoding.Encode("utf-8", subject))
+	b.WriteString("MIME-Version: 1.0\nContent-Type: text/plain; charset=UTF-8\nContent-Transfer-Encoding: 8bit\n\n")
+	b.WriteString(text)
+	if !strings.HasSuffix(text, "\n") {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "-- \njip %s\n\n", buildVersion())
+	return b.String()
+}
+
+// patchStat renders stat like the summary line of git diff --stat.
+func patchStat(stat jj.DiffStat) string {
+	plural := func(n int, one, many string) string {
+		if n == 1 {
+			return fmt.Sprintf("%d %s", n, one)
+		}
+		return fmt.Sprintf("%d %s", n, many)
+	}
+	s := " " + plural(stat.Files, "file changed", "files changed")
+	if stat.Additions > 0 || stat.Deletions == 0 {
+		s += ", " + plural(stat.Additions, "insertion(+)", "insertions(+)")
+	}
+	if stat.Deletions > 0 {
+		s += ", " + plural(stat.Deletions, "deletion(-)", "deletions(-)")
+	}
+	return s
+}
+
+// patchSlug turns title into the file name part git format-patch uses:
+// letters, digits, dots and underscores, with dashes for everything else,
+// at most 52 characters.
+func patchSlug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range title {
+		if r < 128 && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	slug := b.String()
+	if len(slug) > 52 {
+		slug = strings.TrimRight(slug[:52], "-.")
+	}
+	return strings.TrimRight(slug, ".")
+}
@@ -0,0 +1,78 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_ExportPatchDir(t *testing.T) {
+	checkJJ(t)
+
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a\n", "feat: add A\n\nThe first part.")
+	writeAndCommit(t, repoDir, "b.go", "package b\n", "feat: add B")
+
+	out := t.TempDir()
+	var buf bytes.Buffer
+	if err := executeExport(runner, "main", []string{"@-"}, exportFormatPatchDir, out, time.Now(), &buf, &buf); err != nil {
+		t.Fatalf("export failed: %v\n%s", err, buf.String())
+	}
+	t.Logf("Output:\n%s", buf.String())
+
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := "0000-cover-letter.patch 0001-feat-add-A.patch 0002-feat-add-B.patch"; strings.Join(names, " ") != want {
+		t.Fatalf("files = %v, want %s", names, want)
+	}
+	data, err := os.ReadFile(filepath.Join(out, "0001-feat-add-A.patch"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Subject: [PATCH 1/2] feat: add A\n", "The first part.\n", "+++ b/a.go\n", "+package a\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("patch lacks %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestIntegration_ExportMbox(t *testing.T) {
+	checkJJ(t)
+
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a\n", "feat: add A")
+	jjRun(t, repoDir, "commit", "-m", "chore: nothing")
+	writeAndCommit(t, repoDir, "b.go", "package b\n", "feat: add B")
+
+	var stdout, msgs bytes.Buffer
+	if err := executeExport(runner, "main", []string{"@-"}, exportFormatMbox, "", time.Now(), &stdout, &msgs); err != nil {
+		t.Fatalf("export failed: %v\n%s", err, msgs.String())
+	}
+	mbox := stdout.String()
+	if !strings.Contains(msgs.String(), "Skipping empty change") {
+		t.Errorf("the empty change must be reported as skipped, got %q", msgs.String())
+	}
+	if n := strings.Count(mbox, "\nSubject: [PATCH "); n != 3 {
+		t.Errorf("expected a cover letter and 2 patches, got %d messages:\n%s", n, mbox)
+	}
+	if !strings.Contains(mbox, "Subject: [PATCH 0/2] feat: add B\n") || !strings.Contains(mbox, "applies on top of main:") {
+		t.Errorf("unexpected cover letter:\n%s", mbox)
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestPatchSeries(t *testing.T) {
+	date := time.Date(2026, 3, 1, 12, 30, 0, 0, time.FixedZone("", 3600))
+	alice := jj.Signature{Name: "Alice Ärger", Email: "alice@example.com", Timestamp: date}
+	changes := []*jj.Change{
+		{ChangeID: "a", CommitID: "c1", Author: alice, Description: "feat: add parser\n\nParses things.\n\nJip-Stack: 1/2\nPR-Draft: true"},
+		{ChangeID: "b", CommitID: "c2", Author: alice, Description: "fix: handle the empty input"},
+	}
+	diffs := map[string]string{
+		"a": "diff --git a/p.go b/p.go\n--- a/p.go\n+++ b/p.go\n@@ -1 +1,2 @@\n package p\n+func Parse() {}\n",
+		"b": "diff --git a/p.go b/p.go\n--- a/p.go\n+++ b/p.go\n@@ -1,2 +1,2 @@\n-func Parse() {}\n+func Parse() error { return nil }\n",
+	}
+	sender := jj.Signature{Name: "Bob", Email: "bob@example.com", Timestamp: date}
+	patches := patchSeries(changes, diffs, "main", sender)
+
+	var names []string
+	for _, p := range patches {
+		names = append(names, p.name)
+	}
+	if want := "0000-cover-letter.patch 0001-feat-add-parser.patch 0002-fix-handle-the-empty-input.patch"; strings.Join(names, " ") != want {
+		t.Errorf("names = %v, want %s", names, want)
+	}
+
+	cover := patches[0].content
+	for _, want := range []string{
+		"From 0000000000000000000000000000000000000000 Mon Sep 17 00:00:00 2001\n",
+		"From: \"Bob\" <bob@example.com>\n",
+		"Subject: [PATCH 0/2] fix: handle the empty input\n",
+		"applies on top of main:\n",
+		"  1/2 feat: add parser (+1 −0, 1 file)\n",
+		" 1 file changed, 2 insertions(+), 1 deletion(-)\n",
+	} {
+		if !strings.Contains(cover, want) {
+			t.Errorf("cover letter lacks %q:\n%s", want, cover)
+		}
+	}
+
+	first := patches[1].content
+	for _, want := range []string{
+		"From c1 Mon Sep 17 00:00:00 2001\n",
+		"From: =?utf-8?q?Alice_=C3=84rger?= <alice@example.com>\n",
+		"Date: Sun, 1 Mar 2026 12:30:00 +0100\n",
+		"Subject: [PATCH 1/2] feat: add parser\n",
+		"\n\nParses things.\n\n---\n 1 file changed, 1 insertion(+)\n\ndiff --git a/p.go b/p.go\n",
+		"\n-- \njip ",
+	} {
+		if !strings.Contains(first, want) {
+			t.Errorf("first patch lacks %q:\n%s", want, first)
+		}
+	}
+	if strings.Contains(first, "Jip-Stack") || strings.Contains(first, "PR-Draft") {
+		t.Errorf("patches must not carry jip's trailers:\n%s", first)
+	}
+}
+
+func TestPatchSlug(t *testing.T) {
+	tests := []struct {
+		title, want string
+	}{
+		{"feat: add parser", "feat-add-parser"},
+		{"fix(ui): don't crash...", "fix-ui-don-t-crash"},
+		{"Ünïcode ümlauts", "n-code-mlauts"},
+		{"v1.2_release notes", "v1.2_release-notes"},
+		{strings.Repeat("word ", 20), "word-word-word-word-word-word-word-word-word-word-wo"},
+	}
+	for _, tt := range tests {
+		if got := patchSlug(tt.title); got != tt.want {
+			t.Errorf("patchSlug(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/config"
+	gh "github.com/omarkohl/jip/internal/github"
+)
+
+// githubApp returns the GitHub App jip acts as, or nil when none is
+// configured. The JIP_APP_ID, JIP_APP_PRIVATE_KEY (the key itself or the
+// file holding it) and JIP_APP_INSTALLATION_ID environment variables win
+// over the github-app-id, github-app-key and github-app-installation keys
+// of the global config.
+func githubApp() (*gh.App, error) {
+	cfg, err := config.Load("")
+	if err != nil {
+		return nil, err
+	}
+	setting := func(env, key string) string {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+		return cfg[key]
+	}
+	id := setting("JIP_APP_ID", "github-app-id")
+	if id == "" {
+		return nil, nil
+	}
+	app := &gh.App{}
+	if app.ID, err = strconv.ParseInt(id, 10, 64); err != nil || app.ID <= 0 {
+		return nil, fmt.Errorf("invalid GitHub App ID %q", id)
+	}
+	if installation := setting("JIP_APP_INSTALLATION_ID", "github-app-installation"); installation != "" {
+		if app.InstallationID, err = strconv.ParseInt(installation, 10, 64); err != nil || app.InstallationID <= 0 {
+			return nil, fmt.Errorf("invalid GitHub App installation ID %q", installation)
+		}
+	}
+	key := setting("JIP_APP_PRIVATE_KEY", "github-app-key")
+	switch {
+	case key == "":
+		return nil, fmt.Errorf("GitHub App %d has no private key — set JIP_APP_PRIVATE_KEY or github-app-key", app.ID)
+	case strings.HasPrefix(strings.TrimSpace(key), "-----BEGIN"):
+		app.PrivateKey = []byte(key)
+	default:
+		if rest, ok := strings.CutPrefix(key, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				key = filepath.Join(home, rest)
+			}
+		}
+		if app.PrivateKey, err = os.ReadFile(key); err != nil {
+			return nil, fmt.Errorf("reading the GitHub App private key: %w", err)
+		}
+	}
+	return app, nil
+}
+
+// githubClient returns a client for the repository of remoteURL on host, and
+// how it authenticates: as the configured GitHub App, or else with the
+// host's token. remoteURL may be empty for a client not tied to a
+// repository.
+func githubClient(host, remoteURL string) (*gh.Client, string, error) {
+	app, err := githubApp()
+	if err != nil {
+		return nil, "", err
+	}
+	if app != nil {
+		if remoteURL == "" && app.InstallationID == 0 {
+			return nil, "", fmt.Errorf("GitHub App %d: outside a repository the installation ID is needed — set JIP_APP_INSTALLATION_ID or github-app-installation", app.ID)
+		}
+		client, err := gh.NewAppClient(*app, remoteURL, hostAPIURL(host))
+		if err != nil {
+			return nil, "", err
+		}
+		return client.WithContext(runContext), fmt.Sprintf("GitHub App %d", app.ID), nil
+	}
+	token, source := hostToken(host)
+	if token == "" {
+		return nil, "", notAuthenticated(host)
+	}
+	if remoteURL == "" {
+		return gh.NewUserClient(token, hostAPIURL(host)).WithContext(runContext), source, nil
+	}
+	client, err := gh.NewClient(token, remoteURL, hostAPIURL(host))
+	if err != nil {
+		return nil, "", err
+	}
+	return client.WithContext(runContext), source, nil
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/auth"
+	"github.com/omarkohl/jip/internal/bitbucket"
+	gh "github.com/omarkohl/jip/internal/github"
+)
+
+// hostAPIURL returns the API base URL for host. $GITHUB_API_URL, as set by
+// GitHub Actions, wins for github.com and for the host it names; other hosts
+// get their GitHub Enterprise Server API.
+func hostAPIURL(host string) string {
+	if env := os.Getenv("GITHUB_API_URL"); env != "" && (host == defaultHost || envAPIHost(env) == host) {
+		return env
+	}
+	return gh.APIURLForHost(host)
+}
+
+// envAPIHost returns the host a $GITHUB_API_URL belongs to, without the api.
+// subdomain github.com uses.
+func envAPIHost(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Host), "api.")
+}
+
+// hostToken resolves the token for host. GitHub Actions on GitHub Enterprise
+// Server provides GITHUB_TOKEN with a $GITHUB_API_URL naming the server, which
+// go-gh only accepts for github.com, so that case is recognized here.
+func hostToken(host string) (token, source string) {
+	token, source = auth.ResolveToken(host)
+	if token != "" || host == defaultHost || envAPIHost(os.Getenv("GITHUB_API_URL")) != host {
+		return token, source
+	}
+	for _, name := range []string{"GH_TOKEN", "GITHUB_TOKEN"} {
+		if t := os.Getenv(name); t != "" {
+			return t, name
+		}
+	}
+	return "", ""
+}
+
+// notAuthenticated explains how to authenticate with host.
+func notAuthenticated(host string) error {
+	if host == defaultHost {
+		return fmt.Errorf("not authenticated — run 'jip auth login' or set GH_TOKEN")
+	}
+	return fmt.Errorf("not authenticated with %s — run 'gh auth login --hostname %s' or set GH_ENTERPRISE_TOKEN", host, host)
+}
+
+// remoteHost returns the host of the push and upstream remote URLs, or an
+// error when they differ. Cross-host setups are deliberately unsupported:
+// GitHub, Gitea and Bitbucket open pull requests only between repositories
+// of the same host, so the branches must be pushed there.
+func remoteHost(pushURL, upstreamURL string) (string, error) {
+	host, err := gh.ParseHostFromURL(upstreamURL)
+	if err != nil {
+		return "", err
+	}
+	pushHost, err := gh.ParseHostFromURL(pushURL)
+	if err != nil {
+		return "", err
+	}
+	if pushHost != host {
+		return "", fmt.Errorf("the push remote is on %s but PRs are opened on %s — a pull request cannot come from a repository on another host; push to a fork on %s instead", pushHost, host, host)
+	}
+	return host, nil
+}
+
+// Forges jip can open pull requests on, as named by the forge config key.
+const (
+	forgeGitHub    = "github"
+	forgeGitea     = "gitea" // Gitea and Forgejo
+	forgeBitbucket = "bitbucket"
+)
+
+// giteaHosts are public Gitea and Forgejo instances recognized without
+// configuration.
+var giteaHosts = map[string]bool{
+	"codeberg.org": true,
+	"gitea.com":    true,
+}
+
+// resolveForge returns the forge host runs: configured (the forge config key)
+// when set, otherwise bitbucket for Bitbucket Cloud, gitea for the well-known
+// Gitea and Forgejo instances and github for everything else.
+func resolveForge(host, configured string) (string, error) {
+	switch configured {
+	case forgeGitHub, forgeGitea, forgeBitbucket:
+		return configured, nil
+	case "forgejo":
+		return forgeGitea, nil
+	case "":
+	default:
+		return "", fmt.Errorf("invalid forge %q in config (valid: %s, %s, %s)", configured, forgeGitHub, forgeGitea, forgeBitbucket)
+	}
+	if host == bitbucket.Host {
+		return forgeBitbucket, nil
+	}
+	if giteaHosts[host] {
+		return forgeGitea, nil
+	}
+	return forgeGitHub, nil
+}
+
+// forgeToken resolves the token for host on forge kind, or explains how to
+// provide one.
+func forgeToken(kind, host string) (token, source string, err error) {
+	switch kind {
+	case forgeBitbucket:
+		token, source = auth.ResolveBitbucketToken(host)
+		if token == "" {
+			return "", "", fmt.Errorf("not authenticated with %s — create an access token with pull request write access and set BITBUCKET_TOKEN, or set BITBUCKET_USERNAME and BITBUCKET_APP_PASSWORD", host)
+		}
+		return token, source, nil
+	case forgeGitea:
+		token, source = auth.ResolveGiteaToken(host)
+		if token == "" {
+			return "", "", fmt.Errorf("not authenticated with %s — create an access token with read and write access to repositories and issues and set GITEA_TOKEN", host)
+		}
+		return token, source, nil
+	}
+	token, source = hostToken(host)
+	if token == "" {
+		return "", "", notAuthenticated(host)
+	}
+	return token, source, nil
+}
+
+// webURL returns the web address of host, over plain HTTP only when the
+// remote itself uses it (e.g. a local test server).
+func webURL(remoteURL, host string) string {
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(remoteURL)), "http://") {
+		return "http://" + host
+	}
+	return "https://" + host
+}
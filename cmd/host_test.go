@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/auth"
+	"github.com/zalando/go-keyring"
+)
+
+// isolateTokens hides every token source but the environment variables the
+// test sets.
+func isolateTokens(t *testing.T) {
+	t.Helper()
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	for _, name := range []string{"GH_TOKEN", "GITHUB_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN", "GITHUB_API_URL", "GITEA_TOKEN", "FORGEJO_TOKEN", "BITBUCKET_TOKEN", "BITBUCKET_USERNAME", "BITBUCKET_APP_PASSWORD", "JIP_APP_ID", "JIP_APP_PRIVATE_KEY", "JIP_APP_INSTALLATION_ID"} {
+		t.Setenv(name, "")
+	}
+	keyring.MockInit()
+	old := auth.ConfigDir
+	auth.ConfigDir = t.TempDir()
+	t.Cleanup(func() { auth.ConfigDir = old })
+}
+
+func TestHostAPIURL(t *testing.T) {
+	isolateTokens(t)
+	if got := hostAPIURL("github.com"); got != "" {
+		t.Errorf("github.com = %q, want the default", got)
+	}
+	if got := hostAPIURL("ghe.example.com"); got != "https://ghe.example.com/api/v3/" {
+		t.Errorf("ghe.example.com = %q", got)
+	}
+
+	// GitHub Actions on github.com: the variable applies to github.com only.
+	t.Setenv("GITHUB_API_URL", "https://api.github.com")
+	if got := hostAPIURL("github.com"); got != "https://api.github.com" {
+		t.Errorf("github.com with GITHUB_API_URL = %q", got)
+	}
+	if got := hostAPIURL("ghe.example.com"); got != "https://ghe.example.com/api/v3/" {
+		t.Errorf("ghe.example.com with a github.com GITHUB_API_URL = %q", got)
+	}
+
+	// GitHub Actions on a GitHub Enterprise Server.
+	t.Setenv("GITHUB_API_URL", "https://ghe.example.com/api/v3")
+	if got := hostAPIURL("ghe.example.com"); got != "https://ghe.example.com/api/v3" {
+		t.Errorf("ghe.example.com with its GITHUB_API_URL = %q", got)
+	}
+}
+
+func TestHostToken_PerHost(t *testing.T) {
+	isolateTokens(t)
+	t.Setenv("GH_TOKEN", "github-token")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "ghe-token")
+
+	if token, _ := hostToken("github.com"); token != "github-token" {
+		t.Errorf("github.com token = %q", token)
+	}
+	if token, _ := hostToken("ghe.example.com"); token != "ghe-token" {
+		t.Errorf("ghe.example.com token = %q", token)
+	}
+}
+
+func TestHostToken_ActionsOnEnterprise(t *testing.T) {
+	isolateTokens(t)
+	t.Setenv("GITHUB_TOKEN", "actions-token")
+
+	// Without GITHUB_API_URL naming the server, a github.com token is never
+	// sent to another host.
+	if token, _ := hostToken("ghe.example.com"); token != "" {
+		t.Errorf("token = %q, want none", token)
+	}
+	t.Setenv("GITHUB_API_URL", "https://ghe.example.com/api/v3")
+	if token, source := hostToken("ghe.example.com"); token != "actions-token" || source != "GITHUB_TOKEN" {
+		t.Errorf("token = %q via %q, want the Actions token", token, source)
+	}
+}
+
+func TestRemoteHost(t *testing.T) {
+	host, err := remoteHost("git@ghe.example.com:me/repo.git", "https://ghe.example.com/org/repo.git")
+	if err != nil || host != "ghe.example.com" {
+		t.Errorf("same host = %q, %v", host, err)
+	}
+	_, err = remoteHost("git@github.com:me/repo.git", "https://ghe.example.com/org/repo.git")
+	if err == nil || !strings.Contains(err.Error(), "push remote is on github.com but PRs are opened on ghe.example.com") {
+		t.Errorf("mixed hosts: got %v", err)
+	}
+}
+
+func TestNotAuthenticated(t *testing.T) {
+	if err := notAuthenticated("ghe.example.com"); !strings.Contains(err.Error(), "gh auth login --hostname ghe.example.com") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestResolveForge(t *testing.T) {
+	for _, tc := range []struct {
+		host, configured, want string
+	}{
+		{"github.com", "", forgeGitHub},
+		{"ghe.example.com", "", forgeGitHub},
+		{"codeberg.org", "", forgeGitea},
+		{"git.example.com", "gitea", forgeGitea},
+		{"git.example.com", "forgejo", forgeGitea},
+		{"codeberg.org", "github", forgeGitHub},
+		{"bitbucket.org", "", forgeBitbucket},
+	} {
+		got, err := resolveForge(tc.host, tc.configured)
+		if err != nil || got != tc.want {
+			t.Errorf("resolveForge(%q, %q) = %q, %v; want %q", tc.host, tc.configured, got, err, tc.want)
+		}
+	}
+	if _, err := resolveForge("git.example.com", "gitlab"); err == nil {
+		t.Error("expected an error for an unknown forge")
+	}
+}
+
+func TestForgeToken_Gitea(t *testing.T) {
+	isolateTokens(t)
+	t.Setenv("GH_TOKEN", "github-token")
+
+	if _, _, err := forgeToken(forgeGitea, "codeberg.org"); err == nil || !strings.Contains(err.Error(), "GITEA_TOKEN") {
+		t.Errorf("err = %v, want a hint about GITEA_TOKEN", err)
+	}
+	t.Setenv("FORGEJO_TOKEN", "forgejo-token")
+	if token, source, err := forgeToken(forgeGitea, "codeberg.org"); err != nil || token != "forgejo-token" || source != "FORGEJO_TOKEN" {
+		t.Errorf("got %q from %q, %v", token, source, err)
+	}
+	if token, _, _ := forgeToken(forgeGitHub, "github.com"); token != "github-token" {
+		t.Errorf("github.com token = %q", token)
+	}
+}
+
+func TestForgeToken_Bitbucket(t *testing.T) {
+	isolateTokens(t)
+	t.Setenv("BITBUCKET_USERNAME", "alice")
+
+	if _, _, err := forgeToken(forgeBitbucket, "bitbucket.org"); err == nil || !strings.Contains(err.Error(), "BITBUCKET_TOKEN") {
+		t.Errorf("err = %v, want a hint about BITBUCKET_TOKEN", err)
+	}
+	t.Setenv("BITBUCKET_APP_PASSWORD", "secret")
+	if token, _, err := forgeToken(forgeBitbucket, "bitbucket.org"); err != nil || token != "alice:secret" {
+		t.Errorf("got %q, %v", token, err)
+	}
+	t.Setenv("BITBUCKET_TOKEN", "access-token")
+	if token, source, err := forgeToken(forgeBitbucket, "bitbucket.org"); err != nil || token != "access-token" || source != "BITBUCKET_TOKEN" {
+		t.Errorf("got %q from %q, %v", token, source, err)
+	}
+}
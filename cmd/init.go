@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	gh "github.com/omarkohl/jip/internal/github"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a .jip.toml for this repository",
+	Long: `Init detects how this repository is set up and writes a .jip.toml with
+matching defaults: the push remote (origin, or the only remote), the upstream
+remote when origin is a fork of another remote's repository, and the base
+branch the trunk() revset points at. Commit the file to share the defaults
+with everyone using jip on the repository.
+
+With --aliases, init also adds a stack() revset alias to the repository's jj
+config, selecting what jip send sends by default: jip send 'stack()'.
+
+Init only reads the last fetched state; it neither fetches nor talks to
+GitHub.`,
+	Args: cobra.NoArgs,
+	RunE: runInit,
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().String("remote", "", "Push remote name (default: detected)")
+	initCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (default: detected)")
+	initCmd.Flags().Bool("aliases", false, "Also add a stack() revset alias to the repository's jj config")
+	initCmd.Flags().Bool("force", false, "Overwrite an existing .jip.toml and stack() alias")
+	initCmd.Flags().BoolP("dry-run", "n", false, "Print the configuration instead of writing it")
+	addNoLockFlag(initCmd)
+}
+
+// stackAliasKey is the jj config key of the revset alias init creates, and
+// stackAlias its definition: the changes jip send sends without arguments.
+const (
+	stackAliasKey = `revset-aliases."stack()"`
+	stackAlias    = "trunk()..@-"
+)
+
+// initSettings are the repository settings init detected.
+type initSettings struct {
+	remote   string
+	upstream string // "" when PRs are opened in the push remote's repository
+	base     string // "" when trunk() does not resolve to a branch
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	remote, _ := cmd.Flags().GetString("remote")
+	upstream, _ := cmd.Flags().GetString("upstream")
+	aliases, _ := cmd.Flags().GetBool("aliases")
+	force, _ := cmd.Flags().GetBool("force")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	w := cmd.OutOrStdout()
+	if !dryRun {
+		release, err := lockRepo(cmd, repoRoot, w)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	settings, err := detectInit(runner, remote, upstream, w)
+	if err != nil {
+		return err
+	}
+	content := renderInitConfig(settings)
+
+	path := filepath.Join(repoRoot, ".jip.toml")
+	if dryRun {
+		_, _ = fmt.Fprintf(w, "\nDry run — would write %s:\n\n%s", path, content)
+	} else {
+		if _, err := os.Stat(path); err == nil && !force {
+			return fmt.Errorf("%s already exists — pass --force to overwrite it", path)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+		_, _ = fmt.Fprintf(w, "\nWrote %s:\n\n%s", path, content)
+	}
+
+	if aliases {
+		if err := initStackAlias(runner, force, dryRun, w); err != nil {
+			return err
+		}
+	}
+	if !dryRun {
+		_, _ = fmt.Fprintln(w, "\nCommit .jip.toml to share it; put personal overrides in .jip.local.toml and add it to .gitignore.")
+	}
+	return nil
+}
+
+// detectInit works out the settings to write. remote and upstream, when
+// non-empty, are taken as given instead of detected.
+func detectInit(runner jj.Runner, remote, upstream string, w io.Writer) (initSettings, error) {
+	remotes, err := gitRemotes(runner)
+	if err != nil {
+		return initSettings{}, err
+	}
+	s := initSettings{remote: remote, upstream: upstream}
+	if s.remote == "" {
+		if s.remote, err = detectPushRemote(remotes); err != nil {
+			return initSettings{}, err
+		}
+	} else if _, ok := remotes[s.remote]; !ok {
+		return initSettings{}, fmt.Errorf("remote %q not found (available: %v)", s.remote, remotes)
+	}
+	_, _ = fmt.Fprintf(w, "Push remote: %s (%s)\n", s.remote, remotes[s.remote])
+	if s.upstream == "" {
+		s.upstream = detectUpstream(remotes, s.remote)
+	}
+	if s.upstream != "" {
+		_, _ = fmt.Fprintf(w, "Upstream:    %s — PRs are opened there from your fork\n", s.upstream)
+	}
+
+	baseRemote := s.remote
+	if _, ok := remotes[s.upstream]; ok {
+		baseRemote = s.upstream
+	}
+	bookmarkData, err := runner.BookmarkList()
+	if err != nil {
+		return initSettings{}, fmt.Errorf("listing bookmarks: %w", err)
+	}
+	bookmarks, err := jj.ParseBookmarkList(bookmarkData)
+	if err != nil {
+		return initSettings{}, fmt.Errorf("parsing bookmarks: %w", err)
+	}
+	if s.base, err = jj.ResolveBaseBranch(runner, "trunk()", bookmarks, baseRemote); err != nil {
+		_, _ = fmt.Fprintf(w, "Base branch: not detected (%v) — jip will use trunk()\n", err)
+		s.base = ""
+	} else {
+		_, _ = fmt.Fprintf(w, "Base branch: %s\n", s.base)
+	}
+	return s, nil
+}
+
+// detectPushRemote picks origin, or the only remote there is.
+func detectPushRemote(remotes map[string]string) (string, error) {
+	if _, ok := remotes["origin"]; ok {
+		return "origin", nil
+	}
+	if len(remotes) == 1 {
+		for name := range remotes {
+			return name, nil
+		}
+	}
+	names := slices.Sorted(maps.Keys(remotes))
+	return "", errors.New("several remotes and none named origin (" + strings.Join(names, ", ") + ") — pass --remote")
+}
+
+// detectUpstream returns the remote PRs should be opened in when remote is a
+// fork: a remote named upstream, or else a remote with the same repository
+// name under another owner. It returns "" for a non-fork setup.
+func detectUpstream(remotes map[string]string, remote string) string {
+	if _, ok := remotes["upstream"]; ok && remote != "upstream" {
+		return "upstream"
+	}
+	owner, repo, err := gh.ParseRepoFromURL(remotes[remote])
+	if err != nil {
+		return ""
+	}
+	for _, name := range slices.Sorted(maps.Keys(remotes)) {
+		o, r, err := gh.ParseRepoFromURL(remotes[name])
+		if err == nil && name != remote && strings.EqualFold(r, repo) && !strings.EqualFold(o, owner) {
+			return name
+		}
+	}
+	return ""
+}
+
+// renderInitConfig renders the .jip.toml for s.
+func renderInitConfig(s initSettings) string {
+	var b strings.Builder
+	b.WriteString("# jip defaults for this repository, written by jip init. Command-line\n")
+	b.WriteString("# flags override them; personal overrides go in .jip.local.toml.\n")
+	b.WriteString("# Keys mirror the jip send flags (see jip send --help).\n\n")
+	if s.base != "" {
+		fmt.Fprintf(&b, "base = %q\n", s.base)
+	}
+	fmt.Fprintf(&b, "remote = %q\n", s.remote)
+	if s.upstream != "" {
+		fmt.Fprintf(&b, "upstream = %q\n", s.upstream)
+	}
+	return b.String()
+}
+
+// initStackAlias adds the stack() revset alias to the repository's jj
+// config, leaving a different existing definition alone unless force is set.
+func initStackAlias(runner jj.Runner, force, dryRun bool, w io.Writer) error {
+	existing, err := runner.ConfigGet(stackAliasKey)
+	switch {
+	case err == nil && existing == stackAlias:
+		_, _ = fmt.Fprintln(w, "\nThe stack() revset alias is already set.")
+		return nil
+	case err == nil && !force:
+		_, _ = fmt.Fprintf(w, "\nKeeping your stack() revset alias (%s) — pass --force to replace it.\n", existing)
+		return nil
+	}
+	if dryRun {
+		_, _ = fmt.Fprintf(w, "\nWould set the jj repository config %s = %q\n", stackAliasKey, stackAlias)
+		return nil
+	}
+	if err := runner.ConfigSetRepo(stackAliasKey, stackAlias); err != nil {
+		return fmt.Errorf("adding the stack() revset alias: %w", err)
+	}
+	_, _ = fmt.Fprintf(w, "\nAdded the revset alias stack() = %s to the repository's jj config.\n", stackAlias)
+	return nil
+}
@@ -0,0 +1,40 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_InitDetectsAndAddsAlias(t *testing.T) {
+	checkJJ(t)
+
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	var buf bytes.Buffer
+	settings, err := detectInit(runner, "", "", &buf)
+	if err != nil {
+		t.Fatalf("detectInit failed: %v\n%s", err, buf.String())
+	}
+	if settings.remote != "origin" || settings.upstream != "" || settings.base != "main" {
+		t.Errorf("detected %+v, want origin without upstream, base main\n%s", settings, buf.String())
+	}
+
+	if err := initStackAlias(runner, false, false, &buf); err != nil {
+		t.Fatalf("initStackAlias failed: %v", err)
+	}
+	if got, err := runner.ConfigGet(stackAliasKey); err != nil || got != stackAlias {
+		t.Errorf("alias = %q, %v; want %q", got, err, stackAlias)
+	}
+
+	// The alias is what send resolves by default.
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	dags, err := jj.ResolveStacks(runner, []string{"stack()"}, "main")
+	if err != nil || len(dags) != 1 || len(dags[0].Changes) != 1 {
+		t.Errorf("stack() resolved to %v, %v", dags, err)
+	}
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/config"
+)
+
+func TestDetectPushRemote(t *testing.T) {
+	if got, err := detectPushRemote(map[string]string{"origin": "a", "upstream": "b"}); err != nil || got != "origin" {
+		t.Errorf("got %q, %v; want origin", got, err)
+	}
+	if got, err := detectPushRemote(map[string]string{"fork": "a"}); err != nil || got != "fork" {
+		t.Errorf("got %q, %v; want the only remote", got, err)
+	}
+	if _, err := detectPushRemote(map[string]string{"a": "a", "b": "b"}); err == nil {
+		t.Error("expected an error for several remotes without origin")
+	}
+}
+
+func TestDetectUpstream(t *testing.T) {
+	tests := []struct {
+		name    string
+		remotes map[string]string
+		want    string
+	}{
+		{"single remote", map[string]string{"origin": "git@github.com:me/proj.git"}, ""},
+		{"named upstream", map[string]string{"origin": "git@github.com:me/proj.git", "upstream": "https://github.com/org/proj.git"}, "upstream"},
+		{"fork by repository name", map[string]string{"origin": "git@github.com:me/proj.git", "org": "https://github.com/org/proj.git"}, "org"},
+		{"unrelated remote", map[string]string{"origin": "git@github.com:me/proj.git", "tools": "https://github.com/org/tools.git"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectUpstream(tt.remotes, "origin"); got != tt.want {
+				t.Errorf("detectUpstream() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderInitConfig_Loads(t *testing.T) {
+	config.Dir = t.TempDir()
+	defer func() { config.Dir = "" }()
+	root := t.TempDir()
+	content := renderInitConfig(initSettings{remote: "origin", upstream: "upstream", base: "main"})
+	if err := os.WriteFile(filepath.Join(root, ".jip.toml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(root)
+	if err != nil {
+		t.Fatalf("the written config does not load: %v\n%s", err, content)
+	}
+	if cfg["base"] != "main" || cfg["remote"] != "origin" || cfg["upstream"] != "upstream" {
+		t.Errorf("loaded %v", cfg)
+	}
+
+	// Undetected settings are left to the defaults.
+	content = renderInitConfig(initSettings{remote: "origin"})
+	if err := os.WriteFile(filepath.Join(root, ".jip.toml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _ = config.Load(root); len(cfg) != 1 {
+		t.Errorf("expected only remote, loaded %v", cfg)
+	}
+}
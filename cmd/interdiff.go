@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// interdiffThreshold is the --interdiff-threshold: the size a "changes
+// since" diff must exceed to be posted in full. Smaller diffs only get a
+// comment with the compare link. Zero fields are not checked; with both
+// zero, every diff is posted.
+type interdiffThreshold struct {
+	lines int // changed (added or removed) lines
+	files int // touched files
+}
+
+// parseInterdiffThreshold parses an --interdiff-threshold value:
+// comma-separated lines=N and files=M, or a bare N for lines=N. "" sets no
+// threshold.
+func parseInterdiffThreshold(s string) (interdiffThreshold, error) {
+	var t interdiffThreshold
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			key, value = "lines", part
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return interdiffThreshold{}, fmt.Errorf("invalid --interdiff-threshold value %q: %q is not a count", s, value)
+		}
+		switch strings.TrimSpace(key) {
+		case "lines":
+			t.lines = n
+		case "files":
+			t.files = n
+		default:
+			return interdiffThreshold{}, fmt.Errorf("invalid --interdiff-threshold value %q (valid: lines=N, files=M)", s)
+		}
+	}
+	return t, nil
+}
+
+// exceeded reports whether a diff changing lines lines in files files is
+// large enough to be posted in full.
+func (t interdiffThreshold) exceeded(lines, files int) bool {
+	if t.lines == 0 && t.files == 0 {
+		return true
+	}
+	return (t.lines > 0 && lines > t.lines) || (t.files > 0 && files > t.files)
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import "testing"
+
+func TestParseInterdiffThreshold(t *testing.T) {
+	tests := []struct {
+		in   string
+		want interdiffThreshold
+	}{
+		{"", interdiffThreshold{}},
+		{"50", interdiffThreshold{lines: 50}},
+		{"lines=50", interdiffThreshold{lines: 50}},
+		{"files=3", interdiffThreshold{files: 3}},
+		{"lines=50, files=3", interdiffThreshold{lines: 50, files: 3}},
+	}
+	for _, tt := range tests {
+		got, err := parseInterdiffThreshold(tt.in)
+		if err != nil {
+			t.Errorf("parseInterdiffThreshold(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseInterdiffThreshold(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+	for _, bad := range []string{"lots", "lines=-1", "hunks=2", "files="} {
+		if _, err := parseInterdiffThreshold(bad); err == nil {
+			t.Errorf("parseInterdiffThreshold(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestInterdiffThresholdExceeded(t *testing.T) {
+	tests := []struct {
+		t            interdiffThreshold
+		lines, files int
+		want         bool
+	}{
+		{interdiffThreshold{}, 1, 1, true},
+		{interdiffThreshold{lines: 50}, 50, 9, false},
+		{interdiffThreshold{lines: 50}, 51, 1, true},
+		{interdiffThreshold{files: 3}, 500, 3, false},
+		{interdiffThreshold{files: 3}, 2, 4, true},
+		{interdiffThreshold{lines: 50, files: 3}, 10, 4, true},
+		{interdiffThreshold{lines: 50, files: 3}, 10, 2, false},
+	}
+	for _, tt := range tests {
+		if got := tt.t.exceeded(tt.lines, tt.files); got != tt.want {
+			t.Errorf("%+v.exceeded(%d, %d) = %v, want %v", tt.t, tt.lines, tt.files, got, tt.want)
+		}
+	}
+}
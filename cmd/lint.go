@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// Values of --title-lint.
+const (
+	titleLintError = "error"
+	titleLintWarn  = "warn"
+)
+
+// titlePatternConventional selects the built-in conventional commit check
+// for --title-pattern.
+const titlePatternConventional = "conventional"
+
+// conventionalTitle matches a conventional commit subject: a type, an
+// optional scope, an optional "!" and a description, e.g. "feat(cli)!: add x".
+var conventionalTitle = regexp.MustCompile(`^[a-z]+(\([^()]+\))?!?: \S`)
+
+// titleLinter checks the titles of the changes to send, which become the PR
+// titles.
+type titleLinter struct {
+	pattern  *regexp.Regexp
+	name     string // how the pattern is described to the user
+	warnOnly bool
+}
+
+// newTitleLinter returns the linter for a --title-pattern and --title-lint
+// pair, or nil when pattern is empty.
+func newTitleLinter(pattern, mode string) (*titleLinter, error) {
+	if mode != titleLintError && mode != titleLintWarn {
+		return nil, fmt.Errorf("invalid --title-lint value %q (valid: %s, %s)", mode, titleLintError, titleLintWarn)
+	}
+	l := &titleLinter{warnOnly: mode == titleLintWarn}
+	switch pattern {
+	case "":
+		return nil, nil
+	case titlePatternConventional:
+		l.pattern, l.name = conventionalTitle, "the conventional commit format"
+	default:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --title-pattern: %w", err)
+		}
+		l.pattern, l.name = re, fmt.Sprintf("--title-pattern %s", pattern)
+	}
+	return l, nil
+}
+
+// check returns the changes of dags whose PR title does not match.
+func (l *titleLinter) check(dags []*jj.ChangeDAG) []*jj.Change {
+	var bad []*jj.Change
+	for _, dag := range dags {
+		for _, c := range dag.Changes {
+			if !l.pattern.MatchString(c.PRTitle()) {
+				bad = append(bad, c)
+			}
+		}
+	}
+	return bad
+}
+
+// lintTitles reports the changes of dags whose title does not match. It
+// returns an error, before anything is pushed, unless the linter only warns.
+func lintTitles(l *titleLinter, dags []*jj.ChangeDAG, w io.Writer) error {
+	bad := l.check(dags)
+	if len(bad) == 0 {
+		return nil
+	}
+	prefix := ""
+	if l.warnOnly {
+		prefix = "warning: "
+	}
+	_, _ = fmt.Fprintf(w, "%s%d change title(s) do not match %s:\n", prefix, len(bad), l.name)
+	for _, c := range bad {
+		_, _ = fmt.Fprintf(w, "  %s  %s\n", c.Short(), c.PRTitle())
+	}
+	if l.warnOnly {
+		return nil
+	}
+	return fmt.Errorf("%d change title(s) do not match %s — reword them with jj describe, or pass --no-verify", len(bad), l.name)
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func lintDAG(titles ...string) []*jj.ChangeDAG {
+	dag := &jj.ChangeDAG{ByID: make(map[string]*jj.Change)}
+	for i, title := range titles {
+		c := &jj.Change{ChangeID: strings.Repeat(string(rune('a'+i)), 12), Description: title + "\n\nbody"}
+		dag.Changes = append(dag.Changes, c)
+		dag.ByID[c.ChangeID] = c
+	}
+	return []*jj.ChangeDAG{dag}
+}
+
+func TestTitleLinter_Conventional(t *testing.T) {
+	l, err := newTitleLinter(titlePatternConventional, titleLintError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := l.check(lintDAG("feat: add x", "fix(cli)!: drop y", "Add z", "feat:no space", "chore(): empty scope"))
+	var got []string
+	for _, c := range bad {
+		got = append(got, c.Title())
+	}
+	if want := "Add z|feat:no space|chore(): empty scope"; strings.Join(got, "|") != want {
+		t.Errorf("got %q, want %q", strings.Join(got, "|"), want)
+	}
+}
+
+func TestTitleLinter_Regex(t *testing.T) {
+	l, err := newTitleLinter(`^[A-Z]+-\d+ `, titleLintError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bad := l.check(lintDAG("JIRA-12 fix it", "fix it")); len(bad) != 1 || bad[0].Title() != "fix it" {
+		t.Errorf("got %v", bad)
+	}
+	if _, err := newTitleLinter("(", titleLintError); err == nil {
+		t.Error("expected an error for an invalid regex")
+	}
+	if _, err := newTitleLinter("conventional", "loud"); err == nil {
+		t.Error("expected an error for an invalid --title-lint")
+	}
+	if l, err := newTitleLinter("", titleLintWarn); l != nil || err != nil {
+		t.Errorf("got %v, %v without a pattern, want nil, nil", l, err)
+	}
+}
+
+func TestLintTitles(t *testing.T) {
+	dags := lintDAG("feat: ok", "not ok")
+
+	var buf bytes.Buffer
+	l, _ := newTitleLinter(titlePatternConventional, titleLintError)
+	err := lintTitles(l, dags, &buf)
+	if err == nil || !strings.Contains(err.Error(), "--no-verify") {
+		t.Errorf("err = %v, want the --no-verify hint", err)
+	}
+	if !strings.Contains(buf.String(), "  bbbbbbbbbbbb  not ok\n") {
+		t.Errorf("output lacks the offending change:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	l, _ = newTitleLinter(titlePatternConventional, titleLintWarn)
+	if err := lintTitles(l, dags, &buf); err != nil {
+		t.Errorf("warn mode returned %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "warning: 1 change title(s) do not match the conventional commit format:") {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestTitleLinter_PRTitleOverride(t *testing.T) {
+	l, _ := newTitleLinter(titlePatternConventional, titleLintError)
+	dags := lintDAG("Add z", "feat: add y")
+	dags[0].Changes[0].Description += "\n\nPR-Title: feat: add z"
+	dags[0].Changes[1].Description += "\n\nPR-Title: Add y"
+
+	var buf bytes.Buffer
+	if err := lintTitles(l, dags, &buf); err == nil {
+		t.Fatal("expected the overridden non-conventional PR title to fail")
+	}
+	if want := "1 change title(s) do not match the conventional commit format:\n  bbbbbbbbbbbb  Add y\n"; buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
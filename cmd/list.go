@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/i18n"
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the stacks jip has sent, with their PRs and CI checks",
+	Long: `List shows the stacks jip has sent from this repository, as its state file
+in .jj/jip records them at their last send, the most recent first. Each
+change is listed with its PR, whether the PR is open, a draft, merged or
+closed, and the combined status of the CI checks on an open PR's head
+commit (pass, fail, pending, or none).
+
+Stacks whose PRs are all merged or closed are left out; --all lists them
+too. List only reads and changes nothing.`,
+	Args: cobra.NoArgs,
+	RunE: runList,
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().Bool("all", false, "Also list the stacks whose PRs are all merged or closed")
+	listCmd.Flags().String("remote", "origin", "Push remote name")
+	listCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (where PRs are opened)")
+
+	_ = listCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = listCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	st, err := state.Load(state.Path(repoRoot))
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+		return err
+	}
+
+	all, _ := cmd.Flags().GetBool("all")
+	remote, _ := cmd.Flags().GetString("remote")
+	upstream, _ := cmd.Flags().GetString("upstream")
+	w := cmd.OutOrStdout()
+
+	rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], w)
+	if err != nil {
+		return err
+	}
+	changes := recordedChanges(runner, st, w)
+	return executeList(rc.client, st, changes, all, colorsFor(w), w)
+}
+
+// recordedChanges returns the changes of the stacks recorded in st that are
+// still in the repository, by change ID. A failed lookup only costs the
+// titles, so it warns and returns nil.
+func recordedChanges(runner jj.Runner, st *state.State, w io.Writer) map[string]*jj.Change {
+	var revsets []string
+	for _, stack := range recordedStacks(st.Changes) {
+		for _, id := range stack {
+			revsets = append(revsets, "present("+id+")")
+		}
+	}
+	if len(revsets) == 0 {
+		return nil
+	}
+	data, err := runner.Log(strings.Join(revsets, " | "))
+	if err == nil {
+		var changes []jj.Change
+		if changes, err = jj.ParseChanges(data); err == nil {
+			byID := make(map[string]*jj.Change, len(changes))
+			for i := range changes {
+				byID[changes[i].ChangeID] = &changes[i]
+			}
+			return byID
+		}
+	}
+	_, _ = fmt.Fprintf(w, "warning: could not look up the recorded changes: %v\n", err)
+	return nil
+}
+
+// executeList prints the stacks recorded in st, bottom first, with the PR of
+// each change as the forge reports it now. changes holds the recorded changes
+// still in the repository, for their titles.
+func executeList(client forge.Service, st *state.State, changes map[string]*jj.Change, all bool, c colors, w io.Writer) error {
+	stacks := recordedStacks(st.Changes)
+	if len(stacks) == 0 {
+		_, _ = fmt.Fprintln(w, "No stacks yet — jip has no record of a send in this repository.")
+		return nil
+	}
+
+	var branches []string
+	for _, stack := range stacks {
+		for _, id := range stack {
+			if b := st.Changes[id].Bookmark; b != "" {
+				branches = append(branches, b)
+			}
+		}
+	}
+	slices.Sort(branches)
+	branches = slices.Compact(branches)
+	open, err := client.LookupPRsByBranch(branches)
+	if err != nil {
+		return fmt.Errorf("looking up PRs: %w", err)
+	}
+	var rest []string
+	var numbers []int
+	for _, b := range branches {
+		if pr := open[b]; pr != nil {
+			numbers = append(numbers, pr.Number)
+		} else {
+			rest = append(rest, b)
+		}
+	}
+	closed := make(map[string]*forge.PRInfo)
+	if len(rest) > 0 {
+		if closed, err = client.LookupClosedPRsByBranch(rest); err != nil {
+			return fmt.Errorf("looking up merged and closed PRs: %w", err)
+		}
+	}
+	checks := lookupChecks(client, numbers, w)
+
+	listed := 0
+	for _, stack := range stacks {
+		isOpen := func(id string) bool { return open[st.Changes[id].Bookmark] != nil }
+		if !all && !slices.ContainsFunc(stack, isOpen) {
+			continue
+		}
+		listed++
+		_, _ = fmt.Fprintln(w)
+		for _, id := range stack {
+			rec := st.Changes[id]
+			change := changes[id]
+			if change == nil {
+				change = &jj.Change{ChangeID: id}
+			}
+			title := change.Title()
+			if changes[id] == nil {
+				title = c.dim("(no longer in this repository)")
+			}
+			pr := open[rec.Bookmark]
+			if pr == nil {
+				pr = closed[rec.Bookmark]
+			}
+			if pr == nil {
+				label := "-"
+				if rec.PRNumber != 0 {
+					label = fmt.Sprintf("#%d", rec.PRNumber)
+				}
+				_, _ = fmt.Fprintf(w, "  %-5s %s %s  %s\n", label, c.yellow(fmt.Sprintf("%-23s", i18n.T("no PR"))), change.Short(), title)
+				continue
+			}
+			// Padded before coloring: escape codes take no room on screen.
+			var prState, checksText string
+			switch {
+			case pr.State == "MERGED":
+				prState, checksText = c.dim("merged"), fmt.Sprintf("%-15s", "")
+			case pr.State != "OPEN":
+				prState, checksText = c.dim("closed"), fmt.Sprintf("%-15s", "")
+			default:
+				prState = c.green("open  ")
+				if pr.IsDraft {
+					prState = c.dim("draft ")
+				}
+				checksText = fmt.Sprintf("%-15s", "checks: ?")
+				if checks != nil {
+					status := checks[pr.Number]
+					checksText = c.checks(fmt.Sprintf("%-15s", "checks: "+checksLabel(status)), status)
+				}
+			}
+			_, _ = fmt.Fprintf(w, "  #%-4d %s  %s %s  %s\n", pr.Number, prState, checksText, change.Short(), title)
+		}
+	}
+	if listed == 0 {
+		_, _ = fmt.Fprintln(w, "No open stacks — pass --all to list the stacks whose PRs are all merged or closed.")
+	}
+	return nil
+}
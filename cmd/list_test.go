@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestExecuteList(t *testing.T) {
+	st, err := state.Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two stacks: a → b → c, sent last, with c not sent as a PR (yet), and
+	// d → e, whose PRs were both merged.
+	sent := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	for n, id := range []string{"d", "e", "a", "b", "c"} {
+		pr := n + 1
+		if id == "c" {
+			pr = 0
+		}
+		st.RecordSend(id, "jip/"+id, pr, "c"+id, sent.Add(time.Duration(n)*time.Hour))
+	}
+	st.RecordStack("a", []string{"a"})
+	st.RecordStack("b", []string{"a", "b"})
+	st.RecordStack("c", []string{"a", "b", "c"})
+	st.RecordStack("d", []string{"d"})
+	st.RecordStack("e", []string{"d", "e"})
+
+	fake := forgetest.New("owner", "repo")
+	fake.PRs[1] = &forge.PRInfo{Number: 1, State: "MERGED", HeadRefName: "jip/d"}
+	fake.PRs[2] = &forge.PRInfo{Number: 2, State: "MERGED", HeadRefName: "jip/e"}
+	fake.PRs[3] = &forge.PRInfo{Number: 3, State: "OPEN", HeadRefName: "jip/a"}
+	fake.PRs[4] = &forge.PRInfo{Number: 4, State: "OPEN", HeadRefName: "jip/b", IsDraft: true}
+	fake.Checks = map[int]string{3: "pass"}
+	changes := map[string]*jj.Change{
+		"a": {ChangeID: "a", ShortID: "aaaa", Description: "feat: add A"},
+		"b": {ChangeID: "b", ShortID: "bbbb", Description: "feat: add B"},
+		"c": {ChangeID: "c", ShortID: "cccc", Description: "feat: add C"},
+	}
+
+	var out bytes.Buffer
+	if err := executeList(fake, st, changes, false, colors{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	want := `
+  #3    open    checks: pass    aaaa  feat: add A
+  #4    draft   checks: none    bbbb  feat: add B
+  -     no PR                   cccc  feat: add C
+`
+	if out.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if err := executeList(fake, st, changes, true, colors{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"  #1    merged                  d  (no longer in this repository)\n",
+		"  #2    merged                  e  (no longer in this repository)\n",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("--all output lacks %q:\n%s", line, out.String())
+		}
+	}
+	if strings.Index(out.String(), "#3") > strings.Index(out.String(), "#1") {
+		t.Errorf("the stack sent last must come first:\n%s", out.String())
+	}
+}
+
+func TestExecuteList_NoneOpen(t *testing.T) {
+	st, err := state.Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := executeList(forgetest.New("owner", "repo"), st, nil, false, colors{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "No stacks yet") {
+		t.Errorf("empty state: %s", out.String())
+	}
+
+	st.RecordSend("a", "jip/a", 1, "ca", time.Now())
+	st.RecordStack("a", []string{"a"})
+	out.Reset()
+	if err := executeList(forgetest.New("owner", "repo"), st, nil, false, colors{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "No open stacks") {
+		t.Errorf("no open stack: %s", out.String())
+	}
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/omarkohl/jip/internal/lock"
+	"github.com/spf13/cobra"
+)
+
+// addNoLockFlag adds --no-lock to a command that modifies the repository.
+func addNoLockFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("no-lock", false, "Don't take the repository lock that keeps concurrent jip commands apart")
+}
+
+// lockRepo takes the repository lock for cmd unless --no-lock is given. The
+// returned function releases it; call it when the command is done.
+func lockRepo(cmd *cobra.Command, repoRoot string, w io.Writer) (release func(), err error) {
+	if noLock, _ := cmd.Flags().GetBool("no-lock"); noLock {
+		return func() {}, nil
+	}
+	path := lock.Path(repoRoot)
+	l, err := lock.Acquire(path, cmd.Name())
+	if err != nil {
+		var locked *lock.LockedError
+		if errors.As(err, &locked) {
+			return nil, fmt.Errorf("%w — wait for it to finish, or if it is gone delete %s (or pass --no-lock)", err, path)
+		}
+		return nil, err
+	}
+	return func() {
+		if err := l.Release(); err != nil {
+			_, _ = fmt.Fprintf(w, "warning: %v\n", err)
+		}
+	}, nil
+}
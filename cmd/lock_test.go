@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestLockRepo(t *testing.T) {
+	root := t.TempDir()
+	newCmd := func() *cobra.Command {
+		c := &cobra.Command{Use: "send"}
+		addNoLockFlag(c)
+		return c
+	}
+
+	release, err := lockRepo(newCmd(), root, io.Discard)
+	if err != nil {
+		t.Fatalf("lockRepo: %v", err)
+	}
+
+	_, err = lockRepo(newCmd(), root, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "another jip send") || !strings.Contains(err.Error(), "--no-lock") {
+		t.Fatalf("second lockRepo: expected a locked error with a hint, got %v", err)
+	}
+
+	noLock := newCmd()
+	_ = noLock.Flags().Set("no-lock", "true")
+	releaseNoLock, err := lockRepo(noLock, root, io.Discard)
+	if err != nil {
+		t.Fatalf("lockRepo --no-lock: %v", err)
+	}
+	releaseNoLock()
+
+	release()
+	release, err = lockRepo(newCmd(), root, io.Discard)
+	if err != nil {
+		t.Fatalf("lockRepo after release: %v", err)
+	}
+	release()
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Modes of the --open flag.
+const (
+	openNone = "none" // open nothing
+	openTop  = "top"  // the top PR of each stack a PR was created in
+	openAll  = "all"  // every created PR
+)
+
+func checkOpenMode(mode string) error {
+	switch mode {
+	case openNone, openTop, openAll:
+		return nil
+	}
+	return fmt.Errorf("invalid --open value %q (valid: %s, %s, %s)", mode, openTop, openAll, openNone)
+}
+
+// prsToOpen returns the URLs of the PRs of states that --open=mode opens:
+// with all, those created by the send; with top, the PR of the top change of
+// each stack the send created a PR in, so one tab shows the whole stack
+// navigation.
+func prsToOpen(states []changeState, mode string) []string {
+	var urls []string
+	switch mode {
+	case openAll:
+		for _, s := range states {
+			if s.isNew && s.pr.URL != "" {
+				urls = append(urls, s.pr.URL)
+			}
+		}
+	case openTop:
+		idx := make(map[string]int, len(states))
+		hasChild := make(map[string]bool, len(states))
+		for i, s := range states {
+			idx[s.change.ChangeID] = i
+		}
+		for _, s := range states {
+			for _, pid := range s.change.ParentIDs {
+				hasChild[pid] = true
+			}
+		}
+		for _, s := range states {
+			if hasChild[s.change.ChangeID] || s.pr.URL == "" {
+				continue
+			}
+			// Walk down the stack for a created PR.
+			created := false
+			seen := make(map[string]bool)
+			todo := []string{s.change.ChangeID}
+			for len(todo) > 0 && !created {
+				id := todo[0]
+				todo = todo[1:]
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				below := states[idx[id]]
+				created = below.isNew
+				for _, pid := range below.change.ParentIDs {
+					if _, ok := idx[pid]; ok {
+						todo = append(todo, pid)
+					}
+				}
+			}
+			if created {
+				urls = append(urls, s.pr.URL)
+			}
+		}
+	}
+	return urls
+}
+
+// openBrowser starts the browser named by $BROWSER on url, or else the
+// default browser: with open on macOS, rundll32 on Windows and xdg-open
+// elsewhere.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	if args := strings.Fields(os.Getenv("BROWSER")); len(args) > 0 {
+		cmd = exec.Command(args[0], append(args[1:], url)...)
+	} else {
+		switch runtime.GOOS {
+		case "darwin":
+			cmd = exec.Command("open", url)
+		case "windows":
+			cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+		default:
+			cmd = exec.Command("xdg-open", url)
+		}
+	}
+	// A browser started by $BROWSER may run until it is closed; jip does
+	// not wait for it, and only reaps it in the background.
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
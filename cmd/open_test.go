@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestPRsToOpen(t *testing.T) {
+	change := func(id string, parents ...string) *jj.Change {
+		return &jj.Change{ChangeID: id, ParentIDs: parents}
+	}
+	pr := func(n int) *forge.PRInfo {
+		return &forge.PRInfo{Number: n, URL: fmt.Sprintf("https://github.com/o/r/pull/%d", n)}
+	}
+	// Stack a ← b ← c with b new; stack d ← e with nothing new.
+	states := []changeState{
+		{change: change("a"), pr: pr(1)},
+		{change: change("b", "a"), pr: pr(2), isNew: true},
+		{change: change("c", "b"), pr: pr(3)},
+		{change: change("d"), pr: pr(4)},
+		{change: change("e", "d"), pr: pr(5)},
+	}
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{openNone, nil},
+		{openAll, []string{"https://github.com/o/r/pull/2"}},
+		{openTop, []string{"https://github.com/o/r/pull/3"}},
+	}
+	for _, tt := range tests {
+		if got := prsToOpen(states, tt.mode); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("prsToOpen(%s) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestCheckOpenMode(t *testing.T) {
+	for _, mode := range []string{openNone, openTop, openAll} {
+		if err := checkOpenMode(mode); err != nil {
+			t.Errorf("checkOpenMode(%q) = %v", mode, err)
+		}
+	}
+	if err := checkOpenMode("some"); err == nil {
+		t.Error("checkOpenMode(\"some\") = nil, want an error")
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/forge"
+)
+
+// projectRef is a GitHub Projects (v2) board: project number of owner.
+type projectRef struct {
+	owner  string
+	number int
+}
+
+// parseProject parses a --project value: "owner/number", or "number" for a
+// project of defaultOwner. An empty value yields nil.
+func parseProject(spec, defaultOwner string) (*projectRef, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	owner, num, ok := strings.Cut(spec, "/")
+	if !ok {
+		owner, num = defaultOwner, spec
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil || n <= 0 || owner == "" {
+		return nil, fmt.Errorf("invalid --project %q — use the project number, or owner/number for another owner's project", spec)
+	}
+	return &projectRef{owner: owner, number: n}, nil
+}
+
+// addToPlanning puts the new PR number into the milestone and project
+// (either may be unset), warning on failure: the PR itself was created.
+func addToPlanning(client forge.Service, number int, milestone string, project *projectRef, w io.Writer) {
+	if milestone != "" {
+		if err := client.SetMilestone(number, milestone); err != nil {
+			_, _ = fmt.Fprintf(w, "  warning: %v\n", err)
+		}
+	}
+	if project != nil {
+		if err := client.AddToProject(number, project.owner, project.number); err != nil {
+			_, _ = fmt.Fprintf(w, "  warning: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import "testing"
+
+func TestParseProject(t *testing.T) {
+	tests := []struct {
+		spec string
+		want *projectRef
+	}{
+		{"", nil},
+		{"3", &projectRef{owner: "acme", number: 3}},
+		{"octocat/12", &projectRef{owner: "octocat", number: 12}},
+	}
+	for _, tt := range tests {
+		got, err := parseProject(tt.spec, "acme")
+		if err != nil {
+			t.Errorf("parseProject(%q): %v", tt.spec, err)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+			t.Errorf("parseProject(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+	for _, spec := range []string{"board", "acme/", "/3", "acme/0", "acme/x/3"} {
+		if _, err := parseProject(spec, "acme"); err == nil {
+			t.Errorf("parseProject(%q) should fail", spec)
+		}
+	}
+}
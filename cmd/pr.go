@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var prCmd = &cobra.Command{
+	Use:   "pr",
+	Short: "Inspect the PRs of a stack",
+}
+
+func init() {
+	rootCmd.AddCommand(prCmd)
+}
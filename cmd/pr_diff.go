@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var prDiffCmd = &cobra.Command{
+	Use:   "diff [revsets...]",
+	Short: "Show what sending a stack now would change on its PRs",
+	Long: `Diff prints, for each change of the given stacks, the interdiff between the
+commit its branch points at on the push remote and the local change — what
+its PR would get if you sent now — so you can audit the stack before
+pushing. Changes that are up to date, or not on the remote yet, get a line
+saying so.
+
+Diff only reads: it fetches the push remote but changes nothing.
+
+Default revset is @- (the stack up to the last committed change).`,
+	RunE:              runPRDiff,
+	ValidArgsFunction: completeJJRevsets,
+}
+
+func init() {
+	prCmd.AddCommand(prDiffCmd)
+	prDiffCmd.Flags().StringP("base", "b", "trunk()", "Base branch (defaults to the repo's trunk branch, usually main)")
+	prDiffCmd.Flags().String("remote", "origin", "Push remote name")
+	prDiffCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (where PRs are opened)")
+
+	_ = prDiffCmd.RegisterFlagCompletionFunc("base", completeJJBookmarks)
+	_ = prDiffCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = prDiffCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+}
+
+func runPRDiff(cmd *cobra.Command, args []string) error {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+		return err
+	}
+
+	base, _ := cmd.Flags().GetString("base")
+	remote, _ := cmd.Flags().GetString("remote")
+	upstream, _ := cmd.Flags().GetString("upstream")
+	revsets := args
+	if len(revsets) == 0 {
+		revsets = []string{"@-"}
+	}
+	w := cmd.OutOrStdout()
+
+	rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], w)
+	if err != nil {
+		return err
+	}
+	namer, err := configBookmarkNamer(cfg, rc.client.GetAuthenticatedUser)
+	if err != nil {
+		return err
+	}
+	return executePRDiff(runner, rc.client, namer, base, remote, revsets, colorsFor(w), w)
+}
+
+func executePRDiff(runner jj.Runner, client forge.Service, namer *jj.BookmarkNamer, base, remote string, revsets []string, c colors, w io.Writer) error {
+	// Like status, an offline run compares against the last fetched state.
+	_, _ = fmt.Fprintf(w, "Fetching %s...\n", remote)
+	if err := runner.GitFetch(remote); err != nil {
+		_, _ = fmt.Fprintf(w, "warning: could not fetch %s, continuing with local state: %v\n", remote, err)
+	}
+
+	dags, err := jj.ResolveStacks(runner, revsets, base)
+	if err != nil {
+		return fmt.Errorf("resolving stacks: %w", err)
+	}
+	if len(dags) == 0 {
+		_, _ = fmt.Fprintln(w, "No changes.")
+		return nil
+	}
+
+	for _, dag := range dags {
+		entries, err := findStackPRs(runner, client, namer, dag, remote)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			_, _ = fmt.Fprintf(w, "\n%s\n", prDiffHeader(e))
+			if e.bookmark == "" {
+				_, _ = fmt.Fprintf(w, "  %s\n", c.yellow("not on "+remote+" yet — sending pushes it as a new branch"))
+				continue
+			}
+			if err := writeInterdiff(runner, e.pushed, e.change.CommitID, c, w); err != nil {
+				return fmt.Errorf("comparing %s with %s on %s: %w", e.change.Short(), e.bookmark, remote, err)
+			}
+		}
+	}
+	return nil
+}
+
+// writeInterdiff prints the interdiff from the commit from to the commit to,
+// or a line saying they are the same or differ only by a rebase.
+func writeInterdiff(runner jj.Runner, from, to string, c colors, w io.Writer) error {
+	if from == to {
+		_, _ = fmt.Fprintf(w, "  %s\n", c.green("up to date"))
+		return nil
+	}
+	diff, err := runner.Interdiff(from, to)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(diff) == "" {
+		_, _ = fmt.Fprintf(w, "  %s\n", c.yellow("rebased, no changes to its diff"))
+		return nil
+	}
+	_, _ = fmt.Fprint(w, colorDiff(diff, c))
+	return nil
+}
+
+// prDiffHeader names the change of e and the PR or branch it updates, e.g.
+// "#12 kxqpmvzy feat: add widget factory (jip/add-widget-factory)".
+func prDiffHeader(e stackEntry) string {
+	h := fmt.Sprintf("%s %s", e.change.Short(), e.change.Title())
+	if e.pr != nil {
+		h = fmt.Sprintf("#%d %s", e.pr.Number, h)
+	}
+	if e.bookmark != "" {
+		h += " (" + e.bookmark + ")"
+	}
+	return h
+}
+
+// colorDiff colors the lines of a git diff: additions green, deletions
+// red, the file headers and hunk headers dim.
+func colorDiff(diff string, c colors) string {
+	if !c.on {
+		return diff
+	}
+	lines := strings.SplitAfter(diff, "\n")
+	// Only file headers have "---" and "+++" lines; inside hunks they are a
+	// removed "--" or an added "++" line.
+	inHeader := false
+	for i, line := range lines {
+		text := strings.TrimSuffix(line, "\n")
+		nl := line[len(text):]
+		switch {
+		case strings.HasPrefix(text, "diff "):
+			inHeader = true
+			lines[i] = c.dim(text) + nl
+		case strings.HasPrefix(text, "@@"):
+			inHeader = false
+			lines[i] = c.dim(text) + nl
+		case inHeader && (strings.HasPrefix(text, "+++") || strings.HasPrefix(text, "---")):
+			lines[i] = c.dim(text) + nl
+		case strings.HasPrefix(text, "+"):
+			lines[i] = c.green(text) + nl
+		case strings.HasPrefix(text, "-"):
+			lines[i] = c.red(text) + nl
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,64 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_PRDiff(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a\n\nconst A = 1\n", "feat: add A")
+	changeA := getChangeID(t, repoDir, "@-")
+	writeAndCommit(t, repoDir, "b.go", "package b\n", "feat: add B")
+	changeB := getChangeID(t, repoDir, "@-")
+
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+
+	// Change A after sending it, and add a change that was never sent.
+	jjRun(t, repoDir, "edit", changeA)
+	if err := os.WriteFile(filepath.Join(repoDir, "a.go"), []byte("package a\n\nconst A = 2\n"), 0644); err != nil {
+		t.Fatalf("writing a.go: %v", err)
+	}
+	jjRun(t, repoDir, "new", changeB)
+	writeAndCommit(t, repoDir, "c.go", "package c\n", "feat: add C")
+
+	buf.Reset()
+	if err := executePRDiff(runner, mock, defaultNamer(t), "main", "origin", []string{"@-"}, colors{}, &buf); err != nil {
+		t.Fatalf("pr diff failed: %v\n%s", err, buf.String())
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"#1 ",
+		"-const A = 1",
+		"+const A = 2",
+		"#2 ",
+		"rebased, no changes to its diff",
+		"feat: add C",
+		"not on origin yet",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("pr diff output lacks %q:\n%s", want, out)
+		}
+	}
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.Comments) != 0 {
+		t.Errorf("pr diff must not comment, got %v", mock.Comments)
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestPRDiffHeader(t *testing.T) {
+	change := &jj.Change{ChangeID: "kxqpmvzyabcd", Description: "feat: add widget factory\n"}
+	tests := []struct {
+		name  string
+		entry stackEntry
+		want  string
+	}{
+		{"not sent", stackEntry{change: change}, change.Short() + " feat: add widget factory"},
+		{"branch without PR", stackEntry{change: change, bookmark: "jip/widget"}, change.Short() + " feat: add widget factory (jip/widget)"},
+		{"PR", stackEntry{change: change, bookmark: "jip/widget", pr: &forge.PRInfo{Number: 12}}, "#12 " + change.Short() + " feat: add widget factory (jip/widget)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prDiffHeader(tt.entry); got != tt.want {
+				t.Errorf("prDiffHeader = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorDiff(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1,2 +1,2 @@\n-old\n--- comment\n+new\n+++x\n same\n"
+	if got := colorDiff(diff, colors{}); got != diff {
+		t.Errorf("colorDiff without colors changed the diff:\n%s", got)
+	}
+	want := "\x1b[2mdiff --git a/a.go b/a.go\x1b[0m\n" +
+		"\x1b[2m--- a/a.go\x1b[0m\n" +
+		"\x1b[2m+++ b/a.go\x1b[0m\n" +
+		"\x1b[2m@@ -1,2 +1,2 @@\x1b[0m\n" +
+		"\x1b[31m-old\x1b[0m\n" +
+		"\x1b[31m--- comment\x1b[0m\n" +
+		"\x1b[32m+new\x1b[0m\n" +
+		"\x1b[32m+++x\x1b[0m\n" +
+		" same\n"
+	if got := colorDiff(diff, colors{on: true}); got != want {
+		t.Errorf("colorDiff = %q, want %q", got, want)
+	}
+}
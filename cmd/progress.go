@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ways a progress renders the steps of a command.
+type progressMode int
+
+const (
+	progressPlain progressMode = iota // one line per step, for pipes and logs
+	progressTTY                       // a spinner per step, then its timing
+	progressQuiet                     // no steps; only warnings get through
+)
+
+// spinnerFrames are drawn in turn while a step runs on a terminal.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is how often the spinner moves on.
+const spinnerInterval = 100 * time.Millisecond
+
+// progress renders the steps of a command (fetch, resolve, bookmarks, push,
+// PRs, bodies for send) to w, and is the writer for the lines printed while
+// they run. On a terminal the running step shows as a spinner, replaced by
+// the step and its timing once the next one starts; lines written in between
+// go above it. Plain mode prints each step as a line. Quiet mode prints no
+// steps and drops all lines but warnings, so that only the summary, written
+// to w directly after finish, remains.
+type progress struct {
+	mu      sync.Mutex
+	w       io.Writer
+	mode    progressMode
+	now     func() time.Time
+	label   string    // running step; "" for none
+	start   time.Time // when the running step started
+	drawn   bool      // the spinner line is on screen
+	midLine bool      // the last write left the cursor mid-line (e.g. a prompt)
+	frame   int
+	partial []byte // quiet mode: the unfinished line written so far
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func newProgress(w io.Writer, mode progressMode) *progress {
+	return &progress{w: w, mode: mode, now: time.Now}
+}
+
+// progressModeFor returns how to render progress to w: quiet when asked
+// to, spinners on an interactive terminal, plain lines otherwise.
+func progressModeFor(w io.Writer, quiet, ci bool) progressMode {
+	switch {
+	case quiet:
+		return progressQuiet
+	case !ci && isTerminal(w) && os.Getenv("TERM") != "dumb":
+		return progressTTY
+	}
+	return progressPlain
+}
+
+// isTerminal reports whether w is an interactive terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// step ends the running step, if any, and starts the one described by
+// label, e.g. "Fetching origin...".
+func (p *progress) step(label string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.endStep()
+	// A prompt has been answered by now, which ended its line.
+	p.midLine = false
+	switch p.mode {
+	case progressPlain:
+		_, _ = fmt.Fprintln(p.w, label)
+	case progressTTY:
+		p.label, p.start = label, p.now()
+		p.draw()
+		if p.stop == nil {
+			p.stop, p.stopped = make(chan struct{}), make(chan struct{})
+			go p.spin(p.stop, p.stopped)
+		}
+	}
+}
+
+// finish ends the running step. Writes after it go straight to w (through
+// the quiet filter in quiet mode).
+func (p *progress) finish() {
+	p.mu.Lock()
+	p.endStep()
+	stop, stopped := p.stop, p.stopped
+	p.stop, p.stopped = nil, nil
+	p.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-stopped
+	}
+}
+
+// endStep replaces the spinner of the running step with its timing. The
+// caller holds p.mu.
+func (p *progress) endStep() {
+	if p.label == "" {
+		return
+	}
+	p.clear()
+	_, _ = fmt.Fprintf(p.w, "✓ %s (%s)\n", strings.TrimSuffix(p.label, "..."), formatStepDuration(p.now().Sub(p.start)))
+	p.label = ""
+}
+
+// spin moves the spinner on until stop is closed, then closes stopped.
+func (p *progress) spin(stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			p.frame++
+			p.draw()
+			p.mu.Unlock()
+		}
+	}
+}
+
+// draw (re)draws the spinner line of the running step, unless the cursor is
+// mid-line, where a prompt waits for an answer. The caller holds p.mu.
+func (p *progress) draw() {
+	if p.label == "" || p.midLine {
+		return
+	}
+	frame := spinnerFrames[p.frame%len(spinnerFrames)]
+	_, _ = fmt.Fprintf(p.w, "\r\x1b[K%s %s", frame, p.label)
+	p.drawn = true
+}
+
+// clear removes the spinner line. The caller holds p.mu.
+func (p *progress) clear() {
+	if p.drawn {
+		_, _ = fmt.Fprint(p.w, "\r\x1b[K")
+		p.drawn = false
+	}
+}
+
+// Write prints b above the spinner; in quiet mode, only its warnings.
+func (p *progress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.mode == progressQuiet {
+		p.partial = append(p.partial, b...)
+		for {
+			i := bytes.IndexByte(p.partial, '\n')
+			if i < 0 {
+				break
+			}
+			line := p.partial[:i+1]
+			if bytes.HasPrefix(bytes.TrimSpace(line), []byte("warning:")) {
+				if _, err := p.w.Write(line); err != nil {
+					return 0, err
+				}
+			}
+			p.partial = p.partial[i+1:]
+		}
+		return len(b), nil
+	}
+	p.clear()
+	n, err := p.w.Write(b)
+	if len(b) > 0 {
+		p.midLine = b[len(b)-1] != '\n'
+	}
+	p.draw()
+	return n, err
+}
+
+// prompt returns a writer for questions to the user: it writes to w in
+// every mode, and keeps the spinner from drawing over the answer.
+func (p *progress) prompt() io.Writer {
+	return promptWriter{p}
+}
+
+type promptWriter struct{ p *progress }
+
+func (pw promptWriter) Write(b []byte) (int, error) {
+	p := pw.p
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	n, err := p.w.Write(b)
+	if len(b) > 0 {
+		p.midLine = b[len(b)-1] != '\n'
+	}
+	return n, err
+}
+
+// formatStepDuration renders how long a step took, e.g. 0.4s or 1m05s.
+func formatStepDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressPlain(t *testing.T) {
+	var buf bytes.Buffer
+	p := newProgress(&buf, progressPlain)
+	p.step("Fetching origin...")
+	_, _ = fmt.Fprintln(p, "warning: could not fetch")
+	p.step("Resolving stacks...")
+	p.finish()
+
+	want := "Fetching origin...\nwarning: could not fetch\nResolving stacks...\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestProgressQuiet(t *testing.T) {
+	var buf bytes.Buffer
+	p := newProgress(&buf, progressQuiet)
+	p.step("Fetching origin...")
+	_, _ = fmt.Fprint(p, "Auth: token\n  warning: could not ")
+	_, _ = fmt.Fprint(p, "comment on #1\nPushed 1 of 2 bookmark(s)\n")
+	_, _ = fmt.Fprint(p.prompt(), "Delete them? [y/N] ")
+	p.finish()
+
+	want := "  warning: could not comment on #1\nDelete them? [y/N] "
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestProgressTTY(t *testing.T) {
+	var buf bytes.Buffer
+	p := newProgress(&buf, progressTTY)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	p.step("Fetching origin...")
+	now = now.Add(1500 * time.Millisecond)
+	_, _ = fmt.Fprintln(p, "warning: could not fetch")
+	p.step("Pushing 2 bookmark(s)...")
+	now = now.Add(65 * time.Second)
+	p.finish()
+
+	out := buf.String()
+	for _, want := range []string{
+		"⠋ Fetching origin...",
+		"\r\x1b[Kwarning: could not fetch\n",
+		"✓ Fetching origin (1.5s)\n",
+		"✓ Pushing 2 bookmark(s) (1m05s)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%q", want, out)
+		}
+	}
+	if !strings.HasSuffix(out, "(1m05s)\n") {
+		t.Errorf("output does not end with the last step:\n%q", out)
+	}
+}
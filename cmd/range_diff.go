@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var rangeDiffCmd = &cobra.Command{
+	Use:   "range-diff [revsets...]",
+	Short: "Show how the changes of a stack differ from what was last pushed",
+	Long: `Range-diff prints, for each change of the given stacks, the interdiff
+between the commit last pushed for it and the local change — the comparison
+the "changes since" comments of jip send link to, in the terminal.
+
+The old commit is the one jip last sent for the change, as recorded in
+.jj/jip/state.json, or else the commit its bookmark points at on the push
+remote. Range-diff works offline: it neither fetches nor asks the forge.
+
+Default revset is @- (the stack up to the last committed change).`,
+	RunE:              runRangeDiff,
+	ValidArgsFunction: completeJJRevsets,
+}
+
+func init() {
+	rootCmd.AddCommand(rangeDiffCmd)
+	rangeDiffCmd.Flags().StringP("base", "b", "trunk()", "Base branch (defaults to the repo's trunk branch, usually main)")
+	rangeDiffCmd.Flags().String("remote", "origin", "Push remote name")
+
+	_ = rangeDiffCmd.RegisterFlagCompletionFunc("base", completeJJBookmarks)
+	_ = rangeDiffCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+}
+
+func runRangeDiff(cmd *cobra.Command, args []string) error {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+		return err
+	}
+	st, err := state.Load(state.Path(repoRoot))
+	if err != nil {
+		return err
+	}
+	namer, err := configBookmarkNamer(cfg, nil)
+	if err != nil {
+		return err
+	}
+
+	base, _ := cmd.Flags().GetString("base")
+	remote, _ := cmd.Flags().GetString("remote")
+	revsets := args
+	if len(revsets) == 0 {
+		revsets = []string{"@-"}
+	}
+	w := cmd.OutOrStdout()
+	return executeRangeDiff(runner, st, namer, base, remote, revsets, colorsFor(w), w)
+}
+
+func executeRangeDiff(runner jj.Runner, st *state.State, namer *jj.BookmarkNamer, base, remote string, revsets []string, c colors, w io.Writer) error {
+	dags, err := jj.ResolveStacks(runner, revsets, base)
+	if err != nil {
+		return fmt.Errorf("resolving stacks: %w", err)
+	}
+	if len(dags) == 0 {
+		_, _ = fmt.Fprintln(w, "No changes.")
+		return nil
+	}
+	data, err := runner.BookmarkList()
+	if err != nil {
+		return fmt.Errorf("listing bookmarks: %w", err)
+	}
+	bookmarks, err := jj.ParseBookmarkList(data)
+	if err != nil {
+		return fmt.Errorf("parsing bookmarks: %w", err)
+	}
+
+	for _, dag := range dags {
+		matched := jj.MatchBookmarksToChanges(dag, bookmarks)
+		for _, ch := range dag.Changes {
+			old, from, err := lastPushed(runner, st, namer, ch, matched[ch.ChangeID], remote)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(w, "\n%s %s\n", ch.Short(), ch.Title())
+			if old == "" {
+				_, _ = fmt.Fprintf(w, "  %s\n", c.yellow("never pushed"))
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "  %s\n", c.dim(fmt.Sprintf("%.8s → %.8s (%s)", old, ch.CommitID, from)))
+			if err := writeInterdiff(runner, old, ch.CommitID, c, w); err != nil {
+				return fmt.Errorf("comparing %s with %.8s: %w", ch.Short(), old, err)
+			}
+		}
+	}
+	return nil
+}
+
+// lastPushed returns the commit last pushed for ch and where it comes from:
+// the commit jip recorded when it last sent ch, when that is still in the
+// repository, or else the commit on remote of the bookmarks of ch, the one
+// jip sent it with or one namer owns first. It returns "" for a change never
+// pushed.
+func lastPushed(runner jj.Runner, st *state.State, namer *jj.BookmarkNamer, ch *jj.Change, bookmarks []*jj.BookmarkInfo, remote string) (commit, from string, err error) {
+	rec, sent := st.Change(ch.ChangeID)
+	if sent && rec.PushedCommit != "" {
+		exists, err := runner.CommitExists(rec.PushedCommit)
+		if err != nil {
+			return "", "", fmt.Errorf("checking commit %s: %w", rec.PushedCommit, err)
+		}
+		if exists {
+			return rec.PushedCommit, "last jip send", nil
+		}
+	}
+	rank := func(b *jj.BookmarkInfo) int {
+		switch {
+		case sent && b.Name == rec.Bookmark:
+			return 2
+		case namer.Owns(b.Name):
+			return 1
+		}
+		return 0
+	}
+	var best *jj.BookmarkInfo
+	for _, b := range bookmarks {
+		if _, ok := b.Remotes[remote]; ok && (best == nil || rank(b) > rank(best)) {
+			best = b
+		}
+	}
+	if best == nil {
+		return "", "", nil
+	}
+	return best.Remotes[remote].Target, fmt.Sprintf("%s@%s", best.Name, remote), nil
+}
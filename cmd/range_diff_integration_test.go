@@ -0,0 +1,63 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_RangeDiff(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+	st, err := state.Load(state.Path(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	namer := defaultNamer(t)
+
+	writeAndCommit(t, repoDir, "f.go", "package x\n\nconst V = 1\n", "feat: add f")
+	changeID := getChangeID(t, repoDir, "@-")
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, state: st}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+
+	buf.Reset()
+	if err := executeRangeDiff(runner, st, namer, "main", "origin", []string{"@-"}, colors{}, &buf); err != nil {
+		t.Fatalf("range-diff failed: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "up to date") {
+		t.Errorf("a change just sent should be up to date:\n%s", buf.String())
+	}
+
+	editFile(t, repoDir, changeID, "f.go", "package x\n\nconst V = 2\n")
+	for _, tt := range []struct {
+		name string
+		st   *state.State
+		from string
+	}{
+		{"recorded send", st, "(last jip send)"},
+		{"remote bookmark", &state.State{}, "@origin)"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := executeRangeDiff(runner, tt.st, namer, "main", "origin", []string{"@-"}, colors{}, &buf); err != nil {
+				t.Fatalf("range-diff failed: %v\n%s", err, buf.String())
+			}
+			for _, want := range []string{tt.from, "-const V = 1", "+const V = 2"} {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("range-diff output lacks %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestLastPushedFallsBackToRemoteBookmark(t *testing.T) {
+	ch := &jj.Change{ChangeID: "kxqpmvzy", CommitID: "c2"}
+	bookmark := func(name, target string) *jj.BookmarkInfo {
+		return &jj.BookmarkInfo{Name: name, Remotes: map[string]jj.RemoteBookmarkState{"origin": {Target: target}}}
+	}
+	local := &jj.BookmarkInfo{Name: "local-only"}
+	tests := []struct {
+		name       string
+		recorded   string // bookmark of the change in the state; "" for none
+		bookmarks  []*jj.BookmarkInfo
+		wantCommit string
+		wantFrom   string
+	}{
+		{"never pushed", "", []*jj.BookmarkInfo{local}, "", ""},
+		{"any bookmark", "", []*jj.BookmarkInfo{local, bookmark("feature", "c1")}, "c1", "feature@origin"},
+		{"jip bookmark first", "", []*jj.BookmarkInfo{bookmark("feature", "c0"), bookmark("jip/feature", "c1")}, "c1", "jip/feature@origin"},
+		{"template bookmark first", "", []*jj.BookmarkInfo{bookmark("feature", "c0"), bookmark("dev/feature-kxqpmvzy", "c1")}, "c1", "dev/feature-kxqpmvzy@origin"},
+		{"recorded bookmark first", "feature", []*jj.BookmarkInfo{bookmark("feature", "c0"), bookmark("jip/feature", "c1")}, "c0", "feature@origin"},
+	}
+	namer, err := jj.NewBookmarkNamer("dev/{{.Slug}}-{{.ShortID}}", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := state.Load(state.Path(t.TempDir()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.recorded != "" {
+				// No pushed commit recorded, so the runner is not asked.
+				st.RecordSend(ch.ChangeID, tt.recorded, 1, "", time.Now())
+			}
+			commit, from, err := lastPushed(nil, st, namer, ch, tt.bookmarks, "origin")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if commit != tt.wantCommit || from != tt.wantFrom {
+				t.Errorf("lastPushed = %q, %q, want %q, %q", commit, from, tt.wantCommit, tt.wantFrom)
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var rebaseCmd = &cobra.Command{
+	Use:   "rebase [revsets...]",
+	Short: "Rebase stacks onto the base branch",
+	Long: `Rebase fetches the remote and rebases the stacks of the given revsets onto
+the base branch (using jj rebase -b), as send --rebase does, without sending.
+It reports the changes the rebase left with conflicts, and drops the
+changes the base branch already has, e.g. after their PR was squash-merged.
+
+With --send, the stacks are sent right after the rebase, unless it introduced
+conflicts.
+
+Default revset is @- (the stack up to the last committed change).`,
+	RunE:              runRebase,
+	ValidArgsFunction: completeJJRevsets,
+}
+
+func init() {
+	rootCmd.AddCommand(rebaseCmd)
+	rebaseCmd.Flags().StringP("base", "b", "trunk()", "Base branch (defaults to the repo's trunk branch, usually main)")
+	rebaseCmd.Flags().String("remote", "origin", "Push remote name")
+	rebaseCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (fetched when a remote; used by --send)")
+	rebaseCmd.Flags().Bool("no-fetch", false, "Rebase onto the last fetched base branch without fetching")
+	rebaseCmd.Flags().Bool("send", false, "Send the stacks after rebasing them")
+	addNoLockFlag(rebaseCmd)
+
+	_ = rebaseCmd.RegisterFlagCompletionFunc("base", completeJJBookmarks)
+	_ = rebaseCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = rebaseCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+}
+
+// rebaseOpts holds configuration for rebase.
+type rebaseOpts struct {
+	base     string
+	remote   string   // push remote
+	upstream string   // upstream remote, whose base branch wins over remote's
+	remotes  []string // fetched before rebasing
+	noFetch  bool
+	revsets  []string
+	namer    *jj.BookmarkNamer // tells jip's branches, fetched with the base
+}
+
+func runRebase(cmd *cobra.Command, args []string) error {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+		return err
+	}
+
+	base, _ := cmd.Flags().GetString("base")
+	remote, _ := cmd.Flags().GetString("remote")
+	upstream, _ := cmd.Flags().GetString("upstream")
+	noFetch, _ := cmd.Flags().GetBool("no-fetch")
+	send, _ := cmd.Flags().GetBool("send")
+	revsets := args
+	if len(revsets) == 0 {
+		revsets = []string{"@-"}
+	}
+	w := cmd.OutOrStdout()
+
+	remotes, err := gitRemotes(runner)
+	if err != nil {
+		return err
+	}
+	if _, ok := remotes[remote]; !ok {
+		return fmt.Errorf("remote %q not found (available: %v)", remote, remotes)
+	}
+	fetch := []string{remote}
+	upstreamRemote := ""
+	if _, ok := remotes[upstream]; ok && upstream != remote {
+		fetch = append(fetch, upstream)
+		upstreamRemote = upstream
+	}
+
+	namer, err := configBookmarkNamer(cfg, nil)
+	if err != nil {
+		return err
+	}
+
+	release, err := lockRepo(cmd, repoRoot, w)
+	if err != nil {
+		return err
+	}
+	conflicts, err := executeRebase(runner, rebaseOpts{
+		base:     base,
+		remote:   remote,
+		upstream: upstreamRemote,
+		remotes:  fetch,
+		noFetch:  noFetch,
+		revsets:  revsets,
+		namer:    namer,
+	}, w)
+	release()
+	if err != nil || !send {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("not sending: the rebase left %d change(s) with conflicts — resolve them, then run jip send", len(conflicts))
+	}
+
+	// Hand over to send with the same base and remotes. The remotes were
+	// just fetched, and send takes the lock itself.
+	_, _ = fmt.Fprintln(w)
+	flags := sendCmd.Flags()
+	for name, value := range map[string]string{"base": base, "remote": remote, "upstream": upstream, "no-fetch": "true"} {
+		if err := flags.Set(name, value); err != nil {
+			return err
+		}
+	}
+	if noLock, _ := cmd.Flags().GetBool("no-lock"); noLock {
+		_ = flags.Set("no-lock", "true")
+	}
+	return runSend(sendCmd, revsets)
+}
+
+// rebaseConflict is a change left with conflicts by a rebase.
+type rebaseConflict struct {
+	change *jj.Change
+	files  []string
+}
+
+// executeRebase fetches opts.remotes and rebases the stacks of opts.revsets
+// onto opts.base. It returns the changes the rebase left with conflicts.
+func executeRebase(runner jj.Runner, opts rebaseOpts, w io.Writer) ([]rebaseConflict, error) {
+	if !opts.noFetch {
+		for _, remote := range opts.remotes {
+			_, _ = fmt.Fprintf(w, "Fetching %s...\n", remote)
+			if err := runner.GitFetch(remote, fetchScope(runner, opts.base, nil, remote, opts.namer)...); err != nil {
+				return nil, fmt.Errorf("fetching %s: %w", remote, err)
+			}
+		}
+	}
+	base := upstreamBase(runner, opts.base, opts.remote, opts.upstream, w)
+	conflicts, undoOp, err := rebaseStacks(runner, opts.revsets, base, w)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		_, _ = fmt.Fprintln(w, "\nResolve them with jj resolve (or by editing the files), then run jip send.")
+		printUndoRebaseHint(undoOp, w)
+	}
+	return conflicts, nil
+}
+
+// rebaseStacks rebases the stacks of revsets onto base and reports the
+// changes that have conflicts after the rebase but did not before, with
+// their conflicted files. It also returns the jj operation before the
+// rebase, for undoing it ("" if it could not be read).
+func rebaseStacks(runner jj.Runner, revsets []string, base string, w io.Writer) ([]rebaseConflict, string, error) {
+	before, err := jj.ResolveStacks(runner, revsets, base)
+	if err != nil {
+		return nil, "", fmt.Errorf("resolving stacks: %w", err)
+	}
+	if len(before) == 0 {
+		_, _ = fmt.Fprintln(w, "No changes to rebase.")
+		return nil, "", nil
+	}
+	commits := make(map[string]string)
+	conflicted := make(map[string]bool)
+	for _, dag := range before {
+		for _, c := range dag.Changes {
+			if c.Immutable {
+				return nil, "", fmt.Errorf("change %s (%s) is immutable and cannot be rebased — leave it out of the revset, e.g. trunk()..@-, or check --base", c.Short(), c.Title())
+			}
+			commits[c.ChangeID] = c.CommitID
+			conflicted[c.ChangeID] = c.Conflict
+		}
+	}
+	undoOp, err := runner.OpHead()
+	if err != nil {
+		undoOp = ""
+	}
+
+	// A squash-merged PR leaves the base with a copy of its change, which
+	// the rebase would apply twice (and often conflict over): abandon such
+	// changes first. The rebase drops the changes it empties too.
+	merged, err := squashMerged(runner, before, revsets, base)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(merged) > 0 {
+		if err := runner.Abandon(merged); err != nil {
+			return nil, "", fmt.Errorf("dropping merged changes: %w", err)
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "Rebasing onto %s...\n", base)
+	if err := runner.Rebase(revsets, base); err != nil {
+		return nil, "", fmt.Errorf("rebasing onto %s: %w", base, err)
+	}
+
+	after, err := jj.ResolveStacks(runner, revsets, base)
+	if err != nil {
+		return nil, "", fmt.Errorf("resolving stacks: %w", err)
+	}
+	rebased := 0
+	var conflicts []rebaseConflict
+	kept := make(map[string]bool)
+	for _, dag := range after {
+		for _, c := range dag.Changes {
+			kept[c.ChangeID] = true
+			if commits[c.ChangeID] != c.CommitID {
+				rebased++
+			}
+			if !c.Conflict || conflicted[c.ChangeID] {
+				continue
+			}
+			rc := rebaseConflict{change: c}
+			if data, err := runner.ResolveList(c.CommitID); err == nil {
+				rc.files = jj.ParseResolveList(data)
+			}
+			conflicts = append(conflicts, rc)
+		}
+	}
+	var dropped []*jj.Change
+	for _, dag := range before {
+		for _, c := range dag.Changes {
+			if !kept[c.ChangeID] {
+				dropped = append(dropped, c)
+			}
+		}
+	}
+	if rebased == 0 && len(dropped) == 0 {
+		_, _ = fmt.Fprintf(w, "Already on top of %s — nothing to rebase.\n", base)
+		return nil, "", nil
+	}
+	_, _ = fmt.Fprintf(w, "Rebased %d change(s).\n", rebased)
+	if len(dropped) > 0 {
+		_, _ = fmt.Fprintf(w, "Dropped %d change(s) already in %s (e.g. a squash-merged PR):\n", len(dropped), base)
+		for _, c := range dropped {
+			_, _ = fmt.Fprintf(w, "  %s  %s\n", c.Short(), c.Title())
+		}
+	}
+	if len(conflicts) > 0 {
+		_, _ = fmt.Fprintf(w, "\nThe rebase left %d change(s) with conflicts:\n\n", len(conflicts))
+		for _, rc := range conflicts {
+			_, _ = fmt.Fprintf(w, "  %s  %s\n", rc.change.Short(), rc.change.Title())
+			for _, f := range rc.files {
+				_, _ = fmt.Fprintf(w, "         %s\n", f)
+			}
+		}
+	}
+	return conflicts, undoOp, nil
+}
+
+// squashScanLimit caps the new changes of the base that squashMerged
+// compares with, newest first.
+const squashScanLimit = 50
+
+// squashMerged returns the change IDs of the changes of dags whose patch
+// (see jj.PatchID) one of the changes base has but the stacks of revsets do
+// not already has, e.g. the squash merge of their PR.
+func squashMerged(runner jj.Runner, dags []*jj.ChangeDAG, revsets []string, base string) ([]string, error) {
+	data, err := runner.Log(fmt.Sprintf("latest(::(%s) ~ ::(%s), %d)", base, strings.Join(revsets, " | "), squashScanLimit))
+	if err != nil {
+		return nil, fmt.Errorf("listing the new changes of %s: %w", base, err)
+	}
+	landed, err := jj.ParseChanges(data)
+	if err != nil {
+		return nil, fmt.Errorf("listing the new changes of %s: %w", base, err)
+	}
+	if len(landed) == 0 {
+		return nil, nil
+	}
+	patches := make(map[string]bool, len(landed))
+	for _, c := range landed {
+		diff, err := runner.Diff(c.CommitID)
+		if err != nil {
+			return nil, err
+		}
+		if id := jj.PatchID(diff); id != "" {
+			patches[id] = true
+		}
+	}
+	var merged []string
+	for _, dag := range dags {
+		for _, c := range dag.Changes {
+			if c.Empty {
+				continue
+			}
+			diff, err := runner.Diff(c.CommitID)
+			if err != nil {
+				return nil, err
+			}
+			if patches[jj.PatchID(diff)] {
+				merged = append(merged, c.ChangeID)
+			}
+		}
+	}
+	return merged, nil
+}
+
+func printUndoRebaseHint(undoOp string, w io.Writer) {
+	if undoOp == "" {
+		_, _ = fmt.Fprintln(w, "To undo the rebase, restore the operation before it (see jj op log).")
+		return
+	}
+	_, _ = fmt.Fprintf(w, "To undo the rebase: jj op restore %.12s\n", undoOp)
+}
+
+// promptUndoRebase returns a confirmUndoRebase function asking on w and
+// reading the answer from reader. Anything but no undoes the rebase.
+func promptUndoRebase(reader *bufio.Reader, w io.Writer) func() (bool, error) {
+	return func() (bool, error) {
+		_, _ = fmt.Fprint(w, "Undo the rebase and stop? [Y/n] ")
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return false, fmt.Errorf("reading the answer: %w", err)
+		}
+		answer := strings.ToLower(strings.TrimSpace(line))
+		return answer != "n" && answer != "no", nil
+	}
+}
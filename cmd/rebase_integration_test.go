@@ -0,0 +1,228 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_RebaseUpToDate(t *testing.T) {
+	checkJJ(t)
+
+	repoDir, _ := initTestRepoWithRemote(t)
+	spy := &spyRunner{Runner: jj.NewRunner(repoDir)}
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: change A")
+
+	var buf bytes.Buffer
+	conflicts, err := executeRebase(spy, rebaseOpts{
+		base:    "main",
+		remotes: []string{"origin"},
+		revsets: []string{"@-"},
+		namer:   defaultNamer(t),
+	}, &buf)
+	if err != nil {
+		t.Fatalf("rebase failed: %v\nOutput:\n%s", err, buf.String())
+	}
+	output := buf.String()
+	t.Logf("Output:\n%s", output)
+
+	if len(spy.fetchRemotes) != 1 || spy.fetchRemotes[0] != "origin" {
+		t.Errorf("expected a fetch of origin, got %v", spy.fetchRemotes)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %d", len(conflicts))
+	}
+	if !strings.Contains(output, "Already on top of main") {
+		t.Errorf("expected 'Already on top of main' in output")
+	}
+}
+
+func TestIntegration_RebaseReportsConflicts(t *testing.T) {
+	checkJJ(t)
+
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	// A stack of two changes, the first editing the README.
+	writeAndCommit(t, repoDir, "README.md", "# mine", "docs: edit readme")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: change B")
+	changeB := getChangeID(t, repoDir, "@-")
+
+	// main moves on with a conflicting edit.
+	jjRun(t, repoDir, "new", "main")
+	writeAndCommit(t, repoDir, "README.md", "# theirs", "docs: other readme edit")
+	jjRun(t, repoDir, "bookmark", "set", "main", "-r", "@-")
+
+	var buf bytes.Buffer
+	conflicts, err := executeRebase(runner, rebaseOpts{
+		base:    "main",
+		noFetch: true,
+		revsets: []string{changeB},
+	}, &buf)
+	if err != nil {
+		t.Fatalf("rebase failed: %v\nOutput:\n%s", err, buf.String())
+	}
+	output := buf.String()
+	t.Logf("Output:\n%s", output)
+
+	if strings.Contains(output, "Fetching") {
+		t.Errorf("expected no fetch with noFetch")
+	}
+	if !strings.Contains(output, "Rebased 2 change(s)") {
+		t.Errorf("expected 'Rebased 2 change(s)' in output")
+	}
+	// The conflict in the first change is inherited by the second.
+	if len(conflicts) != 2 || conflicts[0].change.Title() != "docs: edit readme" {
+		t.Fatalf("expected both changes reported as conflicted, got %d", len(conflicts))
+	}
+	if len(conflicts[0].files) != 1 || conflicts[0].files[0] != "README.md" {
+		t.Errorf("expected README.md conflicted, got %v", conflicts[0].files)
+	}
+	for _, want := range []string{"left 2 change(s) with conflicts", "         README.md\n", "jj op restore "} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output", want)
+		}
+	}
+}
+
+func TestIntegration_RebaseDropsSquashMerged(t *testing.T) {
+	checkJJ(t)
+
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a\n", "feat: change A")
+	writeAndCommit(t, repoDir, "b.go", "package b\n", "feat: change B")
+	changeB := getChangeID(t, repoDir, "@-")
+
+	// The PR of A is squash-merged into main, and main then edits a.go on,
+	// so applying A again would conflict.
+	jjRun(t, repoDir, "new", "main")
+	writeAndCommit(t, repoDir, "a.go", "package a\n", "feat: change A (#1)")
+	writeAndCommit(t, repoDir, "a.go", "package a\n\nconst A = 1\n", "feat: use A")
+	jjRun(t, repoDir, "bookmark", "set", "main", "-r", "@-")
+
+	var buf bytes.Buffer
+	conflicts, err := executeRebase(runner, rebaseOpts{
+		base:    "main",
+		noFetch: true,
+		revsets: []string{changeB},
+	}, &buf)
+	if err != nil {
+		t.Fatalf("rebase failed: %v\nOutput:\n%s", err, buf.String())
+	}
+	output := buf.String()
+	t.Logf("Output:\n%s", output)
+
+	if len(conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %d", len(conflicts))
+	}
+	if !strings.Contains(output, "Dropped 1 change(s) already in main") || !strings.Contains(output, "feat: change A") {
+		t.Errorf("expected change A reported as dropped")
+	}
+	if got := jjRun(t, repoDir, "log", "--no-graph", "-r", "main.."+changeB, "-T", "description.first_line() ++ \"\\n\""); strings.Contains(got, "feat: change A") {
+		t.Errorf("change A is still in the stack:\n%s", got)
+	}
+}
+
+// addUpstreamRemote adds a second bare remote named upstream to the repo
+// and pushes main to it.
+func addUpstreamRemote(t *testing.T, repoDir string) {
+	t.Helper()
+	upstreamDir := t.TempDir()
+	if out, err := exec.Command("git", "init", "--bare", upstreamDir).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare upstream: %v\n%s", err, out)
+	}
+	jjRun(t, repoDir, "git", "remote", "add", "upstream", upstreamDir)
+	jjRun(t, repoDir, "bookmark", "track", "main", "--remote", "upstream")
+	jjRun(t, repoDir, "git", "push", "--remote", "upstream", "-b", "main")
+}
+
+func TestIntegration_RebaseOntoUpstreamBase(t *testing.T) {
+	checkJJ(t)
+
+	repoDir, _ := initTestRepoWithRemote(t)
+	addUpstreamRemote(t, repoDir)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a\n", "feat: change A")
+	changeA := getChangeID(t, repoDir, "@-")
+
+	// main moves on upstream only; origin's main is left behind.
+	jjRun(t, repoDir, "new", "main")
+	writeAndCommit(t, repoDir, "u.go", "package u\n", "feat: upstream work")
+	jjRun(t, repoDir, "bookmark", "set", "main", "-r", "@-")
+	jjRun(t, repoDir, "git", "push", "--remote", "upstream", "-b", "main")
+	upstreamMain := getCommitID(t, repoDir, "main@upstream")
+
+	var buf bytes.Buffer
+	_, err := executeRebase(runner, rebaseOpts{
+		base:     "main@origin",
+		remote:   "origin",
+		upstream: "upstream",
+		noFetch:  true,
+		revsets:  []string{changeA},
+	}, &buf)
+	if err != nil {
+		t.Fatalf("rebase failed: %v\nOutput:\n%s", err, buf.String())
+	}
+	output := buf.String()
+	t.Logf("Output:\n%s", output)
+
+	if !strings.Contains(output, "using main@upstream") {
+		t.Errorf("expected the base switched to main@upstream")
+	}
+	if strings.Contains(output, "diverged") {
+		t.Errorf("expected no divergence warning for a stale origin")
+	}
+	if got := getCommitID(t, repoDir, changeA+"-"); got != upstreamMain {
+		t.Errorf("change A is on %s, want main@upstream %s", got, upstreamMain)
+	}
+}
+
+func TestIntegration_RebaseWarnsDivergedUpstreamBase(t *testing.T) {
+	checkJJ(t)
+
+	repoDir, _ := initTestRepoWithRemote(t)
+	addUpstreamRemote(t, repoDir)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a\n", "feat: change A")
+	changeA := getChangeID(t, repoDir, "@-")
+
+	// origin's main and upstream's main each get a commit the other lacks.
+	jjRun(t, repoDir, "new", "main")
+	writeAndCommit(t, repoDir, "o.go", "package o\n", "feat: fork work")
+	jjRun(t, repoDir, "bookmark", "set", "main", "-r", "@-")
+	jjRun(t, repoDir, "git", "push", "--remote", "origin", "-b", "main")
+	jjRun(t, repoDir, "new", "main-")
+	writeAndCommit(t, repoDir, "u.go", "package u\n", "feat: upstream work")
+	jjRun(t, repoDir, "bookmark", "set", "main", "-r", "@-", "--allow-backwards")
+	jjRun(t, repoDir, "git", "push", "--remote", "upstream", "-b", "main")
+
+	var buf bytes.Buffer
+	_, err := executeRebase(runner, rebaseOpts{
+		base:     "main@origin",
+		remote:   "origin",
+		upstream: "upstream",
+		noFetch:  true,
+		revsets:  []string{changeA},
+	}, &buf)
+	if err != nil {
+		t.Fatalf("rebase failed: %v\nOutput:\n%s", err, buf.String())
+	}
+	output := buf.String()
+	t.Logf("Output:\n%s", output)
+
+	if !strings.Contains(output, "warning: main@origin has diverged from main@upstream") {
+		t.Errorf("expected a divergence warning")
+	}
+	if !strings.Contains(output, "using main@upstream") {
+		t.Errorf("expected the base switched to main@upstream")
+	}
+}
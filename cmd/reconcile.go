@@ -0,0 +1,420 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	gh "github.com/omarkohl/jip/internal/github"
+	"github.com/spf13/cobra"
+)
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile [pr-numbers...]",
+	Short: "Sync the stack navigation and bases of PRs changed outside jip",
+	Long: `Reconcile brings the PRs of a stack back in line after their branches were
+pushed or their PRs merged without jip, e.g. a fix pushed with git or a PR
+merged on GitHub. It is the bot behind the workflow of jip actions generate,
+and can be run by hand too.
+
+For the given PRs, the open PRs of the given --branch, and the other PRs of
+their stacks, it
+  - retargets PRs whose base branch belongs to a merged or closed PR onto
+    that PR's base,
+  - drops merged and closed PRs from the stack navigation, or strikes them
+    through where it shows the states of the PRs (see --stack-status), and
+  - points the "Only review commit" link at the head of the PR's branch.
+
+Reconcile only reads and edits PRs. It needs no jj repository when --repo
+or $GITHUB_REPOSITORY names the GitHub repository; otherwise it uses the
+repository of --remote (or --upstream), as send does.`,
+	RunE:              runReconcile,
+	ValidArgsFunction: completePRNumbers(true),
+}
+
+func init() {
+	rootCmd.AddCommand(reconcileCmd)
+	reconcileCmd.Flags().StringArray("branch", nil, "Reconcile the stack of the open PR of this branch (repeatable)")
+	reconcileCmd.Flags().String("repo", "", "GitHub repository as owner/name (default $GITHUB_REPOSITORY, else the repository of --remote)")
+	reconcileCmd.Flags().String("hostname", defaultHost, "GitHub host of --repo (default the host of $GITHUB_SERVER_URL)")
+	reconcileCmd.Flags().String("remote", "origin", "Push remote name")
+	reconcileCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (where PRs are opened)")
+	reconcileCmd.Flags().BoolP("dry-run", "n", false, "Only report what would be fixed")
+
+	_ = reconcileCmd.RegisterFlagCompletionFunc("branch", completeJipBranches)
+	_ = reconcileCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = reconcileCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+}
+
+// reconcileOpts holds configuration for reconcile.
+type reconcileOpts struct {
+	numbers  []int
+	branches []string
+	dryRun   bool
+	status   bool // show the states of the PRs in the stack navigation
+	titles   bool // renumber the --title-prefix positions of PR titles
+	// bodiesOnly leaves the bases and titles alone: only the stack
+	// navigation and the review-commit links are updated.
+	bodiesOnly bool
+	// verb and done name what the summary says is done, "reconcile" and
+	// "reconciled" when empty.
+	verb, done string
+}
+
+func runReconcile(cmd *cobra.Command, args []string) error {
+	var numbers []int
+	for _, arg := range args {
+		n, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid PR number %q", arg)
+		}
+		numbers = append(numbers, n)
+	}
+	branches, _ := cmd.Flags().GetStringArray("branch")
+	if len(numbers) == 0 && len(branches) == 0 {
+		return fmt.Errorf("nothing to reconcile — pass PR numbers or --branch")
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	w := cmd.OutOrStdout()
+
+	client, err := reconcileClient(cmd, w)
+	if err != nil {
+		return err
+	}
+	return executeReconcile(client, reconcileOpts{numbers: numbers, branches: branches, dryRun: dryRun}, w)
+}
+
+// reconcileClient returns the client for the repository of --repo (or
+// $GITHUB_REPOSITORY), and otherwise for the repository of the jj
+// workspace's remotes.
+func reconcileClient(cmd *cobra.Command, w io.Writer) (forge.Service, error) {
+	repo, _ := cmd.Flags().GetString("repo")
+	if repo == "" {
+		repo = os.Getenv("GITHUB_REPOSITORY")
+	}
+	if repo == "" {
+		runner, repoRoot, err := workspaceRunner()
+		if err != nil {
+			return nil, err
+		}
+		cfg, err := config.Load(repoRoot)
+		if err != nil {
+			return nil, err
+		}
+		if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+			return nil, err
+		}
+		remote, _ := cmd.Flags().GetString("remote")
+		upstream, _ := cmd.Flags().GetString("upstream")
+		rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], w)
+		if err != nil {
+			return nil, err
+		}
+		return rc.client, nil
+	}
+
+	host, _ := cmd.Flags().GetString("hostname")
+	if !cmd.Flags().Changed("hostname") {
+		if u, err := url.Parse(os.Getenv("GITHUB_SERVER_URL")); err == nil && u.Host != "" {
+			host = strings.ToLower(u.Host)
+		}
+	}
+	client, source, err := githubClient(host, "https://"+host+"/"+repo)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = fmt.Fprintf(w, "Auth: %s\n", source)
+	_, _ = fmt.Fprintf(w, "Repo: %s/%s\n", client.Owner(), client.Repo())
+	return client, nil
+}
+
+func executeReconcile(client forge.Service, opts reconcileOpts, w io.Writer) error {
+	if opts.verb == "" {
+		opts.verb, opts.done = "reconcile", "reconciled"
+	}
+	prs := make(map[int]*forge.PRInfo)
+	get := func(n int) (*forge.PRInfo, error) {
+		if pr, ok := prs[n]; ok {
+			return pr, nil
+		}
+		pr, err := client.GetPR(n)
+		if err != nil {
+			return nil, err
+		}
+		prs[n] = pr
+		return pr, nil
+	}
+
+	var start []*forge.PRInfo
+	for _, n := range opts.numbers {
+		pr, err := get(n)
+		if err != nil {
+			return err
+		}
+		start = append(start, pr)
+	}
+	if len(opts.branches) > 0 {
+		open, err := client.LookupPRsByBranch(opts.branches)
+		if err != nil {
+			return fmt.Errorf("looking up PRs: %w", err)
+		}
+		for _, branch := range opts.branches {
+			pr := open[branch]
+			if pr == nil {
+				_, _ = fmt.Fprintf(w, "No open PR for %s.\n", branch)
+				continue
+			}
+			if _, ok := prs[pr.Number]; !ok {
+				prs[pr.Number] = pr
+			}
+			start = append(start, prs[pr.Number])
+		}
+	}
+
+	// The stack of each PR as its own navigation lists it. All of them are
+	// read first: a PR's base may be the branch of any merged PR.
+	var stack []int
+	for _, pr := range start {
+		numbers := []int{pr.Number}
+		if block := gh.ParseStackBlock(pr.Body); block != nil && block.Current == pr.Number {
+			numbers = block.PRs
+		}
+		for _, n := range numbers {
+			if _, err := get(n); err != nil {
+				return err
+			}
+			if !slices.Contains(stack, n) {
+				stack = append(stack, n)
+			}
+		}
+	}
+	closed := make(map[string]*forge.PRInfo)
+	for _, pr := range prs {
+		if pr.State != "OPEN" {
+			closed[pr.HeadRefName] = pr
+		}
+	}
+
+	links := client.Links()
+	var checked, fixed int
+	for _, n := range stack {
+		pr := prs[n]
+		if pr.State != "OPEN" {
+			continue
+		}
+		checked++
+		update, problems, err := reconcilePR(pr, closed, get, links, opts)
+		if err != nil {
+			return err
+		}
+		if len(problems) == 0 {
+			_, _ = fmt.Fprintf(w, "  #%-4d ok\n", pr.Number)
+			continue
+		}
+		fixed++
+		verb := "fixed"
+		if opts.dryRun {
+			verb = "would fix"
+		}
+		_, _ = fmt.Fprintf(w, "  #%-4d %s: %s\n", pr.Number, verb, strings.Join(problems, "; "))
+		if opts.dryRun {
+			continue
+		}
+		if err := client.UpdatePR(pr.Number, update); err != nil {
+			return fmt.Errorf("updating PR #%d: %w", pr.Number, err)
+		}
+	}
+
+	switch {
+	case checked == 0:
+		_, _ = fmt.Fprintf(w, "No open PRs to %s.\n", opts.verb)
+	case fixed == 0:
+		_, _ = fmt.Fprintf(w, "\n%d PR(s) checked, nothing to %s.\n", checked, opts.verb)
+	case opts.dryRun:
+		_, _ = fmt.Fprintf(w, "\nDry run — %d of %d PR(s) would be %s.\n", fixed, checked, opts.done)
+	default:
+		_, _ = fmt.Fprintf(w, "\n%d of %d PR(s) %s.\n", fixed, checked, opts.done)
+	}
+	return nil
+}
+
+// reconcilePR returns the update that brings the open PR pr in line with
+// the merged and closed PRs (closed, by head branch) and the head of its
+// branch, and the problems it fixes; no problems when pr is fine. get
+// returns a PR by number.
+func reconcilePR(pr *forge.PRInfo, closed map[string]*forge.PRInfo, get func(int) (*forge.PRInfo, error), links forge.Links, opts reconcileOpts) (forge.UpdatePROpts, []string, error) {
+	var update forge.UpdatePROpts
+	var problems []string
+
+	base := pr.BaseRefName
+	for seen := map[string]bool{}; closed[base] != nil && !seen[base]; {
+		seen[base] = true
+		base = closed[base].BaseRefName
+	}
+	if base != pr.BaseRefName && !opts.bodiesOnly {
+		update.Base = &base
+		problems = append(problems, fmt.Sprintf("base %s → %s", pr.BaseRefName, base))
+	}
+
+	if opts.titles && !opts.bodiesOnly {
+		title, err := renumberedTitle(pr, get)
+		if err != nil {
+			return update, nil, err
+		}
+		if title != "" {
+			update.Title = &title
+			problems = append(problems, fmt.Sprintf("title %q → %q", pr.Title, title))
+		}
+	}
+
+	body, bodyProblems, err := restackBody(pr, get, links, opts.status)
+	if err != nil {
+		return update, nil, err
+	}
+	if body != "" {
+		update.Body = &body
+	}
+	return update, append(problems, bodyProblems...), nil
+}
+
+// titlePrefixRe matches the stack position --title-prefix puts in front of
+// PR titles, e.g. "[2/5] ".
+var titlePrefixRe = regexp.MustCompile(`^\[\d+/\d+\] `)
+
+// renumberedTitle returns the title of the open PR pr with its
+// --title-prefix position counted among the open PRs its stack navigation
+// lists, or "" when the title has no position or the right one. A PR left
+// alone loses its position, like a change sent on its own. The position
+// follows the "WIP: " of --wip.
+func renumberedTitle(pr *forge.PRInfo, get func(int) (*forge.PRInfo, error)) (string, error) {
+	title := strings.TrimPrefix(pr.Title, wipPrefix)
+	wip := pr.Title[:len(pr.Title)-len(title)]
+	prefix := titlePrefixRe.FindString(title)
+	block := gh.ParseStackBlock(pr.Body)
+	if prefix == "" || block == nil || block.Current != pr.Number {
+		return "", nil
+	}
+	var open []int
+	for _, n := range block.PRs {
+		p, err := get(n)
+		if err != nil {
+			return "", err
+		}
+		if p.State == "OPEN" {
+			open = append(open, n)
+		}
+	}
+	want := ""
+	if len(open) > 1 {
+		want = fmt.Sprintf("[%d/%d] ", slices.Index(open, pr.Number)+1, len(open))
+	}
+	if want == prefix {
+		return "", nil
+	}
+	return wip + want + strings.TrimPrefix(title, prefix), nil
+}
+
+// restackBody returns the body of the open PR pr with its stack navigation
+// and review-commit link brought up to date, and the problems that fixes; ""
+// when nothing is out of date or pr has no stack navigation. Merged and
+// closed PRs are dropped from the navigation, unless it shows their states
+// (see --stack-status), as it always does with status. get returns a PR by
+// number.
+func restackBody(pr *forge.PRInfo, get func(int) (*forge.PRInfo, error), links forge.Links, status bool) (string, []string, error) {
+	// Only bodies jip wrote for a stack are rewritten, and only when
+	// something in them is out of date.
+	block := gh.ParseStackBlock(pr.Body)
+	if block == nil || block.Current != pr.Number {
+		return "", nil, nil
+	}
+	format := block.Format
+	format.Status = format.Status || status
+	var listed, dropped, unfinished []int
+	states := make(map[int]string)
+	var marked []string
+	for _, n := range block.PRs {
+		p, err := get(n)
+		if err != nil {
+			return "", nil, err
+		}
+		st := ""
+		switch {
+		case p.State != "OPEN":
+			st = p.State
+		case p.IsDraft:
+			st = "DRAFT"
+		}
+		finished := st == "MERGED" || st == "CLOSED"
+		if finished && !format.Status {
+			dropped = append(dropped, n)
+			continue
+		}
+		listed = append(listed, n)
+		if !finished {
+			unfinished = append(unfinished, n)
+		}
+		if !format.Status {
+			continue
+		}
+		if st != "" {
+			states[n] = st
+		}
+		if st != block.Format.States[n] {
+			label := "open"
+			if st != "" {
+				label = strings.ToLower(st)
+			}
+			marked = append(marked, fmt.Sprintf("#%d %s", n, label))
+		}
+	}
+	// A PR left alone is no longer stacked.
+	if len(unfinished) <= 1 {
+		listed = unfinished
+	}
+	if format.Status {
+		format.States = states
+	}
+	if format.Titles != nil {
+		format.Titles = make(map[int]string, len(listed))
+		for _, n := range listed {
+			p, _ := get(n)
+			format.Titles[n] = p.Title
+		}
+	}
+	reviewed := gh.ParseReviewCommit(pr.Body)
+	commit := reviewed
+	if pr.HeadSHA != "" && !strings.HasPrefix(reviewed, pr.HeadSHA) {
+		commit = pr.HeadSHA
+	}
+
+	var problems []string
+	if len(dropped) > 0 {
+		refs := make([]string, len(dropped))
+		for i, n := range dropped {
+			refs[i] = fmt.Sprintf("#%d", n)
+		}
+		problems = append(problems, fmt.Sprintf("dropped merged or closed %s from the stack navigation", strings.Join(refs, ", ")))
+	}
+	if len(marked) > 0 {
+		problems = append(problems, fmt.Sprintf("marked %s in the stack navigation", strings.Join(marked, ", ")))
+	}
+	if commit != reviewed {
+		problems = append(problems, fmt.Sprintf("review-commit link → %.7s", commit))
+	}
+	if len(problems) == 0 || commit == "" {
+		return "", nil, nil
+	}
+	body := gh.BuildStackedPRBody(commit, links, pr.Number, listed, gh.ParseStackedDescription(pr.Body), format)
+	body = gh.WithExtraDescription(gh.MergePRBody(pr.Body, body), gh.ParseExtraDescription(pr.Body))
+	if marker := gh.ParsePushedCommit(pr.Body); marker != "" {
+		body = gh.WithPushedCommitMarker(body, marker)
+	}
+	return body, problems, nil
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	gh "github.com/omarkohl/jip/internal/github"
+)
+
+func TestExecuteReconcile(t *testing.T) {
+	fake := forgetest.New("owner", "repo")
+	stack := []int{1, 2, 3}
+	body := func(n int, commit string) string {
+		b := gh.BuildStackedPRBody(commit, fake.Links(), n, stack, "Change "+string(rune('A'+n-1))+".", gh.StackFormat{})
+		return gh.WithPushedCommitMarker(gh.MergePRBody("", b), commit)
+	}
+	// A stack with chained bases whose bottom PR was merged on GitHub, and
+	// whose top branch got a commit pushed without jip.
+	fake.PRs[1] = &forge.PRInfo{Number: 1, State: "MERGED", HeadRefName: "a", BaseRefName: "main", Body: body(1, "aaaaaaa1")}
+	fake.PRs[2] = &forge.PRInfo{Number: 2, State: "OPEN", HeadRefName: "b", BaseRefName: "a", Body: body(2, "bbbbbbb2"), HeadSHA: "bbbbbbb2"}
+	fake.PRs[3] = &forge.PRInfo{Number: 3, State: "OPEN", HeadRefName: "c", BaseRefName: "b", Body: body(3, "ccccccc3"), HeadSHA: "ddddddd4"}
+	fake.PRs[3].Body = gh.WithPushedCommitMarker(gh.WithExtraDescription(fake.PRs[3].Body, "![screenshot](c.png)"), "ccccccc3")
+	// #2 got a note added on GitHub, outside the part jip generated.
+	fake.PRs[2].Body = "Fixes #7.\n\n" + fake.PRs[2].Body
+
+	var buf bytes.Buffer
+	if err := executeReconcile(fake, reconcileOpts{numbers: []int{1}}, &buf); err != nil {
+		t.Fatalf("reconcile: %v\n%s", err, buf.String())
+	}
+	t.Logf("Output:\n%s", buf.String())
+
+	if got := fake.PRs[2].BaseRefName; got != "main" {
+		t.Errorf("#2 base = %s, want main", got)
+	}
+	if got := fake.PRs[3].BaseRefName; got != "b" {
+		t.Errorf("#3 base = %s, want b", got)
+	}
+	for _, n := range []int{2, 3} {
+		block := gh.ParseStackBlock(fake.PRs[n].Body)
+		if block == nil || !reflect.DeepEqual(block.PRs, []int{2, 3}) {
+			t.Errorf("#%d stack navigation = %+v, want [2 3]", n, block)
+		}
+		if want := "Change " + string(rune('A'+n-1)) + "."; gh.ParseStackedDescription(fake.PRs[n].Body) != want {
+			t.Errorf("#%d lost its description:\n%s", n, fake.PRs[n].Body)
+		}
+	}
+	if got := gh.ParseReviewCommit(fake.PRs[3].Body); got != "ddddddd4" {
+		t.Errorf("#3 review commit = %s, want the pushed head ddddddd4", got)
+	}
+	if !strings.HasPrefix(fake.PRs[2].Body, "Fixes #7.\n\n") {
+		t.Errorf("#2 lost the note added on GitHub:\n%s", fake.PRs[2].Body)
+	}
+	if got := gh.ParseExtraDescription(fake.PRs[3].Body); got != "![screenshot](c.png)" {
+		t.Errorf("#3 lost its hand-written description:\n%s", fake.PRs[3].Body)
+	}
+	if got := gh.ParsePushedCommit(fake.PRs[3].Body); got != "ccccccc3" {
+		t.Errorf("#3 pushed-commit marker = %s, want jip's push ccccccc3 kept", got)
+	}
+
+	// Reconciling again, e.g. from the push event of the same fix, finds
+	// nothing left to do.
+	buf.Reset()
+	if err := executeReconcile(fake, reconcileOpts{branches: []string{"c", "unrelated"}}, &buf); err != nil {
+		t.Fatalf("second reconcile: %v", err)
+	}
+	for _, want := range []string{"No open PR for unrelated.", "2 PR(s) checked, nothing to reconcile."} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("second reconcile lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+// A stack navigation that shows the states of the PRs keeps merged PRs,
+// struck through, and marks drafts.
+func TestExecuteReconcile_StackStatus(t *testing.T) {
+	fake := forgetest.New("owner", "repo")
+	stack := []int{1, 2, 3}
+	format := gh.StackFormat{Status: true, States: map[int]string{}}
+	for n := 1; n <= 3; n++ {
+		b := gh.BuildStackedPRBody("aaaaaaa1", forge.GitHubLinks("https://github.com", "owner", "repo"), n, stack, "Change.", format)
+		fake.PRs[n] = &forge.PRInfo{Number: n, State: "OPEN", HeadRefName: string(rune('a' + n - 1)), BaseRefName: "main", Body: gh.MergePRBody("", b)}
+	}
+	fake.PRs[1].State = "MERGED"
+	fake.PRs[3].IsDraft = true
+
+	var buf bytes.Buffer
+	if err := executeReconcile(fake, reconcileOpts{numbers: []int{2}}, &buf); err != nil {
+		t.Fatalf("reconcile: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "marked #1 merged, #3 draft in the stack navigation") {
+		t.Errorf("expected the marked PRs reported:\n%s", buf.String())
+	}
+	block := gh.ParseStackBlock(fake.PRs[2].Body)
+	want := &gh.StackBlock{PRs: stack, Current: 2, Format: gh.StackFormat{Status: true, States: map[int]string{1: "MERGED", 3: "DRAFT"}}}
+	if !reflect.DeepEqual(block, want) {
+		t.Errorf("stack navigation = %+v, want %+v\n%s", block, want, fake.PRs[2].Body)
+	}
+	if !strings.Contains(fake.PRs[2].Body, "* ~~#1~~ (merged)\n") {
+		t.Errorf("expected #1 struck through:\n%s", fake.PRs[2].Body)
+	}
+}
+
+// With bodiesOnly the stack navigation is updated, but a PR based on the
+// branch of a merged PR keeps its base and its title.
+func TestExecuteReconcile_BodiesOnly(t *testing.T) {
+	fake := forgetest.New("owner", "repo")
+	stack := []int{1, 2, 3}
+	for n := 1; n <= 3; n++ {
+		b := gh.BuildStackedPRBody("aaaaaaa1", fake.Links(), n, stack, "Change.", gh.StackFormat{})
+		fake.PRs[n] = &forge.PRInfo{Number: n, State: "OPEN", Title: fmt.Sprintf("[%d/3] Change", n), HeadRefName: string(rune('a' + n - 1)), Body: gh.MergePRBody("", b)}
+	}
+	fake.PRs[1].State = "MERGED"
+	fake.PRs[1].BaseRefName, fake.PRs[2].BaseRefName, fake.PRs[3].BaseRefName = "main", "a", "b"
+
+	var buf bytes.Buffer
+	if err := executeReconcile(fake, reconcileOpts{numbers: []int{2}, titles: true, bodiesOnly: true}, &buf); err != nil {
+		t.Fatalf("reconcile: %v\n%s", err, buf.String())
+	}
+	if block := gh.ParseStackBlock(fake.PRs[2].Body); block == nil || !reflect.DeepEqual(block.PRs, []int{2, 3}) {
+		t.Errorf("#2 stack navigation = %+v, want [2 3]", block)
+	}
+	if got := fake.PRs[2]; got.BaseRefName != "a" || got.Title != "[2/3] Change" {
+		t.Errorf("#2 base %q, title %q; want them left alone", got.BaseRefName, got.Title)
+	}
+}
+
+func TestWithFinishedPRs(t *testing.T) {
+	fake := forgetest.New("owner", "repo")
+	fake.PRs[1] = &forge.PRInfo{Number: 1, State: "MERGED", Title: "feat: a"}
+	fake.PRs[4] = &forge.PRInfo{Number: 4, State: "OPEN", Title: "feat: d"}
+	body := gh.BuildStackedPRBody("aaaaaaa1", forge.GitHubLinks("https://github.com", "owner", "repo"), 2, []int{1, 2, 3, 4}, "", gh.StackFormat{})
+
+	format := gh.StackFormat{Titles: map[int]string{}, Status: true, States: map[int]string{}}
+	got, err := withFinishedPRs(body, []int{2, 3}, format, cachedGetPR(fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("stack = %v, want [1 2 3]", got)
+	}
+	if format.States[1] != "MERGED" || format.Titles[1] != "feat: a" {
+		t.Errorf("format lacks #1: %+v", format)
+	}
+
+	if got, _ := withFinishedPRs(body, []int{2, 3}, gh.StackFormat{}, cachedGetPR(fake)); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Errorf("without status: stack = %v, want [2 3]", got)
+	}
+	if got, _ := withFinishedPRs(body, []int{2}, format, cachedGetPR(fake)); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("a PR left alone: stack = %v, want [2]", got)
+	}
+}
+
+func TestRenumberedTitle(t *testing.T) {
+	fake := forgetest.New("owner", "repo")
+	stack := []int{1, 2, 3}
+	for n := 1; n <= 3; n++ {
+		b := gh.BuildStackedPRBody("aaaaaaa1", forge.GitHubLinks("https://github.com", "owner", "repo"), n, stack, "", gh.StackFormat{})
+		fake.PRs[n] = &forge.PRInfo{Number: n, State: "OPEN", Title: fmt.Sprintf("[%d/3] change %d", n, n), Body: b}
+	}
+	fake.PRs[1].State = "MERGED"
+	get := cachedGetPR(fake)
+
+	for n, want := range map[int]string{2: "[1/2] change 2", 3: "[2/2] change 3"} {
+		got, err := renumberedTitle(fake.PRs[n], get)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("#%d: got %q, want %q", n, got, want)
+		}
+	}
+
+	wip := &forge.PRInfo{Number: 3, State: "OPEN", Title: "WIP: [3/3] change 3", Body: fake.PRs[3].Body}
+	if got, _ := renumberedTitle(wip, get); got != "WIP: [2/2] change 3" {
+		t.Errorf("a WIP title: got %q, want %q", got, "WIP: [2/2] change 3")
+	}
+
+	unprefixed := &forge.PRInfo{Number: 2, State: "OPEN", Title: "change 2", Body: fake.PRs[2].Body}
+	if got, _ := renumberedTitle(unprefixed, get); got != "" {
+		t.Errorf("a title without a position: got %q, want none", got)
+	}
+
+	fake.PRs[3].State = "CLOSED"
+	get = cachedGetPR(fake)
+	if got, _ := renumberedTitle(fake.PRs[2], get); got != "change 2" {
+		t.Errorf("a PR left alone: got %q, want %q", got, "change 2")
+	}
+}
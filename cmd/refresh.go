@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var refreshCmd = &cobra.Command{
+	Use:   "refresh [revsets...]",
+	Short: "Update the descriptions, titles and bases of a stack's PRs without pushing",
+	Long: `Refresh brings the PRs of the given stacks in line with what happened to them
+on GitHub, e.g. PRs of the stack merged or branches pushed without jip. For
+each open PR of the stacks, it
+  - retargets PRs whose base branch belongs to a merged or closed PR onto
+    that PR's base,
+  - updates the stack navigation: merged and closed PRs are dropped, or
+    struck through with --stack-status,
+  - points the "Only review commit" link at the head of the PR's branch, and
+  - renumbers the positions --title-prefix put in front of the PR titles.
+
+With --bodies-only, it only updates the stack navigation and the "Only
+review commit" links, and leaves the bases and titles alone: a quick way to
+bring descriptions up to date after PRs of a stack merged or left draft.
+
+Refresh neither fetches nor pushes, and leaves the bookmarks alone: it only
+reads the stacks and edits their PRs, so it is safe to run from CI bots. To
+refresh PRs without a jj repository, use jip reconcile.
+
+Default revset is @- (the stack up to the last committed change).`,
+	RunE:              runRefresh,
+	ValidArgsFunction: completeJJRevsets,
+}
+
+func init() {
+	rootCmd.AddCommand(refreshCmd)
+	refreshCmd.Flags().StringP("base", "b", "trunk()", "Base branch (defaults to the repo's trunk branch, usually main)")
+	refreshCmd.Flags().String("remote", "origin", "Push remote name")
+	refreshCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (where PRs are opened)")
+	refreshCmd.Flags().Bool("stack-status", false, "Mark draft PRs in the stack navigation, and keep merged and closed ones struck through")
+	refreshCmd.Flags().Bool("bodies-only", false, "Only update the stack navigation and review links, not the bases or titles")
+	refreshCmd.Flags().BoolP("dry-run", "n", false, "Only report what would be updated")
+
+	_ = refreshCmd.RegisterFlagCompletionFunc("base", completeJJBookmarks)
+	_ = refreshCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = refreshCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+}
+
+func runRefresh(cmd *cobra.Command, args []string) error {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+		return err
+	}
+
+	base, _ := cmd.Flags().GetString("base")
+	remote, _ := cmd.Flags().GetString("remote")
+	upstream, _ := cmd.Flags().GetString("upstream")
+	status, _ := cmd.Flags().GetBool("stack-status")
+	bodiesOnly, _ := cmd.Flags().GetBool("bodies-only")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	revsets := args
+	if len(revsets) == 0 {
+		revsets = []string{"@-"}
+	}
+	w := cmd.OutOrStdout()
+
+	rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], w)
+	if err != nil {
+		return err
+	}
+	namer, err := configBookmarkNamer(cfg, rc.client.GetAuthenticatedUser)
+	if err != nil {
+		return err
+	}
+	return executeRefresh(runner, rc.client, namer, base, remote, revsets, reconcileOpts{dryRun: dryRun, status: status, bodiesOnly: bodiesOnly}, w)
+}
+
+// executeRefresh reconciles the open PRs of the stacks of revsets, with
+// their titles, as opts says.
+func executeRefresh(runner jj.Runner, client forge.Service, namer *jj.BookmarkNamer, base, remote string, revsets []string, opts reconcileOpts, w io.Writer) error {
+	dags, err := jj.ResolveStacks(runner, revsets, base)
+	if err != nil {
+		return fmt.Errorf("resolving stacks: %w", err)
+	}
+	for _, dag := range dags {
+		entries, err := findStackPRs(runner, client, namer, dag, remote)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.pr != nil {
+				opts.numbers = append(opts.numbers, e.pr.Number)
+			}
+		}
+	}
+	if len(opts.numbers) == 0 {
+		_, _ = fmt.Fprintln(w, "No open PRs to refresh.")
+		return nil
+	}
+	opts.titles = true
+	opts.verb, opts.done = "refresh", "refreshed"
+	return executeReconcile(client, opts, w)
+}
@@ -0,0 +1,114 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_Refresh(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a\n", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b\n", "feat: add B")
+	writeAndCommit(t, repoDir, "c.go", "package c\n", "feat: add C")
+
+	var buf bytes.Buffer
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, titlePrefix: true}
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	headsBefore := jjRun(t, repoDir, "bookmark", "list")
+
+	// #1 merged on GitHub.
+	mock.Lock()
+	mock.PRs[1].State = "MERGED"
+	mock.Unlock()
+
+	buf.Reset()
+	if err := executeRefresh(runner, mock, defaultNamer(t), "main", "origin", []string{"@-"}, reconcileOpts{}, &buf); err != nil {
+		t.Fatalf("refresh failed: %v\n%s", err, buf.String())
+	}
+	t.Logf("Output:\n%s", buf.String())
+
+	mock.Lock()
+	pr2, pr3 := *mock.PRs[2], *mock.PRs[3]
+	mock.Unlock()
+	if strings.Contains(pr2.Body, "#1") {
+		t.Errorf("#2 still lists the merged #1:\n%s", pr2.Body)
+	}
+	if pr2.Title != "[1/2] feat: add B" || pr3.Title != "[2/2] feat: add C" {
+		t.Errorf("titles = %q, %q; want them renumbered", pr2.Title, pr3.Title)
+	}
+	if !strings.Contains(buf.String(), "PR(s) refreshed.") {
+		t.Errorf("expected the refresh summary:\n%s", buf.String())
+	}
+	if after := jjRun(t, repoDir, "bookmark", "list"); after != headsBefore {
+		t.Errorf("refresh moved bookmarks:\nbefore:\n%s\nafter:\n%s", headsBefore, after)
+	}
+}
+
+func TestIntegration_RefreshBodiesOnly(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a\n", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b\n", "feat: add B")
+	writeAndCommit(t, repoDir, "c.go", "package c\n", "feat: add C")
+
+	var buf bytes.Buffer
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, stackTitles: true, titlePrefix: true}
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+
+	// #1 merged on GitHub and #3 was turned into a draft there.
+	mock.Lock()
+	mock.PRs[1].State = "MERGED"
+	mock.PRs[3].IsDraft = true
+	base2, title2 := mock.PRs[2].BaseRefName, mock.PRs[2].Title
+	mock.Unlock()
+
+	refresh := func(dryRun bool) {
+		t.Helper()
+		buf.Reset()
+		ropts := reconcileOpts{dryRun: dryRun, status: true, bodiesOnly: true}
+		if err := executeRefresh(runner, mock, defaultNamer(t), "main", "origin", []string{"@-"}, ropts, &buf); err != nil {
+			t.Fatalf("refresh --bodies-only failed: %v\n%s", err, buf.String())
+		}
+	}
+	refresh(true)
+	if !strings.Contains(buf.String(), "would fix: marked #1 merged, #3 draft") {
+		t.Errorf("expected the dry run to report the updates:\n%s", buf.String())
+	}
+
+	refresh(false)
+	mock.Lock()
+	pr2 := *mock.PRs[2]
+	mock.Unlock()
+	for _, want := range []string{"* #3 feat: add C (draft)\n", "* ~~#1 feat: add A~~ (merged)\n", "➡️ #2 feat: add B (this PR, base of the stack"} {
+		if !strings.Contains(pr2.Body, want) {
+			t.Errorf("#2 body lacks %q:\n%s", want, pr2.Body)
+		}
+	}
+	if pr2.BaseRefName != base2 || pr2.Title != title2 {
+		t.Errorf("#2 base %q, title %q; want them left at %q, %q", pr2.BaseRefName, pr2.Title, base2, title2)
+	}
+
+	refresh(false)
+	if !strings.Contains(buf.String(), "nothing to refresh") {
+		t.Errorf("expected nothing left to update:\n%s", buf.String())
+	}
+}
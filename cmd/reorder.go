@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/omarkohl/jip/internal/state"
+)
+
+// chainIDs returns the change IDs of each change's dependency chain (see
+// dependencyChains), bottom first, as send records them.
+func chainIDs(states []changeState) [][]string {
+	chains := dependencyChains(states)
+	ids := make([][]string, len(chains))
+	for i, chain := range chains {
+		ids[i] = make([]string, len(chain))
+		for j, k := range chain {
+			ids[i][j] = states[k].change.ChangeID
+		}
+	}
+	return ids
+}
+
+// reorderNotes returns the comments explaining to the existing PRs of states
+// that their change moved within its stack since the last send recorded in
+// st, by change ID: the changes both chains share are in a different order
+// (e.g. after jj rebase within the stack), and the change directly below is
+// another one. Changes merely added to or dropped from the stack are not
+// reorders.
+func reorderNotes(states []changeState, st *state.State) map[string]string {
+	if st == nil {
+		return nil
+	}
+	idx := make(map[string]int, len(states))
+	for i, s := range states {
+		idx[s.change.ChangeID] = i
+	}
+	notes := make(map[string]string)
+	for i, chain := range chainIDs(states) {
+		s := states[i]
+		if s.isNew || s.pr == nil {
+			continue
+		}
+		rec, ok := st.Change(s.change.ChangeID)
+		if !ok || len(rec.Stack) == 0 || !orderChanged(rec.Stack, chain) {
+			continue
+		}
+		before, now := changeBelow(rec.Stack, s.change.ChangeID), changeBelow(chain, s.change.ChangeID)
+		if before == now {
+			continue
+		}
+		is := "is now at the bottom of the stack"
+		if now != "" {
+			is = fmt.Sprintf("now depends on #%d", states[idx[now]].pr.Number)
+		}
+		was := "was at the bottom of the stack"
+		if before != "" {
+			was = "depended on another change"
+			if r, ok := st.Change(before); ok && r.PRNumber != 0 {
+				was = fmt.Sprintf("depended on #%d", r.PRNumber)
+			}
+		}
+		notes[s.change.ChangeID] = fmt.Sprintf("The stack was reordered: this PR %s (before, it %s).", is, was)
+	}
+	return notes
+}
+
+// orderChanged reports whether the changes in both before and after are in
+// a different order in each.
+func orderChanged(before, after []string) bool {
+	var a, b []string
+	for _, id := range before {
+		if slices.Contains(after, id) {
+			a = append(a, id)
+		}
+	}
+	for _, id := range after {
+		if slices.Contains(before, id) {
+			b = append(b, id)
+		}
+	}
+	return !slices.Equal(a, b)
+}
+
+// changeBelow returns the change right before id in chain, or "" when id is
+// at the bottom (or not in chain).
+func changeBelow(chain []string, id string) string {
+	if i := slices.Index(chain, id); i > 0 {
+		return chain[i-1]
+	}
+	return ""
+}
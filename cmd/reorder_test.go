@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestReorderNotes(t *testing.T) {
+	st, err := state.Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sent as a → b → c, then b and c were swapped and d added on top.
+	now := time.Now()
+	for n, id := range []string{"a", "b", "c"} {
+		st.RecordSend(id, "jip/"+id, n+1, "c"+id, now)
+		st.RecordStack(id, []string{"a", "b", "c"})
+	}
+	change := func(id string, parents ...string) *jj.Change {
+		return &jj.Change{ChangeID: id, ParentIDs: parents}
+	}
+	states := []changeState{
+		{change: change("a"), pr: &forge.PRInfo{Number: 1}},
+		{change: change("c", "a"), pr: &forge.PRInfo{Number: 3}},
+		{change: change("b", "c"), pr: &forge.PRInfo{Number: 2}},
+		{change: change("d", "b"), pr: &forge.PRInfo{Number: 4}, isNew: true},
+	}
+	want := map[string]string{
+		"c": "The stack was reordered: this PR now depends on #1 (before, it depended on #2).",
+		"b": "The stack was reordered: this PR now depends on #3 (before, it depended on #1).",
+	}
+	if got := reorderNotes(states, st); !reflect.DeepEqual(got, want) {
+		t.Errorf("reorderNotes =\n%v\nwant\n%v", got, want)
+	}
+
+	// Once recorded, the same order is no reorder.
+	for i, chain := range chainIDs(states) {
+		st.RecordStack(states[i].change.ChangeID, chain)
+	}
+	if got := reorderNotes(states, st); len(got) != 0 {
+		t.Errorf("reorderNotes after recording = %v, want none", got)
+	}
+}
+
+func TestReorderNotes_InsertIsNoReorder(t *testing.T) {
+	st, err := state.Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.RecordSend("a", "jip/a", 1, "ca", time.Now())
+	st.RecordStack("a", []string{"a"})
+	st.RecordSend("b", "jip/b", 2, "cb", time.Now())
+	st.RecordStack("b", []string{"a", "b"})
+	states := []changeState{
+		{change: &jj.Change{ChangeID: "x"}, pr: &forge.PRInfo{Number: 3}, isNew: true},
+		{change: &jj.Change{ChangeID: "a", ParentIDs: []string{"x"}}, pr: &forge.PRInfo{Number: 1}},
+		{change: &jj.Change{ChangeID: "b", ParentIDs: []string{"a"}}, pr: &forge.PRInfo{Number: 2}},
+	}
+	if got := reorderNotes(states, st); len(got) != 0 {
+		t.Errorf("a change inserted below is no reorder, got %v", got)
+	}
+}
@@ -0,0 +1,334 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	gh "github.com/omarkohl/jip/internal/github"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var repairCmd = &cobra.Command{
+	Use:   "repair [revsets...]",
+	Short: "Check and fix the PR descriptions and bases of sent stacks",
+	Long: `Repair audits the open PRs of the given stacks and fixes, in one pass, what
+jip send would have written differently: stack navigation that is missing or
+lists the wrong PRs, "Only review commit" links pointing at an old commit or
+repository, missing or stale pushed-commit markers (used by --diff-since-jip),
+and bases left over from --stack=gh-native.
+
+Use it after editing PRs by hand or after PRs were sent by an older jip.
+Repair neither pushes nor comments: PRs whose branch on GitHub differs from
+the local change are reported and left alone — send them instead.
+
+Default revset is @- (the stack up to the last committed change).`,
+	RunE:              runRepair,
+	ValidArgsFunction: completeJJRevsets,
+}
+
+func init() {
+	rootCmd.AddCommand(repairCmd)
+	repairCmd.Flags().StringP("base", "b", "trunk()", "Base branch (defaults to the repo's trunk branch, usually main)")
+	repairCmd.Flags().String("remote", "origin", "Push remote name")
+	repairCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (where PRs are opened)")
+	repairCmd.Flags().String("stack", stackModeDefault, "Stacking mode the PRs were sent with: default, gh-native, or none")
+	repairCmd.Flags().String("stack-order", stackOrderNewest, "Order of the PRs in the stack navigation: newest-first or oldest-first")
+	repairCmd.Flags().Bool("stack-titles", false, "Show each PR's title next to its number in the stack navigation")
+	repairCmd.Flags().Bool("stack-status", false, "Mark draft PRs in the stack navigation, and keep merged and closed ones struck through")
+	repairCmd.Flags().Bool("commit-checklist", false, "With --stack=none, list the stack's commits as a review checklist in the PR description")
+	repairCmd.Flags().BoolP("dry-run", "n", false, "Only report what would be fixed")
+	addNoLockFlag(repairCmd)
+
+	_ = repairCmd.RegisterFlagCompletionFunc("base", completeJJBookmarks)
+	_ = repairCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = repairCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+	_ = repairCmd.RegisterFlagCompletionFunc("stack",
+		cobra.FixedCompletions([]string{stackModeDefault, stackModeNative, stackModeNone}, cobra.ShellCompDirectiveNoFileComp))
+	_ = repairCmd.RegisterFlagCompletionFunc("stack-order",
+		cobra.FixedCompletions([]string{stackOrderNewest, stackOrderOldest}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// repairOpts holds configuration for repair.
+type repairOpts struct {
+	base           string
+	remote         string
+	upstreamRemote string // upstream as a named remote (for fetching); empty when it is a URL or unset
+	stackMode      string
+	stackOrder     string
+	stackTitles    bool
+	stackStatus    bool
+	checklist      bool // with stackModeNone, the PR bodies list the stack's commits
+	dryRun         bool
+	revsets        []string
+	namer          *jj.BookmarkNamer // tells jip's bookmarks from others
+}
+
+func runRepair(cmd *cobra.Command, args []string) error {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+		return err
+	}
+
+	base, _ := cmd.Flags().GetString("base")
+	remote, _ := cmd.Flags().GetString("remote")
+	upstream, _ := cmd.Flags().GetString("upstream")
+	stackMode, _ := cmd.Flags().GetString("stack")
+	if _, err := resolveStackMode(stackMode, true, false, false); err != nil {
+		return err
+	}
+	stackOrder, _ := cmd.Flags().GetString("stack-order")
+	if err := checkStackOrder(stackOrder); err != nil {
+		return err
+	}
+	stackTitles, _ := cmd.Flags().GetBool("stack-titles")
+	stackStatus, _ := cmd.Flags().GetBool("stack-status")
+	checklist, _ := cmd.Flags().GetBool("commit-checklist")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	revsets := args
+	if len(revsets) == 0 {
+		revsets = []string{"@-"}
+	}
+	w := cmd.OutOrStdout()
+
+	rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], w)
+	if err != nil {
+		return err
+	}
+	namer, err := configBookmarkNamer(cfg, rc.client.GetAuthenticatedUser)
+	if err != nil {
+		return err
+	}
+	if !dryRun {
+		release, err := lockRepo(cmd, repoRoot, w)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	return executeRepair(runner, rc.client, repairOpts{
+		base:           base,
+		remote:         remote,
+		upstreamRemote: rc.upstreamRemote,
+		stackMode:      stackMode,
+		stackOrder:     stackOrder,
+		stackTitles:    stackTitles,
+		stackStatus:    stackStatus,
+		checklist:      checklist,
+		dryRun:         dryRun,
+		revsets:        revsets,
+		namer:          namer,
+	}, w)
+}
+
+func executeRepair(runner jj.Runner, client forge.Service, opts repairOpts, w io.Writer) error {
+	if opts.stackMode == "" {
+		opts.stackMode = stackModeDefault
+	}
+
+	// Compare against what is on GitHub right now.
+	baseRemote := opts.remote
+	if opts.upstreamRemote != "" {
+		baseRemote = opts.upstreamRemote
+	}
+	fetchRemotes := []string{opts.remote}
+	if baseRemote != opts.remote {
+		fetchRemotes = append(fetchRemotes, baseRemote)
+	}
+	for _, remote := range fetchRemotes {
+		_, _ = fmt.Fprintf(w, "Fetching %s...\n", remote)
+		if err := runner.GitFetch(remote); err != nil {
+			return fmt.Errorf("fetching %s: %w", remote, err)
+		}
+	}
+
+	bookmarkData, err := runner.BookmarkList()
+	if err != nil {
+		return fmt.Errorf("listing bookmarks: %w", err)
+	}
+	bookmarks, err := jj.ParseBookmarkList(bookmarkData)
+	if err != nil {
+		return fmt.Errorf("parsing bookmarks: %w", err)
+	}
+	baseBranch, err := jj.ResolveBaseBranch(runner, opts.base, bookmarks, baseRemote)
+	if err != nil {
+		return err
+	}
+
+	dags, err := jj.ResolveStacks(runner, opts.revsets, opts.base)
+	if err != nil {
+		return fmt.Errorf("resolving stacks: %w", err)
+	}
+
+	links := client.Links()
+	getPR := cachedGetPR(client)
+	var checked, fixed, outOfSync int
+	for _, dag := range dags {
+		entries, err := findStackPRs(runner, client, opts.namer, dag, opts.remote)
+		if err != nil {
+			return err
+		}
+
+		// The PRs of the stack, as send would see them, for the stack
+		// navigation and the expected bases.
+		var states []changeState
+		for _, e := range entries {
+			if e.pr != nil {
+				states = append(states, changeState{change: e.change, pr: e.pr, bookmark: jj.ChangeBookmark{Bookmark: e.bookmark}})
+			}
+		}
+		if len(states) == 0 {
+			continue
+		}
+		var perChangeStack [][]int
+		var format gh.StackFormat
+		if opts.stackMode == stackModeDefault {
+			perChangeStack = computeStackPRs(states)
+			format = stackFormat(states, opts.stackOrder, opts.stackTitles, opts.stackStatus)
+		}
+		desiredBase := make(map[string]string, len(states))
+		stackBranches := make(map[string]bool, len(states))
+		for _, group := range stackGroups(states) {
+			prev := baseBranch
+			for _, s := range group {
+				desiredBase[s.change.ChangeID] = prev
+				stackBranches[s.bookmark.Bookmark] = true
+				if opts.stackMode == stackModeNative {
+					prev = s.bookmark.Bookmark
+				}
+			}
+		}
+
+		pushed := make(map[string]string, len(entries))
+		for _, e := range entries {
+			pushed[e.change.ChangeID] = e.pushed
+		}
+		for i, s := range states {
+			if pushed[s.change.ChangeID] != s.change.CommitID {
+				outOfSync++
+				_, _ = fmt.Fprintf(w, "  #%-4d skipped: local change %s differs from the branch on GitHub — run jip send\n",
+					s.pr.Number, s.change.Short())
+				continue
+			}
+			checked++
+
+			var stack []int
+			if perChangeStack != nil {
+				if stack, err = withFinishedPRs(s.pr.Body, perChangeStack[i], format, getPR); err != nil {
+					return err
+				}
+			}
+			body := s.change.Body()
+			if perChangeStack != nil {
+				body = gh.BuildStackedPRBody(s.change.CommitID, links, s.pr.Number, stack, body, format)
+			}
+			if opts.stackMode == stackModeNone && opts.checklist {
+				// The PR holds every change of the stack up to its own.
+				below := dag.Changes[:slices.Index(dag.Changes, s.change)+1]
+				body = gh.BuildSquashedPRBody(links, s.pr.Number, squashedCommits(below), body, s.pr.Body)
+			}
+			body = gh.MergePRBody(s.pr.Body, body)
+			body = gh.WithExtraDescription(body, gh.ParseExtraDescription(s.pr.Body))
+			body = gh.WithPushedCommitMarker(body, s.change.CommitID)
+			base := desiredBase[s.change.ChangeID]
+			// Outside gh-native a base the user picked is kept; only a base
+			// pointing into the stack itself is a leftover to undo.
+			if opts.stackMode != stackModeNative && !stackBranches[s.pr.BaseRefName] {
+				base = s.pr.BaseRefName
+			}
+
+			problems := repairProblems(s.pr, body, base, s.change.CommitID, links, stack, format)
+			if len(problems) == 0 {
+				_, _ = fmt.Fprintf(w, "  #%-4d ok\n", s.pr.Number)
+				continue
+			}
+			fixed++
+			verb := "fixed"
+			if opts.dryRun {
+				verb = "would fix"
+			}
+			_, _ = fmt.Fprintf(w, "  #%-4d %s: %s\n", s.pr.Number, verb, strings.Join(problems, "; "))
+			if opts.dryRun {
+				continue
+			}
+			update := forge.UpdatePROpts{}
+			if body != s.pr.Body {
+				update.Body = &body
+			}
+			if base != s.pr.BaseRefName {
+				update.Base = &base
+			}
+			if err := client.UpdatePR(s.pr.Number, update); err != nil {
+				return fmt.Errorf("updating PR #%d: %w", s.pr.Number, err)
+			}
+		}
+	}
+
+	switch {
+	case checked == 0 && outOfSync == 0:
+		_, _ = fmt.Fprintln(w, "No open PRs found for these changes.")
+	case checked == 0:
+		// Every PR was skipped; the error below says why.
+	case fixed == 0:
+		_, _ = fmt.Fprintf(w, "\n%d PR(s) checked, nothing to repair.\n", checked)
+	case opts.dryRun:
+		_, _ = fmt.Fprintf(w, "\nDry run — %d of %d PR(s) would be repaired.\n", fixed, checked)
+	default:
+		_, _ = fmt.Fprintf(w, "\n%d of %d PR(s) repaired.\n", fixed, checked)
+	}
+	if outOfSync > 0 {
+		return fmt.Errorf("%d PR(s) not checked: their local changes were not sent", outOfSync)
+	}
+	return nil
+}
+
+// repairProblems describes how pr differs from the body and base jip send
+// would have written for commit, with stack the PR numbers of its stack when
+// the body carries stack navigation, rendered in format. An empty result means
+// the PR is consistent.
+func repairProblems(pr *forge.PRInfo, body, base, commit string, links forge.Links, stack []int, format gh.StackFormat) []string {
+	var problems []string
+	if pr.BaseRefName != base {
+		problems = append(problems, fmt.Sprintf("base %s → %s", pr.BaseRefName, base))
+	}
+	if pr.Body == body {
+		return problems
+	}
+	n := len(problems)
+	switch got := gh.ParsePushedCommit(pr.Body); {
+	case got == "":
+		problems = append(problems, "missing pushed-commit marker")
+	case got != commit:
+		problems = append(problems, "stale pushed-commit marker")
+	}
+	bodyNav := len(stack) > 1
+	hasNav := strings.Contains(pr.Body, "This is a stacked PR")
+	switch {
+	case bodyNav && !hasNav:
+		problems = append(problems, "missing stack navigation")
+	case !bodyNav && hasNav:
+		problems = append(problems, "leftover stack navigation")
+	case bodyNav:
+		if !strings.Contains(pr.Body, "("+links.PRCommitURL(pr.Number, commit)+")") {
+			problems = append(problems, "stale review-commit link")
+		}
+		if !strings.Contains(pr.Body, gh.BuildStackBlock(stack, pr.Number, format)) {
+			problems = append(problems, "outdated stack navigation")
+		}
+	}
+	if len(problems) == n {
+		problems = append(problems, "outdated description")
+	}
+	return problems
+}
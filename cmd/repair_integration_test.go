@@ -0,0 +1,86 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_Repair(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+	writeAndCommit(t, repoDir, "c.go", "package c", "feat: add C")
+	bottomID := getChangeID(t, repoDir, "@---")
+	middleID := getChangeID(t, repoDir, "@--")
+	topID := getChangeID(t, repoDir, "@-")
+
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	bottomBookmark := findBookmarkForChange(t, runner, bottomID)
+	middleBookmark := findBookmarkForChange(t, runner, middleID)
+	topBookmark := findBookmarkForChange(t, runner, topID)
+
+	// Simulate a hand-edited middle PR and a top PR left targeting the
+	// bottom branch.
+	var middle, top *forge.PRInfo
+	mock.Lock()
+	for _, pr := range mock.PRs {
+		switch pr.HeadRefName {
+		case middleBookmark:
+			middle = pr
+		case topBookmark:
+			top = pr
+		}
+	}
+	want := middle.Body
+	middle.Body = "feat: add B, edited on GitHub"
+	top.BaseRefName = bottomBookmark
+	mock.Unlock()
+
+	buf.Reset()
+	if err := executeRepair(runner, mock, repairOpts{base: "main", remote: "origin", namer: defaultNamer(t), dryRun: true, revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("repair --dry-run failed: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "would fix") || middle.Body == want {
+		t.Errorf("dry run should report without fixing:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := executeRepair(runner, mock, repairOpts{base: "main", remote: "origin", namer: defaultNamer(t), revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("repair failed: %v\n%s", err, buf.String())
+	}
+	t.Logf("Output:\n%s", buf.String())
+	if !strings.Contains(buf.String(), "2 of 3 PR(s) repaired") {
+		t.Errorf("expected two repaired PRs:\n%s", buf.String())
+	}
+	mock.Lock()
+	if middle.Body != want {
+		t.Errorf("middle body not restored:\n%s", middle.Body)
+	}
+	if top.BaseRefName != "main" {
+		t.Errorf("top base = %q, want main", top.BaseRefName)
+	}
+	mock.Unlock()
+
+	buf.Reset()
+	if err := executeRepair(runner, mock, repairOpts{base: "main", remote: "origin", namer: defaultNamer(t), revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("second repair failed: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "nothing to repair") {
+		t.Errorf("second repair should find nothing:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
+	gh "github.com/omarkohl/jip/internal/github"
+)
+
+func TestRepairProblems(t *testing.T) {
+	const commit = "abc1234def5678"
+	repo := forge.GitHubLinks("https://github.com", "owner", "repo")
+	stack := []int{1, 2, 3}
+	want := gh.WithPushedCommitMarker(gh.BuildStackedPRBody(commit, repo, 2, stack, "Body.", gh.StackFormat{}), commit)
+	single := gh.WithPushedCommitMarker("Body.", commit)
+
+	tests := []struct {
+		name  string
+		pr    forge.PRInfo
+		body  string
+		base  string
+		stack []int
+		want  []string
+	}{
+		{
+			name:  "consistent",
+			pr:    forge.PRInfo{Number: 2, Body: want, BaseRefName: "main"},
+			body:  want,
+			base:  "main",
+			stack: stack,
+		},
+		{
+			name:  "wrong base",
+			pr:    forge.PRInfo{Number: 2, Body: want, BaseRefName: "jip/a"},
+			body:  want,
+			base:  "main",
+			stack: stack,
+			want:  []string{"base jip/a → main"},
+		},
+		{
+			name:  "missing marker",
+			pr:    forge.PRInfo{Number: 2, Body: gh.BuildStackedPRBody(commit, repo, 2, stack, "Body.", gh.StackFormat{}), BaseRefName: "main"},
+			body:  want,
+			base:  "main",
+			stack: stack,
+			want:  []string{"missing pushed-commit marker"},
+		},
+		{
+			name:  "old format without navigation",
+			pr:    forge.PRInfo{Number: 2, Body: "Body.", BaseRefName: "main"},
+			body:  want,
+			base:  "main",
+			stack: stack,
+			want:  []string{"missing pushed-commit marker", "missing stack navigation"},
+		},
+		{
+			name:  "stale review link and stack list",
+			pr:    forge.PRInfo{Number: 2, Body: gh.WithPushedCommitMarker(gh.BuildStackedPRBody("0dd0dd0dd", repo, 2, []int{1, 2}, "Body.", gh.StackFormat{}), commit), BaseRefName: "main"},
+			body:  want,
+			base:  "main",
+			stack: stack,
+			want:  []string{"stale review-commit link", "outdated stack navigation"},
+		},
+		{
+			name:  "renamed repository",
+			pr:    forge.PRInfo{Number: 2, Body: gh.WithPushedCommitMarker(gh.BuildStackedPRBody(commit, forge.GitHubLinks("https://github.com", "owner", "old"), 2, stack, "Body.", gh.StackFormat{}), commit), BaseRefName: "main"},
+			body:  want,
+			base:  "main",
+			stack: stack,
+			want:  []string{"stale review-commit link"},
+		},
+		{
+			name: "leftover navigation",
+			pr:   forge.PRInfo{Number: 2, Body: gh.WithPushedCommitMarker(gh.BuildStackedPRBody(commit, repo, 2, stack, "Body.", gh.StackFormat{}), "0dd0dd0dd"), BaseRefName: "main"},
+			body: single,
+			base: "main",
+			want: []string{"stale pushed-commit marker", "leftover stack navigation"},
+		},
+		{
+			name: "edited description",
+			pr:   forge.PRInfo{Number: 2, Body: gh.WithPushedCommitMarker("Edited on GitHub.", commit), BaseRefName: "main"},
+			body: single,
+			base: "main",
+			want: []string{"outdated description"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := repairProblems(&tt.pr, tt.body, tt.base, commit, repo, tt.stack, gh.StackFormat{})
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("repairProblems() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/bitbucket"
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/gitea"
+	gh "github.com/omarkohl/jip/internal/github"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// repoContext is the forge side of a jj workspace: a client for the
+// repository PRs are opened in, plus what the commands need to know about
+// the push remote.
+type repoContext struct {
+	client         forge.Service
+	upstreamRemote string // upstream as a named remote (for fetching); empty when it is a URL or unset
+	pushOwner      string // owner parsed from the push remote; set only when --upstream is given
+	pushRepo       string // repo name parsed from the push remote; set together with pushOwner
+	// repos holds the answers of the repository lookups made while
+	// resolving, so that later access checks need not repeat them.
+	repos repoLookups
+}
+
+// repoLookups are answers of RepoInfo by repository, keyed by repoKey. Only
+// definite answers are kept: the repository, or that it is not accessible.
+type repoLookups map[string]repoLookup
+
+// repoLookup is one answer of RepoInfo.
+type repoLookup struct {
+	info *forge.RepoInfo
+	err  error
+}
+
+// repoKey identifies owner/repo in repoLookups; GitHub names are not case
+// sensitive.
+func repoKey(owner, repo string) string {
+	return strings.ToLower(owner + "/" + repo)
+}
+
+// record keeps the answer of RepoInfo for owner/repo when it is definite.
+func (l repoLookups) record(owner, repo string, info *forge.RepoInfo, err error) {
+	if err == nil || errors.Is(err, forge.ErrRepoNotAccessible) {
+		l[repoKey(owner, repo)] = repoLookup{info: info, err: err}
+	}
+}
+
+// resolveRepoContext resolves the forge, the auth token and the repository
+// for the given push remote and optional upstream (a remote name or URL), and
+// reports them on w. forgeName is the forge config key ("" to detect it from
+// the host).
+func resolveRepoContext(runner jj.Runner, remote, upstream, forgeName string, w io.Writer) (*repoContext, error) {
+	// Detect repo from remote.
+	remotes, err := gitRemotes(runner)
+	if err != nil {
+		return nil, err
+	}
+	remoteURL, ok := remotes[remote]
+	if !ok {
+		return nil, fmt.Errorf("remote %q not found (available: %v)", remote, remotes)
+	}
+
+	// Resolve upstream URL: if set, PRs target that repo; otherwise same as push remote.
+	rc := &repoContext{repos: make(repoLookups)}
+	upstreamURL, upstreamRemote, err := resolveUpstream(remotes, upstream)
+	if err != nil {
+		return nil, err
+	}
+	if upstreamURL == "" {
+		upstreamURL = remoteURL
+	}
+	rc.upstreamRemote = upstreamRemote
+
+	// The token and API of the host PRs are opened on, which may be a
+	// GitHub Enterprise Server, a Gitea/Forgejo server or Bitbucket Cloud.
+	host, err := remoteHost(remoteURL, upstreamURL)
+	if err != nil {
+		return nil, err
+	}
+	kind, err := resolveForge(host, forgeName)
+	if err != nil {
+		return nil, err
+	}
+	// GitHub may be reached as a GitHub App instead of with a token.
+	var token, source string
+	var githubAPI *gh.Client
+	if kind == forgeGitHub {
+		githubAPI, source, err = githubClient(host, upstreamURL)
+	} else {
+		token, source, err = forgeToken(kind, host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if host == defaultHost {
+		_, _ = fmt.Fprintf(w, "Auth: %s\n", source)
+	} else {
+		_, _ = fmt.Fprintf(w, "Auth: %s (%s)\n", source, host)
+	}
+	switch kind {
+	case forgeBitbucket:
+		workspace, slug, err := bitbucket.ParseRepoFromURL(upstreamURL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing remote URL: %w", err)
+		}
+		rc.client = bitbucket.NewClient(token, workspace, slug)
+	case forgeGitea:
+		owner, repo, err := gh.ParseRepoFromURL(upstreamURL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing remote URL: %w", err)
+		}
+		rc.client = gitea.NewClient(token, webURL(upstreamURL, host), owner, repo)
+	default:
+		client := githubAPI
+		// Follow renames and transfers, which GraphQL lookups by name do
+		// not. Lookup failures are left to the commands' own access checks,
+		// which explain them.
+		upstreamName := remote
+		if upstream != "" {
+			upstreamName = rc.upstreamRemote // "" when given as a URL
+		}
+		info, old, err := client.Canonicalize()
+		if err != nil {
+			slog.Debug("checking for a moved repository", "err", err)
+		} else if old != "" {
+			warnMovedRepo(w, old, client.Owner(), client.Repo(), upstreamName, upstreamURL)
+		}
+		rc.repos.record(client.Owner(), client.Repo(), info, err)
+		rc.client = client
+	}
+	_, _ = fmt.Fprintf(w, "Repo: %s/%s\n", rc.client.Owner(), rc.client.Repo())
+
+	// For cross-fork PRs, parse the push remote owner to prefix the head ref.
+	if upstream != "" {
+		rc.pushOwner, rc.pushRepo, err = gh.ParseRepoFromURL(remoteURL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing push remote URL: %w", err)
+		}
+		info, err := rc.client.RepoInfo(rc.pushOwner, rc.pushRepo)
+		if err != nil {
+			slog.Debug("checking for a moved push repository", "err", err)
+		} else if !strings.EqualFold(info.Owner, rc.pushOwner) || !strings.EqualFold(info.Name, rc.pushRepo) {
+			warnMovedRepo(w, rc.pushOwner+"/"+rc.pushRepo, info.Owner, info.Name, remote, remoteURL)
+			rc.pushOwner, rc.pushRepo = info.Owner, info.Name
+		}
+		rc.repos.record(rc.pushOwner, rc.pushRepo, info, err)
+	}
+	return rc, nil
+}
+
+// warnMovedRepo tells the user that the repository behind a remote was
+// renamed or transferred, and how to update the remote. remoteName is empty
+// when the repository was given as a URL (--upstream).
+func warnMovedRepo(w io.Writer, old, owner, repo, remoteName, url string) {
+	_, _ = fmt.Fprintf(w, "warning: GitHub repository %s has moved to %s/%s — using the new name\n", old, owner, repo)
+	newURL, err := gh.ReplaceRepoInURL(url, owner, repo)
+	if err != nil {
+		return
+	}
+	if remoteName == "" {
+		_, _ = fmt.Fprintf(w, "  pass --upstream %s from now on\n", newURL)
+		return
+	}
+	_, _ = fmt.Fprintf(w, "  update the remote with: jj git remote set-url %s %s\n", remoteName, newURL)
+}
+
+// gitRemotes returns the repository's git remotes by name. Repositories jip
+// cannot work with at all — not backed by git, or without any remote — get
+// an error that says what is needed instead of a later lookup failure.
+// resolveUpstream returns the URL of the repository --upstream names, a
+// remote name or a URL, and the name of the remote when it is one. Without
+// an upstream it returns "".
+func resolveUpstream(remotes map[string]string, upstream string) (url, remote string, err error) {
+	switch {
+	case upstream == "":
+		return "", "", nil
+	case strings.Contains(upstream, "://") || strings.Contains(upstream, "@"):
+		return upstream, "", nil
+	}
+	if u, ok := remotes[upstream]; ok {
+		return u, upstream, nil
+	}
+	return "", "", fmt.Errorf("upstream remote %q not found (available: %v)", upstream, remotes)
+}
+
+func gitRemotes(runner jj.Runner) (map[string]string, error) {
+	data, err := runner.GitRemoteList()
+	if errors.Is(err, jj.ErrNotGitBacked) {
+		return nil, fmt.Errorf("this jj repository does not use the git backend — jip pushes branches to GitHub, so it needs a repository created with 'jj git init' or 'jj git clone'")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing remotes: %w", err)
+	}
+	remotes := jj.ParseRemoteList(data)
+	if err := checkGitRemotes(remotes); err != nil {
+		return nil, err
+	}
+	return remotes, nil
+}
+
+// checkGitRemotes rejects a repository without git remotes.
+func checkGitRemotes(remotes map[string]string) error {
+	if len(remotes) == 0 {
+		return fmt.Errorf("this jj repository has no git remotes — jip needs the GitHub repository as a remote, e.g. 'jj git remote add origin git@github.com:OWNER/REPO.git'")
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+)
+
+func TestWarnMovedRepo(t *testing.T) {
+	var buf bytes.Buffer
+	warnMovedRepo(&buf, "old/name", "new-org", "name", "origin", "git@github.com:old/name.git")
+	out := buf.String()
+	for _, want := range []string{
+		"old/name has moved to new-org/name",
+		"jj git remote set-url origin git@github.com:new-org/name.git",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWarnMovedRepo_UpstreamURL(t *testing.T) {
+	var buf bytes.Buffer
+	warnMovedRepo(&buf, "old/name", "new-org", "name", "", "https://github.com/old/name.git")
+	if want := "pass --upstream https://github.com/new-org/name.git"; !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, buf.String())
+	}
+}
+
+func TestCheckGitRemotes(t *testing.T) {
+	err := checkGitRemotes(map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), "jj git remote add") {
+		t.Errorf("expected a hint to add a remote, got %v", err)
+	}
+	if err := checkGitRemotes(map[string]string{"origin": "git@github.com:o/r.git"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestResolveUpstream(t *testing.T) {
+	remotes := map[string]string{"origin": "git@github.com:me/repo.git", "upstream": "https://github.com/org/repo.git"}
+	tests := []struct {
+		upstream, url, remote string
+	}{
+		{"", "", ""},
+		{"upstream", "https://github.com/org/repo.git", "upstream"},
+		{"https://github.com/other/repo.git", "https://github.com/other/repo.git", ""},
+		{"git@github.com:other/repo.git", "git@github.com:other/repo.git", ""},
+	}
+	for _, tt := range tests {
+		url, remote, err := resolveUpstream(remotes, tt.upstream)
+		if err != nil || url != tt.url || remote != tt.remote {
+			t.Errorf("resolveUpstream(%q) = %q, %q, %v, want %q, %q", tt.upstream, url, remote, err, tt.url, tt.remote)
+		}
+	}
+	if _, _, err := resolveUpstream(remotes, "fork"); err == nil || !strings.Contains(err.Error(), `upstream remote "fork" not found`) {
+		t.Errorf("unknown remote: got %v", err)
+	}
+}
+
+func TestCheckRepoAccess_ReusesLookups(t *testing.T) {
+	fake := forgetest.New("testowner", "testrepo")
+	fake.InaccessibleRepos = map[string]bool{"forkuser/testrepo": true}
+	// The fake would find the upstream; the recorded answer wins.
+	repos := make(repoLookups)
+	repos.record("TestOwner", "TestRepo", nil, fmt.Errorf("testowner/testrepo: %w", forge.ErrRepoNotAccessible))
+	repos.record("forkuser", "other", nil, errors.New("timeout")) // not definite, not kept
+
+	err := checkRepoAccess(fake, sendOpts{remote: "origin", pushOwner: "forkuser", pushRepo: "testrepo", repos: repos})
+	if err == nil {
+		t.Fatal("expected an access error")
+	}
+	for _, want := range []string{"testowner/testrepo (upstream", "forkuser/testrepo (push repository"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	if len(repos) != 1 {
+		t.Errorf("kept %d lookups, want only the definite one", len(repos))
+	}
+}
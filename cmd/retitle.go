@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	gh "github.com/omarkohl/jip/internal/github"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var retitleCmd = &cobra.Command{
+	Use:     "retitle [revsets...]",
+	Aliases: []string{"title"},
+	Short:   "Update PR titles and descriptions from the change descriptions",
+	Long: `Retitle updates the title and description of each open PR of the given
+stacks from the current description of its change, without fetching,
+pushing or commenting. Use it when only commit messages changed and a full
+send is not needed.
+
+The PR keeps pointing at the commits last pushed, so links in the description
+(e.g. "Only review commit") stay valid; the reworded commits reach GitHub
+with the next send. With --stack=none only the title is updated.
+
+Default revset is @- (the stack up to the last committed change).`,
+	RunE:              runRetitle,
+	ValidArgsFunction: completeJJRevsets,
+}
+
+func init() {
+	rootCmd.AddCommand(retitleCmd)
+	retitleCmd.Flags().StringP("base", "b", "trunk()", "Base branch (defaults to the repo's trunk branch, usually main)")
+	retitleCmd.Flags().String("remote", "origin", "Push remote name")
+	retitleCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (where PRs are opened)")
+	retitleCmd.Flags().String("stack", stackModeDefault, "Stacking mode the PRs were sent with: default, gh-native, or none")
+	retitleCmd.Flags().String("stack-order", stackOrderNewest, "Order of the PRs in the stack navigation: newest-first or oldest-first")
+	retitleCmd.Flags().Bool("stack-titles", false, "Show each PR's title next to its number in the stack navigation")
+	retitleCmd.Flags().Bool("stack-status", false, "Mark draft PRs in the stack navigation, and keep merged and closed ones struck through")
+	retitleCmd.Flags().Bool("title-prefix", false, "Prefix PR titles with their position in the stack, e.g. [2/5]")
+	retitleCmd.Flags().BoolP("dry-run", "n", false, "Only report what would be updated")
+	addNoLockFlag(retitleCmd)
+
+	_ = retitleCmd.RegisterFlagCompletionFunc("base", completeJJBookmarks)
+	_ = retitleCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = retitleCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+	_ = retitleCmd.RegisterFlagCompletionFunc("stack",
+		cobra.FixedCompletions([]string{stackModeDefault, stackModeNative, stackModeNone}, cobra.ShellCompDirectiveNoFileComp))
+	_ = retitleCmd.RegisterFlagCompletionFunc("stack-order",
+		cobra.FixedCompletions([]string{stackOrderNewest, stackOrderOldest}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// retitleOpts holds configuration for retitle.
+type retitleOpts struct {
+	base        string
+	remote      string
+	stackMode   string
+	stackOrder  string
+	stackTitles bool
+	stackStatus bool
+	titlePrefix bool
+	dryRun      bool
+	revsets     []string
+	namer       *jj.BookmarkNamer // tells jip's bookmarks from others
+}
+
+func runRetitle(cmd *cobra.Command, args []string) error {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+		return err
+	}
+
+	base, _ := cmd.Flags().GetString("base")
+	remote, _ := cmd.Flags().GetString("remote")
+	upstream, _ := cmd.Flags().GetString("upstream")
+	stackMode, _ := cmd.Flags().GetString("stack")
+	if _, err := resolveStackMode(stackMode, true, false, false); err != nil {
+		return err
+	}
+	stackOrder, _ := cmd.Flags().GetString("stack-order")
+	if err := checkStackOrder(stackOrder); err != nil {
+		return err
+	}
+	stackTitles, _ := cmd.Flags().GetBool("stack-titles")
+	stackStatus, _ := cmd.Flags().GetBool("stack-status")
+	titlePrefix, _ := cmd.Flags().GetBool("title-prefix")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	revsets := args
+	if len(revsets) == 0 {
+		revsets = []string{"@-"}
+	}
+	w := cmd.OutOrStdout()
+
+	rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], w)
+	if err != nil {
+		return err
+	}
+	namer, err := configBookmarkNamer(cfg, rc.client.GetAuthenticatedUser)
+	if err != nil {
+		return err
+	}
+	if !dryRun {
+		release, err := lockRepo(cmd, repoRoot, w)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	return executeRetitle(runner, rc.client, retitleOpts{
+		base:        base,
+		remote:      remote,
+		stackMode:   stackMode,
+		stackOrder:  stackOrder,
+		stackTitles: stackTitles,
+		stackStatus: stackStatus,
+		titlePrefix: titlePrefix,
+		dryRun:      dryRun,
+		revsets:     revsets,
+		namer:       namer,
+	}, w)
+}
+
+func executeRetitle(runner jj.Runner, client forge.Service, opts retitleOpts, w io.Writer) error {
+	if opts.stackMode == "" {
+		opts.stackMode = stackModeDefault
+	}
+	dags, err := jj.ResolveStacks(runner, opts.revsets, opts.base)
+	if err != nil {
+		return fmt.Errorf("resolving stacks: %w", err)
+	}
+
+	links := client.Links()
+	getPR := cachedGetPR(client)
+	var checked, updated int
+	for _, dag := range dags {
+		entries, err := findStackPRs(runner, client, opts.namer, dag, opts.remote)
+		if err != nil {
+			return err
+		}
+		var states []changeState
+		pushed := make(map[string]string, len(entries))
+		for _, e := range entries {
+			if e.pr != nil {
+				states = append(states, changeState{change: e.change, pr: e.pr, bookmark: jj.ChangeBookmark{Bookmark: e.bookmark}})
+				pushed[e.change.ChangeID] = e.pushed
+			}
+		}
+		if len(states) == 0 {
+			continue
+		}
+		var perChangeStack [][]int
+		var format gh.StackFormat
+		if opts.stackMode == stackModeDefault {
+			perChangeStack = computeStackPRs(states)
+			format = stackFormat(states, opts.stackOrder, opts.stackTitles, opts.stackStatus)
+		}
+		titlePrefix := make([]string, len(states))
+		if opts.titlePrefix {
+			titlePrefix = titlePrefixes(states)
+		}
+
+		for i, s := range states {
+			checked++
+			update := forge.UpdatePROpts{}
+			var what []string
+			wip := strings.HasPrefix(s.pr.Title, wipPrefix)
+			if title := wipTitle(titlePrefix[i]+s.change.PRTitle(), wip); titleChanged(s.pr, title) {
+				update.Title = &title
+				what = append(what, fmt.Sprintf("title %q → %q", s.pr.Title, title))
+			}
+			// The body describes the pushed commit, not the reworded local one.
+			if opts.stackMode != stackModeNone {
+				commit := pushed[s.change.ChangeID]
+				body := s.change.Body()
+				if perChangeStack != nil {
+					stack, err := withFinishedPRs(s.pr.Body, perChangeStack[i], format, getPR)
+					if err != nil {
+						return err
+					}
+					body = gh.BuildStackedPRBody(commit, links, s.pr.Number, stack, body, format)
+				}
+				body = gh.MergePRBody(s.pr.Body, body)
+				body = gh.WithExtraDescription(body, gh.ParseExtraDescription(s.pr.Body))
+				body = gh.WithPushedCommitMarker(body, commit)
+				if body != s.pr.Body {
+					update.Body = &body
+					what = append(what, "description")
+				}
+			}
+			if len(what) == 0 {
+				_, _ = fmt.Fprintf(w, "  #%-4d ok\n", s.pr.Number)
+				continue
+			}
+			updated++
+			verb := "updated"
+			if opts.dryRun {
+				verb = "would update"
+			}
+			_, _ = fmt.Fprintf(w, "  #%-4d %s %s\n", s.pr.Number, verb, strings.Join(what, " and "))
+			if opts.dryRun {
+				continue
+			}
+			if err := client.UpdatePR(s.pr.Number, update); err != nil {
+				return fmt.Errorf("updating PR #%d: %w", s.pr.Number, err)
+			}
+		}
+	}
+
+	switch {
+	case checked == 0:
+		_, _ = fmt.Fprintln(w, "No open PRs found for these changes.")
+	case updated == 0:
+		_, _ = fmt.Fprintf(w, "\n%d PR(s) already up to date.\n", checked)
+	case opts.dryRun:
+		_, _ = fmt.Fprintf(w, "\nDry run — %d of %d PR(s) would be updated.\n", updated, checked)
+	default:
+		_, _ = fmt.Fprintf(w, "\n%d of %d PR(s) updated.\n", updated, checked)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_Retitle(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+	bottomID := getChangeID(t, repoDir, "@--")
+
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	bottomBookmark := findBookmarkForChange(t, runner, bottomID)
+	pushed := strings.TrimSpace(jjRun(t, repoDir, "log", "--no-graph", "-r", bottomID, "-T", "commit_id"))
+
+	// Reword the bottom change locally.
+	jjRun(t, repoDir, "describe", "-r", bottomID, "-m", "feat: add A, reworded\n\nWhy A matters.")
+
+	buf.Reset()
+	if err := executeRetitle(runner, mock, retitleOpts{base: "main", remote: "origin", namer: defaultNamer(t), revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("retitle failed: %v\n%s", err, buf.String())
+	}
+	t.Logf("Output:\n%s", buf.String())
+	if !strings.Contains(buf.String(), "1 of 2 PR(s) updated") {
+		t.Errorf("expected one updated PR:\n%s", buf.String())
+	}
+
+	var bottom *forge.PRInfo
+	mock.Lock()
+	for _, pr := range mock.PRs {
+		if pr.HeadRefName == bottomBookmark {
+			bottom = pr
+		}
+	}
+	mock.Unlock()
+	if bottom.Title != "feat: add A, reworded" {
+		t.Errorf("title = %q", bottom.Title)
+	}
+	if !strings.Contains(bottom.Body, "Why A matters.") {
+		t.Errorf("body lacks the new description:\n%s", bottom.Body)
+	}
+	// Nothing was pushed: the PR still describes the old commit.
+	if !strings.Contains(bottom.Body, "/commits/"+pushed) {
+		t.Errorf("body should link the pushed commit %s:\n%s", pushed, bottom.Body)
+	}
+	if remote := jjRun(t, repoDir, "log", "--no-graph", "-r", bottomBookmark+"@origin", "-T", "commit_id"); strings.TrimSpace(remote) != pushed {
+		t.Errorf("branch moved on the remote to %s", remote)
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// readRevsetFile reads revsets from path, one per line; "-" reads stdin.
+// Blank lines and lines starting with # are ignored, so generated lists can
+// carry comments.
+func readRevsetFile(path string, stdin io.Reader) ([]string, error) {
+	r := stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading revset file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+
+	var revsets []string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		revsets = append(revsets, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading revset file %s: %w", path, err)
+	}
+	return revsets, nil
+}
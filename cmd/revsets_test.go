@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadRevsetFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stack.txt")
+	content := "# generated by a script\nabc\n\n  description(glob:\"feat*\") & mine()  \n#def\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readRevsetFile(path, nil)
+	if err != nil {
+		t.Fatalf("readRevsetFile: %v", err)
+	}
+	want := []string{"abc", `description(glob:"feat*") & mine()`}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadRevsetFile_Stdin(t *testing.T) {
+	got, err := readRevsetFile("-", strings.NewReader("x\ny\n"))
+	if err != nil {
+		t.Fatalf("readRevsetFile: %v", err)
+	}
+	if !slices.Equal(got, []string{"x", "y"}) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestReadRevsetFile_Missing(t *testing.T) {
+	if _, err := readRevsetFile(filepath.Join(t.TempDir(), "nope"), nil); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -1,13 +1,37 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/encrypt"
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/i18n"
+	"github.com/omarkohl/jip/internal/retry"
+	"github.com/omarkohl/jip/pkg/jj"
 	"github.com/spf13/cobra"
 )
 
-var debugFlag bool
+var (
+	debugFlag   bool
+	verboseFlag bool
+	logFile     string
+	caCertFlag  string
+
+	// logOut is the opened --log-file, closed by closeLogFile.
+	logOut *os.File
+)
 
 var rootCmd = &cobra.Command{
 	Use:           "jip",
@@ -15,21 +39,233 @@ var rootCmd = &cobra.Command{
 	Version:       buildVersion(),
 	SilenceUsage:  true,
 	SilenceErrors: true,
-	PersistentPreRun: func(_ *cobra.Command, _ []string) {
-		level := slog.LevelWarn
-		if debugFlag || os.Getenv("JIP_DEBUG") != "" {
-			level = slog.LevelDebug
-		}
-		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-			Level: level,
-		})))
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupLogging(cmd, args); err != nil {
+			return err
+		}
+		if err := checkColorMode(colorFlag); err != nil {
+			return err
+		}
+		if err := setupLanguage(); err != nil {
+			return err
+		}
+		if err := setupEncryption(); err != nil {
+			return err
+		}
+		if err := setupTransport(); err != nil {
+			return err
+		}
+		return setupRetry()
+	},
+	PersistentPostRun: func(cmd *cobra.Command, _ []string) {
+		printUpdateNotice(cmd)
+		closeLogFile()
 	},
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&debugFlag, "debug", false, "enable debug logging to stderr")
+	rootCmd.PersistentFlags().BoolVarP(&verboseFlag, "verbose", "v", false, "log every jj command and GitHub request to stderr")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "write the --verbose (or --debug) log to this file instead of stderr")
+	rootCmd.PersistentFlags().StringVar(&colorFlag, "color", colorAuto, "color the output: auto (on a terminal, unless NO_COLOR is set), always, or never")
+	rootCmd.PersistentFlags().StringVar(&caCertFlag, "ca-cert", "", "trust the CA certificates in this PEM file when connecting to the forge (default the ca-cert config key)")
+
+	_ = rootCmd.RegisterFlagCompletionFunc("color",
+		cobra.FixedCompletions([]string{colorAuto, colorAlways, colorNever}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// setupLogging installs the default logger. --verbose logs at info level,
+// where every jj command and GitHub request is recorded; --debug adds jip's
+// internal decisions. --log-file appends the log to a file, at least at info
+// level, so it can be attached to a bug report.
+func setupLogging(_ *cobra.Command, _ []string) error {
+	level := slog.LevelWarn
+	if verboseFlag || logFile != "" {
+		level = slog.LevelInfo
+	}
+	if debugFlag || os.Getenv("JIP_DEBUG") != "" {
+		level = slog.LevelDebug
+	}
+	var out io.Writer = os.Stderr
+	if logFile != "" {
+		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("opening log file: %w", err)
+		}
+		out, logOut = f, f
+		jj.SetCommandLog(f)
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{
+		Level: level,
+	})))
+	return nil
+}
+
+// closeLogFile closes the --log-file, if one is open, and logs to stderr
+// again.
+func closeLogFile() {
+	if logOut == nil {
+		return
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	jj.SetCommandLog(os.Stderr)
+	_ = logOut.Close()
+	logOut = nil
+}
+
+// setupEncryption applies the global config's encrypt, age-recipient and
+// age-identity keys to the files jip writes. An unreadable config is left to
+// the commands that read it to report.
+func setupEncryption() error {
+	cfg, err := config.Load("")
+	if err != nil {
+		return nil
+	}
+	identity := cfg["age-identity"]
+	if rest, ok := strings.CutPrefix(identity, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			identity = filepath.Join(home, rest)
+		}
+	}
+	return encrypt.Configure(encrypt.Settings{
+		Mode:         cfg["encrypt"],
+		Recipient:    cfg["age-recipient"],
+		IdentityFile: identity,
+	})
+}
+
+// setupTransport applies --ca-cert and the global config's ca-cert and
+// tls-insecure-hosts keys to the connections to forges. An unreadable
+// config is left to the commands that read it to report.
+func setupTransport() error {
+	cfg, _ := config.Load("")
+	caCert := caCertFlag
+	if caCert == "" {
+		caCert = cfg["ca-cert"]
+	}
+	if rest, ok := strings.CutPrefix(caCert, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			caCert = filepath.Join(home, rest)
+		}
+	}
+	var insecure []string
+	for _, host := range strings.Split(cfg["tls-insecure-hosts"], ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			insecure = append(insecure, host)
+		}
+	}
+	return forge.ConfigureTransport(forge.TransportSettings{CACert: caCert, InsecureHosts: insecure})
+}
+
+// setupLanguage selects the language of jip's messages: the language key of
+// the global config, else the locale of the environment ($LC_ALL,
+// $LC_MESSAGES, $LANG).
+func setupLanguage() error {
+	cfg, _ := config.Load("")
+	lang, err := i18n.Detect(cfg["language"])
+	if err != nil {
+		return err
+	}
+	i18n.Set(lang)
+	return nil
+}
+
+// setupRetry applies the JIP_RETRY_* environment variables and the global
+// config's retry-* keys to how often and how patiently forge requests are
+// retried.
+func setupRetry() error {
+	cfg, _ := config.Load("")
+	setting := func(key string) string {
+		if v := os.Getenv("JIP_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))); v != "" {
+			return v
+		}
+		return cfg[key]
+	}
+	var policy retry.Policy
+	if v := setting("retry-attempts"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid retry-attempts %q: want a number of at least 1", v)
+		}
+		policy.MaxAttempts = n
+	}
+	for key, d := range map[string]*time.Duration{
+		"retry-backoff":     &policy.InitialBackoff,
+		"retry-max-backoff": &policy.MaxBackoff,
+		"retry-max-wait":    &policy.MaxWait,
+	} {
+		v := setting(key)
+		if v == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(v)
+		if err != nil || parsed <= 0 {
+			return fmt.Errorf("invalid %s %q: want a duration such as 2s or 1m", key, v)
+		}
+		*d = parsed
+	}
+	retry.Configure(policy)
+	return nil
 }
 
+// runContext is cancelled by the first Ctrl-C (or SIGTERM): jj commands and
+// forge requests stop, retries stop waiting, and a command's deferred
+// cleanup, such as releasing the repository lock, still runs. A second
+// Ctrl-C ends jip at once.
+var runContext = context.Background()
+
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	runContext = ctx
+	// A failed command skips PersistentPostRun.
+	defer closeLogFile()
+
+	err := rootCmd.ExecuteContext(ctx)
+	if ctx.Err() != nil && errors.Is(err, context.Canceled) {
+		return &ExitError{Err: fmt.Errorf("interrupted: %w", err), Code: 130}
+	}
+	if hint := errorHint(err); hint != "" {
+		return fmt.Errorf("%w\n\nhint: %s", err, hint)
+	}
+	return err
+}
+
+// ExitError is an error that sets the exit code of the process.
+type ExitError struct {
+	Err  error
+	Code int
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// ExitCode returns the exit code for an error returned by Execute: the code
+// of an ExitError in its chain, 1 otherwise.
+func ExitCode(err error) int {
+	var exit *ExitError
+	if errors.As(err, &exit) {
+		return exit.Code
+	}
+	return 1
+}
+
+// errorHint suggests how to recover from the jj failures jip recognizes, or
+// returns "" for any other error.
+func errorHint(err error) string {
+	switch {
+	case errors.Is(err, jj.ErrNotARepo):
+		return "run jip inside a jj repository ('jj git init --colocate' adds jj to an existing git repository)"
+	case errors.Is(err, jj.ErrRevsetEmpty):
+		return "the revision does not exist locally — check it with 'jj log -r <revset>', or run 'jj git fetch' if it is on the remote"
+	case errors.Is(err, jj.ErrPushRejected):
+		return "a branch moved on the remote since the last fetch — run 'jj git fetch', check the result, then run jip again"
+	case errors.Is(err, jj.ErrImmutableChange):
+		return "jip will not rewrite immutable changes, such as ones already on trunk — check the revset and --base, or see immutable_heads() in 'jj help -k config'"
+	}
+	return ""
 }
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestErrorHint(t *testing.T) {
+	for _, kind := range []error{jj.ErrNotARepo, jj.ErrRevsetEmpty, jj.ErrPushRejected, jj.ErrImmutableChange} {
+		err := fmt.Errorf("pushing: %w", kind)
+		if errorHint(err) == "" {
+			t.Errorf("no hint for %v", kind)
+		}
+	}
+	if hint := errorHint(errors.New("something else")); hint != "" {
+		t.Errorf("unexpected hint %q for an unrecognized error", hint)
+	}
+	if hint := errorHint(nil); hint != "" {
+		t.Errorf("unexpected hint %q for nil", hint)
+	}
+}
+
+func TestSetupRetry_Invalid(t *testing.T) {
+	old := config.Dir
+	config.Dir = t.TempDir()
+	t.Cleanup(func() { config.Dir = old })
+
+	for env, value := range map[string]string{"JIP_RETRY_ATTEMPTS": "0", "JIP_RETRY_MAX_WAIT": "soon"} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, value)
+			if err := setupRetry(); err == nil {
+				t.Errorf("%s=%s accepted", env, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// selectRevset lists the changes for send --select when no revsets are
+// given: all of yours on top of the base.
+const selectRevset = "mine()"
+
+// errNothingSelected ends send --select when the user quits the picker.
+var errNothingSelected = errors.New("nothing selected")
+
+// selectChanges lists the changes of the stacks of revsets on base with
+// their PRs and asks which to send. It returns the change IDs picked, as
+// revsets for send: each brings the changes below it along.
+func selectChanges(runner jj.Runner, client forge.Service, namer *jj.BookmarkNamer, base, remote string, revsets []string, in *bufio.Reader, w io.Writer) ([]string, error) {
+	dags, err := jj.ResolveStacks(runner, revsets, base)
+	if err != nil {
+		return nil, fmt.Errorf("resolving stacks: %w", err)
+	}
+	var entries []stackEntry
+	for _, dag := range dags {
+		more, err := findStackPRs(runner, client, namer, dag, remote)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, more...)
+	}
+	if len(entries) == 0 {
+		return nil, errNothingSelected
+	}
+	labels := make([]string, len(entries))
+	for i, e := range entries {
+		state := "no PR"
+		if e.pr != nil {
+			state = fmt.Sprintf("#%d open", e.pr.Number)
+			if e.pr.IsDraft {
+				state = fmt.Sprintf("#%d draft", e.pr.Number)
+			}
+		}
+		labels[i] = fmt.Sprintf("%-10s %s  %s", state, e.change.Short(), e.change.Title())
+	}
+	picked, err := pickEntries(labels, in, w)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(picked))
+	for i, n := range picked {
+		ids[i] = entries[n].change.ChangeID
+	}
+	return ids, nil
+}
+
+// pickEntries shows labels numbered from 1 and reads from in which to pick:
+// numbers and ranges ("1,3-4") pick those, other text narrows the list to
+// the labels it fuzzily matches, Enter picks every label shown, and "q"
+// quits with errNothingSelected. It returns the indices picked, in order.
+func pickEntries(labels []string, in *bufio.Reader, w io.Writer) ([]int, error) {
+	shown := make([]int, len(labels))
+	for i := range labels {
+		shown[i] = i
+	}
+	for {
+		for _, i := range shown {
+			_, _ = fmt.Fprintf(w, "  %3d  %s\n", i+1, labels[i])
+		}
+		_, _ = fmt.Fprint(w, "Pick changes by number (e.g. 1,3-4), type to filter, Enter for all shown, q to quit:\n> ")
+		line, err := in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("reading the selection: %w", err)
+		}
+		eof := err == io.EOF
+		answer := strings.TrimSpace(line)
+		switch {
+		case answer == "q" || answer == "" && eof:
+			return nil, errNothingSelected
+		case answer == "":
+			return shown, nil
+		}
+		if picked, ok := parseSelection(answer, len(labels)); ok {
+			return picked, nil
+		}
+		if eof {
+			return nil, errNothingSelected
+		}
+		var matches []int
+		for _, i := range shown {
+			if fuzzyMatch(answer, labels[i]) {
+				matches = append(matches, i)
+			}
+		}
+		if len(matches) == 0 {
+			_, _ = fmt.Fprintf(w, "No change matches %q.\n", answer)
+			continue
+		}
+		shown = matches
+	}
+}
+
+// parseSelection parses numbers and ranges from 1 to n, separated by commas
+// or spaces, into sorted indices from 0. It reports false when s is not such
+// a list.
+func parseSelection(s string, n int) ([]int, bool) {
+	var picked []int
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		lo, hi, isRange := strings.Cut(field, "-")
+		from, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, false
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(hi); err != nil {
+				return nil, false
+			}
+		}
+		if from < 1 || to > n || from > to {
+			return nil, false
+		}
+		for i := from - 1; i < to; i++ {
+			if !slices.Contains(picked, i) {
+				picked = append(picked, i)
+			}
+		}
+	}
+	slices.Sort(picked)
+	return picked, len(picked) > 0
+}
+
+// fuzzyMatch reports whether the characters of pattern appear in s in
+// order, ignoring case, as in fzf.
+func fuzzyMatch(pattern, s string) bool {
+	s = strings.ToLower(s)
+	for _, r := range strings.ToLower(pattern) {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(string(r)):]
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []int
+		ok   bool
+	}{
+		{"1", []int{0}, true},
+		{"3,1", []int{0, 2}, true},
+		{"1-3 5", []int{0, 1, 2, 4}, true},
+		{"2-3,3", []int{1, 2}, true},
+		{"0", nil, false},
+		{"6", nil, false},
+		{"3-2", nil, false},
+		{"widget", nil, false},
+		{"1,x", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSelection(tt.in, 5)
+		if ok != tt.ok || !slices.Equal(got, tt.want) {
+			t.Errorf("parseSelection(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	for pattern, want := range map[string]bool{
+		"widget":  true,
+		"WFac":    true,
+		"add fac": true,
+		"#12":     true,
+		"factadd": false,
+		"zzz":     false,
+	} {
+		if got := fuzzyMatch(pattern, "#12 open   kxqpmvzy  feat: add widget factory"); got != want {
+			t.Errorf("fuzzyMatch(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
+
+func TestPickEntries(t *testing.T) {
+	labels := []string{
+		"#12 open   kxqpmvzy  feat: add widget factory",
+		"#13 draft  lmnopqrs  feat: use widget factory",
+		"no PR      tuvwxyzk  docs: explain widgets",
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  []int
+		err   error
+	}{
+		{"numbers", "1,3\n", []int{0, 2}, nil},
+		{"all", "\n", []int{0, 1, 2}, nil},
+		{"filter then all shown", "factory\n\n", []int{0, 1}, nil},
+		{"filter then pick", "docs\n2\n", []int{1}, nil},
+		{"no match keeps the list", "zzz\nlmno\n\n", []int{1}, nil},
+		{"quit", "q\n", nil, errNothingSelected},
+		{"end of input", "", nil, errNothingSelected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			got, err := pickEntries(labels, bufio.NewReader(strings.NewReader(tt.input)), &out)
+			if !errors.Is(err, tt.err) || !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, %v; want %v, %v\n%s", got, err, tt.want, tt.err, out.String())
+			}
+		})
+	}
+}
@@ -1,17 +1,25 @@
 package cmd
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"maps"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/omarkohl/jip/internal/auth"
 	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
 	gh "github.com/omarkohl/jip/internal/github"
-	"github.com/omarkohl/jip/internal/jj"
+	"github.com/omarkohl/jip/internal/i18n"
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/omarkohl/jip/pkg/jj"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -34,25 +42,86 @@ preview access), or a single PR for the stack tip (none).`,
 
 func init() {
 	rootCmd.AddCommand(sendCmd)
-	sendCmd.Flags().StringP("base", "b", "trunk()", "Base branch (defaults to the repo's trunk branch, usually main)")
+	sendCmd.Flags().StringArrayP("base", "b", []string{"trunk()"}, "Base branch (defaults to the repo's trunk branch, usually main); BRANCH=REVSET sends the stacks of REVSET to BRANCH instead (repeatable)")
 	sendCmd.Flags().String("remote", "origin", "Push remote name")
 	sendCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (where PRs are opened)")
 	sendCmd.Flags().BoolP("dry-run", "n", false, "Show what would happen without making changes")
 	sendCmd.Flags().StringSliceP("reviewer", "r", nil, "Add reviewers (repeatable, comma-separated)")
+	sendCmd.Flags().Bool("assignees-from-blame", false, "Request reviews on new PRs from the most recent authors of the lines each change touches (useful without CODEOWNERS)")
+	sendCmd.Flags().Bool("suggest-reviewers", false, "Request reviews on new PRs from the upstream CODEOWNERS of the files each change touches")
 	sendCmd.Flags().BoolP("draft", "d", false, "Create PRs as drafts")
+	sendCmd.Flags().Bool("wip", false, "Mark the stack's PRs as work in progress: drafts with a \"WIP: \" title prefix")
+	sendCmd.Flags().Bool("ready", false, "Mark the stack's PRs ready for review: drop the \"WIP: \" title prefix and undraft them")
+	sendCmd.Flags().String("auto-merge", "", "Enable GitHub auto-merge on sent PRs with the given method: squash, merge, or rebase")
+	sendCmd.Flags().Lookup("auto-merge").NoOptDefVal = "squash"
+	sendCmd.Flags().String("milestone", "", "Put new PRs into the open milestone with this title")
+	sendCmd.Flags().String("project", "", "Add new PRs to this GitHub Projects board: its number, or owner/number for a board of another user or organization")
 	sendCmd.Flags().BoolP("existing", "x", false, "Only update PRs that already exist (skip new ones)")
 	sendCmd.Flags().String("stack", stackModeDefault, "Stacking mode: default (stack navigation in PR descriptions), gh-native (GitHub's native stacked PRs, requires preview access), or none (send only the tip of each stack as a single PR)")
+	sendCmd.Flags().String("stack-order", stackOrderNewest, "Order of the PRs in the stack navigation: newest-first or oldest-first")
+	sendCmd.Flags().Bool("stack-titles", false, "Show each PR's title next to its number in the stack navigation")
+	sendCmd.Flags().Bool("stack-status", false, "Mark draft PRs in the stack navigation, and keep merged and closed ones struck through")
+	sendCmd.Flags().Bool("commit-checklist", false, "With --stack=none, list the stack's commits as a review checklist in the PR description")
+	sendCmd.Flags().Bool("title-prefix", false, "Prefix PR titles with their position in the stack, e.g. [2/5], renumbered as the stack changes")
 	sendCmd.Flags().Bool("no-stack", false, "Send only the tip of each stack as a single PR")
 	_ = sendCmd.Flags().MarkDeprecated("no-stack", "use --stack=none")
 	sendCmd.Flags().Bool("rebase", false, "Rebase the stack onto the base branch before sending")
+	sendCmd.Flags().Bool("split-by-file", false, "Split the change to send into a stack of changes by --split-groups first")
+	sendCmd.Flags().StringSlice("split-groups", nil, "File groups for --split-by-file, bottom of the stack first; each a space-separated list of globs, e.g. \"proto/** *.proto\"")
 	sendCmd.Flags().Bool("diff-since-jip", false, "Diff against jip's own last send (recorded in the PR) instead of the current remote head, so direct pushes by others don't distort the \"changes since\" comment")
 	sendCmd.Flags().String("no-change-comment", "default", "Comment posted when an updated PR has no code changes: default (formatted comment), short (one plain line), or none")
+	sendCmd.Flags().Bool("no-interdiff", false, "Post no \"changes since\" comments on updated PRs")
+	sendCmd.Flags().String("interdiff-threshold", "", "Post the \"changes since\" diff only when it changes more than lines=N lines or touches more than files=M files, and just the compare link otherwise, e.g. lines=50,files=5")
+	sendCmd.Flags().Bool("diff-gist", false, "Upload the full diff as a secret gist when a \"changes since\" comment is too large for GitHub and has to leave file diffs out")
+	sendCmd.Flags().Bool("revision-history", false, "Keep the \"changes since\" diffs in one rolling \"Revision history\" comment per PR, edited on every push, instead of a new comment per push")
+	sendCmd.Flags().Bool("trailers", false, "Record the stack position and PR number as git trailers (Jip-Stack, Jip-PR) in the pushed commit messages")
+	sendCmd.Flags().String("summary-file", "", "Append a markdown report of the send to this file (e.g. $GITHUB_STEP_SUMMARY in GitHub Actions)")
+	sendCmd.Flags().Bool("ci", false, "Run non-interactively for CI: annotate the run with GitHub Actions workflow commands and exit with 2 when changes were skipped, 1 on other failures")
+	sendCmd.Flags().String("describe", "", "Description for the working copy (@) when it is included but undescribed")
+	sendCmd.Flags().String("body-file", "", "Append this markdown file (- for stdin) below the generated description of the top PR of each stack")
+	sendCmd.Flags().String("revset-file", "", "Read additional revsets from a file, one per line (- for stdin)")
+	sendCmd.Flags().Bool("select", false, "Pick the changes to send from a list of the stacks of the revsets (default: all your changes)")
+	sendCmd.Flags().Bool("resume", false, "Continue the last send where it failed, skipping the bookmarks it pushed and the PRs it already created or updated")
+	sendCmd.Flags().Bool("refresh", false, "Re-query GitHub for existing PRs instead of using the cached lookup")
+	sendCmd.Flags().Bool("no-fetch", false, "Don't fetch the remotes first; send from the last fetched state")
+	sendCmd.Flags().Int("max-prs", 20, "Abort without sending when more PRs than this would be created (0 disables the limit)")
+	sendCmd.Flags().BoolP("yes", "y", false, "Send even when more PRs would be created than --max-prs allows")
+	sendCmd.Flags().Int("large-pr-lines", 400, "Warn about PRs changing more lines than this (0 disables the warning)")
+	sendCmd.Flags().String("cleanup", cleanupOff, "After fetching, delete the jip branches of merged or closed PRs from the remote and locally: yes (without asking), ask (confirm on a terminal), or off")
+	sendCmd.Flags().Lookup("cleanup").NoOptDefVal = cleanupYes
+	sendCmd.Flags().String("title-pattern", "", "Check change titles before sending: conventional (conventional commit format) or a regular expression")
+	sendCmd.Flags().String("title-lint", titleLintError, "What to do with titles not matching --title-pattern: error (send nothing) or warn")
+	sendCmd.Flags().String("signature-check", signatureCheckOff, "Check that commits are signed where GitHub requires signed commits: off, warn, or error (send nothing)")
+	sendCmd.Flags().Bool("no-verify", false, "Skip the --title-pattern and --signature-check checks")
+	sendCmd.Flags().String("bookmark-template", "", "Template for the names of new bookmarks, e.g. {{.User}}/{{.Slug}}-{{.ShortID}} (default jip/{{.Slug}}/{{.ShortID}})")
+	sendCmd.Flags().Int("slug-length", 30, "Maximum length of the slug of the change title in new bookmark names")
+	sendCmd.Flags().String("open", openNone, "Open PRs in the browser after sending: all (every created PR), top (the top PR of each stack with a created PR), or none")
+	sendCmd.Flags().Lookup("open").NoOptDefVal = openAll
+	sendCmd.Flags().BoolP("quiet", "q", false, "Print only the summary (and warnings), not the steps of the send")
+	addNoLockFlag(sendCmd)
 
 	_ = sendCmd.RegisterFlagCompletionFunc("base", completeJJBookmarks)
+	_ = sendCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = sendCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+	_ = sendCmd.RegisterFlagCompletionFunc("reviewer", completeReviewers)
 	_ = sendCmd.RegisterFlagCompletionFunc("no-change-comment",
 		cobra.FixedCompletions([]string{"default", "short", "none"}, cobra.ShellCompDirectiveNoFileComp))
 	_ = sendCmd.RegisterFlagCompletionFunc("stack",
 		cobra.FixedCompletions([]string{stackModeDefault, stackModeNative, stackModeNone}, cobra.ShellCompDirectiveNoFileComp))
+	_ = sendCmd.RegisterFlagCompletionFunc("stack-order",
+		cobra.FixedCompletions([]string{stackOrderNewest, stackOrderOldest}, cobra.ShellCompDirectiveNoFileComp))
+	_ = sendCmd.RegisterFlagCompletionFunc("title-lint",
+		cobra.FixedCompletions([]string{titleLintError, titleLintWarn}, cobra.ShellCompDirectiveNoFileComp))
+	_ = sendCmd.RegisterFlagCompletionFunc("signature-check",
+		cobra.FixedCompletions([]string{signatureCheckOff, signatureCheckWarn, signatureCheckError}, cobra.ShellCompDirectiveNoFileComp))
+	_ = sendCmd.RegisterFlagCompletionFunc("cleanup",
+		cobra.FixedCompletions([]string{cleanupYes, cleanupAsk, cleanupOff}, cobra.ShellCompDirectiveNoFileComp))
+	_ = sendCmd.RegisterFlagCompletionFunc("open",
+		cobra.FixedCompletions([]string{openAll, openTop, openNone}, cobra.ShellCompDirectiveNoFileComp))
+
+	// The copies backport makes are sent, so it takes all of send's flags.
+	// They are added here because backport.go's init runs before this one.
+	backportCmd.Flags().AddFlagSet(sendCmd.Flags())
 }
 
 // Stacking modes for the --stack flag.
@@ -62,32 +131,102 @@ const (
 	stackModeNone    = "none"      // single PR per stack tip, no stacking
 )
 
+// Orders of the stack navigation for the --stack-order flag.
+const (
+	stackOrderNewest = "newest-first"
+	stackOrderOldest = "oldest-first"
+)
+
+// prCacheTTL is how long a cached branch → PR lookup is trusted before send
+// queries GitHub again. Kept short: the cached title and body decide whether
+// a PR needs updating, and someone may edit the PR on GitHub in between.
+const prCacheTTL = 5 * time.Minute
+
 // sendConfigKeys lists the send flags that may be set from config files.
-// Per-invocation flags (--dry-run, --existing) are deliberately excluded.
+// Per-invocation flags (--dry-run, --yes, --existing, --resume, --refresh, --no-fetch, --revset-file,
+// --summary-file, --ci, --describe, --body-file, --no-verify, --no-lock, --split-by-file, --quiet) are deliberately excluded.
 var sendConfigKeys = map[string]bool{
-	"base":              true,
-	"remote":            true,
-	"upstream":          true,
-	"draft":             true,
-	"stack":             true,
-	"no-stack":          true,
-	"rebase":            true,
-	"diff-since-jip":    true,
-	"reviewer":          true,
-	"no-change-comment": true,
+	"base":                 true,
+	"remote":               true,
+	"upstream":             true,
+	"draft":                true,
+	"auto-merge":           true,
+	"milestone":            true,
+	"project":              true,
+	"stack":                true,
+	"stack-order":          true,
+	"stack-titles":         true,
+	"stack-status":         true,
+	"commit-checklist":     true,
+	"title-prefix":         true,
+	"no-stack":             true,
+	"rebase":               true,
+	"split-groups":         true,
+	"diff-since-jip":       true,
+	"reviewer":             true,
+	"assignees-from-blame": true,
+	"suggest-reviewers":    true,
+	"no-change-comment":    true,
+	"trailers":             true,
+	"revision-history":     true,
+	"diff-gist":            true,
+	"no-interdiff":         true,
+	"interdiff-threshold":  true,
+	"cleanup":              true,
+	"large-pr-lines":       true,
+	"max-prs":              true,
+	"title-pattern":        true,
+	"title-lint":           true,
+	"signature-check":      true,
+	"bookmark-template":    true,
+	"slug-length":          true,
+	"open":                 true,
+}
+
+// globalConfigKeys are config keys that configure jip itself rather than a
+// command's flags. They are read from the global config only and skipped when
+// applying flag config.
+var globalConfigKeys = map[string]bool{
+	"jj-bin":        true,
+	"encrypt":       true,
+	"age-recipient": true,
+	"age-identity":  true,
+
+	"ca-cert":            true,
+	"tls-insecure-hosts": true,
+
+	"retry-attempts":    true,
+	"retry-backoff":     true,
+	"retry-max-backoff": true,
+	"retry-max-wait":    true,
+
+	"github-app-id":           true,
+	"github-app-key":          true,
+	"github-app-installation": true,
+
+	"language": true,
 }
 
+// forgeConfigKey names the forge the repository is hosted on (github or
+// gitea). It is read by resolveRepoContext rather than applied to a flag.
+const forgeConfigKey = "forge"
+
 // applySendConfig sets flag values from config files for flags that were not
-// given on the command line, so CLI flags always win.
+// given on the command line, so CLI flags always win. Other commands that
+// share some of send's flags (e.g. --remote) use it too; keys for flags they
+// do not define are ignored.
 func applySendConfig(flags *pflag.FlagSet, cfg map[string]string) error {
 	for _, key := range slices.Sorted(maps.Keys(cfg)) {
+		if globalConfigKeys[key] || key == forgeConfigKey {
+			continue
+		}
 		if !sendConfigKeys[key] {
 			return fmt.Errorf("unsupported config key %q (supported: %s)",
 				key, strings.Join(slices.Sorted(maps.Keys(sendConfigKeys)), ", "))
 		}
 		f := flags.Lookup(key)
-		if f.Changed {
-			continue
+		if f == nil || f.Changed {
+			continue // not a flag of this command, or set on the command line
 		}
 		if err := flags.Set(key, cfg[key]); err != nil {
 			return fmt.Errorf("config key %q: %w", key, err)
@@ -96,6 +235,60 @@ func applySendConfig(flags *pflag.FlagSet, cfg map[string]string) error {
 	return nil
 }
 
+// fetchScope returns the branch patterns send needs from remote: the base
+// branch, the other base branches, jip's branches (as named by namer) and the branches of local
+// bookmarks tracking remote. Other branches, usually most of a big
+// repository's, are left unfetched. It returns nil, fetching everything, when
+// the base branch cannot be resolved from the last fetched state (e.g. on the
+// first send) or jip's branches have no common prefix.
+func fetchScope(runner jj.Runner, base string, branches []string, remote string, namer *jj.BookmarkNamer) []string {
+	globs := namer.Globs()
+	if globs == nil {
+		return nil
+	}
+	data, err := runner.BookmarkList()
+	if err != nil {
+		return nil
+	}
+	bookmarks, err := jj.ParseBookmarkList(data)
+	if err != nil {
+		return nil
+	}
+	baseBranch, err := jj.ResolveBaseBranch(runner, base, bookmarks, remote)
+	if err != nil {
+		slog.Debug("fetching every branch", "err", err)
+		return nil
+	}
+	branches = append([]string{baseBranch}, branches...)
+	var scope []string
+	for _, branch := range branches {
+		scope = append(scope, "exact:"+branch)
+	}
+	scope = append(scope, globs...)
+	for _, b := range bookmarks {
+		if rs, ok := b.Remotes[remote]; ok && rs.Tracked && b.Present &&
+			!slices.Contains(branches, b.Name) && !namer.Owns(b.Name) {
+			scope = append(scope, "exact:"+b.Name)
+		}
+	}
+	return scope
+}
+
+// resolveAutoMerge validates the --auto-merge method. A config file's
+// auto-merge = true means the default method, and false turns it off.
+func resolveAutoMerge(method string) (string, error) {
+	switch method {
+	case "", "false":
+		return "", nil
+	case "true":
+		return "squash", nil
+	}
+	if !slices.Contains(forge.AutoMergeMethods, method) {
+		return "", fmt.Errorf("invalid --auto-merge value %q (valid: %s)", method, strings.Join(forge.AutoMergeMethods, ", "))
+	}
+	return method, nil
+}
+
 // resolveStackMode reconciles the --stack flag with the deprecated --no-stack.
 // CLI flags beat config values: --no-stack on the command line overrides a
 // config-supplied stack key, while an explicit --stack overrides a
@@ -118,18 +311,167 @@ func resolveStackMode(stack string, stackSet, noStack, noStackOnCLI bool) (strin
 type sendOpts struct {
 	base            string
 	remote          string
-	upstream        string // upstream remote URL (where PRs are opened); empty = same as remote
-	upstreamRemote  string // upstream as a named remote (for fetching); empty when upstream is a URL
-	pushOwner       string // owner parsed from push remote (for cross-fork head prefix)
+	upstream        string      // upstream remote URL (where PRs are opened); empty = same as remote
+	upstreamRemote  string      // upstream as a named remote (for fetching); empty when upstream is a URL
+	pushOwner       string      // owner parsed from push remote (for cross-fork head prefix)
+	pushRepo        string      // repo name parsed from push remote; set together with pushOwner
+	repos           repoLookups // the repositories resolveRepoContext looked up
 	dryRun          bool
 	draft           bool
+	wip             bool   // make the PRs drafts with a "WIP: " title prefix
+	ready           bool   // undraft the PRs and drop their "WIP: " title prefix
+	autoMerge       string // merge method to enable auto-merge with; "" leaves auto-merge off
+	milestone       string // milestone title for new PRs; "" for none
+	project         string // --project board for new PRs; "" for none
 	existing        bool
 	stackMode       string // stackModeDefault (or ""), stackModeNative, or stackModeNone
+	stackOrder      string // stackOrderNewest (or "") or stackOrderOldest
+	stackTitles     bool   // show PR titles in the stack navigation
+	stackStatus     bool   // show drafts and merged or closed PRs in the stack navigation
+	checklist       bool   // with stackModeNone, list the stack's commits in the PR body
+	titlePrefix     bool   // prefix PR titles with the stack position
 	rebase          bool
+	splitGroups     []fileGroup // split the change to send by these first; nil disables
 	diffSinceJip    bool
-	noChangeComment string // "default" (or ""), "short", or "none"
+	noChangeComment string             // "default" (or ""), "short", or "none"
+	revisionHistory bool               // edit one rolling history comment instead of posting per push
+	diffGist        bool               // link the full diff from a gist when the comment leaves diffs out
+	noInterdiff     bool               // post no "changes since" comments
+	interdiffMin    interdiffThreshold // post smaller diffs as the compare link only
 	reviewers       []string
+	blameReviewers  bool // also request reviews from the authors of the touched lines
+	ownerReviewers  bool // also request reviews from the CODEOWNERS of the touched files
 	revsets         []string
+	otherBases      []sendBase   // stacks sent to other bases than base (--base BRANCH=REVSET)
+	trailers        bool         // write Jip-* trailers into the commit descriptions
+	summaryFile     string       // append a markdown report here; "" disables it
+	ci              bool         // annotate the report with GitHub Actions workflow commands
+	resume          bool         // continue the send recorded in state.Progress
+	refresh         bool         // ignore the cached PR lookup
+	noFetch         bool         // use the last fetched state of the remotes
+	cleanup         string       // cleanupYes, cleanupAsk, or cleanupOff (or "")
+	largePRLines    int          // warn about PRs changing more lines; 0 disables
+	maxPRs          int          // abort when more PRs would be created; 0 disables
+	yes             bool         // send even beyond maxPRs
+	state           *state.State // persisted repo state; nil disables caching
+
+	// extras are the hand-written descriptions appended below the generated
+	// PR bodies (--body-file and .jip/body); nil keeps the ones PRs have.
+	extras *extraDescriptions
+
+	// describe is the description for an undescribed working copy
+	// (--describe); askDescription asks for one instead, and is nil when
+	// jip is not run interactively.
+	describe       string
+	askDescription func(*jj.Change) (string, error)
+
+	// confirmCleanup asks whether to delete the jip branches of merged or
+	// closed PRs with --cleanup=ask; nil when not interactive.
+	confirmCleanup func([]staleBranch) (bool, error)
+
+	// bookmarkTemplate and slugLength name new bookmarks (see
+	// jj.NewBookmarkNamer); namer is built from them by executeSend.
+	bookmarkTemplate string
+	slugLength       int
+	namer            *jj.BookmarkNamer
+
+	// titleLint checks the change titles before anything is pushed; nil
+	// without --title-pattern or with --no-verify.
+	titleLint *titleLinter
+
+	// signatureCheck is the --signature-check mode; off with --no-verify.
+	signatureCheck string
+
+	// confirmUndoRebase asks whether to undo a --rebase that left changes
+	// with conflicts; nil when not interactive.
+	confirmUndoRebase func() (bool, error)
+
+	// retiredBranches are existing branches whose PR the send replaces: they
+	// are never reused for a change's PR. sent is called with the PRs once
+	// they are all created and updated; nil for none.
+	retiredBranches map[string]bool
+	sent            func([]changeState) error
+
+	// open is the --open mode; openURL opens a PR in the browser and is nil
+	// when PRs are not opened (e.g. --ci).
+	open    string
+	openURL func(url string) error
+
+	// progress renders the steps of the send (--quiet, spinners on a
+	// terminal); nil prints them as plain lines.
+	progress *progress
+	colors   colors // for the summary
+}
+
+// sendBase is a base and the revsets whose stacks are sent to it: the
+// default base, a revset, or a branch given as BRANCH=REVSET.
+type sendBase struct {
+	base    string // revset of the default base; "" for a branch
+	branch  string // branch name; "" for the default base
+	revsets []string
+}
+
+// name returns the base as the user gave it.
+func (b sendBase) name() string {
+	if b.branch == "" {
+		return b.base
+	}
+	return b.branch
+}
+
+// revset returns the revset of the base: a branch is where remote has it.
+func (b sendBase) revset(remote string) string {
+	if b.branch == "" {
+		return b.base
+	}
+	return fmt.Sprintf("remote_bookmarks(exact:%q, exact:%q)", b.branch, remote)
+}
+
+// bases returns the bases of the send with their revsets: base with revsets,
+// unless every revset goes to another base, and then otherBases.
+func (opts *sendOpts) bases() []sendBase {
+	var bases []sendBase
+	if len(opts.revsets) > 0 {
+		bases = append(bases, sendBase{base: opts.base, revsets: opts.revsets})
+	}
+	return append(bases, opts.otherBases...)
+}
+
+// otherBranches returns the branches of opts.otherBases.
+func (opts *sendOpts) otherBranches() []string {
+	var branches []string
+	for _, b := range opts.otherBases {
+		branches = append(branches, b.branch)
+	}
+	return branches
+}
+
+// parseBases splits the values of --base into the default base (the last
+// plain value, or fallback without one) and BRANCH=REVSET entries, grouped
+// by branch in the order given. Only a plain branch name may precede the =,
+// so revsets with keyword arguments such as remote_bookmarks(main,
+// remote=upstream) still work as the default base.
+func parseBases(values []string, fallback string) (string, []sendBase, error) {
+	base := fallback
+	var others []sendBase
+	for _, v := range values {
+		branch, revset, ok := strings.Cut(v, "=")
+		branch, revset = strings.TrimSpace(branch), strings.TrimSpace(revset)
+		if !ok || strings.ContainsAny(branch, "()|&~ ,:") {
+			base = v
+			continue
+		}
+		if branch == "" || revset == "" {
+			return "", nil, fmt.Errorf("invalid --base %q: expected BRANCH=REVSET", v)
+		}
+		i := slices.IndexFunc(others, func(b sendBase) bool { return b.branch == branch })
+		if i == -1 {
+			others = append(others, sendBase{branch: branch})
+			i = len(others) - 1
+		}
+		others[i].revsets = append(others[i].revsets, revset)
+	}
+	return base, others, nil
 }
 
 // skippedEntry records a change that was pre-skipped (before bookmark creation).
@@ -142,9 +484,9 @@ type skippedEntry struct {
 type changeState struct {
 	change   *jj.Change
 	bookmark jj.ChangeBookmark
-	pr       *gh.PRInfo // nil if no existing PR
-	isNew    bool       // true if PR was just created
-	changed  bool       // true if existing PR was modified (title, body, or interdiff)
+	pr       *forge.PRInfo // nil if no existing PR
+	isNew    bool          // true if PR was just created
+	changed  bool          // true if existing PR was modified (title, body, or interdiff)
 }
 
 // skipReason records why a change was skipped during send.
@@ -158,6 +500,14 @@ type skipReason struct {
 }
 
 func runSend(cmd *cobra.Command, args []string) error {
+	return sendCommand(cmd, args, nil)
+}
+
+// sendCommand runs send with the flags of cmd. prepare, when not nil, may
+// adjust the options once the forge client is resolved and the repository
+// locked, before anything is sent; split-pr uses it to send a stack in place
+// of an existing PR, backport to send copies of changes to other branches.
+func sendCommand(cmd *cobra.Command, args []string, prepare func(jj.Runner, forge.Service, *sendOpts) error) error {
 	runner, repoRoot, err := workspaceRunner()
 	if err != nil {
 		return err
@@ -180,7 +530,17 @@ func runSend(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	base, _ := cmd.Flags().GetString("base")
+	baseValues, _ := cmd.Flags().GetStringArray("base")
+	// Only BRANCH=REVSET entries on the command line leave the default base
+	// to the config file.
+	fallback := "trunk()"
+	if cfg["base"] != "" && !strings.Contains(cfg["base"], "=") {
+		fallback = cfg["base"]
+	}
+	base, otherBases, err := parseBases(baseValues, fallback)
+	if err != nil {
+		return err
+	}
 	remote, _ := cmd.Flags().GetString("remote")
 	upstream, _ := cmd.Flags().GetString("upstream")
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
@@ -194,7 +554,24 @@ func runSend(cmd *cobra.Command, args []string) error {
 		}
 	}
 	reviewers = cleanReviewers
+	blame, _ := cmd.Flags().GetBool("assignees-from-blame")
+	owners, _ := cmd.Flags().GetBool("suggest-reviewers")
 	draft, _ := cmd.Flags().GetBool("draft")
+	wip, _ := cmd.Flags().GetBool("wip")
+	ready, _ := cmd.Flags().GetBool("ready")
+	switch {
+	case wip && ready:
+		return fmt.Errorf("--wip and --ready cannot be combined")
+	case ready && draft:
+		return fmt.Errorf("--ready cannot be combined with --draft")
+	}
+	autoMergeFlag, _ := cmd.Flags().GetString("auto-merge")
+	autoMerge, err := resolveAutoMerge(autoMergeFlag)
+	if err != nil {
+		return err
+	}
+	milestone, _ := cmd.Flags().GetString("milestone")
+	project, _ := cmd.Flags().GetString("project")
 	existing, _ := cmd.Flags().GetBool("existing")
 	stackFlag, _ := cmd.Flags().GetString("stack")
 	noStack, _ := cmd.Flags().GetBool("no-stack")
@@ -202,7 +579,25 @@ func runSend(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	stackOrder, _ := cmd.Flags().GetString("stack-order")
+	if err := checkStackOrder(stackOrder); err != nil {
+		return err
+	}
+	stackTitles, _ := cmd.Flags().GetBool("stack-titles")
+	stackStatus, _ := cmd.Flags().GetBool("stack-status")
+	checklist, _ := cmd.Flags().GetBool("commit-checklist")
+	titlePrefix, _ := cmd.Flags().GetBool("title-prefix")
 	rebase, _ := cmd.Flags().GetBool("rebase")
+	var splitGroups []fileGroup
+	if split, _ := cmd.Flags().GetBool("split-by-file"); split {
+		entries, _ := cmd.Flags().GetStringSlice("split-groups")
+		if splitGroups, err = parseFileGroups(entries); err != nil {
+			return err
+		}
+		if len(splitGroups) == 0 {
+			return fmt.Errorf("--split-by-file needs file groups — set split-groups in .jip.toml or pass --split-groups")
+		}
+	}
 	diffSinceJip, _ := cmd.Flags().GetBool("diff-since-jip")
 	noChangeComment, _ := cmd.Flags().GetString("no-change-comment")
 	switch noChangeComment {
@@ -210,82 +605,215 @@ func runSend(cmd *cobra.Command, args []string) error {
 	default:
 		return fmt.Errorf("invalid --no-change-comment value %q (valid: default, short, none)", noChangeComment)
 	}
+	revisionHistory, _ := cmd.Flags().GetBool("revision-history")
+	diffGist, _ := cmd.Flags().GetBool("diff-gist")
+	noInterdiff, _ := cmd.Flags().GetBool("no-interdiff")
+	threshold, _ := cmd.Flags().GetString("interdiff-threshold")
+	interdiffMin, err := parseInterdiffThreshold(threshold)
+	if err != nil {
+		return err
+	}
+	trailers, _ := cmd.Flags().GetBool("trailers")
+	resume, _ := cmd.Flags().GetBool("resume")
+	refresh, _ := cmd.Flags().GetBool("refresh")
+	noFetch, _ := cmd.Flags().GetBool("no-fetch")
+	cleanupFlag, _ := cmd.Flags().GetString("cleanup")
+	cleanup, err := resolveCleanup(cleanupFlag)
+	if err != nil {
+		return err
+	}
+	largePRLines, _ := cmd.Flags().GetInt("large-pr-lines")
+	maxPRs, _ := cmd.Flags().GetInt("max-prs")
+	yes, _ := cmd.Flags().GetBool("yes")
+	titlePattern, _ := cmd.Flags().GetString("title-pattern")
+	titleLintMode, _ := cmd.Flags().GetString("title-lint")
+	titleLint, err := newTitleLinter(titlePattern, titleLintMode)
+	if err != nil {
+		return err
+	}
+	signatureCheck, _ := cmd.Flags().GetString("signature-check")
+	if err := checkSignatureMode(signatureCheck); err != nil {
+		return err
+	}
+	if noVerify, _ := cmd.Flags().GetBool("no-verify"); noVerify {
+		titleLint = nil
+		signatureCheck = signatureCheckOff
+	}
+	bookmarkTemplate, _ := cmd.Flags().GetString("bookmark-template")
+	slugLength, _ := cmd.Flags().GetInt("slug-length")
+	if slugLength <= 0 {
+		return fmt.Errorf("invalid --slug-length %d: it must be positive", slugLength)
+	}
+	summaryFile, _ := cmd.Flags().GetString("summary-file")
+	ci, _ := cmd.Flags().GetBool("ci")
+	if ci && summaryFile == "" {
+		summaryFile = os.Getenv("GITHUB_STEP_SUMMARY")
+	}
+	open, _ := cmd.Flags().GetString("open")
+	if err := checkOpenMode(open); err != nil {
+		return err
+	}
+	var openURL func(string) error
+	if open != openNone && !ci {
+		openURL = openBrowser
+	}
+	describe, _ := cmd.Flags().GetString("describe")
+	bodyFile, _ := cmd.Flags().GetString("body-file")
+	// Every prompt and "-" file reads standard input through one buffered
+	// reader, so input buffered for one is not lost to the next.
+	stdin := bufio.NewReader(cmd.InOrStdin())
+	extras, err := loadExtraDescriptions(filepath.Join(repoRoot, bodyDir), bodyFile, stdin)
+	if err != nil {
+		return err
+	}
 	w := cmd.OutOrStdout()
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	prog := newProgress(w, progressModeFor(w, quiet, ci))
+	var askDescription func(*jj.Change) (string, error)
+	var confirmCleanup func([]staleBranch) (bool, error)
+	var confirmUndoRebase func() (bool, error)
+	if stdinIsTerminal() && !ci {
+		askDescription = promptDescription(stdin, prog.prompt())
+		confirmCleanup = promptCleanup(stdin, prog.prompt())
+		confirmUndoRebase = promptUndoRebase(stdin, prog.prompt())
+	}
 
 	revsets := args
-	if len(revsets) == 0 {
-		revsets = []string{"@-"}
+	if path, _ := cmd.Flags().GetString("revset-file"); path != "" {
+		fromFile, err := readRevsetFile(path, stdin)
+		if err != nil {
+			return err
+		}
+		if len(fromFile) == 0 {
+			return fmt.Errorf("revset file %s contains no revsets", path)
+		}
+		revsets = append(revsets, fromFile...)
 	}
-
-	// 1. Resolve auth.
-	token, source := auth.ResolveToken(defaultHost)
-	if token == "" {
-		return fmt.Errorf("not authenticated — run 'jip auth login' or set GH_TOKEN")
+	pick, _ := cmd.Flags().GetBool("select")
+	switch {
+	case !pick:
+	case resume || len(otherBases) > 0:
+		return fmt.Errorf("--select cannot be combined with --resume or BRANCH=REVSET bases")
+	case !stdinIsTerminal() || ci:
+		return fmt.Errorf("--select needs an interactive terminal — pass the revsets to send instead")
+	case len(revsets) == 0:
+		revsets = []string{selectRevset}
+	}
+	// A resumed send defaults to the revsets of the send it continues; one
+	// with BRANCH=REVSET bases sends only those.
+	if len(revsets) == 0 && !resume && len(otherBases) == 0 {
+		revsets = []string{"@-"}
 	}
-	_, _ = fmt.Fprintf(w, "Auth: %s\n", source)
 
-	// 2. Detect repo from remote.
-	remoteData, err := runner.GitRemoteList()
+	// 1. Resolve auth and the GitHub repository.
+	rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], prog)
 	if err != nil {
-		return fmt.Errorf("listing remotes: %w", err)
-	}
-	remotes := jj.ParseRemoteList(remoteData)
-	remoteURL, ok := remotes[remote]
-	if !ok {
-		return fmt.Errorf("remote %q not found (available: %v)", remote, remotes)
-	}
-
-	// Resolve upstream URL: if set, PRs target that repo; otherwise same as push remote.
-	upstreamURL := remoteURL
-	upstreamIsRemote := false // true when --upstream is a remote name (not a URL)
-	if upstream != "" {
-		if strings.Contains(upstream, "://") || strings.Contains(upstream, "@") {
-			upstreamURL = upstream
-		} else if u, ok := remotes[upstream]; ok {
-			upstreamURL = u
-			upstreamIsRemote = true
-		} else {
-			return fmt.Errorf("upstream remote %q not found (available: %v)", upstream, remotes)
+		if ci {
+			writeWorkflowCommand(w, "error", "jip send failed", err.Error())
 		}
+		return err
 	}
 
-	apiURL := os.Getenv("GITHUB_API_URL")
-	client, err := gh.NewClient(token, upstreamURL, apiURL)
+	// Even a dry run fetches and creates local bookmarks.
+	release, err := lockRepo(cmd, repoRoot, prog)
 	if err != nil {
 		return err
 	}
-	_, _ = fmt.Fprintf(w, "Repo: %s/%s\n", client.Owner(), client.Repo())
+	defer release()
 
-	// For cross-fork PRs, parse the push remote owner to prefix the head ref.
-	var pushOwner string
-	if upstream != "" {
-		pushOwner, _, err = gh.ParseRepoFromURL(remoteURL)
+	st, err := state.Load(state.Path(repoRoot))
+	if err != nil {
+		_, _ = fmt.Fprintf(prog, "warning: ignoring unreadable jip state: %v\n", err)
+		st = nil
+	}
+
+	opts := sendOpts{
+		base:              base,
+		remote:            remote,
+		upstream:          upstream,
+		upstreamRemote:    rc.upstreamRemote,
+		pushOwner:         rc.pushOwner,
+		pushRepo:          rc.pushRepo,
+		repos:             rc.repos,
+		dryRun:            dryRun,
+		draft:             draft,
+		wip:               wip,
+		ready:             ready,
+		autoMerge:         autoMerge,
+		milestone:         milestone,
+		project:           project,
+		existing:          existing,
+		stackMode:         stackMode,
+		stackOrder:        stackOrder,
+		stackTitles:       stackTitles,
+		stackStatus:       stackStatus,
+		checklist:         checklist,
+		titlePrefix:       titlePrefix,
+		rebase:            rebase,
+		splitGroups:       splitGroups,
+		diffSinceJip:      diffSinceJip,
+		noChangeComment:   noChangeComment,
+		revisionHistory:   revisionHistory,
+		diffGist:          diffGist,
+		noInterdiff:       noInterdiff,
+		interdiffMin:      interdiffMin,
+		reviewers:         reviewers,
+		blameReviewers:    blame,
+		ownerReviewers:    owners,
+		revsets:           revsets,
+		otherBases:        otherBases,
+		trailers:          trailers,
+		summaryFile:       summaryFile,
+		resume:            resume,
+		refresh:           refresh,
+		noFetch:           noFetch,
+		cleanup:           cleanup,
+		largePRLines:      largePRLines,
+		maxPRs:            maxPRs,
+		yes:               yes,
+		state:             st,
+		describe:          describe,
+		extras:            extras,
+		askDescription:    askDescription,
+		confirmCleanup:    confirmCleanup,
+		titleLint:         titleLint,
+		signatureCheck:    signatureCheck,
+		bookmarkTemplate:  bookmarkTemplate,
+		slugLength:        slugLength,
+		confirmUndoRebase: confirmUndoRebase,
+		ci:                ci,
+		open:              open,
+		openURL:           openURL,
+		progress:          prog,
+		colors:            colorsFor(w),
+	}
+	if prepare != nil {
+		if err := prepare(runner, rc.client, &opts); err != nil {
+			return err
+		}
+	}
+	if pick {
+		namer, err := jj.NewBookmarkNamer(opts.bookmarkTemplate, opts.slugLength, rc.client.GetAuthenticatedUser)
+		if err != nil {
+			return err
+		}
+		opts.revsets, err = selectChanges(runner, rc.client, namer, base, remote, opts.revsets, stdin, w)
+		if errors.Is(err, errNothingSelected) {
+			_, _ = fmt.Fprintln(w, i18n.T("Nothing selected."))
+			return nil
+		}
 		if err != nil {
-			return fmt.Errorf("parsing push remote URL: %w", err)
+			return err
 		}
 	}
-
-	var upstreamRemoteName string
-	if upstreamIsRemote {
-		upstreamRemoteName = upstream
+	err = executeSend(runner, rc.client, opts, w)
+	if err != nil && !dryRun && st != nil && st.Progress != nil {
+		_, _ = fmt.Fprintln(w, i18n.T("\nThe send did not finish — run jip send --resume to continue where it stopped."))
 	}
-
-	return executeSend(runner, client, sendOpts{
-		base:            base,
-		remote:          remote,
-		upstream:        upstream,
-		upstreamRemote:  upstreamRemoteName,
-		pushOwner:       pushOwner,
-		dryRun:          dryRun,
-		draft:           draft,
-		existing:        existing,
-		stackMode:       stackMode,
-		rebase:          rebase,
-		diffSinceJip:    diffSinceJip,
-		noChangeComment: noChangeComment,
-		reviewers:       reviewers,
-		revsets:         revsets,
-	}, w)
+	if ci {
+		return ciExitError(err)
+	}
+	return err
 }
 
 // workspaceRunner locates the jj workspace containing the current working
@@ -294,6 +822,9 @@ func runSend(cmd *cobra.Command, args []string) error {
 // the workspace root (rather than the cwd) is what lets jip run from a
 // subdirectory of the repository.
 func workspaceRunner() (jj.Runner, string, error) {
+	if err := probeJJ(); err != nil {
+		return nil, "", err
+	}
 	cwd, err := os.Getwd()
 	if err != nil {
 		return nil, "", fmt.Errorf("getting cwd: %w", err)
@@ -303,90 +834,532 @@ func workspaceRunner() (jj.Runner, string, error) {
 		return nil, "", err
 	}
 	if root == "" {
-		return nil, "", fmt.Errorf("%s is not in a jj repository", cwd)
+		return nil, "", fmt.Errorf("%s: %w", cwd, jj.ErrNotARepo)
+	}
+	return jj.NewRunnerContext(runContext, root), root, nil
+}
+
+// errUserUnknown stands in for the user of a bookmark template when there
+// is no forge to ask.
+var errUserUnknown = errors.New("the user is not known")
+
+// configBookmarkNamer returns the namer of cfg's bookmark-template, which
+// tells the bookmarks jip named from others. user looks up the user of a
+// template using {{.User}}; without it (nil), such a template cannot be
+// rendered and the default names, which every namer owns, are assumed.
+func configBookmarkNamer(cfg map[string]string, user func() (string, error)) (*jj.BookmarkNamer, error) {
+	if user == nil {
+		user = func() (string, error) { return "", errUserUnknown }
+	}
+	namer, err := jj.NewBookmarkNamer(cfg["bookmark-template"], 0, user)
+	if errors.Is(err, errUserUnknown) {
+		return jj.NewBookmarkNamer("", 0, nil)
+	}
+	return namer, err
+}
+
+// probeJJ locates jj ($JJ_BIN, the global jj-bin config key, then PATH) and
+// checks its version. The key is not read from repo config so that a cloned
+// repository cannot choose the executable jip runs.
+func probeJJ() error {
+	cfg, err := config.Load("")
+	if err != nil {
+		return err
 	}
-	return jj.NewRunner(root), root, nil
+	_, err = jj.Probe(cfg["jj-bin"])
+	return err
 }
 
 // executeSend runs the core send algorithm: resolve stacks, ensure bookmarks,
 // push branches, and create/update PRs.
-func executeSend(runner jj.Runner, client gh.Service, opts sendOpts, w io.Writer) error {
+func executeSend(runner jj.Runner, client forge.Service, opts sendOpts, w io.Writer) (retErr error) {
 	if opts.stackMode == "" {
 		opts.stackMode = stackModeDefault
 	}
 
+	// The steps, and what is printed while they run, go to the progress;
+	// the summary goes to out once they are done.
+	out := w
+	report := &sendReport{repo: client.Owner() + "/" + client.Repo(), dryRun: opts.dryRun}
+	if opts.summaryFile != "" || opts.ci {
+		defer func() {
+			report.err = retErr
+			if opts.ci {
+				writeAnnotations(out, report)
+			}
+			if opts.summaryFile == "" {
+				return
+			}
+			if err := appendSummaryFile(opts.summaryFile, report); err != nil {
+				_, _ = fmt.Fprintf(out, "warning: %v\n", err)
+			}
+		}()
+	}
+	p := opts.progress
+	if p == nil {
+		p = newProgress(w, progressPlain)
+	}
+	defer p.finish()
+
+	// 1. Check what can be checked before anything is mutated, fetch, and
+	// rebase.
+	resume, project, err := prepareSend(client, &opts, p)
+	if err != nil {
+		return err
+	}
+	if opts.state != nil {
+		defer saveState(opts.state, p)
+	}
+	baseRemote := opts.remote
+	if opts.upstreamRemote != "" {
+		baseRemote = opts.upstreamRemote
+	}
+	rebaseConflicts, err := fetchAndRebase(runner, &opts, baseRemote, p)
+	if err != nil {
+		return err
+	}
+
+	// 2. Resolve the stacks.
+	stacks, err := resolveStacks(runner, client, opts, baseRemote, p)
+	if err != nil {
+		return err
+	}
+	if len(stacks.dags) == 0 {
+		p.finish()
+		_, _ = fmt.Fprintln(out, i18n.T("No changes to send."))
+		return nil
+	}
+
+	// 3. Pre-skip: remove changes that must not be pushed (immutable, empty
+	// description, private commits) plus their descendants, before creating
+	// bookmarks.
+	skips := &sendSkips{pre: stacks.skipped, reasons: make(map[string]skipReason)}
+	if stacks.dags, skips.pre = preSkip(stacks.dags, stacks.private, skips.pre); len(stacks.dags) == 0 && !opts.dryRun {
+		p.finish()
+		printPreSkippedChanges(out, skips.pre, opts.colors)
+		report.addSkips(nil, nil, skips.pre)
+		if n := nonBenignSkips(nil, nil, skips.pre); n > 0 {
+			return &skippedError{n: n, nothingSent: true}
+		}
+		_, _ = fmt.Fprint(out, i18n.T("\nNothing to send.\n"))
+		return nil
+	}
+
+	// Titles become PR titles: check them before anything is pushed.
+	if opts.titleLint != nil {
+		if err := lintTitles(opts.titleLint, stacks.dags, p); err != nil {
+			return err
+		}
+	}
+
+	// 4. Ensure a bookmark for each change, and look up their PRs.
+	states, bms, err := syncBookmarks(runner, client, opts, stacks, resume, baseRemote, p)
+	if err != nil {
+		return err
+	}
+	if opts.existing {
+		if states = existingOnly(states, p); len(states) == 0 {
+			return nil
+		}
+	}
+
+	// 5. Detect diverged/behind bookmarks and skip them (plus descendants).
+	active := skipUnsendable(states, rebaseConflicts, skips)
+
+	// GitHub native stacks cannot express branching or merging dependency
+	// graphs — each stack must be a single linear chain.
+	if opts.stackMode == stackModeNative {
+		if err := checkLinearStacks(active); err != nil {
+			return err
+		}
+	}
+	// A forge requiring signed commits rejects the push of unsigned ones.
+	if opts.signatureCheck != "" {
+		if err := checkSignatures(runner, client, active, bms.baseBranches, opts.signatureCheck, p); err != nil {
+			return err
+		}
+	}
+
+	// A broad revset can open dozens of PRs at once. Above the limit, show
+	// the plan instead of sending it.
+	newPRs := 0
+	for _, s := range active {
+		if s.pr == nil {
+			newPRs++
+		}
+	}
+	tooMany := !opts.dryRun && !opts.yes && opts.maxPRs > 0 && newPRs > opts.maxPRs
+	if opts.dryRun || tooMany {
+		// Show the CI status of the existing PRs, so it is clear which are
+		// mergeable before sending.
+		var prNumbers []int
+		for _, s := range active {
+			if s.pr != nil {
+				prNumbers = append(prNumbers, s.pr.Number)
+			}
+		}
+		checks := lookupChecks(client, prNumbers, p)
+		stats := diffStats(runner, active)
+		p.finish()
+		printSendPlan(opts, active, skips, checks, stats, tooMany, newPRs, report, out)
+		if tooMany {
+			return fmt.Errorf("send would create %d PRs, more than --max-prs=%d — check the revsets, then pass --yes or raise --max-prs", newPRs, opts.maxPRs)
+		}
+		if n := nonBenignSkips(skips.states, skips.reasons, skips.pre); n > 0 {
+			return &skippedError{n: n}
+		}
+		return nil
+	}
+
+	undo := startSendRecord(opts, active, bms.byName, resume)
+
+	// 6. Push bookmarks.
+	if len(active) > 0 {
+		active = pushSendBookmarks(runner, opts, active, bms.resumed, skips, p)
+	}
+
+	if len(active) > 0 {
+		// 7. Create/update PRs.
+		if err := createOrUpdatePRs(runner, client, opts, active, bms, project, undo, p); err != nil {
+			return err
+		}
+
+		// Record the stack position and PR number as commit trailers.
+		// This needs the PR numbers, so it rewrites the already-pushed commits
+		// and pushes them again; the body update below then records the
+		// rewritten commits.
+		if opts.trailers {
+			if err := writeTrailers(runner, active, client.Owner()+"/"+client.Repo(), opts, p); err != nil {
+				return err
+			}
+		}
+
+		// 8. Update all PR bodies, then comment on and enable auto-merge on
+		// the PRs.
+		if err := writePRBodies(client, opts, active, skips.states, stacks.squashed, p); err != nil {
+			return err
+		}
+		applyPRMetadata(client, opts, active, p)
+
+		recordSent(opts, active, undo)
+		p.finish()
+
+		// Hand the stack to split-pr, which replaces a PR with it.
+		if opts.sent != nil {
+			if err := opts.sent(active); err != nil {
+				return err
+			}
+		}
+
+		// 9. Print summary.
+		printSent(runner, opts, active, skips, report, out)
+	}
+
+	p.finish()
+	if len(skips.states) > 0 || len(skips.pre) > 0 {
+		printAllSkipped(out, skips.states, skips.reasons, skips.pre, opts.colors)
+	}
+	report.addSkips(skips.states, skips.reasons, skips.pre)
+	// Only non-benign skips (conflicts, divergence, missing description, …)
+	// constitute a failure. Private commits and up-to-date PRs are expected.
+	if n := nonBenignSkips(skips.states, skips.reasons, skips.pre); n > 0 {
+		return &skippedError{n: n}
+	}
+	return nil
+}
+
+// sendStacks are the stacks a send resolved, and what it found out about
+// them on the way.
+type sendStacks struct {
+	dags     []*jj.ChangeDAG
+	baseOf   map[string]string       // the base each change was resolved against, by change ID
+	private  map[string]bool         // changes matching git.private-commits
+	squashed map[string][]*jj.Change // with --stack=none --commit-checklist, each tip's stack
+	skipped  []skippedEntry          // megamerges and folded changes
+	listed   []byte                  // bookmark list output; nil when bookmarks moved since
+}
+
+// sendBookmarks are the bookmarks of the changes a send pushes, and what it
+// knows about them.
+type sendBookmarks struct {
+	byName       map[string]*jj.BookmarkInfo
+	baseBranches map[string]string             // the branch each change's PR targets, by change ID
+	resumed      map[string]state.SentBookmark // see resumedBookmarks
+}
+
+// sendSkips are the changes a send leaves out, and why.
+type sendSkips struct {
+	pre     []skippedEntry        // left out before their bookmarks were prepared
+	states  []changeState         // left out after
+	reasons map[string]skipReason // why each of states was, by change ID
+}
+
+// prepareSend checks what a send can check before it mutates anything,
+// builds opts.namer, and picks up the send opts.resume continues, which it
+// returns with the --project board.
+func prepareSend(client forge.Service, opts *sendOpts, w io.Writer) (*state.SendProgress, *projectRef, error) {
 	// gh-native mode: fail fast, before mutating anything.
 	if opts.stackMode == stackModeNative {
 		if opts.upstream != "" {
-			return fmt.Errorf("--stack=gh-native does not support --upstream: GitHub native stacks cannot span forks")
+			return nil, nil, fmt.Errorf("--stack=gh-native does not support --upstream: GitHub native stacks cannot span forks")
 		}
 		enabled, err := client.StacksEnabled()
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 		if !enabled {
-			return fmt.Errorf("GitHub native stacked PRs are not enabled for %s/%s — the feature is a private preview (https://gh.io/stacksbeta); use --stack=default until the repository is enrolled",
+			return nil, nil, fmt.Errorf("GitHub native stacked PRs are not enabled for %s/%s — the feature is a private preview (https://gh.io/stacksbeta); use --stack=default until the repository is enrolled",
 				client.Owner(), client.Repo())
 		}
 	}
 
-	// Fetch from remote (and upstream if it's a named remote).
-	_, _ = fmt.Fprintf(w, "Fetching %s...\n", opts.remote)
-	if err := runner.GitFetch(opts.remote); err != nil {
-		return fmt.Errorf("fetching %s: %w", opts.remote, err)
+	// A project of the repository owner is given by number alone.
+	project, err := parseProject(opts.project, client.Owner())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Make sure the token can reach every repository involved before
+	// anything is mutated, instead of failing halfway through on the first
+	// API call that touches the inaccessible side.
+	if err := checkRepoAccess(client, *opts); err != nil {
+		if !opts.dryRun {
+			return nil, nil, err
+		}
+		_, _ = fmt.Fprintf(w, "warning: %v\n", err)
+	}
+
+	namer, err := jj.NewBookmarkNamer(opts.bookmarkTemplate, opts.slugLength, client.GetAuthenticatedUser)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts.namer = namer
+
+	// A resumed send trusts what the interrupted one recorded about the
+	// bookmarks it pushed and their PRs.
+	if !opts.resume {
+		return nil, project, nil
+	}
+	if opts.state == nil || opts.state.Progress == nil {
+		return nil, nil, fmt.Errorf("no unfinished send to resume")
 	}
+	progress := opts.state.Progress
+	if progress.Remote != opts.remote {
+		return nil, nil, fmt.Errorf("the unfinished send pushed to %s — resume it with --remote %s", progress.Remote, progress.Remote)
+	}
+	if len(opts.revsets) == 0 && len(opts.otherBases) == 0 {
+		opts.revsets = progress.Revsets
+		for _, branch := range slices.Sorted(maps.Keys(progress.Bases)) {
+			opts.otherBases = append(opts.otherBases, sendBase{branch: branch, revsets: progress.Bases[branch]})
+		}
+	}
+	var what []string
+	for _, b := range opts.bases() {
+		what = append(what, b.revsets...)
+	}
+	_, _ = fmt.Fprintf(w, i18n.T("Resuming the send of %s from %s.\n"), strings.Join(what, " "), progress.At.Local().Format(time.DateTime))
+	return progress, project, nil
+}
+
+// fetchAndRebase fetches the remotes, moves opts.base to the upstream's
+// copy of the base branch, splits the change to send with --split, and
+// rebases the stacks with --rebase. It returns the changes the rebase left
+// with conflicts, with the base each was rebased onto.
+func fetchAndRebase(runner jj.Runner, opts *sendOpts, baseRemote string, p *progress) (map[string]string, error) {
+	// Fetch from remote (and upstream if it's a named remote). A dry run
+	// only reads, so it carries on from the local view when offline.
+	fetchRemotes := []string{opts.remote}
 	if opts.upstreamRemote != "" && opts.upstreamRemote != opts.remote {
-		_, _ = fmt.Fprintf(w, "Fetching %s...\n", opts.upstreamRemote)
-		if err := runner.GitFetch(opts.upstreamRemote); err != nil {
-			return fmt.Errorf("fetching %s: %w", opts.upstreamRemote, err)
+		fetchRemotes = append(fetchRemotes, opts.upstreamRemote)
+	}
+	if opts.noFetch {
+		fetchRemotes = nil
+	}
+	for _, remote := range fetchRemotes {
+		p.step(fmt.Sprintf(i18n.T("Fetching %s..."), remote))
+		if err := runner.GitFetch(remote, fetchScope(runner, opts.base, opts.otherBranches(), remote, opts.namer)...); err != nil {
+			if !opts.dryRun {
+				return nil, fmt.Errorf("fetching %s: %w", remote, err)
+			}
+			_, _ = fmt.Fprintf(p, "warning: could not fetch %s, continuing with local state: %v\n", remote, err)
 		}
 	}
 
-	// Rebase onto base branch if requested.
-	if opts.rebase {
-		_, _ = fmt.Fprintf(w, "Rebasing onto %s...\n", opts.base)
-		if err := runner.Rebase(opts.revsets, opts.base); err != nil {
-			return fmt.Errorf("rebasing onto %s: %w", opts.base, err)
+	// In a fork, origin's copy of the base branch may lag behind upstream's,
+	// which the PRs target: resolve and rebase the stacks against the latter.
+	opts.base = upstreamBase(runner, opts.base, opts.remote, opts.upstreamRemote, p)
+
+	// Split the change into a stack by file groups if requested. The
+	// revsets may name the bottom part afterwards; add the top.
+	if opts.splitGroups != nil {
+		top, err := splitByFile(runner, opts.revsets, opts.splitGroups, opts.dryRun, p)
+		if err != nil {
+			return nil, err
 		}
+		opts.revsets = append(slices.Clone(opts.revsets), top)
 	}
 
-	repoFullName := client.Owner() + "/" + client.Repo()
+	// Rebase onto base branch if requested. Changes the rebase leaves with
+	// conflicts cannot be sent; offer to undo it rather than send the rest.
+	rebaseConflicts := make(map[string]string) // change ID → the base it was rebased onto
+	if !opts.rebase {
+		return rebaseConflicts, nil
+	}
+	var conflicts []rebaseConflict
+	var undoOp string
+	for _, b := range opts.bases() {
+		more, op, err := rebaseStacks(runner, b.revsets, b.revset(baseRemote), p)
+		if err != nil {
+			return nil, err
+		}
+		for _, rc := range more {
+			rebaseConflicts[rc.change.ChangeID] = b.name()
+		}
+		conflicts = append(conflicts, more...)
+		if undoOp == "" {
+			undoOp = op
+		}
+	}
+	if len(conflicts) > 0 {
+		undo := false
+		if opts.confirmUndoRebase != nil && undoOp != "" {
+			var err error
+			if undo, err = opts.confirmUndoRebase(); err != nil {
+				return nil, err
+			}
+		}
+		if undo {
+			if err := runner.OpRestore(undoOp); err != nil {
+				return nil, fmt.Errorf("undoing the rebase: %w", err)
+			}
+			return nil, fmt.Errorf("rebase undone, nothing sent — resolve the conflicts with jj rebase and jj resolve, then send again")
+		}
+		_, _ = fmt.Fprintln(p, "The conflicted changes and their descendants are skipped.")
+		printUndoRebaseHint(undoOp, p)
+	}
+	return rebaseConflicts, nil
+}
 
-	// 2. Resolve stacks.
-	dags, err := jj.ResolveStacks(runner, opts.revsets, opts.base)
+// resolveStacks resolves the stacks of opts, each against its base, with
+// megamerges split and the changes to skip for a PR folded into the ones
+// above. The stacks are nil when there is nothing to send.
+func resolveStacks(runner jj.Runner, client forge.Service, opts sendOpts, baseRemote string, p *progress) (*sendStacks, error) {
+	// Each jj invocation takes a while on a big repository, so the
+	// bookmarks needed by syncBookmarks are listed meanwhile. The working
+	// copy is snapshotted first, so that the listing can skip the snapshot
+	// instead of taking it at the same time.
+	p.step(i18n.T("Resolving stacks..."))
+	if _, err := runner.Log("@"); err != nil {
+		return nil, fmt.Errorf("snapshotting the working copy: %w", err)
+	}
+	var (
+		stacks      sendStacks
+		bookmarkErr error
+		listing     sync.WaitGroup
+		err         error
+	)
+	listing.Go(func() { stacks.listed, bookmarkErr = jj.WithoutSnapshot(runner).BookmarkList() })
+	stacks.dags, stacks.baseOf, err = resolveSendStacks(runner, opts, baseRemote)
+	listing.Wait()
 	if err != nil {
-		return fmt.Errorf("resolving stacks: %w", err)
+		return nil, err
 	}
-	if len(dags) == 0 {
-		_, _ = fmt.Fprintln(w, "No changes to send.")
-		return nil
+	if bookmarkErr != nil {
+		stacks.listed = nil
 	}
 
-	// If --stack=none, reduce each DAG to its tip (leaf) change only.
+	// `jip send @` commonly includes an undescribed working copy. Describe
+	// it from --describe or a prompt so it can be sent; left undescribed, it
+	// is skipped below with an explanation.
+	if wc := undescribedWorkingCopy(stacks.dags); wc != nil {
+		msg := opts.describe
+		if msg == "" && opts.askDescription != nil && !opts.dryRun {
+			if msg, err = opts.askDescription(wc); err != nil {
+				return nil, err
+			}
+		}
+		switch msg = strings.TrimSpace(msg); {
+		case msg == "":
+		case opts.dryRun:
+			_, _ = fmt.Fprintf(p, "Would describe the working copy (@) %s as %q.\n", wc.Short(), msg)
+			wc.Description = msg
+		default:
+			if err := runner.Describe(wc.ChangeID, msg); err != nil {
+				return nil, fmt.Errorf("describing the working copy: %w", err)
+			}
+			// Describing rewrites the commit; read the stacks again.
+			if stacks.dags, stacks.baseOf, err = resolveSendStacks(runner, opts, baseRemote); err != nil {
+				return nil, err
+			}
+			stacks.listed = nil
+		}
+	}
+
+	// The fetch brought the remote's branches up to date: with --cleanup,
+	// delete the ones whose PRs were merged or closed since.
+	if opts.cleanup != "" && opts.cleanup != cleanupOff && cleanupStaleBranches(runner, client, stacks.dags, opts, p) {
+		stacks.listed = nil
+	}
+	if len(stacks.dags) == 0 {
+		return &stacks, nil
+	}
+
+	// Detect private commits using jj's own revset evaluation.
+	if stacks.private, err = jj.FindPrivateChanges(runner, stacks.dags); err != nil {
+		_, _ = fmt.Fprintf(p, "warning: could not check for private commits: %v\n", err)
+	}
+
+	// Megamerge workflow: an undescribed or private merge on top of several
+	// independent stacks is only a local convenience. Drop it and treat each
+	// merged branch as its own stack, so every stack is sent (and --stack=none
+	// picks each stack's tip rather than the merge).
+	dags, megamerges := jj.SplitMegamerges(stacks.dags, stacks.private)
+	for _, m := range megamerges {
+		stacks.skipped = append(stacks.skipped, skippedEntry{
+			change: m,
+			reason: skipReason{
+				reason: fmt.Sprintf("megamerge of %d stacks (local merge commit, sent as separate stacks)", len(m.ParentIDs)),
+				benign: true,
+			},
+		})
+	}
+	// Changes marked with a Jip-Skip trailer or [skip pr] get no PR of their
+	// own. Each is folded into the changes above it, whose PRs then carry its
+	// commit, rather than skipping them too.
+	dags, folded := foldSkippedChanges(dags)
+	stacks.skipped = append(stacks.skipped, folded...)
+	stacks.dags = dags
+
+	// If --stack=none, reduce each DAG to its tip (leaf) change only. With
+	// --commit-checklist the whole stack is kept per tip for the PR body.
 	if opts.stackMode == stackModeNone {
+		if opts.checklist {
+			stacks.squashed = make(map[string][]*jj.Change, len(dags))
+		}
 		for i, dag := range dags {
 			leaves := dag.LeafChanges()
 			if len(leaves) != 1 {
-				return fmt.Errorf("--stack=none requires a linear stack (found %d tips in one DAG)", len(leaves))
+				return nil, fmt.Errorf("--stack=none requires a linear stack (found %d tips in one DAG)", len(leaves))
 			}
 			tip := leaves[0]
+			if stacks.squashed != nil {
+				stacks.squashed[tip.ChangeID] = dag.Changes
+			}
 			dags[i] = &jj.ChangeDAG{
 				Changes: []*jj.Change{tip},
 				ByID:    map[string]*jj.Change{tip.ChangeID: tip},
 			}
 		}
 	}
+	return &stacks, nil
+}
 
-	// 3. Pre-skip: remove changes that must not be pushed (empty description,
-	// private commits) plus their descendants, before creating bookmarks.
+// preSkip removes the changes that must not be pushed from dags: private,
+// immutable and undescribed ones, and their descendants. It returns the
+// rest, and skipped with the removed changes added.
+func preSkip(dags []*jj.ChangeDAG, private map[string]bool, skipped []skippedEntry) ([]*jj.ChangeDAG, []skippedEntry) {
 	preSkipIDs := make(map[string]skipReason)
-
-	// Detect private commits using jj's own revset evaluation.
-	privateIDs, err := jj.FindPrivateChanges(runner, dags)
-	if err != nil {
-		_, _ = fmt.Fprintf(w, "warning: could not check for private commits: %v\n", err)
-	}
-	for id := range privateIDs {
+	for id := range private {
 		preSkipIDs[id] = skipReason{
 			reason: "private (matches git.private-commits)",
 			benign: true,
@@ -413,77 +1386,95 @@ func executeSend(runner jj.Runner, client gh.Service, opts sendOpts, w io.Writer
 			if _, ok := preSkipIDs[c.ChangeID]; ok {
 				continue
 			}
-			if strings.TrimSpace(c.Description) == "" {
+			if c.Immutable {
 				preSkipIDs[c.ChangeID] = skipReason{
-					reason: "change has no description — add a commit message before sending",
+					reason: "immutable (already on trunk or in immutable_heads()) — leave it out of the revset, e.g. trunk()..@-, or check --base",
 				}
+			} else if strings.TrimSpace(c.Description) == "" {
+				preSkipIDs[c.ChangeID] = emptyDescriptionSkip(c)
 			}
 		}
 	}
+	if len(preSkipIDs) == 0 {
+		return dags, skipped
+	}
 
 	// Collect pre-skipped changes for reporting; filter DAGs.
-	var preSkippedChanges []skippedEntry
-	if len(preSkipIDs) > 0 {
-		for _, dag := range dags {
-			for _, c := range dag.Changes {
-				if r, ok := preSkipIDs[c.ChangeID]; ok {
-					preSkippedChanges = append(preSkippedChanges, skippedEntry{change: c, reason: r})
-				}
-			}
-		}
-		var filteredDAGs []*jj.ChangeDAG
-		for _, dag := range dags {
-			if fd := jj.FilterDAG(dag, preSkipIDs); fd != nil {
-				filteredDAGs = append(filteredDAGs, fd)
+	for _, dag := range dags {
+		for _, c := range dag.Changes {
+			if r, ok := preSkipIDs[c.ChangeID]; ok {
+				skipped = append(skipped, skippedEntry{change: c, reason: r})
 			}
 		}
-		dags = filteredDAGs
-		if len(dags) == 0 && !opts.dryRun {
-			printPreSkippedChanges(w, preSkippedChanges)
-			if n := nonBenignSkips(nil, nil, preSkippedChanges); n > 0 {
-				return fmt.Errorf("%d change(s) skipped — nothing to send", n)
-			}
-			_, _ = fmt.Fprintf(w, "\nNothing to send.\n")
-			return nil
+	}
+	var filteredDAGs []*jj.ChangeDAG
+	for _, dag := range dags {
+		if fd := jj.FilterDAG(dag, preSkipIDs); fd != nil {
+			filteredDAGs = append(filteredDAGs, fd)
 		}
 	}
+	return filteredDAGs, skipped
+}
 
-	// 4. Get existing bookmarks.
-	bookmarkData, err := runner.BookmarkList()
-	if err != nil {
-		return fmt.Errorf("listing bookmarks: %w", err)
+// syncBookmarks ensures a bookmark for each change of stacks, reusing the
+// existing ones, and returns the changes with their bookmarks and PRs.
+func syncBookmarks(runner jj.Runner, client forge.Service, opts sendOpts, stacks *sendStacks, resume *state.SendProgress, baseRemote string, p *progress) ([]changeState, *sendBookmarks, error) {
+	// Get existing bookmarks: those listed while the stacks were
+	// resolved, unless bookmarks moved since.
+	p.step(i18n.T("Preparing bookmarks..."))
+	bookmarkData := stacks.listed
+	if bookmarkData == nil {
+		var err error
+		if bookmarkData, err = runner.BookmarkList(); err != nil {
+			return nil, nil, fmt.Errorf("listing bookmarks: %w", err)
+		}
 	}
 	bookmarks, err := jj.ParseBookmarkList(bookmarkData)
 	if err != nil {
-		return fmt.Errorf("parsing bookmarks: %w", err)
+		return nil, nil, fmt.Errorf("parsing bookmarks: %w", err)
 	}
 
 	// Resolve base revset to a concrete remote bookmark name for GitHub.
 	// GH's PR API needs a branch name; jj ops above can use the revset directly.
-	baseRemote := opts.remote
-	if opts.upstreamRemote != "" {
-		baseRemote = opts.upstreamRemote
-	}
-	baseBranch, err := jj.ResolveBaseBranch(runner, opts.base, bookmarks, baseRemote)
-	if err != nil {
-		return err
+	// Each change targets the branch of the base its stack was resolved
+	// against.
+	bms := &sendBookmarks{baseBranches: make(map[string]string, len(stacks.baseOf))}
+	for _, b := range opts.bases() {
+		branch := b.branch
+		if branch == "" {
+			if branch, err = jj.ResolveBaseBranch(runner, opts.base, bookmarks, baseRemote); err != nil {
+				return nil, nil, err
+			}
+		}
+		for id, base := range stacks.baseOf {
+			if base == b.name() {
+				bms.baseBranches[id] = branch
+			}
+		}
 	}
 
 	// Build lookup: collect all remote branches, query GitHub for existing PRs.
-	bookmarkByName := make(map[string]*jj.BookmarkInfo, len(bookmarks))
+	bms.byName = make(map[string]*jj.BookmarkInfo, len(bookmarks))
 	for i := range bookmarks {
-		bookmarkByName[bookmarks[i].Name] = &bookmarks[i]
+		bms.byName[bookmarks[i].Name] = &bookmarks[i]
 	}
 
+	// Bookmarks the interrupted send pushed the current commit to, by name.
+	// Their PRs are known without asking GitHub.
+	bms.resumed = resumedBookmarks(resume, stacks.dags)
+
 	var remoteBranches []string
 	remoteBranchSet := make(map[string]bool)
-	for _, dag := range dags {
+	for _, dag := range stacks.dags {
 		for _, change := range dag.Changes {
 			for _, bName := range change.Bookmarks {
-				bi, ok := bookmarkByName[bName]
+				bi, ok := bms.byName[bName]
 				if !ok {
 					continue
 				}
+				if _, ok := bms.resumed[bName]; ok {
+					continue
+				}
 				if _, hasRemote := bi.Remotes[opts.remote]; hasRemote && !remoteBranchSet[bName] {
 					remoteBranches = append(remoteBranches, bName)
 					remoteBranchSet[bName] = true
@@ -492,373 +1483,855 @@ func executeSend(runner jj.Runner, client gh.Service, opts sendOpts, w io.Writer
 		}
 	}
 
-	var prMap map[string]*gh.PRInfo
-	if len(remoteBranches) > 0 {
-		prMap, err = client.LookupPRsByBranch(remoteBranches)
-		if err != nil {
-			return fmt.Errorf("looking up PRs: %w", err)
+	prMap, err := lookupPRs(client, remoteBranches, opts, p)
+	if err != nil {
+		return nil, nil, fmt.Errorf("looking up PRs: %w", err)
+	}
+	for name, b := range bms.resumed {
+		if b.PR != nil {
+			pr := *b.PR
+			prMap[name] = &pr
 		}
-	} else {
-		prMap = make(map[string]*gh.PRInfo)
 	}
 
-	// 5. Process each DAG: ensure bookmarks.
-	var allStates []changeState
-
-	for _, dag := range dags {
-		// shouldUseExisting: prefer bookmarks that already have a PR, then any jip/ bookmark.
+	// Process each DAG: ensure bookmarks.
+	var states []changeState
+	for _, dag := range stacks.dags {
+		// shouldUseExisting: prefer bookmarks that already have a PR, then any jip bookmark.
 		shouldUse := func(changeID, bookmark string) bool {
+			if opts.retiredBranches[bookmark] {
+				return false
+			}
 			if _, hasPR := prMap[bookmark]; hasPR {
 				return true
 			}
-			return strings.HasPrefix(bookmark, "jip/")
+			return opts.namer.Owns(bookmark)
+		}
+
+		results, err := jj.EnsureBookmarks(runner, dag, bookmarks, opts.remote, shouldUse, !opts.existing, opts.namer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ensuring bookmarks: %w", err)
+		}
+
+		// Map change ID -> bookmark result.
+		bmByChange := make(map[string]jj.ChangeBookmark, len(results))
+		for _, r := range results {
+			bmByChange[r.ChangeID] = r
+		}
+
+		for _, change := range dag.Changes {
+			bm := bmByChange[change.ChangeID]
+			existingPR := prMap[bm.Bookmark]
+			states = append(states, changeState{
+				change:   change,
+				bookmark: bm,
+				pr:       existingPR,
+			})
+		}
+	}
+	return states, bms, nil
+}
+
+// existingOnly returns the states with an existing PR, for --existing.
+func existingOnly(states []changeState, w io.Writer) []changeState {
+	var filtered []changeState
+	for _, s := range states {
+		if s.pr != nil {
+			filtered = append(filtered, s)
+		}
+	}
+	skipped := len(states) - len(filtered)
+	if skipped > 0 {
+		_, _ = fmt.Fprintf(w, i18n.T("\nSkipping %d change(s) without existing PRs.\n"), skipped)
+	}
+	if len(filtered) == 0 {
+		_, _ = fmt.Fprintln(w, i18n.T("No existing PRs to update."))
+	}
+	return filtered
+}
+
+// skipUnsendable moves the states that cannot be pushed, and their
+// descendants, to skips: changes with conflicts, left conflicted by the
+// rebase, or whose bookmark diverged from the remote or fell behind it. It
+// returns the others.
+func skipUnsendable(states []changeState, rebaseConflicts map[string]string, skips *sendSkips) []changeState {
+	skippedIDs := skips.reasons
+	for _, s := range states {
+		// Check if any parent was skipped.
+		for _, pid := range s.change.ParentIDs {
+			if pr, ok := skippedIDs[pid]; ok {
+				skippedIDs[s.change.ChangeID] = skipReason{
+					reason:   "skipped because ancestor was skipped",
+					ancestor: pid,
+					benign:   pr.benign,
+				}
+				break
+			}
+		}
+		if _, ok := skippedIDs[s.change.ChangeID]; ok {
+			continue // already marked via ancestor
+		}
+		if base, ok := rebaseConflicts[s.change.ChangeID]; ok {
+			skippedIDs[s.change.ChangeID] = skipReason{
+				reason: fmt.Sprintf("the rebase onto %s left conflicts — resolve them, or undo the rebase", base),
+			}
+		} else if s.change.Conflict {
+			skippedIDs[s.change.ChangeID] = skipReason{
+				reason: "change has conflicts — resolve before sending",
+			}
+		} else if s.bookmark.Displaced {
+			skippedIDs[s.change.ChangeID] = skipReason{
+				reason: "remote is ahead of local — pull changes or reset the bookmark",
+			}
+		} else if s.bookmark.Conflict {
+			skippedIDs[s.change.ChangeID] = skipReason{
+				reason: "local and remote have diverged — resolve with `jj bookmark set` or force-push",
+			}
+		}
+	}
+
+	var active []changeState
+	for _, s := range states {
+		if _, ok := skippedIDs[s.change.ChangeID]; ok {
+			skips.states = append(skips.states, s)
+		} else {
+			active = append(active, s)
+		}
+	}
+	return active
+}
+
+// printSendPlan prints what a dry run would send, or what a send that would
+// create too many PRs did not, with the CI checks of the existing PRs and
+// the size of each change, and adds it to report.
+func printSendPlan(opts sendOpts, active []changeState, skips *sendSkips, checks map[int]string, stats map[string]jj.DiffStat, tooMany bool, newPRs int, report *sendReport, w io.Writer) {
+	if tooMany {
+		_, _ = fmt.Fprintf(w, i18n.T("\nNot sent — %d change(s) would be sent, creating %d PRs:\n\n"), len(active), newPRs)
+	} else {
+		_, _ = fmt.Fprintf(w, i18n.T("\nDry run — %d change(s) would be sent:\n\n"), len(active))
+	}
+	for _, s := range active {
+		action := opts.colors.green("CREATE")
+		if s.pr != nil {
+			action = fmt.Sprintf("UPDATE #%d", s.pr.Number)
+		}
+		bmStatus := "new"
+		if !s.bookmark.IsNew {
+			bmStatus = "existing"
+		}
+		_, _ = fmt.Fprintf(w, "  %s  %s  %s\n", action, s.change.Short(), s.change.Title())
+		_, _ = fmt.Fprintf(w, "         bookmark: %s (%s)\n", s.bookmark.Bookmark, bmStatus)
+		if s.pr != nil && checks != nil {
+			_, _ = fmt.Fprintf(w, "         checks: %s\n", checksLabel(checks[s.pr.Number]))
+		}
+		rp := reportPR{action: "would create", changeID: s.change.Short(), title: s.change.Title()}
+		if stat, ok := stats[s.change.ChangeID]; ok {
+			_, _ = fmt.Fprintf(w, "         size: %s\n", opts.colors.diffStat(stat))
+			rp.stat, rp.large = &stat, isLargePR(stat, opts)
+		}
+		if s.pr != nil {
+			rp.number, rp.url, rp.action = s.pr.Number, s.pr.URL, "would update"
+		}
+		report.prs = append(report.prs, rp)
+	}
+	warnLargePRs(report.prs, opts, w)
+	report.addSkips(skips.states, skips.reasons, skips.pre)
+	if opts.stackMode == stackModeNative && len(active) > 1 {
+		_, _ = fmt.Fprint(w, i18n.T("\nPRs would be linked into native GitHub stack(s).\n"))
+	}
+	if opts.trailers && len(active) > 0 {
+		_, _ = fmt.Fprint(w, i18n.T("\nCommit trailers (Jip-Stack, Jip-PR) would be updated and re-pushed.\n"))
+	}
+	if len(skips.states) > 0 || len(skips.pre) > 0 {
+		printAllSkipped(w, skips.states, skips.reasons, skips.pre, opts.colors)
+	}
+}
+
+// startSendRecord records where the bookmarks are before the push, for jip
+// undo, and starts recording the send's progress, for --resume. It returns
+// the undo record, nil when there is none to keep.
+func startSendRecord(opts sendOpts, active []changeState, byName map[string]*jj.BookmarkInfo, resume *state.SendProgress) *state.SendRecord {
+	if opts.state == nil || len(active) == 0 {
+		return nil
+	}
+	// A send that moves nothing on the remote keeps the previous record.
+	// A resumed send adds to the record of the send it continues, which
+	// knows where the bookmarks were before either.
+	undo := newSendRecord(active, byName, opts)
+	if resume != nil && opts.state.LastSend != nil {
+		undo = extendSendRecord(opts.state.LastSend, undo)
+	}
+	if undo != nil {
+		opts.state.LastSend = undo
+	}
+	if resume == nil {
+		opts.state.StartSend(opts.remote, opts.revsets, time.Now())
+		for _, b := range opts.otherBases {
+			if opts.state.Progress.Bases == nil {
+				opts.state.Progress.Bases = make(map[string][]string)
+			}
+			opts.state.Progress.Bases[b.branch] = b.revsets
+		}
+	}
+	return undo
+}
+
+// pushSendBookmarks pushes the bookmarks of active, except those a resumed
+// send already pushed. It tries them all at once first; on failure, it
+// pushes each on its own so that independent bookmarks can still proceed,
+// and moves the changes that fail to skips. It returns the others.
+func pushSendBookmarks(runner jj.Runner, opts sendOpts, active []changeState, resumed map[string]state.SentBookmark, skips *sendSkips, p *progress) []changeState {
+	var pushStates []changeState
+	var pushBookmarks []string
+	for _, s := range active {
+		if _, ok := resumed[s.bookmark.Bookmark]; ok {
+			continue
+		}
+		pushStates = append(pushStates, s)
+		pushBookmarks = append(pushBookmarks, s.bookmark.Bookmark)
+	}
+	if n := len(active) - len(pushStates); n > 0 {
+		_, _ = fmt.Fprintf(p, i18n.T("\nSkipping %d bookmark(s) already pushed.\n"), n)
+	}
+	if len(pushBookmarks) == 0 {
+		return active
+	}
+	p.step(fmt.Sprintf(i18n.T("Pushing %d bookmark(s)..."), len(pushBookmarks)))
+	var pushFailed map[string]string
+	if err := runner.GitPush(pushBookmarks, opts.remote); err != nil {
+		// Batch push failed — try each bookmark individually.
+		_, _ = fmt.Fprintf(p, "Batch push failed, retrying individually...\n")
+		pushFailed = pushIndividually(runner, pushStates, opts.remote, p)
+		if len(pushFailed) > 0 {
+			var newActive []changeState
+			for _, s := range active {
+				if reason, failed := pushFailed[s.change.ChangeID]; failed {
+					skips.reasons[s.change.ChangeID] = skipReason{reason: reason}
+					skips.states = append(skips.states, s)
+				} else {
+					newActive = append(newActive, s)
+				}
+			}
+			active = newActive
+			_, _ = fmt.Fprintf(p, "Pushed %d of %d bookmark(s); continuing with the pushed ones.\n", len(pushBookmarks)-len(pushFailed), len(pushBookmarks))
+		}
+	}
+	if opts.state != nil {
+		for _, s := range pushStates {
+			if _, failed := pushFailed[s.change.ChangeID]; !failed {
+				opts.state.RecordProgress(s.bookmark.Bookmark, state.SentBookmark{ChangeID: s.change.ChangeID, Pushed: s.change.CommitID, PR: s.pr})
+			}
+		}
+		saveState(opts.state, p)
+	}
+	return active
+}
+
+// createOrUpdatePRs creates a PR for each change of active without one and
+// updates the title, draft state and base of the others, in place. New PRs
+// are added to undo.
+//
+// In gh-native mode each PR targets the branch of the change below it
+// (GitHub's stack API requires a valid base-to-head chain); otherwise every
+// PR targets the base branch.
+func createOrUpdatePRs(runner jj.Runner, client forge.Service, opts sendOpts, active []changeState, bms *sendBookmarks, project *projectRef, undo *state.SendRecord, p *progress) error {
+	p.step(i18n.T("Creating and updating PRs..."))
+	groups := stackGroups(active)
+	meta := &prMetadata{project: project, codeOwners: &codeOwnersLookup{client: client}}
+	desiredBase := make(map[string]string, len(active))
+	activeBookmarks := make(map[string]bool, len(active))
+	for _, group := range groups {
+		prev := bms.baseBranches[group[0].change.ChangeID]
+		for _, s := range group {
+			desiredBase[s.change.ChangeID] = prev
+			activeBookmarks[s.bookmark.Bookmark] = true
+			if opts.stackMode == stackModeNative {
+				prev = s.bookmark.Bookmark
+			}
 		}
+	}
+	// With --title-prefix, titles carry the stack position, so a send
+	// that grows or shrinks the stack renumbers them.
+	titlePrefix := make([]string, len(active))
+	if opts.titlePrefix {
+		titlePrefix = titlePrefixes(active)
+	}
 
-		results, err := jj.EnsureBookmarks(runner, dag, bookmarks, opts.remote, shouldUse, !opts.existing)
+	// gh-native: inspect the stacks the existing PRs belong to, and
+	// dissolve any that the append-only stacks API can no longer express
+	// (reorders, mid-stack inserts/removals, base changes) before any PR
+	// base is touched.
+	var stackPlans []nativeStackPlan
+	if opts.stackMode == stackModeNative {
+		var err error
+		if stackPlans, err = prepareNativeStacks(client, groups, bms.baseBranches, p); err != nil {
+			return err
+		}
+	}
+
+	for i := range active {
+		s := &active[i]
+		if b, ok := bms.resumed[s.bookmark.Bookmark]; ok && b.Done {
+			s.isNew, s.changed = b.Created, b.Changed
+			continue
+		}
+		// PR-* trailers in the description override the flags per change.
+		overrides, err := jj.ParseOverrides(s.change.Description)
 		if err != nil {
-			return fmt.Errorf("ensuring bookmarks: %w", err)
+			_, _ = fmt.Fprintf(p, "  warning: change %s: ignoring invalid PR trailers: %v\n", s.change.Short(), err)
+		}
+		base := desiredBase[s.change.ChangeID]
+		if s.pr != nil {
+			if err := updatePR(runner, client, opts, s, titlePrefix[i], base, overrides, activeBookmarks, bms, p); err != nil {
+				return err
+			}
+		} else {
+			if err := createPR(runner, client, opts, s, titlePrefix[i], base, overrides, meta, p); err != nil {
+				return err
+			}
+			if undo != nil {
+				undo.CreatedPRs = append(undo.CreatedPRs, s.pr.Number)
+			}
+		}
+		if opts.state != nil {
+			opts.state.RecordProgress(s.bookmark.Bookmark, sentBookmark(s))
+			saveState(opts.state, p)
+		}
+	}
+
+	// gh-native: link the PRs into native GitHub stacks now that every
+	// PR exists with a chained base.
+	if opts.stackMode == stackModeNative {
+		return finalizeNativeStacks(client, groups, stackPlans, p)
+	}
+	return nil
+}
+
+// updatePR brings the existing PR of s in line with its change: title,
+// draft state and, in gh-native mode, base. It posts the "changes since"
+// comment for what the push changed.
+func updatePR(runner jj.Runner, client forge.Service, opts sendOpts, s *changeState, titlePrefix, base string, overrides jj.Overrides, activeBookmarks map[string]bool, bms *sendBookmarks, w io.Writer) error {
+	// A "WIP: " prefix stays until --ready.
+	wip := opts.wip || (!opts.ready && strings.HasPrefix(s.pr.Title, wipPrefix))
+	update := forge.UpdatePROpts{}
+	if title := wipTitle(titlePrefix+s.change.PRTitle(), wip); titleChanged(s.pr, title) {
+		update.Title = &title
+	}
+	draft := overrides.Draft
+	if opts.wip || opts.ready {
+		draft = &opts.wip
+	}
+	if draft != nil && s.pr.IsDraft != *draft {
+		update.Draft = draft
+	}
+	if update.Title != nil || update.Draft != nil {
+		if err := client.UpdatePR(s.pr.Number, update); err != nil {
+			return fmt.Errorf("updating PR #%d title and draft state: %w", s.pr.Number, err)
+		}
+		if update.Title != nil {
+			s.pr.Title = *update.Title
+		}
+		if update.Draft != nil {
+			s.pr.IsDraft = *update.Draft
+		}
+		s.changed = true
+	}
+
+	// Retarget the PR when its base does not match the chain
+	// (gh-native) — e.g. a new change was inserted below it. In the
+	// other modes jip must not override a base the user chose, so
+	// it only warns, and only when the base looks like a leftover
+	// chained base from an earlier gh-native send (it points at
+	// another branch in this send, so merging would land there
+	// instead of the base branch).
+	if s.pr.BaseRefName != base {
+		switch {
+		case opts.stackMode == stackModeNative:
+			if err := client.UpdatePR(s.pr.Number, forge.UpdatePROpts{Base: &base}); err != nil {
+				return fmt.Errorf("updating PR #%d base: %w", s.pr.Number, err)
+			}
+			s.pr.BaseRefName = base
+			s.changed = true
+		case activeBookmarks[s.pr.BaseRefName]:
+			_, _ = fmt.Fprintf(w, "  warning: PR #%d targets %q, not %q — if this is a leftover from --stack=gh-native, retarget the PR on GitHub or re-send with --stack=gh-native\n",
+				s.pr.Number, s.pr.BaseRefName, base)
+		}
+	}
+
+	// Post "changes since" comment. By default the base is the old
+	// remote commit; with --diff-since-jip it is jip's own previous
+	// push (recorded in the PR body), so direct pushes by others
+	// don't distort the diff.
+	if bi := bms.byName[s.bookmark.Bookmark]; bi != nil {
+		if rs, ok := bi.Remotes[opts.remote]; ok {
+			return postChangesComment(runner, client, s, rs.Target, bms.baseBranches[s.change.ChangeID], opts, w)
+		}
+	}
+	return nil
+}
+
+// createPR opens the PR of s against base and applies the metadata new PRs
+// get.
+func createPR(runner jj.Runner, client forge.Service, opts sendOpts, s *changeState, titlePrefix, base string, overrides jj.Overrides, meta *prMetadata, w io.Writer) error {
+	title := s.change.PRTitle()
+	if title == "" {
+		title = fmt.Sprintf("jip: %s", s.change.Short())
+	}
+	title = wipTitle(titlePrefix+title, opts.wip)
+	head := s.bookmark.Bookmark
+	if opts.pushOwner != "" {
+		head = opts.pushOwner + ":" + head
+	}
+	draft := opts.draft
+	if overrides.Draft != nil {
+		draft = *overrides.Draft
+	}
+	if opts.wip || opts.ready {
+		draft = opts.wip
+	}
+	pr, err := client.CreatePR(head, base, title, s.change.Body(), draft)
+	if err != nil {
+		return fmt.Errorf("creating PR for %s: %w", s.change.Short(), err)
+	}
+	s.pr = pr
+	s.isNew = true
+	applyNewPRMetadata(runner, client, opts, s.change, pr.Number, overrides, meta, w)
+	return nil
+}
+
+// prMetadata is what applyNewPRMetadata looks up once per send.
+type prMetadata struct {
+	project    *projectRef
+	codeOwners *codeOwnersLookup
+	self       string // authenticated login, looked up when first needed
+}
+
+// applyNewPRMetadata requests the reviews of the new PR number of change
+// (--reviewer, PR-Reviewer trailers, CODEOWNERS and blame) and adds it to
+// the --milestone and --project. Failures only warn: the PR exists.
+func applyNewPRMetadata(runner jj.Runner, client forge.Service, opts sendOpts, change *jj.Change, number int, overrides jj.Overrides, meta *prMetadata, w io.Writer) {
+	reviewers := opts.reviewers
+	if len(overrides.Reviewers) > 0 {
+		reviewers = slices.Clone(reviewers)
+		for _, r := range overrides.Reviewers {
+			if !slices.Contains(reviewers, r) {
+				reviewers = append(reviewers, r)
+			}
+		}
+	}
+	if opts.ownerReviewers {
+		suggested, err := suggestCodeOwnerReviewers(runner, meta.codeOwners, change, &meta.self, reviewers)
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "  warning: could not suggest reviewers for #%d from CODEOWNERS: %v\n", number, err)
+		} else if len(suggested) > 0 {
+			_, _ = fmt.Fprintf(w, "  #%-4d reviewers from CODEOWNERS: %s\n", number, strings.Join(suggested, ", "))
+			reviewers = append(slices.Clone(reviewers), suggested...)
+		}
+	}
+	if opts.blameReviewers {
+		suggested, err := suggestBlameReviewers(runner, client, change, &meta.self, reviewers)
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "  warning: could not suggest reviewers for #%d from blame: %v\n", number, err)
+		} else if len(suggested) > 0 {
+			_, _ = fmt.Fprintf(w, "  #%-4d reviewers from blame: %s\n", number, strings.Join(suggested, ", "))
+			reviewers = append(slices.Clone(reviewers), suggested...)
+		}
+	}
+	if len(reviewers) > 0 {
+		if err := client.RequestReviewers(number, reviewers); err != nil {
+			_, _ = fmt.Fprintf(w, "  warning: failed to add reviewers to #%d: %v\n", number, err)
+		}
+	}
+	addToPlanning(client, number, opts.milestone, meta.project, w)
+}
+
+// writePRBodies updates the bodies of the PRs of active, plus the invisible
+// pushed-commit marker that records this push for a later --diff-since-jip.
+// Stack navigation is rendered into the body only in default mode: with
+// gh-native stacks GitHub's own UI shows the stack, and with --stack=none
+// there is none (--commit-checklist lists the stack's commits instead,
+// from squashed). Hand-written descriptions (--body-file, .jip/body) go
+// below all that, and a PR without one keeps its own.
+//
+// Each PR's stack only includes its ancestors and descendants (its
+// dependency chain), not unrelated branches in the same DAG.
+func writePRBodies(client forge.Service, opts sendOpts, active, skipped []changeState, squashed map[string][]*jj.Change, p *progress) error {
+	p.step(i18n.T("Updating PR descriptions..."))
+	links := client.Links()
+	bodyNav := opts.stackMode == stackModeDefault
+	var perChangeStack [][]int
+	var format gh.StackFormat
+	if bodyNav {
+		perChangeStack = computeStackPRs(active)
+		format = stackFormat(active, opts.stackOrder, opts.stackTitles, opts.stackStatus)
+	}
+	getPR := cachedGetPR(client)
+	tops := stackTops(active)
+	for i, s := range active {
+		body := s.change.Body()
+		if bodyNav {
+			stack, err := withFinishedPRs(s.pr.Body, perChangeStack[i], format, getPR)
+			if err != nil {
+				_, _ = fmt.Fprintf(p, "  warning: the stack navigation of #%d leaves out merged and closed PRs: %v\n", s.pr.Number, err)
+			}
+			body = gh.BuildStackedPRBody(
+				s.change.CommitID,
+				links,
+				s.pr.Number,
+				stack,
+				s.change.Body(),
+				format,
+			)
+		}
+		if commits := squashed[s.change.ChangeID]; commits != nil {
+			body = gh.BuildSquashedPRBody(links, s.pr.Number, squashedCommits(commits), s.change.Body(), s.pr.Body)
+		}
+		body = gh.MergePRBody(s.pr.Body, body)
+		body = gh.WithExtraDescription(body, opts.extras.section(s.change, tops[s.change.ChangeID], s.pr.Body))
+		body = gh.WithPushedCommitMarker(body, s.change.CommitID)
+		if body != s.pr.Body {
+			if err := client.UpdatePR(s.pr.Number, forge.UpdatePROpts{Body: &body}); err != nil {
+				return fmt.Errorf("updating PR #%d body: %w", s.pr.Number, err)
+			}
+			s.pr.Body = body
+			active[i].changed = true
+			if opts.state != nil {
+				opts.state.RecordProgress(s.bookmark.Bookmark, sentBookmark(&active[i]))
+			}
+		}
+	}
+	// The bodies of skipped PRs stay, but a PR whose branch moved on
+	// without jip (e.g. a force-push) would link a commit it no longer
+	// has, which GitHub shows as a 404.
+	for _, s := range skipped {
+		if s.pr == nil {
+			continue
+		}
+		if body := gh.RepointReviewCommit(s.pr.Body, links, s.pr.Number, s.pr.HeadSHA); body != s.pr.Body {
+			if err := client.UpdatePR(s.pr.Number, forge.UpdatePROpts{Body: &body}); err != nil {
+				_, _ = fmt.Fprintf(p, "  warning: could not fix the review-commit link of #%d: %v\n", s.pr.Number, err)
+				continue
+			}
+			s.pr.Body = body
 		}
+	}
+	return nil
+}
 
-		// Map change ID -> bookmark result.
-		bmByChange := make(map[string]jj.ChangeBookmark, len(results))
-		for _, r := range results {
-			bmByChange[r.ChangeID] = r
+// applyPRMetadata explains to the PRs of active whose change moved within
+// its stack why their base and navigation changed, and enables
+// --auto-merge. Failures only warn.
+func applyPRMetadata(client forge.Service, opts sendOpts, active []changeState, w io.Writer) {
+	// Explain to the PRs whose change moved within its stack since
+	// the last send (e.g. jj rebase within the stack) why their base and
+	// stack navigation changed.
+	notes := reorderNotes(active, opts.state)
+	for i, s := range active {
+		note, ok := notes[s.change.ChangeID]
+		if !ok {
+			continue
 		}
-
-		for _, change := range dag.Changes {
-			bm := bmByChange[change.ChangeID]
-			existingPR := prMap[bm.Bookmark]
-			allStates = append(allStates, changeState{
-				change:   change,
-				bookmark: bm,
-				pr:       existingPR,
-			})
+		if err := client.CommentOnPR(s.pr.Number, note); err != nil {
+			_, _ = fmt.Fprintf(w, "  warning: could not comment on #%d: %v\n", s.pr.Number, err)
+			continue
 		}
+		_, _ = fmt.Fprintf(w, "  #%-4d reordered within its stack\n", s.pr.Number)
+		active[i].changed = true
 	}
 
-	// Filter to existing PRs only when --existing is set.
-	if opts.existing {
-		var filtered []changeState
-		for _, s := range allStates {
-			if s.pr != nil {
-				filtered = append(filtered, s)
-			}
+	// Enable auto-merge. In default and none mode every PR targets the
+	// base branch and contains the changes below it, so only the bottom of
+	// each stack gets it; the next one follows once a later send finds it
+	// at the bottom. With gh-native stacks GitHub retargets the PR above a
+	// merged one, so every PR gets it.
+	if opts.autoMerge == "" {
+		return
+	}
+	sent := make(map[string]bool, len(active))
+	for _, s := range active {
+		sent[s.change.ChangeID] = true
+	}
+	for _, s := range active {
+		if !(s.isNew || s.changed) || s.pr.IsDraft {
+			continue
 		}
-		skipped := len(allStates) - len(filtered)
-		if skipped > 0 {
-			_, _ = fmt.Fprintf(w, "\nSkipping %d change(s) without existing PRs.\n", skipped)
+		if opts.stackMode != stackModeNative && slices.ContainsFunc(s.change.ParentIDs, func(id string) bool { return sent[id] }) {
+			continue
 		}
-		allStates = filtered
-		if len(allStates) == 0 {
-			_, _ = fmt.Fprintln(w, "No existing PRs to update.")
-			return nil
+		if err := client.EnableAutoMerge(s.pr.Number, opts.autoMerge); err != nil {
+			_, _ = fmt.Fprintf(w, "  warning: could not enable auto-merge on #%d: %v\n", s.pr.Number, err)
+			continue
 		}
+		_, _ = fmt.Fprintf(w, "  #%-4d auto-merge enabled (%s)\n", s.pr.Number, opts.autoMerge)
 	}
+}
 
-	// 6. Detect diverged/behind bookmarks and skip them (plus descendants).
-	skippedIDs := make(map[string]skipReason)
-
-	for _, s := range allStates {
-		// Check if any parent was skipped.
-		for _, pid := range s.change.ParentIDs {
-			if pr, ok := skippedIDs[pid]; ok {
-				skippedIDs[s.change.ChangeID] = skipReason{
-					reason:   "skipped because ancestor was skipped",
-					ancestor: pid,
-					benign:   pr.benign,
+// recordSent remembers the PRs of active as they now are on GitHub, so the
+// next send (or dry run) can skip the lookup, and what was pushed for each
+// change, and finishes the record of the send.
+func recordSent(opts sendOpts, active []changeState, undo *state.SendRecord) {
+	if undo != nil {
+		// Trailers may have rewritten and re-pushed the commits.
+		for _, s := range active {
+			for i := range undo.Bookmarks {
+				if undo.Bookmarks[i].Name == s.bookmark.Bookmark {
+					undo.Bookmarks[i].Pushed = s.change.CommitID
 				}
-				break
 			}
 		}
-		if _, ok := skippedIDs[s.change.ChangeID]; ok {
-			continue // already marked via ancestor
-		}
-		if s.change.Conflict {
-			skippedIDs[s.change.ChangeID] = skipReason{
-				reason: "change has conflicts — resolve before sending",
-			}
-		} else if s.bookmark.Displaced {
-			skippedIDs[s.change.ChangeID] = skipReason{
-				reason: "remote is ahead of local — pull changes or reset the bookmark",
-			}
-		} else if s.bookmark.Conflict {
-			skippedIDs[s.change.ChangeID] = skipReason{
-				reason: "local and remote have diverged — resolve with `jj bookmark set` or force-push",
-			}
+	}
+	if opts.state == nil {
+		return
+	}
+	now := time.Now()
+	chains := chainIDs(active)
+	for i, s := range active {
+		opts.state.RecordPRs([]string{s.bookmark.Bookmark},
+			map[string]*forge.PRInfo{s.bookmark.Bookmark: s.pr}, now)
+		opts.state.RecordSend(s.change.ChangeID, s.bookmark.Bookmark, s.pr.Number, s.change.CommitID, now)
+		if s.isNew {
+			opts.state.RecordOpened(s.change.ChangeID, now)
 		}
+		opts.state.RecordStack(s.change.ChangeID, chains[i])
 	}
+	opts.state.FinishSend()
+}
 
-	var activeStates, skippedStates []changeState
-	for _, s := range allStates {
-		if _, ok := skippedIDs[s.change.ChangeID]; ok {
-			skippedStates = append(skippedStates, s)
+// printSent prints the PRs of active that were created or updated, adds
+// them to report, and opens them with --open. PRs that ended up unchanged
+// (branch already up to date and body already correct) move to skips with
+// reason up-to-date — nothing was actually done for them, so reporting
+// them as "sent" would be noise.
+func printSent(runner jj.Runner, opts sendOpts, active []changeState, skips *sendSkips, report *sendReport, w io.Writer) {
+	var sentStates []changeState
+	for _, s := range active {
+		if s.isNew || s.changed {
+			sentStates = append(sentStates, s)
 		} else {
-			activeStates = append(activeStates, s)
+			skips.reasons[s.change.ChangeID] = skipReason{reason: "up-to-date", benign: true}
+			skips.states = append(skips.states, s)
 		}
 	}
 
-	// GitHub native stacks cannot express branching or merging dependency
-	// graphs — each stack must be a single linear chain.
-	if opts.stackMode == stackModeNative {
-		if err := checkLinearStacks(activeStates); err != nil {
-			return err
+	if len(sentStates) > 0 {
+		stats := diffStats(runner, sentStates)
+		_, _ = fmt.Fprintf(w, i18n.T("\n%d PR(s) sent:\n\n"), len(sentStates))
+		for _, s := range sentStates {
+			action := "updated"
+			if s.isNew {
+				action = "created"
+			}
+			rp := reportPR{number: s.pr.Number, url: s.pr.URL, action: action, changeID: s.change.Short(), title: s.change.Title()}
+			shown := i18n.T(action)
+			if s.isNew {
+				shown = opts.colors.green(shown)
+			}
+			_, _ = fmt.Fprintf(w, "  #%-4d %s  %s\n", s.pr.Number, shown, s.pr.URL)
+			if stat, ok := stats[s.change.ChangeID]; ok {
+				rp.stat, rp.large = &stat, isLargePR(stat, opts)
+				_, _ = fmt.Fprintf(w, "         %s  %s  (%s)\n", s.change.Short(), s.change.Title(), opts.colors.diffStat(stat))
+			} else {
+				_, _ = fmt.Fprintf(w, "         %s  %s\n", s.change.Short(), s.change.Title())
+			}
+			report.prs = append(report.prs, rp)
 		}
+		warnLargePRs(report.prs, opts, w)
 	}
 
-	if opts.dryRun {
-		_, _ = fmt.Fprintf(w, "\nDry run — %d change(s) would be sent:\n\n", len(activeStates))
-		for _, s := range activeStates {
-			action := "CREATE"
-			if s.pr != nil {
-				action = fmt.Sprintf("UPDATE #%d", s.pr.Number)
-			}
-			bmStatus := "new"
-			if !s.bookmark.IsNew {
-				bmStatus = "existing"
+	if opts.openURL != nil {
+		for _, url := range prsToOpen(active, opts.open) {
+			if err := opts.openURL(url); err != nil {
+				_, _ = fmt.Fprintf(w, "warning: could not open %s in the browser: %v\n", url, err)
+				break
 			}
-			_, _ = fmt.Fprintf(w, "  %s  %.12s  %s\n", action, s.change.ChangeID, s.change.Title())
-			_, _ = fmt.Fprintf(w, "         bookmark: %s (%s)\n", s.bookmark.Bookmark, bmStatus)
 		}
-		if opts.stackMode == stackModeNative && len(activeStates) > 1 {
-			_, _ = fmt.Fprintf(w, "\nPRs would be linked into native GitHub stack(s).\n")
+	}
+}
+
+// writeTrailers rewrites the descriptions of states to carry jip's trailers
+// (Jip-Stack: position/size of the dependency chain, Jip-PR: the PR number)
+// and re-pushes their bookmarks. Changes whose trailers are already current
+// are left alone, so a re-send without stack changes rewrites nothing. The
+// states are updated in place with the rewritten commit IDs.
+func writeTrailers(runner jj.Runner, states []changeState, repoFullName string, opts sendOpts, w io.Writer) error {
+	var perChangeStack [][]int
+	if opts.stackMode != stackModeNone {
+		perChangeStack = computeStackPRs(states)
+	}
+
+	var rewritten int
+	for i, s := range states {
+		prRef := fmt.Sprintf("#%d", s.pr.Number)
+		if opts.upstream != "" {
+			// The commits live in the fork, where "#N" would mean a
+			// different PR.
+			prRef = repoFullName + prRef
+		}
+		var trailers []jj.Trailer
+		if perChangeStack != nil {
+			stack := perChangeStack[i]
+			trailers = append(trailers, jj.Trailer{
+				Key:   "Jip-Stack",
+				Value: fmt.Sprintf("%d/%d", slices.Index(stack, s.pr.Number)+1, len(stack)),
+			})
 		}
-		if len(skippedStates) > 0 || len(preSkippedChanges) > 0 {
-			printAllSkipped(w, skippedStates, skippedIDs, preSkippedChanges)
+		trailers = append(trailers, jj.Trailer{Key: "Jip-PR", Value: prRef})
+
+		desc := jj.WithTrailers(s.change.Description, trailers)
+		if desc == s.change.Description {
+			continue
 		}
-		if n := nonBenignSkips(skippedStates, skippedIDs, preSkippedChanges); n > 0 {
-			return fmt.Errorf("%d change(s) skipped", n)
+		if err := runner.Describe(s.change.ChangeID, desc); err != nil {
+			return fmt.Errorf("writing trailers for %s: %w", s.change.Short(), err)
 		}
+		rewritten++
+	}
+	if rewritten == 0 {
 		return nil
 	}
 
-	if len(activeStates) > 0 {
-		// 7. Push bookmarks. Try batch first; on failure, push individually
-		// so that independent bookmarks can still proceed.
-		var pushBookmarks []string
-		for _, s := range activeStates {
-			pushBookmarks = append(pushBookmarks, s.bookmark.Bookmark)
-		}
-		_, _ = fmt.Fprintf(w, "\nPushing %d bookmark(s)...\n", len(pushBookmarks))
-
-		if err := runner.GitPush(pushBookmarks, opts.remote); err != nil {
-			// Batch push failed — try each bookmark individually.
-			_, _ = fmt.Fprintf(w, "Batch push failed, retrying individually...\n")
-			pushFailed := make(map[string]string) // changeID -> error
-			// Build bookmark→changeID map.
-			bmToChange := make(map[string]string, len(activeStates))
-			for _, s := range activeStates {
-				bmToChange[s.bookmark.Bookmark] = s.change.ChangeID
-			}
-			for _, s := range activeStates {
-				// Skip if an ancestor already failed.
-				ancestorFailed := false
-				for _, pid := range s.change.ParentIDs {
-					if _, ok := pushFailed[pid]; ok {
-						ancestorFailed = true
-						break
-					}
-				}
-				if ancestorFailed {
-					pushFailed[s.change.ChangeID] = "skipped because ancestor could not be pushed"
-					continue
-				}
-				if err := runner.GitPush([]string{s.bookmark.Bookmark}, opts.remote); err != nil {
-					pushFailed[s.change.ChangeID] = extractPushError(err)
-				}
-			}
-			if len(pushFailed) > 0 {
-				var newActive []changeState
-				for _, s := range activeStates {
-					if reason, failed := pushFailed[s.change.ChangeID]; failed {
-						skippedIDs[s.change.ChangeID] = skipReason{reason: reason}
-						skippedStates = append(skippedStates, s)
-					} else {
-						newActive = append(newActive, s)
-					}
-				}
-				activeStates = newActive
-			}
-		}
-	}
-
-	if len(activeStates) > 0 {
-		// 8. Create/update PRs.
-		//
-		// In gh-native mode each PR targets the branch of the change below it
-		// (GitHub's stack API requires a valid base-to-head chain); otherwise
-		// every PR targets the base branch.
-		groups := stackGroups(activeStates)
-		desiredBase := make(map[string]string, len(activeStates))
-		activeBookmarks := make(map[string]bool, len(activeStates))
-		for _, group := range groups {
-			prev := baseBranch
-			for _, s := range group {
-				desiredBase[s.change.ChangeID] = prev
-				activeBookmarks[s.bookmark.Bookmark] = true
-				if opts.stackMode == stackModeNative {
-					prev = s.bookmark.Bookmark
-				}
-			}
-		}
-
-		// 8a. gh-native: inspect the stacks the existing PRs belong to, and
-		// dissolve any that the append-only stacks API can no longer express
-		// (reorders, mid-stack inserts/removals, base changes) before any PR
-		// base is touched.
-		var stackPlans []nativeStackPlan
-		if opts.stackMode == stackModeNative {
-			stackPlans, err = prepareNativeStacks(client, groups, baseBranch, w)
-			if err != nil {
-				return err
-			}
+	// Describing a change rewrites it and all its descendants; pick up the
+	// new commits before pushing them.
+	ids := make([]string, len(states))
+	for i, s := range states {
+		ids[i] = s.change.ChangeID
+	}
+	data, err := runner.Log(strings.Join(ids, " | "))
+	if err != nil {
+		return fmt.Errorf("reading rewritten changes: %w", err)
+	}
+	changes, err := jj.ParseChanges(data)
+	if err != nil {
+		return fmt.Errorf("reading rewritten changes: %w", err)
+	}
+	byID := make(map[string]jj.Change, len(changes))
+	for _, c := range changes {
+		byID[c.ChangeID] = c
+	}
+	var bookmarks []string
+	for _, s := range states {
+		if c, ok := byID[s.change.ChangeID]; ok {
+			s.change.CommitID = c.CommitID
+			s.change.Description = c.Description
 		}
+		bookmarks = append(bookmarks, s.bookmark.Bookmark)
+	}
 
-		for i := range activeStates {
-			s := &activeStates[i]
-			if s.pr != nil {
-				// Existing PR — update title if changed, post interdiff comment.
-				if s.pr.Title != s.change.Title() {
-					title := s.change.Title()
-					if err := client.UpdatePR(s.pr.Number, gh.UpdatePROpts{Title: &title}); err != nil {
-						return fmt.Errorf("updating PR #%d title: %w", s.pr.Number, err)
-					}
-					s.changed = true
-				}
-
-				// Retarget the PR when its base does not match the chain
-				// (gh-native) — e.g. a new change was inserted below it. In the
-				// other modes jip must not override a base the user chose, so
-				// it only warns, and only when the base looks like a leftover
-				// chained base from an earlier gh-native send (it points at
-				// another branch in this send, so merging would land there
-				// instead of the base branch).
-				if base := desiredBase[s.change.ChangeID]; s.pr.BaseRefName != base {
-					switch {
-					case opts.stackMode == stackModeNative:
-						if err := client.UpdatePR(s.pr.Number, gh.UpdatePROpts{Base: &base}); err != nil {
-							return fmt.Errorf("updating PR #%d base: %w", s.pr.Number, err)
-						}
-						s.pr.BaseRefName = base
-						s.changed = true
-					case activeBookmarks[s.pr.BaseRefName]:
-						_, _ = fmt.Fprintf(w, "  warning: PR #%d targets %q, not %q — if this is a leftover from --stack=gh-native, retarget the PR on GitHub or re-send with --stack=gh-native\n",
-							s.pr.Number, s.pr.BaseRefName, base)
-					}
-				}
-
-				// Post "changes since" comment. By default the base is the old
-				// remote commit; with --diff-since-jip it is jip's own previous
-				// push (recorded in the PR body), so direct pushes by others
-				// don't distort the diff.
-				bi := bookmarkByName[s.bookmark.Bookmark]
-				if bi != nil {
-					if rs, ok := bi.Remotes[opts.remote]; ok {
-						if err := postChangesComment(runner, client, s, rs.Target, repoFullName, baseBranch, opts, w); err != nil {
-							return err
-						}
-					}
-				}
-			} else {
-				// New PR — create it.
-				title := s.change.Title()
-				if title == "" {
-					title = fmt.Sprintf("jip: %.12s", s.change.ChangeID)
-				}
-				head := s.bookmark.Bookmark
-				if opts.pushOwner != "" {
-					head = opts.pushOwner + ":" + head
-				}
-				pr, err := client.CreatePR(head, desiredBase[s.change.ChangeID], title, s.change.Body(), opts.draft)
-				if err != nil {
-					return fmt.Errorf("creating PR for %s: %w", s.change.ChangeID, err)
-				}
-				s.pr = pr
-				s.isNew = true
+	_, _ = fmt.Fprintf(w, "Updated commit trailers on %d change(s), pushing again...\n", rewritten)
+	if err := runner.GitPush(bookmarks, opts.remote); err != nil {
+		return fmt.Errorf("pushing commits with trailers: %w", err)
+	}
+	return nil
+}
 
-				if len(opts.reviewers) > 0 {
-					if err := client.RequestReviewers(pr.Number, opts.reviewers); err != nil {
-						_, _ = fmt.Fprintf(w, "  warning: failed to add reviewers to #%d: %v\n", pr.Number, err)
-					}
-				}
-			}
+// checkRepoAccess verifies that the token can see the repository PRs are
+// opened in and, in fork workflows, the fork the branches are pushed to
+// (GitHub must read the head repository to open a cross-repository PR).
+// Fine-grained tokens are scoped to a list of repositories, so either side
+// may be missing; the error names every inaccessible one.
+func checkRepoAccess(client forge.Service, opts sendOpts) error {
+	type target struct {
+		owner, repo, role string
+	}
+	targets := []target{{client.Owner(), client.Repo(), "upstream repository, where PRs are opened"}}
+	if opts.pushOwner != "" &&
+		!(strings.EqualFold(opts.pushOwner, client.Owner()) && strings.EqualFold(opts.pushRepo, client.Repo())) {
+		targets = append(targets, target{opts.pushOwner, opts.pushRepo,
+			fmt.Sprintf("push repository (remote %q), where the branches live", opts.remote)})
+	}
+
+	var missing []string
+	for _, t := range targets {
+		// resolveRepoContext usually looked the repositories up already.
+		lookup, ok := opts.repos[repoKey(t.owner, t.repo)]
+		if !ok {
+			lookup.info, lookup.err = client.RepoInfo(t.owner, t.repo)
 		}
-
-		// 8b. gh-native: link the PRs into native GitHub stacks now that every
-		// PR exists with a chained base.
-		if opts.stackMode == stackModeNative {
-			if err := finalizeNativeStacks(client, groups, stackPlans, w); err != nil {
-				return err
-			}
+		err := lookup.err
+		if errors.Is(err, forge.ErrRepoNotAccessible) {
+			missing = append(missing, fmt.Sprintf("%s/%s (%s)", t.owner, t.repo, t.role))
+			continue
 		}
-
-		// 9. Update all PR bodies plus the invisible pushed-commit marker that
-		// records this push for a later --diff-since-jip. Stack navigation is
-		// rendered into the body only in default mode: with gh-native stacks
-		// GitHub's own UI shows the stack, and with --stack=none there is none.
-		//
-		// Each PR's stack only includes its ancestors and descendants (its
-		// dependency chain), not unrelated branches in the same DAG.
-		bodyNav := opts.stackMode == stackModeDefault
-		var perChangeStack [][]int
-		if bodyNav {
-			perChangeStack = computeStackPRs(activeStates)
-		}
-		for i, s := range activeStates {
-			body := s.change.Body()
-			if bodyNav {
-				body = gh.BuildStackedPRBody(
-					s.change.CommitID,
-					repoFullName,
-					s.pr.Number,
-					perChangeStack[i],
-					s.change.Body(),
-				)
-			}
-			body = gh.WithPushedCommitMarker(body, s.change.CommitID)
-			if body != s.pr.Body {
-				if err := client.UpdatePR(s.pr.Number, gh.UpdatePROpts{Body: &body}); err != nil {
-					return fmt.Errorf("updating PR #%d body: %w", s.pr.Number, err)
-				}
-				activeStates[i].changed = true
-			}
+		if err != nil {
+			return fmt.Errorf("checking repository access: %w", err)
 		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the GitHub token cannot access %s — grant the token access (fine-grained tokens list their repositories explicitly) or check --remote/--upstream",
+			strings.Join(missing, " or "))
+	}
+	return nil
+}
 
-		// 10. Print summary. PRs that ended up unchanged (branch already up to
-		// date and body already correct) move to the Skipped section with reason
-		// up-to-date — nothing was actually done for them, so reporting them as
-		// "sent" would be noise.
-		var sentStates []changeState
-		for _, s := range activeStates {
-			if s.isNew || s.changed {
-				sentStates = append(sentStates, s)
-			} else {
-				skippedIDs[s.change.ChangeID] = skipReason{reason: "up-to-date", benign: true}
-				skippedStates = append(skippedStates, s)
+// lookupPRs returns the open PRs for branches. It answers from the state
+// cache when every branch has a fresh entry (unless --refresh), and records
+// what GitHub returned otherwise. A dry run falls back to a stale cache when
+// GitHub cannot be reached, so the plan can still be shown offline.
+func lookupPRs(client forge.Service, branches []string, opts sendOpts, w io.Writer) (map[string]*forge.PRInfo, error) {
+	if len(branches) == 0 {
+		return make(map[string]*forge.PRInfo), nil
+	}
+	if opts.state != nil && !opts.refresh {
+		if prs, ok := opts.state.CachedPRs(branches, prCacheTTL, time.Now()); ok {
+			slog.Debug("PR lookup answered from cache", "branches", len(branches))
+			return prs, nil
+		}
+	}
+	prs, err := client.LookupPRsByBranch(branches)
+	if err != nil {
+		if opts.dryRun && opts.state != nil {
+			if cached, ok := opts.state.CachedPRs(branches, 0, time.Now()); ok {
+				_, _ = fmt.Fprintf(w, "warning: could not query GitHub (%v) — using PR data cached at %s\n",
+					err, opts.state.OldestPR(branches).Local().Format(time.DateTime))
+				return cached, nil
 			}
 		}
+		return nil, err
+	}
+	if opts.state != nil {
+		opts.state.RecordPRs(branches, prs, time.Now())
+	}
+	return prs, nil
+}
 
-		if len(sentStates) > 0 {
-			_, _ = fmt.Fprintf(w, "\n%d PR(s) sent:\n\n", len(sentStates))
-			for _, s := range sentStates {
-				action := "updated"
-				if s.isNew {
-					action = "created"
+// resumedBookmarks returns the progress of the bookmarks progress (nil when
+// not resuming) records as pushed with the current commit of their change
+// in dags, by bookmark name. Changes rewritten since are sent afresh.
+func resumedBookmarks(progress *state.SendProgress, dags []*jj.ChangeDAG) map[string]state.SentBookmark {
+	resumed := make(map[string]state.SentBookmark)
+	if progress == nil {
+		return resumed
+	}
+	for _, dag := range dags {
+		for _, c := range dag.Changes {
+			for _, name := range c.Bookmarks {
+				if b, ok := progress.Bookmarks[name]; ok && b.ChangeID == c.ChangeID && b.Pushed == c.CommitID {
+					resumed[name] = b
 				}
-				_, _ = fmt.Fprintf(w, "  #%-4d %s  %s\n", s.pr.Number, action, s.pr.URL)
-				_, _ = fmt.Fprintf(w, "         %.12s  %s\n", s.change.ChangeID, s.change.Title())
 			}
 		}
 	}
+	return resumed
+}
 
-	if len(skippedStates) > 0 || len(preSkippedChanges) > 0 {
-		printAllSkipped(w, skippedStates, skippedIDs, preSkippedChanges)
+// sentBookmark is the progress of s once its PR was created or updated.
+func sentBookmark(s *changeState) state.SentBookmark {
+	return state.SentBookmark{
+		ChangeID: s.change.ChangeID,
+		Pushed:   s.change.CommitID,
+		PR:       s.pr,
+		Done:     true,
+		Created:  s.isNew,
+		Changed:  s.changed,
 	}
-	// Only non-benign skips (conflicts, divergence, missing description, …)
-	// constitute a failure. Private commits and up-to-date PRs are expected.
-	if n := nonBenignSkips(skippedStates, skippedIDs, preSkippedChanges); n > 0 {
-		return fmt.Errorf("%d change(s) skipped", n)
+}
+
+// saveState persists the repo state, warning instead of failing: the state
+// is a cache and must never turn a successful send into an error.
+func saveState(st *state.State, w io.Writer) {
+	if err := st.Save(); err != nil {
+		_, _ = fmt.Fprintf(w, "warning: could not save jip state: %v\n", err)
 	}
-	return nil
 }
 
 // postChangesComment posts the "changes since" comment for an updated PR.
@@ -873,12 +2346,19 @@ func executeSend(runner jj.Runner, client gh.Service, opts sendOpts, w io.Writer
 // not available locally (e.g. another machine pushed it and we didn't fetch),
 // it documents that the diff could not be generated instead of computing one.
 //
+// A diff too large for a comment leaves the diffs of its biggest files out;
+// with --diff-gist the full diff is uploaded as a gist the comment links.
+//
 // When the interdiff is empty (e.g. a rebase-only push), opts.noChangeComment
 // controls the comment: "default" posts the formatted no-change comment,
 // "short" a single plain-text line, "none" nothing at all.
-func postChangesComment(runner jj.Runner, client gh.Service, s *changeState, remoteTarget, repoFullName, baseBranch string, opts sendOpts, w io.Writer) error {
+func postChangesComment(runner jj.Runner, client forge.Service, s *changeState, remoteTarget, baseBranch string, opts sendOpts, w io.Writer) error {
+	if opts.noInterdiff {
+		return nil
+	}
 	newCommit := s.change.CommitID
 	sinceJip := opts.diffSinceJip
+	links := client.Links()
 
 	base := remoteTarget
 	fromRecord := false
@@ -895,6 +2375,30 @@ func postChangesComment(runner jj.Runner, client gh.Service, s *changeState, rem
 		return nil
 	}
 
+	// A send that failed after commenting still has the old commit recorded
+	// in the PR body, so retrying it would post the same comment again.
+	if opts.state != nil {
+		if rec, ok := opts.state.Change(s.change.ChangeID); ok && rec.Interdiff != nil &&
+			*rec.Interdiff == (state.InterdiffPair{From: base, To: newCommit}) {
+			slog.Debug("interdiff comment already posted", "pr", s.pr.Number, "from", base, "to", newCommit)
+			return nil
+		}
+	}
+	comment := func(body string) error {
+		if opts.revisionHistory {
+			if err := appendRevisionHistory(client, s.pr.Number, body); err != nil {
+				return err
+			}
+		} else if err := client.CommentOnPR(s.pr.Number, body); err != nil {
+			return fmt.Errorf("commenting on PR #%d: %w", s.pr.Number, err)
+		}
+		if opts.state != nil {
+			opts.state.RecordInterdiff(s.change.ChangeID, base, newCommit, time.Now())
+		}
+		s.changed = true
+		return nil
+	}
+
 	// A base recovered from a jip record may not be present locally.
 	if fromRecord {
 		exists, err := runner.CommitExists(base)
@@ -902,12 +2406,7 @@ func postChangesComment(runner jj.Runner, client gh.Service, s *changeState, rem
 			return fmt.Errorf("checking commit %s for #%d: %w", base, s.pr.Number, err)
 		}
 		if !exists {
-			comment := gh.BuildUnavailableDiffComment(repoFullName, baseBranch, base, newCommit)
-			if err := client.CommentOnPR(s.pr.Number, comment); err != nil {
-				return fmt.Errorf("commenting on PR #%d: %w", s.pr.Number, err)
-			}
-			s.changed = true
-			return nil
+			return comment(gh.BuildUnavailableDiffComment(links, baseBranch, base, newCommit))
 		}
 	}
 
@@ -925,18 +2424,40 @@ func postChangesComment(runner jj.Runner, client gh.Service, s *changeState, rem
 			if sinceJip && fromRecord {
 				msg = "No changes since last jip send."
 			}
-			if err := client.CommentOnPR(s.pr.Number, msg); err != nil {
-				return fmt.Errorf("commenting on PR #%d: %w", s.pr.Number, err)
-			}
-			s.changed = true
-			return nil
+			return comment(msg)
+		}
+	}
+	if lines, files := gh.DiffSize(diff); lines+files > 0 && !opts.interdiffMin.exceeded(lines, files) {
+		return comment(gh.BuildCompareLinkComment(links, baseBranch, base, newCommit, sinceJip && fromRecord, lines, files))
+	}
+	body := gh.BuildDiffComment(diff, links, baseBranch, base, newCommit, sinceJip && fromRecord, "")
+	if opts.diffGist && strings.Contains(body, gh.TruncatedDiffMarker) {
+		desc := fmt.Sprintf("Changes to PR #%d of %s from %.7s to %.7s", s.pr.Number, client.Owner()+"/"+client.Repo(), base, newCommit)
+		if url, err := client.CreateGist(desc, fmt.Sprintf("pr-%d-%.7s.diff", s.pr.Number, newCommit), diff); err != nil {
+			_, _ = fmt.Fprintf(w, "  warning: could not upload the full diff of #%d as a gist: %v\n", s.pr.Number, err)
+		} else {
+			body = gh.BuildDiffComment(diff, links, baseBranch, base, newCommit, sinceJip && fromRecord, url)
+		}
+	}
+	return comment(body)
+}
+
+// appendRevisionHistory adds entry to the PR's rolling revision history
+// comment, creating the comment on the first push.
+func appendRevisionHistory(client forge.Service, number int, entry string) error {
+	existing, err := client.FindComment(number, gh.RevisionHistoryMarker)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		if err := client.CommentOnPR(number, gh.AppendRevision("", entry)); err != nil {
+			return fmt.Errorf("commenting on PR #%d: %w", number, err)
 		}
+		return nil
 	}
-	comment := gh.BuildDiffComment(diff, repoFullName, baseBranch, base, newCommit, sinceJip && fromRecord)
-	if err := client.CommentOnPR(s.pr.Number, comment); err != nil {
-		return fmt.Errorf("commenting on PR #%d: %w", s.pr.Number, err)
+	if err := client.EditComment(existing.ID, gh.AppendRevision(existing.Body, entry)); err != nil {
+		return fmt.Errorf("updating revision history on PR #%d: %w", number, err)
 	}
-	s.changed = true
 	return nil
 }
 
@@ -945,6 +2466,91 @@ func postChangesComment(runner jj.Runner, client gh.Service, s *changeState, rem
 // not unrelated branches in the same DAG. PR numbers are returned in the
 // same topological order as the input states.
 func computeStackPRs(states []changeState) [][]int {
+	chains := dependencyChains(states)
+	result := make([][]int, len(states))
+	for i, chain := range chains {
+		prs := make([]int, len(chain))
+		for j, k := range chain {
+			prs[j] = states[k].pr.Number
+		}
+		result[i] = prs
+	}
+	return result
+}
+
+// foldSkippedChanges folds the changes of dags whose descriptions ask for no
+// PR (see jj.SkipsPR) into their children, and returns the remaining DAGs
+// with the folded changes for the skipped report.
+func foldSkippedChanges(dags []*jj.ChangeDAG) ([]*jj.ChangeDAG, []skippedEntry) {
+	var result []*jj.ChangeDAG
+	var skipped []skippedEntry
+	for _, dag := range dags {
+		skip := make(map[string]bool)
+		for _, c := range dag.Changes {
+			if jj.SkipsPR(c.Description) {
+				skip[c.ChangeID] = true
+			}
+		}
+		if len(skip) == 0 {
+			result = append(result, dag)
+			continue
+		}
+		for _, c := range dag.Changes {
+			if !skip[c.ChangeID] {
+				continue
+			}
+			reason := "marked to skip (Jip-Skip or [skip pr])"
+			if slices.ContainsFunc(dag.Changes, func(d *jj.Change) bool { return slices.Contains(d.ParentIDs, c.ChangeID) }) {
+				reason += ", folded into the PR of the change above"
+			}
+			skipped = append(skipped, skippedEntry{change: c, reason: skipReason{reason: reason, benign: true}})
+		}
+		result = append(result, jj.FoldDAG(dag, skip)...)
+	}
+	return result, skipped
+}
+
+// wipPrefix starts the titles of PRs sent with --wip.
+const wipPrefix = "WIP: "
+
+// wipTitle returns title with wipPrefix in front when wip is set, and
+// without it otherwise.
+func wipTitle(title string, wip bool) string {
+	title = strings.TrimPrefix(title, wipPrefix)
+	if wip {
+		return wipPrefix + title
+	}
+	return title
+}
+
+// titleChanged reports whether pr's title differs from title. Forges such
+// as Gitea keep wipPrefix out of the titles they report and set the draft
+// flag instead, so the title of a draft counts as having the prefix.
+func titleChanged(pr *forge.PRInfo, title string) bool {
+	current := pr.Title
+	if pr.IsDraft && strings.HasPrefix(title, wipPrefix) {
+		current = wipTitle(current, true)
+	}
+	return current != title
+}
+
+// titlePrefixes returns the prefix of each of states' PR titles for
+// --title-prefix: the change's position in its dependency chain (see
+// computeStackPRs), e.g. "[2/5] ". Changes sent on their own get none.
+func titlePrefixes(states []changeState) []string {
+	prefixes := make([]string, len(states))
+	for i, chain := range dependencyChains(states) {
+		if len(chain) > 1 {
+			prefixes[i] = fmt.Sprintf("[%d/%d] ", slices.Index(chain, i)+1, len(chain))
+		}
+	}
+	return prefixes
+}
+
+// dependencyChains returns the dependency chain of each change of states,
+// its ancestors and descendants among states, as indices into states in
+// their topological order.
+func dependencyChains(states []changeState) [][]int {
 	idxByChange := make(map[string]int, len(states))
 	for i, s := range states {
 		idxByChange[s.change.ChangeID] = i
@@ -988,18 +2594,109 @@ func computeStackPRs(states []changeState) [][]int {
 		}
 		walkDown(s.change.ChangeID)
 
-		// Collect PR numbers preserving topological order.
-		var prs []int
-		for _, st := range states {
+		// Collect the chain preserving topological order.
+		var chain []int
+		for j, st := range states {
 			if relevant[st.change.ChangeID] {
-				prs = append(prs, st.pr.Number)
+				chain = append(chain, j)
 			}
 		}
-		result[i] = prs
+		result[i] = chain
 	}
 	return result
 }
 
+// checkStackOrder validates a --stack-order value.
+func checkStackOrder(order string) error {
+	switch order {
+	case stackOrderNewest, stackOrderOldest:
+		return nil
+	}
+	return fmt.Errorf("invalid --stack-order value %q (valid: %s, %s)", order, stackOrderNewest, stackOrderOldest)
+}
+
+// squashedCommits lists the changes of a stack sent as a single PR for its
+// commit checklist.
+func squashedCommits(changes []*jj.Change) []gh.SquashedCommit {
+	commits := make([]gh.SquashedCommit, len(changes))
+	for i, c := range changes {
+		commits[i] = gh.SquashedCommit{Hash: c.CommitID, Title: c.Title()}
+	}
+	return commits
+}
+
+// stackFormat returns how the stack navigation of states' PRs is rendered
+// for the given --stack-order, --stack-titles and --stack-status.
+func stackFormat(states []changeState, order string, titles, status bool) gh.StackFormat {
+	format := gh.StackFormat{OldestFirst: order == stackOrderOldest, Status: status}
+	if titles {
+		format.Titles = make(map[int]string, len(states))
+		for _, s := range states {
+			format.Titles[s.pr.Number] = s.pr.Title
+		}
+	}
+	if status {
+		format.States = make(map[int]string, len(states))
+		for _, s := range states {
+			if s.pr.IsDraft {
+				format.States[s.pr.Number] = "DRAFT"
+			}
+		}
+	}
+	return format
+}
+
+// withFinishedPRs returns stack with the PRs prepended that the stack
+// navigation of body lists but stack lacks because they have been merged or
+// closed since, so that --stack-status shows them struck through. Their
+// states and titles go into format. getPR returns a PR by number.
+func withFinishedPRs(body string, stack []int, format gh.StackFormat, getPR func(int) (*forge.PRInfo, error)) ([]int, error) {
+	// A PR left alone is no longer stacked.
+	block := gh.ParseStackBlock(body)
+	if !format.Status || block == nil || len(stack) <= 1 {
+		return stack, nil
+	}
+	var finished []int
+	for _, n := range block.PRs {
+		if slices.Contains(stack, n) {
+			continue
+		}
+		pr, err := getPR(n)
+		if err != nil {
+			return stack, fmt.Errorf("looking up PR #%d: %w", n, err)
+		}
+		if pr.State != "MERGED" && pr.State != "CLOSED" {
+			continue
+		}
+		finished = append(finished, n)
+		format.States[n] = pr.State
+		if format.Titles != nil {
+			format.Titles[n] = pr.Title
+		}
+	}
+	return append(finished, stack...), nil
+}
+
+// cachedGetPR returns client.GetPR, looking each PR up only once; the PRs
+// of known are not looked up at all.
+func cachedGetPR(client forge.Service, known ...*forge.PRInfo) func(int) (*forge.PRInfo, error) {
+	prs := make(map[int]*forge.PRInfo, len(known))
+	for _, pr := range known {
+		prs[pr.Number] = pr
+	}
+	return func(n int) (*forge.PRInfo, error) {
+		if pr, ok := prs[n]; ok {
+			return pr, nil
+		}
+		pr, err := client.GetPR(n)
+		if err != nil {
+			return nil, err
+		}
+		prs[n] = pr
+		return pr, nil
+	}
+}
+
 // stackGroups splits states into connected groups, preserving topological
 // (bottom-to-top) order. Skipping a merge can disconnect one resolved DAG into
 // multiple stacks. The returned pointers alias the input slice, so later
@@ -1065,12 +2762,12 @@ func checkLinearStacks(states []changeState) error {
 	for _, s := range states {
 		id := s.change.ChangeID
 		if parentCount[id] > 1 {
-			return fmt.Errorf("--stack=gh-native requires linear stacks, but change %.12s (%s) has %d parents in the stack — reshape the stack or use --stack=default",
-				id, s.change.Title(), parentCount[id])
+			return fmt.Errorf("--stack=gh-native requires linear stacks, but change %s (%s) has %d parents in the stack — reshape the stack or use --stack=default",
+				s.change.Short(), s.change.Title(), parentCount[id])
 		}
 		if childCount[id] > 1 {
-			return fmt.Errorf("--stack=gh-native requires linear stacks, but change %.12s (%s) has %d children in the stack — reshape the stack or use --stack=default",
-				id, s.change.Title(), childCount[id])
+			return fmt.Errorf("--stack=gh-native requires linear stacks, but change %s (%s) has %d children in the stack — reshape the stack or use --stack=default",
+				s.change.Short(), s.change.Title(), childCount[id])
 		}
 	}
 	return nil
@@ -1081,9 +2778,9 @@ func checkLinearStacks(states []changeState) error {
 // appendTo, or create a fresh stack when both are nil (incompatible remote
 // stacks were already dissolved by prepareNativeStacks).
 type nativeStackPlan struct {
-	appendTo  *gh.Stack
+	appendTo  *forge.Stack
 	prefixLen int
-	keep      *gh.Stack
+	keep      *forge.Stack
 }
 
 // prepareNativeStacks inspects the GitHub stacks that the existing PRs belong
@@ -1092,7 +2789,7 @@ type nativeStackPlan struct {
 // or removal, changed base) is dissolved here and recreated later; dissolving
 // first keeps the PR base updates that follow from conflicting with
 // server-side stack state.
-func prepareNativeStacks(client gh.Service, groups [][]*changeState, baseBranch string, w io.Writer) ([]nativeStackPlan, error) {
+func prepareNativeStacks(client forge.Service, groups [][]*changeState, baseBranches map[string]string, w io.Writer) ([]nativeStackPlan, error) {
 	plans := make([]nativeStackPlan, len(groups))
 	for gi, group := range groups {
 		// Existing PR numbers bottom-to-top; an existing PR above a new one
@@ -1113,7 +2810,7 @@ func prepareNativeStacks(client gh.Service, groups [][]*changeState, baseBranch
 		// Find the distinct stacks the existing PRs belong to. A PR belongs
 		// to at most one stack, so membership in a found stack answers the
 		// lookup for the other PRs it lists.
-		var stacks []*gh.Stack
+		var stacks []*forge.Stack
 		resolved := make(map[int]bool)
 		for _, num := range existing {
 			if resolved[num] {
@@ -1138,7 +2835,7 @@ func prepareNativeStacks(client gh.Service, groups [][]*changeState, baseBranch
 		}
 
 		sameStack := len(stacks) == 1 && !newBelowExisting &&
-			stacks[0].Base.Ref == baseBranch
+			stacks[0].Base.Ref == baseBranches[group[0].change.ChangeID]
 		open := stacks[0].OpenPRNumbers()
 		switch {
 		case sameStack && slices.Equal(open, existing):
@@ -1165,7 +2862,7 @@ func prepareNativeStacks(client gh.Service, groups [][]*changeState, baseBranch
 
 // dissolveStack unstacks a GitHub stack, failing with an actionable error
 // when some PRs cannot be removed (merge-queued or auto-merge enabled).
-func dissolveStack(client gh.Service, number int, w io.Writer) error {
+func dissolveStack(client forge.Service, number int, w io.Writer) error {
 	dissolved, err := client.Unstack(number)
 	if err != nil {
 		return fmt.Errorf("dissolving GitHub stack #%d: %w", number, err)
@@ -1180,7 +2877,7 @@ func dissolveStack(client gh.Service, number int, w io.Writer) error {
 // finalizeNativeStacks creates or extends the native GitHub stack for each
 // group, once every PR exists with a chained base. Groups with a single PR
 // get no stack (GitHub requires at least two).
-func finalizeNativeStacks(client gh.Service, groups [][]*changeState, plans []nativeStackPlan, w io.Writer) error {
+func finalizeNativeStacks(client forge.Service, groups [][]*changeState, plans []nativeStackPlan, w io.Writer) error {
 	for gi, group := range groups {
 		chain := make([]int, len(group))
 		for i, s := range group {
@@ -1223,6 +2920,35 @@ func finalizeNativeStacks(client gh.Service, groups [][]*changeState, plans []na
 	return nil
 }
 
+// pushIndividually pushes the bookmark of each state on its own, in stack
+// order, and reports the outcome per bookmark. A change whose parent could
+// not be pushed is not attempted, since its PR would show the parent's
+// commits. It returns the failure reason by change ID.
+func pushIndividually(runner jj.Runner, states []changeState, remote string, w io.Writer) map[string]string {
+	failed := make(map[string]string)
+	for _, s := range states {
+		ancestorFailed := false
+		for _, pid := range s.change.ParentIDs {
+			if _, ok := failed[pid]; ok {
+				ancestorFailed = true
+				break
+			}
+		}
+		if ancestorFailed {
+			failed[s.change.ChangeID] = "skipped because ancestor could not be pushed"
+			_, _ = fmt.Fprintf(w, "  %s  skipped (ancestor not pushed)\n", s.bookmark.Bookmark)
+			continue
+		}
+		if err := runner.GitPush([]string{s.bookmark.Bookmark}, remote); err != nil {
+			failed[s.change.ChangeID] = extractPushError(err)
+			_, _ = fmt.Fprintf(w, "  %s  failed: %s\n", s.bookmark.Bookmark, failed[s.change.ChangeID])
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "  %s  pushed\n", s.bookmark.Bookmark)
+	}
+	return failed
+}
+
 // extractPushError extracts a clean reason from a jj git push error.
 // It looks for an "Error:" line in the output; falls back to the full message.
 func extractPushError(err error) string {
@@ -1237,11 +2963,11 @@ func extractPushError(err error) string {
 }
 
 // printPreSkippedChanges reports changes that were pre-skipped (before bookmark creation).
-func printPreSkippedChanges(w io.Writer, skipped []skippedEntry) {
-	_, _ = fmt.Fprintf(w, "\nSkipped %d change(s):\n\n", len(skipped))
+func printPreSkippedChanges(w io.Writer, skipped []skippedEntry, c colors) {
+	_, _ = fmt.Fprintf(w, i18n.T("\nSkipped %d change(s):\n\n"), len(skipped))
 	for _, s := range skipped {
-		_, _ = fmt.Fprintf(w, "  %.12s  %s\n", s.change.ChangeID, s.change.Title())
-		_, _ = fmt.Fprintf(w, "         %s\n", s.reason.reason)
+		_, _ = fmt.Fprintf(w, "  %s  %s\n", s.change.Short(), s.change.Title())
+		_, _ = fmt.Fprintf(w, "         %s\n", c.skipReason(s.reason))
 	}
 }
 
@@ -1263,16 +2989,16 @@ func nonBenignSkips(postSkipped []changeState, postReasons map[string]skipReason
 }
 
 // printAllSkipped reports all skipped changes (both pre-skip and post-bookmark-creation).
-func printAllSkipped(w io.Writer, postSkipped []changeState, postReasons map[string]skipReason, preSkipped []skippedEntry) {
+func printAllSkipped(w io.Writer, postSkipped []changeState, postReasons map[string]skipReason, preSkipped []skippedEntry, c colors) {
 	total := len(postSkipped) + len(preSkipped)
-	_, _ = fmt.Fprintf(w, "\nSkipped %d change(s):\n\n", total)
+	_, _ = fmt.Fprintf(w, i18n.T("\nSkipped %d change(s):\n\n"), total)
 	for _, s := range preSkipped {
-		_, _ = fmt.Fprintf(w, "  %.12s  %s\n", s.change.ChangeID, s.change.Title())
-		_, _ = fmt.Fprintf(w, "         %s\n", s.reason.reason)
+		_, _ = fmt.Fprintf(w, "  %s  %s\n", s.change.Short(), s.change.Title())
+		_, _ = fmt.Fprintf(w, "         %s\n", c.skipReason(s.reason))
 	}
 	for _, s := range postSkipped {
 		r := postReasons[s.change.ChangeID]
-		_, _ = fmt.Fprintf(w, "  %.12s  %s\n", s.change.ChangeID, s.change.Title())
-		_, _ = fmt.Fprintf(w, "         %s\n", r.reason)
+		_, _ = fmt.Fprintf(w, "  %s  %s\n", s.change.Short(), s.change.Title())
+		_, _ = fmt.Fprintf(w, "         %s\n", c.skipReason(r))
 	}
 }
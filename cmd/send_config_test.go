@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -32,6 +33,8 @@ func newValueLike(f *pflag.Flag) pflag.Value {
 		tmp.Bool(f.Name, f.DefValue == "true", "")
 	case "stringSlice":
 		tmp.StringSlice(f.Name, nil, "")
+	case "stringArray":
+		tmp.StringArray(f.Name, nil, "")
 	default:
 		tmp.String(f.Name, f.DefValue, "")
 	}
@@ -51,7 +54,7 @@ func TestApplySendConfig_SetsUnsetFlags(t *testing.T) {
 	if got := flags.Lookup("rebase").Value.String(); got != "true" {
 		t.Errorf("rebase = %q, want true", got)
 	}
-	if got := flags.Lookup("base").Value.String(); got != "dev" {
+	if got := flags.Lookup("base").Value.String(); got != "[dev]" {
 		t.Errorf("base = %q, want dev", got)
 	}
 	if got := flags.Lookup("reviewer").Value.String(); got != "[alice,team/backend]" {
@@ -67,7 +70,7 @@ func TestApplySendConfig_CLIFlagWins(t *testing.T) {
 	if err := applySendConfig(flags, map[string]string{"base": "dev"}); err != nil {
 		t.Fatalf("applySendConfig: %v", err)
 	}
-	if got := flags.Lookup("base").Value.String(); got != "release" {
+	if got := flags.Lookup("base").Value.String(); got != "[release]" {
 		t.Errorf("base = %q, want release (CLI must override config)", got)
 	}
 }
@@ -94,6 +97,57 @@ func TestApplySendConfig_InvalidValue(t *testing.T) {
 	}
 }
 
+func TestParseBases(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		wantBase string
+		want     []sendBase
+		wantErr  bool
+	}{
+		{name: "fallback", values: nil, wantBase: "trunk()"},
+		{name: "plain base", values: []string{"dev"}, wantBase: "dev"},
+		{name: "last plain base wins", values: []string{"dev", "main"}, wantBase: "main"},
+		{
+			name:     "mapping",
+			values:   []string{"main", "release/1.2=hotfix"},
+			wantBase: "main",
+			want:     []sendBase{{branch: "release/1.2", revsets: []string{"hotfix"}}},
+		},
+		{
+			name:     "mappings grouped by branch",
+			values:   []string{"release/1.2=a", "release/1.1=b", "release/1.2 = c"},
+			wantBase: "trunk()",
+			want: []sendBase{
+				{branch: "release/1.2", revsets: []string{"a", "c"}},
+				{branch: "release/1.1", revsets: []string{"b"}},
+			},
+		},
+		{name: "revset with keyword argument", values: []string{"remote_bookmarks(main, remote=upstream)"}, wantBase: "remote_bookmarks(main, remote=upstream)"},
+		{name: "missing revset", values: []string{"release/1.2="}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, others, err := parseBases(tt.values, "trunk()")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if base != tt.wantBase {
+				t.Errorf("base = %q, want %q", base, tt.wantBase)
+			}
+			if !reflect.DeepEqual(others, tt.want) {
+				t.Errorf("others = %+v, want %+v", others, tt.want)
+			}
+		})
+	}
+}
+
 func TestResolveStackMode(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -130,6 +184,51 @@ func TestResolveStackMode(t *testing.T) {
 	}
 }
 
+func TestResolveAutoMerge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "", want: ""},
+		{in: "squash", want: "squash"},
+		{in: "rebase", want: "rebase"},
+		{in: "true", want: "squash"},
+		{in: "false", want: ""},
+		{in: "fast-forward", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := resolveAutoMerge(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("resolveAutoMerge(%q): expected error", tt.in)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("resolveAutoMerge(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolveCleanup(t *testing.T) {
+	for in, want := range map[string]string{
+		"":      cleanupOff,
+		"false": cleanupOff,
+		"off":   cleanupOff,
+		"ask":   cleanupAsk,
+		"yes":   cleanupYes,
+		"true":  cleanupYes,
+	} {
+		if got, err := resolveCleanup(in); err != nil || got != want {
+			t.Errorf("resolveCleanup(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := resolveCleanup("always"); err == nil {
+		t.Error("resolveCleanup(\"always\"): expected error")
+	}
+}
+
 // Every key allowed in config must correspond to an actual send flag.
 func TestSendConfigKeys_MatchFlags(t *testing.T) {
 	for key := range sendConfigKeys {
@@ -138,3 +237,10 @@ func TestSendConfigKeys_MatchFlags(t *testing.T) {
 		}
 	}
 }
+
+func TestApplySendConfig_SkipsGlobalKeys(t *testing.T) {
+	flags := newSendFlags()
+	if err := applySendConfig(flags, map[string]string{"jj-bin": "/opt/jj/bin/jj"}); err != nil {
+		t.Fatalf("applySendConfig: %v", err)
+	}
+}
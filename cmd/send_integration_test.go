@@ -5,266 +5,322 @@ package cmd
 import (
 	"bytes"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"slices"
 	"strings"
-	"sync"
 	"testing"
+	"time"
 
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/forge/forgetest"
 	gh "github.com/omarkohl/jip/internal/github"
-	"github.com/omarkohl/jip/internal/jj"
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/omarkohl/jip/pkg/jj"
 )
 
-// mockService implements gh.Service with in-memory state.
-type mockService struct {
-	mu        sync.Mutex
-	prs       map[int]*gh.PRInfo
-	comments  map[int][]string
-	reviewers map[int][]string
-	nextPR    int
-	owner     string
-	repo      string
-
-	// Native stacked-PRs state. stacksEnabled mirrors the private-preview
-	// gate; call counters let tests assert reconciliation behavior.
-	stacksEnabled    bool
-	stacks           map[int]*gh.Stack
-	nextStack        int
-	createStackCalls int
-	addToStackCalls  int
-	unstackCalls     int
-}
-
-func newMockService() *mockService {
-	return &mockService{
-		prs:       make(map[int]*gh.PRInfo),
-		comments:  make(map[int][]string),
-		reviewers: make(map[int][]string),
-		nextPR:    1,
-		owner:     "testowner",
-		repo:      "testrepo",
-		stacks:    make(map[int]*gh.Stack),
-		nextStack: 1,
-	}
-}
-
-func (m *mockService) Owner() string { return m.owner }
-func (m *mockService) Repo() string  { return m.repo }
-
-func (m *mockService) GetAuthenticatedUser() (string, error) {
-	return "testuser", nil
-}
-
-func (m *mockService) CreatePR(head, base, title, body string, draft bool) (*gh.PRInfo, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	num := m.nextPR
-	m.nextPR++
-	pr := &gh.PRInfo{
-		Number:      num,
-		State:       "OPEN",
-		URL:         fmt.Sprintf("https://github.com/%s/%s/pull/%d", m.owner, m.repo, num),
-		Title:       title,
-		Body:        body,
-		HeadRefName: head,
-		BaseRefName: base,
-		IsDraft:     draft,
-	}
-	m.prs[num] = pr
-	return pr, nil
-}
-
-func (m *mockService) UpdatePR(number int, opts gh.UpdatePROpts) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	pr := m.prs[number]
-	if pr != nil {
-		if opts.Title != nil {
-			pr.Title = *opts.Title
-		}
-		if opts.Body != nil {
-			pr.Body = *opts.Body
-		}
-		if opts.Base != nil {
-			pr.BaseRefName = *opts.Base
-		}
-	}
-	return nil
-}
+func TestIntegration_SendCreatesNewPRs(t *testing.T) {
+	checkJJ(t)
 
-func (m *mockService) CommentOnPR(number int, body string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.comments[number] = append(m.comments[number], body)
-	return nil
-}
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
 
-func (m *mockService) RequestReviewers(number int, reviewers []string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.reviewers[number] = append(m.reviewers[number], reviewers...)
-	return nil
-}
+	// Create two changes.
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add feature A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "fix: fix bug B")
 
-func (m *mockService) LookupPRsByBranch(branches []string) (map[string]*gh.PRInfo, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	result := make(map[string]*gh.PRInfo)
-	for _, branch := range branches {
-		for _, pr := range m.prs {
-			if pr.HeadRefName == branch && pr.State == "OPEN" {
-				result[branch] = pr
-				break
-			}
-		}
+	var buf bytes.Buffer
+	err := executeSend(runner, mock, sendOpts{
+		base:    "main",
+		remote:  "origin",
+		revsets: []string{"@-"},
+	}, &buf)
+	if err != nil {
+		t.Fatalf("send failed: %v\nOutput:\n%s", err, buf.String())
 	}
-	return result, nil
-}
 
-func (m *mockService) StacksEnabled() (bool, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return m.stacksEnabled, nil
-}
+	output := buf.String()
+	t.Logf("Output:\n%s", output)
 
-func (m *mockService) FindStackForPR(number int) (*gh.Stack, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	for _, st := range m.stacks {
-		for _, p := range st.PullRequests {
-			if p.Number == number {
-				return st, nil
-			}
+	// Verify PRs were created.
+	mock.Lock()
+	defer mock.Unlock()
+
+	if len(mock.PRs) != 2 {
+		t.Errorf("expected 2 PRs created, got %d", len(mock.PRs))
+	}
+
+	// Verify PR bodies contain stack navigation.
+	for _, pr := range mock.PRs {
+		if !strings.Contains(pr.Body, "stacked PR") {
+			t.Errorf("PR #%d body missing stack navigation:\n%s", pr.Number, pr.Body)
+		}
+		if !strings.Contains(pr.Body, "review commit") {
+			t.Errorf("PR #%d body missing review commit:\n%s", pr.Number, pr.Body)
 		}
 	}
-	return nil, nil
-}
 
-// checkChained enforces the API's base-to-head chain requirement: each PR's
-// base must be the head branch of the PR below it.
-func (m *mockService) checkChained(below, above int) error {
-	prBelow, prAbove := m.prs[below], m.prs[above]
-	if prBelow == nil || prAbove == nil {
-		return fmt.Errorf("unknown PR in stack request (#%d, #%d)", below, above)
+	if !strings.Contains(output, "created") {
+		t.Error("expected 'created' in output")
 	}
-	if prAbove.BaseRefName != prBelow.HeadRefName {
-		return fmt.Errorf("PR #%d base %q does not match PR #%d head %q",
-			above, prAbove.BaseRefName, below, prBelow.HeadRefName)
+	if !strings.Contains(output, "2 PR(s) sent") {
+		t.Errorf("expected '2 PR(s) sent' in output, got:\n%s", output)
 	}
-	return nil
 }
 
-func (m *mockService) CreateStack(prNumbers []int) (*gh.Stack, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.createStackCalls++
-	if len(prNumbers) < 2 {
-		return nil, fmt.Errorf("a stack requires at least 2 PRs, got %d", len(prNumbers))
-	}
-	for i := 1; i < len(prNumbers); i++ {
-		if err := m.checkChained(prNumbers[i-1], prNumbers[i]); err != nil {
-			return nil, err
-		}
+func TestIntegration_SendReviewersFromBlame(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	mock.CommitAuthor = &forge.CommitAuthor{Login: "alice", Date: time.Now()}
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	// Rewrite the README added by the initial commit, so blame points there.
+	writeAndCommit(t, repoDir, "README.md", "# renamed repo", "docs: rename")
+
+	var buf bytes.Buffer
+	err := executeSend(runner, mock, sendOpts{
+		base:           "main",
+		remote:         "origin",
+		revsets:        []string{"@-"},
+		reviewers:      []string{"bob"},
+		blameReviewers: true,
+	}, &buf)
+	if err != nil {
+		t.Fatalf("send failed: %v\nOutput:\n%s", err, buf.String())
 	}
-	st := &gh.Stack{
-		Number: m.nextStack,
-		Open:   true,
-		Base:   gh.StackBase{Ref: m.prs[prNumbers[0]].BaseRefName},
+
+	mock.Lock()
+	defer mock.Unlock()
+	if got := mock.Reviewers[1]; !slices.Equal(got, []string{"bob", "alice"}) {
+		t.Errorf("reviewers = %v, want [bob alice]\nOutput:\n%s", got, buf.String())
 	}
-	m.nextStack++
-	for _, n := range prNumbers {
-		st.PullRequests = append(st.PullRequests, gh.StackPR{Number: n, State: "open"})
+	if !strings.Contains(buf.String(), "reviewers from blame: alice") {
+		t.Errorf("output does not mention the blame reviewers:\n%s", buf.String())
 	}
-	m.stacks[st.Number] = st
-	return st, nil
 }
 
-func (m *mockService) AddToStack(stackNumber int, prNumbers []int) (*gh.Stack, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.addToStackCalls++
-	st := m.stacks[stackNumber]
-	if st == nil {
-		return nil, fmt.Errorf("no such stack #%d", stackNumber)
+func TestIntegration_SendReviewersFromCodeOwners(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	mock.CodeOwnersFile = []byte("* @default-owner\n/docs/ @org/docs-team @bob\n*.go @alice\n")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "docs/guide.md", "# guide", "docs: add guide")
+
+	var buf bytes.Buffer
+	err := executeSend(runner, mock, sendOpts{
+		base:           "main",
+		remote:         "origin",
+		revsets:        []string{"@-"},
+		reviewers:      []string{"bob"},
+		ownerReviewers: true,
+	}, &buf)
+	if err != nil {
+		t.Fatalf("send failed: %v\nOutput:\n%s", err, buf.String())
+	}
+
+	mock.Lock()
+	defer mock.Unlock()
+	if got := mock.Reviewers[1]; !slices.Equal(got, []string{"bob", "org/docs-team"}) {
+		t.Errorf("reviewers = %v, want [bob org/docs-team]\nOutput:\n%s", got, buf.String())
 	}
-	prev := st.PullRequests[len(st.PullRequests)-1].Number
-	for _, n := range prNumbers {
-		if err := m.checkChained(prev, n); err != nil {
-			return nil, err
-		}
-		st.PullRequests = append(st.PullRequests, gh.StackPR{Number: n, State: "open"})
-		prev = n
+	if !strings.Contains(buf.String(), "reviewers from CODEOWNERS: org/docs-team") {
+		t.Errorf("output does not mention the CODEOWNERS reviewers:\n%s", buf.String())
 	}
-	return st, nil
 }
 
-func (m *mockService) Unstack(stackNumber int) (bool, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.unstackCalls++
-	if _, ok := m.stacks[stackNumber]; !ok {
-		return false, fmt.Errorf("no such stack #%d", stackNumber)
+func TestIntegration_SendAutoMerge(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+
+	var buf bytes.Buffer
+	err := executeSend(runner, mock, sendOpts{
+		base:      "main",
+		remote:    "origin",
+		revsets:   []string{"@-"},
+		autoMerge: "rebase",
+	}, &buf)
+	if err != nil {
+		t.Fatalf("send failed: %v\nOutput:\n%s", err, buf.String())
+	}
+
+	mock.Lock()
+	defer mock.Unlock()
+	// Both PRs target main, so only the bottom one may merge on its own.
+	if want := map[int]string{1: "rebase"}; !maps.Equal(mock.AutoMerge, want) {
+		t.Errorf("auto-merge = %v, want %v\nOutput:\n%s", mock.AutoMerge, want, buf.String())
+	}
+	if !strings.Contains(buf.String(), "#1    auto-merge enabled (rebase)") {
+		t.Errorf("output does not mention auto-merge:\n%s", buf.String())
 	}
-	delete(m.stacks, stackNumber)
-	return true, nil
 }
 
-func TestIntegration_SendCreatesNewPRs(t *testing.T) {
+func TestIntegration_SendOpensPRs(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
-	// Create two changes.
-	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add feature A")
-	writeAndCommit(t, repoDir, "b.go", "package b", "fix: fix bug B")
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
 
+	var opened []string
 	var buf bytes.Buffer
 	err := executeSend(runner, mock, sendOpts{
 		base:    "main",
 		remote:  "origin",
 		revsets: []string{"@-"},
+		open:    openTop,
+		openURL: func(url string) error {
+			opened = append(opened, url)
+			return nil
+		},
 	}, &buf)
 	if err != nil {
 		t.Fatalf("send failed: %v\nOutput:\n%s", err, buf.String())
 	}
+	if want := []string{"https://github.com/testowner/testrepo/pull/2"}; !slices.Equal(opened, want) {
+		t.Errorf("opened = %q, want %q", opened, want)
+	}
+
+	// A send that creates no PR opens nothing.
+	opened = nil
+	buf.Reset()
+	if err := executeSend(runner, mock, sendOpts{
+		base:    "main",
+		remote:  "origin",
+		revsets: []string{"@-"},
+		open:    openAll,
+		openURL: func(url string) error {
+			opened = append(opened, url)
+			return nil
+		},
+	}, &buf); err != nil {
+		t.Fatalf("second send failed: %v\nOutput:\n%s", err, buf.String())
+	}
+	if len(opened) != 0 {
+		t.Errorf("opened %q on a send without new PRs", opened)
+	}
+}
 
+func TestIntegration_SendQuiet(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+
+	var buf bytes.Buffer
+	err := executeSend(runner, mock, sendOpts{
+		base:     "main",
+		remote:   "origin",
+		revsets:  []string{"@-"},
+		progress: newProgress(&buf, progressQuiet),
+	}, &buf)
+	if err != nil {
+		t.Fatalf("send failed: %v\nOutput:\n%s", err, buf.String())
+	}
 	output := buf.String()
-	t.Logf("Output:\n%s", output)
+	for _, step := range []string{"Fetching", "Resolving", "Pushing", "Creating"} {
+		if strings.Contains(output, step) {
+			t.Errorf("quiet output mentions %q:\n%s", step, output)
+		}
+	}
+	if !strings.Contains(output, "1 PR(s) sent:") {
+		t.Errorf("quiet output lacks the summary:\n%s", output)
+	}
+}
 
-	// Verify PRs were created.
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
+func TestIntegration_SendMilestoneAndProject(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
 
-	if len(mock.prs) != 2 {
-		t.Errorf("expected 2 PRs created, got %d", len(mock.prs))
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+
+	var buf bytes.Buffer
+	err := executeSend(runner, mock, sendOpts{
+		base:      "main",
+		remote:    "origin",
+		revsets:   []string{"@-"},
+		milestone: "v1.0",
+		project:   "3",
+	}, &buf)
+	if err != nil {
+		t.Fatalf("send failed: %v\nOutput:\n%s", err, buf.String())
 	}
 
-	// Verify PR bodies contain stack navigation.
-	for _, pr := range mock.prs {
-		if !strings.Contains(pr.Body, "stacked PR") {
-			t.Errorf("PR #%d body missing stack navigation:\n%s", pr.Number, pr.Body)
-		}
-		if !strings.Contains(pr.Body, "review commit") {
-			t.Errorf("PR #%d body missing review commit:\n%s", pr.Number, pr.Body)
+	mock.Lock()
+	defer mock.Unlock()
+	if want := map[int]string{1: "v1.0", 2: "v1.0"}; !maps.Equal(mock.Milestones, want) {
+		t.Errorf("milestones = %v, want %v", mock.Milestones, want)
+	}
+	for n := 1; n <= 2; n++ {
+		if got := mock.Projects[n]; len(got) != 1 || got[0] != "testowner/3" {
+			t.Errorf("#%d projects = %v, want [testowner/3]", n, got)
 		}
 	}
+}
 
-	if !strings.Contains(output, "created") {
-		t.Error("expected 'created' in output")
+func TestIntegration_SendOverlappingRevsets(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+
+	// Both revsets cover the bottom change; the same revset is given twice.
+	var buf bytes.Buffer
+	err := executeSend(runner, mock, sendOpts{
+		base:    "main",
+		remote:  "origin",
+		revsets: []string{"@-", "@--", "@-"},
+	}, &buf)
+	if err != nil {
+		t.Fatalf("send failed: %v\nOutput:\n%s", err, buf.String())
 	}
-	if !strings.Contains(output, "2 PR(s) sent") {
-		t.Errorf("expected '2 PR(s) sent' in output, got:\n%s", output)
+
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 2 {
+		t.Fatalf("expected 2 PRs, got %d\nOutput:\n%s", len(mock.PRs), buf.String())
+	}
+	for _, pr := range mock.PRs {
+		if !strings.Contains(pr.Body, "#1") || !strings.Contains(pr.Body, "#2") {
+			t.Errorf("PR #%d should list the whole stack:\n%s", pr.Number, pr.Body)
+		}
+	}
+	if !strings.Contains(buf.String(), "2 PR(s) sent") {
+		t.Errorf("expected '2 PR(s) sent' in output, got:\n%s", buf.String())
 	}
 }
 
 func TestIntegration_SendDryRun(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -292,17 +348,84 @@ func TestIntegration_SendDryRun(t *testing.T) {
 	}
 
 	// Verify no PRs were actually created.
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 0 {
-		t.Errorf("expected 0 PRs in dry run, got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 0 {
+		t.Errorf("expected 0 PRs in dry run, got %d", len(mock.PRs))
+	}
+}
+
+func TestIntegration_SendMaxPRs(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+	writeAndCommit(t, repoDir, "c.go", "package c", "feat: add C")
+
+	var buf bytes.Buffer
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, maxPRs: 2}
+	err := executeSend(runner, mock, opts, &buf)
+	if err == nil || !strings.Contains(err.Error(), "--max-prs") {
+		t.Fatalf("err = %v, want the --max-prs guardrail\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "CREATE") {
+		t.Errorf("expected the plan in the output:\n%s", buf.String())
+	}
+	mock.Lock()
+	if len(mock.PRs) != 0 {
+		t.Errorf("expected no PRs above the limit, got %d", len(mock.PRs))
+	}
+	mock.Unlock()
+
+	buf.Reset()
+	opts.yes = true
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send --yes failed: %v\n%s", err, buf.String())
+	}
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 3 {
+		t.Errorf("expected 3 PRs with --yes, got %d", len(mock.PRs))
+	}
+}
+
+func TestIntegration_SendDryRunShowsChecks(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	mock.Lock()
+	mock.Checks = map[int]string{1: forge.ChecksPending}
+	mock.Unlock()
+
+	buf.Reset()
+	err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, dryRun: true}, &buf)
+	if err != nil {
+		t.Fatalf("send --dry-run failed: %v\n%s", err, buf.String())
+	}
+	out := buf.String()
+	if !strings.Contains(out, "checks: pending") || !strings.Contains(out, "checks: none") {
+		t.Errorf("dry run should show the checks of both PRs:\n%s", out)
 	}
 }
 
 func TestIntegration_SendExistingOnlySkipsNewPRs(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -322,11 +445,11 @@ func TestIntegration_SendExistingOnlySkipsNewPRs(t *testing.T) {
 	}
 	t.Logf("First send:\n%s", buf.String())
 
-	mock.mu.Lock()
-	if len(mock.prs) != 1 {
-		t.Fatalf("expected 1 PR after first send, got %d", len(mock.prs))
+	mock.Lock()
+	if len(mock.PRs) != 1 {
+		t.Fatalf("expected 1 PR after first send, got %d", len(mock.PRs))
 	}
-	mock.mu.Unlock()
+	mock.Unlock()
 
 	// Now send both A and B with --existing: only A should be updated.
 	buf.Reset()
@@ -349,10 +472,10 @@ func TestIntegration_SendExistingOnlySkipsNewPRs(t *testing.T) {
 	}
 
 	// Only the existing PR should be updated, no new ones created.
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 1 {
-		t.Errorf("expected still 1 PR with --existing, got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 1 {
+		t.Errorf("expected still 1 PR with --existing, got %d", len(mock.PRs))
 	}
 
 	if !strings.Contains(output, "up-to-date") {
@@ -366,7 +489,7 @@ func TestIntegration_SendExistingOnlySkipsNewPRs(t *testing.T) {
 func TestIntegration_SendExistingOnlyNoExistingPRs(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -391,17 +514,17 @@ func TestIntegration_SendExistingOnlyNoExistingPRs(t *testing.T) {
 		t.Errorf("expected 'No existing PRs to update' in output, got:\n%s", output)
 	}
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 0 {
-		t.Errorf("expected 0 PRs with --existing, got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 0 {
+		t.Errorf("expected 0 PRs with --existing, got %d", len(mock.PRs))
 	}
 }
 
 func TestIntegration_SendUpdatesExistingPRs(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -419,11 +542,11 @@ func TestIntegration_SendUpdatesExistingPRs(t *testing.T) {
 	}
 	t.Logf("First send:\n%s", buf.String())
 
-	mock.mu.Lock()
-	if len(mock.prs) != 1 {
-		t.Fatalf("expected 1 PR after first send, got %d", len(mock.prs))
+	mock.Lock()
+	if len(mock.PRs) != 1 {
+		t.Fatalf("expected 1 PR after first send, got %d", len(mock.PRs))
 	}
-	mock.mu.Unlock()
+	mock.Unlock()
 
 	// Now send again — should detect existing PR and update.
 	buf.Reset()
@@ -438,10 +561,10 @@ func TestIntegration_SendUpdatesExistingPRs(t *testing.T) {
 	t.Logf("Second send:\n%s", buf.String())
 
 	// No new PRs should have been created.
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 1 {
-		t.Errorf("expected still 1 PR after second send, got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 1 {
+		t.Errorf("expected still 1 PR after second send, got %d", len(mock.PRs))
 	}
 
 	// An unchanged PR is reported as skipped (up-to-date), not as sent, and
@@ -461,7 +584,7 @@ func TestIntegration_SendUpdatesExistingPRs(t *testing.T) {
 func TestIntegration_SendDiamondDAG(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -508,17 +631,17 @@ func TestIntegration_SendDiamondDAG(t *testing.T) {
 	output := buf.String()
 	t.Logf("Output:\n%s", output)
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
+	mock.Lock()
+	defer mock.Unlock()
 
 	// All 4 changes should produce 4 PRs.
-	if len(mock.prs) != 4 {
-		t.Fatalf("expected 4 PRs, got %d", len(mock.prs))
+	if len(mock.PRs) != 4 {
+		t.Fatalf("expected 4 PRs, got %d", len(mock.PRs))
 	}
 
 	// Verify each expected title exists.
 	titles := make(map[string]bool)
-	for _, pr := range mock.prs {
+	for _, pr := range mock.PRs {
 		titles[pr.Title] = true
 	}
 	for _, want := range []string{
@@ -533,15 +656,15 @@ func TestIntegration_SendDiamondDAG(t *testing.T) {
 	}
 
 	// All PRs target main.
-	for _, pr := range mock.prs {
+	for _, pr := range mock.PRs {
 		if pr.BaseRefName != "main" {
 			t.Errorf("PR #%d base is %q, expected \"main\"", pr.Number, pr.BaseRefName)
 		}
 	}
 
 	// Build title → PR lookup for precise stack assertions.
-	prByTitle := make(map[string]*gh.PRInfo)
-	for _, pr := range mock.prs {
+	prByTitle := make(map[string]*forge.PRInfo)
+	for _, pr := range mock.PRs {
 		prByTitle[pr.Title] = pr
 	}
 	prA := prByTitle["feat: add user authentication"]
@@ -550,7 +673,7 @@ func TestIntegration_SendDiamondDAG(t *testing.T) {
 	prD := prByTitle["feat: integrate auth with email notifications"]
 
 	// Every PR body should have stacked PR navigation.
-	for _, pr := range mock.prs {
+	for _, pr := range mock.PRs {
 		if !strings.Contains(pr.Body, "stacked PR") {
 			t.Errorf("PR #%d (%s) body missing 'stacked PR':\n%s", pr.Number, pr.Title, pr.Body)
 		}
@@ -560,13 +683,13 @@ func TestIntegration_SendDiamondDAG(t *testing.T) {
 	}
 
 	// A's stack: A, B, D (not C — C is an unrelated branch).
-	assertPRRefsInBody(t, prA, []*gh.PRInfo{prA, prB, prD}, []*gh.PRInfo{prC})
+	assertPRRefsInBody(t, prA, []*forge.PRInfo{prA, prB, prD}, []*forge.PRInfo{prC})
 	// B's stack: A, B, D (not C).
-	assertPRRefsInBody(t, prB, []*gh.PRInfo{prA, prB, prD}, []*gh.PRInfo{prC})
+	assertPRRefsInBody(t, prB, []*forge.PRInfo{prA, prB, prD}, []*forge.PRInfo{prC})
 	// C's stack: C, D (not A or B).
-	assertPRRefsInBody(t, prC, []*gh.PRInfo{prC, prD}, []*gh.PRInfo{prA, prB})
+	assertPRRefsInBody(t, prC, []*forge.PRInfo{prC, prD}, []*forge.PRInfo{prA, prB})
 	// D's stack: all four (it merges both branches).
-	assertPRRefsInBody(t, prD, []*gh.PRInfo{prA, prB, prC, prD}, nil)
+	assertPRRefsInBody(t, prD, []*forge.PRInfo{prA, prB, prC, prD}, nil)
 
 	if !strings.Contains(output, "4 PR(s) sent") {
 		t.Errorf("expected '4 PR(s) sent' in output, got:\n%s", output)
@@ -579,7 +702,7 @@ func TestIntegration_SendDiamondDAG(t *testing.T) {
 func TestIntegration_SendPostsInterdiffComment(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -601,15 +724,15 @@ func TestIntegration_SendPostsInterdiffComment(t *testing.T) {
 	}
 	t.Logf("First send:\n%s", buf.String())
 
-	mock.mu.Lock()
-	if len(mock.prs) != 1 {
-		t.Fatalf("expected 1 PR after first send, got %d", len(mock.prs))
+	mock.Lock()
+	if len(mock.PRs) != 1 {
+		t.Fatalf("expected 1 PR after first send, got %d", len(mock.PRs))
 	}
 	var prNumber int
-	for n := range mock.prs {
+	for n := range mock.PRs {
 		prNumber = n
 	}
-	mock.mu.Unlock()
+	mock.Unlock()
 
 	// Modify the change: add input validation to the handler.
 	jjRun(t, repoDir, "edit", changeID)
@@ -635,16 +758,16 @@ func TestIntegration_SendPostsInterdiffComment(t *testing.T) {
 	}
 	t.Logf("Second send:\n%s", buf.String())
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
+	mock.Lock()
+	defer mock.Unlock()
 
 	// No new PR should have been created.
-	if len(mock.prs) != 1 {
-		t.Errorf("expected still 1 PR, got %d", len(mock.prs))
+	if len(mock.PRs) != 1 {
+		t.Errorf("expected still 1 PR, got %d", len(mock.PRs))
 	}
 
 	// Verify the interdiff comment was posted on the PR.
-	comments := mock.comments[prNumber]
+	comments := mock.Comments[prNumber]
 	if len(comments) == 0 {
 		t.Fatal("expected an interdiff comment, got none")
 	}
@@ -683,10 +806,10 @@ func TestIntegration_SendPostsInterdiffComment(t *testing.T) {
 // setupNoChangeResend creates a PR via a first send, then rebases the change
 // onto an advanced main without touching its content, so a second send pushes
 // a new commit whose interdiff is empty (a rebase-only update).
-func setupNoChangeResend(t *testing.T) (jj.Runner, *mockService, int) {
+func setupNoChangeResend(t *testing.T) (jj.Runner, *forgetest.Fake, int) {
 	t.Helper()
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -703,16 +826,16 @@ func setupNoChangeResend(t *testing.T) (jj.Runner, *mockService, int) {
 		t.Fatalf("first send failed: %v\nOutput:\n%s", err, buf.String())
 	}
 
-	mock.mu.Lock()
-	if len(mock.prs) != 1 {
-		mock.mu.Unlock()
-		t.Fatalf("expected 1 PR after first send, got %d", len(mock.prs))
+	mock.Lock()
+	if len(mock.PRs) != 1 {
+		mock.Unlock()
+		t.Fatalf("expected 1 PR after first send, got %d", len(mock.PRs))
 	}
 	var prNumber int
-	for n := range mock.prs {
+	for n := range mock.PRs {
 		prNumber = n
 	}
-	mock.mu.Unlock()
+	mock.Unlock()
 
 	// Advance main with an unrelated change and rebase our change onto it.
 	// The commit ID changes (so the bookmark is pushed again) but the
@@ -729,7 +852,7 @@ func setupNoChangeResend(t *testing.T) (jj.Runner, *mockService, int) {
 
 // noChangeResend runs the second send after setupNoChangeResend and returns
 // the comments posted on the PR.
-func noChangeResend(t *testing.T, runner jj.Runner, mock *mockService, prNumber int, noChangeComment string) []string {
+func noChangeResend(t *testing.T, runner jj.Runner, mock *forgetest.Fake, prNumber int, noChangeComment string) []string {
 	t.Helper()
 
 	var buf bytes.Buffer
@@ -743,9 +866,9 @@ func noChangeResend(t *testing.T, runner jj.Runner, mock *mockService, prNumber
 		t.Fatalf("second send failed: %v\nOutput:\n%s", err, buf.String())
 	}
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	return mock.comments[prNumber]
+	mock.Lock()
+	defer mock.Unlock()
+	return mock.Comments[prNumber]
 }
 
 func TestIntegration_SendNoChangeCommentDefault(t *testing.T) {
@@ -789,7 +912,7 @@ func TestIntegration_SendNoChangeCommentNone(t *testing.T) {
 func TestIntegration_SendEmbedsPushedCommitMarker(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -803,10 +926,10 @@ func TestIntegration_SendEmbedsPushedCommitMarker(t *testing.T) {
 
 	commit := getCommitID(t, repoDir, changeID)
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	var pr *gh.PRInfo
-	for _, p := range mock.prs {
+	mock.Lock()
+	defer mock.Unlock()
+	var pr *forge.PRInfo
+	for _, p := range mock.PRs {
 		pr = p
 	}
 	if pr == nil {
@@ -817,13 +940,230 @@ func TestIntegration_SendEmbedsPushedCommitMarker(t *testing.T) {
 	}
 }
 
+// With trailers enabled, the sent commits carry Jip-Stack/Jip-PR trailers, the
+// PR body marker records the rewritten commit, and the trailers stay out of
+// the PR body.
+func TestIntegration_SendWritesTrailers(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+	tipID := getChangeID(t, repoDir, "@-")
+
+	var buf bytes.Buffer
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, trailers: true}
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+
+	data, err := runner.Log(tipID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := jj.ParseChanges(data)
+	if err != nil || len(changes) != 1 {
+		t.Fatalf("reading tip: %v (%d changes)", err, len(changes))
+	}
+	tip := changes[0]
+	if !strings.Contains(tip.Description, "\n\nJip-Stack: 2/2\nJip-PR: #") {
+		t.Errorf("tip description missing trailers:\n%s", tip.Description)
+	}
+
+	mock.Lock()
+	defer mock.Unlock()
+	for _, pr := range mock.PRs {
+		if strings.Contains(pr.Body, "Jip-PR") {
+			t.Errorf("PR #%d body contains trailers:\n%s", pr.Number, pr.Body)
+		}
+		if pr.Title == "feat: add B" && gh.ParsePushedCommit(pr.Body) != tip.CommitID {
+			t.Errorf("body marker = %q, want rewritten commit %q", gh.ParsePushedCommit(pr.Body), tip.CommitID)
+		}
+	}
+}
+
+func TestIntegration_SendTitlePrefix(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+
+	var buf bytes.Buffer
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, titlePrefix: true}
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	if got := mock.PRs[1].Title + " | " + mock.PRs[2].Title; got != "[1/2] feat: add A | [2/2] feat: add B" {
+		t.Errorf("titles = %s", got)
+	}
+
+	// The stack grows: the existing PRs are renumbered.
+	writeAndCommit(t, repoDir, "c.go", "package c", "feat: add C")
+	buf.Reset()
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("second send failed: %v\n%s", err, buf.String())
+	}
+	for n, want := range map[int]string{1: "[1/3] feat: add A", 2: "[2/3] feat: add B", 3: "[3/3] feat: add C"} {
+		if got := mock.PRs[n].Title; got != want {
+			t.Errorf("#%d title = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestIntegration_SendSkipsMarkedChange(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "debug.go", "package debug", "chore: local logging\n\nJip-Skip: true")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	output := buf.String()
+	t.Logf("Output:\n%s", output)
+
+	if len(mock.PRs) != 2 {
+		t.Fatalf("expected 2 PRs, got %d", len(mock.PRs))
+	}
+	for n, want := range map[int]string{1: "feat: add A", 2: "feat: add B"} {
+		if got := mock.PRs[n].Title; got != want {
+			t.Errorf("#%d title = %q, want %q", n, got, want)
+		}
+	}
+	// The skipped change sits in the stack between the two PRs.
+	if block := gh.ParseStackBlock(mock.PRs[2].Body); block == nil || len(block.PRs) != 2 {
+		t.Errorf("expected #2's stack navigation to list both PRs, got %+v", block)
+	}
+	if !strings.Contains(output, "folded into the PR of the change above") {
+		t.Errorf("expected the skipped change to be reported")
+	}
+}
+
+func TestIntegration_SendWIPAndReady(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+
+	// A ready PR is sent first; the stack then grows with --wip.
+	var buf bytes.Buffer
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, titlePrefix: true}
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+	buf.Reset()
+	wip := opts
+	wip.wip = true
+	if err := executeSend(runner, mock, wip, &buf); err != nil {
+		t.Fatalf("send --wip failed: %v\n%s", err, buf.String())
+	}
+	for n, want := range map[int]string{1: "WIP: [1/2] feat: add A", 2: "WIP: [2/2] feat: add B"} {
+		if got := mock.PRs[n].Title; got != want || !mock.PRs[n].IsDraft {
+			t.Errorf("#%d = %q (draft %v), want %q as a draft", n, got, mock.PRs[n].IsDraft, want)
+		}
+	}
+
+	// A plain send keeps the PRs work in progress.
+	buf.Reset()
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	if got := mock.PRs[2].Title; got != "WIP: [2/2] feat: add B" || !mock.PRs[2].IsDraft {
+		t.Errorf("#2 = %q (draft %v) after a plain send, want it kept WIP", got, mock.PRs[2].IsDraft)
+	}
+
+	buf.Reset()
+	ready := opts
+	ready.ready = true
+	if err := executeSend(runner, mock, ready, &buf); err != nil {
+		t.Fatalf("send --ready failed: %v\n%s", err, buf.String())
+	}
+	for n, want := range map[int]string{1: "[1/2] feat: add A", 2: "[2/2] feat: add B"} {
+		if got := mock.PRs[n].Title; got != want || mock.PRs[n].IsDraft {
+			t.Errorf("#%d = %q (draft %v), want %q ready for review", n, got, mock.PRs[n].IsDraft, want)
+		}
+	}
+}
+
+func TestIntegration_SendWIPFoldedIntoDraft(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	var buf bytes.Buffer
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, wip: true}
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send --wip failed: %v\n%s", err, buf.String())
+	}
+	// Gitea reports the prefix as the draft flag, not in the title.
+	mock.Lock()
+	mock.PRs[1].Title = "feat: add A"
+	mock.Unlock()
+
+	buf.Reset()
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("second send --wip failed: %v\n%s", err, buf.String())
+	}
+	if got := mock.PRs[1].Title; got != "feat: add A" {
+		t.Errorf("#1 title = %q, want it not updated again", got)
+	}
+}
+
+func TestIntegration_SendDraftTrailer(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A\n\nPR-Draft: true")
+	var buf bytes.Buffer
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	if !mock.PRs[1].IsDraft {
+		t.Errorf("#1 is not a draft")
+	}
+
+	// The trailer also undrafts the existing PR.
+	jjRun(t, repoDir, "describe", "-r", "@-", "-m", "feat: add A\n\nPR-Draft: false")
+	buf.Reset()
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("second send failed: %v\n%s", err, buf.String())
+	}
+	if mock.PRs[1].IsDraft {
+		t.Errorf("#1 is still a draft")
+	}
+}
+
 // With --diff-since-jip, the interdiff base is the commit recorded in the PR
 // body, not the current remote head. This simulates the remote head having
 // advanced past jip's recorded push (e.g. a direct push by someone else).
 func TestIntegration_SendDiffSinceJipUsesRecordedBase(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -838,11 +1178,11 @@ func TestIntegration_SendDiffSinceJipUsesRecordedBase(t *testing.T) {
 	commit1 := getCommitID(t, repoDir, changeID)
 
 	var prNumber int
-	mock.mu.Lock()
-	for n := range mock.prs {
+	mock.Lock()
+	for n := range mock.PRs {
 		prNumber = n
 	}
-	mock.mu.Unlock()
+	mock.Unlock()
 
 	// Edit to v2 and send again (default), moving the remote head to commit2.
 	editFile(t, repoDir, changeID, "f.go", "package x\n\nconst V = 2\n")
@@ -854,10 +1194,10 @@ func TestIntegration_SendDiffSinceJipUsesRecordedBase(t *testing.T) {
 
 	// Simulate desync: the PR's recorded jip push is still commit1 even though
 	// the remote head is now commit2.
-	mock.mu.Lock()
-	mock.prs[prNumber].Body = gh.WithPushedCommitMarker("feat: add f", commit1)
-	mock.comments[prNumber] = nil
-	mock.mu.Unlock()
+	mock.Lock()
+	mock.PRs[prNumber].Body = gh.WithPushedCommitMarker("feat: add f", commit1)
+	mock.Comments[prNumber] = nil
+	mock.Unlock()
 
 	// Edit to v3 and send with --diff-since-jip.
 	editFile(t, repoDir, changeID, "f.go", "package x\n\nconst V = 3\n")
@@ -866,9 +1206,9 @@ func TestIntegration_SendDiffSinceJipUsesRecordedBase(t *testing.T) {
 		t.Fatalf("send 3 failed: %v\n%s", err, buf.String())
 	}
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	comments := mock.comments[prNumber]
+	mock.Lock()
+	defer mock.Unlock()
+	comments := mock.Comments[prNumber]
 	if len(comments) != 1 {
 		t.Fatalf("expected exactly 1 comment from send 3, got %d", len(comments))
 	}
@@ -893,10 +1233,190 @@ func TestIntegration_SendDiffSinceJipUsesRecordedBase(t *testing.T) {
 // With --diff-since-jip, when the recorded jip commit is not present locally
 // (e.g. pushed from another machine and not fetched), jip documents that the
 // diff could not be generated instead of producing a misleading one.
+// Retrying a send that failed after posting the interdiff comment must not
+// post it again, even though the PR body still records the old commit.
+func TestIntegration_SendRetryDoesNotDuplicateInterdiff(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+	st, err := state.Load(state.Path(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, diffSinceJip: true, state: st}
+
+	writeAndCommit(t, repoDir, "f.go", "package x\n\nconst V = 1\n", "feat: add f")
+	changeID := getChangeID(t, repoDir, "@-")
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send 1 failed: %v\n%s", err, buf.String())
+	}
+
+	editFile(t, repoDir, changeID, "f.go", "package x\n\nconst V = 2\n")
+	mock.Lock()
+	mock.BodyUpdateErr = fmt.Errorf("simulated outage")
+	mock.Unlock()
+	buf.Reset()
+	if err := executeSend(runner, mock, opts, &buf); err == nil {
+		t.Fatalf("send 2 should fail on the body update\n%s", buf.String())
+	}
+
+	mock.Lock()
+	mock.BodyUpdateErr = nil
+	mock.Unlock()
+	buf.Reset()
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send 3 failed: %v\n%s", err, buf.String())
+	}
+
+	mock.Lock()
+	defer mock.Unlock()
+	for n, comments := range mock.Comments {
+		if len(comments) != 1 {
+			t.Errorf("PR #%d got %d comments, want 1:\n%s", n, len(comments), strings.Join(comments, "\n---\n"))
+		}
+	}
+}
+
+// With diffGist, a "changes since" diff too large for a comment is uploaded
+// as a gist the truncated comment links.
+func TestIntegration_SendDiffGist(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, diffGist: true}
+
+	writeAndCommit(t, repoDir, "f.go", "package x\n", "feat: add f")
+	changeID := getChangeID(t, repoDir, "@-")
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send 1 failed: %v\n%s", err, buf.String())
+	}
+
+	editFile(t, repoDir, changeID, "f.go", "package x\n\n"+strings.Repeat("// a line long enough to take room in the comment\n", 2000))
+	buf.Reset()
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send 2 failed: %v\n%s", err, buf.String())
+	}
+
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.Gists) != 1 {
+		t.Fatalf("expected 1 gist, got %d", len(mock.Gists))
+	}
+	var url string
+	for u, content := range mock.Gists {
+		url = u
+		if !strings.Contains(content, "+// a line long enough") {
+			t.Errorf("gist should hold the full diff, got %.200s", content)
+		}
+	}
+	comments := mock.Comments[1]
+	if len(comments) != 1 || !strings.Contains(comments[0], "[this gist]("+url+")") {
+		t.Errorf("the comment should link the gist:\n%s", strings.Join(comments, "\n---\n"))
+	}
+}
+
+// With an interdiff threshold, small diffs get the compare link only; with
+// noInterdiff, no comment at all.
+func TestIntegration_SendInterdiffThreshold(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, interdiffMin: interdiffThreshold{lines: 5}}
+
+	writeAndCommit(t, repoDir, "f.go", "package x\n\nconst V = 1\n", "feat: add f")
+	changeID := getChangeID(t, repoDir, "@-")
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send 1 failed: %v\n%s", err, buf.String())
+	}
+
+	editFile(t, repoDir, changeID, "f.go", "package x\n\nconst V = 2\n")
+	buf.Reset()
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send 2 failed: %v\n%s", err, buf.String())
+	}
+	mock.Lock()
+	comments := mock.Comments[1]
+	mock.Unlock()
+	if len(comments) != 1 || !strings.Contains(comments[0], "2 line(s) changed in 1 file(s).") || strings.Contains(comments[0], "const V") {
+		t.Fatalf("expected a compare-link-only comment:\n%s", strings.Join(comments, "\n---\n"))
+	}
+
+	editFile(t, repoDir, changeID, "f.go", "package x\n\nconst V = 3\n"+strings.Repeat("// more\n", 5))
+	buf.Reset()
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send 3 failed: %v\n%s", err, buf.String())
+	}
+	mock.Lock()
+	comments = mock.Comments[1]
+	mock.Unlock()
+	if len(comments) != 2 || !strings.Contains(comments[1], "+const V = 3") {
+		t.Fatalf("expected the diff beyond the threshold:\n%s", strings.Join(comments, "\n---\n"))
+	}
+
+	opts.noInterdiff = true
+	editFile(t, repoDir, changeID, "f.go", "package x\n\nconst V = 4\n")
+	buf.Reset()
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send 4 failed: %v\n%s", err, buf.String())
+	}
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.Comments[1]) != 2 {
+		t.Errorf("expected no comment with noInterdiff:\n%s", strings.Join(mock.Comments[1], "\n---\n"))
+	}
+}
+
+// With revisionHistory, every push appends to one rolling comment instead of
+// posting a new one.
+func TestIntegration_SendRevisionHistory(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, revisionHistory: true}
+
+	writeAndCommit(t, repoDir, "f.go", "package x\n\nconst V = 1\n", "feat: add f")
+	changeID := getChangeID(t, repoDir, "@-")
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send 1 failed: %v\n%s", err, buf.String())
+	}
+	for i, v := range []string{"2", "3"} {
+		editFile(t, repoDir, changeID, "f.go", "package x\n\nconst V = "+v+"\n")
+		buf.Reset()
+		if err := executeSend(runner, mock, opts, &buf); err != nil {
+			t.Fatalf("send %d failed: %v\n%s", i+2, err, buf.String())
+		}
+	}
+
+	mock.Lock()
+	defer mock.Unlock()
+	for n, comments := range mock.Comments {
+		if len(comments) != 1 {
+			t.Fatalf("PR #%d got %d comments, want one rolling comment", n, len(comments))
+		}
+		for _, want := range []string{gh.RevisionHistoryMarker, "### v2 — ", "### v3 — ", "const V = 3"} {
+			if !strings.Contains(comments[0], want) {
+				t.Errorf("history comment lacks %q:\n%s", want, comments[0])
+			}
+		}
+	}
+}
+
 func TestIntegration_SendDiffSinceJipCommitNotLocal(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -909,15 +1429,15 @@ func TestIntegration_SendDiffSinceJipCommitNotLocal(t *testing.T) {
 	}
 
 	var prNumber int
-	mock.mu.Lock()
-	for n := range mock.prs {
+	mock.Lock()
+	for n := range mock.PRs {
 		prNumber = n
 	}
 	// Record a commit that does not exist in the local repo.
 	missing := "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef"
-	mock.prs[prNumber].Body = gh.WithPushedCommitMarker("feat: add f", missing)
-	mock.comments[prNumber] = nil
-	mock.mu.Unlock()
+	mock.PRs[prNumber].Body = gh.WithPushedCommitMarker("feat: add f", missing)
+	mock.Comments[prNumber] = nil
+	mock.Unlock()
 
 	editFile(t, repoDir, changeID, "f.go", "package x\n\nconst V = 2\n")
 	buf.Reset()
@@ -925,9 +1445,9 @@ func TestIntegration_SendDiffSinceJipCommitNotLocal(t *testing.T) {
 		t.Fatalf("send 2 failed: %v\n%s", err, buf.String())
 	}
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	comments := mock.comments[prNumber]
+	mock.Lock()
+	defer mock.Unlock()
+	comments := mock.Comments[prNumber]
 	if len(comments) != 1 {
 		t.Fatalf("expected exactly 1 comment, got %d", len(comments))
 	}
@@ -943,7 +1463,7 @@ func TestIntegration_SendDiffSinceJipCommitNotLocal(t *testing.T) {
 func TestIntegration_SendCrossForkPrefixesHead(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -962,24 +1482,53 @@ func TestIntegration_SendCrossForkPrefixesHead(t *testing.T) {
 
 	t.Logf("Output:\n%s", buf.String())
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
+	mock.Lock()
+	defer mock.Unlock()
 
-	if len(mock.prs) != 1 {
-		t.Fatalf("expected 1 PR, got %d", len(mock.prs))
+	if len(mock.PRs) != 1 {
+		t.Fatalf("expected 1 PR, got %d", len(mock.PRs))
 	}
 
-	for _, pr := range mock.prs {
+	for _, pr := range mock.PRs {
 		if !strings.HasPrefix(pr.HeadRefName, "forkuser:") {
 			t.Errorf("expected head to start with 'forkuser:', got %q", pr.HeadRefName)
 		}
 	}
 }
 
+// A token that cannot see one side of a fork workflow is reported up front,
+// naming each inaccessible repository, before any jj command runs (the nil
+// runner would panic otherwise).
+func TestIntegration_SendPreflightsRepoAccess(t *testing.T) {
+	mock := forgetest.New("testowner", "testrepo")
+	mock.InaccessibleRepos = map[string]bool{
+		"testowner/testrepo": true,
+		"forkuser/testrepo":  true,
+	}
+
+	var buf bytes.Buffer
+	err := executeSend(nil, mock, sendOpts{
+		base:      "main",
+		remote:    "origin",
+		upstream:  "upstream",
+		pushOwner: "forkuser",
+		pushRepo:  "testrepo",
+		revsets:   []string{"@-"},
+	}, &buf)
+	if err == nil {
+		t.Fatal("expected an access error")
+	}
+	for _, want := range []string{"testowner/testrepo (upstream", "forkuser/testrepo (push repository"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
 func TestIntegration_SendNoPrefixWithoutUpstream(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -995,10 +1544,10 @@ func TestIntegration_SendNoPrefixWithoutUpstream(t *testing.T) {
 		t.Fatalf("send failed: %v\nOutput:\n%s", err, buf.String())
 	}
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
+	mock.Lock()
+	defer mock.Unlock()
 
-	for _, pr := range mock.prs {
+	for _, pr := range mock.PRs {
 		if strings.Contains(pr.HeadRefName, ":") {
 			t.Errorf("expected no owner prefix in head, got %q", pr.HeadRefName)
 		}
@@ -1008,7 +1557,7 @@ func TestIntegration_SendNoPrefixWithoutUpstream(t *testing.T) {
 func TestIntegration_SendPassesRemoteToGitPush(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	spy := &spyRunner{Runner: jj.NewRunner(repoDir)}
 
@@ -1032,31 +1581,61 @@ func TestIntegration_SendPassesRemoteToGitPush(t *testing.T) {
 func TestIntegration_SendFetchesRemote(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	spy := &spyRunner{Runner: jj.NewRunner(repoDir)}
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: fetch test")
+
+	var buf bytes.Buffer
+	err := executeSend(spy, mock, sendOpts{
+		base:    "main",
+		remote:  "origin",
+		revsets: []string{"@-"},
+	}, &buf)
+	if err != nil {
+		t.Fatalf("send failed: %v\nOutput:\n%s", err, buf.String())
+	}
+
+	if len(spy.fetchRemotes) != 1 || spy.fetchRemotes[0] != "origin" {
+		t.Errorf("expected fetch of [origin], got %v", spy.fetchRemotes)
+	}
+	if !slices.Contains(spy.fetchScope, "exact:main") || !slices.Contains(spy.fetchScope, "glob:jip/*") {
+		t.Errorf("expected the fetch limited to main and jip branches, got %v", spy.fetchScope)
+	}
+}
+
+func TestIntegration_SendNoFetch(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	spy := &spyRunner{Runner: jj.NewRunner(repoDir)}
 
-	writeAndCommit(t, repoDir, "a.go", "package a", "feat: fetch test")
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: no fetch test")
 
 	var buf bytes.Buffer
 	err := executeSend(spy, mock, sendOpts{
 		base:    "main",
 		remote:  "origin",
 		revsets: []string{"@-"},
+		noFetch: true,
 	}, &buf)
 	if err != nil {
 		t.Fatalf("send failed: %v\nOutput:\n%s", err, buf.String())
 	}
-
-	if len(spy.fetchRemotes) != 1 || spy.fetchRemotes[0] != "origin" {
-		t.Errorf("expected fetch of [origin], got %v", spy.fetchRemotes)
+	if len(spy.fetchRemotes) != 0 {
+		t.Errorf("expected no fetch, got %v", spy.fetchRemotes)
+	}
+	if len(mock.PRs) != 1 {
+		t.Errorf("expected 1 PR, got %d", len(mock.PRs))
 	}
 }
 
 func TestIntegration_SendFetchesUpstreamRemote(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, remoteDir := initTestRepoWithRemote(t)
 
 	// Add a second bare remote to act as upstream.
@@ -1098,7 +1677,7 @@ func TestIntegration_SendFetchesUpstreamRemote(t *testing.T) {
 func TestIntegration_SendSkipsFetchForUpstreamURL(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	spy := &spyRunner{Runner: jj.NewRunner(repoDir)}
 
@@ -1126,7 +1705,7 @@ func TestIntegration_SendSkipsFetchForUpstreamURL(t *testing.T) {
 func TestIntegration_SendNoStack(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -1148,16 +1727,16 @@ func TestIntegration_SendNoStack(t *testing.T) {
 	output := buf.String()
 	t.Logf("Output:\n%s", output)
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
+	mock.Lock()
+	defer mock.Unlock()
 
 	// Only 1 PR should be created (the tip), not 2.
-	if len(mock.prs) != 1 {
-		t.Fatalf("expected 1 PR with --no-stack, got %d", len(mock.prs))
+	if len(mock.PRs) != 1 {
+		t.Fatalf("expected 1 PR with --no-stack, got %d", len(mock.PRs))
 	}
 
 	// PR title should match the tip commit's description.
-	for _, pr := range mock.prs {
+	for _, pr := range mock.PRs {
 		if pr.Title != "feat: add feature B" {
 			t.Errorf("expected PR title 'feat: add feature B', got %q", pr.Title)
 		}
@@ -1175,7 +1754,7 @@ func TestIntegration_SendNoStack(t *testing.T) {
 func TestIntegration_SendRebase(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	spy := &spyRunner{Runner: jj.NewRunner(repoDir)}
 
@@ -1214,17 +1793,93 @@ func TestIntegration_SendRebase(t *testing.T) {
 	}
 
 	// PR should still be created successfully.
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 1 {
-		t.Errorf("expected 1 PR, got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 1 {
+		t.Errorf("expected 1 PR, got %d", len(mock.PRs))
+	}
+}
+
+// setupRebaseConflict creates a change editing the README and moves main
+// on with a conflicting edit. It returns the change's ID.
+func setupRebaseConflict(t *testing.T, repoDir string) string {
+	t.Helper()
+	writeAndCommit(t, repoDir, "README.md", "# mine", "docs: edit readme")
+	changeID := getChangeID(t, repoDir, "@-")
+	jjRun(t, repoDir, "new", "main")
+	writeAndCommit(t, repoDir, "README.md", "# theirs", "docs: other readme edit")
+	jjRun(t, repoDir, "bookmark", "set", "main", "-r", "@-")
+	jjRun(t, repoDir, "new", changeID)
+	return changeID
+}
+
+func TestIntegration_SendRebaseConflictSkipped(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	changeID := setupRebaseConflict(t, repoDir)
+
+	var buf bytes.Buffer
+	err := executeSend(jj.NewRunner(repoDir), mock, sendOpts{
+		base:    "main",
+		remote:  "origin",
+		revsets: []string{changeID},
+		rebase:  true,
+		noFetch: true,
+	}, &buf)
+	output := buf.String()
+	t.Logf("Output:\n%s", output)
+	if err == nil {
+		t.Fatal("expected an error for the skipped change")
+	}
+	for _, want := range []string{"The rebase left 1 change(s) with conflicts", "         README.md\n", "jj op restore ", "the rebase onto main left conflicts"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output", want)
+		}
+	}
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 0 {
+		t.Errorf("expected no PRs, got %d", len(mock.PRs))
+	}
+}
+
+func TestIntegration_SendRebaseConflictUndone(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	changeID := setupRebaseConflict(t, repoDir)
+	before := jjRun(t, repoDir, "log", "--no-graph", "-r", changeID, "-T", "commit_id")
+
+	var buf bytes.Buffer
+	err := executeSend(jj.NewRunner(repoDir), mock, sendOpts{
+		base:              "main",
+		remote:            "origin",
+		revsets:           []string{changeID},
+		rebase:            true,
+		noFetch:           true,
+		confirmUndoRebase: func() (bool, error) { return true, nil },
+	}, &buf)
+	t.Logf("Output:\n%s", buf.String())
+	if err == nil || !strings.Contains(err.Error(), "rebase undone") {
+		t.Fatalf("expected the rebase undone error, got %v", err)
+	}
+	if after := jjRun(t, repoDir, "log", "--no-graph", "-r", changeID, "-T", "commit_id"); after != before {
+		t.Errorf("change is at %s after the undo, want %s", after, before)
+	}
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 0 {
+		t.Errorf("expected no PRs, got %d", len(mock.PRs))
 	}
 }
 
 func TestIntegration_SendNoRebaseByDefault(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	spy := &spyRunner{Runner: jj.NewRunner(repoDir)}
 
@@ -1249,7 +1904,7 @@ func TestIntegration_SendNoRebaseByDefault(t *testing.T) {
 func TestIntegration_SendSkipsBehindBookmark(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, remoteDir := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -1310,17 +1965,17 @@ func TestIntegration_SendSkipsBehindBookmark(t *testing.T) {
 	}
 
 	// No new PRs should have been created (only the original one).
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 1 {
-		t.Errorf("expected still 1 PR, got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 1 {
+		t.Errorf("expected still 1 PR, got %d", len(mock.PRs))
 	}
 }
 
 func TestIntegration_SendSkipsDescendantsOfBehind(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, remoteDir := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -1390,7 +2045,7 @@ func TestIntegration_SendSkipsDescendantsOfBehind(t *testing.T) {
 func TestIntegration_SendSkipsConflictedChanges(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -1437,17 +2092,17 @@ func TestIntegration_SendSkipsConflictedChanges(t *testing.T) {
 	}
 
 	// A and B should still be sent (they have no conflicts).
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 2 {
-		t.Errorf("expected 2 PRs (non-conflicted changes), got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 2 {
+		t.Errorf("expected 2 PRs (non-conflicted changes), got %d", len(mock.PRs))
 	}
 }
 
 func TestIntegration_SendSkipsDescendantsOfConflicted(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -1503,17 +2158,17 @@ func TestIntegration_SendSkipsDescendantsOfConflicted(t *testing.T) {
 	}
 
 	// A and B should still be sent.
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 2 {
-		t.Errorf("expected 2 PRs (non-conflicted changes), got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 2 {
+		t.Errorf("expected 2 PRs (non-conflicted changes), got %d", len(mock.PRs))
 	}
 }
 
 func TestIntegration_SendAllowsPushAfterLocalRewrite(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -1590,7 +2245,7 @@ func TestIntegration_SendAllowsPushAfterLocalRewrite(t *testing.T) {
 func TestIntegration_SendAllowsPushAfterRebase(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -1679,7 +2334,7 @@ func TestIntegration_SendAllowsPushAfterRebase(t *testing.T) {
 func TestIntegration_SendSkipsEmptyDescription(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -1711,17 +2366,17 @@ func TestIntegration_SendSkipsEmptyDescription(t *testing.T) {
 	}
 
 	// The normal change should still be sent.
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 1 {
-		t.Errorf("expected 1 PR (normal change), got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 1 {
+		t.Errorf("expected 1 PR (normal change), got %d", len(mock.PRs))
 	}
 }
 
 func TestIntegration_SendSkipsDescendantsOfEmptyDescription(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -1757,17 +2412,17 @@ func TestIntegration_SendSkipsDescendantsOfEmptyDescription(t *testing.T) {
 	}
 
 	// The normal change should still be sent.
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 1 {
-		t.Errorf("expected 1 PR (normal change), got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 1 {
+		t.Errorf("expected 1 PR (normal change), got %d", len(mock.PRs))
 	}
 }
 
 func TestIntegration_SendSkipsPrivateCommits(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -1803,17 +2458,136 @@ func TestIntegration_SendSkipsPrivateCommits(t *testing.T) {
 	}
 
 	// The normal change should still be sent.
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 1 {
-		t.Errorf("expected 1 PR (normal change), got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 1 {
+		t.Errorf("expected 1 PR (normal change), got %d", len(mock.PRs))
+	}
+}
+
+func TestIntegration_SendSkipsImmutable(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	// An immutable change with a mutable one on top.
+	writeAndCommit(t, repoDir, "a.go", "package a", "frozen: vendored")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: on top of frozen")
+	jjRun(t, repoDir, "config", "set", "--repo", `revset-aliases."immutable_heads()"`, "present(trunk()) | description(glob:'frozen:*')")
+
+	var buf bytes.Buffer
+	err := executeSend(runner, mock, sendOpts{
+		base:    "main",
+		remote:  "origin",
+		revsets: []string{"@-"},
+	}, &buf)
+
+	output := buf.String()
+	t.Logf("Output:\n%s", output)
+
+	if err == nil {
+		t.Fatal("expected an error for the skipped changes")
+	}
+	if !strings.Contains(output, "immutable (already on trunk or in immutable_heads())") {
+		t.Errorf("expected the immutable skip reason in output")
+	}
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 0 {
+		t.Errorf("expected no PRs, got %d", len(mock.PRs))
+	}
+	data, err := runner.BookmarkList()
+	if err != nil {
+		t.Fatalf("listing bookmarks: %v", err)
+	}
+	if strings.Contains(string(data), "jip/") {
+		t.Errorf("expected no jip bookmarks, got:\n%s", data)
+	}
+}
+
+func TestIntegration_SendTitleLint(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: fine")
+	writeAndCommit(t, repoDir, "b.go", "package b", "Add b")
+
+	lint, err := newTitleLinter(titlePatternConventional, titleLintError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = executeSend(runner, mock, sendOpts{
+		base:      "main",
+		remote:    "origin",
+		revsets:   []string{"@-"},
+		titleLint: lint,
+	}, &buf)
+	t.Logf("Output:\n%s", buf.String())
+	if err == nil || !strings.Contains(err.Error(), "1 change title(s) do not match") {
+		t.Fatalf("err = %v, want the title check to fail", err)
+	}
+	if data, _ := runner.BookmarkList(); strings.Contains(string(data), "jip/") {
+		t.Errorf("expected nothing bookmarked, got:\n%s", data)
+	}
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 0 {
+		t.Errorf("expected no PRs, got %d", len(mock.PRs))
+	}
+}
+
+func TestIntegration_SendBookmarkTemplate(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	// A change sent with the default naming, then one on top with a template.
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: change A")
+	changeA := getChangeID(t, repoDir, "@-")
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("first send failed: %v\n%s", err, buf.String())
+	}
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: change B")
+	changeB := getChangeID(t, repoDir, "@-")
+
+	buf.Reset()
+	err := executeSend(runner, mock, sendOpts{
+		base:             "main",
+		remote:           "origin",
+		revsets:          []string{"@-"},
+		bookmarkTemplate: "{{.User}}/{{.Slug}}-{{.ShortID}}",
+	}, &buf)
+	t.Logf("Output:\n%s", buf.String())
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	// The existing jip/ bookmark is kept; the new change follows the template.
+	if got := findBookmarkForChange(t, runner, changeA); !strings.HasPrefix(got, "jip/") {
+		t.Errorf("change A bookmark = %q, want the original jip/ one", got)
+	}
+	if got, want := findBookmarkForChange(t, runner, changeB), "testuser/change-b-"+changeB[:8]; got != want {
+		t.Errorf("change B bookmark = %q, want %q", got, want)
+	}
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 2 {
+		t.Errorf("expected 2 PRs, got %d", len(mock.PRs))
 	}
 }
 
 func TestIntegration_SendSkipsDescendantsOfPrivate(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -1850,17 +2624,17 @@ func TestIntegration_SendSkipsDescendantsOfPrivate(t *testing.T) {
 	}
 
 	// Normal change should still be sent.
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 1 {
-		t.Errorf("expected 1 PR (normal change), got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 1 {
+		t.Errorf("expected 1 PR (normal change), got %d", len(mock.PRs))
 	}
 }
 
 func TestIntegration_SendNoBookmarksForSkippedChanges(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -1904,7 +2678,7 @@ func TestIntegration_SendNoBookmarksForSkippedChanges(t *testing.T) {
 func TestIntegration_SendPushFailureDoesNotAbort(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	realRunner := jj.NewRunner(repoDir)
 
@@ -1937,17 +2711,127 @@ func TestIntegration_SendPushFailureDoesNotAbort(t *testing.T) {
 	}
 
 	// Change A should still get a PR despite change B's push failure.
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 1 {
-		t.Errorf("expected 1 PR for change A, got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 1 {
+		t.Errorf("expected 1 PR for change A, got %d", len(mock.PRs))
+	}
+}
+
+func TestIntegration_SendResume(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+	st, err := state.Load(state.Path(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+	writeAndCommit(t, repoDir, "c.go", "package c", "feat: add C")
+
+	// The send fails after opening the first PR of the stack.
+	mock.CreateLimit = 1
+	var buf bytes.Buffer
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"main..@-"}, state: st}
+	if err := executeSend(runner, mock, opts, &buf); err == nil {
+		t.Fatalf("send should fail at the second PR:\n%s", buf.String())
+	}
+	if st.Progress == nil || len(st.Progress.Bookmarks) != 3 {
+		t.Fatalf("progress should record the three pushed bookmarks: %+v", st.Progress)
+	}
+
+	mock.CreateLimit = 0
+	mock.LookupCalls = 0
+	buf.Reset()
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", state: st, resume: true}, &buf); err != nil {
+		t.Fatalf("resume failed: %v\n%s", err, buf.String())
+	}
+	output := buf.String()
+	t.Logf("Output:\n%s", output)
+
+	if !strings.Contains(output, "Skipping 3 bookmark(s) already pushed.") || strings.Contains(output, "Pushing") {
+		t.Error("resume should not push the bookmarks again")
+	}
+	if mock.LookupCalls != 0 {
+		t.Errorf("resume looked up PRs %d time(s), want none", mock.LookupCalls)
+	}
+	if len(mock.PRs) != 3 {
+		t.Fatalf("expected 3 PRs, got %d", len(mock.PRs))
+	}
+	for n := 1; n <= 3; n++ {
+		if !strings.Contains(mock.PRs[n].Body, "#3") {
+			t.Errorf("PR #%d body lacks the full stack:\n%s", n, mock.PRs[n].Body)
+		}
+	}
+	if st.Progress != nil {
+		t.Error("a finished send should clear the progress")
+	}
+	if st.LastSend == nil || len(st.LastSend.CreatedPRs) != 3 {
+		t.Errorf("undo record should hold all three new PRs: %+v", st.LastSend)
+	}
+
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", state: st, resume: true}, &buf); err == nil {
+		t.Error("resume without an unfinished send should fail")
+	}
+}
+
+func TestIntegration_SendPushFailureSkipsDescendants(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	realRunner := jj.NewRunner(repoDir)
+
+	// Stack A → B → C; B cannot be pushed.
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: change A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: change B")
+	changeB := getChangeID(t, repoDir, "@-")
+	writeAndCommit(t, repoDir, "c.go", "package c", "feat: change C")
+
+	runner := &failingPushRunner{
+		Runner:        realRunner,
+		failChangeIDs: map[string]bool{changeB[:8]: true},
+	}
+
+	var buf bytes.Buffer
+	err := executeSend(runner, mock, sendOpts{
+		base:    "main",
+		remote:  "origin",
+		revsets: []string{"main..@-"},
+	}, &buf)
+
+	output := buf.String()
+	t.Logf("Output:\n%s", output)
+
+	if err == nil {
+		t.Fatal("expected error due to skipped changes, got nil")
+	}
+	for _, want := range []string{"  pushed\n", "  failed: simulated push failure", "  skipped (ancestor not pushed)\n", "Pushed 1 of 3 bookmark(s)"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output", want)
+		}
+	}
+
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 1 {
+		t.Fatalf("expected 1 PR for change A, got %d", len(mock.PRs))
+	}
+	for _, pr := range mock.PRs {
+		if pr.Title != "feat: change A" {
+			t.Errorf("PR created for %q, want only change A", pr.Title)
+		}
 	}
 }
 
 func TestIntegration_SendAcceptsAlternateBaseBranch(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -1968,13 +2852,13 @@ func TestIntegration_SendAcceptsAlternateBaseBranch(t *testing.T) {
 		t.Fatalf("send failed: %v\nOutput:\n%s", err, buf.String())
 	}
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
+	mock.Lock()
+	defer mock.Unlock()
 
-	if len(mock.prs) != 1 {
-		t.Fatalf("expected 1 PR, got %d", len(mock.prs))
+	if len(mock.PRs) != 1 {
+		t.Fatalf("expected 1 PR, got %d", len(mock.PRs))
 	}
-	for _, pr := range mock.prs {
+	for _, pr := range mock.PRs {
 		if pr.BaseRefName != "develop" {
 			t.Errorf("PR base is %q, expected \"develop\"", pr.BaseRefName)
 		}
@@ -1984,7 +2868,7 @@ func TestIntegration_SendAcceptsAlternateBaseBranch(t *testing.T) {
 func TestIntegration_SendResolvesTrunkRevset(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
+	mock := forgetest.New("testowner", "testrepo")
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -2000,13 +2884,13 @@ func TestIntegration_SendResolvesTrunkRevset(t *testing.T) {
 		t.Fatalf("send failed: %v\nOutput:\n%s", err, buf.String())
 	}
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
+	mock.Lock()
+	defer mock.Unlock()
 
-	if len(mock.prs) != 1 {
-		t.Fatalf("expected 1 PR, got %d", len(mock.prs))
+	if len(mock.PRs) != 1 {
+		t.Fatalf("expected 1 PR, got %d", len(mock.PRs))
 	}
-	for _, pr := range mock.prs {
+	for _, pr := range mock.PRs {
 		if pr.BaseRefName != "main" {
 			t.Errorf("PR base is %q, expected \"main\" (resolved from trunk())", pr.BaseRefName)
 		}
@@ -2055,6 +2939,16 @@ func writeFile(t *testing.T, dir, filename, content string) {
 	}
 }
 
+// defaultNamer returns the namer of the default bookmark names.
+func defaultNamer(t *testing.T) *jj.BookmarkNamer {
+	t.Helper()
+	namer, err := jj.NewBookmarkNamer("", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return namer
+}
+
 // failingPushRunner wraps a real Runner and simulates push failures for
 // bookmarks belonging to specific change IDs (matched by checking whether the
 // bookmark name contains the change ID prefix).
@@ -2078,6 +2972,7 @@ func (u *failingPushRunner) GitPush(bookmarks []string, remote string) error {
 type spyRunner struct {
 	jj.Runner
 	fetchRemotes []string
+	fetchScope   []string
 	pushRemote   string
 	rebaseCalls  []rebaseCall
 }
@@ -2087,9 +2982,10 @@ type rebaseCall struct {
 	destination string
 }
 
-func (s *spyRunner) GitFetch(remote string) error {
+func (s *spyRunner) GitFetch(remote string, branches ...string) error {
 	s.fetchRemotes = append(s.fetchRemotes, remote)
-	return s.Runner.GitFetch(remote)
+	s.fetchScope = branches
+	return s.Runner.GitFetch(remote, branches...)
 }
 
 func (s *spyRunner) GitPush(bookmarks []string, remote string) error {
@@ -2233,7 +3129,7 @@ func editFile(t *testing.T, dir, changeID, filename, content string) {
 	jjRun(t, dir, "new", changeID)
 }
 
-func assertPRRefsInBody(t *testing.T, pr *gh.PRInfo, shouldRef, shouldNotRef []*gh.PRInfo) {
+func assertPRRefsInBody(t *testing.T, pr *forge.PRInfo, shouldRef, shouldNotRef []*forge.PRInfo) {
 	t.Helper()
 	for _, ref := range shouldRef {
 		s := fmt.Sprintf("#%d", ref.Number)
@@ -2261,12 +3157,12 @@ func writeAndCommit(t *testing.T, dir, filename, content, message string) {
 
 // singleStackPRNumbers returns the PR numbers of the single stack in the
 // mock, bottom to top. Fails the test unless exactly one stack exists.
-func singleStackPRNumbers(t *testing.T, m *mockService) []int {
+func singleStackPRNumbers(t *testing.T, m *forgetest.Fake) []int {
 	t.Helper()
-	if len(m.stacks) != 1 {
-		t.Fatalf("expected exactly 1 GitHub stack, got %d", len(m.stacks))
+	if len(m.Stacks) != 1 {
+		t.Fatalf("expected exactly 1 GitHub stack, got %d", len(m.Stacks))
 	}
-	for _, st := range m.stacks {
+	for _, st := range m.Stacks {
 		var nums []int
 		for _, p := range st.PullRequests {
 			nums = append(nums, p.Number)
@@ -2279,8 +3175,8 @@ func singleStackPRNumbers(t *testing.T, m *mockService) []int {
 func TestIntegration_SendNativeStackCreates(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
-	mock.stacksEnabled = true
+	mock := forgetest.New("testowner", "testrepo")
+	mock.NativeStacks = true
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -2300,13 +3196,13 @@ func TestIntegration_SendNativeStackCreates(t *testing.T) {
 	output := buf.String()
 	t.Logf("Output:\n%s", output)
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
+	mock.Lock()
+	defer mock.Unlock()
 
-	if len(mock.prs) != 2 {
-		t.Fatalf("expected 2 PRs, got %d", len(mock.prs))
+	if len(mock.PRs) != 2 {
+		t.Fatalf("expected 2 PRs, got %d", len(mock.PRs))
 	}
-	bottom, top := mock.prs[1], mock.prs[2]
+	bottom, top := mock.PRs[1], mock.PRs[2]
 
 	// Bases must be chained: bottom targets main, top targets bottom's branch.
 	if bottom.BaseRefName != "main" {
@@ -2323,7 +3219,7 @@ func TestIntegration_SendNativeStackCreates(t *testing.T) {
 
 	// Bodies carry the commit message and the pushed-commit marker, but no
 	// jip stack navigation (GitHub's UI shows the stack).
-	for _, pr := range mock.prs {
+	for _, pr := range mock.PRs {
 		if strings.Contains(pr.Body, "stacked PR") || strings.Contains(pr.Body, "PRs:") {
 			t.Errorf("PR #%d body should not contain jip stack navigation:\n%s", pr.Number, pr.Body)
 		}
@@ -2343,8 +3239,8 @@ func TestIntegration_SendNativeStackCreates(t *testing.T) {
 func TestIntegration_SendNativeStackSplitsAtPrivateMerge(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
-	mock.stacksEnabled = true
+	mock := forgetest.New("testowner", "testrepo")
+	mock.NativeStacks = true
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 	jjRun(t, repoDir, "config", "set", "--repo", "git.private-commits", "description(glob:'private:*')")
@@ -2376,17 +3272,17 @@ func TestIntegration_SendNativeStackSplitsAtPrivateMerge(t *testing.T) {
 		t.Fatalf("send --stack=gh-native failed: %v\nOutput:\n%s", err, buf.String())
 	}
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 5 {
-		t.Fatalf("expected 5 PRs, got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 5 {
+		t.Fatalf("expected 5 PRs, got %d", len(mock.PRs))
 	}
-	if len(mock.stacks) != 1 {
-		t.Fatalf("expected one four-PR native stack and one standalone PR, got %d native stacks", len(mock.stacks))
+	if len(mock.Stacks) != 1 {
+		t.Fatalf("expected one four-PR native stack and one standalone PR, got %d native stacks", len(mock.Stacks))
 	}
 
-	var stack *gh.Stack
-	for _, st := range mock.stacks {
+	var stack *forge.Stack
+	for _, st := range mock.Stacks {
 		stack = st
 	}
 	if len(stack.PullRequests) != 4 {
@@ -2394,14 +3290,14 @@ func TestIntegration_SendNativeStackSplitsAtPrivateMerge(t *testing.T) {
 	}
 	stackTitles := make(map[string]bool)
 	for _, p := range stack.PullRequests {
-		stackTitles[mock.prs[p.Number].Title] = true
+		stackTitles[mock.PRs[p.Number].Title] = true
 	}
 	for _, title := range []string{"feat: A", "feat: B", "feat: C", "feat: D"} {
 		if !stackTitles[title] {
 			t.Errorf("native stack missing %q", title)
 		}
 	}
-	for _, pr := range mock.prs {
+	for _, pr := range mock.PRs {
 		if pr.Title == "feat: X" && pr.BaseRefName != "main" {
 			t.Errorf("standalone PR base = %q, want main", pr.BaseRefName)
 		}
@@ -2411,8 +3307,8 @@ func TestIntegration_SendNativeStackSplitsAtPrivateMerge(t *testing.T) {
 func TestIntegration_SendNativeStackAppend(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
-	mock.stacksEnabled = true
+	mock := forgetest.New("testowner", "testrepo")
+	mock.NativeStacks = true
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -2433,17 +3329,17 @@ func TestIntegration_SendNativeStackAppend(t *testing.T) {
 	}
 	t.Logf("Output:\n%s", buf.String())
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
+	mock.Lock()
+	defer mock.Unlock()
 
-	if mock.addToStackCalls != 1 {
-		t.Errorf("addToStackCalls = %d, want 1", mock.addToStackCalls)
+	if mock.AddToStackCalls != 1 {
+		t.Errorf("addToStackCalls = %d, want 1", mock.AddToStackCalls)
 	}
-	if mock.unstackCalls != 0 {
-		t.Errorf("unstackCalls = %d, want 0 (append must not recreate the stack)", mock.unstackCalls)
+	if mock.UnstackCalls != 0 {
+		t.Errorf("unstackCalls = %d, want 0 (append must not recreate the stack)", mock.UnstackCalls)
 	}
-	if mock.createStackCalls != 1 {
-		t.Errorf("createStackCalls = %d, want 1", mock.createStackCalls)
+	if mock.CreateStackCalls != 1 {
+		t.Errorf("createStackCalls = %d, want 1", mock.CreateStackCalls)
 	}
 	if nums := singleStackPRNumbers(t, mock); !slices.Equal(nums, []int{1, 2, 3}) {
 		t.Errorf("stack PRs = %v, want [1 2 3]", nums)
@@ -2456,8 +3352,8 @@ func TestIntegration_SendNativeStackAppend(t *testing.T) {
 func TestIntegration_SendNativeStackUpToDate(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
-	mock.stacksEnabled = true
+	mock := forgetest.New("testowner", "testrepo")
+	mock.NativeStacks = true
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -2475,11 +3371,11 @@ func TestIntegration_SendNativeStackUpToDate(t *testing.T) {
 		t.Fatalf("second send failed: %v\nOutput:\n%s", err, buf.String())
 	}
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if mock.unstackCalls != 0 || mock.addToStackCalls != 0 || mock.createStackCalls != 1 {
+	mock.Lock()
+	defer mock.Unlock()
+	if mock.UnstackCalls != 0 || mock.AddToStackCalls != 0 || mock.CreateStackCalls != 1 {
 		t.Errorf("unchanged resend must not touch the stack: unstack=%d add=%d create=%d",
-			mock.unstackCalls, mock.addToStackCalls, mock.createStackCalls)
+			mock.UnstackCalls, mock.AddToStackCalls, mock.CreateStackCalls)
 	}
 	if !strings.Contains(buf.String(), "GitHub stack #1: up to date") {
 		t.Errorf("expected up-to-date message in output, got:\n%s", buf.String())
@@ -2489,8 +3385,8 @@ func TestIntegration_SendNativeStackUpToDate(t *testing.T) {
 func TestIntegration_SendNativeStackRestructure(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
-	mock.stacksEnabled = true
+	mock := forgetest.New("testowner", "testrepo")
+	mock.NativeStacks = true
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -2515,21 +3411,21 @@ func TestIntegration_SendNativeStackRestructure(t *testing.T) {
 	}
 	t.Logf("Output:\n%s", buf.String())
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
+	mock.Lock()
+	defer mock.Unlock()
 
-	if mock.unstackCalls != 1 {
-		t.Errorf("unstackCalls = %d, want 1", mock.unstackCalls)
+	if mock.UnstackCalls != 1 {
+		t.Errorf("unstackCalls = %d, want 1", mock.UnstackCalls)
 	}
-	if mock.createStackCalls != 2 {
-		t.Errorf("createStackCalls = %d, want 2", mock.createStackCalls)
+	if mock.CreateStackCalls != 2 {
+		t.Errorf("createStackCalls = %d, want 2", mock.CreateStackCalls)
 	}
 	if nums := singleStackPRNumbers(t, mock); !slices.Equal(nums, []int{1, 3}) {
 		t.Errorf("stack PRs = %v, want [1 3]", nums)
 	}
 	// The top PR must have been retargeted onto the bottom PR's branch.
-	if mock.prs[3].BaseRefName != mock.prs[1].HeadRefName {
-		t.Errorf("top PR base = %q, want %q", mock.prs[3].BaseRefName, mock.prs[1].HeadRefName)
+	if mock.PRs[3].BaseRefName != mock.PRs[1].HeadRefName {
+		t.Errorf("top PR base = %q, want %q", mock.PRs[3].BaseRefName, mock.PRs[1].HeadRefName)
 	}
 	if !strings.Contains(buf.String(), "Dissolved GitHub stack #1") {
 		t.Errorf("expected dissolve message in output, got:\n%s", buf.String())
@@ -2539,8 +3435,8 @@ func TestIntegration_SendNativeStackRestructure(t *testing.T) {
 func TestIntegration_SendNativeStackPartialSend(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
-	mock.stacksEnabled = true
+	mock := forgetest.New("testowner", "testrepo")
+	mock.NativeStacks = true
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -2564,29 +3460,29 @@ func TestIntegration_SendNativeStackPartialSend(t *testing.T) {
 	}
 	t.Logf("Output:\n%s", buf.String())
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
+	mock.Lock()
+	defer mock.Unlock()
 
-	if mock.unstackCalls != 0 {
-		t.Errorf("unstackCalls = %d, want 0", mock.unstackCalls)
+	if mock.UnstackCalls != 0 {
+		t.Errorf("unstackCalls = %d, want 0", mock.UnstackCalls)
 	}
-	if mock.createStackCalls != 1 {
-		t.Errorf("createStackCalls = %d, want 1", mock.createStackCalls)
+	if mock.CreateStackCalls != 1 {
+		t.Errorf("createStackCalls = %d, want 1", mock.CreateStackCalls)
 	}
 	if nums := singleStackPRNumbers(t, mock); !slices.Equal(nums, []int{1, 2, 3}) {
 		t.Errorf("stack PRs = %v, want [1 2 3]", nums)
 	}
 	// The untouched top PR must keep its chained base.
-	if mock.prs[3].BaseRefName != mock.prs[2].HeadRefName {
-		t.Errorf("top PR base = %q, want %q", mock.prs[3].BaseRefName, mock.prs[2].HeadRefName)
+	if mock.PRs[3].BaseRefName != mock.PRs[2].HeadRefName {
+		t.Errorf("top PR base = %q, want %q", mock.PRs[3].BaseRefName, mock.PRs[2].HeadRefName)
 	}
 }
 
 func TestIntegration_SendNativeStackInsertBelow(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
-	mock.stacksEnabled = true
+	mock := forgetest.New("testowner", "testrepo")
+	mock.NativeStacks = true
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -2614,25 +3510,25 @@ func TestIntegration_SendNativeStackInsertBelow(t *testing.T) {
 	}
 	t.Logf("Output:\n%s", buf.String())
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
+	mock.Lock()
+	defer mock.Unlock()
 
-	if mock.unstackCalls != 1 {
-		t.Errorf("unstackCalls = %d, want 1", mock.unstackCalls)
+	if mock.UnstackCalls != 1 {
+		t.Errorf("unstackCalls = %d, want 1", mock.UnstackCalls)
 	}
-	if mock.createStackCalls != 2 {
-		t.Errorf("createStackCalls = %d, want 2", mock.createStackCalls)
+	if mock.CreateStackCalls != 2 {
+		t.Errorf("createStackCalls = %d, want 2", mock.CreateStackCalls)
 	}
 	// Stack order is bottom to top: old bottom, inserted change, old top.
 	if nums := singleStackPRNumbers(t, mock); !slices.Equal(nums, []int{1, 3, 2}) {
 		t.Errorf("stack PRs = %v, want [1 3 2]", nums)
 	}
 	// Bases must be re-chained through the inserted PR.
-	if mock.prs[3].BaseRefName != mock.prs[1].HeadRefName {
-		t.Errorf("inserted PR base = %q, want %q", mock.prs[3].BaseRefName, mock.prs[1].HeadRefName)
+	if mock.PRs[3].BaseRefName != mock.PRs[1].HeadRefName {
+		t.Errorf("inserted PR base = %q, want %q", mock.PRs[3].BaseRefName, mock.PRs[1].HeadRefName)
 	}
-	if mock.prs[2].BaseRefName != mock.prs[3].HeadRefName {
-		t.Errorf("top PR base = %q, want %q", mock.prs[2].BaseRefName, mock.prs[3].HeadRefName)
+	if mock.PRs[2].BaseRefName != mock.PRs[3].HeadRefName {
+		t.Errorf("top PR base = %q, want %q", mock.PRs[2].BaseRefName, mock.PRs[3].HeadRefName)
 	}
 	if !strings.Contains(buf.String(), "Dissolved GitHub stack #1") {
 		t.Errorf("expected dissolve message in output, got:\n%s", buf.String())
@@ -2642,8 +3538,8 @@ func TestIntegration_SendNativeStackInsertBelow(t *testing.T) {
 func TestIntegration_SendDefaultWarnsOnChainedBase(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
-	mock.stacksEnabled = true
+	mock := forgetest.New("testowner", "testrepo")
+	mock.NativeStacks = true
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -2666,20 +3562,20 @@ func TestIntegration_SendDefaultWarnsOnChainedBase(t *testing.T) {
 	}
 	t.Logf("Output:\n%s", buf.String())
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
+	mock.Lock()
+	defer mock.Unlock()
 	if !strings.Contains(buf.String(), "warning: PR #2 targets") {
 		t.Errorf("expected chained-base warning in output, got:\n%s", buf.String())
 	}
-	if mock.prs[2].BaseRefName != mock.prs[1].HeadRefName {
-		t.Errorf("default mode must not retarget: PR #2 base = %q", mock.prs[2].BaseRefName)
+	if mock.PRs[2].BaseRefName != mock.PRs[1].HeadRefName {
+		t.Errorf("default mode must not retarget: PR #2 base = %q", mock.PRs[2].BaseRefName)
 	}
 }
 
 func TestIntegration_SendNativeStackNotEnabled(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService() // stacksEnabled defaults to false
+	mock := forgetest.New("testowner", "testrepo") // stacksEnabled defaults to false
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -2699,18 +3595,18 @@ func TestIntegration_SendNativeStackNotEnabled(t *testing.T) {
 		t.Errorf("error should explain the preview gate, got: %v", err)
 	}
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 0 {
-		t.Errorf("no PRs must be created when the check fails, got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 0 {
+		t.Errorf("no PRs must be created when the check fails, got %d", len(mock.PRs))
 	}
 }
 
 func TestIntegration_SendNativeStackSinglePR(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
-	mock.stacksEnabled = true
+	mock := forgetest.New("testowner", "testrepo")
+	mock.NativeStacks = true
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -2727,22 +3623,22 @@ func TestIntegration_SendNativeStackSinglePR(t *testing.T) {
 		t.Fatalf("send failed: %v\nOutput:\n%s", err, buf.String())
 	}
 
-	mock.mu.Lock()
-	defer mock.mu.Unlock()
-	if len(mock.prs) != 1 {
-		t.Fatalf("expected 1 PR, got %d", len(mock.prs))
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 1 {
+		t.Fatalf("expected 1 PR, got %d", len(mock.PRs))
 	}
-	if mock.createStackCalls != 0 || len(mock.stacks) != 0 {
+	if mock.CreateStackCalls != 0 || len(mock.Stacks) != 0 {
 		t.Errorf("a single PR must not create a stack (calls=%d, stacks=%d)",
-			mock.createStackCalls, len(mock.stacks))
+			mock.CreateStackCalls, len(mock.Stacks))
 	}
 }
 
 func TestIntegration_SendNativeStackNonLinear(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
-	mock.stacksEnabled = true
+	mock := forgetest.New("testowner", "testrepo")
+	mock.NativeStacks = true
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -2773,8 +3669,8 @@ func TestIntegration_SendNativeStackNonLinear(t *testing.T) {
 func TestIntegration_SendNativeStackCrossFork(t *testing.T) {
 	checkJJ(t)
 
-	mock := newMockService()
-	mock.stacksEnabled = true
+	mock := forgetest.New("testowner", "testrepo")
+	mock.NativeStacks = true
 	repoDir, _ := initTestRepoWithRemote(t)
 	runner := jj.NewRunner(repoDir)
 
@@ -2793,3 +3689,141 @@ func TestIntegration_SendNativeStackCrossFork(t *testing.T) {
 		t.Errorf("error should mention forks, got: %v", err)
 	}
 }
+
+func TestIntegration_SendSignatureCheck(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	mock.SignedCommitsRequired = true
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: unsigned change")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: another unsigned change")
+
+	// In error mode the unsigned changes stop the send before the push. The
+	// base branch they merge into is looked up once.
+	var buf bytes.Buffer
+	err := executeSend(runner, mock, sendOpts{
+		base:           "main",
+		remote:         "origin",
+		revsets:        []string{"main..@-"},
+		signatureCheck: signatureCheckError,
+	}, &buf)
+	t.Logf("Output:\n%s", buf.String())
+	if err == nil || !strings.Contains(err.Error(), "2 change(s) are not signed") {
+		t.Fatalf("expected a '2 change(s) are not signed' error, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "feat: unsigned change") || !strings.Contains(buf.String(), "feat: another unsigned change") {
+		t.Errorf("expected the unsigned changes listed")
+	}
+	if want := []string{"main"}; !slices.Equal(mock.SignatureChecks, want) {
+		t.Errorf("signature requirement looked up for %q, want %q", mock.SignatureChecks, want)
+	}
+	if len(mock.PRs) != 0 {
+		t.Errorf("expected no PRs, got %d", len(mock.PRs))
+	}
+
+	// With git.sign-on-push, jj signs the commits while pushing them.
+	jjRun(t, repoDir, "config", "set", "--repo", "git.sign-on-push", "true")
+	buf.Reset()
+	if err := checkSignatures(runner, mock, nil, nil, signatureCheckError, &buf); err != nil {
+		t.Errorf("expected no error without states, got %v", err)
+	}
+	dags, err := jj.ResolveStacks(runner, []string{"@-"}, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := []changeState{{change: dags[0].Changes[0], bookmark: jj.ChangeBookmark{Bookmark: "jip/x/y"}}}
+	if err := checkSignatures(runner, mock, states, map[string]string{states[0].change.ChangeID: "main"}, signatureCheckError, &buf); err != nil {
+		t.Errorf("expected no error with git.sign-on-push, got %v", err)
+	}
+}
+
+func TestIntegration_SendNoStackCommitChecklist(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add feature A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add feature B")
+
+	var buf bytes.Buffer
+	err := executeSend(runner, mock, sendOpts{
+		base:      "main",
+		remote:    "origin",
+		revsets:   []string{"@-"},
+		stackMode: stackModeNone,
+		checklist: true,
+	}, &buf)
+	if err != nil {
+		t.Fatalf("send --stack=none --commit-checklist failed: %v\nOutput:\n%s", err, buf.String())
+	}
+
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 1 {
+		t.Fatalf("expected 1 PR, got %d", len(mock.PRs))
+	}
+	for _, pr := range mock.PRs {
+		// It lists both commits, oldest first, for commit-by-commit review.
+		a := strings.Index(pr.Body, "- [ ] [")
+		if a == -1 || !strings.Contains(pr.Body[a:], ") feat: add feature A\n- [ ] [") ||
+			!strings.Contains(pr.Body, ") feat: add feature B\n") {
+			t.Errorf("PR body should list both commits as a checklist:\n%s", pr.Body)
+		}
+	}
+}
+
+func TestIntegration_SendSplitByFile(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	// One change touching a proto file, a migration and code.
+	if err := os.MkdirAll(filepath.Join(repoDir, "migrations"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, repoDir, "user.proto", "message User {}")
+	writeFile(t, repoDir, "migrations/001.sql", "CREATE TABLE users;")
+	writeAndCommit(t, repoDir, "users.go", "package users", "feat: add users\n\nAll of it.")
+
+	groups, err := parseFileGroups([]string{"*.proto", "migrations/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = executeSend(runner, mock, sendOpts{
+		base:        "main",
+		remote:      "origin",
+		revsets:     []string{"@-"},
+		splitGroups: groups,
+	}, &buf)
+	output := buf.String()
+	t.Logf("Output:\n%s", output)
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if !strings.Contains(output, "into 3 changes") {
+		t.Errorf("expected the split plan in output")
+	}
+
+	// The stack is proto, migration, code, bottom first, one PR each.
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 3 {
+		t.Fatalf("expected 3 PRs, got %d", len(mock.PRs))
+	}
+	for i, want := range []string{"feat: add users (part 1/3)", "feat: add users (part 2/3)", "feat: add users (part 3/3)"} {
+		if got := mock.PRs[i+1].Title; got != want {
+			t.Errorf("PR #%d title = %q, want %q", i+1, got, want)
+		}
+	}
+	files := strings.TrimSpace(jjRun(t, repoDir, "diff", "--name-only", "-r", "@---"))
+	if files != "user.proto" {
+		t.Errorf("bottom change files = %q, want user.proto", files)
+	}
+}
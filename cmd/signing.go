@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// Values of --signature-check.
+const (
+	signatureCheckOff   = "off"
+	signatureCheckWarn  = "warn"
+	signatureCheckError = "error"
+)
+
+func checkSignatureMode(mode string) error {
+	switch mode {
+	case signatureCheckOff, signatureCheckWarn, signatureCheckError:
+		return nil
+	}
+	return fmt.Errorf("invalid --signature-check value %q (valid: %s, %s, %s)", mode, signatureCheckOff, signatureCheckWarn, signatureCheckError)
+}
+
+// checkSignatures reports the changes of states that are not signed although
+// the forge requires signed commits on the base branch their stack merges
+// into, and would reject them. baseBranches maps each change ID to that
+// branch; each distinct branch is looked up once. It returns an error,
+// before anything is pushed, in error mode. jj's git.sign-on-push signs
+// commits while pushing them, so nothing is checked when it is set.
+func checkSignatures(runner jj.Runner, client forge.Service, states []changeState, baseBranches map[string]string, mode string, w io.Writer) error {
+	if mode == signatureCheckOff || len(states) == 0 {
+		return nil
+	}
+	if v, err := runner.ConfigGet("git.sign-on-push"); err == nil && v == "true" {
+		return nil
+	}
+
+	required := make(map[string]bool)
+	var changes []*jj.Change
+	for _, s := range states {
+		branch := baseBranches[s.change.ChangeID]
+		req, ok := required[branch]
+		if !ok {
+			var err error
+			if req, err = client.RequiresSignedCommits(branch); err != nil {
+				_, _ = fmt.Fprintf(w, "warning: could not check whether %s requires signed commits: %v\n", branch, err)
+				return nil
+			}
+			required[branch] = req
+		}
+		if req {
+			changes = append(changes, s.change)
+		}
+	}
+	unsigned, err := jj.FindUnsignedChanges(runner, changes)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "warning: could not check commit signatures: %v\n", err)
+		return nil
+	}
+	if len(unsigned) == 0 {
+		return nil
+	}
+
+	prefix := ""
+	if mode == signatureCheckWarn {
+		prefix = "warning: "
+	}
+	_, _ = fmt.Fprintf(w, "%s%d change(s) are not signed, but %s/%s requires signed commits on their base branches:\n", prefix, len(unsigned), client.Owner(), client.Repo())
+	for _, c := range changes {
+		if unsigned[c.ChangeID] {
+			_, _ = fmt.Fprintf(w, "  %s  %s\n", c.Short(), c.Title())
+		}
+	}
+	if mode == signatureCheckWarn {
+		return nil
+	}
+	return fmt.Errorf("%d change(s) are not signed and would be rejected — sign them (see signing.behavior and git.sign-on-push in the jj docs), or pass --no-verify", len(unsigned))
+}
@@ -0,0 +1,14 @@
+package cmd
+
+import "testing"
+
+func TestCheckSignatureMode(t *testing.T) {
+	for _, mode := range []string{signatureCheckOff, signatureCheckWarn, signatureCheckError} {
+		if err := checkSignatureMode(mode); err != nil {
+			t.Errorf("checkSignatureMode(%q): %v", mode, err)
+		}
+	}
+	if err := checkSignatureMode("strict"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// fileGroup is one entry of --split-groups: the files matching any of its
+// globs become one change of the split.
+type fileGroup struct {
+	name     string // the globs as configured
+	patterns []*regexp.Regexp
+}
+
+// parseFileGroups parses --split-groups entries, each a space-separated
+// list of globs.
+func parseFileGroups(entries []string) ([]fileGroup, error) {
+	var groups []fileGroup
+	for _, entry := range entries {
+		globs := strings.Fields(entry)
+		if len(globs) == 0 {
+			continue
+		}
+		g := fileGroup{name: strings.Join(globs, " ")}
+		for _, glob := range globs {
+			re, err := fileGlob(glob)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --split-groups glob %q: %w", glob, err)
+			}
+			g.patterns = append(g.patterns, re)
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+func (g fileGroup) matches(path string) bool {
+	for _, re := range g.patterns {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileGlob translates a gitignore-style glob into a regular expression over
+// repository-relative paths: * and ? stay within a directory, ** spans
+// directories, a trailing slash matches everything below a directory, and a
+// glob without a slash matches at any depth.
+func fileGlob(glob string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(glob, "/"), "/")
+	glob = strings.TrimPrefix(glob, "/")
+	dir := strings.HasSuffix(glob, "/")
+	glob = strings.TrimSuffix(glob, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(glob); i++ {
+		switch ch := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case ch == '*':
+			b.WriteString("[^/]*")
+		case ch == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	if dir {
+		b.WriteString("/.*")
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// splitPart is one change of a split: the files it takes.
+type splitPart struct {
+	group string // the group's globs; "" for the files no group matched
+	files []string
+}
+
+// planSplit assigns each file to the first group matching it, keeping the
+// groups' order; files no group matches come last. Empty parts are dropped.
+func planSplit(files []string, groups []fileGroup) []splitPart {
+	parts := make([]splitPart, len(groups)+1)
+	for i, g := range groups {
+		parts[i].group = g.name
+	}
+	for _, f := range files {
+		i := len(groups)
+		for j, g := range groups {
+			if g.matches(f) {
+				i = j
+				break
+			}
+		}
+		parts[i].files = append(parts[i].files, f)
+	}
+	var plan []splitPart
+	for _, p := range parts {
+		if len(p.files) > 0 {
+			plan = append(plan, p)
+		}
+	}
+	return plan
+}
+
+// partDescription is the description of part i (from 1) of n of a change
+// split from description: the title gets a "(part i/n)" suffix.
+func partDescription(description string, i, n int) string {
+	title, rest, _ := strings.Cut(strings.TrimSpace(description), "\n")
+	return fmt.Sprintf("%s (part %d/%d)", title, i, n) + strings.TrimRight("\n"+rest, "\n")
+}
+
+// splitByFile splits the single change revsets name into a stack of changes,
+// one per file group matching its files, bottom first, followed by the files
+// no group matches. It returns the change ID of the top of the new stack,
+// which still has the original change's descendants. In a dry run it only
+// prints the plan.
+func splitByFile(runner jj.Runner, revsets []string, groups []fileGroup, dryRun bool, w io.Writer) (string, error) {
+	data, err := runner.Log("(" + strings.Join(revsets, ") | (") + ")")
+	if err != nil {
+		return "", fmt.Errorf("resolving the change to split: %w", err)
+	}
+	changes, err := jj.ParseChanges(data)
+	if err != nil {
+		return "", fmt.Errorf("resolving the change to split: %w", err)
+	}
+	if len(changes) != 1 {
+		return "", fmt.Errorf("--split-by-file splits a single change, but the revsets name %d — pass the change to split, e.g. @-", len(changes))
+	}
+	c := changes[0]
+	if c.Immutable {
+		return "", fmt.Errorf("change %s (%s) is immutable and cannot be split", c.Short(), c.Title())
+	}
+	diff, err := runner.Diff(c.CommitID)
+	if err != nil {
+		return "", fmt.Errorf("reading the diff of %s: %w", c.Short(), err)
+	}
+	plan := planSplit(jj.ChangedFiles(diff), groups)
+	if len(plan) < 2 {
+		_, _ = fmt.Fprintf(w, "Not splitting %s: its files all fall into one group.\n", c.Short())
+		return c.ChangeID, nil
+	}
+
+	_, _ = fmt.Fprintf(w, "Splitting %s (%s) into %d changes:\n", c.Short(), c.Title(), len(plan))
+	for i, p := range plan {
+		group := p.group
+		if group == "" {
+			group = "other files"
+		}
+		_, _ = fmt.Fprintf(w, "  %d. %s — %d file(s)\n", i+1, group, len(p.files))
+		for _, f := range p.files {
+			_, _ = fmt.Fprintf(w, "       %s\n", f)
+		}
+	}
+	if dryRun {
+		_, _ = fmt.Fprintln(w, "Dry run — not split; the change is shown unsplit below.")
+		return c.ChangeID, nil
+	}
+
+	// Each split takes the next part off the bottom of what is left. Whether
+	// jj keeps the change ID on the new commit or on the rest differs between
+	// versions, so find the rest by its description.
+	rest := c.ChangeID
+	for i, p := range plan[:len(plan)-1] {
+		msg := partDescription(c.Description, i+1, len(plan))
+		if err := runner.Split(rest, p.files, msg); err != nil {
+			return "", fmt.Errorf("splitting %s: %w", c.Short(), err)
+		}
+		if rest, err = splitRest(runner, rest, msg); err != nil {
+			return "", err
+		}
+	}
+	if err := runner.Describe(rest, partDescription(c.Description, len(plan), len(plan))); err != nil {
+		return "", fmt.Errorf("describing the last part of %s: %w", c.Short(), err)
+	}
+	_, _ = fmt.Fprintln(w)
+	return rest, nil
+}
+
+// splitRest returns the change ID of the rest of rev after splitting off a
+// commit described by msg.
+func splitRest(runner jj.Runner, rev, msg string) (string, error) {
+	for _, revset := range []string{rev, rev + "+"} {
+		data, err := runner.Log(revset)
+		if err != nil {
+			return "", fmt.Errorf("reading the split change: %w", err)
+		}
+		changes, err := jj.ParseChanges(data)
+		if err != nil {
+			return "", fmt.Errorf("reading the split change: %w", err)
+		}
+		if len(changes) != 1 {
+			return "", fmt.Errorf("split change %s resolved to %d commits", revset, len(changes))
+		}
+		if strings.TrimSpace(changes[0].Description) != strings.TrimSpace(msg) {
+			return changes[0].ChangeID, nil
+		}
+	}
+	return "", fmt.Errorf("could not find the rest of the split change %s", rev)
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var splitPRCmd = &cobra.Command{
+	Use:   "split-pr <pr-number> [revsets...]",
+	Short: "Turn an existing PR into a stack of PRs",
+	Long: `Split-pr helps to break up a big pull request that is already under review,
+in two steps.
+
+First, jip split-pr 42 fetches the branch of PR #42, tracks it as a
+bookmark, starts a new change on top of it (using jj new) and lists the
+PR's changes. Split them with jj split, reorder them with jj rebase and
+describe each part with jj describe — or let --split-by-file do the
+splitting in the next step.
+
+Then jip split-pr 42 --send sends the changes (the revsets, default @-) as a
+stack, like jip send with the same flags, comments on #42 with links to the
+new PRs and closes it. With --keep, #42 is not closed but becomes the PR of
+the change its branch points to.
+
+PRs from forks cannot be split: their branches are not on the remote.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSplitPR,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return completePRNumbers(false)(cmd, args, toComplete)
+		}
+		return completeJJRevsets(cmd, args, toComplete)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(splitPRCmd)
+	// The second step is a send, so split-pr takes all of send's flags.
+	splitPRCmd.Flags().AddFlagSet(sendCmd.Flags())
+	splitPRCmd.Flags().Bool("send", false, "Send the split changes as a stack and close the original PR")
+	splitPRCmd.Flags().Bool("keep", false, "With --send, keep the original PR as the PR of the change its branch points to instead of closing it")
+}
+
+func runSplitPR(cmd *cobra.Command, args []string) error {
+	number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil || number <= 0 {
+		return fmt.Errorf("invalid PR number %q", args[0])
+	}
+	send, _ := cmd.Flags().GetBool("send")
+	keep, _ := cmd.Flags().GetBool("keep")
+	if keep && !send {
+		return fmt.Errorf("--keep only applies together with --send")
+	}
+	w := cmd.OutOrStdout()
+	if send {
+		return sendCommand(cmd, args[1:], func(_ jj.Runner, client forge.Service, opts *sendOpts) error {
+			return prepareSplitSend(client, number, keep, opts, w)
+		})
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("revsets only apply together with --send")
+	}
+
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+		return err
+	}
+	remote, _ := cmd.Flags().GetString("remote")
+	upstream, _ := cmd.Flags().GetString("upstream")
+	rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], w)
+	if err != nil {
+		return err
+	}
+	if rc.upstreamRemote != "" {
+		remote = rc.upstreamRemote
+	}
+	release, err := lockRepo(cmd, repoRoot, w)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return executeSplitPRStart(runner, rc.client, checkoutOpts{remote: remote, number: number}, w)
+}
+
+// executeSplitPRStart fetches the branch of the PR to split, checks it out
+// and explains how to split it.
+func executeSplitPRStart(runner jj.Runner, client forge.Service, opts checkoutOpts, w io.Writer) error {
+	pr, err := client.GetPR(opts.number)
+	if err != nil {
+		return err
+	}
+	if pr.State != "OPEN" {
+		return fmt.Errorf("PR #%d is %s — only open PRs can be split", pr.Number, strings.ToLower(pr.State))
+	}
+
+	_, _ = fmt.Fprintf(w, "Fetching %s from %s...\n", pr.HeadRefName, opts.remote)
+	if err := runner.GitFetch(opts.remote, "exact:"+pr.HeadRefName, "exact:"+pr.BaseRefName); err != nil {
+		return fmt.Errorf("fetching %s: %w", opts.remote, err)
+	}
+	data, err := runner.BookmarkList()
+	if err != nil {
+		return fmt.Errorf("listing bookmarks: %w", err)
+	}
+	bookmarks, err := jj.ParseBookmarkList(data)
+	if err != nil {
+		return fmt.Errorf("parsing bookmarks: %w", err)
+	}
+	var head *jj.RemoteBookmarkState
+	for _, b := range bookmarks {
+		if rs, ok := b.Remotes[opts.remote]; ok && b.Name == pr.HeadRefName {
+			head = &rs
+		}
+	}
+	if head == nil {
+		return fmt.Errorf("branch %s of PR #%d is not on %s — PRs from forks cannot be split", pr.HeadRefName, pr.Number, opts.remote)
+	}
+	if !head.Tracked {
+		if err := runner.BookmarkTrack([]string{pr.HeadRefName}, opts.remote); err != nil {
+			return err
+		}
+	}
+
+	data, err = runner.Log(fmt.Sprintf("remote_bookmarks(exact:%q, exact:%q)..%s", pr.BaseRefName, opts.remote, head.Target))
+	if err != nil {
+		return fmt.Errorf("listing the changes of #%d: %w", pr.Number, err)
+	}
+	changes, err := jj.ParseChanges(data)
+	if err != nil {
+		return fmt.Errorf("listing the changes of #%d: %w", pr.Number, err)
+	}
+	if err := runner.New(head.Target); err != nil {
+		return fmt.Errorf("checking out #%d: %w", pr.Number, err)
+	}
+
+	_, _ = fmt.Fprintf(w, "\nChecked out #%d %s (%d change(s), newest first):\n\n", pr.Number, pr.Title, len(changes))
+	for _, c := range changes {
+		_, _ = fmt.Fprintf(w, "  %s  %s\n", c.Short(), c.Title())
+	}
+	_, _ = fmt.Fprintf(w, `
+Split the PR into a stack of changes:
+  jj split -r <change>     split a change in two (repeat as needed)
+  jj rebase -r <change>    reorder the changes
+  jj describe <change>     describe each part
+
+Then send the stack and close #%d in favor of it:
+  jip split-pr %d --send
+(add --split-by-file to split by --split-groups instead, or --keep to keep
+#%d as one of the stack's PRs).
+`, pr.Number, pr.Number, pr.Number)
+	return nil
+}
+
+// prepareSplitSend adjusts the send of the split stack: unless keep, the
+// branch of the PR to split gets no PR of the stack, and once sent, the PR is
+// closed with a comment linking the stack.
+func prepareSplitSend(client forge.Service, number int, keep bool, opts *sendOpts, w io.Writer) error {
+	pr, err := client.GetPR(number)
+	if err != nil {
+		return err
+	}
+	if pr.State != "OPEN" {
+		return fmt.Errorf("PR #%d is %s — only open PRs can be split", pr.Number, strings.ToLower(pr.State))
+	}
+	if !keep {
+		opts.retiredBranches = map[string]bool{pr.HeadRefName: true}
+	}
+	opts.sent = func(states []changeState) error {
+		return finishSplit(client, pr, keep, states, w)
+	}
+	return nil
+}
+
+// finishSplit links the PR that was split to the PRs of the stack it became,
+// bottom first, and closes it unless keep.
+func finishSplit(client forge.Service, pr *forge.PRInfo, keep bool, states []changeState, w io.Writer) error {
+	var refs []string
+	for _, s := range states {
+		ref := fmt.Sprintf("#%d", s.pr.Number)
+		if s.pr.Number == pr.Number {
+			ref += " (this PR)"
+		}
+		refs = append(refs, ref)
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	note := fmt.Sprintf("Split into a stack of %d PRs, bottom first: %s.", len(refs), strings.Join(refs, ", "))
+	if !keep {
+		note += " Closing this PR in favor of them."
+	}
+	if err := client.CommentOnPR(pr.Number, note); err != nil {
+		return fmt.Errorf("commenting on #%d: %w", pr.Number, err)
+	}
+	if keep {
+		_, _ = fmt.Fprintf(w, "\nLinked #%d to the stack.\n", pr.Number)
+		return nil
+	}
+	closed := "closed"
+	if err := client.UpdatePR(pr.Number, forge.UpdatePROpts{State: &closed}); err != nil {
+		return fmt.Errorf("closing #%d: %w", pr.Number, err)
+	}
+	_, _ = fmt.Fprintf(w, "\nClosed #%d in favor of the stack.\n", pr.Number)
+	return nil
+}
@@ -0,0 +1,68 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_SplitPR(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	// One PR holding two commits.
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, stackMode: stackModeNone}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	if len(mock.PRs) != 1 {
+		t.Fatalf("got %d PRs, want 1", len(mock.PRs))
+	}
+	big := 0
+	for n := range mock.PRs {
+		big = n
+	}
+
+	buf.Reset()
+	if err := executeSplitPRStart(runner, mock, checkoutOpts{remote: "origin", number: big}, &buf); err != nil {
+		t.Fatalf("split-pr failed: %v\n%s", err, buf.String())
+	}
+	t.Logf("Output:\n%s", buf.String())
+	for _, want := range []string{"feat: add A", "feat: add B", "jip split-pr"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output lacks %q", want)
+		}
+	}
+
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}
+	if err := prepareSplitSend(mock, big, false, &opts, &buf); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send of the split stack failed: %v\n%s", err, buf.String())
+	}
+	t.Logf("Output:\n%s", buf.String())
+
+	mock.Lock()
+	defer mock.Unlock()
+	if len(mock.PRs) != 3 {
+		t.Fatalf("got %d PRs, want the original and a stack of 2", len(mock.PRs))
+	}
+	if got := mock.PRs[big].State; got != "closed" {
+		t.Errorf("original PR state = %s, want closed", got)
+	}
+	if c := mock.Comments[big]; len(c) != 1 || !strings.Contains(c[0], "Split into a stack of 2 PRs") {
+		t.Errorf("original PR comments = %q", c)
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestSplitPRSend(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		fake := forgetest.New("owner", "repo")
+		fake.PRs[7] = &forge.PRInfo{Number: 7, State: "OPEN", HeadRefName: "big-feature", BaseRefName: "main"}
+
+		var opts sendOpts
+		var buf bytes.Buffer
+		if err := prepareSplitSend(fake, 7, keep, &opts, &buf); err != nil {
+			t.Fatal(err)
+		}
+		if got := opts.retiredBranches["big-feature"]; got == keep {
+			t.Errorf("keep=%v: big-feature retired = %v", keep, got)
+		}
+
+		top := &forge.PRInfo{Number: 9}
+		if keep {
+			top = fake.PRs[7]
+		}
+		states := []changeState{
+			{change: &jj.Change{ChangeID: "a"}, pr: &forge.PRInfo{Number: 8}},
+			{change: &jj.Change{ChangeID: "b", ParentIDs: []string{"a"}}, pr: top},
+		}
+		if err := opts.sent(states); err != nil {
+			t.Fatal(err)
+		}
+
+		want := "Split into a stack of 2 PRs, bottom first: #8, #9. Closing this PR in favor of them."
+		state := "closed"
+		if keep {
+			want = "Split into a stack of 2 PRs, bottom first: #8, #7 (this PR)."
+			state = "OPEN"
+		}
+		if got := fake.Comments[7]; !reflect.DeepEqual(got, []string{want}) {
+			t.Errorf("keep=%v: comments = %q, want %q", keep, got, want)
+		}
+		if got := fake.PRs[7].State; got != state {
+			t.Errorf("keep=%v: state = %s, want %s", keep, got, state)
+		}
+	}
+}
+
+func TestSplitPRSend_ClosedPR(t *testing.T) {
+	fake := forgetest.New("owner", "repo")
+	fake.PRs[7] = &forge.PRInfo{Number: 7, State: "MERGED", HeadRefName: "big-feature"}
+	if err := prepareSplitSend(fake, 7, false, &sendOpts{}, &bytes.Buffer{}); err == nil {
+		t.Error("splitting a merged PR should fail")
+	}
+}
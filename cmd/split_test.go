@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFileGlob(t *testing.T) {
+	tests := []struct {
+		glob string
+		path string
+		want bool
+	}{
+		{"*.proto", "api/v1/user.proto", true},
+		{"*.proto", "user.proto", true},
+		{"*.proto", "user.go", false},
+		{"docs/", "docs/a/b.md", true},
+		{"docs/", "src/docs/b.md", true},
+		{"/docs/", "src/docs/b.md", false},
+		{"internal/store/**", "internal/store/sql/db.go", true},
+		{"internal/*.go", "internal/store/db.go", false},
+		{"internal/*.go", "internal/db.go", true},
+		{"**/testdata/**", "cmd/testdata/x.json", true},
+		{"go.?od", "go.mod", true},
+	}
+	for _, tt := range tests {
+		re, err := fileGlob(tt.glob)
+		if err != nil {
+			t.Fatalf("fileGlob(%q): %v", tt.glob, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("fileGlob(%q) on %q = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPlanSplit(t *testing.T) {
+	groups, err := parseFileGroups([]string{"*.proto", "internal/store/** migrations/", "  "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	files := []string{"cmd/main.go", "api/user.proto", "migrations/001.sql", "internal/store/db.go", "README.md"}
+	want := []splitPart{
+		{group: "*.proto", files: []string{"api/user.proto"}},
+		{group: "internal/store/** migrations/", files: []string{"migrations/001.sql", "internal/store/db.go"}},
+		{group: "", files: []string{"cmd/main.go", "README.md"}},
+	}
+	if got := planSplit(files, groups); !reflect.DeepEqual(got, want) {
+		t.Errorf("planSplit = %+v, want %+v", got, want)
+	}
+
+	// Groups matching nothing are dropped.
+	want = []splitPart{{group: "", files: []string{"cmd/main.go"}}}
+	if got := planSplit([]string{"cmd/main.go"}, groups); !reflect.DeepEqual(got, want) {
+		t.Errorf("planSplit = %+v, want %+v", got, want)
+	}
+}
+
+func TestPartDescription(t *testing.T) {
+	if got, want := partDescription("feat: add users\n\nDetails.\n", 1, 3), "feat: add users (part 1/3)\n\nDetails."; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := partDescription("feat: add users\n", 3, 3), "feat: add users (part 3/3)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	gh "github.com/omarkohl/jip/internal/github"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var squashStackCmd = &cobra.Command{
+	Use:   "squash-stack [revset]",
+	Short: "Collapse a reviewed stack into its bottom PR",
+	Long: `Squash-stack squashes every change of a linear stack into its bottom change
+(using jj squash), pushes the result to the bottom PR, and closes the PRs of
+the other changes with a link to it.
+
+Use it when the stack has been reviewed but upstream wants a single final PR.
+The combined description is the bottom description followed by the others,
+as jj squash would produce; pass --message to set it instead.
+
+Default revset is @- (the stack up to the last committed change).`,
+	Args:              cobra.MaximumNArgs(1),
+	RunE:              runSquashStack,
+	ValidArgsFunction: completeJJRevsets,
+}
+
+func init() {
+	rootCmd.AddCommand(squashStackCmd)
+	squashStackCmd.Flags().StringP("base", "b", "trunk()", "Base branch (defaults to the repo's trunk branch, usually main)")
+	squashStackCmd.Flags().String("remote", "origin", "Push remote name")
+	squashStackCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (where PRs are opened)")
+	squashStackCmd.Flags().StringP("message", "m", "", "Description of the squashed change (default: all descriptions combined)")
+	squashStackCmd.Flags().BoolP("dry-run", "n", false, "Show what would happen without making changes")
+	addNoLockFlag(squashStackCmd)
+
+	_ = squashStackCmd.RegisterFlagCompletionFunc("base", completeJJBookmarks)
+	_ = squashStackCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = squashStackCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+}
+
+// squashOpts holds configuration for squash-stack.
+type squashOpts struct {
+	base    string
+	remote  string
+	message string
+	dryRun  bool
+	revset  string
+	namer   *jj.BookmarkNamer // tells jip's bookmarks from others
+}
+
+func runSquashStack(cmd *cobra.Command, args []string) error {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+		return err
+	}
+
+	base, _ := cmd.Flags().GetString("base")
+	remote, _ := cmd.Flags().GetString("remote")
+	upstream, _ := cmd.Flags().GetString("upstream")
+	message, _ := cmd.Flags().GetString("message")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	revset := "@-"
+	if len(args) > 0 {
+		revset = args[0]
+	}
+	w := cmd.OutOrStdout()
+
+	rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], w)
+	if err != nil {
+		return err
+	}
+	namer, err := configBookmarkNamer(cfg, rc.client.GetAuthenticatedUser)
+	if err != nil {
+		return err
+	}
+	if !dryRun {
+		release, err := lockRepo(cmd, repoRoot, w)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	return executeSquashStack(runner, rc.client, squashOpts{
+		base:    base,
+		remote:  remote,
+		message: message,
+		dryRun:  dryRun,
+		revset:  revset,
+		namer:   namer,
+	}, w)
+}
+
+func executeSquashStack(runner jj.Runner, client forge.Service, opts squashOpts, w io.Writer) error {
+	dags, err := jj.ResolveStacks(runner, []string{opts.revset}, opts.base)
+	if err != nil {
+		return fmt.Errorf("resolving stack: %w", err)
+	}
+	if len(dags) != 1 {
+		return fmt.Errorf("%q resolved to %d stacks — squash-stack works on one stack at a time", opts.revset, len(dags))
+	}
+	dag := dags[0]
+	if len(dag.Changes) < 2 {
+		_, _ = fmt.Fprintln(w, "Stack has a single change — nothing to squash.")
+		return nil
+	}
+	if leaves := dag.LeafChanges(); len(leaves) != 1 {
+		return fmt.Errorf("squash-stack requires a linear stack (found %d tips)", len(leaves))
+	}
+	for _, c := range dag.Changes {
+		inStack := 0
+		for _, pid := range c.ParentIDs {
+			if _, ok := dag.ByID[pid]; ok {
+				inStack++
+			}
+		}
+		if inStack > 1 {
+			return fmt.Errorf("squash-stack requires a linear stack, but change %s (%s) is a merge", c.Short(), c.Title())
+		}
+		if c.Conflict {
+			return fmt.Errorf("change %s has conflicts — resolve before squashing", c.Short())
+		}
+	}
+
+	entries, err := findStackPRs(runner, client, opts.namer, dag, opts.remote)
+	if err != nil {
+		return err
+	}
+	bottom, upper := entries[0], entries[1:]
+	if bottom.pr == nil {
+		return fmt.Errorf("bottom change %s has no open PR — send the stack first", bottom.change.Short())
+	}
+
+	message := opts.message
+	if message == "" {
+		message = squashMessage(entries)
+	}
+
+	_, _ = fmt.Fprintf(w, "\nSquashing %d change(s) into #%d (%s):\n\n", len(upper), bottom.pr.Number, bottom.change.Short())
+	for _, e := range upper {
+		pr := "no PR"
+		if e.pr != nil {
+			pr = fmt.Sprintf("close #%d", e.pr.Number)
+		}
+		_, _ = fmt.Fprintf(w, "  %s  %s  (%s)\n", e.change.Short(), e.change.Title(), pr)
+	}
+	if opts.dryRun {
+		_, _ = fmt.Fprintf(w, "\nDry run — nothing changed.\n")
+		return nil
+	}
+
+	// 1. Squash locally and pick up the rewritten bottom commit.
+	from := make([]string, len(upper))
+	for i, e := range upper {
+		from[i] = e.change.ChangeID
+	}
+	if err := runner.Squash(from, bottom.change.ChangeID, message); err != nil {
+		return fmt.Errorf("squashing: %w", err)
+	}
+	data, err := runner.Log(bottom.change.ChangeID)
+	if err != nil {
+		return fmt.Errorf("reading squashed change: %w", err)
+	}
+	squashed, err := jj.ParseChanges(data)
+	if err != nil {
+		return fmt.Errorf("reading squashed change: %w", err)
+	}
+	if len(squashed) != 1 {
+		return fmt.Errorf("squashed change %s resolved to %d commits", bottom.change.Short(), len(squashed))
+	}
+	result := squashed[0]
+
+	// 2. Close the upper PRs, each pointing at the PR that now holds its
+	// changes. This happens before their branches are deleted, which would
+	// close them without explanation.
+	var closed []string
+	for _, e := range upper {
+		if e.pr == nil {
+			continue
+		}
+		note := fmt.Sprintf("Squashed into #%d together with the rest of the stack.", bottom.pr.Number)
+		if err := client.CommentOnPR(e.pr.Number, note); err != nil {
+			return err
+		}
+		state := "closed"
+		if err := client.UpdatePR(e.pr.Number, forge.UpdatePROpts{State: &state}); err != nil {
+			return fmt.Errorf("closing PR #%d: %w", e.pr.Number, err)
+		}
+		closed = append(closed, fmt.Sprintf("#%d", e.pr.Number))
+	}
+
+	// 3. Delete the upper branches and push the squashed commit. jj may
+	// already have dropped the bookmarks of the abandoned changes.
+	push := []string{bottom.bookmark}
+	var remaining []string
+	bookmarkData, err := runner.BookmarkList()
+	if err != nil {
+		return fmt.Errorf("listing bookmarks: %w", err)
+	}
+	bookmarks, err := jj.ParseBookmarkList(bookmarkData)
+	if err != nil {
+		return fmt.Errorf("parsing bookmarks: %w", err)
+	}
+	present := make(map[string]bool, len(bookmarks))
+	for _, b := range bookmarks {
+		present[b.Name] = b.Present
+	}
+	for _, e := range upper {
+		if e.bookmark == "" {
+			continue
+		}
+		push = append(push, e.bookmark)
+		if present[e.bookmark] {
+			remaining = append(remaining, e.bookmark)
+		}
+	}
+	if len(remaining) > 0 {
+		if err := runner.BookmarkDelete(remaining); err != nil {
+			return err
+		}
+	}
+	_, _ = fmt.Fprintf(w, "\nPushing %s...\n", bottom.bookmark)
+	if err := runner.GitPush(push, opts.remote); err != nil {
+		return fmt.Errorf("pushing: %w", err)
+	}
+
+	// 4. The bottom PR now carries the whole stack.
+	title := result.PRTitle()
+	body := gh.WithPushedCommitMarker(gh.MergePRBody(bottom.pr.Body, result.Body()), result.CommitID)
+	if err := client.UpdatePR(bottom.pr.Number, forge.UpdatePROpts{Title: &title, Body: &body}); err != nil {
+		return fmt.Errorf("updating PR #%d: %w", bottom.pr.Number, err)
+	}
+	if len(closed) > 0 {
+		note := fmt.Sprintf("Squashed %s into this PR.", strings.Join(closed, ", "))
+		if err := client.CommentOnPR(bottom.pr.Number, note); err != nil {
+			return err
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "\n#%-4d now contains the whole stack  %s\n", bottom.pr.Number, bottom.pr.URL)
+	if len(closed) > 0 {
+		_, _ = fmt.Fprintf(w, "      closed %s\n", strings.Join(closed, ", "))
+	}
+	return nil
+}
+
+// squashMessage combines the descriptions of a stack the way jj squash does
+// when both sides are described: bottom first, separated by blank lines.
+// jip's own trailers and the PR override trailers are dropped, as they
+// describe the old stack's PRs.
+func squashMessage(entries []stackEntry) string {
+	var parts []string
+	for _, e := range entries {
+		if d := strings.TrimSpace(jj.StripOverrides(jj.StripTrailers(e.change.Description))); d != "" {
+			parts = append(parts, d)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
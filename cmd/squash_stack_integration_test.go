@@ -0,0 +1,78 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_SquashStack(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+	writeAndCommit(t, repoDir, "c.go", "package c", "feat: add C")
+	bottomID := getChangeID(t, repoDir, "@---")
+
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	bottomBookmark := findBookmarkForChange(t, runner, bottomID)
+
+	buf.Reset()
+	if err := executeSquashStack(runner, mock, squashOpts{base: "main", remote: "origin", namer: defaultNamer(t), revset: "@-"}, &buf); err != nil {
+		t.Fatalf("squash-stack failed: %v\n%s", err, buf.String())
+	}
+	t.Logf("Output:\n%s", buf.String())
+
+	// The stack is now a single change holding all three files.
+	data, err := runner.Log("main..@-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := jj.ParseChanges(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].ChangeID != bottomID {
+		t.Fatalf("expected only the bottom change to remain, got %+v", changes)
+	}
+	for _, title := range []string{"feat: add A", "feat: add B", "feat: add C"} {
+		if !strings.Contains(changes[0].Description, title) {
+			t.Errorf("squashed description lacks %q:\n%s", title, changes[0].Description)
+		}
+	}
+
+	mock.Lock()
+	defer mock.Unlock()
+	var open, closed int
+	for _, pr := range mock.PRs {
+		if pr.HeadRefName == bottomBookmark {
+			open++
+			if pr.State == "closed" {
+				t.Errorf("bottom PR #%d was closed", pr.Number)
+			}
+			if !strings.Contains(mock.Comments[pr.Number][len(mock.Comments[pr.Number])-1], "into this PR") {
+				t.Errorf("bottom PR #%d lacks the squash note: %v", pr.Number, mock.Comments[pr.Number])
+			}
+			continue
+		}
+		if pr.State != "closed" {
+			t.Errorf("upper PR #%d still %q", pr.Number, pr.State)
+		}
+		closed++
+	}
+	if open != 1 || closed != 2 {
+		t.Errorf("got %d open / %d closed PRs, want 1 / 2", open, closed)
+	}
+}
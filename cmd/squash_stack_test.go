@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestSquashMessage(t *testing.T) {
+	entries := []stackEntry{
+		{change: &jj.Change{Description: "feat: add parser\n\nHandles the basics.\n\nJip-Stack: 1/3\nJip-PR: #1"}},
+		{change: &jj.Change{Description: ""}},
+		{change: &jj.Change{Description: "fix: parser edge case\n\nSigned-off-by: A <a@example.com>"}},
+	}
+	want := "feat: add parser\n\nHandles the basics.\n\nfix: parser edge case\n\nSigned-off-by: A <a@example.com>"
+	if got := squashMessage(entries); got != want {
+		t.Errorf("squashMessage() =\n%q\nwant\n%q", got, want)
+	}
+}
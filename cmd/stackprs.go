@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// stackEntry pairs a change with the branch and open PR it was sent as.
+type stackEntry struct {
+	change   *jj.Change
+	bookmark string        // "" when no bookmark on the change exists on the remote
+	pushed   string        // commit the bookmark points at on the remote
+	pr       *forge.PRInfo // nil when the branch has no open PR
+}
+
+// findStackPRs looks up the open PR of each change in dag through the
+// bookmarks that point at the change and exist on remote. When several do,
+// the one with an open PR wins, then one namer owns. Entries are returned in
+// the DAG's topological order.
+func findStackPRs(runner jj.Runner, client forge.Service, namer *jj.BookmarkNamer, dag *jj.ChangeDAG, remote string) ([]stackEntry, error) {
+	data, err := runner.BookmarkList()
+	if err != nil {
+		return nil, fmt.Errorf("listing bookmarks: %w", err)
+	}
+	bookmarks, err := jj.ParseBookmarkList(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing bookmarks: %w", err)
+	}
+	matched := jj.MatchBookmarksToChanges(dag, bookmarks)
+
+	var branches []string
+	for _, c := range dag.Changes {
+		for _, b := range matched[c.ChangeID] {
+			if _, ok := b.Remotes[remote]; ok {
+				branches = append(branches, b.Name)
+			}
+		}
+	}
+	prs := make(map[string]*forge.PRInfo)
+	if len(branches) > 0 {
+		prs, err = client.LookupPRsByBranch(branches)
+		if err != nil {
+			return nil, fmt.Errorf("looking up PRs: %w", err)
+		}
+	}
+
+	entries := make([]stackEntry, len(dag.Changes))
+	for i, c := range dag.Changes {
+		entries[i].change = c
+		for _, b := range matched[c.ChangeID] {
+			rs, ok := b.Remotes[remote]
+			if !ok {
+				continue
+			}
+			if pr := prs[b.Name]; pr != nil {
+				entries[i].bookmark, entries[i].pushed, entries[i].pr = b.Name, rs.Target, pr
+				break
+			}
+			if entries[i].bookmark == "" || namer.Owns(b.Name) {
+				entries[i].bookmark, entries[i].pushed = b.Name, rs.Target
+			}
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show statistics about your stacked PRs in this repository",
+	Long: `Stats summarizes what jip remembers about the changes it sent from this
+repository: how many PRs it tracks and opened, and how deep your stacks are.
+The numbers come from jip's state file in .jj/jip, so they cover this
+repository since jip started recording them, and nothing is sent anywhere.
+
+With --merge-times, stats also asks the forge when each PR was opened and
+merged and shows how long every PR took to merge.`,
+	Args: cobra.NoArgs,
+	RunE: runStats,
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().Bool("merge-times", false, "Look up the PRs on the forge and show how long each took to merge")
+	statsCmd.Flags().String("remote", "origin", "Push remote name")
+	statsCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (where PRs are opened)")
+
+	_ = statsCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = statsCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	st, err := state.Load(state.Path(repoRoot))
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+
+	var client forge.Service
+	if mergeTimes, _ := cmd.Flags().GetBool("merge-times"); mergeTimes {
+		cfg, err := config.Load(repoRoot)
+		if err != nil {
+			return err
+		}
+		if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+			return err
+		}
+		remote, _ := cmd.Flags().GetString("remote")
+		upstream, _ := cmd.Flags().GetString("upstream")
+		rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], w)
+		if err != nil {
+			return err
+		}
+		client = rc.client
+	}
+	return executeStats(st, client, time.Now(), w)
+}
+
+// executeStats prints the statistics of the changes recorded in st. With a
+// client, it also looks up how long each PR took to merge.
+func executeStats(st *state.State, client forge.Service, now time.Time, w io.Writer) error {
+	if len(st.Changes) == 0 {
+		_, _ = fmt.Fprintln(w, "No statistics yet — jip has no record of a send in this repository.")
+		return nil
+	}
+
+	opened := make(map[int]bool)
+	tracked := make(map[int]bool)
+	for _, rec := range st.Changes {
+		if rec.PRNumber == 0 {
+			continue
+		}
+		tracked[rec.PRNumber] = true
+		if !rec.OpenedAt.IsZero() {
+			opened[rec.PRNumber] = true
+		}
+	}
+	_, _ = fmt.Fprintf(w, "Changes sent:  %d\n", len(st.Changes))
+	_, _ = fmt.Fprintf(w, "PRs:           %d, %d of them opened by jip\n", len(tracked), len(opened))
+
+	depths := stackDepths(st.Changes)
+	if len(depths) > 0 {
+		total := 0
+		for _, d := range depths {
+			total += d
+		}
+		_, _ = fmt.Fprintf(w, "Stacks:        %d, %.1f changes deep on average, %d at most\n",
+			len(depths), float64(total)/float64(len(depths)), slices.Max(depths))
+	}
+
+	if client == nil {
+		return nil
+	}
+	_, _ = fmt.Fprintln(w, "\nTime to merge:")
+	var merged []time.Duration
+	for _, number := range slices.Sorted(maps.Keys(tracked)) {
+		pr, err := client.GetPR(number)
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "  warning: %v\n", err)
+			continue
+		}
+		switch {
+		case pr.State == "MERGED" && !pr.CreatedAt.IsZero() && !pr.MergedAt.IsZero():
+			d := pr.MergedAt.Sub(pr.CreatedAt)
+			merged = append(merged, d)
+			_, _ = fmt.Fprintf(w, "  #%-4d merged after %s\n", number, formatSpan(d))
+		case pr.State == "MERGED":
+			_, _ = fmt.Fprintf(w, "  #%-4d merged\n", number)
+		case pr.State == "OPEN" && !pr.CreatedAt.IsZero():
+			_, _ = fmt.Fprintf(w, "  #%-4d open for %s\n", number, formatSpan(now.Sub(pr.CreatedAt)))
+		default:
+			_, _ = fmt.Fprintf(w, "  #%-4d %s\n", number, strings.ToLower(pr.State))
+		}
+	}
+	if len(merged) > 0 {
+		var total time.Duration
+		for _, d := range merged {
+			total += d
+		}
+		slices.Sort(merged)
+		_, _ = fmt.Fprintf(w, "\n%d merged: %s on average, %s median\n",
+			len(merged), formatSpan(total/time.Duration(len(merged))), formatSpan(merged[len(merged)/2]))
+	}
+	return nil
+}
+
+// stackDepths returns the number of changes of each stack recorded in
+// changes, in increasing order.
+func stackDepths(changes map[string]state.ChangeRecord) []int {
+	var depths []int
+	for _, stack := range recordedStacks(changes) {
+		depths = append(depths, len(stack))
+	}
+	slices.Sort(depths)
+	return depths
+}
+
+// recordedStacks returns the stacks recorded in changes: the dependency
+// chains, bottom first, that are not the bottom of a longer one. The stack
+// sent most recently comes first. Changes recorded without a chain are left
+// out.
+func recordedStacks(changes map[string]state.ChangeRecord) [][]string {
+	var stacks [][]string
+	seen := make(map[string]bool)
+	for _, rec := range changes {
+		key := strings.Join(rec.Stack, " ")
+		if len(rec.Stack) == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		top := true
+		for _, other := range changes {
+			if len(other.Stack) > len(rec.Stack) && slices.Equal(other.Stack[:len(rec.Stack)], rec.Stack) {
+				top = false
+				break
+			}
+		}
+		if top {
+			stacks = append(stacks, rec.Stack)
+		}
+	}
+	slices.SortFunc(stacks, func(a, b []string) int {
+		if c := changes[b[len(b)-1]].UpdatedAt.Compare(changes[a[len(a)-1]].UpdatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(strings.Join(a, " "), strings.Join(b, " "))
+	})
+	return stacks
+}
+
+// formatSpan renders a duration to the two largest units, e.g. 3d 4h, 5h
+// 12m or 42m.
+func formatSpan(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days, hours, minutes := int(d/(24*time.Hour)), int(d/time.Hour)%24, int(d/time.Minute)%60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
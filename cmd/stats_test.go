@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/internal/state"
+)
+
+func TestExecuteStats(t *testing.T) {
+	st, err := state.Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	// Two stacks: a → b → c and d → e; c has no PR (yet), e's PR was
+	// opened by hand.
+	for n, id := range []string{"a", "b", "c", "d", "e"} {
+		pr := n + 1
+		if id == "c" {
+			pr = 0
+		}
+		st.RecordSend(id, "jip/"+id, pr, "c"+id, now)
+		if id != "e" && id != "c" {
+			st.RecordOpened(id, now)
+		}
+	}
+	st.RecordStack("a", []string{"a"})
+	st.RecordStack("b", []string{"a", "b"})
+	st.RecordStack("c", []string{"a", "b", "c"})
+	st.RecordStack("d", []string{"d"})
+	st.RecordStack("e", []string{"d", "e"})
+
+	var out bytes.Buffer
+	if err := executeStats(st, nil, now, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Changes sent:  5\n",
+		"PRs:           4, 3 of them opened by jip\n",
+		"Stacks:        2, 2.5 changes deep on average, 3 at most\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Time to merge") {
+		t.Errorf("merge times must only be looked up with a client:\n%s", out.String())
+	}
+
+	fake := forgetest.New("owner", "repo")
+	opened := now.Add(-72 * time.Hour)
+	fake.PRs[1] = &forge.PRInfo{Number: 1, State: "MERGED", CreatedAt: opened, MergedAt: opened.Add(26 * time.Hour)}
+	fake.PRs[2] = &forge.PRInfo{Number: 2, State: "MERGED", CreatedAt: opened, MergedAt: opened.Add(2*time.Hour + 30*time.Minute)}
+	fake.PRs[4] = &forge.PRInfo{Number: 4, State: "OPEN", CreatedAt: opened}
+	fake.PRs[5] = &forge.PRInfo{Number: 5, State: "CLOSED"}
+	out.Reset()
+	if err := executeStats(st, fake, now, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"  #1    merged after 1d 2h\n",
+		"  #2    merged after 2h 30m\n",
+		"  #4    open for 3d 0h\n",
+		"  #5    closed\n",
+		"2 merged: 14h 15m on average, 1d 2h median\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestExecuteStats_NoRecords(t *testing.T) {
+	st, err := state.Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := executeStats(st, nil, time.Now(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "No statistics yet") {
+		t.Errorf("output = %q", out.String())
+	}
+}
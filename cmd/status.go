@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/i18n"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status [revsets...]",
+	Short: "Show the PRs of a stack, their CI checks and reviews",
+	Long: `Status lists each change of the given stacks with its open PR, whether
+the PR is a draft, and the combined status of the CI checks on the PR's head
+commit (pass, fail, pending, or none), so you know which PRs are mergeable
+before acting. PRs with unresolved review threads or reviewers requesting
+changes are marked as needing attention. Changes whose local commit differs
+from the pushed branch are marked as not sent.
+
+Status only reads: it fetches the push remote but changes nothing.
+
+Default revset is @- (the stack up to the last committed change).`,
+	RunE:              runStatus,
+	ValidArgsFunction: completeJJRevsets,
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().StringP("base", "b", "trunk()", "Base branch (defaults to the repo's trunk branch, usually main)")
+	statusCmd.Flags().String("remote", "origin", "Push remote name")
+	statusCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (where PRs are opened)")
+
+	_ = statusCmd.RegisterFlagCompletionFunc("base", completeJJBookmarks)
+	_ = statusCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = statusCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+		return err
+	}
+
+	base, _ := cmd.Flags().GetString("base")
+	remote, _ := cmd.Flags().GetString("remote")
+	upstream, _ := cmd.Flags().GetString("upstream")
+	revsets := args
+	if len(revsets) == 0 {
+		revsets = []string{"@-"}
+	}
+	w := cmd.OutOrStdout()
+
+	rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], w)
+	if err != nil {
+		return err
+	}
+	namer, err := configBookmarkNamer(cfg, rc.client.GetAuthenticatedUser)
+	if err != nil {
+		return err
+	}
+	return executeStatus(runner, rc.client, namer, base, remote, revsets, colorsFor(w), w)
+}
+
+func executeStatus(runner jj.Runner, client forge.Service, namer *jj.BookmarkNamer, base, remote string, revsets []string, c colors, w io.Writer) error {
+	// Status is read-only, so an offline run shows the last fetched state.
+	_, _ = fmt.Fprintf(w, "Fetching %s...\n", remote)
+	if err := runner.GitFetch(remote); err != nil {
+		_, _ = fmt.Fprintf(w, "warning: could not fetch %s, continuing with local state: %v\n", remote, err)
+	}
+
+	dags, err := jj.ResolveStacks(runner, revsets, base)
+	if err != nil {
+		return fmt.Errorf("resolving stacks: %w", err)
+	}
+	if len(dags) == 0 {
+		_, _ = fmt.Fprintln(w, i18n.T("No changes."))
+		return nil
+	}
+
+	stacks := make([][]stackEntry, len(dags))
+	var prNumbers []int
+	for i, dag := range dags {
+		if stacks[i], err = findStackPRs(runner, client, namer, dag, remote); err != nil {
+			return err
+		}
+		for _, e := range stacks[i] {
+			if e.pr != nil {
+				prNumbers = append(prNumbers, e.pr.Number)
+			}
+		}
+	}
+	checks := lookupChecks(client, prNumbers, w)
+	reviews := lookupReviews(client, prNumbers, w)
+
+	for _, entries := range stacks {
+		_, _ = fmt.Fprintln(w)
+		for _, e := range entries {
+			if e.pr == nil {
+				_, _ = fmt.Fprintf(w, "  %-5s %s %s  %s\n", "-", c.yellow(fmt.Sprintf("%-22s", i18n.T("no PR"))), e.change.Short(), e.change.Title())
+				continue
+			}
+			state := c.green("open ")
+			if e.pr.IsDraft {
+				state = c.dim("draft")
+			}
+			// Padded before coloring: escape codes take no room on screen.
+			checksText := fmt.Sprintf("%-15s", "checks: ?")
+			if checks != nil {
+				status := checks[e.pr.Number]
+				checksText = c.checks(fmt.Sprintf("%-15s", "checks: "+checksLabel(status)), status)
+			}
+			_, _ = fmt.Fprintf(w, "  #%-4d %s  %s %s  %s\n", e.pr.Number, state, checksText, e.change.Short(), e.change.Title())
+			if label := reviewsLabel(reviews[e.pr.Number]); label != "" {
+				_, _ = fmt.Fprintf(w, "        %s\n", c.yellow(fmt.Sprintf(i18n.T("needs attention: %s"), label)))
+			}
+			if e.pushed != e.change.CommitID {
+				_, _ = fmt.Fprintf(w, "        %s\n", c.red(i18n.T("local change not sent — run jip send")))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_Status(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	writeAndCommit(t, repoDir, "b.go", "package b", "feat: add B")
+
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	mock.Lock()
+	mock.Checks = map[int]string{1: forge.ChecksPassing, 2: forge.ChecksFailing}
+	mock.Reviews = map[int]forge.ReviewSummary{2: {UnresolvedThreads: 2, ChangesRequested: []string{"carol"}}}
+	mock.Unlock()
+
+	// A third change that was never sent.
+	writeAndCommit(t, repoDir, "c.go", "package c", "feat: add C")
+
+	buf.Reset()
+	if err := executeStatus(runner, mock, defaultNamer(t), "main", "origin", []string{"@-"}, colors{}, &buf); err != nil {
+		t.Fatalf("status failed: %v\n%s", err, buf.String())
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"#1    open   checks: pass",
+		"#2    open   checks: fail",
+		"needs attention: 2 unresolved thread(s), changes requested by carol",
+		"no PR",
+		"feat: add C",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("status output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "not sent — run jip send") {
+		t.Errorf("sent changes should not be reported as unsent:\n%s", out)
+	}
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// sendReport is the outcome of a send, written as markdown by --summary-file.
+type sendReport struct {
+	repo    string
+	dryRun  bool
+	prs     []reportPR
+	skipped []reportSkip
+	err     error // the error send returned, if any
+}
+
+// reportPR is a PR that was created or updated (in a dry run: would be).
+type reportPR struct {
+	number   int // 0 for a PR a dry run would create
+	url      string
+	action   string
+	changeID string
+	title    string
+	stat     *jj.DiffStat // nil when the diff could not be read
+	large    bool         // changes more lines than --large-pr-lines
+}
+
+// reportSkip is a change that was not sent.
+type reportSkip struct {
+	changeID string
+	title    string
+	reason   string
+	benign   bool
+}
+
+// addSkips records the skipped changes the way printAllSkipped reports them.
+func (r *sendReport) addSkips(postSkipped []changeState, postReasons map[string]skipReason, preSkipped []skippedEntry) {
+	for _, s := range preSkipped {
+		r.skipped = append(r.skipped, reportSkip{s.change.Short(), s.change.Title(), s.reason.reason, s.reason.benign})
+	}
+	for _, s := range postSkipped {
+		reason := postReasons[s.change.ChangeID]
+		r.skipped = append(r.skipped, reportSkip{s.change.Short(), s.change.Title(), reason.reason, reason.benign})
+	}
+}
+
+// markdown renders the report in the GitHub-flavored markdown accepted by
+// GitHub Actions job summaries.
+func (r *sendReport) markdown() string {
+	var b strings.Builder
+	heading := "jip send"
+	if r.dryRun {
+		heading += " (dry run)"
+	}
+	fmt.Fprintf(&b, "### %s — %s\n\n", heading, r.repo)
+
+	if r.err != nil {
+		fmt.Fprintf(&b, "> [!CAUTION]\n> %s\n\n", mdCell(r.err.Error()))
+	}
+
+	if len(r.prs) == 0 {
+		b.WriteString("No PRs sent.\n\n")
+	} else {
+		b.WriteString("| PR | Action | Change | Title | Size |\n|---|---|---|---|---|\n")
+		for _, p := range r.prs {
+			pr := "—"
+			if p.number != 0 {
+				pr = fmt.Sprintf("[#%d](%s)", p.number, p.url)
+			}
+			size := "—"
+			if p.stat != nil {
+				size = p.stat.String()
+				if p.large {
+					size = "⚠️ " + size
+				}
+			}
+			fmt.Fprintf(&b, "| %s | %s | `%.12s` | %s | %s |\n", pr, p.action, p.changeID, mdCell(p.title), size)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.skipped) > 0 {
+		fmt.Fprintf(&b, "**Skipped %d change(s)**\n\n", len(r.skipped))
+		b.WriteString("| Change | Title | Reason |\n|---|---|---|\n")
+		for _, s := range r.skipped {
+			reason := mdCell(s.reason)
+			if !s.benign {
+				reason = "⚠️ " + reason
+			}
+			fmt.Fprintf(&b, "| `%.12s` | %s | %s |\n", s.changeID, mdCell(s.title), reason)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// diffStats returns the size of each change of states, keyed by change ID,
+// from its local diff. A diff that cannot be read only costs its stats.
+func diffStats(runner jj.Runner, states []changeState) map[string]jj.DiffStat {
+	stats := make(map[string]jj.DiffStat, len(states))
+	for _, s := range states {
+		diff, err := runner.Diff(s.change.ChangeID)
+		if err != nil {
+			continue
+		}
+		stats[s.change.ChangeID] = jj.ParseDiffStat(diff)
+	}
+	return stats
+}
+
+// isLargePR reports whether stat exceeds the --large-pr-lines threshold.
+func isLargePR(stat jj.DiffStat, opts sendOpts) bool {
+	return opts.largePRLines > 0 && stat.Lines() > opts.largePRLines
+}
+
+// warnLargePRs warns about the PRs of prs exceeding the size threshold.
+func warnLargePRs(prs []reportPR, opts sendOpts, w io.Writer) {
+	first := true
+	for _, p := range prs {
+		if !p.large {
+			continue
+		}
+		if first {
+			_, _ = fmt.Fprintln(w)
+			first = false
+		}
+		pr := p.changeID
+		if p.number != 0 {
+			pr = fmt.Sprintf("#%d", p.number)
+		}
+		_, _ = fmt.Fprintf(w, "  warning: %s changes %d lines (more than %d) — consider splitting it into smaller changes\n",
+			pr, p.stat.Lines(), opts.largePRLines)
+	}
+}
+
+// mdCell makes s safe for a single markdown table cell.
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// appendSummaryFile appends the report to path. Appending (rather than
+// overwriting) matches $GITHUB_STEP_SUMMARY, which collects the summaries of
+// every command in a step.
+func appendSummaryFile(path string, r *sendReport) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("writing summary file: %w", err)
+	}
+	if _, err := f.WriteString(r.markdown()); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing summary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing summary file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestSendReportMarkdown(t *testing.T) {
+	r := &sendReport{
+		repo: "acme/widgets",
+		prs: []reportPR{
+			{number: 12, url: "https://github.com/acme/widgets/pull/12", action: "created", changeID: "abcdefabcdefabcdef", title: "feat: a | b"},
+		},
+		skipped: []reportSkip{
+			{changeID: "zzzzzzzzzzzzzz", title: "wip", reason: "private (matches git.private-commits)", benign: true},
+			{changeID: "yyyyyyyyyyyyyy", title: "fix: c", reason: "change has conflicts — resolve before sending"},
+		},
+		err: errors.New("1 change(s) skipped"),
+	}
+	md := r.markdown()
+	for _, want := range []string{
+		"### jip send — acme/widgets",
+		"> [!CAUTION]\n> 1 change(s) skipped",
+		"| [#12](https://github.com/acme/widgets/pull/12) | created | `abcdefabcdef` | feat: a \\| b |",
+		"**Skipped 2 change(s)**",
+		"| `zzzzzzzzzzzz` | wip | private (matches git.private-commits) |",
+		"| `yyyyyyyyyyyy` | fix: c | ⚠️ change has conflicts",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestSendReportMarkdown_DryRunPlannedPR(t *testing.T) {
+	r := &sendReport{repo: "o/r", dryRun: true, prs: []reportPR{{action: "would create", changeID: "abc", title: "t"}}}
+	md := r.markdown()
+	if !strings.Contains(md, "(dry run)") || !strings.Contains(md, "| — | would create |") {
+		t.Errorf("unexpected dry-run markdown:\n%s", md)
+	}
+}
+
+func TestSendReportMarkdown_Size(t *testing.T) {
+	r := &sendReport{repo: "o/r", prs: []reportPR{
+		{number: 1, url: "u1", action: "created", changeID: "a", title: "small", stat: &jj.DiffStat{Files: 1, Additions: 3, Deletions: 1}},
+		{number: 2, url: "u2", action: "updated", changeID: "b", title: "big", stat: &jj.DiffStat{Files: 9, Additions: 700}, large: true},
+		{number: 3, url: "u3", action: "updated", changeID: "c", title: "unknown"},
+	}}
+	md := r.markdown()
+	for _, want := range []string{
+		"| small | +3 −1, 1 file |",
+		"| big | ⚠️ +700 −0, 9 files |",
+		"| unknown | — |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestWarnLargePRs(t *testing.T) {
+	var buf bytes.Buffer
+	warnLargePRs([]reportPR{
+		{number: 1, stat: &jj.DiffStat{Additions: 10}},
+		{number: 2, stat: &jj.DiffStat{Additions: 450, Deletions: 50}, large: true},
+	}, sendOpts{largePRLines: 400}, &buf)
+	if got := buf.String(); got != "\n  warning: #2 changes 500 lines (more than 400) — consider splitting it into smaller changes\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestAppendSummaryFile_Appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := os.WriteFile(path, []byte("earlier step\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendSummaryFile(path, &sendReport{repo: "o/r"}); err != nil {
+		t.Fatalf("appendSummaryFile: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "earlier step\n### jip send — o/r") {
+		t.Errorf("summary not appended:\n%s", data)
+	}
+}
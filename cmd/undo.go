@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Reverse the last send",
+	Long: `Undo reverses the most recent jip send in this repository: it closes the
+PRs the send opened, deletes the branches it created, and moves the branches
+it updated back to where they were, on the remote and locally.
+
+A branch that changed after the send (pushed again, or its change rewritten
+locally) is left alone and reported. Comments and PR descriptions the send
+wrote stay, and local changes to the commits (--rebase, --trailers) are not
+reverted — use jj undo or jj op restore for those.
+
+Only the last send can be undone, once.`,
+	Args: cobra.NoArgs,
+	RunE: runUndo,
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+	undoCmd.Flags().BoolP("dry-run", "n", false, "Show what would be undone without changing anything")
+	addNoLockFlag(undoCmd)
+}
+
+// newSendRecord records where the bookmarks of states are before they are
+// pushed, as read from bookmarkByName. It returns nil when the push would
+// not move any of them on the remote.
+func newSendRecord(states []changeState, bookmarkByName map[string]*jj.BookmarkInfo, opts sendOpts) *state.SendRecord {
+	rec := &state.SendRecord{Remote: opts.remote, Upstream: opts.upstream, At: time.Now()}
+	moves := false
+	for _, s := range states {
+		m := state.BookmarkMove{Name: s.bookmark.Bookmark, Pushed: s.change.CommitID}
+		if bi := bookmarkByName[m.Name]; bi != nil {
+			m.PreviousLocal = bi.Target
+			if rs, ok := bi.Remotes[opts.remote]; ok {
+				m.PreviousRemote = rs.Target
+			}
+		}
+		if m.PreviousRemote != m.Pushed {
+			moves = true
+		}
+		rec.Bookmarks = append(rec.Bookmarks, m)
+	}
+	if !moves {
+		return nil
+	}
+	return rec
+}
+
+// extendSendRecord adds the bookmarks of more (nil when it moves nothing)
+// that rec does not have to rec, and returns rec. A resumed send extends the
+// record of the send it continues this way.
+func extendSendRecord(rec, more *state.SendRecord) *state.SendRecord {
+	if more == nil {
+		return rec
+	}
+	for _, m := range more.Bookmarks {
+		if !slices.ContainsFunc(rec.Bookmarks, func(b state.BookmarkMove) bool { return b.Name == m.Name }) {
+			rec.Bookmarks = append(rec.Bookmarks, m)
+		}
+	}
+	return rec
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	w := cmd.OutOrStdout()
+
+	st, err := state.Load(state.Path(repoRoot))
+	if err != nil {
+		return err
+	}
+	if st.LastSend == nil {
+		_, _ = fmt.Fprintln(w, "Nothing to undo — jip has no record of a send in this repository.")
+		return nil
+	}
+	if !dryRun {
+		release, err := lockRepo(cmd, repoRoot, w)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return err
+	}
+	rc, err := resolveRepoContext(runner, st.LastSend.Remote, st.LastSend.Upstream, cfg[forgeConfigKey], w)
+	if err != nil {
+		return err
+	}
+	return executeUndo(runner, rc.client, st, dryRun, w)
+}
+
+func executeUndo(runner jj.Runner, client forge.Service, st *state.State, dryRun bool, w io.Writer) error {
+	rec := st.LastSend
+	_, _ = fmt.Fprintf(w, "Fetching %s...\n", rec.Remote)
+	if err := runner.GitFetch(rec.Remote); err != nil {
+		return fmt.Errorf("fetching %s: %w", rec.Remote, err)
+	}
+	bookmarkData, err := runner.BookmarkList()
+	if err != nil {
+		return fmt.Errorf("listing bookmarks: %w", err)
+	}
+	bookmarks, err := jj.ParseBookmarkList(bookmarkData)
+	if err != nil {
+		return fmt.Errorf("parsing bookmarks: %w", err)
+	}
+	byName := make(map[string]*jj.BookmarkInfo, len(bookmarks))
+	for i := range bookmarks {
+		byName[bookmarks[i].Name] = &bookmarks[i]
+	}
+
+	verb := func(done, planned string) string {
+		if dryRun {
+			return "would " + planned
+		}
+		return done
+	}
+	_, _ = fmt.Fprintf(w, "\nUndoing the send of %s to %s:\n\n", rec.At.Local().Format(time.DateTime), rec.Remote)
+
+	// 1. Close the PRs the send opened, before their branches go away.
+	for _, number := range rec.CreatedPRs {
+		if !dryRun {
+			closed := "closed"
+			if err := client.UpdatePR(number, forge.UpdatePROpts{State: &closed}); err != nil {
+				_, _ = fmt.Fprintf(w, "  warning: %v\n", err)
+				continue
+			}
+		}
+		_, _ = fmt.Fprintf(w, "  #%-4d %s\n", number, verb("closed", "close"))
+	}
+
+	// 2. Move the pushed branches back on the remote: point the local
+	// bookmark at the previous remote target (or delete it) and push.
+	var push []string
+	var restore []state.BookmarkMove
+	left := 0
+	for _, m := range rec.Bookmarks {
+		if m.Pushed == m.PreviousRemote && m.Pushed == m.PreviousLocal {
+			continue // already up to date before the send
+		}
+		var local, remote string
+		if bi := byName[m.Name]; bi != nil {
+			local = bi.Target
+			if rs, ok := bi.Remotes[rec.Remote]; ok {
+				remote = rs.Target
+			}
+		}
+		if local != m.Pushed || (remote != m.Pushed && remote != m.PreviousRemote) {
+			_, _ = fmt.Fprintf(w, "  %s  changed since the send — left alone\n", m.Name)
+			left++
+			continue
+		}
+		restore = append(restore, m)
+		if remote == m.PreviousRemote {
+			continue // the push failed; only the local bookmark moved
+		}
+		push = append(push, m.Name)
+		if dryRun {
+			continue
+		}
+		if m.PreviousRemote == "" {
+			err = runner.BookmarkDelete([]string{m.Name})
+		} else {
+			err = runner.BookmarkReset(m.Name, m.PreviousRemote)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(push) > 0 && !dryRun {
+		_, _ = fmt.Fprintf(w, "  Pushing %d bookmark(s)...\n", len(push))
+		if err := runner.GitPush(push, rec.Remote); err != nil {
+			return fmt.Errorf("pushing: %w", err)
+		}
+	}
+
+	// 3. Put the local bookmarks back where they were before the send. A
+	// bookmark that only existed on the remote tracks it again.
+	for _, m := range restore {
+		pushed := slices.Contains(push, m.Name)
+		current := m.Pushed // where the local bookmark points now
+		if pushed {
+			current = m.PreviousRemote
+		}
+		want := m.PreviousLocal
+		if want == "" {
+			want = m.PreviousRemote
+		}
+		if !dryRun && want != current {
+			if want == "" {
+				err = runner.BookmarkDelete([]string{m.Name})
+			} else {
+				err = runner.BookmarkReset(m.Name, want)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if want == "" {
+			_, _ = fmt.Fprintf(w, "  %s  %s\n", m.Name, verb("deleted", "delete"))
+		} else {
+			_, _ = fmt.Fprintf(w, "  %s  %s %.12s\n", m.Name, verb("reset to", "reset to"), want)
+		}
+	}
+
+	if dryRun {
+		return nil
+	}
+	st.LastSend = nil
+	if err := st.Save(); err != nil {
+		return err
+	}
+	if left > 0 {
+		return fmt.Errorf("%d bookmark(s) changed since the send were left alone", left)
+	}
+	return nil
+}
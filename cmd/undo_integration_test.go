@@ -0,0 +1,113 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/internal/state"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// remoteBranch returns the commit branch points at in the bare repository
+// remoteDir, or "" when it does not exist.
+func remoteBranch(t *testing.T, remoteDir, branch string) string {
+	t.Helper()
+	out, err := exec.Command("git", "--git-dir", remoteDir, "rev-parse", "--verify", "-q", "refs/heads/"+branch).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestIntegration_UndoNewPRs(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, remoteDir := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+	st, err := state.Load(state.Path(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	changeID := getChangeID(t, repoDir, "@-")
+
+	var buf bytes.Buffer
+	if err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, state: st}, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	bookmark := findBookmarkForChange(t, runner, changeID)
+	if st.LastSend == nil || len(st.LastSend.CreatedPRs) != 1 {
+		t.Fatalf("send should be recorded with its new PR: %+v", st.LastSend)
+	}
+
+	buf.Reset()
+	if err := executeUndo(runner, mock, st, false, &buf); err != nil {
+		t.Fatalf("undo failed: %v\n%s", err, buf.String())
+	}
+	if got := mock.PRs[1].State; got != "closed" {
+		t.Errorf("PR #1 state = %q, want closed", got)
+	}
+	if got := remoteBranch(t, remoteDir, bookmark); got != "" {
+		t.Errorf("remote branch %s still exists at %s", bookmark, got)
+	}
+	if out := jjRun(t, repoDir, "bookmark", "list", bookmark); strings.TrimSpace(out) != "" {
+		t.Errorf("local bookmark %s still exists:\n%s", bookmark, out)
+	}
+	if st.LastSend != nil {
+		t.Error("undo should clear the send record")
+	}
+
+	buf.Reset()
+	if err := executeUndo(runner, mock, st, false, &buf); err == nil {
+		t.Error("a second undo should fail without a record")
+	}
+}
+
+func TestIntegration_UndoUpdatedPR(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, remoteDir := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+	st, err := state.Load(state.Path(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	changeID := getChangeID(t, repoDir, "@-")
+	var buf bytes.Buffer
+	opts := sendOpts{base: "main", remote: "origin", revsets: []string{"@-"}, state: st}
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("send failed: %v\n%s", err, buf.String())
+	}
+	bookmark := findBookmarkForChange(t, runner, changeID)
+	first := remoteBranch(t, remoteDir, bookmark)
+
+	editFile(t, repoDir, changeID, "a.go", "package a // v2")
+	buf.Reset()
+	if err := executeSend(runner, mock, opts, &buf); err != nil {
+		t.Fatalf("second send failed: %v\n%s", err, buf.String())
+	}
+	if remoteBranch(t, remoteDir, bookmark) == first {
+		t.Fatal("second send should have moved the branch")
+	}
+
+	buf.Reset()
+	if err := executeUndo(runner, mock, st, false, &buf); err != nil {
+		t.Fatalf("undo failed: %v\n%s", err, buf.String())
+	}
+	if got := remoteBranch(t, remoteDir, bookmark); got != first {
+		t.Errorf("remote branch at %s, want %s\n%s", got, first, buf.String())
+	}
+	if mock.PRs[1].State == "closed" {
+		t.Error("undo must not close a PR the undone send did not open")
+	}
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestNewSendRecord(t *testing.T) {
+	states := []changeState{
+		{change: &jj.Change{CommitID: "new1"}, bookmark: jj.ChangeBookmark{Bookmark: "jip/a"}},
+		{change: &jj.Change{CommitID: "same"}, bookmark: jj.ChangeBookmark{Bookmark: "jip/b"}},
+	}
+	bookmarks := map[string]*jj.BookmarkInfo{
+		"jip/a": {Name: "jip/a", Target: "new1", Remotes: map[string]jj.RemoteBookmarkState{"origin": {Target: "old1"}}},
+		"jip/b": {Name: "jip/b", Target: "same", Remotes: map[string]jj.RemoteBookmarkState{"origin": {Target: "same"}}},
+	}
+	opts := sendOpts{remote: "origin", upstream: "upstream"}
+
+	rec := newSendRecord(states, bookmarks, opts)
+	if rec == nil {
+		t.Fatal("expected a record when a bookmark moves")
+	}
+	if rec.Remote != "origin" || rec.Upstream != "upstream" {
+		t.Errorf("remote/upstream = %q/%q", rec.Remote, rec.Upstream)
+	}
+	if len(rec.Bookmarks) != 2 {
+		t.Fatalf("got %d bookmarks, want 2", len(rec.Bookmarks))
+	}
+	if m := rec.Bookmarks[0]; m.PreviousLocal != "new1" || m.PreviousRemote != "old1" || m.Pushed != "new1" {
+		t.Errorf("jip/a move = %+v", m)
+	}
+
+	if rec := newSendRecord(states[1:], bookmarks, opts); rec != nil {
+		t.Errorf("expected no record when nothing moves, got %+v", rec)
+	}
+
+	// A bookmark that only exists locally (new PR) has no previous remote.
+	delete(bookmarks, "jip/a")
+	rec = newSendRecord(states[:1], bookmarks, opts)
+	if rec == nil || rec.Bookmarks[0].PreviousRemote != "" || rec.Bookmarks[0].PreviousLocal != "" {
+		t.Errorf("new bookmark move = %+v", rec)
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/omarkohl/jip/internal/auth"
+	"github.com/omarkohl/jip/internal/update"
+	"github.com/spf13/cobra"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade jip to the latest release",
+	Long: `Upgrade checks GitHub for the latest jip release, downloads the build for
+this platform, verifies it against the release's SHA-256 checksums and
+replaces the running executable with it. Releases are not signed, so the
+checksums catch corrupted downloads, not a compromised release.
+
+--channel=prerelease also considers release candidates and betas.`,
+	Args: cobra.NoArgs,
+	RunE: runUpgrade,
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCmd)
+	upgradeCmd.Flags().String("channel", update.ChannelStable, "Release channel: stable or prerelease")
+	upgradeCmd.Flags().Bool("check", false, "Only report whether a newer release exists")
+	upgradeCmd.Flags().Bool("force", false, "Install the latest release even if it is not newer")
+
+	_ = upgradeCmd.RegisterFlagCompletionFunc("channel", cobra.FixedCompletions(
+		[]string{update.ChannelStable, update.ChannelPrerelease}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	channel, _ := cmd.Flags().GetString("channel")
+	check, _ := cmd.Flags().GetBool("check")
+	force, _ := cmd.Flags().GetBool("force")
+	w := cmd.OutOrStdout()
+
+	token, _ := auth.ResolveToken(defaultHost)
+	checker := update.NewChecker(token, "", 0)
+	rel, err := checker.Latest(cmd.Context(), channel)
+	if err != nil {
+		return err
+	}
+
+	current := buildVersion()
+	if update.Compare(rel.Version, current) <= 0 && !force {
+		_, _ = fmt.Fprintf(w, "jip %s is up to date (latest %s release: %s)\n", current, channel, rel.Version)
+		return nil
+	}
+	_, _ = fmt.Fprintf(w, "jip %s is available (you have %s)  %s\n", rel.Version, current, rel.URL)
+	if check {
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locating the jip executable: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("locating the jip executable: %w", err)
+	}
+	_, _ = fmt.Fprintf(w, "Installing %s to %s...\n", rel.Version, exe)
+	if err := checker.Install(cmd.Context(), rel, exe); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(w, "Upgraded jip %s → %s\n", current, rel.Version)
+	return nil
+}
+
+// updateNoticeTimeout bounds the weekly release check, which runs after the
+// command's own work and must not hold up the prompt.
+const updateNoticeTimeout = 3 * time.Second
+
+// printUpdateNotice prints the weekly "new version available" note to stderr.
+// It stays quiet for development builds, in CI, when JIP_NO_UPDATE_NOTIFIER
+// is set, and for commands where the note would be noise.
+func printUpdateNotice(cmd *cobra.Command) {
+	if Version == "dev" || os.Getenv("CI") != "" || os.Getenv("JIP_NO_UPDATE_NOTIFIER") != "" {
+		return
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case upgradeCmd.Name(), "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return
+		}
+	}
+	path, err := update.NoticePath()
+	if err != nil {
+		return
+	}
+	checker := update.NewChecker("", "", updateNoticeTimeout)
+	token := func() string {
+		token, _ := auth.ResolveToken(defaultHost)
+		return token
+	}
+	if note := checker.Notice(cmd.Context(), buildVersion(), path, time.Now(), token); note != "" {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "\n%s\n", note)
+	}
+}
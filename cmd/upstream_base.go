@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// upstreamBase checks the base branch of a fork workflow, where changes are
+// pushed to remote and PRs opened on upstream. Origin's copy of the branch
+// is often stale: when base resolves to it (as trunk() usually does) rather
+// than to the branch on upstream, it returns the upstream branch as the
+// base, so that stacks are resolved and rebased against what the PRs will
+// target. It warns when the two copies of the branch have diverged. Any
+// failure to tell leaves base as it is.
+func upstreamBase(runner jj.Runner, base, remote, upstream string, w io.Writer) string {
+	if upstream == "" || upstream == remote {
+		return base
+	}
+	data, err := runner.BookmarkList()
+	if err != nil {
+		return base
+	}
+	bookmarks, err := jj.ParseBookmarkList(data)
+	if err != nil {
+		return base
+	}
+	branch, err := jj.ResolveBaseBranch(runner, base, bookmarks, upstream)
+	if err != nil {
+		return base
+	}
+	var up, own jj.RemoteBookmarkState
+	var onUp, onOwn bool
+	for _, b := range bookmarks {
+		if b.Name == branch {
+			up, onUp = b.Remotes[upstream]
+			own, onOwn = b.Remotes[remote]
+		}
+	}
+	if !onUp || !onOwn || up.Target == own.Target {
+		return base
+	}
+
+	behind, err := isAncestor(runner, own.Target, up.Target)
+	if err != nil {
+		return base
+	}
+	if !behind {
+		if ahead, err := isAncestor(runner, up.Target, own.Target); err == nil && !ahead {
+			_, _ = fmt.Fprintf(w, "warning: %s@%s has diverged from %s@%s — PRs target %s on %s, update %s@%s if your stacks are based on it\n",
+				branch, remote, branch, upstream, branch, upstream, branch, remote)
+		}
+	}
+
+	at, err := resolveCommit(runner, base)
+	if err != nil || at != own.Target {
+		return base
+	}
+	_, _ = fmt.Fprintf(w, "Base %s is %s@%s, which differs from %s@%s; using %s@%s.\n",
+		base, branch, remote, branch, upstream, branch, upstream)
+	return fmt.Sprintf("remote_bookmarks(exact:%q, exact:%q)", branch, upstream)
+}
+
+// isAncestor reports whether commit a is an ancestor of (or the same as)
+// commit b.
+func isAncestor(runner jj.Runner, a, b string) (bool, error) {
+	out, err := runner.Log(fmt.Sprintf("%s & ::%s", a, b))
+	if err != nil {
+		return false, err
+	}
+	changes, err := jj.ParseChanges(out)
+	if err != nil {
+		return false, err
+	}
+	return len(changes) > 0, nil
+}
+
+// resolveCommit returns the ID of the single commit revset resolves to.
+func resolveCommit(runner jj.Runner, revset string) (string, error) {
+	out, err := runner.Log(revset)
+	if err != nil {
+		return "", err
+	}
+	changes, err := jj.ParseChanges(out)
+	if err != nil {
+		return "", err
+	}
+	if len(changes) != 1 {
+		return "", fmt.Errorf("%q resolved to %d commits, expected 1", revset, len(changes))
+	}
+	return changes[0].CommitID, nil
+}
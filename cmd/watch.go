@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/omarkohl/jip/internal/config"
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/pkg/jj"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [revsets...]",
+	Short: "Follow the PRs of a stack and report what happens to them",
+	Long: `Watch polls the open PRs of the given stacks every --interval and prints a
+line for each thing that happens to them: a PR merged or closed, its CI
+checks passing or failing, reviewers requesting changes or review threads
+left unresolved. With --notify, the same lines also show as desktop
+notifications (notify-send on Linux, osascript on macOS).
+
+With --rebase, the stacks are rebased onto the base branch, like jip rebase
+does, whenever one of their PRs merges, so the rest of the stack is ready to
+send.
+
+Watch stops on Ctrl-C, or once none of the PRs is open any more.
+
+Default revset is @- (the stack up to the last committed change).`,
+	RunE:              runWatch,
+	ValidArgsFunction: completeJJRevsets,
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().StringP("base", "b", "trunk()", "Base branch (defaults to the repo's trunk branch, usually main)")
+	watchCmd.Flags().String("remote", "origin", "Push remote name")
+	watchCmd.Flags().StringP("upstream", "u", "", "Upstream remote name or URL (where PRs are opened)")
+	watchCmd.Flags().Duration("interval", 30*time.Second, "Time between two looks at the PRs")
+	watchCmd.Flags().Bool("notify", false, "Also show what happens as desktop notifications")
+	watchCmd.Flags().Bool("rebase", false, "Rebase the stacks onto the base branch when one of their PRs merges")
+	addNoLockFlag(watchCmd)
+
+	_ = watchCmd.RegisterFlagCompletionFunc("base", completeJJBookmarks)
+	_ = watchCmd.RegisterFlagCompletionFunc("remote", completeRemotes)
+	_ = watchCmd.RegisterFlagCompletionFunc("upstream", completeRemotes)
+}
+
+// watchOpts holds configuration for watch.
+type watchOpts struct {
+	interval time.Duration
+	notify   func(title, message string) error // nil for no desktop notifications
+	rebase   func() error                      // rebases the stacks; nil for no rebase on merge
+	// wait waits d before the next look at the PRs. It returns false when
+	// watching should stop instead, e.g. on Ctrl-C.
+	wait func(d time.Duration) bool
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	runner, repoRoot, err := workspaceRunner()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := applySendConfig(cmd.Flags(), cfg); err != nil {
+		return err
+	}
+
+	base, _ := cmd.Flags().GetString("base")
+	remote, _ := cmd.Flags().GetString("remote")
+	upstream, _ := cmd.Flags().GetString("upstream")
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if interval <= 0 {
+		return fmt.Errorf("invalid --interval %s: it must be positive", interval)
+	}
+	revsets := args
+	if len(revsets) == 0 {
+		revsets = []string{"@-"}
+	}
+	w := cmd.OutOrStdout()
+
+	rc, err := resolveRepoContext(runner, remote, upstream, cfg[forgeConfigKey], w)
+	if err != nil {
+		return err
+	}
+	namer, err := configBookmarkNamer(cfg, rc.client.GetAuthenticatedUser)
+	if err != nil {
+		return err
+	}
+	opts := watchOpts{
+		interval: interval,
+		wait: func(d time.Duration) bool {
+			select {
+			case <-runContext.Done():
+				return false
+			case <-time.After(d):
+				return true
+			}
+		},
+	}
+	if notify, _ := cmd.Flags().GetBool("notify"); notify {
+		opts.notify = desktopNotify
+	}
+	if rebase, _ := cmd.Flags().GetBool("rebase"); rebase {
+		fetch := []string{remote}
+		if rc.upstreamRemote != "" && rc.upstreamRemote != remote {
+			fetch = append(fetch, rc.upstreamRemote)
+		}
+		opts.rebase = func() error {
+			release, err := lockRepo(cmd, repoRoot, w)
+			if err != nil {
+				return err
+			}
+			defer release()
+			_, err = executeRebase(runner, rebaseOpts{base: base, remote: remote, upstream: rc.upstreamRemote, remotes: fetch, revsets: revsets, namer: namer}, w)
+			return err
+		}
+	}
+
+	stackPRs := func() ([]int, error) {
+		_, _ = fmt.Fprintf(w, "Fetching %s...\n", remote)
+		if err := runner.GitFetch(remote); err != nil {
+			_, _ = fmt.Fprintf(w, "warning: could not fetch %s, continuing with local state: %v\n", remote, err)
+		}
+		return openStackPRs(runner, rc.client, namer, base, remote, revsets)
+	}
+	return executeWatch(rc.client, stackPRs, opts, w)
+}
+
+// openStackPRs returns the numbers of the open PRs of the stacks of revsets.
+func openStackPRs(runner jj.Runner, client forge.Service, namer *jj.BookmarkNamer, base, remote string, revsets []string) ([]int, error) {
+	dags, err := jj.ResolveStacks(runner, revsets, base)
+	if err != nil {
+		return nil, fmt.Errorf("resolving stacks: %w", err)
+	}
+	var numbers []int
+	for _, dag := range dags {
+		entries, err := findStackPRs(runner, client, namer, dag, remote)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.pr != nil {
+				numbers = append(numbers, e.pr.Number)
+			}
+		}
+	}
+	return numbers, nil
+}
+
+// prSnapshot is what watch knows about a PR at one look.
+type prSnapshot struct {
+	title   string
+	state   string // OPEN, MERGED or CLOSED
+	checks  string // see forge.Service.PRChecks; "" for none
+	reviews string // see reviewsLabel
+}
+
+// executeWatch watches the PRs stackPRs returns until none of them is open
+// or opts.wait says to stop. stackPRs is asked again after a rebase.
+func executeWatch(client forge.Service, stackPRs func() ([]int, error), opts watchOpts, w io.Writer) error {
+	numbers, err := stackPRs()
+	if err != nil {
+		return err
+	}
+	if len(numbers) == 0 {
+		_, _ = fmt.Fprintln(w, "No open PRs to watch.")
+		return nil
+	}
+	_, _ = fmt.Fprintf(w, "Watching %d PR(s) every %s (Ctrl-C to stop).\n", len(numbers), opts.interval)
+
+	var prev map[int]prSnapshot
+	for {
+		cur, err := lookPRs(client, numbers)
+		if err != nil {
+			// A failed look is retried at the next one.
+			_, _ = fmt.Fprintf(w, "warning: could not look up the PRs: %v\n", err)
+		} else {
+			events := watchEvents(numbers, prev, cur)
+			for _, e := range events {
+				_, _ = fmt.Fprintf(w, "%s  %s\n", time.Now().Format(time.TimeOnly), e)
+				if opts.notify != nil {
+					if err := opts.notify("jip watch", e); err != nil {
+						_, _ = fmt.Fprintf(w, "warning: could not show a desktop notification, no longer trying: %v\n", err)
+						opts.notify = nil
+					}
+				}
+			}
+			merged := false
+			for _, n := range numbers {
+				if cur[n].state == "MERGED" && prev != nil && prev[n].state != "MERGED" {
+					merged = true
+				}
+			}
+			if merged && opts.rebase != nil {
+				if err := opts.rebase(); err != nil {
+					_, _ = fmt.Fprintf(w, "warning: could not rebase: %v\n", err)
+				} else if more, err := stackPRs(); err == nil {
+					numbers = appendMissing(numbers, more)
+				}
+			}
+			if !anyOpen(cur) {
+				_, _ = fmt.Fprintln(w, "None of the PRs is open any more.")
+				return nil
+			}
+			prev = cur
+		}
+		if !opts.wait(opts.interval) {
+			return nil
+		}
+	}
+}
+
+// lookPRs takes a snapshot of each PR of numbers.
+func lookPRs(client forge.Service, numbers []int) (map[int]prSnapshot, error) {
+	snaps := make(map[int]prSnapshot, len(numbers))
+	var open []int
+	for _, n := range numbers {
+		pr, err := client.GetPR(n)
+		if err != nil {
+			return nil, err
+		}
+		snaps[n] = prSnapshot{title: pr.Title, state: pr.State}
+		if pr.State == "OPEN" {
+			open = append(open, n)
+		}
+	}
+	if len(open) == 0 {
+		return snaps, nil
+	}
+	checks, err := client.PRChecks(open)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := client.PRReviews(open)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range open {
+		s := snaps[n]
+		s.checks, s.reviews = checks[n], reviewsLabel(reviews[n])
+		snaps[n] = s
+	}
+	return snaps, nil
+}
+
+// watchEvents describes what happened to the PRs of numbers between the
+// snapshots prev and cur. Without prev, it describes the PRs as they are.
+func watchEvents(numbers []int, prev, cur map[int]prSnapshot) []string {
+	var events []string
+	for _, n := range numbers {
+		c, ok := cur[n]
+		if !ok {
+			continue
+		}
+		p, seen := prev[n]
+		var what []string
+		switch {
+		case !seen:
+			what = append(what, fmt.Sprintf("%s, checks %s", stateLabel(c.state), checksLabel(c.checks)))
+			if c.reviews != "" {
+				what = append(what, "needs attention: "+c.reviews)
+			}
+		default:
+			if c.state != p.state {
+				what = append(what, stateLabel(c.state))
+			}
+			if c.state == "OPEN" && c.checks != p.checks {
+				what = append(what, "checks "+checksLabel(c.checks))
+			}
+			if c.state == "OPEN" && c.reviews != p.reviews {
+				if c.reviews == "" {
+					what = append(what, "review feedback addressed")
+				} else {
+					what = append(what, "needs attention: "+c.reviews)
+				}
+			}
+		}
+		for _, x := range what {
+			events = append(events, fmt.Sprintf("#%d %s: %s", n, c.title, x))
+		}
+	}
+	return events
+}
+
+// stateLabel renders a PR state for watch.
+func stateLabel(state string) string {
+	switch state {
+	case "OPEN":
+		return "open"
+	case "MERGED":
+		return "merged"
+	case "CLOSED":
+		return "closed"
+	}
+	return state
+}
+
+func anyOpen(snaps map[int]prSnapshot) bool {
+	for _, s := range snaps {
+		if s.state == "OPEN" {
+			return true
+		}
+	}
+	return false
+}
+
+// appendMissing appends the numbers of more that numbers lacks.
+func appendMissing(numbers, more []int) []int {
+	seen := make(map[int]bool, len(numbers))
+	for _, n := range numbers {
+		seen[n] = true
+	}
+	for _, n := range more {
+		if !seen[n] {
+			numbers = append(numbers, n)
+			seen[n] = true
+		}
+	}
+	return numbers
+}
+
+// desktopNotify shows a desktop notification: with osascript on macOS,
+// notify-send elsewhere.
+func desktopNotify(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", fmt.Sprintf("display notification %q with title %q", message, title))
+	case "windows":
+		return fmt.Errorf("desktop notifications are not supported on Windows")
+	default:
+		cmd = exec.Command("notify-send", title, message)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", cmd.Args[0], err, out)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+)
+
+func TestWatchEvents(t *testing.T) {
+	prev := map[int]prSnapshot{
+		1: {title: "feat: A", state: "OPEN", checks: "pending"},
+		2: {title: "feat: B", state: "OPEN", checks: "pending", reviews: "1 unresolved thread(s)"},
+	}
+	cur := map[int]prSnapshot{
+		1: {title: "feat: A", state: "MERGED"},
+		2: {title: "feat: B", state: "OPEN", checks: "fail"},
+	}
+	got := watchEvents([]int{1, 2}, prev, cur)
+	want := []string{
+		"#1 feat: A: merged",
+		"#2 feat: B: checks fail",
+		"#2 feat: B: review feedback addressed",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+
+	if got := watchEvents([]int{1, 2}, cur, cur); len(got) != 0 {
+		t.Errorf("events without changes = %q", got)
+	}
+
+	got = watchEvents([]int{2}, nil, prev)
+	want = []string{"#2 feat: B: open, checks pending", "#2 feat: B: needs attention: 1 unresolved thread(s)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("first events = %q, want %q", got, want)
+	}
+}
+
+func TestExecuteWatch(t *testing.T) {
+	fake := forgetest.New("owner", "repo")
+	fake.PRs[1] = &forge.PRInfo{Number: 1, Title: "feat: A", State: "OPEN"}
+	fake.PRs[2] = &forge.PRInfo{Number: 2, Title: "feat: B", State: "OPEN"}
+	fake.Checks[1] = "pending"
+
+	// Each wait lets something happen on the forge.
+	steps := []func(){
+		func() { fake.Checks[1] = "pass" },
+		func() { fake.PRs[1].State = "MERGED" },
+		func() { fake.PRs[2].State = "MERGED" },
+	}
+	var notified []string
+	rebases := 0
+	opts := watchOpts{
+		interval: time.Minute,
+		notify: func(_, message string) error {
+			notified = append(notified, message)
+			return nil
+		},
+		rebase: func() error {
+			rebases++
+			return nil
+		},
+		wait: func(time.Duration) bool {
+			if len(steps) == 0 {
+				t.Fatal("watch did not stop once no PR was open")
+			}
+			steps[0]()
+			steps = steps[1:]
+			return true
+		},
+	}
+	var buf bytes.Buffer
+	stackPRs := func() ([]int, error) { return []int{1, 2}, nil }
+	if err := executeWatch(fake, stackPRs, opts, &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	t.Logf("Output:\n%s", out)
+
+	for _, want := range []string{"#1 feat: A: checks pass", "#1 feat: A: merged", "#2 feat: B: merged", "None of the PRs is open any more."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q", want)
+		}
+	}
+	if rebases != 2 {
+		t.Errorf("rebased %d times, want once per merge", rebases)
+	}
+	if len(notified) != 5 {
+		t.Errorf("notifications = %q, want one per event", notified)
+	}
+}
+
+func TestExecuteWatch_StopsOnWait(t *testing.T) {
+	fake := forgetest.New("owner", "repo")
+	fake.PRs[1] = &forge.PRInfo{Number: 1, Title: "feat: A", State: "OPEN"}
+	opts := watchOpts{interval: time.Minute, wait: func(time.Duration) bool { return false }}
+	var buf bytes.Buffer
+	if err := executeWatch(fake, func() ([]int, error) { return []int{1}, nil }, opts, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "#1 feat: A: open, checks none") {
+		t.Errorf("output lacks the first look:\n%s", buf.String())
+	}
+}
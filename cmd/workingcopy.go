@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// resolveSendStacks resolves the stacks of opts.revsets, each base's
+// against that base, and returns them with the name of each change's base by
+// change ID. baseRemote holds the branches of the other bases.
+func resolveSendStacks(runner jj.Runner, opts sendOpts, baseRemote string) ([]*jj.ChangeDAG, map[string]string, error) {
+	var dags []*jj.ChangeDAG
+	baseOf := make(map[string]string) // change ID → name of its base
+	for _, b := range opts.bases() {
+		more, err := jj.ResolveStacks(runner, b.revsets, b.revset(baseRemote))
+		if err != nil {
+			return nil, nil, fmt.Errorf("resolving stacks: %w", err)
+		}
+		for _, dag := range more {
+			for _, c := range dag.Changes {
+				if other, ok := baseOf[c.ChangeID]; ok && other != b.name() {
+					return nil, nil, fmt.Errorf("change %s is in the stacks of both %s and %s — send each stack to one base", c.Short(), other, b.name())
+				}
+				baseOf[c.ChangeID] = b.name()
+			}
+		}
+		dags = append(dags, more...)
+	}
+	return dags, baseOf, nil
+}
+
+// undescribedWorkingCopy returns the working-copy change among dags when it
+// has file changes but no description, or nil. An undescribed merge is left
+// alone: it is a megamerge, which send splits into its stacks.
+func undescribedWorkingCopy(dags []*jj.ChangeDAG) *jj.Change {
+	for _, dag := range dags {
+		for _, c := range dag.Changes {
+			if c.WorkingCopy && !c.Empty && len(c.ParentIDs) < 2 && strings.TrimSpace(c.Description) == "" {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// emptyDescriptionSkip explains why a change without a description is not
+// sent. An empty working copy, as left by jj commit or jj new, is expected
+// in `jip send @` and not a failure.
+func emptyDescriptionSkip(c *jj.Change) skipReason {
+	switch {
+	case c.WorkingCopy && c.Empty:
+		return skipReason{reason: "empty working copy (@) — nothing to send", benign: true}
+	case c.WorkingCopy:
+		return skipReason{reason: "working copy (@) has no description — describe it with jj describe or jip send --describe to send it"}
+	}
+	return skipReason{reason: "change has no description — add a commit message before sending"}
+}
+
+// stdinIsTerminal reports whether standard input is an interactive terminal.
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// promptDescription returns an askDescription function reading the
+// working-copy description from reader. An empty answer leaves it
+// undescribed.
+func promptDescription(reader *bufio.Reader, w io.Writer) func(*jj.Change) (string, error) {
+	return func(c *jj.Change) (string, error) {
+		_, _ = fmt.Fprintf(w, "The working copy (@) %s has no description. Describe it to send it, or press Enter to leave it out:\n> ", c.Short())
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("reading the description: %w", err)
+		}
+		return strings.TrimSpace(line), nil
+	}
+}
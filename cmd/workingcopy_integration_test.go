@@ -0,0 +1,105 @@
+//go:build integration
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge/forgetest"
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestIntegration_SendWorkingCopyEmpty(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+
+	// @ is the empty change jj commit left behind.
+	var buf bytes.Buffer
+	err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@"}}, &buf)
+	if err != nil {
+		t.Fatalf("send @ failed: %v\nOutput:\n%s", err, buf.String())
+	}
+	if len(mock.PRs) != 1 {
+		t.Errorf("expected 1 PR, got %d", len(mock.PRs))
+	}
+	if !strings.Contains(buf.String(), "empty working copy (@)") {
+		t.Errorf("output should explain the skipped working copy:\n%s", buf.String())
+	}
+}
+
+func TestIntegration_SendWorkingCopyUndescribed(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	writeAndCommit(t, repoDir, "a.go", "package a", "feat: add A")
+	if err := os.WriteFile(filepath.Join(repoDir, "b.go"), []byte("package b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@"}}, &buf)
+	if err == nil {
+		t.Fatalf("expected an error for the undescribed working copy\nOutput:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "working copy (@) has no description") {
+		t.Errorf("output should explain how to describe the working copy:\n%s", buf.String())
+	}
+
+	// Answering the prompt describes it and sends it.
+	asked := 0
+	buf.Reset()
+	err = executeSend(runner, mock, sendOpts{
+		base:    "main",
+		remote:  "origin",
+		revsets: []string{"@"},
+		askDescription: func(c *jj.Change) (string, error) {
+			asked++
+			return "feat: add B", nil
+		},
+	}, &buf)
+	if err != nil {
+		t.Fatalf("send @ failed: %v\nOutput:\n%s", err, buf.String())
+	}
+	if asked != 1 {
+		t.Errorf("asked for a description %d times, want 1", asked)
+	}
+	if got := strings.TrimSpace(jjRun(t, repoDir, "log", "-r", "@", "--no-graph", "-T", "description")); got != "feat: add B" {
+		t.Errorf("working copy description = %q, want %q", got, "feat: add B")
+	}
+	if len(mock.PRs) != 2 {
+		t.Errorf("expected 2 PRs, got %d\nOutput:\n%s", len(mock.PRs), buf.String())
+	}
+}
+
+func TestIntegration_SendWorkingCopyDescribeFlag(t *testing.T) {
+	checkJJ(t)
+
+	mock := forgetest.New("testowner", "testrepo")
+	repoDir, _ := initTestRepoWithRemote(t)
+	runner := jj.NewRunner(repoDir)
+
+	if err := os.WriteFile(filepath.Join(repoDir, "a.go"), []byte("package a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err := executeSend(runner, mock, sendOpts{base: "main", remote: "origin", revsets: []string{"@"}, describe: "feat: add A"}, &buf)
+	if err != nil {
+		t.Fatalf("send @ --describe failed: %v\nOutput:\n%s", err, buf.String())
+	}
+	if len(mock.PRs) != 1 || mock.PRs[1].Title != "feat: add A" {
+		t.Errorf("expected PR #1 titled %q, got %v", "feat: add A", mock.PRs)
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+func TestEmptyDescriptionSkip(t *testing.T) {
+	if r := emptyDescriptionSkip(&jj.Change{WorkingCopy: true, Empty: true}); !r.benign {
+		t.Errorf("an empty working copy should be a benign skip: %+v", r)
+	}
+	if r := emptyDescriptionSkip(&jj.Change{WorkingCopy: true}); r.benign || !strings.Contains(r.reason, "--describe") {
+		t.Errorf("an undescribed working copy should fail with a hint: %+v", r)
+	}
+	if r := emptyDescriptionSkip(&jj.Change{}); r.benign || strings.Contains(r.reason, "working copy") {
+		t.Errorf("unexpected skip for another change: %+v", r)
+	}
+}
+
+func TestPromptDescription(t *testing.T) {
+	var out bytes.Buffer
+	ask := promptDescription(bufio.NewReader(strings.NewReader("  feat: add B \n")), &out)
+	got, err := ask(&jj.Change{ChangeID: "kxqpmvzy"})
+	if err != nil || got != "feat: add B" {
+		t.Errorf("ask = %q, %v; want %q", got, err, "feat: add B")
+	}
+	if !strings.Contains(out.String(), "kxqpmvzy has no description") {
+		t.Errorf("prompt does not name the change: %q", out.String())
+	}
+
+	// End of input leaves the working copy undescribed.
+	got, err = promptDescription(bufio.NewReader(strings.NewReader("")), &out)(&jj.Change{})
+	if err != nil || got != "" {
+		t.Errorf("ask at EOF = %q, %v; want empty", got, err)
+	}
+}
@@ -2,8 +2,12 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/omarkohl/jip/internal/encrypt"
 )
 
 // HostConfig holds auth credentials for a single GitHub host.
@@ -41,6 +45,9 @@ func LoadConfig() (Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if data, err = encrypt.Open(data); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
 
 	var cfg Config
 	if err := json.Unmarshal(data, &cfg); err != nil {
@@ -49,15 +56,38 @@ func LoadConfig() (Config, error) {
 	return cfg, nil
 }
 
-// SaveToken stores an OAuth token for the given host.
-func SaveToken(host, token string) error {
+// saveFileToken stores a token for host in the config file.
+func saveFileToken(host, token string) error {
 	cfg, err := LoadConfig()
+	if errors.Is(err, encrypt.ErrDecrypt) {
+		return err // overwriting would lose the other hosts' tokens
+	}
 	if err != nil {
 		cfg = make(Config)
 	}
-
 	cfg[host] = HostConfig{OAuthToken: token}
+	return saveConfig(cfg)
+}
+
+// removeFileToken removes the token for host from the config file. It
+// reports whether there was one.
+func removeFileToken(host string) (bool, error) {
+	cfg, err := LoadConfig()
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, ok := cfg[host]; !ok {
+		return false, nil
+	}
+	delete(cfg, host)
+	return true, saveConfig(cfg)
+}
 
+// saveConfig writes cfg to the config file.
+func saveConfig(cfg Config) error {
 	path, err := configPath()
 	if err != nil {
 		return err
@@ -71,6 +101,9 @@ func SaveToken(host, token string) error {
 	if err != nil {
 		return err
 	}
+	if data, err = encrypt.Seal(data); err != nil {
+		return err
+	}
 
 	return os.WriteFile(path, data, 0o600)
 }
@@ -1,16 +1,31 @@
 package auth
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/omarkohl/jip/internal/encrypt"
+	"github.com/zalando/go-keyring"
 )
 
+// fileTokens makes the test store tokens in the config file, with a mocked
+// keyring.
+func fileTokens(t *testing.T) {
+	t.Helper()
+	keyring.MockInit()
+	fileOnly = true
+	t.Cleanup(func() { fileOnly = false })
+}
+
 func TestSaveAndLoadToken(t *testing.T) {
 	ConfigDir = t.TempDir()
 	defer func() { ConfigDir = "" }()
+	fileTokens(t)
 
-	if err := SaveToken("github.com", "ghp_testtoken123"); err != nil {
+	if _, err := SaveToken("github.com", "ghp_testtoken123"); err != nil {
 		t.Fatalf("SaveToken: %v", err)
 	}
 
@@ -25,14 +40,50 @@ func TestSaveAndLoadToken(t *testing.T) {
 	}
 }
 
+func TestSaveTokenEncrypted(t *testing.T) {
+	ConfigDir = t.TempDir()
+	defer func() { ConfigDir = "" }()
+	fileTokens(t)
+	keyring.MockInit()
+	if err := encrypt.Configure(encrypt.Settings{Mode: encrypt.ModeKeyring}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = encrypt.Configure(encrypt.Settings{}) }()
+
+	if _, err := SaveToken("github.com", "ghp_testtoken123"); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(ConfigDir, "jip", "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !encrypt.IsEncrypted(data) || strings.Contains(string(data), "ghp_testtoken123") {
+		t.Fatalf("token written in plain text:\n%s", data)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if got := cfg["github.com"].OAuthToken; got != "ghp_testtoken123" {
+		t.Errorf("got token %q", got)
+	}
+
+	// Without the key, saving must not replace the other hosts' tokens.
+	_ = encrypt.Configure(encrypt.Settings{})
+	if _, err := SaveToken("ghe.example.com", "ghp_other"); !errors.Is(err, encrypt.ErrDecrypt) {
+		t.Errorf("SaveToken without the key: got %v, want ErrDecrypt", err)
+	}
+}
+
 func TestSaveTokenPreservesOtherHosts(t *testing.T) {
 	ConfigDir = t.TempDir()
 	defer func() { ConfigDir = "" }()
+	fileTokens(t)
 
-	if err := SaveToken("github.com", "token1"); err != nil {
+	if _, err := SaveToken("github.com", "token1"); err != nil {
 		t.Fatalf("SaveToken: %v", err)
 	}
-	if err := SaveToken("github.example.com", "token2"); err != nil {
+	if _, err := SaveToken("github.example.com", "token2"); err != nil {
 		t.Fatalf("SaveToken: %v", err)
 	}
 
@@ -52,6 +103,7 @@ func TestSaveTokenPreservesOtherHosts(t *testing.T) {
 func TestLoadConfigMissingFile(t *testing.T) {
 	ConfigDir = t.TempDir()
 	defer func() { ConfigDir = "" }()
+	fileTokens(t)
 
 	_, err := LoadConfig()
 	if err == nil {
@@ -62,6 +114,7 @@ func TestLoadConfigMissingFile(t *testing.T) {
 func TestLoadConfigInvalidJSON(t *testing.T) {
 	ConfigDir = t.TempDir()
 	defer func() { ConfigDir = "" }()
+	fileTokens(t)
 
 	dir := filepath.Join(ConfigDir, "jip")
 	if err := os.MkdirAll(dir, 0o700); err != nil {
@@ -80,9 +133,10 @@ func TestLoadConfigInvalidJSON(t *testing.T) {
 func TestSaveTokenCreatesDirectory(t *testing.T) {
 	ConfigDir = t.TempDir()
 	defer func() { ConfigDir = "" }()
+	fileTokens(t)
 
 	// The jip subdirectory doesn't exist yet
-	err := SaveToken("github.com", "tok")
+	_, err := SaveToken("github.com", "tok")
 	if err != nil {
 		t.Fatalf("SaveToken should create directories: %v", err)
 	}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+// Where a token jip stored itself is kept, as reported by the Resolve
+// functions and SaveToken.
+const (
+	SourceKeyring = "OS keyring"
+	SourceFile    = "jip config"
+)
+
+// Tokens live in the OS keyring (macOS Keychain, Windows Credential Manager,
+// the Secret Service on Linux) under this service, one entry per host. The
+// config file is the fallback where there is no keyring, e.g. on a server
+// without a desktop session.
+const keyringService = "jip"
+
+func keyringUser(host string) string { return "token:" + host }
+
+// fileOnly makes tests store tokens in the config file only, while the
+// mocked keyring still serves the file encryption key.
+var fileOnly bool
+
+// SaveToken stores a token for host: in the OS keyring when there is one,
+// otherwise in the config file. It returns where the token went. A token
+// for host left in the config file is removed once the keyring has it.
+func SaveToken(host, token string) (string, error) {
+	if err := keyringSet(host, token); err != nil {
+		if err := saveFileToken(host, token); err != nil {
+			return "", err
+		}
+		return SourceFile, nil
+	}
+	if _, err := removeFileToken(host); err != nil {
+		return SourceKeyring, fmt.Errorf("token saved in the OS keyring, but removing the old one from the config file failed: %w", err)
+	}
+	return SourceKeyring, nil
+}
+
+// DeleteToken removes the token jip stored for host from the OS keyring and
+// the config file. It returns where a token was removed from; none when jip
+// stored no token for host.
+func DeleteToken(host string) ([]string, error) {
+	var removed []string
+	switch err := keyring.Delete(keyringService, keyringUser(host)); {
+	case err == nil:
+		removed = append(removed, SourceKeyring)
+	case errors.Is(err, keyring.ErrNotFound):
+	default:
+		// Without a keyring there is nothing to delete; a keyring that
+		// holds the token but refuses to delete it is an error.
+		if _, getErr := keyring.Get(keyringService, keyringUser(host)); getErr == nil {
+			return removed, fmt.Errorf("removing the token from the OS keyring: %w", err)
+		}
+	}
+	ok, err := removeFileToken(host)
+	if err != nil {
+		return removed, fmt.Errorf("removing the token from the config file: %w", err)
+	}
+	if ok {
+		removed = append(removed, SourceFile)
+	}
+	return removed, nil
+}
+
+// StoredToken returns the token jip stored for host, and where. A token
+// found in the config file is moved to the keyring when there is one, so
+// tokens saved by older versions of jip migrate on first use.
+func StoredToken(host string) (token, source string) {
+	if t, err := keyring.Get(keyringService, keyringUser(host)); err == nil && t != "" {
+		return t, SourceKeyring
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		return "", ""
+	}
+	hostCfg, ok := cfg[host]
+	if !ok || hostCfg.OAuthToken == "" {
+		return "", ""
+	}
+	if keyringSet(host, hostCfg.OAuthToken) == nil {
+		if _, err := removeFileToken(host); err == nil {
+			return hostCfg.OAuthToken, SourceKeyring
+		}
+	}
+	return hostCfg.OAuthToken, SourceFile
+}
+
+func keyringSet(host, token string) error {
+	if fileOnly {
+		return errors.New("keyring disabled")
+	}
+	return keyring.Set(keyringService, keyringUser(host), token)
+}
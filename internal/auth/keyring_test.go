@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestSaveTokenUsesKeyring(t *testing.T) {
+	ConfigDir = t.TempDir()
+	defer func() { ConfigDir = "" }()
+	keyring.MockInit()
+
+	where, err := SaveToken("github.com", "ghp_keyring")
+	if err != nil || where != SourceKeyring {
+		t.Fatalf("SaveToken = %q, %v; want the keyring", where, err)
+	}
+	if _, err := LoadConfig(); err == nil {
+		t.Error("the token must not be written to the config file")
+	}
+	if token, source := StoredToken("github.com"); token != "ghp_keyring" || source != SourceKeyring {
+		t.Errorf("StoredToken = %q from %q", token, source)
+	}
+}
+
+func TestSaveTokenFallsBackToFile(t *testing.T) {
+	ConfigDir = t.TempDir()
+	defer func() { ConfigDir = "" }()
+	keyring.MockInitWithError(errors.New("no secret service"))
+	defer keyring.MockInit()
+
+	where, err := SaveToken("github.com", "ghp_file")
+	if err != nil || where != SourceFile {
+		t.Fatalf("SaveToken = %q, %v; want the config file", where, err)
+	}
+	if token, source := StoredToken("github.com"); token != "ghp_file" || source != SourceFile {
+		t.Errorf("StoredToken = %q from %q", token, source)
+	}
+}
+
+func TestStoredTokenMigratesFileToken(t *testing.T) {
+	ConfigDir = t.TempDir()
+	defer func() { ConfigDir = "" }()
+	keyring.MockInit()
+	// Tokens saved by a jip without keyring support.
+	if err := saveConfig(Config{"github.com": {OAuthToken: "ghp_old"}, "ghe.example.com": {OAuthToken: "ghp_ghe"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if token, source := StoredToken("github.com"); token != "ghp_old" || source != SourceKeyring {
+		t.Errorf("StoredToken = %q from %q, want it moved to the keyring", token, source)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Config{"ghe.example.com": {OAuthToken: "ghp_ghe"}}); !reflect.DeepEqual(cfg, want) {
+		t.Errorf("config file = %v, want only the other host", cfg)
+	}
+	if got, _ := keyring.Get(keyringService, keyringUser("github.com")); got != "ghp_old" {
+		t.Errorf("keyring holds %q", got)
+	}
+}
+
+func TestDeleteToken(t *testing.T) {
+	ConfigDir = t.TempDir()
+	defer func() { ConfigDir = "" }()
+	keyring.MockInit()
+	if _, err := SaveToken("github.com", "ghp_keyring"); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveConfig(Config{"github.com": {OAuthToken: "ghp_stale"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := DeleteToken("github.com")
+	if err != nil {
+		t.Fatalf("DeleteToken: %v", err)
+	}
+	if want := []string{SourceKeyring, SourceFile}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed from %v, want %v", removed, want)
+	}
+	if token, _ := StoredToken("github.com"); token != "" {
+		t.Errorf("token %q left behind", token)
+	}
+
+	removed, err = DeleteToken("github.com")
+	if err != nil || len(removed) != 0 {
+		t.Errorf("second DeleteToken = %v, %v; want nothing removed", removed, err)
+	}
+}
@@ -1,11 +1,14 @@
 package auth
 
 import (
+	"os"
+
 	ghAuth "github.com/cli/go-gh/v2/pkg/auth"
 )
 
 // ResolveToken tries to find a GitHub token for the given host.
-// It checks in order: env vars (GH_TOKEN/GITHUB_TOKEN), gh CLI config, jip config.
+// It checks in order: env vars (GH_TOKEN/GITHUB_TOKEN), gh CLI config, the
+// token jip stored (OS keyring, then jip config).
 // Returns the token and a human-readable source description.
 func ResolveToken(host string) (token, source string) {
 	// 1. Environment variables and gh CLI config (go-gh handles both)
@@ -19,13 +22,43 @@ func ResolveToken(host string) (token, source string) {
 		}
 	}
 
-	// 2. jip's own config file
-	cfg, err := LoadConfig()
-	if err == nil {
-		if hostCfg, ok := cfg[host]; ok && hostCfg.OAuthToken != "" {
-			return hostCfg.OAuthToken, "jip config"
+	// 2. jip's own token, in the OS keyring or its config file
+	if token, source := StoredToken(host); token != "" {
+		return token, source
+	}
+
+	return "", ""
+}
+
+// ResolveGiteaToken tries to find a Gitea or Forgejo token for the given
+// host. It checks in order: env vars (GITEA_TOKEN/FORGEJO_TOKEN), the token
+// jip stored.
+func ResolveGiteaToken(host string) (token, source string) {
+	for _, name := range []string{"GITEA_TOKEN", "FORGEJO_TOKEN"} {
+		if t := os.Getenv(name); t != "" {
+			return t, name
 		}
 	}
+	if token, source := StoredToken(host); token != "" {
+		return token, source
+	}
+	return "", ""
+}
 
+// ResolveBitbucketToken tries to find Bitbucket Cloud credentials for the
+// given host. It checks in order: BITBUCKET_TOKEN (an access token),
+// BITBUCKET_USERNAME with BITBUCKET_APP_PASSWORD (returned as
+// "USERNAME:APP_PASSWORD"), the token jip stored.
+func ResolveBitbucketToken(host string) (token, source string) {
+	if t := os.Getenv("BITBUCKET_TOKEN"); t != "" {
+		return t, "BITBUCKET_TOKEN"
+	}
+	user, pass := os.Getenv("BITBUCKET_USERNAME"), os.Getenv("BITBUCKET_APP_PASSWORD")
+	if user != "" && pass != "" {
+		return user + ":" + pass, "BITBUCKET_USERNAME/BITBUCKET_APP_PASSWORD"
+	}
+	if token, source := StoredToken(host); token != "" {
+		return token, source
+	}
 	return "", ""
 }
@@ -51,8 +51,9 @@ func TestResolveTokenFromJipConfig(t *testing.T) {
 
 	ConfigDir = t.TempDir()
 	defer func() { ConfigDir = "" }()
+	fileTokens(t)
 
-	if err := SaveToken("github.com", "ghp_jip_token"); err != nil {
+	if _, err := SaveToken("github.com", "ghp_jip_token"); err != nil {
 		t.Fatalf("SaveToken: %v", err)
 	}
 
@@ -73,6 +74,7 @@ func TestResolveTokenReturnsEmptyWhenNothingConfigured(t *testing.T) {
 
 	ConfigDir = t.TempDir()
 	defer func() { ConfigDir = "" }()
+	fileTokens(t)
 
 	token, source := ResolveToken("github.com")
 	if token != "" {
@@ -0,0 +1,775 @@
+// Package bitbucket implements forge.Service for Bitbucket Cloud through its
+// 2.0 REST API.
+package bitbucket
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/retry"
+)
+
+// Host is the host of Bitbucket Cloud repositories.
+const Host = "bitbucket.org"
+
+// defaultAPIURL is the Bitbucket Cloud REST API.
+const defaultAPIURL = "https://api.bitbucket.org/2.0"
+
+// branchQueryBatchSize is the number of branches looked up per query. Every
+// branch adds a clause to the q filter, which is sent in the URL.
+const branchQueryBatchSize = 20
+
+var repoURLRe = regexp.MustCompile(`^(?:[a-z][a-z0-9+.-]*://)?(?:[^@/]+@)?bitbucket\.org[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// ParseRepoFromURL extracts the workspace and repository slug from a
+// Bitbucket Cloud remote URL (https://bitbucket.org/WORKSPACE/REPO.git,
+// https://USER@bitbucket.org/..., git@bitbucket.org:WORKSPACE/REPO.git or
+// ssh://git@bitbucket.org/...). Slugs are lowercase on Bitbucket, so both
+// are lowercased.
+func ParseRepoFromURL(remoteURL string) (workspace, slug string, err error) {
+	m := repoURLRe.FindStringSubmatch(strings.TrimSpace(remoteURL))
+	if m == nil {
+		return "", "", fmt.Errorf("cannot parse Bitbucket workspace/repository from URL: %s", remoteURL)
+	}
+	return strings.ToLower(m[1]), strings.ToLower(m[2]), nil
+}
+
+// Client talks to the Bitbucket Cloud REST API for one repository.
+type Client struct {
+	http      *http.Client
+	apiURL    string
+	workspace string
+	slug      string
+	username  string // set for app passwords and API tokens (basic auth)
+	secret    string
+	backoff   []retry.Option
+
+	// commentPRs remembers which pull request a comment returned by
+	// FindComment belongs to: Bitbucket addresses comments through their
+	// pull request, while forge.Service edits them by ID alone.
+	commentPRs map[int64]int
+}
+
+var _ forge.Service = (*Client)(nil)
+
+// NewClient creates a client for workspace/slug. token is a repository,
+// project or workspace access token, or "USERNAME:SECRET" for an app
+// password or API token.
+func NewClient(token, workspace, slug string) *Client {
+	c := &Client{
+		http:       &http.Client{Transport: forge.Transport()},
+		apiURL:     defaultAPIURL,
+		workspace:  workspace,
+		slug:       slug,
+		secret:     token,
+		commentPRs: make(map[int64]int),
+	}
+	if user, secret, ok := strings.Cut(token, ":"); ok {
+		c.username, c.secret = user, secret
+	}
+	return c
+}
+
+// Owner returns the repository's workspace.
+func (c *Client) Owner() string { return c.workspace }
+
+// Repo returns the repository slug.
+func (c *Client) Repo() string { return c.slug }
+
+// Links returns the web links of the repository.
+func (c *Client) Links() forge.Links { return forge.BitbucketLinks(c.workspace, c.slug) }
+
+// apiError is a non-2xx response.
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("Bitbucket API returned %d", e.Status)
+	}
+	return fmt.Sprintf("Bitbucket API returned %d: %s", e.Status, e.Message)
+}
+
+func isStatus(err error, statuses ...int) bool {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, s := range statuses {
+		if apiErr.Status == s {
+			return true
+		}
+	}
+	return false
+}
+
+// do sends a request to path (relative to the API URL, or an absolute
+// pagination URL) with in as the JSON body when non-nil, and decodes a
+// successful response into out when non-nil. Server errors are retried.
+func (c *Client) do(method, path string, query url.Values, in, out any) error {
+	u := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		u = c.apiURL + path
+	}
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("marshaling request: %w", err)
+		}
+	}
+
+	var status int
+	var raw []byte
+	err := retry.Do(func() error {
+		req, err := http.NewRequest(method, u, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		if c.username != "" {
+			req.SetBasicAuth(c.username, c.secret)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+c.secret)
+		}
+		req.Header.Set("Accept", "application/json")
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		start := time.Now()
+		resp, err := c.http.Do(req)
+		duration := time.Since(start).Round(time.Millisecond)
+		if err != nil {
+			slog.Info("bitbucket", "method", method, "path", req.URL.Path, "err", err, "duration", duration)
+			return err
+		}
+		slog.Info("bitbucket", "method", method, "path", req.URL.Path, "status", resp.StatusCode, "duration", duration)
+		raw, err = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		status = resp.StatusCode
+		// Retry on server errors (5xx); don't retry client errors (4xx).
+		if status >= 500 {
+			return &apiError{Status: status, Message: string(raw)}
+		}
+		return nil
+	}, c.backoff...)
+	if err != nil {
+		return err
+	}
+
+	if status < 200 || status > 299 {
+		var msg struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		text := strings.TrimSpace(string(raw))
+		if json.Unmarshal(raw, &msg) == nil && msg.Error.Message != "" {
+			text = msg.Error.Message
+		}
+		return &apiError{Status: status, Message: text}
+	}
+	if out != nil && len(raw) > 0 {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return fmt.Errorf("parsing response: %w", err)
+		}
+	}
+	return nil
+}
+
+// repoPath returns the API path of the client's repository plus suffix.
+func (c *Client) repoPath(suffix string) string {
+	return "/repositories/" + url.PathEscape(c.workspace) + "/" + url.PathEscape(c.slug) + suffix
+}
+
+// page is one page of a paginated list.
+type page[T any] struct {
+	Values []T    `json:"values"`
+	Next   string `json:"next"`
+}
+
+// list collects every page of the list at path.
+func list[T any](c *Client, path string, query url.Values) ([]T, error) {
+	var all []T
+	for path != "" {
+		var p page[T]
+		if err := c.do("GET", path, query, nil, &p); err != nil {
+			return nil, err
+		}
+		all = append(all, p.Values...)
+		path, query = p.Next, nil // next carries the query
+	}
+	return all, nil
+}
+
+type branchRef struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Repository *struct {
+		FullName string `json:"full_name"`
+	} `json:"repository,omitempty"`
+	Commit *struct {
+		Hash string `json:"hash"`
+	} `json:"commit,omitempty"`
+}
+
+func newBranchRef(name string) branchRef {
+	var r branchRef
+	r.Branch.Name = name
+	return r
+}
+
+type account struct {
+	AccountID string `json:"account_id"`
+}
+
+// pullRequest is a pull request as returned by the API.
+type pullRequest struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	State       string    `json:"state"` // OPEN, MERGED, DECLINED or SUPERSEDED
+	Draft       bool      `json:"draft"`
+	Source      branchRef `json:"source"`
+	Destination branchRef `json:"destination"`
+	Reviewers   []account `json:"reviewers"`
+	CreatedOn   time.Time `json:"created_on"`
+	UpdatedOn   time.Time `json:"updated_on"`
+	Links       struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+func (p *pullRequest) info() *forge.PRInfo {
+	var head string
+	if p.Source.Commit != nil {
+		head = p.Source.Commit.Hash
+	}
+	// A merged pull request has no merge date; it is not updated after the
+	// merge, so the last update is when it was merged.
+	var merged time.Time
+	if p.State == "MERGED" {
+		merged = p.UpdatedOn
+	}
+	return &forge.PRInfo{
+		Number:      p.ID,
+		State:       p.State,
+		URL:         p.Links.HTML.Href,
+		Title:       p.Title,
+		Body:        p.Description,
+		HeadRefName: p.Source.Branch.Name,
+		BaseRefName: p.Destination.Branch.Name,
+		IsDraft:     p.Draft,
+		HeadSHA:     head,
+		CreatedAt:   p.CreatedOn,
+		MergedAt:    merged,
+	}
+}
+
+func (c *Client) pullRequest(number int) (*pullRequest, error) {
+	var p pullRequest
+	if err := c.do("GET", c.repoPath(fmt.Sprintf("/pullrequests/%d", number)), nil, nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreatePR creates a new pull request and returns its info. head may be
+// "workspace:branch" for a pull request from a fork, which must have the
+// same repository slug.
+func (c *Client) CreatePR(head, base, title, body string, draft bool) (*forge.PRInfo, error) {
+	slog.Debug("CreatePR", "head", head, "base", base, "title", title, "draft", draft)
+	source := newBranchRef(head)
+	if ws, branch, ok := strings.Cut(head, ":"); ok {
+		source = newBranchRef(branch)
+		source.Repository = &struct {
+			FullName string `json:"full_name"`
+		}{FullName: ws + "/" + c.slug}
+	}
+	var p pullRequest
+	err := c.do("POST", c.repoPath("/pullrequests"), nil, map[string]any{
+		"title":       title,
+		"description": body,
+		"source":      source,
+		"destination": newBranchRef(base),
+		"draft":       draft,
+	}, &p)
+	if err != nil {
+		slog.Debug("CreatePR failed", "err", err)
+		return nil, fmt.Errorf("creating PR: %w", err)
+	}
+	slog.Debug("CreatePR ok", "number", p.ID)
+	return p.info(), nil
+}
+
+// GetPR returns the pull request with the given number, in any state.
+// Bitbucket's states are OPEN, MERGED, DECLINED and SUPERSEDED.
+func (c *Client) GetPR(number int) (*forge.PRInfo, error) {
+	slog.Debug("GetPR", "number", number)
+	p, err := c.pullRequest(number)
+	if err != nil {
+		return nil, fmt.Errorf("getting PR #%d: %w", number, err)
+	}
+	return p.info(), nil
+}
+
+// UpdatePR updates fields of an existing pull request. Closing declines
+// it; a declined pull request cannot be reopened on Bitbucket.
+func (c *Client) UpdatePR(number int, opts forge.UpdatePROpts) error {
+	slog.Debug("UpdatePR", "number", number)
+	if opts.State != nil {
+		switch *opts.State {
+		case "closed":
+			err := c.do("POST", c.repoPath(fmt.Sprintf("/pullrequests/%d/decline", number)), nil, nil, nil)
+			if err != nil {
+				return fmt.Errorf("declining PR #%d: %w", number, err)
+			}
+		default:
+			return fmt.Errorf("updating PR #%d: Bitbucket cannot reopen a declined pull request", number)
+		}
+	}
+	req := map[string]any{}
+	if opts.Title != nil {
+		req["title"] = *opts.Title
+	}
+	if opts.Body != nil {
+		req["description"] = *opts.Body
+	}
+	if opts.Base != nil {
+		req["destination"] = newBranchRef(*opts.Base)
+	}
+	if opts.Draft != nil {
+		req["draft"] = *opts.Draft
+	}
+	if len(req) == 0 {
+		return nil
+	}
+	if _, ok := req["title"]; !ok {
+		// The title is required on every update.
+		p, err := c.pullRequest(number)
+		if err != nil {
+			return fmt.Errorf("updating PR #%d: %w", number, err)
+		}
+		req["title"] = p.Title
+	}
+	if err := c.do("PUT", c.repoPath(fmt.Sprintf("/pullrequests/%d", number)), nil, req, nil); err != nil {
+		slog.Debug("UpdatePR failed", "number", number, "err", err)
+		return fmt.Errorf("updating PR #%d: %w", number, err)
+	}
+	slog.Debug("UpdatePR ok", "number", number)
+	return nil
+}
+
+type comment struct {
+	ID      int64 `json:"id"`
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+	Deleted bool `json:"deleted"`
+}
+
+func commentBody(body string) map[string]any {
+	return map[string]any{"content": map[string]string{"raw": body}}
+}
+
+// CommentOnPR adds a comment to a pull request.
+func (c *Client) CommentOnPR(number int, body string) error {
+	slog.Debug("CommentOnPR", "number", number, "bodyLen", len(body))
+	if err := c.do("POST", c.repoPath(fmt.Sprintf("/pullrequests/%d/comments", number)), nil, commentBody(body), nil); err != nil {
+		return fmt.Errorf("commenting on PR #%d: %w", number, err)
+	}
+	return nil
+}
+
+// FindComment returns the most recent comment on a pull request whose body
+// contains marker, or nil if there is none.
+func (c *Client) FindComment(number int, marker string) (*forge.Comment, error) {
+	slog.Debug("FindComment", "number", number)
+	comments, err := list[comment](c, c.repoPath(fmt.Sprintf("/pullrequests/%d/comments", number)),
+		url.Values{"pagelen": {"100"}, "sort": {"created_on"}})
+	if err != nil {
+		return nil, fmt.Errorf("listing comments on PR #%d: %w", number, err)
+	}
+	var found *forge.Comment
+	for _, cm := range comments {
+		if !cm.Deleted && strings.Contains(cm.Content.Raw, marker) {
+			found = &forge.Comment{ID: cm.ID, Body: cm.Content.Raw}
+		}
+	}
+	if found != nil {
+		c.commentPRs[found.ID] = number
+	}
+	return found, nil
+}
+
+// EditComment replaces the body of a pull request comment previously
+// returned by FindComment.
+func (c *Client) EditComment(id int64, body string) error {
+	slog.Debug("EditComment", "id", id, "bodyLen", len(body))
+	number, ok := c.commentPRs[id]
+	if !ok {
+		return fmt.Errorf("editing comment %d: unknown pull request", id)
+	}
+	if err := c.do("PUT", c.repoPath(fmt.Sprintf("/pullrequests/%d/comments/%d", number, id)), nil, commentBody(body), nil); err != nil {
+		return fmt.Errorf("editing comment %d: %w", id, err)
+	}
+	return nil
+}
+
+// GetAuthenticatedUser returns the account ID of the token's user, the
+// identity reviewers and commit authors are named by.
+func (c *Client) GetAuthenticatedUser() (string, error) {
+	var user account
+	if err := c.do("GET", "/user", nil, nil, &user); err != nil {
+		return "", fmt.Errorf("getting authenticated user: %w", err)
+	}
+	return user.AccountID, nil
+}
+
+// RequestReviewers adds reviewers, given as Bitbucket account IDs (or
+// {UUID}s), to a pull request.
+func (c *Client) RequestReviewers(number int, reviewers []string) error {
+	slog.Debug("RequestReviewers", "number", number, "reviewers", reviewers)
+	p, err := c.pullRequest(number)
+	if err != nil {
+		return fmt.Errorf("requesting reviewers on PR #%d: %w", number, err)
+	}
+	var all []map[string]string
+	seen := make(map[string]bool)
+	for _, r := range p.Reviewers {
+		all = append(all, map[string]string{"account_id": r.AccountID})
+		seen[r.AccountID] = true
+	}
+	for _, r := range reviewers {
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+		if strings.HasPrefix(r, "{") {
+			all = append(all, map[string]string{"uuid": r})
+		} else {
+			all = append(all, map[string]string{"account_id": r})
+		}
+	}
+	err = c.do("PUT", c.repoPath(fmt.Sprintf("/pullrequests/%d", number)), nil, map[string]any{
+		"title":     p.Title,
+		"reviewers": all,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("requesting reviewers on PR #%d: %w", number, err)
+	}
+	return nil
+}
+
+// EnableAutoMerge fails: Bitbucket Cloud's API cannot schedule a merge.
+func (c *Client) EnableAutoMerge(number int, method string) error {
+	return fmt.Errorf("auto-merge is not available on Bitbucket")
+}
+
+// SetMilestone fails: Bitbucket pull requests have no milestones.
+func (c *Client) SetMilestone(number int, milestone string) error {
+	return fmt.Errorf("milestones are not available on Bitbucket")
+}
+
+// AddToProject fails: Bitbucket pull requests cannot be added to projects.
+func (c *Client) AddToProject(number int, owner string, project int) error {
+	return fmt.Errorf("projects are not available on Bitbucket")
+}
+
+// CommitAuthors looks up the authors of the given commits. Commits Bitbucket
+// does not know (e.g. never pushed) are left out of the result.
+func (c *Client) CommitAuthors(shas []string) (map[string]forge.CommitAuthor, error) {
+	slog.Debug("CommitAuthors", "count", len(shas))
+	authors := make(map[string]forge.CommitAuthor, len(shas))
+	for _, sha := range shas {
+		var commit struct {
+			Date   time.Time `json:"date"`
+			Author struct {
+				User *account `json:"user"`
+			} `json:"author"`
+		}
+		err := c.do("GET", c.repoPath("/commit/"+url.PathEscape(sha)), nil, nil, &commit)
+		if isStatus(err, http.StatusNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("looking up commit %s: %w", sha, err)
+		}
+		a := forge.CommitAuthor{Date: commit.Date}
+		if commit.Author.User != nil {
+			a.Login = commit.Author.User.AccountID
+		}
+		authors[sha] = a
+	}
+	return authors, nil
+}
+
+// CodeOwners returns nil: Bitbucket Cloud has no CODEOWNERS file of its own.
+func (c *Client) CodeOwners() ([]byte, error) { return nil, nil }
+
+// RequiresSignedCommits reports false: Bitbucket Cloud has no rule requiring
+// signed commits.
+func (c *Client) RequiresSignedCommits(branch string) (bool, error) { return false, nil }
+
+// LookupPRsByBranch returns the open pull requests whose source branch is
+// one of branches.
+func (c *Client) LookupPRsByBranch(branches []string) (map[string]*forge.PRInfo, error) {
+	slog.Debug("LookupPRsByBranch", "branches", branches)
+	out, err := c.latestPRs(`state = "OPEN"`, branches)
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug("LookupPRsByBranch ok", "found", len(out))
+	return out, nil
+}
+
+// LookupClosedPRsByBranch returns, for each branch whose most recently
+// updated pull request is merged or declined, that pull request. Branches
+// with an open pull request or none at all are left out.
+func (c *Client) LookupClosedPRsByBranch(branches []string) (map[string]*forge.PRInfo, error) {
+	slog.Debug("LookupClosedPRsByBranch", "branches", len(branches))
+	out, err := c.latestPRs(`(state = "OPEN" OR state = "MERGED" OR state = "DECLINED" OR state = "SUPERSEDED")`, branches)
+	if err != nil {
+		return nil, err
+	}
+	for branch, pr := range out {
+		if pr.State == "OPEN" {
+			delete(out, branch)
+		}
+	}
+	return out, nil
+}
+
+// latestPRs returns the most recently updated pull request matching the
+// stateFilter query clause of each of branches, in batches of
+// branchQueryBatchSize.
+func (c *Client) latestPRs(stateFilter string, branches []string) (map[string]*forge.PRInfo, error) {
+	out := make(map[string]*forge.PRInfo, len(branches))
+	for start := 0; start < len(branches); start += branchQueryBatchSize {
+		batch := branches[start:min(start+branchQueryBatchSize, len(branches))]
+		clauses := make([]string, len(batch))
+		for i, b := range batch {
+			clauses[i] = fmt.Sprintf("source.branch.name = %q", b)
+		}
+		query := url.Values{
+			"q":       {fmt.Sprintf(`%s AND (%s)`, stateFilter, strings.Join(clauses, " OR "))},
+			"sort":    {"-updated_on"},
+			"pagelen": {"50"},
+		}
+		prs, err := list[pullRequest](c, c.repoPath("/pullrequests"), query)
+		if err != nil {
+			return nil, fmt.Errorf("looking up PRs: %w", err)
+		}
+		for i := range prs {
+			if name := prs[i].Source.Branch.Name; out[name] == nil {
+				out[name] = prs[i].info()
+			}
+		}
+	}
+	return out, nil
+}
+
+// PRChecks returns the combined build status of each pull request, keyed by
+// PR number.
+func (c *Client) PRChecks(numbers []int) (map[int]string, error) {
+	slog.Debug("PRChecks", "numbers", numbers)
+	out := make(map[int]string, len(numbers))
+	for _, number := range numbers {
+		statuses, err := list[struct {
+			State string `json:"state"`
+		}](c, c.repoPath(fmt.Sprintf("/pullrequests/%d/statuses", number)), nil)
+		if err != nil {
+			return nil, fmt.Errorf("querying checks: %w", err)
+		}
+		if len(statuses) == 0 {
+			continue
+		}
+		state := forge.ChecksPassing
+		for _, s := range statuses {
+			switch s.State {
+			case "FAILED", "STOPPED":
+				state = forge.ChecksFailing
+			case "INPROGRESS":
+				if state != forge.ChecksFailing {
+					state = forge.ChecksPending
+				}
+			}
+		}
+		out[number] = state
+	}
+	return out, nil
+}
+
+// ListOpenPRs returns up to limit open pull requests (at most a page), the
+// most recently updated first.
+func (c *Client) ListOpenPRs(limit int) ([]*forge.PRInfo, error) {
+	slog.Debug("ListOpenPRs", "limit", limit)
+	var p page[pullRequest]
+	query := url.Values{
+		"state":   {"OPEN"},
+		"sort":    {"-updated_on"},
+		"pagelen": {fmt.Sprint(min(limit, 50))},
+	}
+	if err := c.do("GET", c.repoPath("/pullrequests"), query, nil, &p); err != nil {
+		return nil, fmt.Errorf("listing open PRs: %w", err)
+	}
+	out := make([]*forge.PRInfo, len(p.Values))
+	for i := range p.Values {
+		out[i] = p.Values[i].info()
+	}
+	return out, nil
+}
+
+// Collaborators returns the account IDs of the repository's default
+// reviewers, as RequestReviewers takes them. Bitbucket lists a repository's
+// members only to its admins.
+func (c *Client) Collaborators() ([]string, error) {
+	slog.Debug("Collaborators")
+	reviewers, err := list[struct {
+		User account `json:"user"`
+	}](c, c.repoPath("/effective-default-reviewers"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("listing collaborators: %w", err)
+	}
+	ids := make([]string, len(reviewers))
+	for i, r := range reviewers {
+		ids[i] = r.User.AccountID
+	}
+	return ids, nil
+}
+
+// CreateGist fails: jip does not upload Bitbucket snippets.
+func (c *Client) CreateGist(description, filename, content string) (string, error) {
+	return "", errors.New("gists are not supported on Bitbucket")
+}
+
+// PRReviews returns the unresolved inline comment threads and the
+// participants requesting changes of each pull request, keyed by PR number.
+func (c *Client) PRReviews(numbers []int) (map[int]forge.ReviewSummary, error) {
+	slog.Debug("PRReviews", "numbers", numbers)
+	out := make(map[int]forge.ReviewSummary, len(numbers))
+	for _, number := range numbers {
+		var p struct {
+			Participants []struct {
+				State string `json:"state"` // approved, changes_requested or null
+				User  struct {
+					Nickname    string `json:"nickname"`
+					DisplayName string `json:"display_name"`
+				} `json:"user"`
+			} `json:"participants"`
+		}
+		if err := c.do("GET", c.repoPath(fmt.Sprintf("/pullrequests/%d", number)), nil, nil, &p); err != nil {
+			return nil, fmt.Errorf("querying reviews: %w", err)
+		}
+		comments, err := list[struct {
+			Deleted    bool            `json:"deleted"`
+			Parent     json.RawMessage `json:"parent"`
+			Inline     json.RawMessage `json:"inline"`
+			Resolution json.RawMessage `json:"resolution"`
+		}](c, c.repoPath(fmt.Sprintf("/pullrequests/%d/comments", number)), nil)
+		if err != nil {
+			return nil, fmt.Errorf("querying reviews: %w", err)
+		}
+
+		var s forge.ReviewSummary
+		for _, pt := range p.Participants {
+			if pt.State == "changes_requested" {
+				name := pt.User.Nickname
+				if name == "" {
+					name = pt.User.DisplayName
+				}
+				s.ChangesRequested = append(s.ChangesRequested, name)
+			}
+		}
+		// A thread is a top-level inline comment; replies share its state.
+		for _, cm := range comments {
+			if !cm.Deleted && isNull(cm.Parent) && !isNull(cm.Inline) && isNull(cm.Resolution) {
+				s.UnresolvedThreads++
+			}
+		}
+		if s.UnresolvedThreads > 0 || len(s.ChangesRequested) > 0 {
+			out[number] = s
+		}
+	}
+	return out, nil
+}
+
+// isNull reports whether a JSON value is missing or null.
+func isNull(v json.RawMessage) bool {
+	return len(v) == 0 || string(v) == "null"
+}
+
+// RepoInfo looks up workspace/slug with the client's token. It returns an
+// error wrapping forge.ErrRepoNotAccessible when the token cannot see the
+// repository.
+func (c *Client) RepoInfo(owner, repo string) (*forge.RepoInfo, error) {
+	slog.Debug("RepoInfo", "owner", owner, "repo", repo)
+	var r struct {
+		Slug      string `json:"slug"`
+		IsPrivate bool   `json:"is_private"`
+		Workspace struct {
+			Slug string `json:"slug"`
+		} `json:"workspace"`
+	}
+	err := c.do("GET", "/repositories/"+url.PathEscape(owner)+"/"+url.PathEscape(repo), nil, nil, &r)
+	if isStatus(err, http.StatusNotFound, http.StatusForbidden) {
+		return nil, fmt.Errorf("%s/%s: %w", owner, repo, forge.ErrRepoNotAccessible)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("looking up repository %s/%s: %w", owner, repo, err)
+	}
+	info := &forge.RepoInfo{Owner: r.Workspace.Slug, Name: r.Slug, Private: r.IsPrivate}
+
+	// Access tokens cannot list user permissions; leave CanPush unset then.
+	var perms page[struct {
+		Permission string `json:"permission"`
+	}]
+	q := url.Values{"q": {fmt.Sprintf("repository.full_name = %q", owner+"/"+repo)}}
+	if err := c.do("GET", "/user/permissions/repositories", q, nil, &perms); err == nil {
+		for _, p := range perms.Values {
+			info.CanPush = p.Permission == "write" || p.Permission == "admin"
+		}
+	}
+	return info, nil
+}
+
+// errNoStacks is returned by the native stack operations, which only GitHub
+// has.
+var errNoStacks = errors.New("native stacked PRs are not supported on Bitbucket")
+
+// StacksEnabled reports false: Bitbucket has no native stacks.
+func (c *Client) StacksEnabled() (bool, error) { return false, nil }
+
+// FindStackForPR reports that no pull request belongs to a native stack.
+func (c *Client) FindStackForPR(number int) (*forge.Stack, error) { return nil, nil }
+
+// CreateStack fails: Bitbucket has no native stacks.
+func (c *Client) CreateStack(prNumbers []int) (*forge.Stack, error) { return nil, errNoStacks }
+
+// AddToStack fails: Bitbucket has no native stacks.
+func (c *Client) AddToStack(stackNumber int, prNumbers []int) (*forge.Stack, error) {
+	return nil, errNoStacks
+}
+
+// Unstack fails: Bitbucket has no native stacks.
+func (c *Client) Unstack(stackNumber int) (bool, error) { return false, errNoStacks }
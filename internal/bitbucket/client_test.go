@@ -0,0 +1,341 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/retry"
+)
+
+func newTestClient(t *testing.T, token string, mux *http.ServeMux) *Client {
+	t.Helper()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	c := NewClient(token, "ws", "repo")
+	c.apiURL = server.URL
+	c.backoff = []retry.Option{retry.WithInitialBackoff(time.Millisecond)}
+	return c
+}
+
+func decode(t *testing.T, r *http.Request) map[string]any {
+	t.Helper()
+	var req map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		t.Fatalf("decoding request: %v", err)
+	}
+	return req
+}
+
+func TestParseRepoFromURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://bitbucket.org/ws/repo.git", "ws/repo"},
+		{"https://alice@bitbucket.org/WS/Repo.git", "ws/repo"},
+		{"git@bitbucket.org:ws/repo.git", "ws/repo"},
+		{"ssh://git@bitbucket.org/ws/repo", "ws/repo"},
+	}
+	for _, tt := range tests {
+		ws, slug, err := ParseRepoFromURL(tt.url)
+		if err != nil {
+			t.Errorf("ParseRepoFromURL(%q): %v", tt.url, err)
+			continue
+		}
+		if got := ws + "/" + slug; got != tt.want {
+			t.Errorf("ParseRepoFromURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+	if _, _, err := ParseRepoFromURL("https://github.com/ws/repo.git"); err == nil {
+		t.Error("expected an error for a non-Bitbucket URL")
+	}
+}
+
+func TestCreatePR_Fork(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repositories/ws/repo/pullrequests", func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "alice" || pass != "secret" {
+			t.Errorf("basic auth = %q, %q, %v", user, pass, ok)
+		}
+		req := decode(t, r)
+		source := req["source"].(map[string]any)
+		if source["branch"].(map[string]any)["name"] != "jip/a" ||
+			source["repository"].(map[string]any)["full_name"] != "fork/repo" ||
+			req["draft"] != true || req["description"] != "body" {
+			t.Errorf("unexpected request: %v", req)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":          7,
+			"state":       "OPEN",
+			"title":       req["title"],
+			"description": req["description"],
+			"draft":       true,
+			"source":      map[string]any{"branch": map[string]any{"name": "jip/a"}},
+			"destination": map[string]any{"branch": map[string]any{"name": "main"}},
+			"links":       map[string]any{"html": map[string]any{"href": "https://bitbucket.org/ws/repo/pull-requests/7"}},
+		})
+	})
+
+	pr, err := newTestClient(t, "alice:secret", mux).CreatePR("fork:jip/a", "main", "feat: my change", "body", true)
+	if err != nil {
+		t.Fatalf("CreatePR: %v", err)
+	}
+	want := forge.PRInfo{
+		Number: 7, State: "OPEN", URL: "https://bitbucket.org/ws/repo/pull-requests/7",
+		Title: "feat: my change", Body: "body", HeadRefName: "jip/a", BaseRefName: "main", IsDraft: true,
+	}
+	if *pr != want {
+		t.Errorf("got %+v, want %+v", *pr, want)
+	}
+}
+
+func TestUpdatePR_KeepsTitle(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repositories/ws/repo/pullrequests/3", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 3, "title": "feat: x"})
+	})
+	var got map[string]any
+	mux.HandleFunc("PUT /repositories/ws/repo/pullrequests/3", func(w http.ResponseWriter, r *http.Request) {
+		got = decode(t, r)
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 3})
+	})
+
+	base := "dev"
+	if err := newTestClient(t, "secret", mux).UpdatePR(3, forge.UpdatePROpts{Base: &base}); err != nil {
+		t.Fatalf("UpdatePR: %v", err)
+	}
+	if got["title"] != "feat: x" || got["destination"].(map[string]any)["branch"].(map[string]any)["name"] != "dev" {
+		t.Errorf("unexpected request: %v", got)
+	}
+}
+
+func TestUpdatePR_CloseDeclines(t *testing.T) {
+	mux := http.NewServeMux()
+	declined := false
+	mux.HandleFunc("POST /repositories/ws/repo/pullrequests/3/decline", func(w http.ResponseWriter, r *http.Request) {
+		declined = true
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 3, "state": "DECLINED"})
+	})
+
+	closed := "closed"
+	if err := newTestClient(t, "secret", mux).UpdatePR(3, forge.UpdatePROpts{State: &closed}); err != nil {
+		t.Fatalf("UpdatePR: %v", err)
+	}
+	if !declined {
+		t.Error("PR was not declined")
+	}
+}
+
+func TestLookupPRsByBranch_Pages(t *testing.T) {
+	mux := http.NewServeMux()
+	var serverURL string
+	mux.HandleFunc("GET /repositories/ws/repo/pullrequests", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"values": []map[string]any{
+				{"id": 2, "state": "OPEN", "source": map[string]any{"branch": map[string]any{"name": "jip/b"}}},
+			}})
+			return
+		}
+		if q := r.URL.Query().Get("q"); q != `state = "OPEN" AND (source.branch.name = "jip/a" OR source.branch.name = "jip/b")` {
+			t.Errorf("q = %s", q)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"values": []map[string]any{
+				{"id": 1, "state": "OPEN", "source": map[string]any{"branch": map[string]any{"name": "jip/a"}}},
+			},
+			"next": serverURL + "/repositories/ws/repo/pullrequests?page=2",
+		})
+	})
+
+	c := newTestClient(t, "secret", mux)
+	serverURL = c.apiURL
+	got, err := c.LookupPRsByBranch([]string{"jip/a", "jip/b"})
+	if err != nil {
+		t.Fatalf("LookupPRsByBranch: %v", err)
+	}
+	if len(got) != 2 || got["jip/a"].Number != 1 || got["jip/b"].Number != 2 {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestLookupClosedPRsByBranch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repositories/ws/repo/pullrequests", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"values": []map[string]any{
+			{"id": 3, "state": "MERGED", "source": map[string]any{"branch": map[string]any{"name": "jip/a"}}},
+			{"id": 2, "state": "OPEN", "source": map[string]any{"branch": map[string]any{"name": "jip/b"}}},
+			{"id": 1, "state": "DECLINED", "source": map[string]any{"branch": map[string]any{"name": "jip/b"}}},
+		}})
+	})
+
+	got, err := newTestClient(t, "secret", mux).LookupClosedPRsByBranch([]string{"jip/a", "jip/b"})
+	if err != nil {
+		t.Fatalf("LookupClosedPRsByBranch: %v", err)
+	}
+	if len(got) != 1 || got["jip/a"].Number != 3 {
+		t.Errorf("got %+v, want only jip/a → #3", got)
+	}
+}
+
+func TestFindAndEditComment(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repositories/ws/repo/pullrequests/5/comments", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"values": []map[string]any{
+			{"id": 10, "content": map[string]any{"raw": "<!-- jip --> old"}},
+			{"id": 11, "content": map[string]any{"raw": "<!-- jip --> deleted"}, "deleted": true},
+			{"id": 12, "content": map[string]any{"raw": "unrelated"}},
+		}})
+	})
+	var edited map[string]any
+	mux.HandleFunc("PUT /repositories/ws/repo/pullrequests/5/comments/10", func(w http.ResponseWriter, r *http.Request) {
+		edited = decode(t, r)
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 10})
+	})
+
+	c := newTestClient(t, "secret", mux)
+	cm, err := c.FindComment(5, "<!-- jip -->")
+	if err != nil {
+		t.Fatalf("FindComment: %v", err)
+	}
+	if cm == nil || cm.ID != 10 {
+		t.Fatalf("got %+v, want comment 10", cm)
+	}
+	if err := c.EditComment(cm.ID, "new"); err != nil {
+		t.Fatalf("EditComment: %v", err)
+	}
+	if edited["content"].(map[string]any)["raw"] != "new" {
+		t.Errorf("unexpected edit: %v", edited)
+	}
+}
+
+func TestRepoInfo_NotAccessible(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repositories/ws/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"type": "error", "error": {"message": "Repository ws/gone not found"}}`))
+	})
+
+	_, err := newTestClient(t, "secret", mux).RepoInfo("ws", "gone")
+	if !errors.Is(err, forge.ErrRepoNotAccessible) {
+		t.Errorf("got %v, want ErrRepoNotAccessible", err)
+	}
+}
+
+func TestPRChecks(t *testing.T) {
+	mux := http.NewServeMux()
+	statuses := map[string][]string{
+		"1": {"SUCCESSFUL"},
+		"2": {"SUCCESSFUL", "INPROGRESS"},
+		"3": {"INPROGRESS", "FAILED"},
+		"4": {},
+	}
+	mux.HandleFunc("GET /repositories/ws/repo/pullrequests/{id}/statuses", func(w http.ResponseWriter, r *http.Request) {
+		var values []map[string]any
+		for _, s := range statuses[r.PathValue("id")] {
+			values = append(values, map[string]any{"state": s})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"values": values})
+	})
+
+	got, err := newTestClient(t, "secret", mux).PRChecks([]int{1, 2, 3, 4})
+	if err != nil {
+		t.Fatalf("PRChecks: %v", err)
+	}
+	want := map[int]string{1: forge.ChecksPassing, 2: forge.ChecksPending, 3: forge.ChecksFailing}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for n, s := range want {
+		if got[n] != s {
+			t.Errorf("PR %d: got %q, want %q", n, got[n], s)
+		}
+	}
+}
+
+func TestPRReviews(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repositories/ws/repo/pullrequests/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"participants": []map[string]any{
+			{"state": "approved", "user": map[string]any{"nickname": "bob"}},
+			{"state": "changes_requested", "user": map[string]any{"nickname": "carol"}},
+			{"state": nil, "user": map[string]any{"nickname": "dave"}},
+		}})
+	})
+	mux.HandleFunc("GET /repositories/ws/repo/pullrequests/{id}/comments", func(w http.ResponseWriter, r *http.Request) {
+		inline := map[string]any{"path": "a.go"}
+		_ = json.NewEncoder(w).Encode(map[string]any{"values": []map[string]any{
+			{"inline": inline}, // open thread
+			{"inline": inline, "parent": map[string]any{"id": 1}},   // reply
+			{"inline": inline, "resolution": map[string]any{}},      // resolved
+			{"inline": inline, "deleted": true},                     // deleted
+			{"content": map[string]any{"raw": "jip stack comment"}}, // not a review thread
+		}})
+	})
+
+	got, err := newTestClient(t, "secret", mux).PRReviews([]int{1})
+	if err != nil {
+		t.Fatalf("PRReviews: %v", err)
+	}
+	s := got[1]
+	if s.UnresolvedThreads != 1 || len(s.ChangesRequested) != 1 || s.ChangesRequested[0] != "carol" {
+		t.Errorf("got %+v, want 1 thread and carol requesting changes", s)
+	}
+}
+
+func TestServerErrorsAreRetried(t *testing.T) {
+	mux := http.NewServeMux()
+	calls := 0
+	mux.HandleFunc("GET /user", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"account_id": "557058:abc"})
+	})
+
+	user, err := newTestClient(t, "secret", mux).GetAuthenticatedUser()
+	if err != nil || user != "557058:abc" {
+		t.Errorf("got %q, %v", user, err)
+	}
+}
+
+func TestListOpenPRs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repositories/ws/repo/pullrequests", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("state") != "OPEN" || q.Get("pagelen") != "20" {
+			t.Errorf("PRs listed with %v", q)
+		}
+		_, _ = w.Write([]byte(`{"values":[{"id":9,"state":"OPEN","title":"feat: B","source":{"branch":{"name":"b"}}}],"next":"http://example.com/not-followed"}`))
+	})
+	prs, err := newTestClient(t, "tok", mux).ListOpenPRs(20)
+	if err != nil {
+		t.Fatalf("ListOpenPRs: %v", err)
+	}
+	if len(prs) != 1 || prs[0].Number != 9 || prs[0].HeadRefName != "b" {
+		t.Errorf("ListOpenPRs = %+v", prs)
+	}
+}
+
+func TestCollaborators(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repositories/ws/repo/effective-default-reviewers", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"values":[{"user":{"account_id":"557058:a"}},{"user":{"account_id":"557058:b"}}]}`))
+	})
+	got, err := newTestClient(t, "tok", mux).Collaborators()
+	if err != nil {
+		t.Fatalf("Collaborators: %v", err)
+	}
+	if len(got) != 2 || got[0] != "557058:a" {
+		t.Errorf("Collaborators = %q", got)
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -207,6 +208,34 @@ func TestLoad_GlobalConfigDirUnresolvable(t *testing.T) {
 	}
 }
 
+func TestGlobalPath_PlatformDefault(t *testing.T) {
+	old := Dir
+	Dir = ""
+	t.Cleanup(func() { Dir = old })
+
+	base := t.TempDir()
+	var want string
+	switch runtime.GOOS {
+	case "windows":
+		t.Setenv("APPDATA", base)
+		want = filepath.Join(base, "jip", "config.toml")
+	case "darwin":
+		t.Setenv("HOME", base)
+		want = filepath.Join(base, "Library", "Application Support", "jip", "config.toml")
+	default:
+		t.Setenv("XDG_CONFIG_HOME", base)
+		want = filepath.Join(base, "jip", "config.toml")
+	}
+
+	got, err := GlobalPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("GlobalPath() = %q, want %q", got, want)
+	}
+}
+
 func TestLoad_InvalidTOML(t *testing.T) {
 	setGlobalConfig(t, "")
 	root := writeRepoConfig(t, "rebase = \n")
@@ -0,0 +1,205 @@
+// Package encrypt optionally encrypts the files jip keeps on disk (the token
+// fallback storage and the repo state) with age, for machines where file
+// permissions alone do not keep other users out.
+//
+// The key comes from the OS keyring, where jip creates one on first use, or
+// from an age identity file the user manages. Encrypted files are recognized
+// by their age header, so files written before encryption was turned on stay
+// readable and are encrypted on their next write.
+package encrypt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/zalando/go-keyring"
+)
+
+// Encryption modes for the encrypt config key.
+const (
+	ModeNone    = "none"    // files are written in plain text
+	ModeKeyring = "keyring" // an age key jip generates and keeps in the OS keyring
+	ModeAge     = "age"     // the user's own age identity and recipient
+)
+
+// The keyring entry holding the key of ModeKeyring.
+const (
+	keyringService = "jip"
+	keyringUser    = "file-encryption-key"
+)
+
+// ErrDecrypt means an encrypted file could not be decrypted, for lack of a
+// key or because the key does not match.
+var ErrDecrypt = errors.New("cannot decrypt file")
+
+// Settings selects how files are encrypted.
+type Settings struct {
+	Mode         string // ModeNone (or ""), ModeKeyring, or ModeAge
+	Recipient    string // ModeAge: the age recipient to encrypt to; defaults to the identity's
+	IdentityFile string // ModeAge: the file holding the age identity that decrypts
+}
+
+var (
+	settings Settings
+	cached   *keys // resolved lazily: the keyring may prompt or be slow
+)
+
+// keys are the resolved recipients and identities of the settings.
+type keys struct {
+	recipients []age.Recipient
+	identities []age.Identity
+}
+
+// Configure validates s and makes Seal and Open use it.
+func Configure(s Settings) error {
+	switch s.Mode {
+	case "", ModeNone, ModeKeyring:
+	case ModeAge:
+		if s.IdentityFile == "" {
+			return errors.New(`encrypt = "age" needs age-identity, the file holding your age identity`)
+		}
+	default:
+		return fmt.Errorf("invalid encrypt value %q (valid: %s, %s, %s)", s.Mode, ModeNone, ModeKeyring, ModeAge)
+	}
+	settings, cached = s, nil
+	return nil
+}
+
+// Enabled reports whether Seal encrypts.
+func Enabled() bool {
+	return settings.Mode == ModeKeyring || settings.Mode == ModeAge
+}
+
+// IsEncrypted reports whether data is an age-encrypted file.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("age-encryption.org/v1\n"))
+}
+
+// Seal encrypts data for writing to disk, or returns it unchanged when
+// encryption is off.
+func Seal(data []byte) ([]byte, error) {
+	if !Enabled() {
+		return data, nil
+	}
+	k, err := resolve(true)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, k.recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("encrypting: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("encrypting: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("encrypting: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Open decrypts data read from disk. Data that is not encrypted is returned
+// unchanged, whatever the settings.
+func Open(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	if !Enabled() {
+		return nil, fmt.Errorf("%w: it is encrypted but encryption is off (set encrypt in the global config)", ErrDecrypt)
+	}
+	k, err := resolve(false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+	r, err := age.Decrypt(bytes.NewReader(data), k.identities...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+	return out, nil
+}
+
+// resolve loads the keys of the current settings. create generates the
+// keyring key when there is none yet; reading never does, as a new key could
+// not decrypt anything.
+func resolve(create bool) (*keys, error) {
+	if cached != nil {
+		return cached, nil
+	}
+	var (
+		k   *keys
+		err error
+	)
+	if settings.Mode == ModeKeyring {
+		k, err = keyringKeys(create)
+	} else {
+		k, err = ageKeys(settings.IdentityFile, settings.Recipient)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cached = k
+	return k, nil
+}
+
+func keyringKeys(create bool) (*keys, error) {
+	secret, err := keyring.Get(keyringService, keyringUser)
+	if errors.Is(err, keyring.ErrNotFound) {
+		if !create {
+			return nil, errors.New("the OS keyring has no jip encryption key")
+		}
+		id, err := age.GenerateX25519Identity()
+		if err != nil {
+			return nil, err
+		}
+		if err := keyring.Set(keyringService, keyringUser, id.String()); err != nil {
+			return nil, fmt.Errorf("storing the encryption key in the OS keyring: %w", err)
+		}
+		return &keys{recipients: []age.Recipient{id.Recipient()}, identities: []age.Identity{id}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading the encryption key from the OS keyring: %w", err)
+	}
+	id, err := age.ParseX25519Identity(strings.TrimSpace(secret))
+	if err != nil {
+		return nil, fmt.Errorf("parsing the encryption key from the OS keyring: %w", err)
+	}
+	return &keys{recipients: []age.Recipient{id.Recipient()}, identities: []age.Identity{id}}, nil
+}
+
+func ageKeys(identityFile, recipient string) (*keys, error) {
+	f, err := os.Open(identityFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading age identity: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing age identity %s: %w", identityFile, err)
+	}
+	k := &keys{identities: ids}
+	if recipient != "" {
+		if k.recipients, err = age.ParseRecipients(strings.NewReader(recipient)); err != nil {
+			return nil, fmt.Errorf("parsing age-recipient: %w", err)
+		}
+		return k, nil
+	}
+	for _, id := range ids {
+		if x, ok := id.(*age.X25519Identity); ok {
+			k.recipients = append(k.recipients, x.Recipient())
+		}
+	}
+	if len(k.recipients) == 0 {
+		return nil, fmt.Errorf("age identity %s has no X25519 key to encrypt to — set age-recipient", identityFile)
+	}
+	return k, nil
+}
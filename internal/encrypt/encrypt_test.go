@@ -0,0 +1,121 @@
+package encrypt
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/zalando/go-keyring"
+)
+
+// use configures s for the duration of the test.
+func use(t *testing.T, s Settings) {
+	t.Helper()
+	if err := Configure(s); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = Configure(Settings{}) })
+}
+
+func TestSealOpen_Off(t *testing.T) {
+	use(t, Settings{})
+	data := []byte(`{"prs": {}}`)
+	sealed, err := Seal(data)
+	if err != nil || string(sealed) != string(data) {
+		t.Fatalf("Seal with encryption off = %q, %v", sealed, err)
+	}
+	opened, err := Open(data)
+	if err != nil || string(opened) != string(data) {
+		t.Fatalf("Open of a plain file = %q, %v", opened, err)
+	}
+}
+
+func TestSealOpen_Keyring(t *testing.T) {
+	keyring.MockInit()
+	use(t, Settings{Mode: ModeKeyring})
+
+	if _, err := Open(mustSealWith(t, mustIdentity(t).Recipient())); err == nil {
+		t.Error("Open without a keyring key should fail")
+	}
+
+	sealed, err := Seal([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(sealed) {
+		t.Fatalf("Seal did not encrypt: %q", sealed)
+	}
+	// A fresh process finds the key generated by the first Seal.
+	use(t, Settings{Mode: ModeKeyring})
+	opened, err := Open(sealed)
+	if err != nil || string(opened) != "secret" {
+		t.Fatalf("Open = %q, %v", opened, err)
+	}
+}
+
+func TestSealOpen_AgeIdentity(t *testing.T) {
+	id := mustIdentity(t)
+	path := filepath.Join(t.TempDir(), "age.key")
+	if err := os.WriteFile(path, []byte("# created: today\n"+id.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	use(t, Settings{Mode: ModeAge, IdentityFile: path})
+
+	sealed, err := Seal([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := Open(sealed)
+	if err != nil || string(opened) != "secret" {
+		t.Fatalf("Open = %q, %v", opened, err)
+	}
+
+	// Files encrypted to someone else are reported, not returned garbled.
+	if _, err := Open(mustSealWith(t, mustIdentity(t).Recipient())); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Open of a foreign file: got %v, want ErrDecrypt", err)
+	}
+}
+
+func TestOpen_EncryptedWhileOff(t *testing.T) {
+	use(t, Settings{})
+	if _, err := Open(mustSealWith(t, mustIdentity(t).Recipient())); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("got %v, want ErrDecrypt", err)
+	}
+}
+
+func TestConfigure_Invalid(t *testing.T) {
+	defer func() { _ = Configure(Settings{}) }()
+	if err := Configure(Settings{Mode: "rot13"}); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+	if err := Configure(Settings{Mode: ModeAge}); err == nil {
+		t.Error("expected error for age without an identity")
+	}
+}
+
+func mustIdentity(t *testing.T) *age.X25519Identity {
+	t.Helper()
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// mustSealWith encrypts a small payload to r, independently of the settings.
+func mustSealWith(t *testing.T, r age.Recipient) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte("x"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
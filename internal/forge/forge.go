@@ -0,0 +1,164 @@
+// Package forge defines what jip needs from the service hosting a
+// repository's pull requests, independent of any one forge. The GitHub
+// implementation lives in internal/github; internal/forge/forgetest has an
+// in-memory fake for tests.
+package forge
+
+import (
+	"errors"
+	"time"
+)
+
+// Service defines the forge operations needed by the send pipeline.
+type Service interface {
+	CreatePR(head, base, title, body string, draft bool) (*PRInfo, error)
+	UpdatePR(number int, opts UpdatePROpts) error
+	GetPR(number int) (*PRInfo, error)
+	CommentOnPR(number int, body string) error
+	FindComment(number int, marker string) (*Comment, error)
+	EditComment(id int64, body string) error
+	GetAuthenticatedUser() (string, error)
+	RequestReviewers(number int, reviewers []string) error
+	EnableAutoMerge(number int, method string) error
+	SetMilestone(number int, milestone string) error
+	AddToProject(number int, owner string, project int) error
+	CommitAuthors(shas []string) (map[string]CommitAuthor, error)
+	CodeOwners() ([]byte, error)
+	LookupPRsByBranch(branches []string) (map[string]*PRInfo, error)
+	LookupClosedPRsByBranch(branches []string) (map[string]*PRInfo, error)
+	PRChecks(numbers []int) (map[int]string, error)
+	PRReviews(numbers []int) (map[int]ReviewSummary, error)
+	ListOpenPRs(limit int) ([]*PRInfo, error)
+	Collaborators() ([]string, error)
+	// CreateGist uploads content as a secret gist holding the file filename
+	// and returns the gist's URL. Only GitHub has gists.
+	CreateGist(description, filename, content string) (string, error)
+	Owner() string
+	Repo() string
+	// Links returns the web links of the repository.
+	Links() Links
+	RepoInfo(owner, repo string) (*RepoInfo, error)
+	RequiresSignedCommits(branch string) (bool, error)
+
+	// Native stacked-PRs operations (GitHub's private preview).
+	StacksEnabled() (bool, error)
+	FindStackForPR(number int) (*Stack, error)
+	CreateStack(prNumbers []int) (*Stack, error)
+	AddToStack(stackNumber int, prNumbers []int) (*Stack, error)
+	Unstack(stackNumber int) (dissolved bool, err error)
+}
+
+// PRInfo holds the essential fields of a pull request.
+type PRInfo struct {
+	Number      int    `json:"number"`
+	State       string `json:"state"`
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Body        string `json:"body"`
+	HeadRefName string `json:"headRefName"`
+	BaseRefName string `json:"baseRefName"`
+	IsDraft     bool   `json:"isDraft"`
+	HeadSHA     string `json:"headRefOid"` // head commit of the branch, abbreviated on Bitbucket; "" when unknown
+
+	// CreatedAt and MergedAt are when the PR was opened and merged; MergedAt
+	// is zero unless State is MERGED. GetPR fills them in, lookups of several
+	// PRs may leave them zero.
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+	MergedAt  time.Time `json:"mergedAt,omitzero"`
+}
+
+// UpdatePROpts contains optional fields for updating a PR.
+type UpdatePROpts struct {
+	Title *string
+	Body  *string
+	Base  *string
+	Draft *bool
+	State *string // "open" or "closed"
+}
+
+// Comment is an issue comment on a pull request.
+type Comment struct {
+	ID   int64
+	Body string
+}
+
+// CommitAuthor is the forge account behind a commit.
+type CommitAuthor struct {
+	Login string    // empty when the author email is not linked to an account
+	Date  time.Time // author date
+}
+
+// AutoMergeMethods are the merge methods EnableAutoMerge accepts.
+var AutoMergeMethods = []string{"squash", "merge", "rebase"}
+
+// Combined CI status of a pull request's head commit, as returned by
+// PRChecks. A PR whose head commit has no check runs or commit statuses is
+// left out of PRChecks' result.
+const (
+	ChecksPassing = "pass"
+	ChecksFailing = "fail"
+	ChecksPending = "pending"
+)
+
+// ReviewSummary is the review feedback on a pull request that still needs
+// the author's attention, as returned by PRReviews. A PR with none is left
+// out of PRReviews' result.
+type ReviewSummary struct {
+	UnresolvedThreads int      // review comment threads not marked resolved
+	ChangesRequested  []string // reviewers whose latest review requests changes
+}
+
+// ErrRepoNotAccessible is returned by RepoInfo when the repository does not
+// exist or the token cannot see it. Forges answer both with 404 (or 403 for
+// some token restrictions), so the two cannot be told apart.
+var ErrRepoNotAccessible = errors.New("repository not found or not accessible with this token")
+
+// RepoInfo describes a repository as seen by the authenticated token.
+type RepoInfo struct {
+	// Owner and Name are the forge's canonical names, which differ from the
+	// requested ones when the repository was renamed or transferred.
+	Owner   string
+	Name    string
+	Private bool
+	// CanPush reports whether the token has write access to the repository.
+	CanPush bool
+}
+
+// StackPR is a pull request entry within a native stack.
+type StackPR struct {
+	Number   int     `json:"number"`
+	State    string  `json:"state"` // "open" or "closed"
+	Draft    bool    `json:"draft"`
+	MergedAt *string `json:"merged_at"`
+}
+
+// Merged reports whether the pull request has been merged.
+func (p StackPR) Merged() bool { return p.MergedAt != nil && *p.MergedAt != "" }
+
+// StackBase describes the base ref of a stack.
+type StackBase struct {
+	Ref string `json:"ref"`
+}
+
+// Stack is a native stack of pull requests. Number is the human-facing
+// stack number used in API paths; PullRequests is ordered bottom to top.
+type Stack struct {
+	Number       int       `json:"number"`
+	URL          string    `json:"url"`
+	Open         bool      `json:"open"`
+	Base         StackBase `json:"base"`
+	PullRequests []StackPR `json:"pull_requests"`
+}
+
+// OpenPRNumbers returns the numbers of the stack's open, unmerged PRs, bottom
+// to top. Merged PRs stay listed in a stack after a partial merge, so callers
+// comparing against a local stack must ignore them.
+func (s *Stack) OpenPRNumbers() []int {
+	var nums []int
+	for _, p := range s.PullRequests {
+		if p.State == "open" && !p.Merged() {
+			nums = append(nums, p.Number)
+		}
+	}
+	return nums
+}
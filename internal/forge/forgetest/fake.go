@@ -0,0 +1,465 @@
+// Package forgetest provides an in-memory forge.Service for tests.
+package forgetest
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/omarkohl/jip/internal/forge"
+)
+
+// Fake implements forge.Service with in-memory state. Tests inspect and
+// seed the exported fields directly; hold the embedded mutex while doing so
+// if the code under test may run concurrently.
+type Fake struct {
+	sync.Mutex
+
+	PRs       map[int]*forge.PRInfo
+	Comments  map[int][]string
+	Reviewers map[int][]string
+	NextPR    int
+
+	// BodyUpdateErr, when set, is returned by UpdatePR for body updates,
+	// simulating a send that fails after posting its comments.
+	BodyUpdateErr error
+
+	// CreateLimit, when positive, makes CreatePR fail once this many PRs
+	// exist, simulating a send that fails partway through a stack.
+	CreateLimit int
+
+	// LookupCalls counts the calls of LookupPRsByBranch.
+	LookupCalls int
+
+	// InaccessibleRepos lists "owner/repo" names RepoInfo reports as not
+	// accessible with the token.
+	InaccessibleRepos map[string]bool
+
+	// CommitAuthor, when set, is reported by CommitAuthors for every commit.
+	CommitAuthor *forge.CommitAuthor
+
+	// CodeOwnersFile is the CODEOWNERS file CodeOwners returns.
+	CodeOwnersFile []byte
+
+	// AutoMerge records the merge method auto-merge was enabled with per PR.
+	AutoMerge map[int]string
+
+	// Milestones records the milestone of each PR; Projects the projects
+	// ("owner/number") each PR was added to.
+	Milestones map[int]string
+	Projects   map[int][]string
+
+	// Checks is the CI status PRChecks reports per PR.
+	Checks map[int]string
+
+	// Reviews is the review feedback PRReviews reports per PR.
+	Reviews map[int]forge.ReviewSummary
+
+	// Logins are the users Collaborators reports.
+	Logins []string
+
+	// Gists records the content of each gist CreateGist uploaded, keyed by
+	// its URL. GistErr, when set, makes CreateGist fail.
+	Gists   map[string]string
+	GistErr error
+
+	// SignedCommitsRequired makes RequiresSignedCommits report true for
+	// every branch. SignatureChecks records the branches it was asked
+	// about, in order.
+	SignedCommitsRequired bool
+	SignatureChecks       []string
+
+	// Native stacked-PRs state. NativeStacks mirrors the private-preview
+	// gate; call counters let tests assert reconciliation behavior.
+	NativeStacks     bool
+	Stacks           map[int]*forge.Stack
+	NextStack        int
+	CreateStackCalls int
+	AddToStackCalls  int
+	UnstackCalls     int
+
+	owner string
+	repo  string
+}
+
+var _ forge.Service = (*Fake)(nil)
+
+// New returns an empty Fake for the repository owner/repo.
+func New(owner, repo string) *Fake {
+	return &Fake{
+		PRs:        make(map[int]*forge.PRInfo),
+		Comments:   make(map[int][]string),
+		Reviewers:  make(map[int][]string),
+		AutoMerge:  make(map[int]string),
+		Milestones: make(map[int]string),
+		Projects:   make(map[int][]string),
+		Checks:     make(map[int]string),
+		NextPR:     1,
+		Stacks:     make(map[int]*forge.Stack),
+		NextStack:  1,
+		owner:      owner,
+		repo:       repo,
+	}
+}
+
+func (f *Fake) Owner() string { return f.owner }
+func (f *Fake) Repo() string  { return f.repo }
+func (f *Fake) Links() forge.Links {
+	return forge.GitHubLinks("https://github.com", f.owner, f.repo)
+}
+
+func (f *Fake) RepoInfo(owner, repo string) (*forge.RepoInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+	if f.InaccessibleRepos[owner+"/"+repo] {
+		return nil, fmt.Errorf("%s/%s: %w", owner, repo, forge.ErrRepoNotAccessible)
+	}
+	return &forge.RepoInfo{Owner: owner, Name: repo, CanPush: true}, nil
+}
+
+func (f *Fake) RequiresSignedCommits(branch string) (bool, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.SignatureChecks = append(f.SignatureChecks, branch)
+	return f.SignedCommitsRequired, nil
+}
+
+func (f *Fake) GetAuthenticatedUser() (string, error) {
+	return "testuser", nil
+}
+
+func (f *Fake) CreatePR(head, base, title, body string, draft bool) (*forge.PRInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+	if f.CreateLimit > 0 && len(f.PRs) >= f.CreateLimit {
+		return nil, fmt.Errorf("creating PR for %s: rate limit exceeded", head)
+	}
+	num := f.NextPR
+	f.NextPR++
+	pr := &forge.PRInfo{
+		Number:      num,
+		State:       "OPEN",
+		URL:         fmt.Sprintf("https://github.com/%s/%s/pull/%d", f.owner, f.repo, num),
+		Title:       title,
+		Body:        body,
+		HeadRefName: head,
+		BaseRefName: base,
+		IsDraft:     draft,
+	}
+	f.PRs[num] = pr
+	return pr, nil
+}
+
+// GetPR returns a copy of the PR with the given number, in any state.
+func (f *Fake) GetPR(number int) (*forge.PRInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+	pr, ok := f.PRs[number]
+	if !ok {
+		return nil, fmt.Errorf("PR #%d not found", number)
+	}
+	cp := *pr
+	return &cp, nil
+}
+
+func (f *Fake) UpdatePR(number int, opts forge.UpdatePROpts) error {
+	f.Lock()
+	defer f.Unlock()
+	if opts.Body != nil && f.BodyUpdateErr != nil {
+		return f.BodyUpdateErr
+	}
+	pr := f.PRs[number]
+	if pr == nil {
+		return nil // e.g. a PR only known from a cached lookup
+	}
+	if opts.Title != nil {
+		pr.Title = *opts.Title
+	}
+	if opts.Body != nil {
+		pr.Body = *opts.Body
+	}
+	if opts.Base != nil {
+		pr.BaseRefName = *opts.Base
+	}
+	if opts.Draft != nil {
+		pr.IsDraft = *opts.Draft
+	}
+	if opts.State != nil {
+		pr.State = *opts.State
+	}
+	return nil
+}
+
+func (f *Fake) CommentOnPR(number int, body string) error {
+	f.Lock()
+	defer f.Unlock()
+	f.Comments[number] = append(f.Comments[number], body)
+	return nil
+}
+
+// Comment IDs encode the PR number and the comment's index.
+func (f *Fake) FindComment(number int, marker string) (*forge.Comment, error) {
+	f.Lock()
+	defer f.Unlock()
+	comments := f.Comments[number]
+	for i := len(comments) - 1; i >= 0; i-- {
+		if strings.Contains(comments[i], marker) {
+			return &forge.Comment{ID: int64(number)*1000 + int64(i), Body: comments[i]}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *Fake) EditComment(id int64, body string) error {
+	f.Lock()
+	defer f.Unlock()
+	number, i := int(id/1000), int(id%1000)
+	if i >= len(f.Comments[number]) {
+		return fmt.Errorf("comment %d not found", id)
+	}
+	f.Comments[number][i] = body
+	return nil
+}
+
+func (f *Fake) RequestReviewers(number int, reviewers []string) error {
+	f.Lock()
+	defer f.Unlock()
+	f.Reviewers[number] = append(f.Reviewers[number], reviewers...)
+	return nil
+}
+
+func (f *Fake) EnableAutoMerge(number int, method string) error {
+	f.Lock()
+	defer f.Unlock()
+	f.AutoMerge[number] = method
+	return nil
+}
+
+func (f *Fake) SetMilestone(number int, milestone string) error {
+	f.Lock()
+	defer f.Unlock()
+	f.Milestones[number] = milestone
+	return nil
+}
+
+// AddToProject records the project, once per PR as on GitHub.
+func (f *Fake) AddToProject(number int, owner string, project int) error {
+	f.Lock()
+	defer f.Unlock()
+	ref := fmt.Sprintf("%s/%d", owner, project)
+	for _, p := range f.Projects[number] {
+		if p == ref {
+			return nil
+		}
+	}
+	f.Projects[number] = append(f.Projects[number], ref)
+	return nil
+}
+
+func (f *Fake) CommitAuthors(shas []string) (map[string]forge.CommitAuthor, error) {
+	f.Lock()
+	defer f.Unlock()
+	authors := make(map[string]forge.CommitAuthor)
+	if f.CommitAuthor != nil {
+		for _, sha := range shas {
+			authors[sha] = *f.CommitAuthor
+		}
+	}
+	return authors, nil
+}
+
+func (f *Fake) CodeOwners() ([]byte, error) {
+	f.Lock()
+	defer f.Unlock()
+	return f.CodeOwnersFile, nil
+}
+
+// LookupPRsByBranch returns the open PRs, as created by CreatePR (state
+// "OPEN") and not since closed through UpdatePR.
+func (f *Fake) LookupPRsByBranch(branches []string) (map[string]*forge.PRInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.LookupCalls++
+	result := make(map[string]*forge.PRInfo)
+	for _, branch := range branches {
+		for _, pr := range f.PRs {
+			if pr.HeadRefName == branch && pr.State == "OPEN" {
+				result[branch] = pr
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// LookupClosedPRsByBranch returns the most recent PR of each branch when it is
+// no longer open (closed through UpdatePR, or seeded as "MERGED").
+func (f *Fake) LookupClosedPRsByBranch(branches []string) (map[string]*forge.PRInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+	result := make(map[string]*forge.PRInfo)
+	for _, branch := range branches {
+		var latest *forge.PRInfo
+		for _, pr := range f.PRs {
+			if pr.HeadRefName == branch && (latest == nil || pr.Number > latest.Number) {
+				latest = pr
+			}
+		}
+		if latest != nil && latest.State != "OPEN" {
+			result[branch] = latest
+		}
+	}
+	return result, nil
+}
+
+func (f *Fake) PRChecks(numbers []int) (map[int]string, error) {
+	f.Lock()
+	defer f.Unlock()
+	out := make(map[int]string)
+	for _, n := range numbers {
+		if state, ok := f.Checks[n]; ok {
+			out[n] = state
+		}
+	}
+	return out, nil
+}
+
+func (f *Fake) PRReviews(numbers []int) (map[int]forge.ReviewSummary, error) {
+	f.Lock()
+	defer f.Unlock()
+	out := make(map[int]forge.ReviewSummary)
+	for _, n := range numbers {
+		if s, ok := f.Reviews[n]; ok {
+			out[n] = s
+		}
+	}
+	return out, nil
+}
+
+// ListOpenPRs returns up to limit open PRs, the highest number first.
+func (f *Fake) ListOpenPRs(limit int) ([]*forge.PRInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+	var out []*forge.PRInfo
+	for _, pr := range f.PRs {
+		if pr.State == "OPEN" {
+			cp := *pr
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Number > out[j].Number })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (f *Fake) Collaborators() ([]string, error) {
+	f.Lock()
+	defer f.Unlock()
+	return slices.Clone(f.Logins), nil
+}
+
+func (f *Fake) CreateGist(description, filename, content string) (string, error) {
+	f.Lock()
+	defer f.Unlock()
+	if f.GistErr != nil {
+		return "", f.GistErr
+	}
+	if f.Gists == nil {
+		f.Gists = make(map[string]string)
+	}
+	url := fmt.Sprintf("https://gist.example.com/%d", len(f.Gists)+1)
+	f.Gists[url] = content
+	return url, nil
+}
+
+func (f *Fake) StacksEnabled() (bool, error) {
+	f.Lock()
+	defer f.Unlock()
+	return f.NativeStacks, nil
+}
+
+func (f *Fake) FindStackForPR(number int) (*forge.Stack, error) {
+	f.Lock()
+	defer f.Unlock()
+	for _, st := range f.Stacks {
+		for _, p := range st.PullRequests {
+			if p.Number == number {
+				return st, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// checkChained enforces the API's base-to-head chain requirement: each PR's
+// base must be the head branch of the PR below it.
+func (f *Fake) checkChained(below, above int) error {
+	prBelow, prAbove := f.PRs[below], f.PRs[above]
+	if prBelow == nil || prAbove == nil {
+		return fmt.Errorf("unknown PR in stack request (#%d, #%d)", below, above)
+	}
+	if prAbove.BaseRefName != prBelow.HeadRefName {
+		return fmt.Errorf("PR #%d base %q does not match PR #%d head %q",
+			above, prAbove.BaseRefName, below, prBelow.HeadRefName)
+	}
+	return nil
+}
+
+func (f *Fake) CreateStack(prNumbers []int) (*forge.Stack, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.CreateStackCalls++
+	if len(prNumbers) < 2 {
+		return nil, fmt.Errorf("a stack requires at least 2 PRs, got %d", len(prNumbers))
+	}
+	for i := 1; i < len(prNumbers); i++ {
+		if err := f.checkChained(prNumbers[i-1], prNumbers[i]); err != nil {
+			return nil, err
+		}
+	}
+	st := &forge.Stack{
+		Number: f.NextStack,
+		Open:   true,
+		Base:   forge.StackBase{Ref: f.PRs[prNumbers[0]].BaseRefName},
+	}
+	f.NextStack++
+	for _, n := range prNumbers {
+		st.PullRequests = append(st.PullRequests, forge.StackPR{Number: n, State: "open"})
+	}
+	f.Stacks[st.Number] = st
+	return st, nil
+}
+
+func (f *Fake) AddToStack(stackNumber int, prNumbers []int) (*forge.Stack, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.AddToStackCalls++
+	st := f.Stacks[stackNumber]
+	if st == nil {
+		return nil, fmt.Errorf("no such stack #%d", stackNumber)
+	}
+	prev := st.PullRequests[len(st.PullRequests)-1].Number
+	for _, n := range prNumbers {
+		if err := f.checkChained(prev, n); err != nil {
+			return nil, err
+		}
+		st.PullRequests = append(st.PullRequests, forge.StackPR{Number: n, State: "open"})
+		prev = n
+	}
+	return st, nil
+}
+
+func (f *Fake) Unstack(stackNumber int) (bool, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.UnstackCalls++
+	if _, ok := f.Stacks[stackNumber]; !ok {
+		return false, fmt.Errorf("no such stack #%d", stackNumber)
+	}
+	delete(f.Stacks, stackNumber)
+	return true, nil
+}
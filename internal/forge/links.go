@@ -0,0 +1,70 @@
+package forge
+
+import "fmt"
+
+// Links lays out the web pages of a repository that PR bodies and comments
+// link to. Each forge has its own URL scheme; GitHubLinks, GiteaLinks and
+// BitbucketLinks return them.
+type Links struct {
+	// Name names the forge in link texts, e.g. GitHub.
+	Name string
+	// Repo is the repository's web URL, e.g. https://github.com/owner/repo.
+	Repo string
+	// PRCommit, Commit and Compare are fmt patterns of the paths below Repo:
+	// a commit within a pull request (PR number, commit hash), a commit
+	// (hash) and the diff between two commits (older, newer hash).
+	PRCommit string
+	Commit   string
+	Compare  string
+}
+
+// GitHubLinks returns the links of owner/repo on the GitHub or GitHub
+// Enterprise Server at webURL (e.g. https://github.com).
+func GitHubLinks(webURL, owner, repo string) Links {
+	return Links{
+		Name:     "GitHub",
+		Repo:     webURL + "/" + owner + "/" + repo,
+		PRCommit: "/pull/%d/commits/%s",
+		Commit:   "/commit/%s",
+		Compare:  "/compare/%s..%s",
+	}
+}
+
+// GiteaLinks returns the links of owner/repo on the Gitea or Forgejo server
+// at webURL (e.g. https://codeberg.org).
+func GiteaLinks(webURL, owner, repo string) Links {
+	return Links{
+		Name:     "Gitea",
+		Repo:     webURL + "/" + owner + "/" + repo,
+		PRCommit: "/pulls/%d/commits/%s",
+		Commit:   "/commit/%s",
+		Compare:  "/compare/%s...%s",
+	}
+}
+
+// BitbucketLinks returns the links of workspace/slug on Bitbucket Cloud,
+// whose compare page takes the newer commit first.
+func BitbucketLinks(workspace, slug string) Links {
+	return Links{
+		Name:     "Bitbucket",
+		Repo:     "https://bitbucket.org/" + workspace + "/" + slug,
+		PRCommit: "/pull-requests/%d/commits/%s",
+		Commit:   "/commits/%s",
+		Compare:  "/branches/compare/%[2]s%%0D%[1]s",
+	}
+}
+
+// PRCommitURL returns the URL of commit within pull request number.
+func (l Links) PRCommitURL(number int, commit string) string {
+	return l.Repo + fmt.Sprintf(l.PRCommit, number, commit)
+}
+
+// CommitURL returns the URL of commit.
+func (l Links) CommitURL(commit string) string {
+	return l.Repo + fmt.Sprintf(l.Commit, commit)
+}
+
+// CompareURL returns the URL of the diff from oldCommit to newCommit.
+func (l Links) CompareURL(oldCommit, newCommit string) string {
+	return l.Repo + fmt.Sprintf(l.Compare, oldCommit, newCommit)
+}
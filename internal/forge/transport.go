@@ -0,0 +1,85 @@
+package forge
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// TransportSettings configure how jip reaches forges from networks that
+// need it: behind a TLS-intercepting proxy or with an internal server whose
+// certificate no public CA signed. Proxies come from HTTPS_PROXY,
+// HTTP_PROXY and NO_PROXY either way.
+type TransportSettings struct {
+	// CACert is a PEM file of CA certificates trusted besides the
+	// system's.
+	CACert string
+	// InsecureHosts are hosts whose certificates are not verified at all.
+	InsecureHosts []string
+}
+
+var transport http.RoundTripper = newTransport(nil)
+
+// Transport returns the transport every forge client sends its requests
+// through.
+func Transport() http.RoundTripper { return transport }
+
+// ConfigureTransport applies s to the transport of the clients created
+// afterwards.
+func ConfigureTransport(s TransportSettings) error {
+	if s.CACert == "" && len(s.InsecureHosts) == 0 {
+		transport = newTransport(nil)
+		return nil
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if s.CACert != "" {
+		pem, err := os.ReadFile(s.CACert)
+		if err != nil {
+			return fmt.Errorf("reading the CA certificates: %w", err)
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%s holds no PEM-encoded certificates", s.CACert)
+		}
+	}
+	transport = newTransport(&tls.Config{RootCAs: roots})
+	if len(s.InsecureHosts) > 0 {
+		transport = &hostTransport{
+			hosts:    s.InsecureHosts,
+			matching: newTransport(&tls.Config{InsecureSkipVerify: true}),
+			other:    transport,
+		}
+	}
+	return nil
+}
+
+// hostTransport sends requests to hosts through matching, and all others
+// through other.
+type hostTransport struct {
+	hosts           []string
+	matching, other http.RoundTripper
+}
+
+func (t *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if slices.ContainsFunc(t.hosts, func(h string) bool { return strings.EqualFold(h, req.URL.Hostname()) }) {
+		return t.matching.RoundTrip(req)
+	}
+	return t.other.RoundTrip(req)
+}
+
+// newTransport returns a transport like http.DefaultTransport, which takes
+// proxies from the environment, with the given TLS configuration.
+func newTransport(cfg *tls.Config) http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	if cfg != nil {
+		t.TLSClientConfig = cfg
+	}
+	return t
+}
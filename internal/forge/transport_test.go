@@ -0,0 +1,57 @@
+package forge
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigureTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	t.Cleanup(func() { _ = ConfigureTransport(TransportSettings{}) })
+
+	get := func() error {
+		resp, err := (&http.Client{Transport: Transport()}).Get(server.URL)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return err
+	}
+	if err := get(); err == nil {
+		t.Fatal("a certificate from an unknown CA was accepted")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConfigureTransport(TransportSettings{CACert: caFile}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(); err != nil {
+		t.Errorf("with the CA: %v", err)
+	}
+
+	// Skipping verification only applies to the hosts named.
+	if err := ConfigureTransport(TransportSettings{InsecureHosts: []string{"ghe.internal"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(); err == nil {
+		t.Error("verification skipped for a host not named")
+	}
+	if err := ConfigureTransport(TransportSettings{InsecureHosts: []string{"127.0.0.1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(); err != nil {
+		t.Errorf("with verification skipped: %v", err)
+	}
+
+	if err := ConfigureTransport(TransportSettings{CACert: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("no error for a missing CA file")
+	}
+}
@@ -0,0 +1,726 @@
+// Package gitea implements forge.Service for Gitea and Forgejo, whose REST
+// APIs (/api/v1) are the same for everything jip uses.
+package gitea
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/retry"
+)
+
+// pageSize is the number of items requested per page. Servers cap it at
+// their MAX_RESPONSE_ITEMS setting (50 by default), so pagination stops on an
+// empty page rather than a short one.
+const pageSize = 50
+
+// Client talks to a Gitea or Forgejo server's REST API.
+type Client struct {
+	http    *http.Client
+	webURL  string // e.g. https://codeberg.org
+	apiURL  string // e.g. https://codeberg.org/api/v1
+	owner   string
+	repo    string
+	token   string
+	backoff []retry.Option
+
+	mu         sync.Mutex
+	milestones map[string]int64 // IDs looked up by title, once per client
+}
+
+var _ forge.Service = (*Client)(nil)
+
+// NewClient creates a client for the repository owner/repo on the server at
+// webURL (e.g. https://codeberg.org).
+func NewClient(token, webURL, owner, repo string) *Client {
+	webURL = strings.TrimSuffix(webURL, "/")
+	return &Client{
+		http:   &http.Client{Transport: forge.Transport()},
+		webURL: webURL,
+		apiURL: webURL + "/api/v1",
+		owner:  owner,
+		repo:   repo,
+		token:  token,
+
+		milestones: make(map[string]int64),
+	}
+}
+
+// Owner returns the repository owner.
+func (c *Client) Owner() string { return c.owner }
+
+// Repo returns the repository name.
+func (c *Client) Repo() string { return c.repo }
+
+// Links returns the web links of the repository.
+func (c *Client) Links() forge.Links { return forge.GiteaLinks(c.webURL, c.owner, c.repo) }
+
+// apiError is a non-2xx response.
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("Gitea API returned %d", e.Status)
+	}
+	return fmt.Sprintf("Gitea API returned %d: %s", e.Status, e.Message)
+}
+
+func isStatus(err error, statuses ...int) bool {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, s := range statuses {
+		if apiErr.Status == s {
+			return true
+		}
+	}
+	return false
+}
+
+// do sends a request to the API path (relative to /api/v1) with in as the
+// JSON body when non-nil, and decodes a successful response into out when
+// non-nil. Server errors are retried.
+func (c *Client) do(method, path string, query url.Values, in, out any) error {
+	u := c.apiURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("marshaling request: %w", err)
+		}
+	}
+
+	var status int
+	var raw []byte
+	err := retry.Do(func() error {
+		req, err := http.NewRequest(method, u, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "token "+c.token)
+		req.Header.Set("Accept", "application/json")
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		start := time.Now()
+		resp, err := c.http.Do(req)
+		duration := time.Since(start).Round(time.Millisecond)
+		if err != nil {
+			slog.Info("gitea", "method", method, "path", path, "err", err, "duration", duration)
+			return err
+		}
+		slog.Info("gitea", "method", method, "path", path, "status", resp.StatusCode, "duration", duration)
+		raw, err = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		status = resp.StatusCode
+		// Retry on server errors (5xx); don't retry client errors (4xx).
+		if status >= 500 {
+			return &apiError{Status: status, Message: string(raw)}
+		}
+		return nil
+	}, c.backoff...)
+	if err != nil {
+		return err
+	}
+
+	if status < 200 || status > 299 {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(raw, &msg) != nil || msg.Message == "" {
+			msg.Message = strings.TrimSpace(string(raw))
+		}
+		return &apiError{Status: status, Message: msg.Message}
+	}
+	if out != nil && len(raw) > 0 {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return fmt.Errorf("parsing response: %w", err)
+		}
+	}
+	return nil
+}
+
+// repoPath returns the API path of the client's repository plus suffix.
+func (c *Client) repoPath(suffix string) string {
+	return "/repos/" + url.PathEscape(c.owner) + "/" + url.PathEscape(c.repo) + suffix
+}
+
+// pull is a pull request as returned by the API.
+type pull struct {
+	Number  int    `json:"number"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	Draft   bool   `json:"draft"`
+	Merged  bool   `json:"merged"`
+	Head    struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	Created  time.Time  `json:"created_at"`
+	MergedAt *time.Time `json:"merged_at"`
+}
+
+// wipPrefixes are the title prefixes that make a pull request a draft ("work
+// in progress"); the first is the one jip writes. Older servers have no
+// separate draft flag, so the prefix is the only portable marker.
+var wipPrefixes = []string{"WIP: ", "WIP:", "[WIP] ", "[WIP]"}
+
+// splitWIP returns title without a work-in-progress prefix and whether it
+// had one.
+func splitWIP(title string) (string, bool) {
+	for _, p := range wipPrefixes {
+		if len(title) >= len(p) && strings.EqualFold(title[:len(p)], p) {
+			return strings.TrimLeft(title[len(p):], " "), true
+		}
+	}
+	return title, false
+}
+
+// withWIP returns title with the work-in-progress prefix when draft is set,
+// and without one otherwise. A prefix title already has is not repeated.
+func withWIP(title string, draft bool) string {
+	title, _ = splitWIP(title)
+	if draft {
+		return wipPrefixes[0] + title
+	}
+	return title
+}
+
+func (p *pull) info() *forge.PRInfo {
+	title, wip := splitWIP(p.Title)
+	state := strings.ToUpper(p.State)
+	var merged time.Time
+	if p.Merged {
+		state = "MERGED"
+		if p.MergedAt != nil {
+			merged = *p.MergedAt
+		}
+	}
+	return &forge.PRInfo{
+		Number:      p.Number,
+		State:       state,
+		URL:         p.HTMLURL,
+		Title:       title,
+		Body:        p.Body,
+		HeadRefName: p.Head.Ref,
+		BaseRefName: p.Base.Ref,
+		IsDraft:     wip || p.Draft,
+		HeadSHA:     p.Head.SHA,
+		CreatedAt:   p.Created,
+		MergedAt:    merged,
+	}
+}
+
+// CreatePR creates a new pull request and returns its info. A draft is
+// marked with a "WIP: " title prefix. head may be "owner:branch" for a pull
+// request from a fork.
+func (c *Client) CreatePR(head, base, title, body string, draft bool) (*forge.PRInfo, error) {
+	slog.Debug("CreatePR", "head", head, "base", base, "title", title, "draft", draft)
+	var p pull
+	err := c.do("POST", c.repoPath("/pulls"), nil, map[string]any{
+		"head":  head,
+		"base":  base,
+		"title": withWIP(title, draft),
+		"body":  body,
+	}, &p)
+	if err != nil {
+		slog.Debug("CreatePR failed", "err", err)
+		return nil, fmt.Errorf("creating PR: %w", err)
+	}
+	slog.Debug("CreatePR ok", "number", p.Number)
+	return p.info(), nil
+}
+
+// GetPR returns the pull request with the given number, in any state.
+func (c *Client) GetPR(number int) (*forge.PRInfo, error) {
+	slog.Debug("GetPR", "number", number)
+	var p pull
+	if err := c.do("GET", c.repoPath(fmt.Sprintf("/pulls/%d", number)), nil, nil, &p); err != nil {
+		return nil, fmt.Errorf("getting PR #%d: %w", number, err)
+	}
+	return p.info(), nil
+}
+
+// UpdatePR updates fields of an existing pull request. Changing only one of
+// the title and the draft state needs the other, so the pull request is read
+// first in that case.
+func (c *Client) UpdatePR(number int, opts forge.UpdatePROpts) error {
+	slog.Debug("UpdatePR", "number", number)
+	req := map[string]any{}
+	if opts.Title != nil || opts.Draft != nil {
+		title, draft := "", false
+		if opts.Title == nil || opts.Draft == nil {
+			var p pull
+			if err := c.do("GET", c.repoPath(fmt.Sprintf("/pulls/%d", number)), nil, nil, &p); err != nil {
+				return fmt.Errorf("updating PR #%d: %w", number, err)
+			}
+			current := p.info()
+			title, draft = current.Title, current.IsDraft
+		}
+		if opts.Title != nil {
+			title = *opts.Title
+		}
+		if opts.Draft != nil {
+			draft = *opts.Draft
+		}
+		req["title"] = withWIP(title, draft)
+	}
+	if opts.Body != nil {
+		req["body"] = *opts.Body
+	}
+	if opts.Base != nil {
+		req["base"] = *opts.Base
+	}
+	if opts.State != nil {
+		req["state"] = *opts.State
+	}
+	if err := c.do("PATCH", c.repoPath(fmt.Sprintf("/pulls/%d", number)), nil, req, nil); err != nil {
+		slog.Debug("UpdatePR failed", "number", number, "err", err)
+		return fmt.Errorf("updating PR #%d: %w", number, err)
+	}
+	slog.Debug("UpdatePR ok", "number", number)
+	return nil
+}
+
+// CommentOnPR adds a comment to a pull request.
+func (c *Client) CommentOnPR(number int, body string) error {
+	slog.Debug("CommentOnPR", "number", number, "bodyLen", len(body))
+	err := c.do("POST", c.repoPath(fmt.Sprintf("/issues/%d/comments", number)), nil, map[string]string{"body": body}, nil)
+	if err != nil {
+		slog.Debug("CommentOnPR failed", "number", number, "err", err)
+		return fmt.Errorf("commenting on PR #%d: %w", number, err)
+	}
+	return nil
+}
+
+// FindComment returns the most recent comment on a pull request whose body
+// contains marker, or nil if there is none.
+func (c *Client) FindComment(number int, marker string) (*forge.Comment, error) {
+	slog.Debug("FindComment", "number", number)
+	var found *forge.Comment
+	for page := 1; ; page++ {
+		var comments []struct {
+			ID   int64  `json:"id"`
+			Body string `json:"body"`
+		}
+		query := url.Values{"page": {fmt.Sprint(page)}, "limit": {fmt.Sprint(pageSize)}}
+		if err := c.do("GET", c.repoPath(fmt.Sprintf("/issues/%d/comments", number)), query, nil, &comments); err != nil {
+			return nil, fmt.Errorf("listing comments on PR #%d: %w", number, err)
+		}
+		if len(comments) == 0 {
+			return found, nil
+		}
+		for _, cm := range comments {
+			if strings.Contains(cm.Body, marker) {
+				found = &forge.Comment{ID: cm.ID, Body: cm.Body}
+			}
+		}
+	}
+}
+
+// EditComment replaces the body of a pull request comment.
+func (c *Client) EditComment(id int64, body string) error {
+	slog.Debug("EditComment", "id", id, "bodyLen", len(body))
+	if err := c.do("PATCH", c.repoPath(fmt.Sprintf("/issues/comments/%d", id)), nil, map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("editing comment %d: %w", id, err)
+	}
+	return nil
+}
+
+// GetAuthenticatedUser returns the login of the token's user.
+func (c *Client) GetAuthenticatedUser() (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := c.do("GET", "/user", nil, nil, &user); err != nil {
+		return "", fmt.Errorf("getting authenticated user: %w", err)
+	}
+	return user.Login, nil
+}
+
+// RequestReviewers requests reviews from the given users.
+func (c *Client) RequestReviewers(number int, reviewers []string) error {
+	slog.Debug("RequestReviewers", "number", number, "reviewers", reviewers)
+	err := c.do("POST", c.repoPath(fmt.Sprintf("/pulls/%d/requested_reviewers", number)), nil,
+		map[string]any{"reviewers": reviewers}, nil)
+	if err != nil {
+		return fmt.Errorf("requesting reviewers on PR #%d: %w", number, err)
+	}
+	return nil
+}
+
+// EnableAutoMerge schedules the pull request to be merged with method (one
+// of forge.AutoMergeMethods) once its checks succeed.
+func (c *Client) EnableAutoMerge(number int, method string) error {
+	slog.Debug("EnableAutoMerge", "number", number, "method", method)
+	err := c.do("POST", c.repoPath(fmt.Sprintf("/pulls/%d/merge", number)), nil, map[string]any{
+		"Do":                        method,
+		"merge_when_checks_succeed": true,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("enabling auto-merge on PR #%d: %w", number, err)
+	}
+	return nil
+}
+
+// SetMilestone puts the pull request into the milestone with the given
+// title.
+func (c *Client) SetMilestone(number int, milestone string) error {
+	slog.Debug("SetMilestone", "number", number, "milestone", milestone)
+	id, err := c.milestoneID(milestone)
+	if err == nil {
+		err = c.do("PATCH", c.repoPath(fmt.Sprintf("/pulls/%d", number)), nil, map[string]any{"milestone": id}, nil)
+	}
+	if err != nil {
+		slog.Debug("SetMilestone failed", "number", number, "err", err)
+		return fmt.Errorf("setting the milestone of PR #%d: %w", number, err)
+	}
+	slog.Debug("SetMilestone ok", "number", number)
+	return nil
+}
+
+// milestoneID returns the ID of the milestone titled title.
+func (c *Client) milestoneID(title string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if id, ok := c.milestones[title]; ok {
+		return id, nil
+	}
+	// The milestone endpoint takes a title where an ID is expected.
+	var m struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.do("GET", c.repoPath("/milestones/"+url.PathEscape(title)), nil, nil, &m); err != nil {
+		return 0, err
+	}
+	c.milestones[title] = m.ID
+	return m.ID, nil
+}
+
+// AddToProject fails: Gitea's API cannot add pull requests to projects.
+func (c *Client) AddToProject(number int, owner string, project int) error {
+	return fmt.Errorf("projects are not available on Gitea")
+}
+
+// CommitAuthors looks up the authors of the given commits. Commits the
+// server does not know (e.g. never pushed) are left out of the result.
+func (c *Client) CommitAuthors(shas []string) (map[string]forge.CommitAuthor, error) {
+	slog.Debug("CommitAuthors", "count", len(shas))
+	authors := make(map[string]forge.CommitAuthor, len(shas))
+	for _, sha := range shas {
+		var commit struct {
+			Author *struct {
+				Login string `json:"login"`
+			} `json:"author"`
+			Commit struct {
+				Author struct {
+					Date time.Time `json:"date"`
+				} `json:"author"`
+			} `json:"commit"`
+		}
+		err := c.do("GET", c.repoPath("/git/commits/"+url.PathEscape(sha)), url.Values{"stat": {"false"}}, nil, &commit)
+		if isStatus(err, http.StatusNotFound, http.StatusUnprocessableEntity) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("looking up commit %s: %w", sha, err)
+		}
+		a := forge.CommitAuthor{Date: commit.Commit.Author.Date}
+		if commit.Author != nil {
+			a.Login = commit.Author.Login
+		}
+		authors[sha] = a
+	}
+	return authors, nil
+}
+
+// codeOwnersPaths are where Gitea and Forgejo look for a CODEOWNERS file.
+var codeOwnersPaths = []string{"CODEOWNERS", "docs/CODEOWNERS", ".gitea/CODEOWNERS", ".forgejo/CODEOWNERS"}
+
+// CodeOwners returns the repository's CODEOWNERS file from its default
+// branch, or nil when there is none.
+func (c *Client) CodeOwners() ([]byte, error) {
+	for _, path := range codeOwnersPaths {
+		var file struct {
+			Content  string `json:"content"`
+			Encoding string `json:"encoding"`
+		}
+		err := c.do("GET", c.repoPath("/contents/"+path), nil, nil, &file)
+		if isStatus(err, http.StatusNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		if file.Encoding != "base64" {
+			return []byte(file.Content), nil
+		}
+		data, err := base64.StdEncoding.DecodeString(file.Content)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", path, err)
+		}
+		return data, nil
+	}
+	return nil, nil
+}
+
+// LookupPRsByBranch returns the open pull requests whose head branch is one
+// of branches. The API cannot filter by head branch, so the open pull
+// requests of the repository are listed until every branch is found.
+func (c *Client) LookupPRsByBranch(branches []string) (map[string]*forge.PRInfo, error) {
+	slog.Debug("LookupPRsByBranch", "branches", branches)
+	out, err := c.latestPulls("open", branches)
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug("LookupPRsByBranch ok", "found", len(out))
+	return out, nil
+}
+
+// LookupClosedPRsByBranch returns, for each branch whose most recently
+// updated pull request is merged or closed, that pull request. Branches with
+// an open pull request or none at all are left out.
+func (c *Client) LookupClosedPRsByBranch(branches []string) (map[string]*forge.PRInfo, error) {
+	slog.Debug("LookupClosedPRsByBranch", "branches", len(branches))
+	out, err := c.latestPulls("all", branches)
+	if err != nil {
+		return nil, err
+	}
+	for branch, pr := range out {
+		if pr.State == "OPEN" {
+			delete(out, branch)
+		}
+	}
+	return out, nil
+}
+
+// latestPulls returns the most recently updated pull request in state
+// ("open", "closed" or "all") of each of branches, listing pages until all
+// are found or the list ends.
+func (c *Client) latestPulls(state string, branches []string) (map[string]*forge.PRInfo, error) {
+	out := make(map[string]*forge.PRInfo, len(branches))
+	wanted := make(map[string]bool, len(branches))
+	for _, b := range branches {
+		wanted[b] = true
+	}
+	for page := 1; len(out) < len(wanted); page++ {
+		var pulls []pull
+		query := url.Values{
+			"state": {state},
+			"sort":  {"recentupdate"},
+			"page":  {fmt.Sprint(page)},
+			"limit": {fmt.Sprint(pageSize)},
+		}
+		if err := c.do("GET", c.repoPath("/pulls"), query, nil, &pulls); err != nil {
+			return nil, fmt.Errorf("listing PRs: %w", err)
+		}
+		if len(pulls) == 0 {
+			break
+		}
+		for i := range pulls {
+			if p := &pulls[i]; wanted[p.Head.Ref] && out[p.Head.Ref] == nil {
+				out[p.Head.Ref] = p.info()
+			}
+		}
+	}
+	return out, nil
+}
+
+// PRChecks returns the combined commit status of each pull request's head
+// commit, keyed by PR number. Gitea Actions and external CI both report
+// commit statuses.
+func (c *Client) PRChecks(numbers []int) (map[int]string, error) {
+	slog.Debug("PRChecks", "numbers", numbers)
+	out := make(map[int]string, len(numbers))
+	for _, number := range numbers {
+		var p pull
+		if err := c.do("GET", c.repoPath(fmt.Sprintf("/pulls/%d", number)), nil, nil, &p); err != nil {
+			return nil, fmt.Errorf("querying checks: %w", err)
+		}
+		var status struct {
+			State      string `json:"state"`
+			TotalCount int    `json:"total_count"`
+		}
+		if err := c.do("GET", c.repoPath("/commits/"+url.PathEscape(p.Head.SHA)+"/status"), nil, nil, &status); err != nil {
+			return nil, fmt.Errorf("querying checks: %w", err)
+		}
+		if status.TotalCount == 0 {
+			continue
+		}
+		switch status.State {
+		case "success", "warning":
+			out[number] = forge.ChecksPassing
+		case "failure", "error":
+			out[number] = forge.ChecksFailing
+		case "pending":
+			out[number] = forge.ChecksPending
+		}
+	}
+	return out, nil
+}
+
+// PRReviews returns the reviewers whose latest review of each pull request
+// requests changes, keyed by PR number. Dismissed reviews do not count. The
+// API does not tell which review comments are resolved, so
+// UnresolvedThreads stays zero.
+func (c *Client) PRReviews(numbers []int) (map[int]forge.ReviewSummary, error) {
+	slog.Debug("PRReviews", "numbers", numbers)
+	out := make(map[int]forge.ReviewSummary, len(numbers))
+	for _, number := range numbers {
+		var reviews []struct {
+			State     string `json:"state"`
+			Dismissed bool   `json:"dismissed"`
+			User      struct {
+				Login string `json:"login"`
+			} `json:"user"`
+		}
+		if err := c.do("GET", c.repoPath(fmt.Sprintf("/pulls/%d/reviews", number)), nil, nil, &reviews); err != nil {
+			return nil, fmt.Errorf("querying reviews: %w", err)
+		}
+		// Reviews come oldest first; a later verdict replaces an earlier one.
+		latest := make(map[string]string)
+		var order []string
+		for _, r := range reviews {
+			if r.Dismissed || (r.State != "APPROVED" && r.State != "REQUEST_CHANGES") {
+				continue
+			}
+			if _, seen := latest[r.User.Login]; !seen {
+				order = append(order, r.User.Login)
+			}
+			latest[r.User.Login] = r.State
+		}
+		var s forge.ReviewSummary
+		for _, login := range order {
+			if latest[login] == "REQUEST_CHANGES" {
+				s.ChangesRequested = append(s.ChangesRequested, login)
+			}
+		}
+		if len(s.ChangesRequested) > 0 {
+			out[number] = s
+		}
+	}
+	return out, nil
+}
+
+// ListOpenPRs returns up to limit open pull requests (at most a page), the
+// most recently updated first.
+func (c *Client) ListOpenPRs(limit int) ([]*forge.PRInfo, error) {
+	slog.Debug("ListOpenPRs", "limit", limit)
+	var pulls []pull
+	query := url.Values{
+		"state": {"open"},
+		"sort":  {"recentupdate"},
+		"limit": {fmt.Sprint(min(limit, pageSize))},
+	}
+	if err := c.do("GET", c.repoPath("/pulls"), query, nil, &pulls); err != nil {
+		return nil, fmt.Errorf("listing open PRs: %w", err)
+	}
+	out := make([]*forge.PRInfo, len(pulls))
+	for i := range pulls {
+		out[i] = pulls[i].info()
+	}
+	return out, nil
+}
+
+// Collaborators returns the logins of the users who can be asked to review
+// pull requests: the repository's assignable users.
+func (c *Client) Collaborators() ([]string, error) {
+	slog.Debug("Collaborators")
+	var users []struct {
+		Login string `json:"login"`
+	}
+	if err := c.do("GET", c.repoPath("/assignees"), nil, nil, &users); err != nil {
+		return nil, fmt.Errorf("listing collaborators: %w", err)
+	}
+	logins := make([]string, len(users))
+	for i, u := range users {
+		logins[i] = u.Login
+	}
+	return logins, nil
+}
+
+// CreateGist fails: Gitea and Forgejo have no gists.
+func (c *Client) CreateGist(description, filename, content string) (string, error) {
+	return "", errors.New("gists are not supported on Gitea or Forgejo")
+}
+
+// RepoInfo looks up owner/repo with the client's token. It returns an error
+// wrapping forge.ErrRepoNotAccessible when the token cannot see the
+// repository.
+func (c *Client) RepoInfo(owner, repo string) (*forge.RepoInfo, error) {
+	slog.Debug("RepoInfo", "owner", owner, "repo", repo)
+	var r struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+		Private     bool `json:"private"`
+		Permissions struct {
+			Push bool `json:"push"`
+		} `json:"permissions"`
+	}
+	err := c.do("GET", "/repos/"+url.PathEscape(owner)+"/"+url.PathEscape(repo), nil, nil, &r)
+	if isStatus(err, http.StatusNotFound, http.StatusForbidden) {
+		return nil, fmt.Errorf("%s/%s: %w", owner, repo, forge.ErrRepoNotAccessible)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("looking up repository %s/%s: %w", owner, repo, err)
+	}
+	return &forge.RepoInfo{
+		Owner:   r.Owner.Login,
+		Name:    r.Name,
+		Private: r.Private,
+		CanPush: r.Permissions.Push,
+	}, nil
+}
+
+// errNoStacks is returned by the native stack operations, which only GitHub
+// has.
+var errNoStacks = errors.New("native stacked PRs are not supported on Gitea or Forgejo")
+
+// RequiresSignedCommits reports false: Gitea and Forgejo only show branch
+// protection rules, which can require signed commits, to repository admins.
+func (c *Client) RequiresSignedCommits(branch string) (bool, error) { return false, nil }
+
+// StacksEnabled reports false: Gitea and Forgejo have no native stacks.
+func (c *Client) StacksEnabled() (bool, error) { return false, nil }
+
+// FindStackForPR reports that no pull request belongs to a native stack.
+func (c *Client) FindStackForPR(number int) (*forge.Stack, error) { return nil, nil }
+
+// CreateStack fails: Gitea and Forgejo have no native stacks.
+func (c *Client) CreateStack(prNumbers []int) (*forge.Stack, error) { return nil, errNoStacks }
+
+// AddToStack fails: Gitea and Forgejo have no native stacks.
+func (c *Client) AddToStack(stackNumber int, prNumbers []int) (*forge.Stack, error) {
+	return nil, errNoStacks
+}
+
+// Unstack fails: Gitea and Forgejo have no native stacks.
+func (c *Client) Unstack(stackNumber int) (bool, error) { return false, errNoStacks }
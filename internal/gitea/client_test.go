@@ -0,0 +1,352 @@
+package gitea
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/omarkohl/jip/internal/retry"
+)
+
+func newTestClient(t *testing.T, mux *http.ServeMux) *Client {
+	t.Helper()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	c := NewClient("secret", server.URL, "owner", "repo")
+	c.backoff = []retry.Option{retry.WithInitialBackoff(time.Millisecond)}
+	return c
+}
+
+func decode(t *testing.T, r *http.Request) map[string]any {
+	t.Helper()
+	var req map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		t.Fatalf("decoding request: %v", err)
+	}
+	return req
+}
+
+func TestCreatePR_Draft(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/repos/owner/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "token secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		req := decode(t, r)
+		if req["title"] != "WIP: feat: my change" || req["head"] != "fork:jip/a" || req["base"] != "main" {
+			t.Errorf("unexpected request: %v", req)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"number":   7,
+			"state":    "open",
+			"html_url": "https://codeberg.org/owner/repo/pulls/7",
+			"title":    req["title"],
+			"body":     req["body"],
+			"head":     map[string]any{"ref": "jip/a"},
+			"base":     map[string]any{"ref": "main"},
+		})
+	})
+
+	pr, err := newTestClient(t, mux).CreatePR("fork:jip/a", "main", "feat: my change", "body", true)
+	if err != nil {
+		t.Fatalf("CreatePR: %v", err)
+	}
+	want := forge.PRInfo{
+		Number: 7, State: "OPEN", URL: "https://codeberg.org/owner/repo/pulls/7",
+		Title: "feat: my change", Body: "body", HeadRefName: "jip/a", BaseRefName: "main", IsDraft: true,
+	}
+	if *pr != want {
+		t.Errorf("got %+v, want %+v", *pr, want)
+	}
+}
+
+func TestUpdatePR_DraftKeepsTitle(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/pulls/3", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"number": 3, "title": "WIP: feat: x"})
+	})
+	var got map[string]any
+	mux.HandleFunc("PATCH /api/v1/repos/owner/repo/pulls/3", func(w http.ResponseWriter, r *http.Request) {
+		got = decode(t, r)
+		_ = json.NewEncoder(w).Encode(map[string]any{"number": 3})
+	})
+
+	ready := false
+	base := "dev"
+	if err := newTestClient(t, mux).UpdatePR(3, forge.UpdatePROpts{Draft: &ready, Base: &base}); err != nil {
+		t.Fatalf("UpdatePR: %v", err)
+	}
+	if got["title"] != "feat: x" || got["base"] != "dev" {
+		t.Errorf("unexpected update: %v", got)
+	}
+}
+
+func TestSetMilestone(t *testing.T) {
+	mux := http.NewServeMux()
+	var lookups int
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/milestones/{id}", func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		if got := r.PathValue("id"); got != "v2 beta" {
+			t.Errorf("looked up milestone %q", got)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 12, "title": "v2 beta"})
+	})
+	var got map[string]any
+	mux.HandleFunc("PATCH /api/v1/repos/owner/repo/pulls/{number}", func(w http.ResponseWriter, r *http.Request) {
+		got = decode(t, r)
+		_ = json.NewEncoder(w).Encode(map[string]any{"number": 3})
+	})
+
+	client := newTestClient(t, mux)
+	for _, number := range []int{3, 4} {
+		if err := client.SetMilestone(number, "v2 beta"); err != nil {
+			t.Fatalf("SetMilestone: %v", err)
+		}
+		if got["milestone"] != float64(12) {
+			t.Errorf("unexpected update: %v", got)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("milestone looked up %d times, want once", lookups)
+	}
+}
+
+func TestLookupPRsByBranch_Pages(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("state") != "open" {
+			t.Errorf("state = %q", r.URL.Query().Get("state"))
+		}
+		var pulls []map[string]any
+		switch r.URL.Query().Get("page") {
+		case "1":
+			pulls = append(pulls, map[string]any{"number": 1, "state": "open", "head": map[string]any{"ref": "other"}})
+		case "2":
+			pulls = append(pulls, map[string]any{"number": 2, "state": "open", "head": map[string]any{"ref": "jip/b"}})
+		}
+		_ = json.NewEncoder(w).Encode(pulls)
+	})
+
+	prs, err := newTestClient(t, mux).LookupPRsByBranch([]string{"jip/a", "jip/b"})
+	if err != nil {
+		t.Fatalf("LookupPRsByBranch: %v", err)
+	}
+	if len(prs) != 1 || prs["jip/b"] == nil || prs["jip/b"].Number != 2 {
+		t.Errorf("got %v, want only jip/b → #2", prs)
+	}
+}
+
+func TestLookupClosedPRsByBranch(t *testing.T) {
+	mux := http.NewServeMux()
+	pages := 0
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		if r.URL.Query().Get("state") != "all" {
+			t.Errorf("state = %q", r.URL.Query().Get("state"))
+		}
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"number": 3, "state": "closed", "merged": true, "head": map[string]any{"ref": "jip/merged"}},
+			{"number": 2, "state": "open", "head": map[string]any{"ref": "jip/open"}},
+			{"number": 1, "state": "closed", "head": map[string]any{"ref": "jip/merged"}},
+		})
+	})
+
+	prs, err := newTestClient(t, mux).LookupClosedPRsByBranch([]string{"jip/merged", "jip/open"})
+	if err != nil {
+		t.Fatalf("LookupClosedPRsByBranch: %v", err)
+	}
+	if len(prs) != 1 || prs["jip/merged"].Number != 3 || prs["jip/merged"].State != "MERGED" {
+		t.Errorf("got %v, want only jip/merged → merged #3", prs)
+	}
+	if pages != 1 {
+		t.Errorf("listed %d pages, want 1: every branch was on the first", pages)
+	}
+}
+
+func TestFindComment_LastMatchWins(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/issues/5/comments", func(w http.ResponseWriter, r *http.Request) {
+		var comments []map[string]any
+		if r.URL.Query().Get("page") == "1" {
+			comments = []map[string]any{
+				{"id": 10, "body": "<!-- m --> old"},
+				{"id": 11, "body": "unrelated"},
+				{"id": 12, "body": "<!-- m --> new"},
+			}
+		}
+		_ = json.NewEncoder(w).Encode(comments)
+	})
+
+	c, err := newTestClient(t, mux).FindComment(5, "<!-- m -->")
+	if err != nil {
+		t.Fatalf("FindComment: %v", err)
+	}
+	if c == nil || c.ID != 12 {
+		t.Errorf("got %+v, want comment 12", c)
+	}
+}
+
+func TestRepoInfo_NotAccessible(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/repos/owner/secret", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"not found"}`))
+	})
+	mux.HandleFunc("GET /api/v1/repos/owner/repo", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"name": "Repo", "owner": map[string]any{"login": "Owner"}, "permissions": map[string]any{"push": true},
+		})
+	})
+	c := newTestClient(t, mux)
+
+	if _, err := c.RepoInfo("owner", "secret"); !errors.Is(err, forge.ErrRepoNotAccessible) {
+		t.Errorf("err = %v, want ErrRepoNotAccessible", err)
+	}
+	info, err := c.RepoInfo("owner", "repo")
+	if err != nil {
+		t.Fatalf("RepoInfo: %v", err)
+	}
+	if info.Owner != "Owner" || info.Name != "Repo" || !info.CanPush {
+		t.Errorf("got %+v", info)
+	}
+}
+
+func TestPRChecks(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/pulls/{n}", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"head": map[string]any{"sha": "sha" + r.PathValue("n")}})
+	})
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/commits/{sha}/status", func(w http.ResponseWriter, r *http.Request) {
+		status := map[string]map[string]any{
+			"sha1": {"state": "success", "total_count": 2},
+			"sha2": {"state": "failure", "total_count": 1},
+			"sha3": {"state": "pending", "total_count": 0},
+		}[r.PathValue("sha")]
+		_ = json.NewEncoder(w).Encode(status)
+	})
+
+	checks, err := newTestClient(t, mux).PRChecks([]int{1, 2, 3})
+	if err != nil {
+		t.Fatalf("PRChecks: %v", err)
+	}
+	want := map[int]string{1: forge.ChecksPassing, 2: forge.ChecksFailing}
+	if len(checks) != len(want) || checks[1] != want[1] || checks[2] != want[2] {
+		t.Errorf("got %v, want %v", checks, want)
+	}
+}
+
+func TestPRReviews(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/pulls/{n}/reviews", func(w http.ResponseWriter, r *http.Request) {
+		reviews := map[string][]map[string]any{
+			"1": {
+				{"state": "REQUEST_CHANGES", "user": map[string]any{"login": "bob"}},
+				{"state": "COMMENT", "user": map[string]any{"login": "bob"}},
+				{"state": "REQUEST_CHANGES", "user": map[string]any{"login": "carol"}, "dismissed": true},
+			},
+			"2": {
+				{"state": "REQUEST_CHANGES", "user": map[string]any{"login": "bob"}},
+				{"state": "APPROVED", "user": map[string]any{"login": "bob"}},
+			},
+		}[r.PathValue("n")]
+		_ = json.NewEncoder(w).Encode(reviews)
+	})
+
+	got, err := newTestClient(t, mux).PRReviews([]int{1, 2})
+	if err != nil {
+		t.Fatalf("PRReviews: %v", err)
+	}
+	if len(got) != 1 || len(got[1].ChangesRequested) != 1 || got[1].ChangesRequested[0] != "bob" {
+		t.Errorf("got %+v, want bob requesting changes on #1", got)
+	}
+}
+
+func TestServerErrorsAreRetried(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/user", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"login": "alice"})
+	})
+
+	login, err := newTestClient(t, mux).GetAuthenticatedUser()
+	if err != nil || login != "alice" {
+		t.Errorf("got %q, %v; want alice", login, err)
+	}
+}
+
+func TestSplitWIP(t *testing.T) {
+	for _, tc := range []struct {
+		in, title string
+		wip       bool
+	}{
+		{"feat: x", "feat: x", false},
+		{"WIP: feat: x", "feat: x", true},
+		{"wip:feat: x", "feat: x", true},
+		{"[WIP] feat: x", "feat: x", true},
+		{"WIPE out", "WIPE out", false},
+	} {
+		title, wip := splitWIP(tc.in)
+		if title != tc.title || wip != tc.wip {
+			t.Errorf("splitWIP(%q) = %q, %v; want %q, %v", tc.in, title, wip, tc.title, tc.wip)
+		}
+	}
+}
+
+func TestWithWIP(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		draft bool
+		want  string
+	}{
+		{"feat: x", true, "WIP: feat: x"},
+		{"WIP: feat: x", true, "WIP: feat: x"},
+		{"[WIP] feat: x", true, "WIP: feat: x"},
+		{"feat: x", false, "feat: x"},
+		{"WIP: feat: x", false, "feat: x"},
+	} {
+		if got := withWIP(tc.in, tc.draft); got != tc.want {
+			t.Errorf("withWIP(%q, %v) = %q, want %q", tc.in, tc.draft, got, tc.want)
+		}
+	}
+}
+
+func TestListOpenPRs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("state") != "open" || q.Get("limit") != "20" {
+			t.Errorf("PRs listed with %v", q)
+		}
+		_, _ = w.Write([]byte(`[{"number":9,"state":"open","title":"WIP: feat: B","head":{"ref":"b"}}]`))
+	})
+	prs, err := newTestClient(t, mux).ListOpenPRs(20)
+	if err != nil {
+		t.Fatalf("ListOpenPRs: %v", err)
+	}
+	if len(prs) != 1 || prs[0].Number != 9 || prs[0].Title != "feat: B" || !prs[0].IsDraft {
+		t.Errorf("ListOpenPRs = %+v", prs)
+	}
+}
+
+func TestCollaborators(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/repos/owner/repo/assignees", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"login":"alice"},{"login":"bob"}]`))
+	})
+	got, err := newTestClient(t, mux).Collaborators()
+	if err != nil {
+		t.Fatalf("Collaborators: %v", err)
+	}
+	if len(got) != 2 || got[0] != "alice" || got[1] != "bob" {
+		t.Errorf("Collaborators = %q", got)
+	}
+}
@@ -0,0 +1,142 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/retry"
+)
+
+// APIClient sends raw requests to the GitHub API, for jip api. It shares
+// jip's authentication, API host, request logging and retries, but knows
+// nothing about repositories.
+type APIClient struct {
+	http       *http.Client
+	token      string
+	restURL    string
+	graphqlURL string
+	ctx        context.Context
+}
+
+// NewAPIClient creates an APIClient. apiURL is the API base URL as for
+// NewClient; empty means api.github.com.
+func NewAPIClient(token, apiURL string) *APIClient {
+	c := &APIClient{
+		http:       &http.Client{Transport: NewLoggingTransport(nil)},
+		token:      token,
+		restURL:    "https://api.github.com/",
+		graphqlURL: "https://api.github.com/graphql",
+		ctx:        context.Background(),
+	}
+	if apiURL != "" {
+		c.restURL = strings.TrimSuffix(apiURL, "/") + "/"
+		c.graphqlURL = c.restURL + "graphql"
+	}
+	return c
+}
+
+// WithContext returns a copy of the client whose requests ctx cancels.
+func (c *APIClient) WithContext(ctx context.Context) *APIClient {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// APIResponse is the raw answer to an API request.
+type APIResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+// REST sends a request for path, relative to the API base URL (a leading
+// slash is optional, and a query string is kept). body is sent as JSON when
+// non-nil. Server errors and rate limiting are retried; other statuses are
+// returned as they are, for the caller to report.
+func (c *APIClient) REST(method, path string, body []byte) (*APIResponse, error) {
+	url := c.restURL + strings.TrimPrefix(path, "/")
+	slog.Debug("API request", "method", method, "url", url)
+	return c.do(method, url, body)
+}
+
+// GraphQL sends query with variables to the GraphQL endpoint. A response
+// carrying GraphQL errors is returned along with an error naming the first.
+func (c *APIClient) GraphQL(query string, variables map[string]any) (*APIResponse, error) {
+	body, err := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request: %w", err)
+	}
+	resp, err := c.do(http.MethodPost, c.graphqlURL, body)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	var result struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(resp.Body, &result); err == nil && len(result.Errors) > 0 {
+		return resp, fmt.Errorf("GraphQL errors: %s", result.Errors[0].Message)
+	}
+	return resp, nil
+}
+
+func (c *APIClient) do(method, url string, body []byte) (*APIResponse, error) {
+	var resp *APIResponse
+	err := retry.DoContext(c.ctx, func(ctx context.Context) error {
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "bearer "+c.token)
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		httpResp, err := c.http.Do(req)
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(httpResp.Body)
+		_ = httpResp.Body.Close()
+		if err != nil {
+			return err
+		}
+		resp = &APIResponse{StatusCode: httpResp.StatusCode, Body: data}
+
+		// Retry on server errors and rate limiting; client errors are final.
+		if isRateLimited(httpResp) {
+			return rateLimited(httpResp, data)
+		}
+		if httpResp.StatusCode >= 500 {
+			return fmt.Errorf("GitHub API returned %d: %s", httpResp.StatusCode, string(data))
+		}
+		return nil
+	}, retry.WithRetryAfter(retryAfter))
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	return resp, nil
+}
+
+// isRateLimited reports whether GitHub refused a request for exceeding a rate
+// limit, which it signals with 429 or with 403 and no remaining requests.
+func isRateLimited(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != ""
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIClient_REST(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("PATCH /api/v3/repos/owner/repo/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "bearer test-token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"title":"x"}` {
+			t.Errorf("body = %s", body)
+		}
+		_, _ = w.Write([]byte(`{"number":1}`))
+	})
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/pulls/2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewAPIClient("test-token", server.URL+"/api/v3/")
+	resp, err := client.REST("PATCH", "/repos/owner/repo/pulls/1", []byte(`{"title":"x"}`))
+	if err != nil || resp.StatusCode != 200 || string(resp.Body) != `{"number":1}` {
+		t.Fatalf("REST = %+v, %v", resp, err)
+	}
+	// Client errors are not retried and come back for the caller to print.
+	resp, err = client.REST("GET", "repos/owner/repo/pulls/2", nil)
+	if err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("REST = %+v, %v", resp, err)
+	}
+}
+
+func TestAPIClient_RetriesRateLimit(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	resp, err := NewAPIClient("test-token", server.URL).REST("GET", "rate_limit", nil)
+	if err != nil || resp.StatusCode != 200 || calls != 2 {
+		t.Fatalf("REST = %+v, %v after %d calls", resp, err, calls)
+	}
+}
+
+func TestAPIClient_Cancelled(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	// A cancelled context stops the request instead of retrying it.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewAPIClient("test-token", server.URL).WithContext(ctx).REST("GET", "rate_limit", nil); err == nil {
+		t.Fatal("REST succeeded with a cancelled context")
+	}
+	if calls != 0 {
+		t.Errorf("%d requests sent with a cancelled context", calls)
+	}
+}
+
+func TestAPIClient_GraphQL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Variables["number"] != float64(42) {
+			t.Errorf("variables = %v", req.Variables)
+		}
+		if strings.Contains(req.Query, "bad") {
+			_, _ = w.Write([]byte(`{"errors":[{"message":"Field 'bad' doesn't exist"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"viewer":{"login":"me"}}}`))
+	}))
+	defer server.Close()
+
+	client := NewAPIClient("test-token", server.URL)
+	resp, err := client.GraphQL("{viewer{login}}", map[string]any{"number": 42})
+	if err != nil || !strings.Contains(string(resp.Body), `"me"`) {
+		t.Fatalf("GraphQL = %+v, %v", resp, err)
+	}
+	resp, err = client.GraphQL("{bad}", map[string]any{"number": 42})
+	if err == nil || !strings.Contains(err.Error(), "Field 'bad'") || resp == nil {
+		t.Fatalf("expected GraphQL error with the response, got %+v, %v", resp, err)
+	}
+}
@@ -0,0 +1,246 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omarkohl/jip/internal/retry"
+)
+
+// App holds the credentials of a GitHub App. jip acts as one of the app's
+// installations, for bots in organizations that do not allow personal
+// access tokens.
+type App struct {
+	ID int64
+	// PrivateKey is the PEM-encoded RSA key GitHub generated for the app.
+	PrivateKey []byte
+	// InstallationID is the installation to act as; 0 looks up the
+	// installation on the client's repository.
+	InstallationID int64
+}
+
+// tokenSource supplies the token each request is sent with.
+type tokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// staticToken is a token that never changes, such as a personal access
+// token.
+type staticToken string
+
+func (t staticToken) Token(context.Context) (string, error) { return string(t), nil }
+
+// authTransport adds the token of tokens to requests that do not carry
+// one yet.
+type authTransport struct {
+	base   http.RoundTripper
+	tokens tokenSource
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+	token, err := t.tokens.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
+
+// appTokens mints installation tokens of an app. They expire after an hour,
+// so a new one is minted when less than five minutes remain.
+type appTokens struct {
+	app         App
+	key         *rsa.PrivateKey
+	apiURL      string // REST API base URL, ending in "/"
+	owner, repo string // where to look up the installation
+	http        *http.Client
+	now         func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func newAppTokens(app App, apiURL, owner, repo string) (*appTokens, error) {
+	key, err := parseAppKey(app.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if apiURL == "" {
+		apiURL = "https://api.github.com/"
+	}
+	return &appTokens{
+		app:    app,
+		key:    key,
+		apiURL: apiURL,
+		owner:  owner,
+		repo:   repo,
+		http:   &http.Client{Transport: NewLoggingTransport(nil)},
+		now:    time.Now,
+	}, nil
+}
+
+// parseAppKey parses an app's private key, which GitHub hands out in PKCS
+// #1 form; PKCS #8 is accepted too.
+func parseAppKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("the GitHub App private key is not PEM-encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the GitHub App private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the GitHub App private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// appJWT returns the JSON Web Token an app authenticates with as itself:
+// signed with RS256, issued a minute in the past to allow for clock drift,
+// and valid for nine minutes of the ten GitHub allows.
+func appJWT(appID int64, key *rsa.PrivateKey, now time.Time) (string, error) {
+	enc := base64.RawURLEncoding
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	signed := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("signing the GitHub App token: %w", err)
+	}
+	return signed + "." + enc.EncodeToString(sig), nil
+}
+
+// Token returns an installation token, minting a new one when there is none
+// yet or it is about to expire. ctx cancels the requests minting it.
+func (a *appTokens) Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && a.now().Add(5*time.Minute).Before(a.expires) {
+		return a.token, nil
+	}
+	slog.Debug("minting a GitHub App installation token", "app", a.app.ID)
+	jwt, err := appJWT(a.app.ID, a.key, a.now())
+	if err != nil {
+		return "", err
+	}
+	if a.app.InstallationID == 0 {
+		if a.owner == "" {
+			return "", fmt.Errorf("GitHub App %d: the installation ID is needed outside a repository", a.app.ID)
+		}
+		var installation struct {
+			ID int64 `json:"id"`
+		}
+		if err := a.call(ctx, jwt, "GET", "repos/"+a.owner+"/"+a.repo+"/installation", &installation); err != nil {
+			return "", fmt.Errorf("finding the installation of GitHub App %d on %s/%s: %w", a.app.ID, a.owner, a.repo, err)
+		}
+		a.app.InstallationID = installation.ID
+	}
+	var minted struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := a.call(ctx, jwt, "POST", fmt.Sprintf("app/installations/%d/access_tokens", a.app.InstallationID), &minted); err != nil {
+		return "", fmt.Errorf("creating a token for installation %d of GitHub App %d: %w", a.app.InstallationID, a.app.ID, err)
+	}
+	a.token, a.expires = minted.Token, minted.ExpiresAt
+	slog.Debug("minted a GitHub App installation token", "installation", a.app.InstallationID, "expires", a.expires)
+	return a.token, nil
+}
+
+// Expires returns when the current installation token expires.
+func (a *appTokens) Expires() time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.expires
+}
+
+// Slug returns the app's name as it appears in URLs and bot logins.
+func (a *appTokens) Slug(ctx context.Context) (string, error) {
+	jwt, err := appJWT(a.app.ID, a.key, a.now())
+	if err != nil {
+		return "", err
+	}
+	var app struct {
+		Slug string `json:"slug"`
+	}
+	if err := a.call(ctx, jwt, "GET", "app", &app); err != nil {
+		return "", fmt.Errorf("looking up GitHub App %d: %w", a.app.ID, err)
+	}
+	return app.Slug, nil
+}
+
+// call sends a request authenticated as the app itself and decodes the JSON
+// response into out. ctx cancels the request and the waits between retries.
+func (a *appTokens) call(ctx context.Context, jwt, method, path string, out any) error {
+	var refused error // a client error, final rather than retried
+	err := retry.DoContext(ctx, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, method, a.apiURL+path, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+jwt)
+		req.Header.Set("Accept", "application/vnd.github+json")
+		resp, err := a.http.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode >= 300 {
+			var msg struct {
+				Message string `json:"message"`
+			}
+			_ = json.Unmarshal(body, &msg)
+			err := fmt.Errorf("GitHub returned %d: %s", resp.StatusCode, strings.TrimSpace(msg.Message))
+			if isRateLimited(resp) {
+				return rateLimited(resp, body)
+			}
+			if resp.StatusCode < 500 {
+				refused = err
+				return nil
+			}
+			return err
+		}
+		return json.Unmarshal(body, out)
+	}, retry.WithRetryAfter(retryAfter))
+	if err != nil {
+		return err
+	}
+	return refused
+}
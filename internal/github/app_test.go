@@ -0,0 +1,139 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testAppKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func TestAppJWT(t *testing.T) {
+	key, _ := testAppKey(t)
+	now := time.Unix(1_700_000_000, 0)
+	jwt, err := appJWT(123, key, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("JWT has %d parts", len(parts))
+	}
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], sig); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		Iat, Exp int64
+		Iss      string
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Iss != "123" || claims.Iat != now.Unix()-60 || claims.Exp != now.Unix()+540 {
+		t.Errorf("claims = %+v", claims)
+	}
+}
+
+func TestParseAppKey_PKCS8(t *testing.T) {
+	key, _ := testAppKey(t)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseAppKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})); err != nil {
+		t.Errorf("PKCS #8 key: %v", err)
+	}
+	if _, err := parseAppKey([]byte("not a key")); err == nil {
+		t.Error("no error for a key that is not PEM")
+	}
+}
+
+func TestAppClient(t *testing.T) {
+	_, pemKey := testAppKey(t)
+	var minted int
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/installation", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ey") {
+			t.Errorf("installation lookup not authenticated with the app JWT: %q", r.Header.Get("Authorization"))
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 42})
+	})
+	mux.HandleFunc("POST /api/v3/app/installations/42/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		minted++
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"token":      "ghs_" + string(rune('0'+minted)),
+			"expires_at": time.Now().Add(time.Hour).Format(time.RFC3339),
+		})
+	})
+	mux.HandleFunc("GET /api/v3/app", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"slug": "jip-bot"})
+	})
+	mux.HandleFunc("GET /api/v3/user", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("GET /user called with an installation token")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+	})
+	var used []string
+	mux.HandleFunc("GET /api/v3/repos/owner/repo", func(w http.ResponseWriter, r *http.Request) {
+		used = append(used, r.Header.Get("Authorization"))
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": "repo", "owner": map[string]any{"login": "owner"}})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewAppClient(App{ID: 7, PrivateKey: pemKey}, "https://github.com/owner/repo", server.URL+"/api/v3/")
+	if err != nil {
+		t.Fatalf("NewAppClient: %v", err)
+	}
+	tokens := client.tokens.(*appTokens)
+	for range 2 {
+		if _, err := client.RepoInfo("owner", "repo"); err != nil {
+			t.Fatalf("RepoInfo: %v", err)
+		}
+	}
+	// Close to expiry a new token is minted.
+	tokens.now = func() time.Time { return time.Now().Add(56 * time.Minute) }
+	if _, err := client.RepoInfo("owner", "repo"); err != nil {
+		t.Fatalf("RepoInfo: %v", err)
+	}
+	if want := []string{"Bearer ghs_1", "Bearer ghs_1", "Bearer ghs_2"}; strings.Join(used, ",") != strings.Join(want, ",") {
+		t.Errorf("requests sent with %q, want %q", used, want)
+	}
+
+	info, err := client.TokenInfo()
+	if err != nil {
+		t.Fatalf("TokenInfo: %v", err)
+	}
+	if info.Login != "jip-bot[bot]" || info.Kind != TokenApp || info.Scopes != nil {
+		t.Errorf("TokenInfo = %+v", info)
+	}
+
+	// GET /user refuses installation tokens; the app's bot user is used.
+	login, err := client.GetAuthenticatedUser()
+	if err != nil {
+		t.Fatalf("GetAuthenticatedUser: %v", err)
+	}
+	if login != "jip-bot[bot]" {
+		t.Errorf("GetAuthenticatedUser = %q, want jip-bot[bot]", login)
+	}
+}
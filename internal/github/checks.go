@@ -0,0 +1,76 @@
+package github
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/forge"
+)
+
+// PRChecks returns the combined status of the check runs and commit statuses
+// on the head commit of each pull request, keyed by PR number. It uses
+// GitHub's status check rollup, which is what the PR page shows.
+func (c *Client) PRChecks(numbers []int) (map[int]string, error) {
+	slog.Debug("PRChecks", "numbers", numbers)
+	out := make(map[int]string, len(numbers))
+	for start := 0; start < len(numbers); start += prQueryBatchSize {
+		batch := numbers[start:min(start+prQueryBatchSize, len(numbers))]
+		var data struct {
+			Repository map[string]*struct {
+				Commits struct {
+					Nodes []struct {
+						Commit struct {
+							StatusCheckRollup *struct {
+								State string `json:"state"`
+							} `json:"statusCheckRollup"`
+						} `json:"commit"`
+					} `json:"nodes"`
+				} `json:"commits"`
+			} `json:"repository"`
+		}
+		err := c.graphql(buildChecksQuery(batch), map[string]any{
+			"owner": c.owner,
+			"repo":  c.repo,
+		}, &data)
+		if err != nil {
+			slog.Debug("PRChecks failed", "err", err)
+			return nil, fmt.Errorf("querying checks: %w", err)
+		}
+		for i, number := range batch {
+			pr := data.Repository[fmt.Sprintf("p%d", i)]
+			if pr == nil || len(pr.Commits.Nodes) == 0 || pr.Commits.Nodes[0].Commit.StatusCheckRollup == nil {
+				continue
+			}
+			if state := checksState(pr.Commits.Nodes[0].Commit.StatusCheckRollup.State); state != "" {
+				out[number] = state
+			}
+		}
+	}
+	slog.Debug("PRChecks ok", "found", len(out))
+	return out, nil
+}
+
+func buildChecksQuery(numbers []int) string {
+	var b strings.Builder
+	b.WriteString("query($owner:String!,$repo:String!){repository(owner:$owner,name:$repo){")
+	for i, number := range numbers {
+		fmt.Fprintf(&b, `p%d:pullRequest(number:%d){commits(last:1){nodes{commit{statusCheckRollup{state}}}}}`, i, number)
+	}
+	b.WriteString("}}")
+	return b.String()
+}
+
+// checksState maps a GraphQL StatusState to forge.ChecksPassing,
+// forge.ChecksFailing or forge.ChecksPending.
+func checksState(state string) string {
+	switch state {
+	case "SUCCESS":
+		return forge.ChecksPassing
+	case "FAILURE", "ERROR":
+		return forge.ChecksFailing
+	case "PENDING", "EXPECTED":
+		return forge.ChecksPending
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package github
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
+)
+
+func TestPRChecks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !strings.Contains(req.Query, "p0:pullRequest(number:3)") || !strings.Contains(req.Query, "p3:pullRequest(number:9)") {
+			t.Errorf("unexpected query: %s", req.Query)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"repository":{
+			"p0":{"commits":{"nodes":[{"commit":{"statusCheckRollup":{"state":"SUCCESS"}}}]}},
+			"p1":{"commits":{"nodes":[{"commit":{"statusCheckRollup":{"state":"ERROR"}}}]}},
+			"p2":{"commits":{"nodes":[{"commit":{"statusCheckRollup":{"state":"EXPECTED"}}}]}},
+			"p3":{"commits":{"nodes":[{"commit":{"statusCheckRollup":null}}]}}
+		}}}`))
+	}))
+	defer server.Close()
+
+	client := newGraphQLTestClient(t, server, "owner", "repo")
+	got, err := client.PRChecks([]int{3, 5, 7, 9})
+	if err != nil {
+		t.Fatalf("PRChecks: %v", err)
+	}
+	want := map[int]string{3: forge.ChecksPassing, 5: forge.ChecksFailing, 7: forge.ChecksPending}
+	if !maps.Equal(got, want) {
+		t.Errorf("PRChecks = %v, want %v", got, want)
+	}
+}
@@ -4,41 +4,30 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"strings"
 
 	gogithub "github.com/google/go-github/v68/github"
 
-	"github.com/omarkohl/jip/internal/retry"
+	"github.com/omarkohl/jip/internal/forge"
 )
 
-// Service defines the GitHub operations needed by the send pipeline.
-type Service interface {
-	CreatePR(head, base, title, body string, draft bool) (*PRInfo, error)
-	UpdatePR(number int, opts UpdatePROpts) error
-	CommentOnPR(number int, body string) error
-	GetAuthenticatedUser() (string, error)
-	RequestReviewers(number int, reviewers []string) error
-	LookupPRsByBranch(branches []string) (map[string]*PRInfo, error)
-	Owner() string
-	Repo() string
-
-	// Native GitHub stacked-PRs (private preview) operations.
-	StacksEnabled() (bool, error)
-	FindStackForPR(number int) (*Stack, error)
-	CreateStack(prNumbers []int) (*Stack, error)
-	AddToStack(stackNumber int, prNumbers []int) (*Stack, error)
-	Unstack(stackNumber int) (dissolved bool, err error)
-}
-
 // Client wraps go-github for PR mutations and GraphQL queries.
 type Client struct {
 	gh         *gogithub.Client
+	http       *http.Client // for GraphQL requests
 	owner      string
 	repo       string
-	token      string
+	webURL     string // e.g. https://github.com
+	tokens     tokenSource
 	graphqlURL string
+	ctx        context.Context
+	planning   *planningIDs // shared by the copies WithContext makes
 }
 
+// Client is jip's GitHub forge.
+var _ forge.Service = (*Client)(nil)
+
 // NewClient creates a GitHub client for the given repository.
 // remoteURL is the git remote URL (e.g. https://github.com/owner/repo.git),
 // from which owner and repo are parsed.
@@ -49,8 +38,41 @@ func NewClient(token, remoteURL, apiURL string) (*Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("parsing remote URL: %w", err)
 	}
+	c := newClient(staticToken(token), owner, repo, apiURL)
+	c.webURL = hostWebURL(remoteURL)
+	return c, nil
+}
+
+// NewAppClient creates a GitHub client for the given repository that acts
+// as an installation of app, minting installation tokens as they are needed.
+// remoteURL and apiURL are as for NewClient; remoteURL may be empty when
+// app names its installation, for a client not tied to a repository.
+func NewAppClient(app App, remoteURL, apiURL string) (*Client, error) {
+	var owner, repo string
+	if remoteURL != "" {
+		var err error
+		if owner, repo, err = ParseRepoFromURL(remoteURL); err != nil {
+			return nil, fmt.Errorf("parsing remote URL: %w", err)
+		}
+	}
+	tokens, err := newAppTokens(app, apiURL, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	c := newClient(tokens, owner, repo, apiURL)
+	c.webURL = hostWebURL(remoteURL)
+	return c, nil
+}
+
+// NewUserClient creates a GitHub client that is not tied to a repository,
+// for questions about the token and its user such as TokenInfo.
+func NewUserClient(token, apiURL string) *Client {
+	return newClient(staticToken(token), "", "", apiURL)
+}
 
-	gh := gogithub.NewClient(nil).WithAuthToken(token)
+func newClient(tokens tokenSource, owner, repo, apiURL string) *Client {
+	httpClient := &http.Client{Transport: &authTransport{base: NewLoggingTransport(nil), tokens: tokens}}
+	gh := gogithub.NewClient(httpClient)
 	if apiURL != "" {
 		gh, _ = gh.WithEnterpriseURLs(apiURL, apiURL)
 	}
@@ -62,11 +84,25 @@ func NewClient(token, remoteURL, apiURL string) (*Client, error) {
 
 	return &Client{
 		gh:         gh,
+		http:       httpClient,
 		owner:      owner,
 		repo:       repo,
-		token:      token,
+		webURL:     "https://" + DefaultHost,
+		tokens:     tokens,
 		graphqlURL: graphqlURL,
-	}, nil
+		ctx:        context.Background(),
+		planning: &planningIDs{
+			milestones: make(map[string]int),
+			projects:   make(map[projectKey]string),
+		},
+	}
+}
+
+// WithContext returns a copy of the client whose requests ctx cancels.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
 }
 
 // Owner returns the repository owner.
@@ -75,21 +111,16 @@ func (c *Client) Owner() string { return c.owner }
 // Repo returns the repository name.
 func (c *Client) Repo() string { return c.repo }
 
-// UpdatePROpts contains optional fields for updating a PR.
-type UpdatePROpts struct {
-	Title *string
-	Body  *string
-	Base  *string
-	Draft *bool
-}
+// Links returns the web links of the repository.
+func (c *Client) Links() forge.Links { return forge.GitHubLinks(c.webURL, c.owner, c.repo) }
 
 // CreatePR creates a new pull request and returns its info.
-func (c *Client) CreatePR(head, base, title, body string, draft bool) (*PRInfo, error) {
+func (c *Client) CreatePR(head, base, title, body string, draft bool) (*forge.PRInfo, error) {
 	slog.Debug("CreatePR", "head", head, "base", base, "title", title, "draft", draft)
 	var pr *gogithub.PullRequest
-	err := retry.Do(func() error {
+	err := c.retry(func(ctx context.Context) error {
 		var apiErr error
-		pr, _, apiErr = c.gh.PullRequests.Create(context.Background(), c.owner, c.repo, &gogithub.NewPullRequest{
+		pr, _, apiErr = c.gh.PullRequests.Create(ctx, c.owner, c.repo, &gogithub.NewPullRequest{
 			Title: &title,
 			Head:  &head,
 			Base:  &base,
@@ -103,7 +134,7 @@ func (c *Client) CreatePR(head, base, title, body string, draft bool) (*PRInfo,
 		return nil, fmt.Errorf("creating PR: %w", err)
 	}
 	slog.Debug("CreatePR ok", "number", pr.GetNumber())
-	return &PRInfo{
+	return &forge.PRInfo{
 		Number:      pr.GetNumber(),
 		State:       pr.GetState(),
 		URL:         pr.GetHTMLURL(),
@@ -112,11 +143,47 @@ func (c *Client) CreatePR(head, base, title, body string, draft bool) (*PRInfo,
 		HeadRefName: pr.GetHead().GetRef(),
 		BaseRefName: pr.GetBase().GetRef(),
 		IsDraft:     pr.GetDraft(),
+		HeadSHA:     pr.GetHead().GetSHA(),
+		CreatedAt:   pr.GetCreatedAt().Time,
+		MergedAt:    pr.GetMergedAt().Time,
+	}, nil
+}
+
+// GetPR returns the pull request with the given number, in any state. The
+// state is upper-cased as GraphQL reports it: OPEN, CLOSED or MERGED.
+func (c *Client) GetPR(number int) (*forge.PRInfo, error) {
+	slog.Debug("GetPR", "number", number)
+	var pr *gogithub.PullRequest
+	err := c.retry(func(ctx context.Context) error {
+		var apiErr error
+		pr, _, apiErr = c.gh.PullRequests.Get(ctx, c.owner, c.repo, number)
+		return apiErr
+	})
+	if err != nil {
+		slog.Debug("GetPR failed", "err", err)
+		return nil, fmt.Errorf("getting PR #%d: %w", number, err)
+	}
+	state := strings.ToUpper(pr.GetState())
+	if pr.GetMerged() {
+		state = "MERGED"
+	}
+	return &forge.PRInfo{
+		Number:      pr.GetNumber(),
+		State:       state,
+		URL:         pr.GetHTMLURL(),
+		Title:       pr.GetTitle(),
+		Body:        pr.GetBody(),
+		HeadRefName: pr.GetHead().GetRef(),
+		BaseRefName: pr.GetBase().GetRef(),
+		IsDraft:     pr.GetDraft(),
+		HeadSHA:     pr.GetHead().GetSHA(),
+		CreatedAt:   pr.GetCreatedAt().Time,
+		MergedAt:    pr.GetMergedAt().Time,
 	}, nil
 }
 
 // UpdatePR updates fields on an existing pull request.
-func (c *Client) UpdatePR(number int, opts UpdatePROpts) error {
+func (c *Client) UpdatePR(number int, opts forge.UpdatePROpts) error {
 	slog.Debug("UpdatePR", "number", number)
 	update := &gogithub.PullRequest{}
 	if opts.Title != nil {
@@ -128,10 +195,20 @@ func (c *Client) UpdatePR(number int, opts UpdatePROpts) error {
 	if opts.Base != nil {
 		update.Base = &gogithub.PullRequestBranch{Ref: opts.Base}
 	}
-	err := retry.Do(func() error {
-		_, _, apiErr := c.gh.PullRequests.Edit(context.Background(), c.owner, c.repo, number, update)
-		return apiErr
-	})
+	if opts.State != nil {
+		update.State = opts.State
+	}
+	var err error
+	if opts.Title != nil || opts.Body != nil || opts.Base != nil || opts.State != nil {
+		err = c.retry(func(ctx context.Context) error {
+			_, _, apiErr := c.gh.PullRequests.Edit(ctx, c.owner, c.repo, number, update)
+			return apiErr
+		})
+	}
+	// The REST API cannot change the draft state; GraphQL mutations can.
+	if err == nil && opts.Draft != nil {
+		err = c.setDraft(number, *opts.Draft)
+	}
 	if err != nil {
 		slog.Debug("UpdatePR failed", "number", number, "err", err)
 		return fmt.Errorf("updating PR #%d: %w", number, err)
@@ -143,8 +220,8 @@ func (c *Client) UpdatePR(number int, opts UpdatePROpts) error {
 // CommentOnPR posts a comment on a pull request.
 func (c *Client) CommentOnPR(number int, body string) error {
 	slog.Debug("CommentOnPR", "number", number)
-	err := retry.Do(func() error {
-		_, _, apiErr := c.gh.Issues.CreateComment(context.Background(), c.owner, c.repo, number, &gogithub.IssueComment{
+	err := c.retry(func(ctx context.Context) error {
+		_, _, apiErr := c.gh.Issues.CreateComment(ctx, c.owner, c.repo, number, &gogithub.IssueComment{
 			Body: &body,
 		})
 		return apiErr
@@ -157,13 +234,73 @@ func (c *Client) CommentOnPR(number int, body string) error {
 	return nil
 }
 
-// GetAuthenticatedUser returns the login of the authenticated user.
+// FindComment returns the most recent comment on a pull request whose body
+// contains marker, or nil if there is none.
+func (c *Client) FindComment(number int, marker string) (*forge.Comment, error) {
+	slog.Debug("FindComment", "number", number)
+	var found *forge.Comment
+	opts := &gogithub.IssueListCommentsOptions{ListOptions: gogithub.ListOptions{PerPage: 100}}
+	for {
+		var comments []*gogithub.IssueComment
+		var resp *gogithub.Response
+		err := c.retry(func(ctx context.Context) error {
+			var apiErr error
+			comments, resp, apiErr = c.gh.Issues.ListComments(ctx, c.owner, c.repo, number, opts)
+			return apiErr
+		})
+		if err != nil {
+			slog.Debug("FindComment failed", "number", number, "err", err)
+			return nil, fmt.Errorf("listing comments on PR #%d: %w", number, err)
+		}
+		for _, ic := range comments {
+			if strings.Contains(ic.GetBody(), marker) {
+				found = &forge.Comment{ID: ic.GetID(), Body: ic.GetBody()}
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	slog.Debug("FindComment ok", "number", number, "found", found != nil)
+	return found, nil
+}
+
+// EditComment replaces the body of an existing comment.
+func (c *Client) EditComment(id int64, body string) error {
+	slog.Debug("EditComment", "id", id)
+	err := c.retry(func(ctx context.Context) error {
+		_, _, apiErr := c.gh.Issues.EditComment(ctx, c.owner, c.repo, id, &gogithub.IssueComment{
+			Body: &body,
+		})
+		return apiErr
+	})
+	if err != nil {
+		slog.Debug("EditComment failed", "id", id, "err", err)
+		return fmt.Errorf("editing comment %d: %w", id, err)
+	}
+	slog.Debug("EditComment ok", "id", id)
+	return nil
+}
+
+// GetAuthenticatedUser returns the login of the authenticated user. A client
+// acting as a GitHub App installation, which cannot read GET /user, is the
+// app's bot user.
 func (c *Client) GetAuthenticatedUser() (string, error) {
 	slog.Debug("GetAuthenticatedUser")
+	if app, ok := c.tokens.(*appTokens); ok {
+		slug, err := app.Slug(c.ctx)
+		if err != nil {
+			slog.Debug("GetAuthenticatedUser failed", "err", err)
+			return "", fmt.Errorf("getting authenticated user: %w", err)
+		}
+		slog.Debug("GetAuthenticatedUser ok", "login", slug+"[bot]")
+		return slug + "[bot]", nil
+	}
 	var user *gogithub.User
-	err := retry.Do(func() error {
+	err := c.retry(func(ctx context.Context) error {
 		var apiErr error
-		user, _, apiErr = c.gh.Users.Get(context.Background(), "")
+		user, _, apiErr = c.gh.Users.Get(ctx, "")
 		return apiErr
 	})
 	if err != nil {
@@ -174,13 +311,147 @@ func (c *Client) GetAuthenticatedUser() (string, error) {
 	return user.GetLogin(), nil
 }
 
-// RequestReviewers adds reviewers to a pull request.
+// TokenInfo describes the token a client authenticates with.
+type TokenInfo struct {
+	Login string
+	// Kind is one of the Token* kinds, as told by the token's prefix.
+	Kind string
+	// Scopes lists the OAuth scopes of a classic or OAuth token. It is nil
+	// for fine-grained and GitHub App tokens, which do not report scopes.
+	Scopes []string
+	// Expires is when the token expires, as GitHub reports it; empty for
+	// tokens without an expiration.
+	Expires string
+}
+
+// Kinds of tokens. Only classic and OAuth tokens report their scopes.
+const (
+	TokenClassic     = "classic personal access token"
+	TokenFineGrained = "fine-grained personal access token"
+	TokenOAuth       = "OAuth token"
+	TokenApp         = "GitHub App token"
+	TokenUnknown     = "token"
+)
+
+// tokenString returns the client's token when it is a fixed one, and ""
+// otherwise.
+func (c *Client) tokenString() string {
+	if t, ok := c.tokens.(staticToken); ok {
+		return string(t)
+	}
+	return ""
+}
+
+// tokenKind tells the kind of a token by its prefix.
+func tokenKind(token string) string {
+	switch {
+	case strings.HasPrefix(token, "ghp_"):
+		return TokenClassic
+	case strings.HasPrefix(token, "github_pat_"):
+		return TokenFineGrained
+	case strings.HasPrefix(token, "gho_"):
+		return TokenOAuth
+	case strings.HasPrefix(token, "ghu_"), strings.HasPrefix(token, "ghs_"):
+		return TokenApp
+	}
+	return TokenUnknown
+}
+
+// TokenInfo returns who the token belongs to and its scopes. A client
+// acting as a GitHub App installation belongs to the app's bot user.
+func (c *Client) TokenInfo() (*TokenInfo, error) {
+	slog.Debug("TokenInfo")
+	if app, ok := c.tokens.(*appTokens); ok {
+		if _, err := app.Token(c.ctx); err != nil {
+			return nil, err
+		}
+		slug, err := app.Slug(c.ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &TokenInfo{
+			Login:   slug + "[bot]",
+			Kind:    TokenApp,
+			Expires: app.Expires().UTC().Format("2006-01-02 15:04:05 MST"),
+		}, nil
+	}
+	var user *gogithub.User
+	var resp *gogithub.Response
+	err := c.retry(func(ctx context.Context) error {
+		var apiErr error
+		user, resp, apiErr = c.gh.Users.Get(ctx, "")
+		return apiErr
+	})
+	if err != nil {
+		slog.Debug("TokenInfo failed", "err", err)
+		return nil, fmt.Errorf("getting authenticated user: %w", err)
+	}
+	info := &TokenInfo{
+		Login:   user.GetLogin(),
+		Kind:    tokenKind(c.tokenString()),
+		Expires: resp.Header.Get("GitHub-Authentication-Token-Expiration"),
+	}
+	if values, ok := resp.Header["X-Oauth-Scopes"]; ok {
+		info.Scopes = []string{}
+		for _, v := range values {
+			for _, scope := range strings.Split(v, ",") {
+				if scope = strings.TrimSpace(scope); scope != "" {
+					info.Scopes = append(info.Scopes, scope)
+				}
+			}
+		}
+	}
+	slog.Debug("TokenInfo ok", "login", info.Login, "scopes", info.Scopes)
+	return info, nil
+}
+
+// CommitAuthors looks up the authors of the given commits. Commits GitHub
+// does not know (e.g. never pushed) are left out of the result.
+func (c *Client) CommitAuthors(shas []string) (map[string]forge.CommitAuthor, error) {
+	slog.Debug("CommitAuthors", "count", len(shas))
+	authors := make(map[string]forge.CommitAuthor, len(shas))
+	for _, sha := range shas {
+		var commit *gogithub.RepositoryCommit
+		missing := false
+		err := c.retry(func(ctx context.Context) error {
+			var apiErr error
+			commit, _, apiErr = c.gh.Repositories.GetCommit(ctx, c.owner, c.repo, sha, nil)
+			if isNotFound(apiErr) || isUnprocessable(apiErr) {
+				missing = true // not on GitHub, not a transient failure
+				return nil
+			}
+			return apiErr
+		})
+		if err != nil {
+			slog.Debug("CommitAuthors failed", "sha", sha, "err", err)
+			return nil, fmt.Errorf("looking up commit %.12s: %w", sha, err)
+		}
+		if missing {
+			continue
+		}
+		authors[sha] = forge.CommitAuthor{
+			Login: commit.GetAuthor().GetLogin(),
+			Date:  commit.GetCommit().GetAuthor().GetDate().Time,
+		}
+	}
+	slog.Debug("CommitAuthors ok", "found", len(authors))
+	return authors, nil
+}
+
+// RequestReviewers adds reviewers to a pull request. Reviewers of the form
+// org/team are requested as teams.
 func (c *Client) RequestReviewers(number int, reviewers []string) error {
 	slog.Debug("RequestReviewers", "number", number, "reviewers", reviewers)
-	err := retry.Do(func() error {
-		_, _, apiErr := c.gh.PullRequests.RequestReviewers(context.Background(), c.owner, c.repo, number, gogithub.ReviewersRequest{
-			Reviewers: reviewers,
-		})
+	var req gogithub.ReviewersRequest
+	for _, r := range reviewers {
+		if _, team, ok := strings.Cut(r, "/"); ok {
+			req.TeamReviewers = append(req.TeamReviewers, team)
+		} else {
+			req.Reviewers = append(req.Reviewers, r)
+		}
+	}
+	err := c.retry(func(ctx context.Context) error {
+		_, _, apiErr := c.gh.PullRequests.RequestReviewers(ctx, c.owner, c.repo, number, req)
 		return apiErr
 	})
 	if err != nil {
@@ -6,7 +6,11 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/omarkohl/jip/internal/forge"
 )
 
 func TestCreatePR(t *testing.T) {
@@ -80,12 +84,148 @@ func TestUpdatePR(t *testing.T) {
 
 	client := newTestClient(t, server, "owner", "repo")
 	title := "updated title"
-	err := client.UpdatePR(10, UpdatePROpts{Title: &title})
+	err := client.UpdatePR(10, forge.UpdatePROpts{Title: &title})
 	if err != nil {
 		t.Fatalf("UpdatePR: %v", err)
 	}
 }
 
+func TestUpdatePR_Close(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("PATCH /api/v3/repos/owner/repo/pulls/10", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req map[string]any
+		_ = json.Unmarshal(body, &req)
+
+		if req["state"] != "closed" {
+			t.Errorf("unexpected state: %v", req["state"])
+		}
+		if _, ok := req["title"]; ok {
+			t.Errorf("title must not be sent when unchanged: %v", req["title"])
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"number": 10, "state": "closed"})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server, "owner", "repo")
+	closed := "closed"
+	if err := client.UpdatePR(10, forge.UpdatePROpts{State: &closed}); err != nil {
+		t.Fatalf("UpdatePR: %v", err)
+	}
+}
+
+func TestUpdatePR_Draft(t *testing.T) {
+	var mutations []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("PATCH /api/v3/repos/owner/repo/pulls/10", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("no REST edit expected for a draft change alone")
+	})
+	mux.HandleFunc("POST /graphql", func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasPrefix(req.Query, "query") {
+			_, _ = w.Write([]byte(`{"data":{"repository":{"pullRequest":{"id":"PR_kwDO10"}}}}`))
+			return
+		}
+		if req.Variables["id"] != "PR_kwDO10" {
+			t.Errorf("mutation id = %v, want PR_kwDO10", req.Variables["id"])
+		}
+		mutations = append(mutations, req.Query)
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server, "owner", "repo")
+	for _, draft := range []bool{true, false} {
+		if err := client.UpdatePR(10, forge.UpdatePROpts{Draft: &draft}); err != nil {
+			t.Fatalf("UpdatePR(draft=%v): %v", draft, err)
+		}
+	}
+	if len(mutations) != 2 || !strings.Contains(mutations[0], "convertPullRequestToDraft") ||
+		!strings.Contains(mutations[1], "markPullRequestReadyForReview") {
+		t.Errorf("mutations = %q, want convertPullRequestToDraft then markPullRequestReadyForReview", mutations)
+	}
+}
+
+func TestGetPR_Merged(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/pulls/12", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"number":     12,
+			"state":      "closed",
+			"merged":     true,
+			"html_url":   "https://github.com/owner/repo/pull/12",
+			"title":      "feat: bottom",
+			"head":       map[string]any{"ref": "jip/user/bottom/abc"},
+			"base":       map[string]any{"ref": "main"},
+			"created_at": "2026-03-01T10:00:00Z",
+			"merged_at":  "2026-03-02T12:30:00Z",
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server, "owner", "repo")
+	pr, err := client.GetPR(12)
+	if err != nil {
+		t.Fatalf("GetPR: %v", err)
+	}
+	if pr.State != "MERGED" {
+		t.Errorf("State = %q, want MERGED", pr.State)
+	}
+	if pr.HeadRefName != "jip/user/bottom/abc" || pr.BaseRefName != "main" {
+		t.Errorf("unexpected refs: head %q, base %q", pr.HeadRefName, pr.BaseRefName)
+	}
+	if want := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC); !pr.CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt = %v, want %v", pr.CreatedAt, want)
+	}
+	if want := time.Date(2026, 3, 2, 12, 30, 0, 0, time.UTC); !pr.MergedAt.Equal(want) {
+		t.Errorf("MergedAt = %v, want %v", pr.MergedAt, want)
+	}
+}
+
+func TestFindComment_Paginates(t *testing.T) {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/issues/5/comments", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			_ = json.NewEncoder(w).Encode([]map[string]any{
+				{"id": 3, "body": "<!-- m --> newest"},
+				{"id": 4, "body": "unrelated"},
+			})
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/api/v3/repos/owner/repo/issues/5/comments?page=2>; rel="next"`, server.URL))
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"id": 1, "body": "<!-- m --> oldest"},
+			{"id": 2, "body": "unrelated"},
+		})
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server, "owner", "repo")
+	c, err := client.FindComment(5, "<!-- m -->")
+	if err != nil {
+		t.Fatalf("FindComment: %v", err)
+	}
+	if c == nil || c.ID != 3 {
+		t.Errorf("FindComment = %+v, want comment 3", c)
+	}
+
+	none, err := client.FindComment(5, "<!-- absent -->")
+	if err != nil || none != nil {
+		t.Errorf("FindComment(absent) = %+v, %v; want nil, nil", none, err)
+	}
+}
+
 func TestCommentOnPR(t *testing.T) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("POST /api/v3/repos/owner/repo/issues/5/comments", func(w http.ResponseWriter, r *http.Request) {
@@ -141,7 +281,117 @@ func TestRequestReviewers(t *testing.T) {
 	}
 }
 
+func TestRequestReviewers_Teams(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/repos/owner/repo/pulls/7/requested_reviewers", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Reviewers     []string `json:"reviewers"`
+			TeamReviewers []string `json:"team_reviewers"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if fmt.Sprint(req.Reviewers) != "[alice]" || fmt.Sprint(req.TeamReviewers) != "[docs-team]" {
+			t.Errorf("reviewers = %v, teams = %v", req.Reviewers, req.TeamReviewers)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"number": 7})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server, "owner", "repo")
+	if err := client.RequestReviewers(7, []string{"alice", "org/docs-team"}); err != nil {
+		t.Fatalf("RequestReviewers: %v", err)
+	}
+}
+
 // newTestClient creates a Client pointed at a test server.
+func TestTokenInfo(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		header string // "-" for no X-OAuth-Scopes header
+		want   []string
+	}{
+		{"classic", "repo, read:org", []string{"repo", "read:org"}},
+		{"classic without scopes", "", []string{}},
+		{"fine-grained", "-", nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/v3/user", func(w http.ResponseWriter, r *http.Request) {
+				if tt.header != "-" {
+					w.Header().Set("X-OAuth-Scopes", tt.header)
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{"login": "octocat"})
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			info, err := newTestClient(t, server, "owner", "repo").TokenInfo()
+			if err != nil {
+				t.Fatalf("TokenInfo: %v", err)
+			}
+			if info.Login != "octocat" {
+				t.Errorf("login = %q", info.Login)
+			}
+			if (info.Scopes == nil) != (tt.want == nil) || fmt.Sprint(info.Scopes) != fmt.Sprint(tt.want) {
+				t.Errorf("scopes = %#v, want %#v", info.Scopes, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenInfo_KindAndExpiration(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("GitHub-Authentication-Token-Expiration", "2026-12-01 00:00:00 UTC")
+		_ = json.NewEncoder(w).Encode(map[string]any{"login": "octocat"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	info, err := NewUserClient("github_pat_abc", server.URL+"/").TokenInfo()
+	if err != nil {
+		t.Fatalf("TokenInfo: %v", err)
+	}
+	if info.Kind != TokenFineGrained || info.Expires != "2026-12-01 00:00:00 UTC" {
+		t.Errorf("TokenInfo = %+v", info)
+	}
+	for token, want := range map[string]string{"ghp_x": TokenClassic, "gho_x": TokenOAuth, "ghs_x": TokenApp, "abc": TokenUnknown} {
+		if got := tokenKind(token); got != want {
+			t.Errorf("tokenKind(%s) = %q, want %q", token, got, want)
+		}
+	}
+}
+
+func TestCommitAuthors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/commits/aaa", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"sha":    "aaa",
+			"author": map[string]any{"login": "alice"},
+			"commit": map[string]any{"author": map[string]any{"date": "2026-01-02T03:04:05Z"}},
+		})
+	})
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/commits/bbb", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": "No commit found for SHA: bbb"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	authors, err := newTestClient(t, server, "owner", "repo").CommitAuthors([]string{"aaa", "bbb"})
+	if err != nil {
+		t.Fatalf("CommitAuthors: %v", err)
+	}
+	if len(authors) != 1 {
+		t.Fatalf("authors = %v, want only aaa", authors)
+	}
+	a := authors["aaa"]
+	if a.Login != "alice" || a.Date.Format("2006-01-02") != "2026-01-02" {
+		t.Errorf("aaa = %+v", a)
+	}
+}
+
 func newTestClient(t *testing.T, server *httptest.Server, owner, repo string) *Client {
 	t.Helper()
 	remoteURL := fmt.Sprintf("https://github.com/%s/%s", owner, repo)
@@ -0,0 +1,147 @@
+package github
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	gogithub "github.com/google/go-github/v68/github"
+)
+
+// codeOwnersPaths are the places GitHub looks for a CODEOWNERS file, in the
+// order it looks.
+var codeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwners fetches the CODEOWNERS file from the repository's default
+// branch. It returns nil when the repository has none.
+func (c *Client) CodeOwners() ([]byte, error) {
+	slog.Debug("CodeOwners")
+	for _, path := range codeOwnersPaths {
+		var file *gogithub.RepositoryContent
+		missing := false
+		err := c.retry(func(ctx context.Context) error {
+			var apiErr error
+			file, _, _, apiErr = c.gh.Repositories.GetContents(ctx, c.owner, c.repo, path, nil)
+			if isNotFound(apiErr) {
+				missing = true
+				return nil
+			}
+			return apiErr
+		})
+		if err != nil {
+			slog.Debug("CodeOwners failed", "path", path, "err", err)
+			return nil, err
+		}
+		if missing || file == nil {
+			continue
+		}
+		content, err := file.GetContent()
+		if err != nil {
+			return nil, err
+		}
+		slog.Debug("CodeOwners ok", "path", path)
+		return []byte(content), nil
+	}
+	slog.Debug("CodeOwners ok", "found", false)
+	return nil, nil
+}
+
+// CodeOwnerRules are the parsed rules of a CODEOWNERS file.
+type CodeOwnerRules struct {
+	rules []codeOwnerRule
+}
+
+type codeOwnerRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// ParseCodeOwners parses a CODEOWNERS file. Owners are kept as logins and
+// org/team names without the leading @; email owners are dropped, as reviews
+// cannot be requested by email. Lines with invalid patterns are skipped, as
+// GitHub does.
+func ParseCodeOwners(data []byte) *CodeOwnerRules {
+	co := &CodeOwnerRules{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		re, err := codeOwnersPattern(fields[0])
+		if err != nil {
+			continue
+		}
+		var owners []string
+		for _, o := range fields[1:] {
+			if login, ok := strings.CutPrefix(o, "@"); ok && login != "" {
+				owners = append(owners, login)
+			}
+		}
+		co.rules = append(co.rules, codeOwnerRule{pattern: re, owners: owners})
+	}
+	return co
+}
+
+// Owners returns the owners of path (relative to the repository root). The
+// last matching rule wins, and a matching rule without owners means the path
+// has none.
+func (co *CodeOwnerRules) Owners(path string) []string {
+	for i := len(co.rules) - 1; i >= 0; i-- {
+		if co.rules[i].pattern.MatchString(path) {
+			return co.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// codeOwnersPattern translates a gitignore-style CODEOWNERS pattern into a
+// regular expression over repository-relative paths. A pattern matches a
+// path or, unless its last segment has a wildcard, any directory above it;
+// without a slash except at the end it matches at any depth. So docs/*
+// matches docs/a.md but not docs/a/b.md.
+func codeOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case ch == '*':
+			b.WriteString("[^/]*")
+		case ch == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	last := pattern[strings.LastIndex(pattern, "/")+1:]
+	switch {
+	case dirOnly:
+		b.WriteString("/.*$")
+	case strings.ContainsAny(last, "*?"):
+		b.WriteString("$")
+	default:
+		b.WriteString("(?:/.*)?$")
+	}
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,97 @@
+package github
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestParseCodeOwners(t *testing.T) {
+	rules := ParseCodeOwners([]byte(`# Default owners
+*                 @everyone
+*.go              @gopher # Go code
+/docs/            @org/docs-team docs@example.com
+build/            @builder
+/cmd/*.go         @cli
+apps/**/test      @tester
+/vendor/
+`))
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"README.md", []string{"everyone"}},
+		{"internal/jj/runner.go", []string{"gopher"}},
+		{"docs/guide.md", []string{"org/docs-team"}},
+		{"src/docs/guide.md", []string{"everyone"}}, // /docs/ is anchored
+		{"build/out.txt", []string{"builder"}},
+		{"src/build/out.txt", []string{"builder"}}, // build/ is not
+		{"cmd/send.go", []string{"cli"}},
+		{"cmd/sub/send.go", []string{"gopher"}}, // * does not cross directories
+		{"apps/web/e2e/test/login.ts", []string{"tester"}},
+		{"vendor/lib/lib.go", nil}, // no owners
+	}
+	for _, tt := range tests {
+		if got := rules.Owners(tt.path); !slices.Equal(got, tt.want) {
+			t.Errorf("Owners(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCodeOwnersPattern_Nested(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"docs/*", "docs/a.md", true},
+		{"docs/*", "docs/a/b.md", false}, // * matches files, not what is below them
+		{"docs/**", "docs/a/b.md", true},
+		{"docs", "docs/a/b.md", true}, // no wildcard: the directory's contents
+		{"src/docs", "src/docs/a/b.md", true},
+		{"*.md", "docs/a/b.md", true},
+		{"*.md", "docs/x.md/b.txt", false},
+	}
+	for _, tt := range tests {
+		re, err := codeOwnersPattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("codeOwnersPattern(%q): %v", tt.pattern, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCodeOwners_FallsBackToRoot(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/contents/.github/CODEOWNERS", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/contents/CODEOWNERS", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"type":     "file",
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString([]byte("* @alice\n")),
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	data, err := newTestClient(t, server, "owner", "repo").CodeOwners()
+	if err != nil || string(data) != "* @alice\n" {
+		t.Fatalf("CodeOwners = %q, %v", data, err)
+	}
+}
+
+func TestCodeOwners_None(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	data, err := newTestClient(t, server, "owner", "repo").CodeOwners()
+	if err != nil || data != nil {
+		t.Fatalf("CodeOwners = %q, %v; want nil, nil", data, err)
+	}
+}
@@ -0,0 +1,35 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	gogithub "github.com/google/go-github/v68/github"
+)
+
+// CreateGist uploads content as a secret gist holding the file filename and
+// returns the gist's URL. The token needs the gist scope (classic tokens) or
+// the Gists permission (fine-grained tokens); GitHub App installation tokens
+// cannot create gists.
+func (c *Client) CreateGist(description, filename, content string) (string, error) {
+	slog.Debug("CreateGist", "filename", filename, "bytes", len(content))
+	var gist *gogithub.Gist
+	err := c.retry(func(ctx context.Context) error {
+		var apiErr error
+		gist, _, apiErr = c.gh.Gists.Create(ctx, &gogithub.Gist{
+			Description: gogithub.Ptr(description),
+			Public:      gogithub.Ptr(false),
+			Files: map[gogithub.GistFilename]gogithub.GistFile{
+				gogithub.GistFilename(filename): {Content: gogithub.Ptr(content)},
+			},
+		})
+		return apiErr
+	})
+	if err != nil {
+		slog.Debug("CreateGist failed", "err", err)
+		return "", fmt.Errorf("creating gist: %w", err)
+	}
+	slog.Debug("CreateGist ok", "url", gist.GetHTMLURL())
+	return gist.GetHTMLURL(), nil
+}
@@ -0,0 +1,39 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCreateGist(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/gists", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Description string `json:"description"`
+			Public      bool   `json:"public"`
+			Files       map[string]struct {
+				Content string `json:"content"`
+			} `json:"files"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		if req.Public || req.Description != "interdiff" || req.Files["a.diff"].Content != "+x\n" {
+			t.Errorf("gist created with %+v", req)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"html_url":"https://gist.github.com/abc"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	url, err := newTestClient(t, server, "owner", "repo").CreateGist("interdiff", "a.diff", "+x\n")
+	if err != nil {
+		t.Fatalf("CreateGist: %v", err)
+	}
+	if url != "https://gist.github.com/abc" {
+		t.Errorf("CreateGist = %q", url)
+	}
+}
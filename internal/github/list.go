@@ -0,0 +1,68 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	gogithub "github.com/google/go-github/v68/github"
+	"github.com/omarkohl/jip/internal/forge"
+)
+
+// ListOpenPRs returns up to limit open pull requests, the most recently
+// updated first.
+func (c *Client) ListOpenPRs(limit int) ([]*forge.PRInfo, error) {
+	slog.Debug("ListOpenPRs", "limit", limit)
+	var prs []*gogithub.PullRequest
+	err := c.retry(func(ctx context.Context) error {
+		var apiErr error
+		prs, _, apiErr = c.gh.PullRequests.List(ctx, c.owner, c.repo, &gogithub.PullRequestListOptions{
+			State:       "open",
+			Sort:        "updated",
+			Direction:   "desc",
+			ListOptions: gogithub.ListOptions{PerPage: min(limit, 100)},
+		})
+		return apiErr
+	})
+	if err != nil {
+		slog.Debug("ListOpenPRs failed", "err", err)
+		return nil, fmt.Errorf("listing open PRs: %w", err)
+	}
+	out := make([]*forge.PRInfo, 0, len(prs))
+	for _, pr := range prs {
+		out = append(out, &forge.PRInfo{
+			Number:      pr.GetNumber(),
+			State:       strings.ToUpper(pr.GetState()),
+			URL:         pr.GetHTMLURL(),
+			Title:       pr.GetTitle(),
+			HeadRefName: pr.GetHead().GetRef(),
+			BaseRefName: pr.GetBase().GetRef(),
+			IsDraft:     pr.GetDraft(),
+			HeadSHA:     pr.GetHead().GetSHA(),
+		})
+	}
+	slog.Debug("ListOpenPRs ok", "found", len(out))
+	return out, nil
+}
+
+// Collaborators returns the logins of up to 100 users who can be asked to
+// review pull requests: the repository's assignable users.
+func (c *Client) Collaborators() ([]string, error) {
+	slog.Debug("Collaborators")
+	var users []*gogithub.User
+	err := c.retry(func(ctx context.Context) error {
+		var apiErr error
+		users, _, apiErr = c.gh.Issues.ListAssignees(ctx, c.owner, c.repo, &gogithub.ListOptions{PerPage: 100})
+		return apiErr
+	})
+	if err != nil {
+		slog.Debug("Collaborators failed", "err", err)
+		return nil, fmt.Errorf("listing collaborators: %w", err)
+	}
+	logins := make([]string, 0, len(users))
+	for _, u := range users {
+		logins = append(logins, u.GetLogin())
+	}
+	return logins, nil
+}
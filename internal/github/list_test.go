@@ -0,0 +1,46 @@
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestListOpenPRs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("state") != "open" || q.Get("sort") != "updated" || q.Get("per_page") != "20" {
+			t.Errorf("PRs listed with %v", q)
+		}
+		_, _ = w.Write([]byte(`[{"number":9,"state":"open","title":"feat: B","head":{"ref":"b"}},{"number":4,"state":"open","title":"feat: A","draft":true,"head":{"ref":"a"}}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	prs, err := newTestClient(t, server, "owner", "repo").ListOpenPRs(20)
+	if err != nil {
+		t.Fatalf("ListOpenPRs: %v", err)
+	}
+	if len(prs) != 2 || prs[0].Number != 9 || prs[1].Title != "feat: A" || !prs[1].IsDraft || prs[0].State != "OPEN" {
+		t.Errorf("ListOpenPRs = %+v", prs)
+	}
+}
+
+func TestCollaborators(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/assignees", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"login":"alice"},{"login":"bob"}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	got, err := newTestClient(t, server, "owner", "repo").Collaborators()
+	if err != nil {
+		t.Fatalf("Collaborators: %v", err)
+	}
+	if want := []string{"alice", "bob"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Collaborators = %q, want %q", got, want)
+	}
+}
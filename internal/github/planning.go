@@ -0,0 +1,129 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	gogithub "github.com/google/go-github/v68/github"
+)
+
+// planningIDs holds the milestone numbers and project IDs a client looked
+// up, so that a stack of PRs looks each up once instead of once per PR.
+type planningIDs struct {
+	mu         sync.Mutex
+	milestones map[string]int
+	projects   map[projectKey]string
+}
+
+// projectKey identifies the project number of owner.
+type projectKey struct {
+	owner  string
+	number int
+}
+
+// SetMilestone puts the pull request into the open milestone with the given
+// title.
+func (c *Client) SetMilestone(number int, milestone string) error {
+	slog.Debug("SetMilestone", "number", number, "milestone", milestone)
+	id, err := c.milestoneNumber(milestone)
+	if err == nil {
+		err = c.retry(func(ctx context.Context) error {
+			_, _, apiErr := c.gh.Issues.Edit(ctx, c.owner, c.repo, number, &gogithub.IssueRequest{Milestone: &id})
+			return apiErr
+		})
+	}
+	if err != nil {
+		slog.Debug("SetMilestone failed", "number", number, "err", err)
+		return fmt.Errorf("setting the milestone of PR #%d: %w", number, err)
+	}
+	slog.Debug("SetMilestone ok", "number", number)
+	return nil
+}
+
+// milestoneNumber returns the number of the open milestone titled title.
+func (c *Client) milestoneNumber(title string) (int, error) {
+	c.planning.mu.Lock()
+	defer c.planning.mu.Unlock()
+	if n, ok := c.planning.milestones[title]; ok {
+		return n, nil
+	}
+	opts := &gogithub.MilestoneListOptions{State: "open", ListOptions: gogithub.ListOptions{PerPage: 100}}
+	for {
+		var milestones []*gogithub.Milestone
+		var resp *gogithub.Response
+		err := c.retry(func(ctx context.Context) error {
+			var apiErr error
+			milestones, resp, apiErr = c.gh.Issues.ListMilestones(ctx, c.owner, c.repo, opts)
+			return apiErr
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, m := range milestones {
+			if m.GetTitle() == title {
+				c.planning.milestones[title] = m.GetNumber()
+				return m.GetNumber(), nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return 0, fmt.Errorf("no open milestone %q in %s/%s", title, c.owner, c.repo)
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// AddToProject adds the pull request to the Projects (v2) board with the
+// given number of owner, a user or an organization. A PR already on the
+// board is left as it is.
+func (c *Client) AddToProject(number int, owner string, project int) error {
+	slog.Debug("AddToProject", "number", number, "owner", owner, "project", project)
+	projectID, err := c.projectID(owner, project)
+	var prID string
+	if err == nil {
+		prID, err = c.pullRequestID(number)
+	}
+	if err == nil {
+		err = c.graphql(
+			`mutation($project:ID!,$content:ID!){addProjectV2ItemById(input:{projectId:$project,contentId:$content}){item{id}}}`,
+			map[string]any{"project": projectID, "content": prID},
+			nil)
+	}
+	if err != nil {
+		slog.Debug("AddToProject failed", "number", number, "err", err)
+		return fmt.Errorf("adding PR #%d to project %s/%d: %w", number, owner, project, err)
+	}
+	slog.Debug("AddToProject ok", "number", number)
+	return nil
+}
+
+// projectID returns the node ID of the Projects (v2) board with the given
+// number of owner.
+func (c *Client) projectID(owner string, project int) (string, error) {
+	c.planning.mu.Lock()
+	defer c.planning.mu.Unlock()
+	key := projectKey{owner: owner, number: project}
+	if id, ok := c.planning.projects[key]; ok {
+		return id, nil
+	}
+	var lookup struct {
+		RepositoryOwner *struct {
+			ProjectV2 *struct {
+				ID string `json:"id"`
+			} `json:"projectV2"`
+		} `json:"repositoryOwner"`
+	}
+	err := c.graphql(
+		`query($login:String!,$project:Int!){repositoryOwner(login:$login){...on Organization{projectV2(number:$project){id}} ...on User{projectV2(number:$project){id}}}}`,
+		map[string]any{"login": owner, "project": project},
+		&lookup)
+	if err != nil {
+		return "", err
+	}
+	if lookup.RepositoryOwner == nil || lookup.RepositoryOwner.ProjectV2 == nil {
+		return "", fmt.Errorf("project %s/%d not found", owner, project)
+	}
+	c.planning.projects[key] = lookup.RepositoryOwner.ProjectV2.ID
+	return lookup.RepositoryOwner.ProjectV2.ID, nil
+}
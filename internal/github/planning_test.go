@@ -0,0 +1,105 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetMilestone(t *testing.T) {
+	var edited map[string]any
+	var lists int
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/milestones", func(w http.ResponseWriter, r *http.Request) {
+		lists++
+		if r.URL.Query().Get("state") != "open" {
+			t.Errorf("milestones listed with state %q, want open", r.URL.Query().Get("state"))
+		}
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s?page=2>; rel="next"`, "http://"+r.Host+r.URL.Path))
+			_, _ = w.Write([]byte(`[{"number":1,"title":"v1.0"}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"number":4,"title":"v2.0"}]`))
+	})
+	mux.HandleFunc("PATCH /api/v3/repos/owner/repo/issues/{number}", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&edited)
+		_, _ = w.Write([]byte(`{"number":7}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server, "owner", "repo")
+	for _, number := range []int{7, 8} {
+		if err := client.SetMilestone(number, "v2.0"); err != nil {
+			t.Fatalf("SetMilestone: %v", err)
+		}
+		if edited["milestone"] != float64(4) {
+			t.Errorf("PR edited with %v, want milestone 4", edited)
+		}
+	}
+	if lists != 2 {
+		t.Errorf("milestones listed in %d pages, want the 2 pages once", lists)
+	}
+
+	err := client.SetMilestone(7, "v3.0")
+	if err == nil || !strings.Contains(err.Error(), `no open milestone "v3.0"`) {
+		t.Errorf("unknown milestone: got %v", err)
+	}
+}
+
+func TestAddToProject(t *testing.T) {
+	var mutation graphQLRequest
+	var lookups int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(req.Query, "repositoryOwner"):
+			lookups++
+			if req.Variables["login"] != "acme" || req.Variables["project"] != float64(3) {
+				t.Errorf("looked up project %v", req.Variables)
+			}
+			_, _ = w.Write([]byte(`{"data":{"repositoryOwner":{"projectV2":{"id":"PVT_kw3"}}}}`))
+		case strings.HasPrefix(req.Query, "query"):
+			_, _ = w.Write([]byte(`{"data":{"repository":{"pullRequest":{"id":"PR_kwDO7"}}}}`))
+		default:
+			mutation = req
+			_, _ = w.Write([]byte(`{"data":{"addProjectV2ItemById":{"item":{"id":"PVTI_1"}}}}`))
+		}
+	}))
+	defer server.Close()
+
+	client := newGraphQLTestClient(t, server, "owner", "repo")
+	if err := client.AddToProject(7, "acme", 3); err != nil {
+		t.Fatalf("AddToProject: %v", err)
+	}
+	if !strings.Contains(mutation.Query, "addProjectV2ItemById") ||
+		mutation.Variables["project"] != "PVT_kw3" || mutation.Variables["content"] != "PR_kwDO7" {
+		t.Errorf("mutation = %+v", mutation)
+	}
+	if err := client.AddToProject(8, "acme", 3); err != nil {
+		t.Fatalf("AddToProject: %v", err)
+	}
+	if lookups != 1 {
+		t.Errorf("project looked up %d times, want once", lookups)
+	}
+}
+
+func TestAddToProject_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"repositoryOwner":{}}}`))
+	}))
+	defer server.Close()
+
+	client := newGraphQLTestClient(t, server, "owner", "repo")
+	err := client.AddToProject(7, "acme", 9)
+	if err == nil || !strings.Contains(err.Error(), "project acme/9 not found") {
+		t.Errorf("got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package github
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,68 +10,127 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/omarkohl/jip/internal/retry"
+	"github.com/omarkohl/jip/internal/forge"
 )
 
-// PRInfo holds the essential fields of a pull request.
-type PRInfo struct {
-	Number      int    `json:"number"`
-	State       string `json:"state"`
-	URL         string `json:"url"`
-	Title       string `json:"title"`
-	Body        string `json:"body"`
-	HeadRefName string `json:"headRefName"`
-	BaseRefName string `json:"baseRefName"`
-	IsDraft     bool   `json:"isDraft"`
-}
-
 type graphQLRequest struct {
 	Query     string         `json:"query"`
 	Variables map[string]any `json:"variables"`
 }
 
 type prNodes struct {
-	Nodes []PRInfo `json:"nodes"`
+	Nodes []forge.PRInfo `json:"nodes"`
 }
 
+// prQueryBatchSize is the number of branches looked up per GraphQL query.
+// Every branch is an aliased pullRequests connection; GitHub limits the
+// nodes and the complexity of a single query, so large stacks (or many
+// stacks sent at once) are split into several queries.
+const prQueryBatchSize = 50
+
 // LookupPRsByBranch queries GitHub's GraphQL API for open PRs matching the
 // given head branch names. Returns a map from branch name to PRInfo for
 // branches that have an open PR.
-func (c *Client) LookupPRsByBranch(branches []string) (map[string]*PRInfo, error) {
+//
+// The branches are paged through in batches of prQueryBatchSize. Each
+// branch only needs its most recently updated open PR (first:1), so no
+// connection within a query has further pages.
+func (c *Client) LookupPRsByBranch(branches []string) (map[string]*forge.PRInfo, error) {
 	slog.Debug("LookupPRsByBranch", "branches", branches)
-	if len(branches) == 0 {
-		return map[string]*PRInfo{}, nil
+	out, err := c.lookupPRs(branches, "OPEN")
+	if err != nil {
+		return nil, err
 	}
+	slog.Debug("LookupPRsByBranch ok", "matched", len(out), "queries", (len(branches)+prQueryBatchSize-1)/prQueryBatchSize)
+	return out, nil
+}
 
-	query := buildPRQuery(branches)
-	reqBody := graphQLRequest{
-		Query: query,
-		Variables: map[string]any{
-			"owner": c.owner,
-			"repo":  c.repo,
-		},
+// LookupClosedPRsByBranch returns, for each branch whose most recently
+// updated PR is merged or closed, that PR. Branches with an open PR or none
+// at all are left out.
+func (c *Client) LookupClosedPRsByBranch(branches []string) (map[string]*forge.PRInfo, error) {
+	slog.Debug("LookupClosedPRsByBranch", "branches", len(branches))
+	prs, err := c.lookupPRs(branches, "OPEN,CLOSED,MERGED")
+	if err != nil {
+		return nil, err
 	}
+	for branch, pr := range prs {
+		if pr.State == "OPEN" {
+			delete(prs, branch)
+		}
+	}
+	return prs, nil
+}
+
+// lookupPRs returns the most recently updated PR in one of states (a GraphQL
+// PullRequestState list) of each branch, in batches of prQueryBatchSize.
+func (c *Client) lookupPRs(branches []string, states string) (map[string]*forge.PRInfo, error) {
+	out := make(map[string]*forge.PRInfo, len(branches))
+	for start := 0; start < len(branches); start += prQueryBatchSize {
+		batch := branches[start:min(start+prQueryBatchSize, len(branches))]
+		prs, err := c.lookupPRBatch(batch, states)
+		if err != nil {
+			return nil, err
+		}
+		for branch, pr := range prs {
+			out[branch] = pr
+		}
+	}
+	return out, nil
+}
+
+// lookupPRBatch runs a single GraphQL query for branches.
+func (c *Client) lookupPRBatch(branches []string, states string) (map[string]*forge.PRInfo, error) {
+	var data struct {
+		Repository map[string]prNodes
+	}
+	err := c.graphql(buildPRQuery(branches, states), map[string]any{
+		"owner": c.owner,
+		"repo":  c.repo,
+	}, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]*forge.PRInfo, len(branches))
+	for i, branch := range branches {
+		alias := fmt.Sprintf("b%d", i)
+		if nodes, ok := data.Repository[alias]; ok && len(nodes.Nodes) > 0 {
+			pr := nodes.Nodes[0]
+			out[branch] = &pr
+		}
+	}
+	return out, nil
+}
 
-	body, err := json.Marshal(reqBody)
+// graphql sends query with variables to the GraphQL endpoint and decodes the
+// response's data into data. GraphQL errors are returned as an error naming
+// the first.
+func (c *Client) graphql(query string, variables map[string]any, data any) error {
+	body, err := json.Marshal(graphQLRequest{Query: query, Variables: variables})
 	if err != nil {
-		return nil, fmt.Errorf("marshaling request: %w", err)
+		return fmt.Errorf("marshaling request: %w", err)
 	}
 
 	req, err := http.NewRequest("POST", c.graphqlURL, bytes.NewReader(body))
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return fmt.Errorf("creating request: %w", err)
 	}
-	req.Header.Set("Authorization", "bearer "+c.token)
+	token, err := c.tokens.Token(c.ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 
 	var resp *http.Response
 	var rawBody []byte
-	err = retry.Do(func() error {
+	err = c.retry(func(ctx context.Context) error {
 		// Reset the request body for each attempt.
 		req.Body = io.NopCloser(bytes.NewReader(body))
 
 		var doErr error
-		resp, doErr = http.DefaultClient.Do(req)
+		resp, doErr = c.http.Do(req.WithContext(ctx))
 		if doErr != nil {
 			return doErr
 		}
@@ -81,51 +141,48 @@ func (c *Client) LookupPRsByBranch(branches []string) (map[string]*PRInfo, error
 			return doErr
 		}
 
-		// Retry on server errors (5xx); don't retry client errors (4xx).
+		// Retry on server errors (5xx) and rate limiting; don't retry
+		// other client errors (4xx).
+		if isRateLimited(resp) {
+			return rateLimited(resp, rawBody)
+		}
 		if resp.StatusCode >= 500 {
 			return fmt.Errorf("GitHub API returned %d: %s", resp.StatusCode, string(rawBody))
 		}
 		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("sending request: %w", err)
+		return fmt.Errorf("sending request: %w", err)
 	}
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("GitHub API returned %d: %s", resp.StatusCode, string(rawBody))
+		return fmt.Errorf("GitHub API returned %d: %s", resp.StatusCode, string(rawBody))
 	}
 
 	// Parse the GraphQL response envelope.
 	var result struct {
-		Data struct {
-			Repository map[string]prNodes
-		} `json:"data"`
+		Data   json.RawMessage `json:"data"`
 		Errors []struct {
 			Message string `json:"message"`
 		} `json:"errors"`
 	}
 	if err := json.Unmarshal(rawBody, &result); err != nil {
-		return nil, fmt.Errorf("parsing response: %w", err)
+		return fmt.Errorf("parsing response: %w", err)
 	}
 
 	if len(result.Errors) > 0 {
-		return nil, fmt.Errorf("GraphQL errors: %s", result.Errors[0].Message)
+		return fmt.Errorf("GraphQL errors: %s", result.Errors[0].Message)
 	}
-
-	out := make(map[string]*PRInfo, len(branches))
-	for i, branch := range branches {
-		alias := fmt.Sprintf("b%d", i)
-		if nodes, ok := result.Data.Repository[alias]; ok && len(nodes.Nodes) > 0 {
-			pr := nodes.Nodes[0]
-			out[branch] = &pr
-		}
+	if data == nil || len(result.Data) == 0 || string(result.Data) == "null" {
+		return nil
 	}
-
-	slog.Debug("LookupPRsByBranch ok", "matched", len(out))
-	return out, nil
+	if err := json.Unmarshal(result.Data, data); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	return nil
 }
 
-func buildPRQuery(branches []string) string {
+func buildPRQuery(branches []string, states string) string {
 	var b strings.Builder
 	b.WriteString("query($owner:String!,$repo:String!){repository(owner:$owner,name:$repo){")
 	for i, branch := range branches {
@@ -133,9 +190,66 @@ func buildPRQuery(branches []string) string {
 		escaped := strings.ReplaceAll(branch, `\`, `\\`)
 		escaped = strings.ReplaceAll(escaped, `"`, `\"`)
 		fmt.Fprintf(&b,
-			`%s:pullRequests(headRefName:"%s",first:1,states:[OPEN],orderBy:{field:UPDATED_AT,direction:DESC}){nodes{number state url title body headRefName baseRefName isDraft}}`,
-			alias, escaped)
+			`%s:pullRequests(headRefName:"%s",first:1,states:[%s],orderBy:{field:UPDATED_AT,direction:DESC}){nodes{number state url title body headRefName baseRefName isDraft headRefOid}}`,
+			alias, escaped, states)
 	}
 	b.WriteString("}}")
 	return b.String()
 }
+
+// EnableAutoMerge turns on GitHub's auto-merge for a pull request, so it is
+// merged with method (one of forge.AutoMergeMethods) once its required
+// checks and reviews pass. Enabling it again on a PR that already has it is harmless.
+func (c *Client) EnableAutoMerge(number int, method string) error {
+	slog.Debug("EnableAutoMerge", "number", number, "method", method)
+	id, err := c.pullRequestID(number)
+	if err == nil {
+		err = c.graphql(
+			`mutation($id:ID!,$method:PullRequestMergeMethod!){enablePullRequestAutoMerge(input:{pullRequestId:$id,mergeMethod:$method}){clientMutationId}}`,
+			map[string]any{"id": id, "method": strings.ToUpper(method)},
+			nil)
+	}
+	if err != nil {
+		slog.Debug("EnableAutoMerge failed", "number", number, "err", err)
+		return fmt.Errorf("enabling auto-merge on PR #%d: %w", number, err)
+	}
+	slog.Debug("EnableAutoMerge ok", "number", number)
+	return nil
+}
+
+// setDraft converts a pull request to a draft, or marks a draft ready for
+// review.
+func (c *Client) setDraft(number int, draft bool) error {
+	id, err := c.pullRequestID(number)
+	if err != nil {
+		return err
+	}
+	mutation := `mutation($id:ID!){markPullRequestReadyForReview(input:{pullRequestId:$id}){clientMutationId}}`
+	if draft {
+		mutation = `mutation($id:ID!){convertPullRequestToDraft(input:{pullRequestId:$id}){clientMutationId}}`
+	}
+	return c.graphql(mutation, map[string]any{"id": id}, nil)
+}
+
+// pullRequestID returns the GraphQL node ID of the pull request, which
+// mutations take instead of its number.
+func (c *Client) pullRequestID(number int) (string, error) {
+	var lookup struct {
+		Repository struct {
+			PullRequest *struct {
+				ID string `json:"id"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	err := c.graphql(
+		`query($owner:String!,$repo:String!,$number:Int!){repository(owner:$owner,name:$repo){pullRequest(number:$number){id}}}`,
+		map[string]any{"owner": c.owner, "repo": c.repo, "number": number},
+		&lookup)
+	if err != nil {
+		return "", err
+	}
+	if lookup.Repository.PullRequest == nil {
+		return "", fmt.Errorf("PR not found")
+	}
+	return lookup.Repository.PullRequest.ID, nil
+}
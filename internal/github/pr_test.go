@@ -1,11 +1,15 @@
 package github
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
 )
 
 const testAPIResponse = `{
@@ -195,7 +199,7 @@ func TestLookupPRsByBranch_APIError(t *testing.T) {
 
 	client := newGraphQLTestClient(t, server, "owner", "repo")
 	// Override token to test bad auth.
-	client.token = "bad-token"
+	client.tokens = staticToken("bad-token")
 	_, err := client.LookupPRsByBranch([]string{"branch"})
 	if err == nil {
 		t.Fatal("expected error for 401 response")
@@ -217,10 +221,38 @@ func TestLookupPRsByBranch_GraphQLError(t *testing.T) {
 	}
 }
 
+func TestLookupClosedPRsByBranch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		if !strings.Contains(req.Query, "states:[OPEN,CLOSED,MERGED]") {
+			t.Errorf("query does not ask for every state: %s", req.Query)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"repository":{
+			"b0":{"nodes":[{"number":3,"state":"MERGED","headRefName":"jip/merged"}]},
+			"b1":{"nodes":[{"number":4,"state":"OPEN","headRefName":"jip/open"}]},
+			"b2":{"nodes":[{"number":5,"state":"CLOSED","headRefName":"jip/closed"}]},
+			"b3":{"nodes":[]}}}}`))
+	}))
+	defer server.Close()
+
+	client := newGraphQLTestClient(t, server, "acme-corp", "widgets")
+	prs, err := client.LookupClosedPRsByBranch([]string{"jip/merged", "jip/open", "jip/closed", "jip/none"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(prs) != 2 || prs["jip/merged"].Number != 3 || prs["jip/closed"].Number != 5 {
+		t.Errorf("got %+v, want only the merged and closed PRs", prs)
+	}
+}
+
 func TestBuildPRQuery_SingleBranch(t *testing.T) {
-	q := buildPRQuery([]string{"my-branch"})
+	q := buildPRQuery([]string{"my-branch"}, "OPEN")
 	want := `query($owner:String!,$repo:String!){repository(owner:$owner,name:$repo){` +
-		`b0:pullRequests(headRefName:"my-branch",first:1,states:[OPEN],orderBy:{field:UPDATED_AT,direction:DESC}){nodes{number state url title body headRefName baseRefName isDraft}}` +
+		`b0:pullRequests(headRefName:"my-branch",first:1,states:[OPEN],orderBy:{field:UPDATED_AT,direction:DESC}){nodes{number state url title body headRefName baseRefName isDraft headRefOid}}` +
 		`}}`
 	if q != want {
 		t.Errorf("query mismatch:\ngot:  %s\nwant: %s", q, want)
@@ -228,7 +260,7 @@ func TestBuildPRQuery_SingleBranch(t *testing.T) {
 }
 
 func TestBuildPRQuery_MultipleBranches(t *testing.T) {
-	q := buildPRQuery([]string{"branch-a", "branch-b", "branch-c"})
+	q := buildPRQuery([]string{"branch-a", "branch-b", "branch-c"}, "OPEN")
 	for _, alias := range []string{`b0:pullRequests(headRefName:"branch-a"`, `b1:pullRequests(headRefName:"branch-b"`, `b2:pullRequests(headRefName:"branch-c"`} {
 		if !strings.Contains(q, alias) {
 			t.Errorf("query missing %q:\n%s", alias, q)
@@ -237,8 +269,138 @@ func TestBuildPRQuery_MultipleBranches(t *testing.T) {
 }
 
 func TestBuildPRQuery_EscapesQuotes(t *testing.T) {
-	q := buildPRQuery([]string{`branch"with"quotes`})
+	q := buildPRQuery([]string{`branch"with"quotes`}, "OPEN")
 	if !strings.Contains(q, `branch\"with\"quotes`) {
 		t.Errorf("expected escaped quotes in query: %s", q)
 	}
 }
+
+func TestLookupPRsByBranch_BatchesLargeLookups(t *testing.T) {
+	aliasRe := regexp.MustCompile(`(b\d+):pullRequests\(headRefName:"([^"]*)"`)
+	var queries int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		matches := aliasRe.FindAllStringSubmatch(req.Query, -1)
+		if len(matches) > prQueryBatchSize {
+			t.Errorf("query has %d branches, batch size is %d", len(matches), prQueryBatchSize)
+		}
+		repo := make(map[string]prNodes, len(matches))
+		for _, m := range matches {
+			var n int
+			_, _ = fmt.Sscanf(m[2], "branch-%d", &n)
+			if n%2 == 0 { // only even branches have a PR
+				repo[m[1]] = prNodes{Nodes: []forge.PRInfo{{Number: 1000 + n, HeadRefName: m[2]}}}
+			} else {
+				repo[m[1]] = prNodes{}
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"repository": repo}})
+	}))
+	defer server.Close()
+
+	client := newGraphQLTestClient(t, server, "owner", "repo")
+	var branches []string
+	for i := range 157 {
+		branches = append(branches, fmt.Sprintf("branch-%d", i))
+	}
+	prs, err := client.LookupPRsByBranch(branches)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if queries != 4 {
+		t.Errorf("expected 4 queries for 157 branches, got %d", queries)
+	}
+	if len(prs) != 79 {
+		t.Errorf("expected 79 PRs, got %d", len(prs))
+	}
+	for i, b := range branches {
+		pr := prs[b]
+		if i%2 == 1 {
+			if pr != nil {
+				t.Errorf("%s: unexpected PR #%d", b, pr.Number)
+			}
+			continue
+		}
+		if pr == nil || pr.Number != 1000+i {
+			t.Errorf("%s: got %+v, want PR #%d", b, pr, 1000+i)
+		}
+	}
+}
+
+func TestLookupPRsByBranch_BatchErrorFailsLookup(t *testing.T) {
+	var queries int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		if queries == 2 {
+			_, _ = w.Write([]byte(`{"errors":[{"message":"boom"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"repository":{}}}`))
+	}))
+	defer server.Close()
+
+	client := newGraphQLTestClient(t, server, "owner", "repo")
+	branches := make([]string, prQueryBatchSize+1)
+	for i := range branches {
+		branches[i] = fmt.Sprintf("branch-%d", i)
+	}
+	if _, err := client.LookupPRsByBranch(branches); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected the second batch's error, got %v", err)
+	}
+}
+
+func TestEnableAutoMerge(t *testing.T) {
+	var mutation graphQLRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasPrefix(req.Query, "query") {
+			if req.Variables["number"] != float64(7) {
+				t.Errorf("looked up PR %v, want 7", req.Variables["number"])
+			}
+			_, _ = w.Write([]byte(`{"data":{"repository":{"pullRequest":{"id":"PR_kwDO7"}}}}`))
+			return
+		}
+		mutation = req
+		_, _ = w.Write([]byte(`{"data":{"enablePullRequestAutoMerge":{"clientMutationId":null}}}`))
+	}))
+	defer server.Close()
+
+	client := newGraphQLTestClient(t, server, "owner", "repo")
+	if err := client.EnableAutoMerge(7, "squash"); err != nil {
+		t.Fatalf("EnableAutoMerge: %v", err)
+	}
+	if !strings.Contains(mutation.Query, "enablePullRequestAutoMerge") {
+		t.Fatalf("no enablePullRequestAutoMerge mutation sent, got %q", mutation.Query)
+	}
+	if mutation.Variables["id"] != "PR_kwDO7" || mutation.Variables["method"] != "SQUASH" {
+		t.Errorf("mutation variables = %v, want id PR_kwDO7 and method SQUASH", mutation.Variables)
+	}
+}
+
+func TestEnableAutoMerge_NotAllowed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasPrefix(req.Query, "query") {
+			_, _ = w.Write([]byte(`{"data":{"repository":{"pullRequest":{"id":"PR_kwDO7"}}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"enablePullRequestAutoMerge":null},"errors":[{"message":"Auto merge is not allowed for this repository"}]}`))
+	}))
+	defer server.Close()
+
+	client := newGraphQLTestClient(t, server, "owner", "repo")
+	err := client.EnableAutoMerge(7, "merge")
+	if err == nil || !strings.Contains(err.Error(), "Auto merge is not allowed") {
+		t.Fatalf("expected the GraphQL error, got %v", err)
+	}
+}
@@ -2,13 +2,21 @@ package github
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+
+	"github.com/omarkohl/jip/internal/forge"
 )
 
 // collapseThreshold is the maximum total diff lines before collapsing
 // file sections by default.
 const collapseThreshold = 20
 
+// contextKept is how many unchanged lines a diff comment keeps next to each
+// change; longer runs of unchanged lines are collapsed into one line.
+const contextKept = 1
+
 // pushedCommitMarkerPrefix is an invisible HTML-comment marker embedded in
 // every PR body by jip. It records the commit jip pushed so that a later
 // `send --diff-since-jip` can recover the previous jip push as the interdiff
@@ -88,14 +96,105 @@ func ParsePushedCommit(commentBody string) string {
 	return value
 }
 
+// The hand-written description section appended below the body jip
+// generates is delimited by these invisible markers, so that regenerating the
+// body can carry it over unchanged.
+const (
+	extraDescriptionStart = "<!-- jip:description -->"
+	extraDescriptionEnd   = "<!-- /jip:description -->"
+)
+
+// WithExtraDescription replaces the hand-written description section of body
+// with extra, in place, or appends it below everything else when body has
+// none. An empty (or blank) extra removes the section.
+func WithExtraDescription(body, extra string) string {
+	extra = strings.TrimSpace(extra)
+	section := ""
+	if extra != "" {
+		section = extraDescriptionStart + "\n" + extra + "\n" + extraDescriptionEnd
+	}
+	if i := strings.Index(body, extraDescriptionStart); i != -1 {
+		if j := strings.Index(body[i:], extraDescriptionEnd); j != -1 {
+			before, after := body[:i], body[i+j+len(extraDescriptionEnd):]
+			if section == "" {
+				return strings.TrimSuffix(before, "\n\n") + after
+			}
+			return before + section + after
+		}
+	}
+	if section == "" || body == "" {
+		return section + body
+	}
+	return strings.TrimRight(body, "\n") + "\n\n" + section
+}
+
+// ParseExtraDescription returns the hand-written description section that
+// WithExtraDescription wrote into prBody, or "" if it has none.
+func ParseExtraDescription(prBody string) string {
+	_, rest, ok := strings.Cut(prBody, extraDescriptionStart)
+	if !ok {
+		return ""
+	}
+	extra, _, ok := strings.Cut(rest, extraDescriptionEnd)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(extra)
+}
+
+// The part of a PR body that jip generates is delimited by these invisible
+// markers, so that text users add around it on GitHub survives the next time
+// jip rewrites the body.
+const (
+	generatedStart = "<!-- jip:generated -->"
+	generatedEnd   = "<!-- /jip:generated -->"
+)
+
+// MergePRBody returns previous, the PR's current body, with its generated
+// part replaced by generated. Everything outside the generated markers is
+// kept: text users added on GitHub above or below it, the hand-written
+// description section, and the pushed-commit marker. A body without the
+// markers, written by an older jip or not by jip at all, is replaced.
+func MergePRBody(previous, generated string) string {
+	part := generatedStart + "\n" + generated
+	if !strings.HasSuffix(part, "\n") {
+		part += "\n"
+	}
+	part += generatedEnd
+	if i := strings.Index(previous, generatedStart); i != -1 {
+		if j := strings.Index(previous[i:], generatedEnd); j != -1 {
+			return previous[:i] + part + previous[i+j+len(generatedEnd):]
+		}
+	}
+	return part
+}
+
+// generatedPart returns the part of prBody between the generated markers, or
+// all of it when it has none, so that what users wrote around it cannot be
+// mistaken for what jip wrote.
+func generatedPart(prBody string) string {
+	_, rest, ok := strings.Cut(prBody, generatedStart)
+	if !ok {
+		return prBody
+	}
+	part, _, ok := strings.Cut(rest, generatedEnd)
+	if !ok {
+		return prBody
+	}
+	return part
+}
+
 // ParseReviewCommit extracts the commit hash from the "Only review commit"
 // link that BuildStackedPRBody writes into a stacked PR's body, or "" if the
 // body has no such link (e.g. a standalone, non-stacked PR).
 //
 // Only the line that starts with "Only review commit" is searched, and the
-// hash is extracted from the exact /pull/<number>/commits/<hash> URL shape to
-// avoid matching unrelated URLs in user-written descriptions.
+// hash is extracted from the /commits/<hash> URL shape of a commit within a
+// PR on every forge (or GitHub's and Gitea's /commit/<hash>, see
+// RepointReviewCommit) to avoid matching unrelated URLs in user-written
+// descriptions.
 func ParseReviewCommit(prBody string) string {
+	prBody = generatedPart(prBody)
 	const lineMarker = "Only review commit "
 	idx := strings.Index(prBody, lineMarker)
 	if idx == -1 {
@@ -106,12 +205,12 @@ func ParseReviewCommit(prBody string) string {
 	if nl := strings.IndexByte(line, '\n'); nl != -1 {
 		line = line[:nl]
 	}
-	const urlMarker = "/commits/"
-	ci := strings.Index(line, urlMarker)
-	if ci == -1 {
-		return ""
+	_, rest, ok := strings.Cut(line, "/commits/")
+	if !ok {
+		if _, rest, ok = strings.Cut(line, "/commit/"); !ok {
+			return ""
+		}
 	}
-	rest := line[ci+len(urlMarker):]
 	end := 0
 	for end < len(rest) && isHexChar(rest[end]) {
 		end++
@@ -126,47 +225,222 @@ func isHexChar(c byte) bool {
 	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
 }
 
+// StackFormat controls how the stack navigation block lists the PRs.
+type StackFormat struct {
+	// OldestFirst lists the base of the stack first instead of the newest PR.
+	OldestFirst bool
+	// Titles maps PR numbers to the titles shown next to them; nil shows
+	// numbers only.
+	Titles map[int]string
+	// Status marks draft PRs and strikes merged and closed ones through, by
+	// their state in States: MERGED, CLOSED or DRAFT (an open draft).
+	Status bool
+	States map[int]string
+}
+
+// stackStatusMarker follows the "PRs:" heading of a stack block written with
+// StackFormat.Status, so that later rewrites keep showing the states.
+const stackStatusMarker = "<!-- jip:stack-status -->"
+
 // BuildStackBlock generates a markdown stack navigation block showing
 // the current PR's position in the stack.
-func BuildStackBlock(prNumbers []int, current int) string {
+func BuildStackBlock(prNumbers []int, current int, format StackFormat) string {
 	if len(prNumbers) <= 1 {
 		return ""
 	}
 
+	dependsOn := "depends on the ones below ⬇️"
+	order := make([]int, 0, len(prNumbers))
+	if format.OldestFirst {
+		dependsOn = "depends on the ones above ⬆️"
+		order = append(order, prNumbers...)
+	} else {
+		// Display top-to-bottom (newest first).
+		for i := len(prNumbers) - 1; i >= 0; i-- {
+			order = append(order, prNumbers[i])
+		}
+	}
+
+	// The base of the stack is its bottom PR that is neither merged nor
+	// closed.
+	base := prNumbers[0]
 	var b strings.Builder
-	b.WriteString("PRs:\n")
-	// Display top-to-bottom (newest first).
-	for i := len(prNumbers) - 1; i >= 0; i-- {
-		num := prNumbers[i]
-		if num == current {
-			if i == 0 {
-				// Current PR is the bottom of the stack.
-				fmt.Fprintf(&b, "* ➡️ #%d (this PR, base of the stack — can be merged first)\n", num)
-			} else {
-				fmt.Fprintf(&b, "* ➡️ #%d (this PR, depends on the ones below ⬇️)\n", num)
+	b.WriteString("PRs:")
+	if format.Status {
+		b.WriteString(stackStatusMarker)
+		for _, num := range prNumbers {
+			if st := format.States[num]; st != "MERGED" && st != "CLOSED" {
+				base = num
+				break
 			}
-		} else {
-			fmt.Fprintf(&b, "* #%d\n", num)
+		}
+	}
+	b.WriteString("\n")
+	for _, num := range order {
+		entry := fmt.Sprintf("#%d", num)
+		if title := format.Titles[num]; title != "" {
+			entry += " " + title
+		}
+		if format.Status {
+			switch st := format.States[num]; st {
+			case "MERGED", "CLOSED":
+				entry = fmt.Sprintf("~~%s~~ (%s)", entry, strings.ToLower(st))
+			case "DRAFT":
+				entry += " (draft)"
+			}
+		}
+		switch {
+		case num != current:
+			fmt.Fprintf(&b, "* %s\n", entry)
+		case num == base:
+			// Current PR is the bottom of the stack.
+			fmt.Fprintf(&b, "* ➡️ %s (this PR, base of the stack — can be merged first)\n", entry)
+		default:
+			fmt.Fprintf(&b, "* ➡️ %s (this PR, %s)\n", entry, dependsOn)
 		}
 	}
 	return b.String()
 }
 
-// BuildStackedPRBody generates the full PR body for a stacked PR.
+// StackBlock is the stack navigation block of a PR body, as parsed by
+// ParseStackBlock.
+type StackBlock struct {
+	PRs     []int // the PRs of the stack, bottom first
+	Current int   // the PR the body belongs to
+	// Format is the format the block was written in. Titles is non-nil when
+	// the block shows titles, and then holds the titles shown.
+	Format StackFormat
+}
+
+// ParseStackBlock reads the stack navigation block that BuildStackBlock
+// wrote into prBody. It returns nil when the body has no stack block.
+func ParseStackBlock(prBody string) *StackBlock {
+	var sb StackBlock
+	_, block, ok := strings.Cut(generatedPart(prBody), "PRs:\n")
+	if !ok {
+		if _, block, ok = strings.Cut(generatedPart(prBody), "PRs:"+stackStatusMarker+"\n"); !ok {
+			return nil
+		}
+		sb.Format.Status = true
+		sb.Format.States = make(map[int]string)
+	}
+	for _, line := range strings.Split(block, "\n") {
+		entry, ok := strings.CutPrefix(line, "* ")
+		if !ok {
+			break
+		}
+		entry, isCurrent := strings.CutPrefix(entry, "➡️ ")
+		state := ""
+		if sb.Format.Status {
+			entry, state = parseStackStatus(entry)
+		}
+		ref, ok := strings.CutPrefix(entry, "#")
+		if !ok {
+			break
+		}
+		num, title, _ := strings.Cut(ref, " ")
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			break
+		}
+		if isCurrent {
+			sb.Current = n
+			// A title may contain the marker itself; the last one is jip's.
+			i := strings.LastIndex(title, "(this PR, ")
+			if i < 0 {
+				break
+			}
+			position := title[i+len("(this PR, "):]
+			title = strings.TrimSuffix(title[:i], " ")
+			if sb.Format.Status {
+				if t, ok := strings.CutSuffix(" "+title, " (draft)"); ok {
+					title, state = strings.TrimPrefix(t, " "), "DRAFT"
+				}
+			}
+			switch position {
+			case "depends on the ones above ⬆️)":
+				sb.Format.OldestFirst = true
+			case "base of the stack — can be merged first)":
+				// The base is listed first only when the oldest PR is.
+				sb.Format.OldestFirst = len(sb.PRs) == 0
+			}
+		}
+		if state != "" {
+			sb.Format.States[n] = state
+		}
+		if title != "" {
+			if sb.Format.Titles == nil {
+				sb.Format.Titles = make(map[int]string)
+			}
+			sb.Format.Titles[n] = title
+		}
+		sb.PRs = append(sb.PRs, n)
+	}
+	if sb.Current == 0 {
+		return nil
+	}
+	if !sb.Format.OldestFirst {
+		slices.Reverse(sb.PRs)
+	}
+	return &sb
+}
+
+// parseStackStatus strips the state BuildStackBlock marked a stack entry
+// (other than the current PR's) with, and returns the state.
+func parseStackStatus(entry string) (string, string) {
+	if rest, ok := strings.CutPrefix(entry, "~~"); ok {
+		for _, st := range []string{"MERGED", "CLOSED"} {
+			if e, ok := strings.CutSuffix(rest, "~~ ("+strings.ToLower(st)+")"); ok {
+				return e, st
+			}
+		}
+	}
+	if e, ok := strings.CutSuffix(entry, " (draft)"); ok {
+		return e, "DRAFT"
+	}
+	return entry, ""
+}
+
+// ParseStackedDescription returns the description of the change that
+// BuildStackedPRBody wrote into prBody, or "" if it has none.
+func ParseStackedDescription(prBody string) string {
+	_, rest, ok := strings.Cut(generatedPart(prBody), "\n---\n\n## Description\n\n")
+	if !ok {
+		return ""
+	}
+	description, _, _ := strings.Cut(rest, "\n\n[^1]: A stacked PR")
+	return strings.TrimSuffix(description, "\n")
+}
+
+// RepointReviewCommit points the "Only review commit" link of prBody at the
+// commit in the repository instead of within the PR when the commit is no
+// longer the head of the PR's branch (headSHA), e.g. because someone
+// force-pushed it since: GitHub answers the PR's link to a commit that left
+// the branch with a 404. An unknown head ("") leaves prBody as it is.
+func RepointReviewCommit(prBody string, links forge.Links, prNumber int, headSHA string) string {
+	commit := ParseReviewCommit(prBody)
+	if headSHA == "" || commit == "" || strings.HasPrefix(commit, headSHA) || strings.HasPrefix(headSHA, commit) {
+		return prBody
+	}
+	return strings.Replace(prBody, "("+links.PRCommitURL(prNumber, commit)+")", "("+links.CommitURL(commit)+")", 1)
+}
+
+// BuildStackedPRBody generates the full PR body for a stacked PR, linking
+// the commit to review within the PR on the forge of links.
 // For a single PR (len(allPRs) <= 1), only the commitBody is returned.
-func BuildStackedPRBody(commitHash, repoFullName string, prNumber int, allPRs []int, commitBody string) string {
+func BuildStackedPRBody(commitHash string, links forge.Links, prNumber int, allPRs []int, commitBody string, format StackFormat) string {
 	// Single PR: just use the commit body directly.
 	if len(allPRs) <= 1 {
 		return commitBody
 	}
 
 	shortHash := commitHash[:minInt(7, len(commitHash))]
-	commitLink := fmt.Sprintf("https://github.com/%s/pull/%d/commits/%s", repoFullName, prNumber, commitHash)
+	commitLink := links.PRCommitURL(prNumber, commitHash)
 
 	var b strings.Builder
 	fmt.Fprintf(&b, "This is a stacked PR[^1]. Only review commit [%s](%s).\n\n", shortHash, commitLink)
 
-	b.WriteString(BuildStackBlock(allPRs, prNumber))
+	b.WriteString(BuildStackBlock(allPRs, prNumber, format))
 
 	if commitBody != "" {
 		b.WriteString("\n---\n\n## Description\n\n")
@@ -174,26 +448,141 @@ func BuildStackedPRBody(commitHash, repoFullName string, prNumber int, allPRs []
 		b.WriteString("\n")
 	}
 
+	below, above := "below", "above"
+	if format.OldestFirst {
+		below, above = above, below
+	}
 	b.WriteString("\n[^1]: A stacked PR is a pull request that depends on other pull requests. ")
-	b.WriteString("The current PR depends on the ones listed below it and MUST NOT be merged before they are merged. ")
-	b.WriteString("The PRs listed above the current one in turn depend on it and won't be merged until the current one is. ")
+	fmt.Fprintf(&b, "The current PR depends on the ones listed %s it and MUST NOT be merged before they are merged. ", below)
+	fmt.Fprintf(&b, "The PRs listed %s the current one in turn depend on it and won't be merged until the current one is. ", above)
 	b.WriteString("Learn more about [why](https://github.com/omarkohl/jip/blob/main/docs/why.md) and [how to review](https://github.com/omarkohl/jip/blob/main/docs/reviewing.md).\n")
 
 	return b.String()
 }
 
+// SquashedCommit is one commit of a stack sent as a single PR.
+type SquashedCommit struct {
+	Hash  string
+	Title string
+}
+
+// BuildSquashedPRBody generates the PR body for a whole stack sent as one PR
+// (--stack=none): a checklist linking each commit, oldest first, so the PR
+// can be reviewed commit by commit, followed by commitBody. Boxes ticked in
+// previous (the PR's current body) stay ticked for commits that are
+// unchanged; a rewritten commit needs another look. For a single commit,
+// only commitBody is returned.
+func BuildSquashedPRBody(links forge.Links, prNumber int, commits []SquashedCommit, commitBody, previous string) string {
+	if len(commits) <= 1 {
+		return commitBody
+	}
+	reviewed := ParseReviewedCommits(previous)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "This PR contains a stack of %d commits. Review them one by one:\n\n", len(commits))
+	for _, c := range commits {
+		box := " "
+		if reviewed[c.Hash] {
+			box = "x"
+		}
+		shortHash := c.Hash[:minInt(7, len(c.Hash))]
+		fmt.Fprintf(&b, "- [%s] [%s](%s) %s\n", box, shortHash, links.PRCommitURL(prNumber, c.Hash), c.Title)
+	}
+	if commitBody != "" {
+		b.WriteString("\n---\n\n## Description\n\n")
+		b.WriteString(commitBody)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ParseReviewedCommits returns the commits whose box is ticked in the
+// checklist BuildSquashedPRBody writes into a PR body. Only checklist lines
+// linking a commit within a pull request (/pull/<number>/commits/<hash>, or
+// /pulls/ on Gitea and /pull-requests/ on Bitbucket) count, so task lists in
+// user-written descriptions are ignored.
+func ParseReviewedCommits(prBody string) map[string]bool {
+	reviewed := make(map[string]bool)
+	for _, line := range strings.Split(generatedPart(prBody), "\n") {
+		rest, ok := strings.CutPrefix(line, "- [x] [")
+		if !ok {
+			rest, ok = strings.CutPrefix(line, "- [X] [")
+		}
+		if !ok {
+			continue
+		}
+		_, rest, ok = strings.Cut(rest, "](")
+		if !ok {
+			continue
+		}
+		url, _, _ := strings.Cut(rest, ")")
+		if !strings.Contains(url, "/pull") {
+			continue
+		}
+		_, hash, ok := strings.Cut(url, "/commits/")
+		if !ok || len(hash) < 7 || strings.IndexFunc(hash, func(r rune) bool { return r > 0x7f || !isHexChar(byte(r)) }) != -1 {
+			continue
+		}
+		reviewed[hash] = true
+	}
+	return reviewed
+}
+
 // fileDiff represents a single file's diff section.
 type fileDiff struct {
-	header string // the diff --git a/... b/... line and hunks header
-	body   string // the actual diff content
+	header  string // the file's path (after a rename or copy)
+	oldPath string // the path before a rename or copy; "" for neither
+	status  string // "new", "deleted", "renamed", "copied" or "" for modified
+	oldMode string // from the "old mode" or "deleted file mode" line
+	newMode string // from the "new mode" or "new file mode" line
+	binary  bool   // git printed no text diff for the file
+	hunks   bool   // the body has at least one @@ hunk
+	added   int    // lines added by the hunks
+	removed int    // lines removed by the hunks
+	body    string // the actual diff content
+}
+
+// notes annotates f in its summary line, e.g. "renamed from <code>a.go</code>,
+// +1, -0".
+func (f fileDiff) notes() string {
+	var notes []string
+	switch f.status {
+	case "new":
+		notes = append(notes, "new file")
+	case "deleted":
+		notes = append(notes, "deleted")
+	case "renamed", "copied":
+		notes = append(notes, fmt.Sprintf("%s from <code>%s</code>", f.status, f.oldPath))
+	}
+	if f.status == "" && f.oldMode != "" && f.newMode != "" && f.oldMode != f.newMode {
+		notes = append(notes, fmt.Sprintf("mode %s → %s", f.oldMode, f.newMode))
+	}
+	if f.binary {
+		notes = append(notes, "binary")
+	}
+	if f.hunks {
+		notes = append(notes, fmt.Sprintf("+%d, -%d", f.added, f.removed))
+	}
+	return strings.Join(notes, ", ")
 }
 
+// TruncatedDiffMarker is in a diff comment whose file diffs did not all fit
+// within GitHub's comment size limit.
+const TruncatedDiffMarker = "<!-- jip:truncated-diff -->"
+
 // BuildDiffComment generates a PR comment with interdiff output,
 // using collapsible sections for each file. When sinceJip is true the header
 // reads "Changes since last jip send" (the base is jip's own previous send
-// rather than the current remote head).
-func BuildDiffComment(codeDiff, repoName, baseBranch, oldCommit, newCommit string, sinceJip bool) string {
-	footer := rangeDiffFooter(repoName, baseBranch, oldCommit, newCommit)
+// rather than the current remote head). With several files, a table at the
+// top lists them with their line counts and links to their sections. Runs
+// of unchanged lines are collapsed to contextKept lines next to the changes.
+//
+// A comment that would exceed GitHub's size limit leaves out the diffs of the
+// biggest files, so that as few files as possible lose theirs, and says so
+// under TruncatedDiffMarker; fullDiffURL, when not "", links the full diff
+// there.
+func BuildDiffComment(codeDiff string, links forge.Links, baseBranch, oldCommit, newCommit string, sinceJip bool, fullDiffURL string) string {
+	footer := rangeDiffFooter(links, baseBranch, oldCommit, newCommit)
 	header := "### Changes since last push\n"
 	if sinceJip {
 		header = "### Changes since last jip send\n"
@@ -205,35 +594,172 @@ func BuildDiffComment(codeDiff, repoName, baseBranch, oldCommit, newCommit strin
 
 	files := parseGitDiff(codeDiff)
 
-	var b strings.Builder
-	b.WriteString(header)
-
 	totalLines := 0
-	for _, f := range files {
-		totalLines += len(strings.Split(f.body, "\n"))
+	for i := range files {
+		files[i].body = collapseContext(files[i].body)
+		totalLines += len(strings.Split(files[i].body, "\n"))
 	}
 	expand := totalLines <= collapseThreshold
 
-	for _, f := range files {
-		added, removed := diffStats(f.body)
-		openAttr := ""
-		if expand {
-			openAttr = " open"
+	// Anchors carry the commit, so that the sections of the diffs of several
+	// pushes in a revision history comment stay apart.
+	anchorPrefix := "jip-diff-"
+	if newCommit != "" {
+		anchorPrefix = fmt.Sprintf("jip-diff-%.7s-", newCommit)
+	}
+	sections := make([]string, len(files))
+	size := len(header) + len(footer)
+	for i, f := range files {
+		sections[i] = fileSection(f, expand, fmt.Sprintf("%s%d", anchorPrefix, i+1))
+		size += len(sections[i])
+	}
+	if size <= maxCommentLen {
+		summary := ""
+		if len(files) > 1 {
+			summary = filesSummary(files, anchorPrefix)
 		}
-		fence := codeFence(f.body)
-		fmt.Fprintf(&b, "\n<details%s>\n<summary><code>%s</code> (+%d, -%d)</summary>\n\n%sdiff\n%s\n%s\n\n</details>\n",
-			openAttr, f.header, added, removed, fence, f.body, fence)
+		if size+len(summary) <= maxCommentLen {
+			return header + summary + strings.Join(sections, "") + footer
+		}
+		return header + strings.Join(sections, "") + footer
 	}
 
-	b.WriteString(footer)
+	// The note is budgeted at its longest, with every file left out.
+	note := func(left int) string {
+		n := fmt.Sprintf("\n%s\n> **This diff is too large for a comment:** the diffs of %d of %d files are left out.", TruncatedDiffMarker, left, len(files))
+		switch {
+		case fullDiffURL != "":
+			n += fmt.Sprintf(" The full diff is in [this gist](%s).", fullDiffURL)
+		case footer != "":
+			n += " View the full diff with the links below."
+		}
+		return n + "\n"
+	}
+	size += len(note(len(files)))
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return len(sections[b]) - len(sections[a]) })
+	left := 0
+	for _, i := range order {
+		if size <= maxCommentLen {
+			break
+		}
+		omitted := fmt.Sprintf("\n- <code>%s</code> (%s) — diff left out\n", files[i].header, files[i].notes())
+		size += len(omitted) - len(sections[i])
+		sections[i] = omitted
+		left++
+	}
+	// With thousands of files, even a line each is too long.
+	more := 0
+	moreLine := "\n- … and %d more files\n"
+	for size > maxCommentLen && len(sections) > 0 {
+		if more == 0 {
+			size += len(fmt.Sprintf(moreLine, len(files)))
+		}
+		size -= len(sections[len(sections)-1])
+		sections = sections[:len(sections)-1]
+		more++
+	}
+	rest := ""
+	if more > 0 {
+		rest = fmt.Sprintf(moreLine, more)
+	}
+	return header + note(left) + strings.Join(sections, "") + rest + footer
+}
+
+// fileSection renders the diff of f for a diff comment: a collapsible
+// section, open when expand is set, under the anchor name, or a single line
+// for files without a text diff (binary files, pure renames and mode
+// changes).
+func fileSection(f fileDiff, expand bool, anchor string) string {
+	if !hasSection(f) {
+		return fmt.Sprintf("\n- <code>%s</code> (%s)\n", f.header, f.notes())
+	}
+	openAttr := ""
+	if expand {
+		openAttr = " open"
+	}
+	fence := codeFence(f.body)
+	return fmt.Sprintf("\n<a name=\"%s\"></a>\n<details%s>\n<summary><code>%s</code> (%s)</summary>\n\n%sdiff\n%s\n%s\n\n</details>\n",
+		anchor, openAttr, f.header, f.notes(), fence, f.body, fence)
+}
+
+// hasSection reports whether fileSection renders f as a collapsible section.
+func hasSection(f fileDiff) bool {
+	return f.hunks && !f.binary
+}
+
+// filesSummary renders the table of files at the top of a diff comment,
+// linking the files with a section to it through the anchors anchorPrefix
+// starts.
+func filesSummary(files []fileDiff, anchorPrefix string) string {
+	var b strings.Builder
+	b.WriteString("\n| File | Changes |\n|---|---|\n")
+	for i, f := range files {
+		name := "`" + strings.ReplaceAll(f.header, "|", "\\|") + "`"
+		if hasSection(f) {
+			name = fmt.Sprintf("[%s](#%s%d)", name, anchorPrefix, i+1)
+		}
+		fmt.Fprintf(&b, "| %s | %s |\n", name, strings.ReplaceAll(f.notes(), "|", "\\|"))
+	}
 	return b.String()
 }
 
+// collapseContext shortens the runs of unchanged lines in the hunks of a
+// file's diff to contextKept lines next to each change, replacing the rest
+// of a run with a line saying how many lines it left out.
+func collapseContext(body string) string {
+	var out, run []string // run: the unchanged lines since the last change
+	inHunk, afterChange := false, false
+	flush := func(beforeChange bool) {
+		head, tail := 0, 0
+		if afterChange {
+			head = contextKept
+		}
+		if beforeChange {
+			tail = contextKept
+		}
+		// Replacing a single line by a line saying so would not help.
+		if hidden := len(run) - head - tail; hidden >= 2 {
+			out = append(out, run[:head]...)
+			out = append(out, fmt.Sprintf(" ⋯ %d unchanged lines", hidden))
+			out = append(out, run[len(run)-tail:]...)
+		} else {
+			out = append(out, run...)
+		}
+		run = nil
+	}
+	for _, line := range strings.Split(body, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			flush(false)
+			out = append(out, line)
+			inHunk, afterChange = true, false
+		case !inHunk:
+			out = append(out, line)
+		case strings.HasPrefix(line, "+"), strings.HasPrefix(line, "-"):
+			flush(true)
+			out = append(out, line)
+			afterChange = true
+		case strings.HasPrefix(line, "\\"):
+			// "\ No newline at end of file" belongs to the line before it.
+			flush(true)
+			out = append(out, line)
+		default:
+			run = append(run, line)
+		}
+	}
+	flush(false)
+	return strings.Join(out, "\n")
+}
+
 // BuildUnavailableDiffComment generates a PR comment for the case where
 // --diff-since-jip knows the previous jip-pushed commit but cannot find it
 // locally (e.g. it was pushed from another machine and not fetched). It
 // documents that the diff could not be generated and points at the remote.
-func BuildUnavailableDiffComment(repoName, baseBranch, oldCommit, newCommit string) string {
+func BuildUnavailableDiffComment(links forge.Links, baseBranch, oldCommit, newCommit string) string {
 	oldShort := oldCommit[:minInt(7, len(oldCommit))]
 	var b strings.Builder
 	b.WriteString("### Changes since last jip send\n\n")
@@ -242,42 +768,131 @@ func BuildUnavailableDiffComment(repoName, baseBranch, oldCommit, newCommit stri
 			"available locally (it may have been pushed from another machine). "+
 			"Fetch the remote to inspect it.\n",
 		oldShort)
-	b.WriteString(rangeDiffFooter(repoName, baseBranch, oldCommit, newCommit))
+	b.WriteString(rangeDiffFooter(links, baseBranch, oldCommit, newCommit))
 	return b.String()
 }
 
-// rangeDiffFooter builds a footer with a GitHub compare link and a local
-// range-diff command hint.
-func rangeDiffFooter(repoName, baseBranch, oldCommit, newCommit string) string {
-	if oldCommit == "" || newCommit == "" || repoName == "" {
+// DiffSize returns the number of lines a unified diff adds or removes and
+// the number of files it touches.
+func DiffSize(codeDiff string) (lines, files int) {
+	parsed := parseGitDiff(codeDiff)
+	for _, f := range parsed {
+		lines += f.added + f.removed
+	}
+	return lines, len(parsed)
+}
+
+// BuildCompareLinkComment generates a PR comment for a diff below the
+// --interdiff-threshold: only its size and the links of the footer, no
+// diff.
+func BuildCompareLinkComment(links forge.Links, baseBranch, oldCommit, newCommit string, sinceJip bool, lines, files int) string {
+	header := "### Changes since last push\n"
+	if sinceJip {
+		header = "### Changes since last jip send\n"
+	}
+	return fmt.Sprintf("%s\n%d line(s) changed in %d file(s).\n%s", header, lines, files,
+		rangeDiffFooter(links, baseBranch, oldCommit, newCommit))
+}
+
+// RevisionHistoryMarker identifies jip's rolling "Revision history" comment,
+// which collects every push's interdiff in one comment instead of one comment
+// per push.
+const RevisionHistoryMarker = "<!-- jip:revision-history -->"
+
+// revisionMarkerPrefix starts the invisible marker in front of each revision
+// entry of the history comment.
+const revisionMarkerPrefix = "<!-- jip:revision="
+
+// maxCommentLen stays safely below GitHub's 65536-character comment limit.
+const maxCommentLen = 60000
+
+// AppendRevision adds entry (a comment as built by BuildDiffComment and
+// friends) to the revision history comment history, which may be "" to start
+// a new one. Revisions are numbered like patchsets: the PR as opened is v1,
+// so the first entry is v2. When the comment would exceed GitHub's size
+// limit, the diffs of the oldest revisions are dropped, keeping their
+// headings.
+func AppendRevision(history, entry string) string {
+	entries := splitRevisions(history)
+	n := len(entries) + 2
+
+	header, rest, _ := strings.Cut(entry, "\n")
+	if title, ok := strings.CutPrefix(header, "### "); ok {
+		entry = fmt.Sprintf("### v%d — %s\n%s", n, title, rest)
+	} else {
+		entry = fmt.Sprintf("### v%d\n\n%s", n, entry)
+	}
+	entries = append(entries, fmt.Sprintf("%s%d -->\n%s", revisionMarkerPrefix, n, strings.TrimRight(entry, "\n")))
+
+	render := func() string {
+		return RevisionHistoryMarker + "\n## Revision history\n\n" + strings.Join(entries, "\n\n") + "\n"
+	}
+	for i := 0; i < len(entries)-1 && len(render()) > maxCommentLen; i++ {
+		marker, rest, _ := strings.Cut(entries[i], "\n")
+		heading, _, _ := strings.Cut(rest, "\n")
+		entries[i] = marker + "\n" + heading + "\n\n_Diff dropped to stay within GitHub's comment size limit._"
+	}
+	return render()
+}
+
+// splitRevisions returns the revision entries (each starting with its
+// marker) of a history comment.
+func splitRevisions(history string) []string {
+	var entries []string
+	for {
+		i := strings.Index(history, revisionMarkerPrefix)
+		if i < 0 {
+			return entries
+		}
+		history = history[i:]
+		next := strings.Index(history[len(revisionMarkerPrefix):], revisionMarkerPrefix)
+		if next < 0 {
+			return append(entries, strings.TrimRight(history, "\n"))
+		}
+		next += len(revisionMarkerPrefix)
+		entries = append(entries, strings.TrimRight(history[:next], "\n"))
+		history = history[next:]
+	}
+}
+
+// rangeDiffFooter builds a footer with a compare link on the forge of links
+// and a local range-diff command hint.
+func rangeDiffFooter(links forge.Links, baseBranch, oldCommit, newCommit string) string {
+	if oldCommit == "" || newCommit == "" || links.Repo == "" {
 		return ""
 	}
 	oldShort := oldCommit[:minInt(7, len(oldCommit))]
 	newShort := newCommit[:minInt(7, len(newCommit))]
-	compareURL := fmt.Sprintf("https://github.com/%s/compare/%s..%s", repoName, oldCommit, newCommit)
 	return fmt.Sprintf(
-		"\n---\n<sub>View the diff on [GitHub](%s) "+
-			"(may include unrelated changes due to rebases since GitHub does not currently implement `git range-diff`).\n"+
+		"\n---\n<sub>View the diff on [%s](%s) "+
+			"(may include unrelated changes due to rebases since %s does not currently implement `git range-diff`).\n"+
 			"View the diff locally (will only work if you fetched the older commit at some point):\n"+
 			"`git range-diff %s %s %s`\n"+
 			"`jj interdiff -f %s -t %s`\n"+
 			"</sub>\n",
-		compareURL, baseBranch, oldShort, newShort, oldShort, newShort,
+		links.Name, links.CompareURL(oldCommit, newCommit), links.Name, baseBranch, oldShort, newShort, oldShort, newShort,
 	)
 }
 
-// parseGitDiff splits a unified diff into per-file sections.
+// parseGitDiff splits a unified diff into per-file sections. It reads the
+// extended header lines git writes for renames, copies, new and deleted
+// files, mode changes and binary files, and counts the lines the hunks add
+// and remove.
 func parseGitDiff(diff string) []fileDiff {
 	var files []fileDiff
 	lines := strings.Split(diff, "\n")
 	var current *fileDiff
+	flush := func() {
+		if current != nil {
+			current.body = strings.TrimRight(current.body, "\n")
+			current.added, current.removed = diffStats(current.body)
+			files = append(files, *current)
+		}
+	}
 
 	for _, line := range lines {
 		if strings.HasPrefix(line, "diff --git ") {
-			if current != nil {
-				current.body = strings.TrimRight(current.body, "\n")
-				files = append(files, *current)
-			}
+			flush()
 			// Extract file path from "diff --git a/path b/path"
 			header := line
 			parts := strings.SplitN(line, " b/", 2)
@@ -287,23 +902,59 @@ func parseGitDiff(diff string) []fileDiff {
 			current = &fileDiff{header: header}
 			continue
 		}
-		if current != nil {
-			current.body += line + "\n"
+		if current == nil {
+			continue
+		}
+		current.body += line + "\n"
+		if strings.HasPrefix(line, "@@") {
+			current.hunks = true
+		} else if !current.hunks {
+			parseExtendedHeader(current, line)
 		}
 	}
-	if current != nil {
-		current.body = strings.TrimRight(current.body, "\n")
-		files = append(files, *current)
-	}
+	flush()
 	return files
 }
 
-// diffStats counts added and removed lines in a diff chunk.
+// parseExtendedHeader records what an extended header line of a git diff,
+// one between "diff --git" and the first hunk, says about f.
+func parseExtendedHeader(f *fileDiff, line string) {
+	switch {
+	case strings.HasPrefix(line, "rename from "):
+		f.status, f.oldPath = "renamed", strings.TrimPrefix(line, "rename from ")
+	case strings.HasPrefix(line, "rename to "):
+		f.header = strings.TrimPrefix(line, "rename to ")
+	case strings.HasPrefix(line, "copy from "):
+		f.status, f.oldPath = "copied", strings.TrimPrefix(line, "copy from ")
+	case strings.HasPrefix(line, "copy to "):
+		f.header = strings.TrimPrefix(line, "copy to ")
+	case strings.HasPrefix(line, "new file mode "):
+		f.status, f.newMode = "new", strings.TrimPrefix(line, "new file mode ")
+	case strings.HasPrefix(line, "deleted file mode "):
+		f.status, f.oldMode = "deleted", strings.TrimPrefix(line, "deleted file mode ")
+	case strings.HasPrefix(line, "old mode "):
+		f.oldMode = strings.TrimPrefix(line, "old mode ")
+	case strings.HasPrefix(line, "new mode "):
+		f.newMode = strings.TrimPrefix(line, "new mode ")
+	case strings.HasPrefix(line, "Binary files ") && strings.HasSuffix(line, " differ"),
+		line == "GIT binary patch":
+		f.binary = true
+	}
+}
+
+// diffStats counts added and removed lines in the hunks of a diff chunk.
+// Lines before the first @@ hunk header, such as the --- and +++ file
+// lines, are not counted.
 func diffStats(chunk string) (added, removed int) {
+	inHunk := false
 	for _, line := range strings.Split(chunk, "\n") {
-		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk:
+		case strings.HasPrefix(line, "+"):
 			added++
-		} else if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
+		case strings.HasPrefix(line, "-"):
 			removed++
 		}
 	}
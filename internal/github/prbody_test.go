@@ -2,10 +2,15 @@ package github
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
 )
 
+var testLinks = forge.GitHubLinks("https://github.com", "owner", "repo")
+
 func TestWithPushedCommitMarker_RoundTrip(t *testing.T) {
 	body := WithPushedCommitMarker("Some description", "abcdef1234567890")
 	if !strings.Contains(body, "Some description") {
@@ -95,14 +100,14 @@ func TestParsePushedCommit_NoMarker(t *testing.T) {
 }
 
 func TestParseReviewCommit_FromStackedBody(t *testing.T) {
-	body := BuildStackedPRBody("abcdef1234567890", "owner/repo", 2, []int{1, 2, 3}, "desc")
+	body := BuildStackedPRBody("abcdef1234567890", testLinks, 2, []int{1, 2, 3}, "desc", StackFormat{})
 	if got := ParseReviewCommit(body); got != "abcdef1234567890" {
 		t.Errorf("ParseReviewCommit = %q, want %q", got, "abcdef1234567890")
 	}
 }
 
 func TestParseReviewCommit_StandaloneBodyHasNone(t *testing.T) {
-	body := BuildStackedPRBody("abcdef1234567890", "owner/repo", 1, []int{1}, "desc")
+	body := BuildStackedPRBody("abcdef1234567890", testLinks, 1, []int{1}, "desc", StackFormat{})
 	if got := ParseReviewCommit(body); got != "" {
 		t.Errorf("standalone body has no commit link, got %q", got)
 	}
@@ -110,22 +115,111 @@ func TestParseReviewCommit_StandaloneBodyHasNone(t *testing.T) {
 
 func TestParseReviewCommit_UnrelatedCommitsURLNotMatched(t *testing.T) {
 	// A /commits/ URL in the user description must not be picked up.
-	body := BuildStackedPRBody("abcdef1234567890", "owner/repo", 2, []int{1, 2, 3},
-		"See https://github.com/other/repo/commits/deadbeefcafe123 for context")
+	body := BuildStackedPRBody("abcdef1234567890", testLinks, 2, []int{1, 2, 3},
+		"See https://github.com/other/repo/commits/deadbeefcafe123 for context", StackFormat{})
 	if got := ParseReviewCommit(body); got != "abcdef1234567890" {
 		t.Errorf("ParseReviewCommit = %q, want stacked-PR hash %q", got, "abcdef1234567890")
 	}
 }
 
 func TestBuildDiffComment_SinceJipHeader(t *testing.T) {
-	result := BuildDiffComment("", "owner/repo", "main", "aaa111", "bbb222", true)
+	result := BuildDiffComment("", testLinks, "main", "aaa111", "bbb222", true, "")
 	if !strings.Contains(result, "Changes since last jip send") {
 		t.Errorf("expected jip-specific header, got:\n%s", result)
 	}
 }
 
+func TestRepointReviewCommit(t *testing.T) {
+	body := BuildStackedPRBody("abcdef1234567890", testLinks, 2, []int{1, 2, 3}, "desc", StackFormat{})
+
+	for _, head := range []string{"", "abcdef1234567890", "abcdef1"} {
+		if got := RepointReviewCommit(body, testLinks, 2, head); got != body {
+			t.Errorf("head %q: expected the body unchanged, got:\n%s", head, got)
+		}
+	}
+
+	got := RepointReviewCommit(body, testLinks, 2, "0123456789abcdef")
+	if !strings.Contains(got, "[abcdef1](https://github.com/owner/repo/commit/abcdef1234567890)") {
+		t.Errorf("expected the link to point at the commit in the repository, got:\n%s", got)
+	}
+	if strings.Contains(got, "/pull/2/commits/") {
+		t.Errorf("expected no link into the PR, got:\n%s", got)
+	}
+	if c := ParseReviewCommit(got); c != "abcdef1234567890" {
+		t.Errorf("ParseReviewCommit = %q, want %q", c, "abcdef1234567890")
+	}
+	if again := RepointReviewCommit(got, testLinks, 2, "0123456789abcdef"); again != got {
+		t.Errorf("expected repointing to be idempotent, got:\n%s", again)
+	}
+}
+
+func TestPRBodyLinks_OtherForges(t *testing.T) {
+	tests := []struct {
+		name                      string
+		links                     forge.Links
+		prCommit, commit, compare string
+	}{
+		{
+			name:     "gitea",
+			links:    forge.GiteaLinks("https://codeberg.org", "owner", "repo"),
+			prCommit: "https://codeberg.org/owner/repo/pulls/2/commits/abcdef1234567890",
+			commit:   "https://codeberg.org/owner/repo/commit/abcdef1234567890",
+			compare:  "https://codeberg.org/owner/repo/compare/old1234567890...new4567890123",
+		},
+		{
+			name:     "bitbucket",
+			links:    forge.BitbucketLinks("ws", "repo"),
+			prCommit: "https://bitbucket.org/ws/repo/pull-requests/2/commits/abcdef1234567890",
+			commit:   "https://bitbucket.org/ws/repo/commits/abcdef1234567890",
+			compare:  "https://bitbucket.org/ws/repo/branches/compare/new4567890123%0Dold1234567890",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := BuildStackedPRBody("abcdef1234567890", tt.links, 2, []int{1, 2, 3}, "desc", StackFormat{})
+			if !strings.Contains(body, "[abcdef1]("+tt.prCommit+")") {
+				t.Errorf("expected the review-commit link %s, got:\n%s", tt.prCommit, body)
+			}
+			if strings.Contains(body, "https://github.com/owner/repo") {
+				t.Errorf("expected no github.com links, got:\n%s", body)
+			}
+			if c := ParseReviewCommit(body); c != "abcdef1234567890" {
+				t.Errorf("ParseReviewCommit = %q", c)
+			}
+
+			repointed := RepointReviewCommit(body, tt.links, 2, "0123456789abcdef")
+			if !strings.Contains(repointed, "[abcdef1]("+tt.commit+")") {
+				t.Errorf("expected the link repointed at %s, got:\n%s", tt.commit, repointed)
+			}
+			if c := ParseReviewCommit(repointed); c != "abcdef1234567890" {
+				t.Errorf("ParseReviewCommit after repointing = %q", c)
+			}
+
+			squashed := BuildSquashedPRBody(tt.links, 2, []SquashedCommit{
+				{Hash: "abcdef1234567890", Title: "feat: add A"},
+				{Hash: "bbbbbbb2222222", Title: "feat: use A"},
+			}, "", "")
+			if !strings.Contains(squashed, "[abcdef1]("+tt.prCommit+")") {
+				t.Errorf("expected the checklist to link %s, got:\n%s", tt.prCommit, squashed)
+			}
+			ticked := strings.Replace(squashed, "- [ ]", "- [x]", 1)
+			if got := ParseReviewedCommits(ticked); len(got) != 1 || !got["abcdef1234567890"] {
+				t.Errorf("ParseReviewedCommits = %v", got)
+			}
+
+			footer := rangeDiffFooter(tt.links, "main", "old1234567890", "new4567890123")
+			if !strings.Contains(footer, "("+tt.compare+")") {
+				t.Errorf("expected the compare link %s, got:\n%s", tt.compare, footer)
+			}
+			if !strings.Contains(footer, "View the diff on ["+tt.links.Name+"]") {
+				t.Errorf("expected the forge named in the footer, got:\n%s", footer)
+			}
+		})
+	}
+}
+
 func TestBuildUnavailableDiffComment(t *testing.T) {
-	result := BuildUnavailableDiffComment("owner/repo", "main", "aaaaaaa1111111", "bbbbbbb2222222")
+	result := BuildUnavailableDiffComment(testLinks, "main", "aaaaaaa1111111", "bbbbbbb2222222")
 	if !strings.Contains(result, "Changes since last jip send") {
 		t.Errorf("expected jip header, got:\n%s", result)
 	}
@@ -137,15 +231,40 @@ func TestBuildUnavailableDiffComment(t *testing.T) {
 	}
 }
 
+func TestDiffSize(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1,2 +1,2 @@\n-old\n+new\n ctx\n" +
+		"diff --git a/b.go b/b.go\n--- a/b.go\n+++ b/b.go\n@@ -1 +1,2 @@\n ctx\n+added\n"
+	lines, files := DiffSize(diff)
+	if lines != 3 || files != 2 {
+		t.Errorf("DiffSize = %d lines, %d files; want 3, 2", lines, files)
+	}
+}
+
+func TestBuildCompareLinkComment(t *testing.T) {
+	result := BuildCompareLinkComment(testLinks, "main", "aaaaaaa1111111", "bbbbbbb2222222", false, 3, 2)
+	if !strings.Contains(result, "Changes since last push") {
+		t.Errorf("expected header, got:\n%s", result)
+	}
+	if !strings.Contains(result, "3 line(s) changed in 2 file(s).") {
+		t.Errorf("expected the size of the diff, got:\n%s", result)
+	}
+	if !strings.Contains(result, "https://github.com/owner/repo/compare/aaaaaaa1111111..bbbbbbb2222222") {
+		t.Errorf("expected compare link, got:\n%s", result)
+	}
+	if strings.Contains(result, "```") {
+		t.Errorf("expected no diff, got:\n%s", result)
+	}
+}
+
 func TestBuildStackBlock_SinglePR(t *testing.T) {
-	result := BuildStackBlock([]int{1}, 1)
+	result := BuildStackBlock([]int{1}, 1, StackFormat{})
 	if result != "" {
 		t.Errorf("expected empty for single PR, got %q", result)
 	}
 }
 
 func TestBuildStackBlock_MultiplePRs(t *testing.T) {
-	result := BuildStackBlock([]int{1, 2, 3}, 2)
+	result := BuildStackBlock([]int{1, 2, 3}, 2, StackFormat{})
 	if !strings.Contains(result, "PRs:") {
 		t.Errorf("expected 'PRs:' header, got:\n%s", result)
 	}
@@ -167,21 +286,170 @@ func TestBuildStackBlock_MultiplePRs(t *testing.T) {
 }
 
 func TestBuildStackBlock_CurrentIsBottom(t *testing.T) {
-	result := BuildStackBlock([]int{1, 2, 3}, 1)
+	result := BuildStackBlock([]int{1, 2, 3}, 1, StackFormat{})
 	if !strings.Contains(result, "* ➡️ #1 (this PR, base of the stack — can be merged first)") {
 		t.Errorf("expected base-of-stack annotation for bottom PR, got:\n%s", result)
 	}
 }
 
 func TestBuildStackBlock_CurrentIsTop(t *testing.T) {
-	result := BuildStackBlock([]int{1, 2, 3}, 3)
+	result := BuildStackBlock([]int{1, 2, 3}, 3, StackFormat{})
 	if !strings.Contains(result, "* ➡️ #3 (this PR, depends on the ones below ⬇️)") {
 		t.Errorf("expected dependency annotation for top PR, got:\n%s", result)
 	}
 }
 
+func TestBuildStackBlock_OldestFirst(t *testing.T) {
+	result := BuildStackBlock([]int{1, 2, 3}, 2, StackFormat{OldestFirst: true})
+	want := "PRs:\n* #1\n* ➡️ #2 (this PR, depends on the ones above ⬆️)\n* #3\n"
+	if result != want {
+		t.Errorf("got:\n%s\nwant:\n%s", result, want)
+	}
+}
+
+func TestBuildStackBlock_Titles(t *testing.T) {
+	titles := map[int]string{1: "feat: add auth", 3: "feat: use auth"}
+	result := BuildStackBlock([]int{1, 2, 3}, 1, StackFormat{Titles: titles})
+	want := "PRs:\n* #3 feat: use auth\n* #2\n* ➡️ #1 feat: add auth (this PR, base of the stack — can be merged first)\n"
+	if result != want {
+		t.Errorf("got:\n%s\nwant:\n%s", result, want)
+	}
+}
+
+func TestParseStackBlock(t *testing.T) {
+	titles := map[int]string{4: "feat: #2 follow-up", 5: "fix: thing", 6: "docs: (this PR, not really)"}
+	for _, format := range []StackFormat{{}, {OldestFirst: true}, {Titles: titles}} {
+		for _, current := range []int{4, 5, 6} {
+			body := BuildStackedPRBody("abc1234def", testLinks, current, []int{4, 5, 6}, "Body.", format)
+			got := ParseStackBlock(body)
+			want := &StackBlock{PRs: []int{4, 5, 6}, Current: current, Format: format}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("format %+v, current #%d: got %+v", format, current, got)
+			}
+		}
+	}
+}
+
+func TestBuildStackBlock_Status(t *testing.T) {
+	format := StackFormat{
+		Titles: map[int]string{1: "feat: add auth", 2: "feat: log in"},
+		Status: true,
+		States: map[int]string{1: "MERGED", 3: "DRAFT"},
+	}
+	result := BuildStackBlock([]int{1, 2, 3}, 2, format)
+	want := "PRs:" + stackStatusMarker + "\n* #3 (draft)\n* ➡️ #2 feat: log in (this PR, base of the stack — can be merged first)\n* ~~#1 feat: add auth~~ (merged)\n"
+	if result != want {
+		t.Errorf("got:\n%s\nwant:\n%s", result, want)
+	}
+}
+
+func TestParseStackBlock_Status(t *testing.T) {
+	titles := map[int]string{4: "feat: a", 5: "fix: b (draft)", 6: "docs: c"}
+	states := map[int]string{4: "MERGED", 5: "DRAFT", 6: "DRAFT", 7: "CLOSED"}
+	for _, format := range []StackFormat{{Status: true, States: states}, {Titles: titles, Status: true, States: states}} {
+		for _, current := range []int{5, 6} {
+			body := BuildStackedPRBody("abc1234def", testLinks, current, []int{4, 5, 6, 7}, "Body.", format)
+			got := ParseStackBlock(body)
+			want := &StackBlock{PRs: []int{4, 5, 6, 7}, Current: current, Format: format}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("format %+v, current #%d: got %+v", format, current, got)
+			}
+		}
+	}
+}
+
+func TestParseStackBlock_None(t *testing.T) {
+	if got := ParseStackBlock("Just a description.\n\n* #3 mentioned"); got != nil {
+		t.Errorf("got %+v, want no stack", got)
+	}
+}
+
+func TestParseStackedDescription(t *testing.T) {
+	body := WithPushedCommitMarker(BuildStackedPRBody("abc1234def", testLinks, 2, []int{1, 2}, "Line one.\n\nLine two.", StackFormat{}), "abc1234def")
+	if got, want := ParseStackedDescription(body), "Line one.\n\nLine two."; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := ParseStackedDescription(BuildStackedPRBody("abc1234def", testLinks, 2, []int{1, 2}, "", StackFormat{})); got != "" {
+		t.Errorf("got %q for a PR without description", got)
+	}
+}
+
+func TestWithExtraDescription(t *testing.T) {
+	generated := BuildStackedPRBody("abc1234def", testLinks, 2, []int{1, 2}, "Generated.", StackFormat{})
+	body := WithPushedCommitMarker(WithExtraDescription(generated, "![screenshot](x.png)\n\n- [ ] tested\n"), "abc1234def")
+	if got, want := ParseExtraDescription(body), "![screenshot](x.png)\n\n- [ ] tested"; got != want {
+		t.Errorf("ParseExtraDescription = %q, want %q", got, want)
+	}
+	if got := ParseStackedDescription(body); got != "Generated." {
+		t.Errorf("the extra section leaked into the description: %q", got)
+	}
+	if !strings.HasPrefix(body, generated) {
+		t.Errorf("the extra section should follow the generated body, got:\n%s", body)
+	}
+
+	// Replacing the section keeps a single one; a blank one removes it.
+	replaced := WithExtraDescription(body, "New.")
+	if got := ParseExtraDescription(replaced); got != "New." || strings.Count(replaced, extraDescriptionStart) != 1 {
+		t.Errorf("replacing the section got:\n%s", replaced)
+	}
+	if got := ParsePushedCommit(replaced); got != "abc1234def" {
+		t.Errorf("replacing the section dropped the pushed-commit marker:\n%s", replaced)
+	}
+	if removed := WithExtraDescription(replaced, " \n"); strings.Contains(removed, extraDescriptionStart) {
+		t.Errorf("a blank section should be removed, got:\n%s", removed)
+	}
+	if got := WithExtraDescription("", "Only this."); ParseExtraDescription(got) != "Only this." {
+		t.Errorf("empty body got %q", got)
+	}
+	if got := ParseExtraDescription(generated); got != "" {
+		t.Errorf("ParseExtraDescription = %q for a body without the section", got)
+	}
+}
+
+func TestMergePRBody(t *testing.T) {
+	first := BuildStackedPRBody("abc1234def", testLinks, 2, []int{1, 2}, "First.", StackFormat{})
+	body := WithPushedCommitMarker(MergePRBody("feat: old body without markers", first), "abc1234def")
+	if strings.Contains(body, "old body") || !strings.Contains(body, first) {
+		t.Errorf("a body without markers should be replaced, got:\n%s", body)
+	}
+
+	// Users add text around the generated part on GitHub.
+	edited := "Fixes #7.\n\n" + body + "\n\n## Notes\n\nPRs:\n* #99 is unrelated\n"
+	second := BuildStackedPRBody("fedcba9876", testLinks, 2, []int{1, 2, 3}, "Second.", StackFormat{})
+	merged := MergePRBody(edited, second)
+	for _, want := range []string{"Fixes #7.\n\n" + generatedStart, second, "## Notes", pushedCommitMarker("abc1234def")} {
+		if !strings.Contains(merged, want) {
+			t.Errorf("merged body lacks %q:\n%s", want, merged)
+		}
+	}
+	if strings.Contains(merged, "First.") {
+		t.Errorf("merged body kept the old generated part:\n%s", merged)
+	}
+	if got := MergePRBody(merged, second); got != merged {
+		t.Errorf("merging again changed the body:\n%s", got)
+	}
+
+	// Only the generated part is parsed.
+	if block := ParseStackBlock(merged); block == nil || !reflect.DeepEqual(block.PRs, []int{1, 2, 3}) {
+		t.Errorf("ParseStackBlock = %+v, want [1 2 3]", block)
+	}
+	if got := ParseReviewCommit(merged); got != "fedcba9876" {
+		t.Errorf("ParseReviewCommit = %q", got)
+	}
+	if got := ParseStackedDescription(merged); got != "Second." {
+		t.Errorf("ParseStackedDescription = %q", got)
+	}
+}
+
+func TestBuildStackedPRBody_OldestFirstFootnote(t *testing.T) {
+	body := BuildStackedPRBody("abcdef1234567890", testLinks, 2, []int{1, 2, 3}, "", StackFormat{OldestFirst: true})
+	if !strings.Contains(body, "depends on the ones listed above it") || !strings.Contains(body, "The PRs listed below the current one") {
+		t.Errorf("footnote should match the oldest-first order, got:\n%s", body)
+	}
+}
+
 func TestBuildStackedPRBody_WithStack(t *testing.T) {
-	body := BuildStackedPRBody("abcdef1234567890", "owner/repo", 2, []int{1, 2, 3}, "Some description")
+	body := BuildStackedPRBody("abcdef1234567890", testLinks, 2, []int{1, 2, 3}, "Some description", StackFormat{})
 	if !strings.Contains(body, "stacked PR") {
 		t.Error("expected stacked PR intro")
 	}
@@ -206,7 +474,7 @@ func TestBuildStackedPRBody_WithStack(t *testing.T) {
 }
 
 func TestBuildStackedPRBody_WithStack_DescriptionHeadingPosition(t *testing.T) {
-	body := BuildStackedPRBody("abcdef1234567890", "owner/repo", 2, []int{1, 2, 3}, "My detailed description")
+	body := BuildStackedPRBody("abcdef1234567890", testLinks, 2, []int{1, 2, 3}, "My detailed description", StackFormat{})
 	// ## Description should appear after the --- divider and before the commit body
 	descrIdx := strings.Index(body, "## Description")
 	dividerIdx := strings.Index(body, "---")
@@ -220,14 +488,14 @@ func TestBuildStackedPRBody_WithStack_DescriptionHeadingPosition(t *testing.T) {
 }
 
 func TestBuildStackedPRBody_WithStack_NoBody(t *testing.T) {
-	body := BuildStackedPRBody("abcdef1234567890", "owner/repo", 2, []int{1, 2, 3}, "")
+	body := BuildStackedPRBody("abcdef1234567890", testLinks, 2, []int{1, 2, 3}, "", StackFormat{})
 	if strings.Contains(body, "## Description") {
 		t.Errorf("should not contain ## Description when commit body is empty, got:\n%s", body)
 	}
 }
 
 func TestBuildStackedPRBody_NoStack(t *testing.T) {
-	body := BuildStackedPRBody("abc123", "owner/repo", 1, []int{1}, "my body")
+	body := BuildStackedPRBody("abc123", testLinks, 1, []int{1}, "my body", StackFormat{})
 	if strings.Contains(body, "stacked PR") {
 		t.Error("expected no stacked PR intro for single PR")
 	}
@@ -240,14 +508,65 @@ func TestBuildStackedPRBody_NoStack(t *testing.T) {
 }
 
 func TestBuildStackedPRBody_NoStack_EmptyBody(t *testing.T) {
-	body := BuildStackedPRBody("abc123", "owner/repo", 1, []int{1}, "")
+	body := BuildStackedPRBody("abc123", testLinks, 1, []int{1}, "", StackFormat{})
 	if body != "" {
 		t.Errorf("expected empty body for single PR with no commit body, got %q", body)
 	}
 }
 
+func TestBuildSquashedPRBody(t *testing.T) {
+	commits := []SquashedCommit{
+		{Hash: "aaaaaaa1111111", Title: "feat: add A"},
+		{Hash: "bbbbbbb2222222", Title: "feat: use A"},
+	}
+	body := BuildSquashedPRBody(testLinks, 7, commits, "my body", "")
+
+	want := "This PR contains a stack of 2 commits. Review them one by one:\n\n" +
+		"- [ ] [aaaaaaa](https://github.com/owner/repo/pull/7/commits/aaaaaaa1111111) feat: add A\n" +
+		"- [ ] [bbbbbbb](https://github.com/owner/repo/pull/7/commits/bbbbbbb2222222) feat: use A\n" +
+		"\n---\n\n## Description\n\nmy body\n"
+	if body != want {
+		t.Errorf("body =\n%s\nwant\n%s", body, want)
+	}
+}
+
+func TestBuildSquashedPRBody_KeepsTicksOfUnchangedCommits(t *testing.T) {
+	previous := BuildSquashedPRBody(testLinks, 7, []SquashedCommit{
+		{Hash: "aaaaaaa1111111", Title: "feat: add A"},
+		{Hash: "bbbbbbb2222222", Title: "feat: use A"},
+	}, "", "")
+	previous = strings.ReplaceAll(previous, "- [ ]", "- [x]")
+
+	body := BuildSquashedPRBody(testLinks, 7, []SquashedCommit{
+		{Hash: "aaaaaaa1111111", Title: "feat: add A"},
+		{Hash: "ccccccc3333333", Title: "feat: use A"},
+	}, "", previous)
+	if !strings.Contains(body, "- [x] [aaaaaaa]") {
+		t.Errorf("unchanged commit should stay ticked:\n%s", body)
+	}
+	if !strings.Contains(body, "- [ ] [ccccccc]") {
+		t.Errorf("rewritten commit should be unticked:\n%s", body)
+	}
+}
+
+func TestBuildSquashedPRBody_SingleCommit(t *testing.T) {
+	body := BuildSquashedPRBody(testLinks, 7, []SquashedCommit{{Hash: "aaaaaaa1111111", Title: "feat: add A"}}, "my body", "")
+	if body != "my body" {
+		t.Errorf("expected plain commit body for a single commit, got %q", body)
+	}
+}
+
+func TestParseReviewedCommits_IgnoresOtherTaskLists(t *testing.T) {
+	got := ParseReviewedCommits("- [x] [docs](https://example.com/docs)\n" +
+		"- [X] [abc1234](https://github.com/o/r/pull/1/commits/abc1234def)\n" +
+		"- [x] [zzz](https://github.com/o/r/commits/def5678abc)\n")
+	if len(got) != 1 || !got["abc1234def"] {
+		t.Errorf("got %v, want only abc1234def", got)
+	}
+}
+
 func TestBuildDiffComment_EmptyDiff(t *testing.T) {
-	result := BuildDiffComment("", "owner/repo", "main", "aaa111", "bbb222", false)
+	result := BuildDiffComment("", testLinks, "main", "aaa111", "bbb222", false, "")
 	if !strings.Contains(result, "Changes since last push") {
 		t.Errorf("expected 'Changes since last push' header, got:\n%s", result)
 	}
@@ -266,7 +585,7 @@ func TestBuildDiffComment_WithDiff(t *testing.T) {
  func Bar() {}
 -// old comment
 `
-	result := BuildDiffComment(diff, "owner/repo", "main", "old1234567890ab", "new4567890abcde", false)
+	result := BuildDiffComment(diff, testLinks, "main", "old1234567890ab", "new4567890abcde", false, "")
 	if !strings.Contains(result, "Changes since last push") {
 		t.Error("expected 'Changes since last push' header")
 	}
@@ -307,7 +626,7 @@ func TestBuildDiffComment_LargeDiff_CollapsedByDefault(t *testing.T) {
 	}
 	diff := strings.Join(diffLines, "\n")
 
-	result := BuildDiffComment(diff, "owner/repo", "main", "old123", "new456", false)
+	result := BuildDiffComment(diff, testLinks, "main", "old123", "new456", false, "")
 	if strings.Contains(result, "<details open>") {
 		t.Errorf("expected collapsed details for large diff, got:\n%s", result)
 	}
@@ -317,7 +636,7 @@ func TestBuildDiffComment_LargeDiff_CollapsedByDefault(t *testing.T) {
 }
 
 func TestBuildDiffComment_EmptyDiff_WithFooter(t *testing.T) {
-	result := BuildDiffComment("", "owner/repo", "main", "aaa111222333", "bbb444555666", false)
+	result := BuildDiffComment("", testLinks, "main", "aaa111222333", "bbb444555666", false, "")
 	if !strings.Contains(result, "View the diff on") {
 		t.Errorf("expected compare link even for empty diff, got:\n%s", result)
 	}
@@ -366,6 +685,102 @@ func TestDiffStats(t *testing.T) {
 	}
 }
 
+func TestParseGitDiff_ExtendedHeaders(t *testing.T) {
+	diff := `diff --git a/old.go b/new.go
+similarity index 90%
+rename from old.go
+rename to new.go
+index 1111111..2222222 100644
+--- a/old.go
++++ b/new.go
+@@ -1 +1 @@
+-package old
++package new
+diff --git a/logo.png b/logo.png
+index 3333333..4444444 100644
+Binary files a/logo.png and b/logo.png differ
+diff --git a/run.sh b/run.sh
+old mode 100644
+new mode 100755
+diff --git a/gone.go b/gone.go
+deleted file mode 100644
+index 5555555..0000000
+--- a/gone.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package gone
+`
+	files := parseGitDiff(diff)
+	if len(files) != 4 {
+		t.Fatalf("expected 4 files, got %d", len(files))
+	}
+	rename := files[0]
+	if rename.header != "new.go" || rename.oldPath != "old.go" || rename.status != "renamed" {
+		t.Errorf("rename parsed as %+v", rename)
+	}
+	if !rename.hunks || rename.added != 1 || rename.removed != 1 {
+		t.Errorf("rename stats: hunks=%v +%d -%d, want +1 -1", rename.hunks, rename.added, rename.removed)
+	}
+	if !files[1].binary || files[1].hunks {
+		t.Errorf("binary parsed as %+v", files[1])
+	}
+	if files[2].oldMode != "100644" || files[2].newMode != "100755" || files[2].hunks {
+		t.Errorf("mode change parsed as %+v", files[2])
+	}
+	if files[3].status != "deleted" || files[3].removed != 1 {
+		t.Errorf("deletion parsed as %+v", files[3])
+	}
+}
+
+func TestBuildDiffComment_AnnotatesFileTypes(t *testing.T) {
+	diff := `diff --git a/old.go b/new.go
+similarity index 100%
+rename from old.go
+rename to new.go
+diff --git a/logo.png b/logo.png
+new file mode 100644
+index 0000000..4444444
+Binary files /dev/null and b/logo.png differ
+diff --git a/run.sh b/run.sh
+old mode 100644
+new mode 100755
+diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1 +1 @@
+-package a
++package b
+`
+	result := BuildDiffComment(diff, testLinks, "main", "old1234567890ab", "new4567890abcde", false, "")
+	for _, want := range []string{
+		"\n- <code>new.go</code> (renamed from <code>old.go</code>)\n",
+		"\n- <code>logo.png</code> (new file, binary)\n",
+		"\n- <code>run.sh</code> (mode 100644 → 100755)\n",
+		"<summary><code>a.go</code> (+1, -1)</summary>",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("comment lacks %q:\n%s", want, result)
+		}
+	}
+	if n := strings.Count(result, "<details"); n != 1 {
+		t.Errorf("only the text diff should get a collapsible section, got %d:\n%s", n, result)
+	}
+}
+
+func TestDiffStats_CountsOnlyHunkLines(t *testing.T) {
+	chunk := `--- a/file.md
++++ b/file.md
+@@ -1 +1,2 @@
+-old
++++ a line that starts with two plus signs
++new
+`
+	added, removed := diffStats(chunk)
+	if added != 2 || removed != 1 {
+		t.Errorf("diffStats = +%d -%d, want +2 -1", added, removed)
+	}
+}
+
 func TestBuildDiffComment_DiffContainingCodeFences(t *testing.T) {
 	// A diff of a markdown file that itself contains fenced code blocks.
 	// The triple backticks inside the diff must not break the outer code fence.
@@ -383,7 +798,7 @@ func TestBuildDiffComment_DiffContainingCodeFences(t *testing.T) {
 +
  ## Configuration
 `
-	result := BuildDiffComment(diff, "owner/repo", "main", "abc1234567890", "def4567890abc", false)
+	result := BuildDiffComment(diff, testLinks, "main", "abc1234567890", "def4567890abc", false, "")
 
 	// The output must contain the footer (which comes after the diff block).
 	// If triple backticks in the diff prematurely close the fence, the footer
@@ -403,15 +818,140 @@ func TestBuildDiffComment_DiffContainingCodeFences(t *testing.T) {
 	}
 }
 
+func TestBuildDiffComment_TruncatesOversizedDiff(t *testing.T) {
+	small := "diff --git a/small.go b/small.go\n--- a/small.go\n+++ b/small.go\n@@ -1 +1 @@\n-a\n+b\n"
+	big := "diff --git a/big.go b/big.go\n--- a/big.go\n+++ b/big.go\n@@ -0,0 +1,40000 @@\n" + strings.Repeat("+xx\n", 40000)
+	diff := small + big
+
+	result := BuildDiffComment(diff, testLinks, "main", "old1234567890ab", "new4567890abcde", false, "")
+	if len(result) > maxCommentLen {
+		t.Fatalf("comment is %d chars, limit %d", len(result), maxCommentLen)
+	}
+	for _, want := range []string{
+		TruncatedDiffMarker,
+		"the diffs of 1 of 2 files are left out. View the full diff with the links below.",
+		"- <code>big.go</code> (+40000, -0) — diff left out",
+		"<summary><code>small.go</code> (+1, -1)</summary>",
+		"View the diff on",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("comment lacks %q:\n%.600s", want, result)
+		}
+	}
+
+	linked := BuildDiffComment(diff, testLinks, "main", "old1234567890ab", "new4567890abcde", false, "https://gist.github.com/abc")
+	if !strings.Contains(linked, "The full diff is in [this gist](https://gist.github.com/abc).") {
+		t.Errorf("comment should link the gist:\n%.600s", linked)
+	}
+}
+
+func TestBuildDiffComment_TruncatesManyFiles(t *testing.T) {
+	var b strings.Builder
+	for i := range 3000 {
+		fmt.Fprintf(&b, "diff --git a/some/long/path/file%04d.go b/some/long/path/file%04d.go\n--- a/f\n+++ b/f\n@@ -1 +1 @@\n-a\n+b\n", i, i)
+	}
+	result := BuildDiffComment(b.String(), testLinks, "main", "old1234567890ab", "new4567890abcde", false, "")
+	if len(result) > maxCommentLen {
+		t.Fatalf("comment is %d chars, limit %d", len(result), maxCommentLen)
+	}
+	if !strings.Contains(result, "more files\n") {
+		t.Errorf("files that do not fit even as a line should be counted:\n%.600s", result)
+	}
+}
+
+func TestBuildDiffComment_FitsUntouched(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n"
+	result := BuildDiffComment(diff, testLinks, "main", "old1234567890ab", "new4567890abcde", false, "https://gist.github.com/abc")
+	if strings.Contains(result, TruncatedDiffMarker) || strings.Contains(result, "gist") {
+		t.Errorf("a comment that fits should not be truncated:\n%s", result)
+	}
+}
+
+func TestBuildDiffComment_SummaryLinksFiles(t *testing.T) {
+	diff := `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1 +1 @@
+-package a
++package b
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+`
+	result := BuildDiffComment(diff, testLinks, "main", "old1234567890ab", "new4567890abcde", false, "")
+	for _, want := range []string{
+		"| File | Changes |\n|---|---|\n",
+		"| [`a.go`](#jip-diff-new4567-1) | +1, -1 |\n",
+		"| `logo.png` | binary |\n",
+		"<a name=\"jip-diff-new4567-1\"></a>\n<details open>",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("comment lacks %q:\n%s", want, result)
+		}
+	}
+	if strings.Index(result, "| File |") > strings.Index(result, "<details") {
+		t.Errorf("the summary should come before the diffs:\n%s", result)
+	}
+
+	single := BuildDiffComment(diff[:strings.Index(diff, "diff --git a/logo.png")], testLinks, "main", "old1234567890ab", "new4567890abcde", false, "")
+	if strings.Contains(single, "| File |") {
+		t.Errorf("a single file needs no summary:\n%s", single)
+	}
+}
+
+func TestCollapseContext(t *testing.T) {
+	body := `--- a/f.go
++++ b/f.go
+@@ -1,12 +1,12 @@
+ one
+ two
+ three
+-four
++FOUR
+ five
+ six
+ seven
+ eight
+ nine
+-ten
++TEN
+ eleven
+ twelve`
+	want := `--- a/f.go
++++ b/f.go
+@@ -1,12 +1,12 @@
+ ⋯ 2 unchanged lines
+ three
+-four
++FOUR
+ five
+ ⋯ 3 unchanged lines
+ nine
+-ten
++TEN
+ eleven
+ twelve`
+	if got := collapseContext(body); got != want {
+		t.Errorf("collapseContext =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCollapseContext_NoNewlineMarker(t *testing.T) {
+	body := "@@ -1,4 +1,4 @@\n a\n b\n c\n-d\n\\ No newline at end of file\n+e\n\\ No newline at end of file"
+	want := "@@ -1,4 +1,4 @@\n ⋯ 2 unchanged lines\n c\n-d\n\\ No newline at end of file\n+e\n\\ No newline at end of file"
+	if got := collapseContext(body); got != want {
+		t.Errorf("collapseContext =\n%s\nwant\n%s", got, want)
+	}
+}
+
 func TestRangeDiffFooter_Empty(t *testing.T) {
-	result := rangeDiffFooter("", "main", "old", "new")
+	result := rangeDiffFooter(forge.Links{}, "main", "old", "new")
 	if result != "" {
 		t.Errorf("expected empty footer with no repo name, got %q", result)
 	}
 }
 
 func TestRangeDiffFooter_WithData(t *testing.T) {
-	result := rangeDiffFooter("owner/repo", "main", "old1234567890", "new4567890123")
+	result := rangeDiffFooter(testLinks, "main", "old1234567890", "new4567890123")
 	if !strings.Contains(result, "https://github.com/owner/repo/compare/old1234567890..new4567890123") {
 		t.Errorf("expected compare URL, got:\n%s", result)
 	}
@@ -422,3 +962,42 @@ func TestRangeDiffFooter_WithData(t *testing.T) {
 		t.Errorf("expected jj interdiff command, got:\n%s", result)
 	}
 }
+
+func TestAppendRevision_NumbersLikePatchsets(t *testing.T) {
+	first := BuildDiffComment("", testLinks, "main", "aaa", "bbb", false, "")
+	h := AppendRevision("", first)
+	if !strings.HasPrefix(h, RevisionHistoryMarker) {
+		t.Errorf("history must start with its marker:\n%s", h)
+	}
+	if !strings.Contains(h, "### v2 — Changes since last push") {
+		t.Errorf("first entry should be v2:\n%s", h)
+	}
+
+	h = AppendRevision(h, "No changes since last push.")
+	if !strings.Contains(h, "### v3\n\nNo changes since last push.") {
+		t.Errorf("plain entries get a version heading:\n%s", h)
+	}
+	if strings.Index(h, "### v2") > strings.Index(h, "### v3") {
+		t.Errorf("entries must be in push order:\n%s", h)
+	}
+	if strings.Count(h, RevisionHistoryMarker) != 1 {
+		t.Errorf("history marker duplicated:\n%s", h)
+	}
+}
+
+func TestAppendRevision_DropsOldDiffsWhenTooLong(t *testing.T) {
+	big := "diff --git a/f b/f\n--- a/f\n+++ b/f\n@@ -1 +1 @@\n" + strings.Repeat("+x\n", 12000)
+	var h string
+	for range 3 {
+		h = AppendRevision(h, BuildDiffComment(big, testLinks, "main", "aaa", "bbb", false, ""))
+	}
+	if len(h) > maxCommentLen {
+		t.Errorf("history is %d chars, limit %d", len(h), maxCommentLen)
+	}
+	if !strings.Contains(h, "### v2 — Changes since last push\n\n_Diff dropped") {
+		t.Errorf("oldest diff should be dropped:\n%.300s", h)
+	}
+	if !strings.Contains(h, "### v4 — Changes since last push") || strings.Contains(h, "### v4 — Changes since last push\n\n_Diff dropped") {
+		t.Error("newest revision must keep its diff")
+	}
+}
@@ -0,0 +1,85 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	gogithub "github.com/google/go-github/v68/github"
+
+	"github.com/omarkohl/jip/internal/retry"
+)
+
+// retry is retry.DoContext honoring how long GitHub asks jip to wait when
+// it refuses a request for exceeding a rate limit. fn sends the client's
+// requests with ctx so that cancelling the client's context stops them and
+// the waits between them.
+func (c *Client) retry(fn func(ctx context.Context) error) error {
+	return retry.DoContext(c.ctx, fn, retry.WithRetryAfter(retryAfter))
+}
+
+// retryAfter tells how long GitHub asked to wait before retrying after err:
+// the secondary (abuse) limit's Retry-After, the time until the primary
+// limit resets, or the hint of a rate-limited response.
+func retryAfter(err error) (time.Duration, bool) {
+	var abuse *gogithub.AbuseRateLimitError
+	if errors.As(err, &abuse) && abuse.RetryAfter != nil {
+		return *abuse.RetryAfter, true
+	}
+	var limit *gogithub.RateLimitError
+	if errors.As(err, &limit) {
+		return max(time.Until(limit.Rate.Reset.Time), 0), true
+	}
+	var limited *rateLimitedError
+	if errors.As(err, &limited) {
+		return limited.wait, limited.hinted
+	}
+	var ghErr *gogithub.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil && isRateLimited(ghErr.Response) {
+		return waitHint(ghErr.Response.Header, time.Now())
+	}
+	return 0, false
+}
+
+// rateLimitedError is a response GitHub refused for exceeding a rate limit,
+// for the requests jip sends without go-github.
+type rateLimitedError struct {
+	status int
+	body   string
+	wait   time.Duration
+	hinted bool // whether GitHub said how long to wait
+}
+
+func (e *rateLimitedError) Error() string {
+	return fmt.Sprintf("GitHub API returned %d: %s", e.status, e.body)
+}
+
+// rateLimited returns the error for the rate-limited response resp with
+// body.
+func rateLimited(resp *http.Response, body []byte) error {
+	wait, hinted := waitHint(resp.Header, time.Now())
+	return &rateLimitedError{status: resp.StatusCode, body: string(body), wait: wait, hinted: hinted}
+}
+
+// waitHint reads from response headers how long GitHub asks to wait:
+// Retry-After in seconds or as a date, or else the reset time of a rate
+// limit with no requests remaining.
+func waitHint(h http.Header, now time.Time) (time.Duration, bool) {
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+		if at, err := http.ParseTime(v); err == nil {
+			return max(at.Sub(now), 0), true
+		}
+	}
+	if h.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Unix(reset, 0).Sub(now), 0), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,55 @@
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWaitHint(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	for _, tt := range []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		ok     bool
+	}{
+		{"seconds", http.Header{"Retry-After": {"30"}}, 30 * time.Second, true},
+		{"date", http.Header{"Retry-After": {now.Add(time.Minute).UTC().Format(http.TimeFormat)}}, time.Minute, true},
+		{"reset", http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {strconv.FormatInt(now.Add(90*time.Second).Unix(), 10)}}, 90 * time.Second, true},
+		{"requests left", http.Header{"X-Ratelimit-Remaining": {"10"}, "X-Ratelimit-Reset": {"1"}}, 0, false},
+		{"none", http.Header{}, 0, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := waitHint(tt.header, now)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("waitHint = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestGraphQL_RetriesAfterSecondaryRateLimit(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"You have exceeded a secondary rate limit"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	client := newGraphQLTestClient(t, server, "owner", "repo")
+	if err := client.graphql(`query{viewer{login}}`, nil, nil); err != nil {
+		t.Fatalf("graphql: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("%d requests, want a retry after the limit", calls)
+	}
+}
@@ -1,9 +1,17 @@
 package github
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"regexp"
 	"strings"
+
+	gogithub "github.com/google/go-github/v68/github"
+
+	"github.com/omarkohl/jip/internal/forge"
 )
 
 var (
@@ -25,3 +33,147 @@ func ParseRepoFromURL(url string) (owner, repo string, err error) {
 	}
 	return "", "", fmt.Errorf("cannot parse owner/repo from URL: %s", url)
 }
+
+// DefaultHost is the host of github.com repositories.
+const DefaultHost = "github.com"
+
+var hostRe = regexp.MustCompile(`^(?:([a-z][a-z0-9+.-]*)://)?(?:[^@/]+@)?([^/:@]+)(?::(\d+))?`)
+
+// ParseHostFromURL extracts the GitHub host (github.com or a GitHub
+// Enterprise Server hostname) from a remote URL, lowercased. The port is kept
+// for HTTP(S) URLs, where it is also the API's, and dropped for SSH.
+func ParseHostFromURL(url string) (string, error) {
+	m := hostRe.FindStringSubmatch(strings.TrimSpace(url))
+	if m == nil || !strings.Contains(m[2], ".") && m[2] != "localhost" {
+		return "", fmt.Errorf("cannot parse host from URL: %s", url)
+	}
+	host := strings.ToLower(m[2])
+	if m[3] != "" && (m[1] == "https" || m[1] == "http") {
+		host += ":" + m[3]
+	}
+	return host, nil
+}
+
+// hostWebURL returns the web URL of the host remoteURL names, or that of
+// github.com when remoteURL names none (e.g. is empty).
+func hostWebURL(remoteURL string) string {
+	host, err := ParseHostFromURL(remoteURL)
+	if err != nil {
+		return "https://" + DefaultHost
+	}
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(remoteURL)), "http://") {
+		return "http://" + host
+	}
+	return "https://" + host
+}
+
+// APIURLForHost returns the REST API base URL of host: empty (go-github's
+// default, api.github.com) for github.com and https://HOST/api/v3/ for
+// GitHub Enterprise Server.
+func APIURLForHost(host string) string {
+	if host == DefaultHost {
+		return ""
+	}
+	return "https://" + host + "/api/v3/"
+}
+
+// RepoInfo looks up owner/repo with the client's token. It returns an error
+// wrapping forge.ErrRepoNotAccessible when the token cannot see the repository.
+func (c *Client) RepoInfo(owner, repo string) (*forge.RepoInfo, error) {
+	slog.Debug("RepoInfo", "owner", owner, "repo", repo)
+	var r *gogithub.Repository
+	inaccessible := false
+	err := c.retry(func(ctx context.Context) error {
+		var apiErr error
+		r, _, apiErr = c.gh.Repositories.Get(ctx, owner, repo)
+		if isNotFound(apiErr) || isForbidden(apiErr) {
+			inaccessible = true // an answer, not a transient failure
+			return nil
+		}
+		return apiErr
+	})
+	if err != nil {
+		slog.Debug("RepoInfo failed", "err", err)
+		return nil, fmt.Errorf("looking up repository %s/%s: %w", owner, repo, err)
+	}
+	if inaccessible {
+		return nil, fmt.Errorf("%s/%s: %w", owner, repo, forge.ErrRepoNotAccessible)
+	}
+	info := &forge.RepoInfo{
+		Owner:   r.GetOwner().GetLogin(),
+		Name:    r.GetName(),
+		Private: r.GetPrivate(),
+		CanPush: r.GetPermissions()["push"],
+	}
+	slog.Debug("RepoInfo ok", "owner", info.Owner, "repo", info.Name, "private", info.Private, "push", info.CanPush)
+	return info, nil
+}
+
+// CanReadPRs reports whether the token may list the pull requests of the
+// client's repository. Fine-grained and GitHub App tokens without the pull
+// requests permission are refused even when they can see the repository.
+func (c *Client) CanReadPRs() (bool, error) {
+	slog.Debug("CanReadPRs", "owner", c.owner, "repo", c.repo)
+	refused := false
+	err := c.retry(func(ctx context.Context) error {
+		_, _, apiErr := c.gh.PullRequests.List(ctx, c.owner, c.repo, &gogithub.PullRequestListOptions{ListOptions: gogithub.ListOptions{PerPage: 1}})
+		if isNotFound(apiErr) || isForbidden(apiErr) {
+			refused = true // an answer, not a transient failure
+			return nil
+		}
+		return apiErr
+	})
+	if err != nil {
+		slog.Debug("CanReadPRs failed", "err", err)
+		return false, fmt.Errorf("listing the PRs of %s/%s: %w", c.owner, c.repo, err)
+	}
+	slog.Debug("CanReadPRs ok", "allowed", !refused)
+	return !refused, nil
+}
+
+// isForbidden reports whether err is a GitHub API 403 response. Rate limits
+// are not matched: go-github reports them as separate error types.
+func isForbidden(err error) bool {
+	var ghErr *gogithub.ErrorResponse
+	return errors.As(err, &ghErr) && ghErr.Response != nil &&
+		ghErr.Response.StatusCode == http.StatusForbidden
+}
+
+// Canonicalize points the client at the repository's current name when it
+// was renamed or transferred since the remote URL was set. GitHub redirects
+// REST requests for the old name, but GraphQL lookups by owner and name do
+// not follow it, so PR lookups would fail. It returns the repository, as
+// RepoInfo does, and the previous "owner/repo" when the client moved, or ""
+// when the name was current (differences in letter case alone are not a
+// move).
+func (c *Client) Canonicalize() (*forge.RepoInfo, string, error) {
+	info, err := c.RepoInfo(c.owner, c.repo)
+	if err != nil {
+		return nil, "", err
+	}
+	if strings.EqualFold(info.Owner, c.owner) && strings.EqualFold(info.Name, c.repo) {
+		return info, "", nil
+	}
+	old := c.owner + "/" + c.repo
+	slog.Debug("repository moved", "from", old, "to", info.Owner+"/"+info.Name)
+	c.owner, c.repo = info.Owner, info.Name
+	return info, old, nil
+}
+
+// ReplaceRepoInURL rewrites a GitHub remote URL (HTTPS or SSH) to point at
+// owner/repo instead of the repository it currently names.
+func ReplaceRepoInURL(url, owner, repo string) (string, error) {
+	oldOwner, oldRepo, err := ParseRepoFromURL(url)
+	if err != nil {
+		return "", err
+	}
+	return strings.Replace(url, oldOwner+"/"+oldRepo, owner+"/"+repo, 1), nil
+}
+
+// isUnprocessable reports whether err is a GitHub API 422 response, which
+// the commits endpoint returns for a SHA it has never seen.
+func isUnprocessable(err error) bool {
+	var ghErr *gogithub.ErrorResponse
+	return errors.As(err, &ghErr) && ghErr.Response != nil &&
+		ghErr.Response.StatusCode == http.StatusUnprocessableEntity
+}
@@ -0,0 +1,78 @@
+package github
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/omarkohl/jip/internal/forge"
+)
+
+// PRReviews returns the unresolved review threads and the reviewers
+// requesting changes of each pull request, keyed by PR number. Only the
+// latest review of each reviewer counts, as on the PR page. The first 100
+// threads and reviews of a PR are considered.
+func (c *Client) PRReviews(numbers []int) (map[int]forge.ReviewSummary, error) {
+	slog.Debug("PRReviews", "numbers", numbers)
+	out := make(map[int]forge.ReviewSummary, len(numbers))
+	for start := 0; start < len(numbers); start += prQueryBatchSize {
+		batch := numbers[start:min(start+prQueryBatchSize, len(numbers))]
+		var data struct {
+			Repository map[string]*struct {
+				ReviewThreads struct {
+					Nodes []struct {
+						IsResolved bool `json:"isResolved"`
+					} `json:"nodes"`
+				} `json:"reviewThreads"`
+				LatestOpinionatedReviews struct {
+					Nodes []struct {
+						State  string `json:"state"`
+						Author *struct {
+							Login string `json:"login"`
+						} `json:"author"`
+					} `json:"nodes"`
+				} `json:"latestOpinionatedReviews"`
+			} `json:"repository"`
+		}
+		err := c.graphql(buildReviewsQuery(batch), map[string]any{
+			"owner": c.owner,
+			"repo":  c.repo,
+		}, &data)
+		if err != nil {
+			slog.Debug("PRReviews failed", "err", err)
+			return nil, fmt.Errorf("querying reviews: %w", err)
+		}
+		for i, number := range batch {
+			pr := data.Repository[fmt.Sprintf("p%d", i)]
+			if pr == nil {
+				continue
+			}
+			var s forge.ReviewSummary
+			for _, t := range pr.ReviewThreads.Nodes {
+				if !t.IsResolved {
+					s.UnresolvedThreads++
+				}
+			}
+			for _, r := range pr.LatestOpinionatedReviews.Nodes {
+				if r.State == "CHANGES_REQUESTED" && r.Author != nil {
+					s.ChangesRequested = append(s.ChangesRequested, r.Author.Login)
+				}
+			}
+			if s.UnresolvedThreads > 0 || len(s.ChangesRequested) > 0 {
+				out[number] = s
+			}
+		}
+	}
+	slog.Debug("PRReviews ok", "found", len(out))
+	return out, nil
+}
+
+func buildReviewsQuery(numbers []int) string {
+	var b strings.Builder
+	b.WriteString("query($owner:String!,$repo:String!){repository(owner:$owner,name:$repo){")
+	for i, number := range numbers {
+		fmt.Fprintf(&b, `p%d:pullRequest(number:%d){reviewThreads(first:100){nodes{isResolved}}latestOpinionatedReviews(first:100){nodes{state author{login}}}}`, i, number)
+	}
+	b.WriteString("}}")
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/omarkohl/jip/internal/forge"
+)
+
+func TestPRReviews(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !strings.Contains(req.Query, "p0:pullRequest(number:3)") || !strings.Contains(req.Query, "reviewThreads") {
+			t.Errorf("unexpected query: %s", req.Query)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"repository":{
+			"p0":{"reviewThreads":{"nodes":[{"isResolved":false},{"isResolved":true},{"isResolved":false}]},
+			      "latestOpinionatedReviews":{"nodes":[{"state":"APPROVED","author":{"login":"bob"}}]}},
+			"p1":{"reviewThreads":{"nodes":[]},
+			      "latestOpinionatedReviews":{"nodes":[{"state":"CHANGES_REQUESTED","author":{"login":"carol"}}]}},
+			"p2":{"reviewThreads":{"nodes":[{"isResolved":true}]},
+			      "latestOpinionatedReviews":{"nodes":[]}}
+		}}}`))
+	}))
+	defer server.Close()
+
+	client := newGraphQLTestClient(t, server, "owner", "repo")
+	got, err := client.PRReviews([]int{3, 5, 7})
+	if err != nil {
+		t.Fatalf("PRReviews: %v", err)
+	}
+	want := map[int]forge.ReviewSummary{
+		3: {UnresolvedThreads: 2},
+		5: {ChangesRequested: []string{"carol"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PRReviews = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,50 @@
+package github
+
+import (
+	"context"
+	"log/slog"
+
+	gogithub "github.com/google/go-github/v68/github"
+)
+
+// RequiresSignedCommits reports whether GitHub rejects unsigned commits
+// pushed to branch, through a ruleset or a classic branch protection rule.
+// The branch does not need to exist. Classic protection is only visible to
+// repository admins; without access it counts as not requiring signatures.
+func (c *Client) RequiresSignedCommits(branch string) (bool, error) {
+	slog.Debug("RequiresSignedCommits", "branch", branch)
+	var rules []*gogithub.RepositoryRule
+	err := c.retry(func(ctx context.Context) error {
+		var apiErr error
+		rules, _, apiErr = c.gh.Repositories.GetRulesForBranch(ctx, c.owner, c.repo, branch)
+		return apiErr
+	})
+	if err != nil {
+		slog.Debug("RequiresSignedCommits failed", "err", err)
+		return false, err
+	}
+	for _, r := range rules {
+		if r.Type == "required_signatures" {
+			slog.Debug("RequiresSignedCommits ok", "required", true, "ruleset", r.RulesetID)
+			return true, nil
+		}
+	}
+
+	var protection *gogithub.SignaturesProtectedBranch
+	err = c.retry(func(ctx context.Context) error {
+		var apiErr error
+		protection, _, apiErr = c.gh.Repositories.GetSignaturesProtectedBranch(ctx, c.owner, c.repo, branch)
+		if isNotFound(apiErr) || isForbidden(apiErr) {
+			protection = nil // not protected, or not visible with this token
+			return nil
+		}
+		return apiErr
+	})
+	if err != nil {
+		slog.Debug("RequiresSignedCommits failed", "err", err)
+		return false, err
+	}
+	required := protection.GetEnabled()
+	slog.Debug("RequiresSignedCommits ok", "required", required)
+	return required, nil
+}
@@ -0,0 +1,70 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequiresSignedCommits_Ruleset(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/repo/rules/branches/{branch...}", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.PathValue("branch"); got != "jip/add-x/xyzklmno" {
+			t.Errorf("branch = %q", got)
+		}
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"type": "deletion", "ruleset_id": 1},
+			{"type": "required_signatures", "ruleset_id": 2},
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(t, server, "owner", "repo")
+	required, err := client.RequiresSignedCommits("jip/add-x/xyzklmno")
+	if err != nil {
+		t.Fatalf("RequiresSignedCommits: %v", err)
+	}
+	if !required {
+		t.Error("expected signed commits to be required")
+	}
+}
+
+func TestRequiresSignedCommits_BranchProtection(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		status int
+		want   bool
+	}{
+		{"enabled", http.StatusOK, true},
+		{"not protected", http.StatusNotFound, false},
+		{"not admin", http.StatusForbidden, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/v3/repos/owner/repo/rules/branches/main", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("[]"))
+			})
+			mux.HandleFunc("GET /api/v3/repos/owner/repo/branches/main/protection/required_signatures", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				if tt.status != http.StatusOK {
+					_ = json.NewEncoder(w).Encode(map[string]any{"message": http.StatusText(tt.status)})
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{"enabled": true})
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			client := newTestClient(t, server, "owner", "repo")
+			required, err := client.RequiresSignedCommits("main")
+			if err != nil {
+				t.Fatalf("RequiresSignedCommits: %v", err)
+			}
+			if required != tt.want {
+				t.Errorf("required = %v, want %v", required, tt.want)
+			}
+		})
+	}
+}
@@ -9,7 +9,7 @@ import (
 
 	gogithub "github.com/google/go-github/v68/github"
 
-	"github.com/omarkohl/jip/internal/retry"
+	"github.com/omarkohl/jip/internal/forge"
 )
 
 // The Stacks REST API (repos/{owner}/{repo}/stacks...) backs GitHub's native
@@ -17,45 +17,6 @@ import (
 // endpoints and payload shapes mirror what the official gh-stack CLI
 // extension uses and may change before general availability.
 
-// StackPR is a pull request entry within a native GitHub stack.
-type StackPR struct {
-	Number   int     `json:"number"`
-	State    string  `json:"state"` // "open" or "closed"
-	Draft    bool    `json:"draft"`
-	MergedAt *string `json:"merged_at"`
-}
-
-// Merged reports whether the pull request has been merged.
-func (p StackPR) Merged() bool { return p.MergedAt != nil && *p.MergedAt != "" }
-
-// StackBase describes the base ref of a stack.
-type StackBase struct {
-	Ref string `json:"ref"`
-}
-
-// Stack is a native GitHub stack of pull requests. Number is the human-facing
-// stack number used in API paths; PullRequests is ordered bottom to top.
-type Stack struct {
-	Number       int       `json:"number"`
-	URL          string    `json:"url"`
-	Open         bool      `json:"open"`
-	Base         StackBase `json:"base"`
-	PullRequests []StackPR `json:"pull_requests"`
-}
-
-// OpenPRNumbers returns the numbers of the stack's open, unmerged PRs, bottom
-// to top. Merged PRs stay listed in a stack after a partial merge, so callers
-// comparing against a local stack must ignore them.
-func (s *Stack) OpenPRNumbers() []int {
-	var nums []int
-	for _, p := range s.PullRequests {
-		if p.State == "open" && !p.Merged() {
-			nums = append(nums, p.Number)
-		}
-	}
-	return nums
-}
-
 func (c *Client) stacksPath() string {
 	return fmt.Sprintf("repos/%s/%s/stacks", c.owner, c.repo)
 }
@@ -72,13 +33,13 @@ func isNotFound(err error) bool {
 func (c *Client) StacksEnabled() (bool, error) {
 	slog.Debug("StacksEnabled")
 	enabled := true
-	err := retry.Do(func() error {
+	err := c.retry(func(ctx context.Context) error {
 		req, err := c.gh.NewRequest(http.MethodGet, c.stacksPath(), nil)
 		if err != nil {
 			return err
 		}
-		var stacks []Stack
-		_, apiErr := c.gh.Do(context.Background(), req, &stacks)
+		var stacks []forge.Stack
+		_, apiErr := c.gh.Do(ctx, req, &stacks)
 		if isNotFound(apiErr) {
 			enabled = false // a 404 is an answer, not a transient failure
 			return nil
@@ -95,16 +56,16 @@ func (c *Client) StacksEnabled() (bool, error) {
 
 // FindStackForPR returns the stack containing the given PR, or nil when the
 // PR is not part of any stack.
-func (c *Client) FindStackForPR(number int) (*Stack, error) {
+func (c *Client) FindStackForPR(number int) (*forge.Stack, error) {
 	slog.Debug("FindStackForPR", "number", number)
-	var stacks []Stack
-	err := retry.Do(func() error {
+	var stacks []forge.Stack
+	err := c.retry(func(ctx context.Context) error {
 		path := fmt.Sprintf("%s?pull_request=%d", c.stacksPath(), number)
 		req, err := c.gh.NewRequest(http.MethodGet, path, nil)
 		if err != nil {
 			return err
 		}
-		_, apiErr := c.gh.Do(context.Background(), req, &stacks)
+		_, apiErr := c.gh.Do(ctx, req, &stacks)
 		return apiErr
 	})
 	if err != nil {
@@ -125,15 +86,15 @@ type stackRequest struct {
 // CreateStack creates a native GitHub stack from PR numbers ordered bottom to
 // top. The PRs must already form a valid base-to-head chain (each PR based on
 // the head branch of the one below), and there must be at least two.
-func (c *Client) CreateStack(prNumbers []int) (*Stack, error) {
+func (c *Client) CreateStack(prNumbers []int) (*forge.Stack, error) {
 	slog.Debug("CreateStack", "prs", prNumbers)
-	var stack Stack
-	err := retry.Do(func() error {
+	var stack forge.Stack
+	err := c.retry(func(ctx context.Context) error {
 		req, err := c.gh.NewRequest(http.MethodPost, c.stacksPath(), stackRequest{PullRequests: prNumbers})
 		if err != nil {
 			return err
 		}
-		_, apiErr := c.gh.Do(context.Background(), req, &stack)
+		_, apiErr := c.gh.Do(ctx, req, &stack)
 		return apiErr
 	})
 	if err != nil {
@@ -148,16 +109,16 @@ func (c *Client) CreateStack(prNumbers []int) (*Stack, error) {
 // new PR numbers (the delta) are given, ordered from the current top upward.
 // The stacks API is append-only: reordering or mid-stack changes require
 // Unstack followed by CreateStack.
-func (c *Client) AddToStack(stackNumber int, prNumbers []int) (*Stack, error) {
+func (c *Client) AddToStack(stackNumber int, prNumbers []int) (*forge.Stack, error) {
 	slog.Debug("AddToStack", "stack", stackNumber, "prs", prNumbers)
-	var stack Stack
-	err := retry.Do(func() error {
+	var stack forge.Stack
+	err := c.retry(func(ctx context.Context) error {
 		path := fmt.Sprintf("%s/%d/add", c.stacksPath(), stackNumber)
 		req, err := c.gh.NewRequest(http.MethodPost, path, stackRequest{PullRequests: prNumbers})
 		if err != nil {
 			return err
 		}
-		_, apiErr := c.gh.Do(context.Background(), req, &stack)
+		_, apiErr := c.gh.Do(ctx, req, &stack)
 		return apiErr
 	})
 	if err != nil {
@@ -173,14 +134,14 @@ func (c *Client) AddToStack(stackNumber int, prNumbers []int) (*Stack, error) {
 // stack instead of 204).
 func (c *Client) Unstack(stackNumber int) (dissolved bool, err error) {
 	slog.Debug("Unstack", "stack", stackNumber)
-	err = retry.Do(func() error {
+	err = c.retry(func(ctx context.Context) error {
 		path := fmt.Sprintf("%s/%d/unstack", c.stacksPath(), stackNumber)
 		req, reqErr := c.gh.NewRequest(http.MethodPost, path, nil)
 		if reqErr != nil {
 			return reqErr
 		}
-		var remaining Stack
-		resp, apiErr := c.gh.Do(context.Background(), req, &remaining)
+		var remaining forge.Stack
+		resp, apiErr := c.gh.Do(ctx, req, &remaining)
 		if apiErr != nil {
 			return apiErr
 		}
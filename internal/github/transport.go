@@ -0,0 +1,36 @@
+package github
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/omarkohl/jip/internal/forge"
+)
+
+// loggingTransport logs every GitHub request at info level, which --verbose
+// turns on, so a bug report shows what jip asked GitHub and what it answered.
+type loggingTransport struct {
+	base http.RoundTripper
+}
+
+// NewLoggingTransport wraps base (forge.Transport() when nil) with request
+// logging.
+func NewLoggingTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = forge.Transport()
+	}
+	return &loggingTransport{base: base}
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	duration := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		slog.Info("github", "method", req.Method, "path", req.URL.Path, "err", err, "duration", duration)
+		return nil, err
+	}
+	slog.Info("github", "method", req.Method, "path", req.URL.Path, "status", resp.StatusCode, "duration", duration)
+	return resp, nil
+}
@@ -0,0 +1,35 @@
+package github
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoggingTransport(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(old *slog.Logger) { slog.SetDefault(old) }(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewLoggingTransport(nil)}
+	resp, err := client.Get(server.URL + "/repos/owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	out := buf.String()
+	for _, want := range []string{"msg=github", "method=GET", "path=/repos/owner/repo", "status=418", "duration="} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package i18n
+
+// german holds the German translations.
+var german = map[string]string{
+	// send
+	"Resuming the send of %s from %s.\n":                                      "Setze das Senden von %s vom %s fort.\n",
+	"Fetching %s...":                                                          "Rufe %s ab...",
+	"Resolving stacks...":                                                     "Ermittle die Stacks...",
+	"Preparing bookmarks...":                                                  "Bereite die Bookmarks vor...",
+	"Pushing %d bookmark(s)...":                                               "Pushe %d Bookmark(s)...",
+	"Creating and updating PRs...":                                            "Erstelle und aktualisiere die PRs...",
+	"Updating PR descriptions...":                                             "Aktualisiere die PR-Beschreibungen...",
+	"No changes to send.":                                                     "Keine Änderungen zu senden.",
+	"\nNothing to send.\n":                                                    "\nNichts zu senden.\n",
+	"\nSkipping %d change(s) without existing PRs.\n":                         "\nÜberspringe %d Änderung(en) ohne bestehenden PR.\n",
+	"No existing PRs to update.":                                              "Keine bestehenden PRs zu aktualisieren.",
+	"\nNot sent — %d change(s) would be sent, creating %d PRs:\n\n":           "\nNicht gesendet — %d Änderung(en) würden gesendet und dabei %d PRs erstellt:\n\n",
+	"\nDry run — %d change(s) would be sent:\n\n":                             "\nProbelauf — %d Änderung(en) würden gesendet:\n\n",
+	"\nPRs would be linked into native GitHub stack(s).\n":                    "\nDie PRs würden zu nativen GitHub-Stacks verknüpft.\n",
+	"\nCommit trailers (Jip-Stack, Jip-PR) would be updated and re-pushed.\n": "\nDie Commit-Trailer (Jip-Stack, Jip-PR) würden aktualisiert und erneut gepusht.\n",
+	"\nSkipping %d bookmark(s) already pushed.\n":                             "\nÜberspringe %d bereits gepushte(s) Bookmark(s).\n",
+	"\n%d PR(s) sent:\n\n":                                                    "\n%d PR(s) gesendet:\n\n",
+	"created":                                                                 "erstellt",
+	"updated":                                                                 "aktualisiert",
+	"\nSkipped %d change(s):\n\n":                                             "\n%d Änderung(en) übersprungen:\n\n",
+	"Nothing selected.":                                                       "Nichts ausgewählt.",
+	"\nThe send did not finish — run jip send --resume to continue where it stopped.": "\nDas Senden wurde nicht abgeschlossen — jip send --resume setzt es dort fort, wo es aufgehört hat.",
+
+	// status
+	"No changes.":                          "Keine Änderungen.",
+	"no PR":                                "kein PR",
+	"needs attention: %s":                  "braucht Aufmerksamkeit: %s",
+	"local change not sent — run jip send": "lokale Änderung nicht gesendet — jip send ausführen",
+}
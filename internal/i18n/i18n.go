@@ -0,0 +1,87 @@
+// Package i18n translates jip's user-facing messages. The English text of a
+// message is its key: T looks it up in the catalog of the current language
+// and falls back to it, so untranslated messages stay in English. Errors
+// are not translated, so that they can be searched for.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// English is the language of the messages as written.
+const English = "en"
+
+// catalogs holds the translations of each language but English, keyed by
+// the English text.
+var catalogs = map[string]map[string]string{
+	"de": german,
+}
+
+// current is the language messages are translated to.
+var current = English
+
+// Languages returns the supported languages, sorted.
+func Languages() []string {
+	langs := []string{English}
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+// Detect returns the language to use: configured (the language config
+// key) when set, otherwise the first of $LC_ALL, $LC_MESSAGES and $LANG
+// that is set, as POSIX orders them. A locale such as de_DE.UTF-8 selects
+// its language; English is the fallback for languages without a catalog.
+// An unsupported configured language is an error.
+func Detect(configured string) (string, error) {
+	if configured != "" {
+		lang := language(configured)
+		if lang != English && catalogs[lang] == nil {
+			return "", fmt.Errorf("unsupported language %q (supported: %s)", configured, strings.Join(Languages(), ", "))
+		}
+		return lang, nil
+	}
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			if lang := language(v); catalogs[lang] != nil {
+				return lang, nil
+			}
+			return English, nil
+		}
+	}
+	return English, nil
+}
+
+// language returns the language of a locale name, e.g. "de" for
+// "de_DE.UTF-8" or "de-AT".
+func language(locale string) string {
+	lang, _, _ := strings.Cut(locale, ".")
+	lang, _, _ = strings.Cut(lang, "@")
+	lang, _, _ = strings.Cut(lang, "_")
+	lang, _, _ = strings.Cut(lang, "-")
+	switch lang = strings.ToLower(lang); lang {
+	case "c", "posix", "":
+		return English
+	}
+	return lang
+}
+
+// Set makes T translate to lang, one of Languages.
+func Set(lang string) {
+	current = lang
+}
+
+// T returns msg translated to the current language, or msg itself when the
+// language has no translation of it. msg may be a format: the translation
+// takes the same arguments.
+func T(msg string) string {
+	if t, ok := catalogs[current][msg]; ok {
+		return t
+	}
+	return msg
+}
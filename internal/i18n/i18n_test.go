@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		env        map[string]string
+		want       string
+	}{
+		{"nothing set", "", nil, English},
+		{"LANG", "", map[string]string{"LANG": "de_DE.UTF-8"}, "de"},
+		{"LC_ALL wins", "", map[string]string{"LC_ALL": "en_US.UTF-8", "LANG": "de_DE.UTF-8"}, English},
+		{"LC_MESSAGES before LANG", "", map[string]string{"LC_MESSAGES": "de_AT", "LANG": "C"}, "de"},
+		{"unknown locale", "", map[string]string{"LANG": "fr_FR.UTF-8"}, English},
+		{"POSIX", "", map[string]string{"LANG": "POSIX"}, English},
+		{"config wins", "de", map[string]string{"LANG": "en_US.UTF-8"}, "de"},
+		{"config locale", "de-CH", nil, "de"},
+		{"config English", "en", map[string]string{"LANG": "de_DE.UTF-8"}, English},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+				t.Setenv(key, tt.env[key])
+			}
+			got, err := Detect(tt.configured)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Detect(%q) = %q, want %q", tt.configured, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Detect("fr"); err == nil {
+		t.Error("Detect(\"fr\") = nil error, want unsupported language")
+	}
+}
+
+func TestT(t *testing.T) {
+	t.Cleanup(func() { Set(English) })
+
+	if got := T("No changes."); got != "No changes." {
+		t.Errorf("English T = %q", got)
+	}
+	Set("de")
+	if got, want := T("No changes."), "Keine Änderungen."; got != want {
+		t.Errorf("German T = %q, want %q", got, want)
+	}
+	if got, want := T("not in the catalog"), "not in the catalog"; got != want {
+		t.Errorf("untranslated T = %q, want %q", got, want)
+	}
+}
+
+// Translations take the same arguments as the messages they translate.
+func TestCatalogVerbs(t *testing.T) {
+	verb := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+	for lang, catalog := range catalogs {
+		for msg, translation := range catalog {
+			if want, got := verb.FindAllString(msg, -1), verb.FindAllString(translation, -1); !slices.Equal(got, want) {
+				t.Errorf("%s translation of %q has verbs %q, want %q", lang, msg, got, want)
+			}
+		}
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	if got, want := Languages(), []string{"de", English}; !slices.Equal(got, want) {
+		t.Errorf("Languages() = %q, want %q", got, want)
+	}
+}
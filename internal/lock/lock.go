@@ -0,0 +1,119 @@
+// Package lock keeps two jip processes from modifying the same repository at
+// once, which would interleave their bookmark updates and pushes.
+//
+// The lock is a file at .jj/jip/lock, created exclusively and naming the
+// process that holds it. A lock left behind by a process that is no longer
+// running on this host is stale and taken over.
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Lock is a held repository lock.
+type Lock struct {
+	path string
+}
+
+// Holder describes the process holding a lock.
+type Holder struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Command string    `json:"command"`
+	Started time.Time `json:"started"`
+}
+
+// LockedError is returned by Acquire when another live process holds the lock.
+type LockedError struct {
+	Path   string
+	Holder Holder
+}
+
+func (e *LockedError) Error() string {
+	h := e.Holder
+	return fmt.Sprintf("another jip %s (pid %d on %s, started %s) is working on this repository",
+		h.Command, h.PID, h.Host, h.Started.Local().Format(time.DateTime))
+}
+
+// unreadableGrace is how long a lock file without valid contents is left
+// alone: its holder may have created it and not yet written to it.
+const unreadableGrace = 10 * time.Second
+
+// Path returns the lock file location for the jj workspace at repoRoot.
+func Path(repoRoot string) string {
+	return filepath.Join(repoRoot, ".jj", "jip", "lock")
+}
+
+// Acquire takes the lock at path for command (e.g. "send"). It fails with a
+// *LockedError while another live process holds it, and takes over a stale
+// lock.
+func Acquire(path, command string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating lock dir: %w", err)
+	}
+	host, _ := os.Hostname()
+	self := Holder{PID: os.Getpid(), Host: host, Command: command, Started: time.Now()}
+	data, err := json.Marshal(self)
+	if err != nil {
+		return nil, err
+	}
+	// One takeover attempt: if the stale lock is replaced by another process
+	// in between, that process wins.
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, werr := f.Write(data)
+			if cerr := f.Close(); werr == nil {
+				werr = cerr
+			}
+			if werr != nil {
+				_ = os.Remove(path)
+				return nil, fmt.Errorf("writing lock %s: %w", path, werr)
+			}
+			return &Lock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("creating lock %s: %w", path, err)
+		}
+		holder, stale := inspect(path, host)
+		if !stale || attempt > 0 {
+			return nil, &LockedError{Path: path, Holder: holder}
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("removing stale lock %s: %w", path, err)
+		}
+	}
+}
+
+// Release removes the lock.
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing lock %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// inspect reads the holder of the lock at path and reports whether the lock
+// is stale: its process no longer runs on this host, or the file has had no
+// valid contents for longer than unreadableGrace. Locks held from another
+// host (a shared file system) are never considered stale.
+func inspect(path, host string) (Holder, bool) {
+	var h Holder
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &h)
+	}
+	if err != nil || h.PID <= 0 {
+		info, statErr := os.Stat(path)
+		if statErr != nil {
+			return h, errors.Is(statErr, os.ErrNotExist)
+		}
+		return h, time.Since(info.ModTime()) > unreadableGrace
+	}
+	return h, h.Host == host && !processAlive(h.PID)
+}
@@ -0,0 +1,111 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	path := Path(t.TempDir())
+	l, err := Acquire(path, "send")
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	_, err = Acquire(path, "repair")
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("second Acquire: expected *LockedError, got %v", err)
+	}
+	if locked.Holder.PID != os.Getpid() || locked.Holder.Command != "send" {
+		t.Errorf("holder = %+v, want this process running send", locked.Holder)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	l, err = Acquire(path, "repair")
+	if err != nil {
+		t.Fatalf("Acquire after Release: %v", err)
+	}
+	_ = l.Release()
+}
+
+// writeHolder writes a lock file as if h held it.
+func writeHolder(t *testing.T, path string, h Holder) {
+	t.Helper()
+	data, _ := json.Marshal(h)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// deadPID returns the PID of a process that has exited.
+func deadPID(t *testing.T) int {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(exe, "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("running helper process: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestAcquire_TakesOverStaleLock(t *testing.T) {
+	path := Path(t.TempDir())
+	host, _ := os.Hostname()
+	writeHolder(t, path, Holder{PID: deadPID(t), Host: host, Command: "send", Started: time.Now()})
+
+	l, err := Acquire(path, "send")
+	if err != nil {
+		t.Fatalf("Acquire over a stale lock: %v", err)
+	}
+	_ = l.Release()
+}
+
+func TestAcquire_KeepsLockOfOtherHost(t *testing.T) {
+	path := Path(t.TempDir())
+	writeHolder(t, path, Holder{PID: deadPID(t), Host: "elsewhere.invalid", Command: "send", Started: time.Now()})
+
+	var locked *LockedError
+	if _, err := Acquire(path, "send"); !errors.As(err, &locked) {
+		t.Fatalf("expected *LockedError for another host's lock, got %v", err)
+	}
+}
+
+func TestAcquire_UnreadableLock(t *testing.T) {
+	path := Path(t.TempDir())
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fresh: the holder may still be writing it.
+	var locked *LockedError
+	if _, err := Acquire(path, "send"); !errors.As(err, &locked) {
+		t.Fatalf("expected *LockedError for a fresh empty lock, got %v", err)
+	}
+
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Acquire(path, "send")
+	if err != nil {
+		t.Fatalf("Acquire over an old empty lock: %v", err)
+	}
+	_ = l.Release()
+}
@@ -1,6 +1,8 @@
 package retry
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"math"
 	"math/rand/v2"
@@ -13,6 +15,43 @@ type config struct {
 	initialBackoff time.Duration
 	multiplier     float64
 	maxBackoff     time.Duration
+	maxWait        time.Duration
+	retryAfter     func(error) (time.Duration, bool)
+}
+
+// Policy holds the defaults of every Do call; zero fields keep the built-in
+// default.
+type Policy struct {
+	MaxAttempts    int           // attempts in all (default 3)
+	InitialBackoff time.Duration // before the second attempt (default 1s)
+	MaxBackoff     time.Duration // cap of the growing backoff (default 30s)
+	// MaxWait caps how long a server may ask to be left alone (Retry-After)
+	// before Do gives up instead of waiting (default 60s).
+	MaxWait time.Duration
+}
+
+var defaults = config{
+	maxAttempts:    3,
+	initialBackoff: 1 * time.Second,
+	multiplier:     2.0,
+	maxBackoff:     30 * time.Second,
+	maxWait:        60 * time.Second,
+}
+
+// Configure sets the defaults of the Do calls made afterwards.
+func Configure(p Policy) {
+	if p.MaxAttempts > 0 {
+		defaults.maxAttempts = p.MaxAttempts
+	}
+	if p.InitialBackoff > 0 {
+		defaults.initialBackoff = p.InitialBackoff
+	}
+	if p.MaxBackoff > 0 {
+		defaults.maxBackoff = p.MaxBackoff
+	}
+	if p.MaxWait > 0 {
+		defaults.maxWait = p.MaxWait
+	}
 }
 
 // Option configures retry behavior.
@@ -38,35 +77,85 @@ func WithMaxBackoff(d time.Duration) Option {
 	return func(c *config) { c.maxBackoff = d }
 }
 
+// WithRetryAfter makes Do ask hint how long the server wants it to wait
+// after an error, e.g. from a Retry-After header, and wait that long
+// instead of backing off. A wait longer than the policy's MaxWait ends the
+// retries with the error.
+func WithRetryAfter(hint func(error) (time.Duration, bool)) Option {
+	return func(c *config) { c.retryAfter = hint }
+}
+
 // Do calls fn up to maxAttempts times, sleeping with exponential backoff
 // and jitter between attempts. Returns the last error if all attempts fail.
 func Do(fn func() error, opts ...Option) error {
-	cfg := config{
-		maxAttempts:    3,
-		initialBackoff: 1 * time.Second,
-		multiplier:     2.0,
-		maxBackoff:     30 * time.Second,
-	}
+	return DoContext(context.Background(), func(context.Context) error { return fn() }, opts...)
+}
+
+// DoContext is Do for work that ctx can cancel: fn gets ctx, and a
+// cancellation ends the sleep between attempts at once, returning ctx's
+// error together with the last one of fn.
+func DoContext(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error {
+	cfg := defaults
 	for _, o := range opts {
 		o(&cfg)
 	}
 
 	var err error
 	for attempt := range cfg.maxAttempts {
-		err = fn()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			if err == nil {
+				return ctxErr
+			}
+			return fmt.Errorf("%w: %w", ctxErr, err)
+		}
+		err = fn(ctx)
 		if err == nil {
 			return nil
 		}
-		if attempt < cfg.maxAttempts-1 {
+		if attempt == cfg.maxAttempts-1 {
+			break
+		}
+		var wait time.Duration
+		if hinted, ok := retryAfter(cfg, err); ok {
+			if hinted > cfg.maxWait {
+				slog.Debug("not retrying", "err", err, "retry-after", hinted, "max-wait", cfg.maxWait)
+				return err
+			}
+			wait = hinted
+			slog.Debug("retrying", "attempt", attempt+2, "max", cfg.maxAttempts, "err", err, "retry-after", wait)
+		} else {
 			backoff := float64(cfg.initialBackoff) * math.Pow(cfg.multiplier, float64(attempt))
 			if backoff > float64(cfg.maxBackoff) {
 				backoff = float64(cfg.maxBackoff)
 			}
 			// Add jitter: 50-100% of the computed backoff.
-			jittered := time.Duration(backoff * (0.5 + rand.Float64()*0.5))
-			slog.Debug("retrying", "attempt", attempt+2, "max", cfg.maxAttempts, "err", err, "backoff", jittered)
-			time.Sleep(jittered)
+			wait = time.Duration(backoff * (0.5 + rand.Float64()*0.5))
+			slog.Debug("retrying", "attempt", attempt+2, "max", cfg.maxAttempts, "err", err, "backoff", wait)
+		}
+		if !sleep(ctx, wait) {
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
 		}
 	}
 	return err
 }
+
+// retryAfter asks cfg's hint, if any, how long to wait after err.
+func retryAfter(cfg config, err error) (time.Duration, bool) {
+	if cfg.retryAfter == nil {
+		return 0, false
+	}
+	return cfg.retryAfter(err)
+}
+
+// sleep waits for d and reports whether it did, or returns false as soon as
+// ctx is cancelled.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
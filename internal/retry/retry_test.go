@@ -1,6 +1,7 @@
 package retry
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -95,3 +96,97 @@ func TestDoSingleAttempt(t *testing.T) {
 		t.Fatalf("expected sentinel error, got: %v", err)
 	}
 }
+
+func TestDoWithRetryAfter(t *testing.T) {
+	limited := errors.New("rate limited")
+	hint := func(err error) (time.Duration, bool) {
+		if errors.Is(err, limited) {
+			return 20 * time.Millisecond, true
+		}
+		return 0, false
+	}
+
+	start := time.Now()
+	calls := 0
+	err := Do(func() error {
+		calls++
+		if calls == 1 {
+			return limited
+		}
+		return nil
+	}, WithRetryAfter(hint), WithInitialBackoff(time.Millisecond))
+	if err != nil || calls != 2 {
+		t.Fatalf("err = %v after %d calls", err, calls)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("waited only %v, want the 20ms the hint asked for", elapsed)
+	}
+
+	// A wait longer than MaxWait gives up at once.
+	defer func(old config) { defaults = old }(defaults)
+	Configure(Policy{MaxWait: 10 * time.Millisecond})
+	calls = 0
+	err = Do(func() error {
+		calls++
+		return limited
+	}, WithRetryAfter(hint))
+	if !errors.Is(err, limited) || calls != 1 {
+		t.Errorf("err = %v after %d calls, want the error after one", err, calls)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	defer func(old config) { defaults = old }(defaults)
+	Configure(Policy{MaxAttempts: 5, InitialBackoff: time.Millisecond})
+	calls := 0
+	_ = Do(func() error {
+		calls++
+		return errors.New("fail")
+	})
+	if calls != 5 {
+		t.Errorf("expected 5 calls, got %d", calls)
+	}
+	// Options still win over the configured defaults.
+	calls = 0
+	_ = Do(func() error {
+		calls++
+		return errors.New("fail")
+	}, WithMaxAttempts(2))
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestDoContextStopsSleepingWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fail := errors.New("fail")
+	calls := 0
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	err := DoContext(ctx, func(got context.Context) error {
+		if got != ctx {
+			t.Error("fn did not get the context")
+		}
+		calls++
+		return fail
+	}, WithMaxAttempts(3), WithInitialBackoff(time.Minute), WithMultiplier(1.0))
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("cancellation did not end the sleep (%v)", elapsed)
+	}
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, fail) {
+		t.Errorf("err = %v, want the cancellation and the last error", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+
+	// An already cancelled context runs nothing.
+	calls = 0
+	if err := DoContext(ctx, func(context.Context) error { calls++; return nil }); !errors.Is(err, context.Canceled) || calls != 0 {
+		t.Errorf("err = %v after %d calls", err, calls)
+	}
+}
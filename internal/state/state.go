@@ -0,0 +1,323 @@
+// Package state persists per-repository jip data between runs.
+//
+// The state file lives inside the jj repository at .jj/jip/state.json, so it
+// is never tracked or pushed and disappears together with the repository.
+// Everything in it is a cache or a record of earlier runs: jip must keep
+// working (more slowly, or with less context) when the file is missing.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/omarkohl/jip/internal/encrypt"
+	"github.com/omarkohl/jip/internal/forge"
+)
+
+// SchemaVersion is the version of the state file format written by this
+// build. Bump it for changes that older builds cannot safely read.
+const SchemaVersion = 1
+
+// State is the persisted per-repository state.
+type State struct {
+	// Version is the schema version the file was written with. Files from
+	// before versioning was introduced have no version and read as 0.
+	Version int `json:"version"`
+
+	// Changes records what jip last did for each change, keyed by change ID.
+	Changes map[string]ChangeRecord `json:"changes,omitempty"`
+
+	// PRs caches the result of looking up the open PR for a branch, keyed by
+	// branch name. An entry with a nil PR records that the branch had no
+	// open PR at FetchedAt.
+	PRs map[string]CachedPR `json:"prs,omitempty"`
+
+	// LastSend records what the most recent send changed on the remote, so
+	// jip undo can reverse it. Undo clears it.
+	LastSend *SendRecord `json:"last_send,omitempty"`
+
+	// Progress records how far the last send got while it runs, so that
+	// jip send --resume can continue it after a failure. A send that
+	// completes clears it.
+	Progress *SendProgress `json:"progress,omitempty"`
+
+	path string
+}
+
+// SendRecord is what a send changed: the bookmarks it pushed and the PRs it
+// opened.
+type SendRecord struct {
+	Remote     string         `json:"remote"`
+	Upstream   string         `json:"upstream,omitempty"` // --upstream as given; "" when PRs are opened in Remote's repository
+	At         time.Time      `json:"at"`
+	Bookmarks  []BookmarkMove `json:"bookmarks"`
+	CreatedPRs []int          `json:"created_prs,omitempty"`
+}
+
+// BookmarkMove records where a send moved a bookmark from and to. Empty
+// previous targets mean the bookmark did not exist there before.
+type BookmarkMove struct {
+	Name           string `json:"name"`
+	PreviousLocal  string `json:"previous_local,omitempty"`
+	PreviousRemote string `json:"previous_remote,omitempty"`
+	Pushed         string `json:"pushed"`
+}
+
+// SendProgress is how far a send got: the bookmarks it pushed and what it
+// did with their PRs.
+type SendProgress struct {
+	Remote    string                  `json:"remote"`
+	Revsets   []string                `json:"revsets"`
+	Bases     map[string][]string     `json:"bases,omitempty"` // revsets sent to other bases than the default, by branch
+	At        time.Time               `json:"at"`
+	Bookmarks map[string]SentBookmark `json:"bookmarks,omitempty"` // by bookmark name
+}
+
+// SentBookmark is the progress of one change of a send.
+type SentBookmark struct {
+	ChangeID string        `json:"change_id"`
+	Pushed   string        `json:"pushed"`       // commit pushed to the bookmark
+	PR       *forge.PRInfo `json:"pr,omitempty"` // the PR as the send last saw or wrote it; nil while there is none
+	Done     bool          `json:"done,omitempty"`
+	Created  bool          `json:"created,omitempty"` // Done by opening the PR
+	Changed  bool          `json:"changed,omitempty"` // Done by editing or commenting on the existing PR
+}
+
+// CachedPR is a cached branch → PR lookup result.
+type CachedPR struct {
+	PR        *forge.PRInfo `json:"pr"`
+	FetchedAt time.Time     `json:"fetched_at"`
+}
+
+// ChangeRecord is what jip remembers about a change it has sent.
+type ChangeRecord struct {
+	Bookmark     string    `json:"bookmark"`
+	PRNumber     int       `json:"pr_number,omitempty"`
+	PushedCommit string    `json:"pushed_commit,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Stack is the change's dependency chain at the last send, the change
+	// IDs bottom first, including the change itself. Records from before
+	// it was introduced have none.
+	Stack []string `json:"stack,omitempty"`
+
+	// Interdiff is the last "changes since" comment posted on the PR.
+	Interdiff *InterdiffPair `json:"interdiff,omitempty"`
+
+	// OpenedAt is when jip opened PR PRNumber; zero when the PR was opened
+	// some other way, or before jip recorded it.
+	OpenedAt time.Time `json:"opened_at,omitzero"`
+}
+
+// InterdiffPair identifies an interdiff comment by the two commits compared.
+type InterdiffPair struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Path returns the state file location for the jj workspace at repoRoot.
+func Path(repoRoot string) string {
+	return filepath.Join(repoRoot, ".jj", "jip", "state.json")
+}
+
+// Load reads the state file at path. A missing file yields an empty state
+// that will be written to path on Save. A file written by a newer jip (higher
+// schema version) is rejected rather than risk losing data it understood.
+func Load(path string) (*State, error) {
+	s := &State{Version: SchemaVersion, path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("reading state %s: %w", path, err)
+	}
+	if data, err = encrypt.Open(data); err != nil {
+		return nil, fmt.Errorf("reading state %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parsing state %s: %w", path, err)
+	}
+	if s.Version > SchemaVersion {
+		return nil, fmt.Errorf("state %s has schema version %d, this jip supports up to %d — upgrade jip",
+			path, s.Version, SchemaVersion)
+	}
+	// Version 0 files predate versioning; their fields are a subset of v1.
+	s.Version = SchemaVersion
+	return s, nil
+}
+
+// Save writes the state back to the file it was loaded from. The file is
+// replaced atomically (write to a temporary file, then rename), so a crash
+// or a concurrent jip never leaves a half-written state behind.
+func (s *State) Save() error {
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating state dir: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+	if data, err = encrypt.Seal(data); err != nil {
+		return fmt.Errorf("writing state %s: %w", s.path, err)
+	}
+	tmp, err := os.CreateTemp(dir, "state-*.json.tmp")
+	if err != nil {
+		return fmt.Errorf("writing state %s: %w", s.path, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // no-op after a successful rename
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing state %s: %w", s.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing state %s: %w", s.path, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("writing state %s: %w", s.path, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("writing state %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Change returns the record for changeID, if jip has sent it before.
+func (s *State) Change(changeID string) (ChangeRecord, bool) {
+	rec, ok := s.Changes[changeID]
+	return rec, ok
+}
+
+// RecordSend records that changeID was pushed as commit on bookmark and is
+// tracked by PR prNumber. The last interdiff is kept.
+func (s *State) RecordSend(changeID, bookmark string, prNumber int, commit string, now time.Time) {
+	if s.Changes == nil {
+		s.Changes = make(map[string]ChangeRecord)
+	}
+	rec := s.Changes[changeID]
+	if rec.PRNumber != prNumber {
+		rec.OpenedAt = time.Time{}
+	}
+	rec.Bookmark = bookmark
+	rec.PRNumber = prNumber
+	rec.PushedCommit = commit
+	rec.UpdatedAt = now
+	s.Changes[changeID] = rec
+}
+
+// RecordOpened records that jip opened the PR of changeID, as recorded by
+// RecordSend, at now.
+func (s *State) RecordOpened(changeID string, now time.Time) {
+	if s.Changes == nil {
+		s.Changes = make(map[string]ChangeRecord)
+	}
+	rec := s.Changes[changeID]
+	rec.OpenedAt = now
+	s.Changes[changeID] = rec
+}
+
+// RecordStack records the dependency chain changeID was sent in, bottom
+// first.
+func (s *State) RecordStack(changeID string, stack []string) {
+	if s.Changes == nil {
+		s.Changes = make(map[string]ChangeRecord)
+	}
+	rec := s.Changes[changeID]
+	rec.Stack = stack
+	s.Changes[changeID] = rec
+}
+
+// RecordInterdiff records the commits compared by the interdiff comment just
+// posted for changeID.
+func (s *State) RecordInterdiff(changeID, from, to string, now time.Time) {
+	if s.Changes == nil {
+		s.Changes = make(map[string]ChangeRecord)
+	}
+	rec := s.Changes[changeID]
+	rec.Interdiff = &InterdiffPair{From: from, To: to}
+	rec.UpdatedAt = now
+	s.Changes[changeID] = rec
+}
+
+// CachedPRs answers a PR lookup for branches from the cache. It reports ok
+// only when every branch has an entry no older than maxAge (maxAge <= 0
+// accepts entries of any age). The returned map has the same shape as
+// LookupPRsByBranch: branches without an open PR are absent. The PRInfo
+// values are copies, so callers may modify them freely.
+func (s *State) CachedPRs(branches []string, maxAge time.Duration, now time.Time) (map[string]*forge.PRInfo, bool) {
+	out := make(map[string]*forge.PRInfo, len(branches))
+	for _, b := range branches {
+		e, ok := s.PRs[b]
+		if !ok {
+			return nil, false
+		}
+		if maxAge > 0 && now.Sub(e.FetchedAt) > maxAge {
+			return nil, false
+		}
+		if e.PR != nil {
+			pr := *e.PR
+			out[b] = &pr
+		}
+	}
+	return out, true
+}
+
+// RecordPRs stores a lookup result for branches. Branches absent from prs
+// are recorded as having no open PR.
+func (s *State) RecordPRs(branches []string, prs map[string]*forge.PRInfo, now time.Time) {
+	if s.PRs == nil {
+		s.PRs = make(map[string]CachedPR)
+	}
+	for _, b := range branches {
+		var pr *forge.PRInfo
+		if p := prs[b]; p != nil {
+			cp := *p
+			pr = &cp
+		}
+		s.PRs[b] = CachedPR{PR: pr, FetchedAt: now}
+	}
+}
+
+// OldestPR returns the fetch time of the oldest cached entry among branches,
+// or the zero time when none of them is cached.
+func (s *State) OldestPR(branches []string) time.Time {
+	var oldest time.Time
+	for _, b := range branches {
+		if e, ok := s.PRs[b]; ok && (oldest.IsZero() || e.FetchedAt.Before(oldest)) {
+			oldest = e.FetchedAt
+		}
+	}
+	return oldest
+}
+
+// StartSend records the start of a send to remote of revsets, replacing the
+// progress of an earlier one.
+func (s *State) StartSend(remote string, revsets []string, now time.Time) {
+	s.Progress = &SendProgress{Remote: remote, Revsets: revsets, At: now}
+}
+
+// RecordProgress records the progress of the change on bookmark in the
+// running send. The PR is copied.
+func (s *State) RecordProgress(bookmark string, b SentBookmark) {
+	if s.Progress == nil {
+		return
+	}
+	if s.Progress.Bookmarks == nil {
+		s.Progress.Bookmarks = make(map[string]SentBookmark)
+	}
+	if b.PR != nil {
+		pr := *b.PR
+		b.PR = &pr
+	}
+	s.Progress.Bookmarks[bookmark] = b
+}
+
+// FinishSend records that the running send completed: there is nothing left
+// to resume.
+func (s *State) FinishSend() {
+	s.Progress = nil
+}
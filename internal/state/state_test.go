@@ -0,0 +1,257 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/omarkohl/jip/internal/encrypt"
+	"github.com/omarkohl/jip/internal/forge"
+	"github.com/zalando/go-keyring"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(s.PRs) != 0 {
+		t.Errorf("expected empty state, got %d PRs", len(s.PRs))
+	}
+}
+
+func TestLoadInvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{nope"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected error for invalid JSON")
+	}
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	path := Path(t.TempDir())
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.RecordPRs([]string{"jip/a/1", "jip/b/2"}, map[string]*forge.PRInfo{
+		"jip/a/1": {Number: 7, Title: "feat: a"},
+	}, now)
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save (must create .jj/jip): %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	prs, ok := loaded.CachedPRs([]string{"jip/a/1", "jip/b/2"}, 0, now)
+	if !ok {
+		t.Fatal("expected cache hit after round trip")
+	}
+	if prs["jip/a/1"] == nil || prs["jip/a/1"].Number != 7 {
+		t.Errorf("jip/a/1 = %+v, want PR #7", prs["jip/a/1"])
+	}
+	if _, has := prs["jip/b/2"]; has {
+		t.Error("jip/b/2 was recorded without a PR and must stay absent")
+	}
+}
+
+func TestSaveEncrypted(t *testing.T) {
+	keyring.MockInit()
+	if err := encrypt.Configure(encrypt.Settings{Mode: encrypt.ModeKeyring}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = encrypt.Configure(encrypt.Settings{}) }()
+
+	path := Path(t.TempDir())
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	s.RecordSend("abc", "jip/a/1", 7, "c0ffee", time.Now())
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !encrypt.IsEncrypted(data) {
+		t.Fatalf("state written in plain text:\n%s", data)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if rec, ok := loaded.Change("abc"); !ok || rec.PRNumber != 7 {
+		t.Errorf("change record = %+v, %v", rec, ok)
+	}
+}
+
+func TestCachedPRs_Freshness(t *testing.T) {
+	s := &State{}
+	fetched := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.RecordPRs([]string{"b"}, map[string]*forge.PRInfo{"b": {Number: 1}}, fetched)
+
+	if _, ok := s.CachedPRs([]string{"b"}, 5*time.Minute, fetched.Add(time.Minute)); !ok {
+		t.Error("expected fresh entry to hit")
+	}
+	if _, ok := s.CachedPRs([]string{"b"}, 5*time.Minute, fetched.Add(time.Hour)); ok {
+		t.Error("expected stale entry to miss")
+	}
+	if _, ok := s.CachedPRs([]string{"b"}, 0, fetched.Add(24*time.Hour)); !ok {
+		t.Error("maxAge 0 must accept entries of any age")
+	}
+}
+
+func TestCachedPRs_MissingBranchMisses(t *testing.T) {
+	s := &State{}
+	now := time.Now()
+	s.RecordPRs([]string{"a"}, nil, now)
+	if _, ok := s.CachedPRs([]string{"a", "b"}, 0, now); ok {
+		t.Error("a lookup including an uncached branch must miss")
+	}
+}
+
+func TestCachedPRs_ReturnsCopies(t *testing.T) {
+	s := &State{}
+	now := time.Now()
+	s.RecordPRs([]string{"a"}, map[string]*forge.PRInfo{"a": {Number: 1, Title: "old"}}, now)
+	prs, _ := s.CachedPRs([]string{"a"}, 0, now)
+	prs["a"].Title = "changed"
+	again, _ := s.CachedPRs([]string{"a"}, 0, now)
+	if again["a"].Title != "old" {
+		t.Errorf("cache was mutated through returned value: %q", again["a"].Title)
+	}
+}
+
+func TestLoadRejectsNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected error for a newer schema version")
+	}
+}
+
+func TestLoadUnversionedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte(`{"prs": {}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if s.Version != SchemaVersion {
+		t.Errorf("Version = %d, want %d", s.Version, SchemaVersion)
+	}
+}
+
+func TestSaveLeavesNoTempFiles(t *testing.T) {
+	path := Path(t.TempDir())
+	s, _ := Load(path)
+	s.RecordSend("abc", "jip/x/abc", 3, "c1", time.Now())
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("second Save: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "state.json" {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("state dir contains %v, want only state.json", names)
+	}
+}
+
+func TestChangeRecords(t *testing.T) {
+	path := Path(t.TempDir())
+	s, _ := Load(path)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.RecordInterdiff("abc", "c0", "c1", now)
+	s.RecordSend("abc", "jip/x/abc", 3, "c1", now)
+	s.RecordStack("abc", []string{"xyz", "abc"})
+	s.RecordOpened("abc", now)
+	s.RecordSend("xyz", "jip/x/xyz", 2, "c2", now)
+	s.RecordOpened("xyz", now)
+	s.RecordSend("xyz", "jip/x/xyz", 5, "c3", now)
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	rec, ok := loaded.Change("abc")
+	if !ok {
+		t.Fatal("expected record for abc")
+	}
+	if rec.Bookmark != "jip/x/abc" || rec.PRNumber != 3 || rec.PushedCommit != "c1" {
+		t.Errorf("record = %+v", rec)
+	}
+	if rec.Interdiff == nil || *rec.Interdiff != (InterdiffPair{From: "c0", To: "c1"}) {
+		t.Errorf("RecordSend must keep the interdiff, got %+v", rec.Interdiff)
+	}
+	if !reflect.DeepEqual(rec.Stack, []string{"xyz", "abc"}) {
+		t.Errorf("stack = %v", rec.Stack)
+	}
+	if !rec.OpenedAt.Equal(now) {
+		t.Errorf("opened at = %v, want %v", rec.OpenedAt, now)
+	}
+	if rec, _ := loaded.Change("xyz"); !rec.OpenedAt.IsZero() {
+		t.Errorf("a new PR jip did not open must clear the opening time, got %v", rec.OpenedAt)
+	}
+	if _, ok := loaded.Change("other"); ok {
+		t.Error("unexpected record for unknown change")
+	}
+}
+
+func TestSendProgress(t *testing.T) {
+	path := Path(t.TempDir())
+	s, _ := Load(path)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.RecordProgress("jip/x/abc", SentBookmark{ChangeID: "abc"})
+	if s.Progress != nil {
+		t.Fatal("RecordProgress outside a send must not start one")
+	}
+	s.StartSend("origin", []string{"@-"}, now)
+	pr := &forge.PRInfo{Number: 3, Title: "feat: x"}
+	s.RecordProgress("jip/x/abc", SentBookmark{ChangeID: "abc", Pushed: "c1", PR: pr, Done: true, Created: true})
+	pr.Title = "changed later"
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	p := loaded.Progress
+	if p == nil || p.Remote != "origin" || len(p.Revsets) != 1 || !p.At.Equal(now) {
+		t.Fatalf("progress = %+v", p)
+	}
+	b := p.Bookmarks["jip/x/abc"]
+	if b.ChangeID != "abc" || b.Pushed != "c1" || !b.Done || !b.Created || b.PR == nil || b.PR.Title != "feat: x" {
+		t.Errorf("bookmark progress = %+v", b)
+	}
+
+	loaded.FinishSend()
+	if loaded.Progress != nil {
+		t.Error("FinishSend must clear the progress")
+	}
+}
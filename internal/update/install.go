@@ -0,0 +1,192 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// AssetName returns the name of the archive goreleaser publishes for a
+// platform: jip_<version>_<os>_<arch>.tar.gz, or .zip on Windows.
+func AssetName(version, goos, goarch string) string {
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("jip_%s_%s_%s%s", version, goos, goarch, ext)
+}
+
+// checksumsName returns the name of the release's SHA-256 checksums file.
+func checksumsName(version string) string {
+	return fmt.Sprintf("jip_%s_checksums.txt", version)
+}
+
+// Install downloads the release archive for the running platform, verifies
+// it against the release's checksums file and replaces the executable at
+// exe with the jip binary inside it. Releases are not signed: the checksums
+// file comes from the same release as the archive, so it proves the
+// download intact (integrity), not that the release is genuine
+// (authenticity).
+func (c *Checker) Install(ctx context.Context, rel *Release, exe string) error {
+	name := AssetName(rel.Version, runtime.GOOS, runtime.GOARCH)
+	archiveURL, ok := rel.assets[name]
+	if !ok {
+		return fmt.Errorf("release %s has no build for %s/%s (expected %s)", rel.Version, runtime.GOOS, runtime.GOARCH, name)
+	}
+	sumsURL, ok := rel.assets[checksumsName(rel.Version)]
+	if !ok {
+		return fmt.Errorf("release %s has no checksums file — refusing to install an unverified binary", rel.Version)
+	}
+
+	sums, err := c.download(ctx, sumsURL)
+	if err != nil {
+		return err
+	}
+	want, err := findChecksum(sums, name)
+	if err != nil {
+		return err
+	}
+	archive, err := c.download(ctx, archiveURL)
+	if err != nil {
+		return err
+	}
+	if got := sha256.Sum256(archive); hex.EncodeToString(got[:]) != want {
+		return fmt.Errorf("checksum mismatch for %s: got %x, want %s", name, got, want)
+	}
+	slog.Debug("checksum ok", "asset", name)
+
+	binary, err := extractBinary(archive, runtime.GOOS)
+	if err != nil {
+		return fmt.Errorf("extracting %s: %w", name, err)
+	}
+	return replaceExecutable(exe, binary)
+}
+
+func (c *Checker) download(ctx context.Context, url string) ([]byte, error) {
+	slog.Debug("download", "url", url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// findChecksum returns the hex SHA-256 listed for name in a sha256sum-style
+// checksums file ("<hex>  <name>" per line).
+func findChecksum(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("checksums file does not list %s", name)
+}
+
+// extractBinary returns the jip executable from a release archive.
+func extractBinary(archive []byte, goos string) ([]byte, error) {
+	if goos == "windows" {
+		return extractZip(archive, "jip.exe")
+	}
+	return extractTarGz(archive, "jip")
+}
+
+func extractTarGz(archive []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("archive does not contain %s", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == name {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+func extractZip(archive []byte, name string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if path.Base(f.Name) != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = rc.Close() }()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("archive does not contain %s", name)
+}
+
+// replaceExecutable writes binary next to exe and renames it over exe, so
+// the executable is never left half-written. Windows cannot overwrite a
+// running executable but can rename it, so the old one is moved aside first
+// and left as exe.old.
+func replaceExecutable(exe string, binary []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".jip-upgrade-*")
+	if err != nil {
+		return fmt.Errorf("writing new executable (is %s writable?): %w", filepath.Dir(exe), err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(binary); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing new executable: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing new executable: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return fmt.Errorf("writing new executable: %w", err)
+	}
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return fmt.Errorf("moving old executable aside: %w", err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("replacing %s: %w", exe, err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// NoticeInterval is how often Notice asks GitHub for the latest release.
+const NoticeInterval = 7 * 24 * time.Hour
+
+// noticeState is what Notice remembers between runs.
+type noticeState struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+}
+
+// NoticePath returns the file Notice keeps its state in, under the user's
+// cache directory.
+func NoticePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "jip", "update-check.json"), nil
+}
+
+// Notice returns a one-line note when a stable release newer than current
+// exists, or "". It asks GitHub at most once per NoticeInterval and answers
+// from statePath in between, so most runs make no request. token is only
+// called when GitHub is asked, and its token, if any, authenticates the
+// request; resolving one may ask the keyring, which most runs need not.
+// Failures are only logged: the notice must never get in the way of the
+// command.
+func (c *Checker) Notice(ctx context.Context, current, statePath string, now time.Time, token func() string) string {
+	var st noticeState
+	if data, err := os.ReadFile(statePath); err == nil {
+		if err := json.Unmarshal(data, &st); err != nil {
+			slog.Debug("ignoring unreadable update state", "path", statePath, "err", err)
+		}
+	}
+	if now.Sub(st.CheckedAt) >= NoticeInterval {
+		// Record the attempt even when it fails, so an offline machine
+		// does not retry on every run.
+		st.CheckedAt = now
+		if t := token(); t != "" {
+			c.gh = c.gh.WithAuthToken(t)
+		}
+		if rel, err := c.Latest(ctx, ChannelStable); err != nil {
+			slog.Debug("update check failed", "err", err)
+		} else {
+			st.Latest = rel.Version
+		}
+		if err := saveNoticeState(statePath, st); err != nil {
+			slog.Debug("saving update state", "path", statePath, "err", err)
+		}
+	}
+	if st.Latest == "" || Compare(st.Latest, current) <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("jip %s is available (you have %s) — run 'jip upgrade' to install it", st.Latest, current)
+}
+
+func saveNoticeState(path string, st noticeState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,184 @@
+// Package update finds newer jip releases on GitHub and installs them over
+// the running executable.
+package update
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	gogithub "github.com/google/go-github/v68/github"
+
+	gh "github.com/omarkohl/jip/internal/github"
+)
+
+// The repository jip releases are published in.
+const (
+	owner = "omarkohl"
+	repo  = "jip"
+)
+
+// Release channels.
+const (
+	ChannelStable     = "stable"     // published releases only
+	ChannelPrerelease = "prerelease" // also release candidates and betas
+)
+
+// Release is a published jip release.
+type Release struct {
+	Version    string // without the leading "v"
+	URL        string // release page
+	Prerelease bool
+	assets     map[string]string // asset name → download URL
+}
+
+// Checker looks up and downloads jip releases.
+type Checker struct {
+	gh   *gogithub.Client
+	http *http.Client
+}
+
+// NewChecker creates a Checker. token may be empty (release data is public,
+// but unauthenticated requests have a low rate limit). If apiURL is non-empty
+// it is used as the GitHub API base URL (for testing). timeout bounds every
+// request; zero means no limit.
+func NewChecker(token, apiURL string, timeout time.Duration) *Checker {
+	httpClient := &http.Client{Timeout: timeout, Transport: gh.NewLoggingTransport(nil)}
+	client := gogithub.NewClient(httpClient)
+	if token != "" {
+		client = client.WithAuthToken(token)
+	}
+	if apiURL != "" {
+		client, _ = client.WithEnterpriseURLs(apiURL, apiURL)
+	}
+	return &Checker{gh: client, http: httpClient}
+}
+
+// Latest returns the newest release on the given channel.
+func (c *Checker) Latest(ctx context.Context, channel string) (*Release, error) {
+	switch channel {
+	case ChannelStable:
+		r, _, err := c.gh.Repositories.GetLatestRelease(ctx, owner, repo)
+		if err != nil {
+			return nil, fmt.Errorf("looking up the latest release: %w", err)
+		}
+		return newRelease(r), nil
+	case ChannelPrerelease:
+		rs, _, err := c.gh.Repositories.ListReleases(ctx, owner, repo, &gogithub.ListOptions{PerPage: 30})
+		if err != nil {
+			return nil, fmt.Errorf("listing releases: %w", err)
+		}
+		var latest *Release
+		for _, r := range rs {
+			if r.GetDraft() {
+				continue
+			}
+			if rel := newRelease(r); latest == nil || Compare(rel.Version, latest.Version) > 0 {
+				latest = rel
+			}
+		}
+		if latest == nil {
+			return nil, fmt.Errorf("no releases of %s/%s found", owner, repo)
+		}
+		return latest, nil
+	}
+	return nil, fmt.Errorf("invalid channel %q (valid: %s, %s)", channel, ChannelStable, ChannelPrerelease)
+}
+
+func newRelease(r *gogithub.RepositoryRelease) *Release {
+	rel := &Release{
+		Version:    strings.TrimPrefix(r.GetTagName(), "v"),
+		URL:        r.GetHTMLURL(),
+		Prerelease: r.GetPrerelease(),
+		assets:     make(map[string]string, len(r.Assets)),
+	}
+	for _, a := range r.Assets {
+		rel.assets[a.GetName()] = a.GetBrowserDownloadURL()
+	}
+	slog.Debug("release", "version", rel.Version, "prerelease", rel.Prerelease, "assets", len(rel.assets))
+	return rel
+}
+
+// Compare compares two versions of the form 1.2.3 or 1.2.3-rc.1 (a leading
+// "v" is ignored) and returns -1, 0 or +1. A pre-release sorts before its
+// release, as in semantic versioning. Versions that do not parse sort before
+// all others, so a development build is always considered outdated.
+func Compare(a, b string) int {
+	va, oka := parse(a)
+	vb, okb := parse(b)
+	switch {
+	case !oka && !okb:
+		return 0
+	case !oka:
+		return -1
+	case !okb:
+		return 1
+	}
+	for i := range va.core {
+		if va.core[i] != vb.core[i] {
+			return cmp.Compare(va.core[i], vb.core[i])
+		}
+	}
+	switch {
+	case va.pre == "" && vb.pre == "":
+		return 0
+	case va.pre == "":
+		return 1
+	case vb.pre == "":
+		return -1
+	}
+	return comparePre(strings.Split(va.pre, "."), strings.Split(vb.pre, "."))
+}
+
+type version struct {
+	core [3]int
+	pre  string
+}
+
+func parse(s string) (version, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+") // build metadata does not affect precedence
+	core, pre, _ := strings.Cut(s, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return version{}, false
+	}
+	var v version
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return version{}, false
+		}
+		v.core[i] = n
+	}
+	v.pre = pre
+	return v, true
+}
+
+// comparePre compares dot-separated pre-release identifiers: numeric ones
+// numerically and below alphanumeric ones, others lexically, and a shorter
+// list first when all shared identifiers are equal.
+func comparePre(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		na, errA := strconv.Atoi(a[i])
+		nb, errB := strconv.Atoi(b[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return cmp.Compare(na, nb)
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		case a[i] != b[i]:
+			return strings.Compare(a[i], b[i])
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
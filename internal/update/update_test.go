@@ -0,0 +1,323 @@
+package update
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.9", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-1", "1.0.0-alpha", -1},
+		{"1.0.0-rc", "1.0.0-rc.1", -1},
+		{"1.0.0+build.5", "1.0.0", 0},
+		{"dev", "0.1.0", -1},
+		{"0.1.0", "dev", 1},
+		{"dev", "dev", 0},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAssetName(t *testing.T) {
+	if got := AssetName("1.2.0", "linux", "amd64"); got != "jip_1.2.0_linux_amd64.tar.gz" {
+		t.Errorf("linux: got %q", got)
+	}
+	if got := AssetName("1.2.0", "windows", "arm64"); got != "jip_1.2.0_windows_arm64.zip" {
+		t.Errorf("windows: got %q", got)
+	}
+}
+
+// releaseServer serves the GitHub releases API and the release assets.
+type releaseServer struct {
+	*httptest.Server
+	releases    []map[string]any
+	assets      map[string][]byte
+	latestCalls int
+	auth        string
+}
+
+func newReleaseServer(t *testing.T) *releaseServer {
+	t.Helper()
+	rs := &releaseServer{assets: make(map[string][]byte)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/omarkohl/jip/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		rs.latestCalls++
+		rs.auth = r.Header.Get("Authorization")
+		for _, rel := range rs.releases {
+			if rel["prerelease"] != true {
+				_ = json.NewEncoder(w).Encode(rel)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /api/v3/repos/omarkohl/jip/releases", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(rs.releases)
+	})
+	mux.HandleFunc("GET /download/{name}", func(w http.ResponseWriter, r *http.Request) {
+		data, ok := rs.assets[r.PathValue("name")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	})
+	rs.Server = httptest.NewServer(mux)
+	t.Cleanup(rs.Close)
+	return rs
+}
+
+// addRelease publishes version with the given assets; a nil content lists
+// the asset without serving it.
+func (rs *releaseServer) addRelease(version string, prerelease bool, assets map[string][]byte) {
+	var list []map[string]any
+	for name, data := range assets {
+		list = append(list, map[string]any{
+			"name":                 name,
+			"browser_download_url": rs.URL + "/download/" + name,
+		})
+		if data != nil {
+			rs.assets[name] = data
+		}
+	}
+	rs.releases = append(rs.releases, map[string]any{
+		"tag_name":   "v" + version,
+		"html_url":   "https://github.com/omarkohl/jip/releases/tag/v" + version,
+		"prerelease": prerelease,
+		"assets":     list,
+	})
+}
+
+func (rs *releaseServer) checker() *Checker {
+	return NewChecker("", rs.URL+"/", time.Second)
+}
+
+func noToken() string { return "" }
+
+func TestLatest(t *testing.T) {
+	rs := newReleaseServer(t)
+	rs.addRelease("0.4.0-rc.1", true, nil)
+	rs.addRelease("0.3.1", false, nil)
+	rs.addRelease("0.3.0", false, nil)
+
+	stable, err := rs.checker().Latest(context.Background(), ChannelStable)
+	if err != nil {
+		t.Fatalf("Latest(stable): %v", err)
+	}
+	if stable.Version != "0.3.1" || stable.Prerelease {
+		t.Errorf("stable = %+v, want 0.3.1", stable)
+	}
+
+	pre, err := rs.checker().Latest(context.Background(), ChannelPrerelease)
+	if err != nil {
+		t.Fatalf("Latest(prerelease): %v", err)
+	}
+	if pre.Version != "0.4.0-rc.1" || !pre.Prerelease {
+		t.Errorf("prerelease = %+v, want 0.4.0-rc.1", pre)
+	}
+
+	if _, err := rs.checker().Latest(context.Background(), "nightly"); err == nil {
+		t.Error("expected error for unknown channel")
+	}
+}
+
+// tarGz builds a release archive holding a jip binary with the given content.
+func tarGz(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range map[string]string{"README.md": "readme", "jip": content} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestInstall(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("archive fixture is a tarball")
+	}
+	archive := tarGz(t, "new binary")
+	name := AssetName("0.5.0", runtime.GOOS, runtime.GOARCH)
+	sums := fmt.Sprintf("%x  %s\n%x  other.tar.gz\n", sha256.Sum256(archive), name, sha256.Sum256(nil))
+
+	rs := newReleaseServer(t)
+	rs.addRelease("0.5.0", false, map[string][]byte{
+		name:                      archive,
+		"jip_0.5.0_checksums.txt": []byte(sums),
+	})
+	rel, err := rs.checker().Latest(context.Background(), ChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exe := filepath.Join(t.TempDir(), "jip")
+	if err := os.WriteFile(exe, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.checker().Install(context.Background(), rel, exe); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	got, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "new binary" {
+		t.Errorf("executable = %q, want the new binary", got)
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0o111 == 0 {
+		t.Errorf("new executable is not executable: %v", info.Mode())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(exe))
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestInstall_ChecksumMismatch(t *testing.T) {
+	archive := tarGz(t, "tampered")
+	name := AssetName("0.5.0", runtime.GOOS, runtime.GOARCH)
+	sums := fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte("something else")), name)
+
+	rs := newReleaseServer(t)
+	rs.addRelease("0.5.0", false, map[string][]byte{
+		name:                      archive,
+		"jip_0.5.0_checksums.txt": []byte(sums),
+	})
+	rel, err := rs.checker().Latest(context.Background(), ChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exe := filepath.Join(t.TempDir(), "jip")
+	if err := os.WriteFile(exe, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	err = rs.checker().Install(context.Background(), rel, exe)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "old binary" {
+		t.Errorf("executable was replaced despite the mismatch: %q", got)
+	}
+}
+
+func TestInstall_NoChecksums(t *testing.T) {
+	rs := newReleaseServer(t)
+	rs.addRelease("0.5.0", false, map[string][]byte{
+		AssetName("0.5.0", runtime.GOOS, runtime.GOARCH): tarGz(t, "new binary"),
+	})
+	rel, err := rs.checker().Latest(context.Background(), ChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.checker().Install(context.Background(), rel, filepath.Join(t.TempDir(), "jip")); err == nil {
+		t.Fatal("expected refusal without a checksums file")
+	}
+}
+
+func TestNotice(t *testing.T) {
+	rs := newReleaseServer(t)
+	rs.addRelease("0.5.0", false, nil)
+	statePath := filepath.Join(t.TempDir(), "jip", "update-check.json")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tokenCalls := 0
+	token := func() string {
+		tokenCalls++
+		return "secret"
+	}
+
+	note := rs.checker().Notice(context.Background(), "0.4.2", statePath, now, token)
+	if !strings.Contains(note, "0.5.0") || !strings.Contains(note, "jip upgrade") {
+		t.Errorf("note = %q", note)
+	}
+	if rs.latestCalls != 1 {
+		t.Fatalf("latest calls = %d, want 1", rs.latestCalls)
+	}
+	if tokenCalls != 1 || rs.auth != "Bearer secret" {
+		t.Errorf("token calls = %d, Authorization = %q", tokenCalls, rs.auth)
+	}
+
+	// Within the interval the remembered answer is reused, and no token is
+	// resolved.
+	if note := rs.checker().Notice(context.Background(), "0.4.2", statePath, now.Add(3*24*time.Hour), token); note == "" {
+		t.Error("expected the remembered notice within the interval")
+	}
+	if rs.latestCalls != 1 {
+		t.Errorf("checked again within the interval (%d calls)", rs.latestCalls)
+	}
+	if tokenCalls != 1 {
+		t.Errorf("token resolved within the interval (%d calls)", tokenCalls)
+	}
+
+	// After it, GitHub is asked again.
+	rs.addRelease("0.6.0", false, nil)
+	rs.releases = rs.releases[1:]
+	note = rs.checker().Notice(context.Background(), "0.4.2", statePath, now.Add(NoticeInterval), noToken)
+	if rs.latestCalls != 2 || !strings.Contains(note, "0.6.0") {
+		t.Errorf("after the interval: calls = %d, note = %q", rs.latestCalls, note)
+	}
+}
+
+func TestNotice_UpToDate(t *testing.T) {
+	rs := newReleaseServer(t)
+	rs.addRelease("0.5.0", false, nil)
+	statePath := filepath.Join(t.TempDir(), "update-check.json")
+	if note := rs.checker().Notice(context.Background(), "0.5.0", statePath, time.Now(), noToken); note != "" {
+		t.Errorf("note for an up-to-date version: %q", note)
+	}
+}
+
+func TestNotice_Offline(t *testing.T) {
+	rs := newReleaseServer(t)
+	rs.Close()
+	statePath := filepath.Join(t.TempDir(), "update-check.json")
+	if note := rs.checker().Notice(context.Background(), "0.4.0", statePath, time.Now(), noToken); note != "" {
+		t.Errorf("note while offline: %q", note)
+	}
+	if _, err := os.Stat(statePath); err != nil {
+		t.Errorf("failed check was not recorded: %v", err)
+	}
+}
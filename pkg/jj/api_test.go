@@ -0,0 +1,110 @@
+package jj_test
+
+import (
+	"testing"
+
+	"github.com/omarkohl/jip/pkg/jj"
+)
+
+// These assignments pin the signatures of the supported API: changing one
+// incompatibly breaks the build of this test, which is the point. See the
+// Compatibility section of the package documentation.
+var (
+	_ func(string) jj.Runner                                               = jj.NewRunner
+	_ func(string) (string, error)                                         = jj.WorkspaceRoot
+	_ func(jj.Runner, []string, string) ([]*jj.ChangeDAG, error)           = jj.ResolveStacks
+	_ func(jj.Runner, string, []jj.BookmarkInfo, string) (string, error)   = jj.ResolveBaseBranch
+	_ func([]byte) ([]jj.Change, error)                                    = jj.ParseChanges
+	_ func([]jj.Change) ([]*jj.ChangeDAG, error)                           = jj.BuildDAGs
+	_ func(*jj.ChangeDAG) []*jj.ChangeDAG                                  = jj.SplitComponents
+	_ func(jj.Runner, []*jj.ChangeDAG) (map[string]bool, error)            = jj.FindPrivateChanges
+	_ func([]byte) ([]jj.BookmarkInfo, error)                              = jj.ParseBookmarkList
+	_ func(*jj.ChangeDAG, []jj.BookmarkInfo) map[string][]*jj.BookmarkInfo = jj.MatchBookmarksToChanges
+	_ func(string, string) string                                          = jj.GenerateBookmarkName
+	_ func(string, int, func() (string, error)) (*jj.BookmarkNamer, error) = jj.NewBookmarkNamer
+	_ func([]byte) map[string]string                                       = jj.ParseRemoteList
+	_ func(string) (jj.Version, error)                                     = jj.Probe
+	_ func(string) (string, error)                                         = jj.Locate
+	_ func(jj.Version, jj.Version, string) error                           = jj.CheckVersion
+	_ func([]byte) []string                                                = jj.ParseResolveList
+	_ func(string) jj.DiffStat                                             = jj.ParseDiffStat
+	_ func(string, []jj.Trailer) string                                    = jj.WithTrailers
+	_ func(jj.Runner) jj.Runner                                            = jj.WithoutSnapshot
+	_ func(string) (jj.Overrides, error)                                   = jj.ParseOverrides
+
+	_ func(jj.Runner, *jj.ChangeDAG, []jj.BookmarkInfo, string, func(string, string) bool, bool, *jj.BookmarkNamer) ([]jj.ChangeBookmark, error) = jj.EnsureBookmarks
+)
+
+// Runner methods other tools build on.
+var _ interface {
+	Log(revset string) ([]byte, error)
+	BookmarkList() ([]byte, error)
+	BookmarkSet(name, rev string) error
+	BookmarkSetEach(targets []jj.BookmarkTarget) error
+	BookmarkSetAll(targets []jj.BookmarkTarget) error
+	GitPush(bookmarks []string, remote string) error
+	ConfigGet(key string) (string, error)
+} = jj.Runner(nil)
+
+// TestAPI_ParseChanges pins the fields of Change read from jj log output.
+func TestAPI_ParseChanges(t *testing.T) {
+	data := []byte(`{"change_id":"kkkkkkkk","short_id":"kk","commit_id":"c1","description":"feat: x\n\nbody\n","conflict":false,"empty":false,"immutable":false,"working_copy":false,"parent_ids":["pppppppp"],"bookmarks":["jip/x/kkkkkkkk"]}` + "\n")
+	changes, err := jj.ParseChanges(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := jj.Change{
+		ChangeID:    "kkkkkkkk",
+		ShortID:     "kk",
+		CommitID:    "c1",
+		Description: "feat: x\n\nbody", // trimmed
+		ParentIDs:   []string{"pppppppp"},
+		Bookmarks:   []string{"jip/x/kkkkkkkk"},
+	}
+	if len(changes) != 1 {
+		t.Fatalf("got %d changes", len(changes))
+	}
+	c := changes[0]
+	if c.ChangeID != want.ChangeID || c.ShortID != want.ShortID || c.CommitID != want.CommitID ||
+		c.Description != want.Description || len(c.ParentIDs) != 1 || len(c.Bookmarks) != 1 {
+		t.Errorf("got %+v, want %+v", c, want)
+	}
+	if c.Title() != "feat: x" || c.Short() != "kk" {
+		t.Errorf("Title() = %q, Short() = %q", c.Title(), c.Short())
+	}
+}
+
+// TestAPI_BuildDAGs pins the order of a stack: parents before children.
+func TestAPI_BuildDAGs(t *testing.T) {
+	dags, err := jj.BuildDAGs([]jj.Change{
+		{ChangeID: "b", ParentIDs: []string{"a"}},
+		{ChangeID: "a", ParentIDs: []string{"trunk"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dags) != 1 || len(dags[0].Changes) != 2 || dags[0].Changes[0].ChangeID != "a" || dags[0].ByID["b"] == nil {
+		t.Errorf("unexpected DAGs: %+v", dags)
+	}
+}
+
+// TestAPI_ParseBookmarkList pins how bookmarks and their remotes are read.
+func TestAPI_ParseBookmarkList(t *testing.T) {
+	data := []byte(`{"name":"feature","remote":null,"present":true,"conflict":false,"target":"c1","change_id":"kkkkkkkk","tracked":false,"synced":false}
+{"name":"feature","remote":"origin","present":true,"conflict":false,"target":"c0","change_id":"kkkkkkkk","tracked":true,"synced":false}
+`)
+	bookmarks, err := jj.ParseBookmarkList(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 1 {
+		t.Fatalf("got %d bookmarks", len(bookmarks))
+	}
+	b := bookmarks[0]
+	if b.Name != "feature" || b.Target != "c1" || !b.Present || b.Remotes["origin"].Target != "c0" || !b.Remotes["origin"].Tracked {
+		t.Errorf("got %+v", b)
+	}
+	if s := b.SyncWith("origin"); s != jj.SyncAhead {
+		t.Errorf("SyncWith = %v, want %v", s, jj.SyncAhead)
+	}
+}
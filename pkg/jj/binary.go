@@ -0,0 +1,144 @@
+package jj
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// BinaryEnv names the environment variable that points jip at a specific jj
+// executable. It wins over the jj-bin config key and PATH.
+const BinaryEnv = "JJ_BIN"
+
+// MinVersion is the oldest jj release jip works with. Older releases lack
+// template functions the Runner relies on (json() and the bookmark tracking
+// fields), which otherwise surface as cryptic template parse errors.
+var MinVersion = Version{Major: 0, Minor: 26, Patch: 0}
+
+// detected is the version Probe found; zero until it runs.
+var detected Version
+
+// jjBin is the jj executable every command runs. Locate replaces it with the
+// resolved path.
+var jjBin = "jj"
+
+// Binary returns the jj executable commands are run with.
+func Binary() string {
+	return jjBin
+}
+
+// ErrNotFound is returned by Locate when no jj executable can be found.
+var ErrNotFound = errors.New("jj not found — install it (https://jj-vcs.github.io/jj/latest/install-and-setup/) or set " + BinaryEnv)
+
+// Locate finds the jj executable and makes it the one commands run with. It
+// checks $JJ_BIN, then configured (the jj-bin config key, may be empty), then
+// PATH.
+func Locate(configured string) (string, error) {
+	source, path := "PATH", "jj"
+	if env := os.Getenv(BinaryEnv); env != "" {
+		source, path = BinaryEnv, env
+	} else if configured != "" {
+		source, path = "config jj-bin", configured
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		if source == "PATH" {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("jj executable %q (from %s) not usable: %w", path, source, err)
+	}
+	slog.Debug("jj binary", "path", resolved, "source", source)
+	jjBin = resolved
+	return resolved, nil
+}
+
+// Version is a jj release version.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Less reports whether v is an older release than o.
+func (v Version) Less(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+var versionRe = regexp.MustCompile(`(\d+)\.(\d+)\.(\d+)`)
+
+// ParseVersion extracts the version from `jj --version` output, e.g.
+// "jj 0.28.2" or "jj 0.29.0-a1b2c3d4" for builds from source.
+func ParseVersion(out string) (Version, error) {
+	m := versionRe.FindStringSubmatch(out)
+	if m == nil {
+		return Version{}, fmt.Errorf("cannot parse jj version from %q", strings.TrimSpace(out))
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	return v, nil
+}
+
+// DetectVersion runs `jj --version` with the located binary.
+func DetectVersion() (Version, error) {
+	args := []string{"--version"}
+	logCmd("jj", args)
+	out, err := combinedOutput(command(args))
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return Version{}, fmt.Errorf("jj --version: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return ParseVersion(string(out))
+}
+
+// CheckVersion returns an actionable error when v is older than min. feature
+// names what needs the newer release.
+func CheckVersion(v, min Version, feature string) error {
+	if v.Less(min) {
+		return fmt.Errorf("%s needs jj %s or newer, but %s is jj %s — upgrade jj or point %s at a newer one",
+			feature, min, jjBin, v, BinaryEnv)
+	}
+	return nil
+}
+
+// Probe locates jj and checks that it is recent enough for jip. It is meant
+// to run once at startup, before any other jj command.
+func Probe(configured string) (Version, error) {
+	if _, err := Locate(configured); err != nil {
+		return Version{}, err
+	}
+	v, err := DetectVersion()
+	if err != nil {
+		return Version{}, err
+	}
+	slog.Debug("jj version", "version", v.String())
+	if err := CheckVersion(v, MinVersion, "jip"); err != nil {
+		return Version{}, err
+	}
+	detected = v
+	return v, nil
+}
+
+// RequireVersion gates a feature on the jj version found by Probe. Before
+// Probe has run (as in tests) the version is unknown and the feature is
+// allowed.
+func RequireVersion(min Version, feature string) error {
+	if detected == (Version{}) {
+		return nil
+	}
+	return CheckVersion(detected, min, feature)
+}
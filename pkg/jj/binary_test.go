@@ -0,0 +1,121 @@
+package jj
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		out  string
+		want Version
+	}{
+		{"jj 0.28.2\n", Version{0, 28, 2}},
+		{"jj 0.29.0-a1b2c3d4e5f6\n", Version{0, 29, 0}},
+		{"jj 1.0.0", Version{1, 0, 0}},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.out)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q): %v", tt.out, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseVersion(%q) = %v, want %v", tt.out, got, tt.want)
+		}
+	}
+}
+
+func TestParseVersion_Invalid(t *testing.T) {
+	if _, err := ParseVersion("jj unknown"); err == nil {
+		t.Error("expected error for unparseable version")
+	}
+}
+
+func TestVersionLess(t *testing.T) {
+	tests := []struct {
+		a, b Version
+		want bool
+	}{
+		{Version{0, 25, 0}, Version{0, 26, 0}, true},
+		{Version{0, 26, 1}, Version{0, 26, 0}, false},
+		{Version{0, 26, 0}, Version{0, 26, 0}, false},
+		{Version{0, 99, 9}, Version{1, 0, 0}, true},
+		{Version{1, 0, 0}, Version{0, 99, 9}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Less(tt.b); got != tt.want {
+			t.Errorf("%v.Less(%v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	if err := CheckVersion(Version{0, 30, 0}, Version{0, 26, 0}, "jip"); err != nil {
+		t.Errorf("newer version rejected: %v", err)
+	}
+	err := CheckVersion(Version{0, 20, 1}, Version{0, 26, 0}, "jip")
+	if err == nil {
+		t.Fatal("expected error for old version")
+	}
+	for _, want := range []string{"0.26.0", "0.20.1", BinaryEnv} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestLocate(t *testing.T) {
+	defer func(old string) { jjBin = old }(jjBin)
+
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "my-jj")
+	if runtime.GOOS == "windows" {
+		fake += ".exe" // LookPath only accepts executable extensions
+	}
+	if err := os.WriteFile(fake, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("env wins over config", func(t *testing.T) {
+		t.Setenv(BinaryEnv, fake)
+		got, err := Locate("/nonexistent/jj")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != fake || Binary() != fake {
+			t.Errorf("got %q (Binary %q), want %q", got, Binary(), fake)
+		}
+	})
+
+	t.Run("config", func(t *testing.T) {
+		t.Setenv(BinaryEnv, "")
+		got, err := Locate(fake)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != fake {
+			t.Errorf("got %q, want %q", got, fake)
+		}
+	})
+
+	t.Run("bad config path", func(t *testing.T) {
+		t.Setenv(BinaryEnv, "")
+		_, err := Locate(filepath.Join(dir, "missing"))
+		if err == nil || !strings.Contains(err.Error(), "config jj-bin") {
+			t.Errorf("expected error naming the config key, got %v", err)
+		}
+	})
+
+	t.Run("not on PATH", func(t *testing.T) {
+		t.Setenv(BinaryEnv, "")
+		t.Setenv("PATH", dir)
+		_, err := Locate("")
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package jj
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// annotateTemplate outputs the commit and line number of each annotated
+// line. Templates over annotation lines need AnnotateMinVersion.
+const annotateTemplate = `commit.commit_id() ++ " " ++ line_number ++ "\n"`
+
+// AnnotateMinVersion is the oldest jj whose file annotate accepts
+// annotateTemplate.
+var AnnotateMinVersion = Version{Major: 0, Minor: 28, Patch: 0}
+
+// BlameCommits counts, per commit ID, the lines of change's parent that the
+// change's diff touches (changed lines plus their diff context): the commits
+// whose code the change modifies. Files the change adds have no history and
+// are skipped; so are merges, whose diff is against several parents.
+func BlameCommits(runner Runner, change *Change) (map[string]int, error) {
+	if err := RequireVersion(AnnotateMinVersion, "blaming touched lines"); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	if len(change.ParentIDs) != 1 {
+		return counts, nil
+	}
+	diff, err := runner.Diff(change.ChangeID)
+	if err != nil {
+		return nil, err
+	}
+	for path, lines := range TouchedLines(diff) {
+		data, err := runner.Annotate(change.ParentIDs[0], path)
+		if err != nil {
+			return nil, fmt.Errorf("annotating %s: %w", path, err)
+		}
+		byLine := ParseAnnotate(data)
+		for _, n := range lines {
+			if commit, ok := byLine[n]; ok {
+				counts[commit]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// TouchedLines returns, for each file a git-style diff modifies, the line
+// numbers of the old version its hunks cover. Added files have no old
+// version and are left out.
+func TouchedLines(diff string) map[string][]int {
+	touched := make(map[string][]int)
+	var path string
+	// Only file headers name paths; inside hunks "--- " is a removed line.
+	inHeader := false
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			path, inHeader = "", true
+		case strings.HasPrefix(line, "@@ "):
+			inHeader = false
+			if path == "" {
+				break
+			}
+			start, count, ok := parseOldRange(line)
+			for n := start; ok && n < start+count; n++ {
+				touched[path] = append(touched[path], n)
+			}
+		case inHeader && strings.HasPrefix(line, "--- "):
+			path = strings.TrimPrefix(strings.TrimPrefix(line, "--- "), "a/")
+			if path == "/dev/null" {
+				path = ""
+			}
+		}
+	}
+	return touched
+}
+
+// ChangedFiles returns the paths a git-style diff touches, in diff order.
+// Renamed files are listed under both names, deleted files under their old
+// one.
+func ChangedFiles(diff string) []string {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if path != "/dev/null" && !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	// Only file headers name paths; inside hunks "--- " is a removed line.
+	inHeader := false
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			inHeader = true
+		case strings.HasPrefix(line, "@@ "):
+			inHeader = false
+		case !inHeader:
+		case strings.HasPrefix(line, "--- "):
+			add(strings.TrimPrefix(strings.TrimPrefix(line, "--- "), "a/"))
+		case strings.HasPrefix(line, "+++ "):
+			add(strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/"))
+		case strings.HasPrefix(line, "rename from "):
+			add(strings.TrimPrefix(line, "rename from "))
+		case strings.HasPrefix(line, "rename to "):
+			add(strings.TrimPrefix(line, "rename to "))
+		}
+	}
+	return files
+}
+
+// parseOldRange parses the old-file range of a hunk header such as
+// "@@ -12,7 +12,9 @@ func f() {". A missing count means one line.
+func parseOldRange(header string) (start, count int, ok bool) {
+	fields := strings.Fields(header)
+	if len(fields) < 2 || !strings.HasPrefix(fields[1], "-") {
+		return 0, 0, false
+	}
+	startStr, countStr, hasCount := strings.Cut(strings.TrimPrefix(fields[1], "-"), ",")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, false
+	}
+	count = 1
+	if hasCount {
+		if count, err = strconv.Atoi(countStr); err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, count, true
+}
+
+// ParseAnnotate parses Annotate output into line number → commit ID.
+func ParseAnnotate(data []byte) map[int]string {
+	byLine := make(map[int]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		commit, num, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(num); err == nil {
+			byLine[n] = commit
+		}
+	}
+	return byLine
+}
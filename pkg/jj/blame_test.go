@@ -0,0 +1,102 @@
+package jj
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTouchedLines(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -3,4 +3,5 @@ import "fmt"
+ func main() {
+-	fmt.Println("hi")
++	fmt.Println("hello")
++	fmt.Println("world")
+ }
+@@ -20 +21 @@ func other() {
+-	return 1
++	return 2
+diff --git a/new.go b/new.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,2 @@
++package main
++
+diff --git a/gone.go b/gone.go
+deleted file mode 100644
+--- a/gone.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package main
+-
+`
+	got := TouchedLines(diff)
+	want := map[string][]int{
+		"main.go": {3, 4, 5, 6, 20},
+		"gone.go": {1, 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TouchedLines = %v, want %v", got, want)
+	}
+}
+
+func TestTouchedLines_RemovedLineLikeHeader(t *testing.T) {
+	// A removed "-- x" line reads "--- x" inside a hunk, like a file header.
+	diff := `diff --git a/query.sql b/query.sql
+index 1111111..2222222 100644
+--- a/query.sql
++++ b/query.sql
+@@ -1,3 +1,2 @@
+ SELECT 1;
+--- old comment
+ SELECT 2;
+@@ -10 +9 @@
+-SELECT 10;
++SELECT 11;
+`
+	got := TouchedLines(diff)
+	want := map[string][]int{"query.sql": {1, 2, 3, 10}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TouchedLines = %v, want %v", got, want)
+	}
+}
+
+func TestParseAnnotate(t *testing.T) {
+	data := []byte("aaaa 1\naaaa 2\nbbbb 3\nmalformed\n")
+	got := ParseAnnotate(data)
+	want := map[int]string{1: "aaaa", 2: "aaaa", 3: "bbbb"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseAnnotate = %v, want %v", got, want)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,2 +1,2 @@
+--- a/not-a-header
++x
+diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1 @@
++package main
+diff --git a/old/name.go b/new/name.go
+similarity index 100%
+rename from old/name.go
+rename to new/name.go
+`
+	got := ChangedFiles(diff)
+	want := []string{"main.go", "new.go", "old/name.go", "new/name.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedFiles = %q, want %q", got, want)
+	}
+}
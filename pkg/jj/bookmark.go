@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"strings"
+	"text/template"
+	"time"
 )
 
 // SyncState describes how a local bookmark relates to a remote copy.
@@ -197,10 +200,16 @@ type ChangeBookmark struct {
 	Displaced bool      // bookmark exists but no longer points to this change's commit
 }
 
+// BookmarkTarget is a bookmark and the revision it is to point at.
+type BookmarkTarget struct {
+	Name string
+	Rev  string
+}
+
 // EnsureBookmarks assigns a bookmark to each change in the DAG. For changes
 // that already have a matching bookmark, it is reused (subject to the
 // shouldUseExisting callback). For changes without a bookmark, a new one is
-// created using the jip naming convention.
+// created, named by namer (the jip naming convention when nil).
 //
 // shouldUseExisting is called for each existing bookmark on a change and returns
 // true if that bookmark should be used for the PR. This is the extension point
@@ -213,7 +222,11 @@ func EnsureBookmarks(
 	pushRemote string,
 	shouldUseExisting func(changeID, bookmark string) bool,
 	createNew bool,
+	namer *BookmarkNamer,
 ) ([]ChangeBookmark, error) {
+	if namer == nil {
+		namer, _ = NewBookmarkNamer("", 0, nil)
+	}
 	matched := MatchBookmarksToChanges(dag, bookmarks)
 
 	// Build name lookup for detecting bookmarks that exist but point to a
@@ -224,6 +237,7 @@ func EnsureBookmarks(
 	}
 
 	var result []ChangeBookmark
+	var create []BookmarkTarget // the new bookmarks, set together below
 	for _, change := range dag.Changes {
 		existing := matched[change.ChangeID]
 
@@ -254,7 +268,7 @@ func EnsureBookmarks(
 		if len(shortID) > 8 {
 			shortID = shortID[:8]
 		}
-		name := GenerateBookmarkName(change.Description, shortID)
+		name := namer.Name(change.Description, shortID)
 
 		if bi, exists := bookmarkByName[name]; exists {
 			// Bookmark exists but points to a different commit than our change.
@@ -274,9 +288,7 @@ func EnsureBookmarks(
 			continue
 		}
 
-		if err := runner.BookmarkSet(name, change.ChangeID); err != nil {
-			return nil, fmt.Errorf("creating bookmark for %s: %w", change.ChangeID, err)
-		}
+		create = append(create, BookmarkTarget{Name: name, Rev: change.ChangeID})
 		result = append(result, ChangeBookmark{
 			ChangeID:  change.ChangeID,
 			Bookmark:  name,
@@ -284,17 +296,169 @@ func EnsureBookmarks(
 			SyncState: SyncLocalOnly,
 		})
 	}
+	if len(create) > 0 {
+		start := time.Now()
+		if err := runner.BookmarkSetEach(create); err != nil {
+			return nil, fmt.Errorf("creating bookmarks: %w", err)
+		}
+		slog.Debug("bookmarks created", "count", len(create), "duration", time.Since(start))
+	}
 	return result, nil
 }
 
 // GenerateBookmarkName creates a bookmark name following the jip convention:
 // jip/<slugified-description>/<short-change-id>
 func GenerateBookmarkName(description, shortChangeID string) string {
-	slug := slugify(description)
+	return fmt.Sprintf("jip/%s/%s", descriptionSlug(description, maxSlugLen), shortChangeID)
+}
+
+func descriptionSlug(description string, maxLen int) string {
+	slug := slugifyLen(description, maxLen)
 	if slug == "" {
 		slug = "change"
 	}
-	return fmt.Sprintf("jip/%s/%s", slug, shortChangeID)
+	return slug
+}
+
+// DefaultBookmarkTemplate is the template of the jip naming convention.
+const DefaultBookmarkTemplate = "jip/{{.Slug}}/{{.ShortID}}"
+
+// BookmarkNameData is what a bookmark name template can refer to.
+type BookmarkNameData struct {
+	User    string // the forge user sending, e.g. the GitHub login
+	Slug    string // slugified change title
+	ShortID string // first 8 characters of the change ID
+}
+
+// BookmarkNamer names the bookmarks of new changes from a text/template and
+// recognizes the bookmarks it named, as well as those named by the default
+// jip/ convention before the template was changed.
+type BookmarkNamer struct {
+	tmpl    *template.Template
+	user    string
+	slugLen int
+	pattern *regexp.Regexp // matches the names tmpl renders
+	prefix  string         // the fixed start of every name tmpl renders
+}
+
+// Placeholders rendered into a template to find where its variable parts go.
+const (
+	userMarker    = "\x00user\x00"
+	slugMarker    = "\x00slug\x00"
+	shortIDMarker = "\x00id\x00"
+)
+
+// NewBookmarkNamer parses text, a template over BookmarkNameData; an empty
+// text selects DefaultBookmarkTemplate. Slugs are cut to at most slugLen
+// characters (30 when 0). user is called, only if the template uses
+// {{.User}}, to look up the user, which is made safe for a branch name. The
+// template must use {{.ShortID}}, which keeps names unique.
+func NewBookmarkNamer(text string, slugLen int, user func() (string, error)) (*BookmarkNamer, error) {
+	if text == "" {
+		text = DefaultBookmarkTemplate
+	}
+	switch {
+	case slugLen < 0:
+		return nil, fmt.Errorf("invalid slug length %d: it must be positive", slugLen)
+	case slugLen == 0:
+		slugLen = maxSlugLen
+	}
+	tmpl, err := template.New("bookmark").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bookmark template: %w", err)
+	}
+	n := &BookmarkNamer{tmpl: tmpl, slugLen: slugLen}
+
+	var sample strings.Builder
+	if err := tmpl.Execute(&sample, BookmarkNameData{User: userMarker, Slug: slugMarker, ShortID: shortIDMarker}); err != nil {
+		return nil, fmt.Errorf("invalid bookmark template: %w", err)
+	}
+	rendered := sample.String()
+	if !strings.Contains(rendered, shortIDMarker) {
+		return nil, fmt.Errorf("invalid bookmark template %q: it must contain {{.ShortID}} to keep names unique", text)
+	}
+	if strings.Contains(rendered, userMarker) {
+		if user == nil {
+			return nil, fmt.Errorf("bookmark template %q uses {{.User}}, but the user is not known", text)
+		}
+		login, err := user()
+		if err != nil {
+			return nil, fmt.Errorf("looking up the user for the bookmark template: %w", err)
+		}
+		if n.user = refSafe(login); n.user == "" {
+			return nil, fmt.Errorf("user %q cannot be part of a bookmark name", login)
+		}
+		rendered = strings.ReplaceAll(rendered, userMarker, n.user)
+	}
+	example := strings.NewReplacer(slugMarker, "change", shortIDMarker, "abcdefgh").Replace(rendered)
+	if !validBranchName(example) {
+		return nil, fmt.Errorf("invalid bookmark template %q: %q is not a valid branch name", text, example)
+	}
+	n.prefix = rendered[:strings.IndexByte(rendered, 0)]
+	quoted := regexp.QuoteMeta(rendered)
+	quoted = strings.ReplaceAll(quoted, regexp.QuoteMeta(slugMarker), `[a-z0-9-]+`)
+	quoted = strings.ReplaceAll(quoted, regexp.QuoteMeta(shortIDMarker), `[a-z0-9]+`)
+	n.pattern = regexp.MustCompile("^" + quoted + "$")
+	return n, nil
+}
+
+// Name returns the bookmark name for a change.
+func (n *BookmarkNamer) Name(description, shortChangeID string) string {
+	var b strings.Builder
+	// Execution cannot fail: NewBookmarkNamer rendered the template already.
+	_ = n.tmpl.Execute(&b, BookmarkNameData{User: n.user, Slug: descriptionSlug(description, n.slugLen), ShortID: shortChangeID})
+	return b.String()
+}
+
+// Owns reports whether name was generated by jip: with this template or the
+// default convention.
+func (n *BookmarkNamer) Owns(name string) bool {
+	return strings.HasPrefix(name, "jip/") || n.pattern.MatchString(name)
+}
+
+// Globs returns the jj string patterns matching every name Owns accepts,
+// or nil if they cannot be narrowed down from a fixed prefix.
+func (n *BookmarkNamer) Globs() []string {
+	if n.prefix == "" {
+		return nil
+	}
+	globs := []string{"glob:jip/*"}
+	if !strings.HasPrefix(n.prefix, "jip/") {
+		globs = append(globs, "glob:"+n.prefix+"*")
+	}
+	return globs
+}
+
+// refSafeRe matches runs of characters not allowed in a bookmark name
+// component.
+var refSafeRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// refSafe makes s usable as part of a branch name.
+func refSafe(s string) string {
+	return strings.Trim(refSafeRe.ReplaceAllString(s, "-"), "-.")
+}
+
+// validBranchName reports whether name is a valid git branch name, following
+// the rules of git check-ref-format that a template can break.
+func validBranchName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") ||
+		strings.HasSuffix(name, ".") || strings.HasSuffix(name, ".lock") {
+		return false
+	}
+	if strings.Contains(name, "..") || strings.Contains(name, "//") || strings.Contains(name, "@{") {
+		return false
+	}
+	for _, r := range name {
+		if r <= ' ' || r == 0x7f || strings.ContainsRune("~^:?*[\\", r) {
+			return false
+		}
+	}
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	return true
 }
 
 // conventionalPrefixRe matches conventional commit prefixes like "feat:", "fix(scope):", etc.
@@ -306,21 +470,28 @@ var nonAlnumRe = regexp.MustCompile(`[^a-z0-9]+`)
 const maxSlugLen = 30
 
 // slugify converts a commit description into a bookmark-safe slug.
-// It strips conventional commit prefixes, lowercases, replaces non-alphanumeric
-// characters with hyphens, and truncates to maxSlugLen.
+// It strips conventional commit prefixes, lowercases, transliterates letters
+// like é or ж to ASCII, replaces other non-alphanumeric characters with
+// hyphens, and truncates to maxSlugLen.
 func slugify(s string) string {
+	return slugifyLen(s, maxSlugLen)
+}
+
+// slugifyLen is slugify truncating to maxLen instead.
+func slugifyLen(s string, maxLen int) string {
 	// Strip conventional commit prefix.
 	s = conventionalPrefixRe.ReplaceAllString(s, "")
-	s = strings.ToLower(s)
+	s = transliterate(strings.ToLower(s))
 	s = nonAlnumRe.ReplaceAllString(s, "-")
 	s = strings.Trim(s, "-")
 
 	// Truncate at word boundary if possible.
-	if len(s) > maxSlugLen {
-		s = s[:maxSlugLen]
-		if i := strings.LastIndex(s, "-"); i > maxSlugLen/2 {
+	if len(s) > maxLen {
+		s = s[:maxLen]
+		if i := strings.LastIndex(s, "-"); i > maxLen/2 {
 			s = s[:i]
 		}
+		s = strings.TrimRight(s, "-")
 	}
 	return s
 }
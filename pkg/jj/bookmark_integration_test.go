@@ -322,7 +322,7 @@ func TestIntegration_BookmarkCreation(t *testing.T) {
 	}
 
 	// EnsureBookmarks should create new bookmarks.
-	results, err := EnsureBookmarks(runner, dags[0], bookmarks, "origin", nil, true)
+	results, err := EnsureBookmarks(runner, dags[0], bookmarks, "origin", nil, true, nil)
 	if err != nil {
 		t.Fatalf("EnsureBookmarks: %v", err)
 	}
@@ -378,7 +378,7 @@ func TestIntegration_BookmarkReuse(t *testing.T) {
 
 	// shouldUseExisting always returns true → reuse existing bookmark.
 	results, err := EnsureBookmarks(runner, dags[0], bookmarks, "origin",
-		func(changeID, bookmark string) bool { return true }, true)
+		func(changeID, bookmark string) bool { return true }, true, nil)
 	if err != nil {
 		t.Fatalf("EnsureBookmarks: %v", err)
 	}
@@ -424,7 +424,7 @@ func TestIntegration_BookmarkSelectiveReuse(t *testing.T) {
 	results, err := EnsureBookmarks(runner, dags[0], bookmarks, "origin",
 		func(changeID, bookmark string) bool {
 			return strings.HasPrefix(bookmark, "jip/")
-		}, true)
+		}, true, nil)
 	if err != nil {
 		t.Fatalf("EnsureBookmarks: %v", err)
 	}
@@ -464,6 +464,44 @@ func TestSlugify_Truncation(t *testing.T) {
 	}
 }
 
+func TestSlugify_Transliteration(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"feat: Café crème", "cafe-creme"},
+		{"Änderung der Straße", "anderung-der-strasse"},
+		{"fix: Łódź encoding", "lodz-encoding"},
+		{"Ærø og Œuvre", "aero-og-oeuvre"},
+		{"Добавить логин", "dobavit-login"},
+		{"修复登录", ""},
+	}
+	for _, tt := range tests {
+		got := slugify(tt.input)
+		if got != tt.want {
+			t.Errorf("slugify(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSlugifyLen(t *testing.T) {
+	long := "add the new authentication module"
+	tests := []struct {
+		maxLen int
+		want   string
+	}{
+		{10, "add-the"},
+		{20, "add-the-new"},
+		{50, "add-the-new-authentication-module"},
+	}
+	for _, tt := range tests {
+		got := slugifyLen(long, tt.maxLen)
+		if got != tt.want {
+			t.Errorf("slugifyLen(%q, %d) = %q, want %q", long, tt.maxLen, got, tt.want)
+		}
+	}
+}
+
 // --- GenerateBookmarkName tests ---
 
 func TestGenerateBookmarkName_Basic(t *testing.T) {
@@ -474,6 +512,14 @@ func TestGenerateBookmarkName_Basic(t *testing.T) {
 	}
 }
 
+func TestGenerateBookmarkName_CJKDescription(t *testing.T) {
+	name := GenerateBookmarkName("修复登录问题", "abc12345")
+	want := "jip/change/abc12345"
+	if name != want {
+		t.Errorf("got %q, want %q", name, want)
+	}
+}
+
 func TestGenerateBookmarkName_EmptyDescription(t *testing.T) {
 	name := GenerateBookmarkName("", "abc12345")
 	want := "jip/change/abc12345"
@@ -482,6 +528,94 @@ func TestGenerateBookmarkName_EmptyDescription(t *testing.T) {
 	}
 }
 
+// --- BookmarkNamer tests ---
+
+func TestBookmarkNamer_Default(t *testing.T) {
+	n, err := NewBookmarkNamer("", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n.Name("feat: add auth module", "xyzklmno"), GenerateBookmarkName("feat: add auth module", "xyzklmno"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := n.Globs(); len(got) != 1 || got[0] != "glob:jip/*" {
+		t.Errorf("globs = %v", got)
+	}
+}
+
+func TestBookmarkNamer_User(t *testing.T) {
+	n, err := NewBookmarkNamer("{{.User}}/{{.Slug}}-{{.ShortID}}", 0, func() (string, error) { return "Alice Smith", nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n.Name("fix: the thing", "xyzklmno"), "Alice-Smith/the-thing-xyzklmno"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	for name, want := range map[string]bool{
+		"Alice-Smith/the-thing-xyzklmno": true,
+		"jip/old-name/xyzklmno":          true, // named before the template changed
+		"Alice-Smith/notes":              false,
+		"bob/the-thing-xyzklmno":         false,
+		"main":                           false,
+	} {
+		if got := n.Owns(name); got != want {
+			t.Errorf("Owns(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if got := n.Globs(); len(got) != 2 || got[1] != "glob:Alice-Smith/*" {
+		t.Errorf("globs = %v", got)
+	}
+}
+
+func TestBookmarkNamer_UserOnlyLookedUpWhenUsed(t *testing.T) {
+	called := false
+	user := func() (string, error) { called = true; return "alice", nil }
+	if _, err := NewBookmarkNamer("stack/{{.ShortID}}", 0, user); err != nil {
+		t.Fatal(err)
+	}
+	if called {
+		t.Error("user looked up for a template without {{.User}}")
+	}
+}
+
+func TestBookmarkNamer_NoPrefix(t *testing.T) {
+	n, err := NewBookmarkNamer("{{.Slug}}-{{.ShortID}}", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Globs() != nil {
+		t.Errorf("globs = %v, want nil (fetch everything)", n.Globs())
+	}
+}
+
+func TestBookmarkNamer_SlugLength(t *testing.T) {
+	n, err := NewBookmarkNamer("", 12, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n.Name("feat: add the auth module", "xyzklmno"), "jip/add-the/xyzklmno"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := NewBookmarkNamer("", -1, nil); err == nil {
+		t.Error("expected an error for a negative slug length")
+	}
+}
+
+func TestBookmarkNamer_Invalid(t *testing.T) {
+	for _, text := range []string{
+		"jip/{{.Slug}",               // parse error
+		"jip/{{.Slug}}",              // no ShortID
+		"jip/{{.Nope}}/{{.ShortID}}", // unknown field
+		"jip {{.ShortID}}",           // space
+		"jip/{{.ShortID}}.lock",      // reserved suffix
+		"{{.User}}/{{.ShortID}}",     // no user lookup
+	} {
+		if _, err := NewBookmarkNamer(text, 0, nil); err == nil {
+			t.Errorf("NewBookmarkNamer(%q): expected an error", text)
+		}
+	}
+}
+
 // --- MatchBookmarksToChanges tests ---
 
 func TestMatchBookmarksToChanges_Basic(t *testing.T) {
@@ -4,18 +4,48 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
+	"time"
 )
 
 // Change represents a single jj change in a stack.
 type Change struct {
 	ChangeID    string   `json:"change_id"`
+	ShortID     string   `json:"short_id"` // as jj displays it (format_short_change_id)
 	CommitID    string   `json:"commit_id"`
 	Description string   `json:"description"`
 	Conflict    bool     `json:"conflict"`
+	Empty       bool     `json:"empty"`        // no changes to the files
+	Immutable   bool     `json:"immutable"`    // in immutable_heads() or an ancestor of it
+	WorkingCopy bool     `json:"working_copy"` // the workspace's working-copy change (@)
 	ParentIDs   []string `json:"parent_ids"`
 	Bookmarks   []string `json:"bookmarks"`
+
+	// Author is who wrote the change, and when. Changes not read from jj
+	// log have none.
+	Author Signature `json:"author"`
+}
+
+// Signature is the author of a change: who wrote it and when.
+type Signature struct {
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Short returns the change ID the way jj shows it: the shortest unique
+// prefix, honoring revsets.short-prefixes and the format_short_change_id
+// template alias. Changes not read from jj log fall back to 12 characters.
+func (c *Change) Short() string {
+	if c.ShortID != "" {
+		return c.ShortID
+	}
+	if len(c.ChangeID) > 12 {
+		return c.ChangeID[:12]
+	}
+	return c.ChangeID
 }
 
 // Title returns the first line of the description (the commit subject).
@@ -26,16 +56,27 @@ func (c *Change) Title() string {
 	return c.Description
 }
 
+// PRTitle returns the title of the change's PR: the PR-Title trailer if the
+// description has one, the commit subject otherwise.
+func (c *Change) PRTitle() string {
+	if o, _ := ParseOverrides(c.Description); o.Title != "" {
+		return o.Title
+	}
+	return c.Title()
+}
+
 // Body returns everything after the first blank line separator in the
-// description, trimmed of leading/trailing whitespace. Returns "" if there
-// is no body.
+// description, trimmed of leading/trailing whitespace. jip's own trailers
+// (see WithTrailers) and the PR override trailers (see ParseOverrides) are not
+// part of the body. Returns "" if there is no body.
 func (c *Change) Body() string {
 	// Convention: title, blank line, body.
-	idx := strings.Index(c.Description, "\n\n")
+	desc := StripOverrides(StripTrailers(c.Description))
+	idx := strings.Index(desc, "\n\n")
 	if idx < 0 {
 		return ""
 	}
-	return strings.TrimSpace(c.Description[idx+2:])
+	return strings.TrimSpace(desc[idx+2:])
 }
 
 // ChangeDAG is a connected DAG of changes. Changes are topologically sorted
@@ -54,8 +95,10 @@ func ParseChanges(data []byte) ([]Change, error) {
 		if err := dec.Decode(&c); err != nil {
 			return nil, fmt.Errorf("parsing change: %w", err)
 		}
+		// Descriptions written by a Windows editor carry CRLF line endings;
+		// normalize them so titles and trailers parse the same everywhere.
 		// jj's description template includes a trailing newline; trim it.
-		c.Description = strings.TrimRight(c.Description, "\n")
+		c.Description = strings.TrimRight(strings.ReplaceAll(c.Description, "\r\n", "\n"), "\n")
 		changes = append(changes, c)
 	}
 	return changes, nil
@@ -156,6 +199,86 @@ func (dag *ChangeDAG) LeafChanges() []*Change {
 	return leaves
 }
 
+// SplitMegamerges removes "megamerge" changes from the DAGs and splits what
+// remains into one DAG per independent stack. A megamerge is a tip merge (no
+// children in its DAG, two or more parents in it) that is either private or
+// has no description — the local merge commit a developer works on top of to
+// see several independent stacks at once. It is never meant to become a PR.
+// The returned DAGs reference the same Change values as the input.
+func SplitMegamerges(dags []*ChangeDAG, private map[string]bool) ([]*ChangeDAG, []*Change) {
+	var result []*ChangeDAG
+	var megamerges []*Change
+	for _, dag := range dags {
+		skip := make(map[string]bool)
+		for _, leaf := range dag.LeafChanges() {
+			inDAG := 0
+			for _, pid := range leaf.ParentIDs {
+				if _, ok := dag.ByID[pid]; ok {
+					inDAG++
+				}
+			}
+			if inDAG < 2 {
+				continue
+			}
+			if private[leaf.ChangeID] || strings.TrimSpace(leaf.Description) == "" {
+				skip[leaf.ChangeID] = true
+				megamerges = append(megamerges, leaf)
+			}
+		}
+		if len(skip) == 0 {
+			result = append(result, dag)
+			continue
+		}
+		if fd := FilterDAG(dag, skip); fd != nil {
+			result = append(result, SplitComponents(fd)...)
+		}
+	}
+	return result, megamerges
+}
+
+// SplitComponents splits a DAG into its connected components, each returned
+// as its own DAG. Topological order is preserved within each component, and
+// components are ordered by their first change.
+func SplitComponents(dag *ChangeDAG) []*ChangeDAG {
+	idx := make(map[string]int, len(dag.Changes))
+	for i, c := range dag.Changes {
+		idx[c.ChangeID] = i
+	}
+	parent := make([]int, len(dag.Changes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(x int) int {
+		if parent[x] != x {
+			parent[x] = find(parent[x])
+		}
+		return parent[x]
+	}
+	for i, c := range dag.Changes {
+		for _, pid := range c.ParentIDs {
+			if j, ok := idx[pid]; ok {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	var result []*ChangeDAG
+	byRoot := make(map[int]*ChangeDAG)
+	for i, c := range dag.Changes {
+		root := find(i)
+		comp, ok := byRoot[root]
+		if !ok {
+			comp = &ChangeDAG{ByID: make(map[string]*Change)}
+			byRoot[root] = comp
+			result = append(result, comp)
+		}
+		comp.Changes = append(comp.Changes, c)
+		comp.ByID[c.ChangeID] = c
+	}
+	return result
+}
+
 // FindPrivateChanges queries jj for the git.private-commits config and
 // returns the set of change IDs from the given DAGs that match the configured
 // private revset. Returns an empty set if git.private-commits is not configured.
@@ -193,6 +316,33 @@ func FindPrivateChanges(runner Runner, dags []*ChangeDAG) (map[string]bool, erro
 	return result, nil
 }
 
+// FindUnsignedChanges returns the set of change IDs of changes whose commit
+// is not cryptographically signed, using jj's signed() revset.
+func FindUnsignedChanges(runner Runner, changes []*Change) (map[string]bool, error) {
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, len(changes))
+	for i, c := range changes {
+		ids[i] = c.CommitID
+	}
+
+	data, err := runner.Log("(" + strings.Join(ids, " | ") + ") ~ signed()")
+	if err != nil {
+		return nil, fmt.Errorf("evaluating commit signatures: %w", err)
+	}
+	unsigned, err := ParseChanges(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing unsigned commits: %w", err)
+	}
+
+	result := make(map[string]bool, len(unsigned))
+	for _, c := range unsigned {
+		result[c.ChangeID] = true
+	}
+	return result, nil
+}
+
 // FilterDAG returns a new ChangeDAG excluding changes whose IDs are keys in skip.
 // Returns nil if all changes are skipped.
 func FilterDAG[T any](dag *ChangeDAG, skip map[string]T) *ChangeDAG {
@@ -210,6 +360,49 @@ func FilterDAG[T any](dag *ChangeDAG, skip map[string]T) *ChangeDAG {
 	return &ChangeDAG{Changes: filtered, ByID: byID}
 }
 
+// FoldDAG removes the changes whose IDs are keys in fold from dag, folding
+// each into its children: a child takes over the parents of the folded
+// change below it, so the stack skips over the folded change while its
+// commit stays in the child's history. The children are copies; the other
+// changes are shared with dag. Folding can disconnect the DAG, so the result
+// is split into its components.
+func FoldDAG[T any](dag *ChangeDAG, fold map[string]T) []*ChangeDAG {
+	folded := make(map[string][]string) // folded change ID → parents it passes on
+	var kept []*Change
+	byID := make(map[string]*Change)
+	for _, c := range dag.Changes {
+		var parents []string
+		rewired := false
+		for _, pid := range c.ParentIDs {
+			if pp, ok := folded[pid]; ok {
+				rewired = true
+				for _, p := range pp {
+					if !slices.Contains(parents, p) {
+						parents = append(parents, p)
+					}
+				}
+			} else if !slices.Contains(parents, pid) {
+				parents = append(parents, pid)
+			}
+		}
+		if _, ok := fold[c.ChangeID]; ok {
+			folded[c.ChangeID] = parents
+			continue
+		}
+		if rewired {
+			cp := *c
+			cp.ParentIDs = parents
+			c = &cp
+		}
+		kept = append(kept, c)
+		byID[c.ChangeID] = c
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return SplitComponents(&ChangeDAG{Changes: kept, ByID: byID})
+}
+
 // topoSort performs Kahn's algorithm on the subset of changes identified
 // by memberIndices, returning a ChangeDAG with changes ordered roots-first.
 func topoSort(all []Change, memberIndices []int, known map[string]int) (*ChangeDAG, error) {
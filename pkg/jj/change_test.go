@@ -2,6 +2,7 @@ package jj
 
 import (
 	"testing"
+	"time"
 )
 
 // --- Layer 1: Pure DAG logic tests ---
@@ -190,6 +191,146 @@ func TestLeafChanges_SingleChange(t *testing.T) {
 	}
 }
 
+func TestSplitMegamerges_UndescribedMerge(t *testing.T) {
+	// Three independent stacks merged locally by an undescribed change m.
+	changes := []Change{
+		{ChangeID: "a", Description: "feat: a", ParentIDs: []string{"base"}},
+		{ChangeID: "b1", Description: "feat: b1", ParentIDs: []string{"base"}},
+		{ChangeID: "b2", Description: "feat: b2", ParentIDs: []string{"b1"}},
+		{ChangeID: "c", Description: "feat: c", ParentIDs: []string{"base"}},
+		{ChangeID: "m", ParentIDs: []string{"a", "b2", "c"}},
+	}
+	dags := mustBuildDAGs(t, changes)
+	split, megamerges := SplitMegamerges(dags, nil)
+	if len(megamerges) != 1 || megamerges[0].ChangeID != "m" {
+		t.Fatalf("expected megamerge m, got %v", megamerges)
+	}
+	if len(split) != 3 {
+		t.Fatalf("expected 3 stacks, got %d", len(split))
+	}
+	var sizes []int
+	for _, d := range split {
+		sizes = append(sizes, len(d.Changes))
+		if _, ok := d.ByID["m"]; ok {
+			t.Error("megamerge must not remain in a stack")
+		}
+	}
+	if sizes[0]+sizes[1]+sizes[2] != 4 {
+		t.Errorf("expected 4 changes across stacks, got %v", sizes)
+	}
+}
+
+func TestSplitMegamerges_PrivateMerge(t *testing.T) {
+	changes := []Change{
+		{ChangeID: "a", Description: "feat: a", ParentIDs: []string{"base"}},
+		{ChangeID: "b", Description: "feat: b", ParentIDs: []string{"base"}},
+		{ChangeID: "m", Description: "private: local merge", ParentIDs: []string{"a", "b"}},
+	}
+	dags := mustBuildDAGs(t, changes)
+	split, megamerges := SplitMegamerges(dags, map[string]bool{"m": true})
+	if len(megamerges) != 1 {
+		t.Fatalf("expected 1 megamerge, got %d", len(megamerges))
+	}
+	if len(split) != 2 {
+		t.Fatalf("expected 2 stacks, got %d", len(split))
+	}
+}
+
+func TestSplitMegamerges_DescribedMergeIsKept(t *testing.T) {
+	// A described, public merge (e.g. "merge main into release") is a real
+	// change that should be sent.
+	changes := []Change{
+		{ChangeID: "a", Description: "feat: a", ParentIDs: []string{"base"}},
+		{ChangeID: "b", Description: "feat: b", ParentIDs: []string{"base"}},
+		{ChangeID: "m", Description: "chore: merge", ParentIDs: []string{"a", "b"}},
+	}
+	dags := mustBuildDAGs(t, changes)
+	split, megamerges := SplitMegamerges(dags, nil)
+	if len(megamerges) != 0 {
+		t.Fatalf("expected no megamerges, got %d", len(megamerges))
+	}
+	if len(split) != 1 || len(split[0].Changes) != 3 {
+		t.Fatalf("expected the DAG untouched, got %d DAG(s)", len(split))
+	}
+}
+
+func TestSplitMegamerges_SharedAncestorStaysTogether(t *testing.T) {
+	// Both merged branches build on r, so they remain one stack.
+	changes := []Change{
+		{ChangeID: "r", Description: "feat: r", ParentIDs: []string{"base"}},
+		{ChangeID: "a", Description: "feat: a", ParentIDs: []string{"r"}},
+		{ChangeID: "b", Description: "feat: b", ParentIDs: []string{"r"}},
+		{ChangeID: "m", ParentIDs: []string{"a", "b"}},
+	}
+	dags := mustBuildDAGs(t, changes)
+	split, megamerges := SplitMegamerges(dags, nil)
+	if len(megamerges) != 1 {
+		t.Fatalf("expected 1 megamerge, got %d", len(megamerges))
+	}
+	if len(split) != 1 {
+		t.Fatalf("expected 1 stack, got %d", len(split))
+	}
+	assertOrder(t, split[0], []string{"r", "a", "b"})
+}
+
+func TestSplitComponents_PreservesOrder(t *testing.T) {
+	dag := &ChangeDAG{ByID: map[string]*Change{}}
+	for _, c := range []*Change{
+		{ChangeID: "a", ParentIDs: []string{"base"}},
+		{ChangeID: "x", ParentIDs: []string{"base"}},
+		{ChangeID: "b", ParentIDs: []string{"a"}},
+		{ChangeID: "y", ParentIDs: []string{"x"}},
+	} {
+		dag.Changes = append(dag.Changes, c)
+		dag.ByID[c.ChangeID] = c
+	}
+	comps := SplitComponents(dag)
+	if len(comps) != 2 {
+		t.Fatalf("expected 2 components, got %d", len(comps))
+	}
+	assertOrder(t, comps[0], []string{"a", "b"})
+	assertOrder(t, comps[1], []string{"x", "y"})
+}
+
+func TestFoldDAG(t *testing.T) {
+	dags := mustBuildDAGs(t, []Change{
+		{ChangeID: "a", Description: "feat: a", ParentIDs: []string{"base"}},
+		{ChangeID: "s", Description: "local tweak [skip pr]", ParentIDs: []string{"a"}},
+		{ChangeID: "b", Description: "feat: b", ParentIDs: []string{"s"}},
+		{ChangeID: "c", Description: "feat: c", ParentIDs: []string{"b"}},
+	})
+	original := dags[0].ByID["b"]
+	folded := FoldDAG(dags[0], map[string]bool{"s": true})
+	if len(folded) != 1 {
+		t.Fatalf("expected 1 DAG, got %d", len(folded))
+	}
+	assertOrder(t, folded[0], []string{"a", "b", "c"})
+	if got := folded[0].ByID["b"].ParentIDs; len(got) != 1 || got[0] != "a" {
+		t.Errorf("b's parents = %v, want [a]", got)
+	}
+	if original.ParentIDs[0] != "s" {
+		t.Errorf("the input change was modified: parents %v", original.ParentIDs)
+	}
+	if folded[0].ByID["c"] != dags[0].ByID["c"] {
+		t.Errorf("changes that keep their parents should be shared")
+	}
+}
+
+func TestFoldDAG_SplitsAtFoldedRoot(t *testing.T) {
+	dags := mustBuildDAGs(t, []Change{
+		{ChangeID: "s", Description: "tweak", ParentIDs: []string{"base"}},
+		{ChangeID: "a", Description: "feat: a", ParentIDs: []string{"s"}},
+		{ChangeID: "b", Description: "feat: b", ParentIDs: []string{"s"}},
+	})
+	folded := FoldDAG(dags[0], map[string]bool{"s": true})
+	if len(folded) != 2 {
+		t.Fatalf("expected 2 DAGs, got %d", len(folded))
+	}
+	if FoldDAG(dags[0], map[string]bool{"s": true, "a": true, "b": true}) != nil {
+		t.Errorf("folding every change should leave nothing")
+	}
+}
+
 // --- Layer 2: Parse + build tests ---
 
 func TestParseChanges_Valid(t *testing.T) {
@@ -214,6 +355,53 @@ func TestParseChanges_Valid(t *testing.T) {
 	}
 }
 
+func TestParseChanges_Author(t *testing.T) {
+	jsonl := `{"change_id":"aaa","commit_id":"c1","description":"first","parent_ids":[],"bookmarks":[],"author":{"name":"Alice","email":"alice@example.com","timestamp":"2026-03-01T12:30:00+01:00"}}`
+	changes, err := ParseChanges([]byte(jsonl))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a := changes[0].Author
+	if a.Name != "Alice" || a.Email != "alice@example.com" {
+		t.Errorf("author = %+v", a)
+	}
+	if want := time.Date(2026, 3, 1, 11, 30, 0, 0, time.UTC); !a.Timestamp.Equal(want) {
+		t.Errorf("timestamp = %v, want %v", a.Timestamp, want)
+	}
+	if _, offset := a.Timestamp.Zone(); offset != 3600 {
+		t.Errorf("the author's time zone must be kept, got offset %d", offset)
+	}
+}
+
+func TestParseChanges_Immutable(t *testing.T) {
+	jsonl := `{"change_id":"aaa","commit_id":"c1","description":"on trunk","immutable":true,"parent_ids":[],"bookmarks":[]}`
+	changes, err := ParseChanges([]byte(jsonl))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !changes[0].Immutable {
+		t.Error("expected the change to be immutable")
+	}
+}
+
+func TestParseChanges_CRLFDescription(t *testing.T) {
+	jsonl := `{"change_id":"aaa","commit_id":"c1","description":"Add thing\r\n\r\nLonger body.\r\n","parent_ids":[],"bookmarks":[]}`
+	changes, err := ParseChanges([]byte(jsonl))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := changes[0]
+	if c.Description != "Add thing\n\nLonger body." {
+		t.Errorf("description = %q", c.Description)
+	}
+	if c.Title() != "Add thing" {
+		t.Errorf("title = %q", c.Title())
+	}
+	if c.Body() != "Longer body." {
+		t.Errorf("body = %q", c.Body())
+	}
+}
+
 func TestParseChanges_MalformedJSON(t *testing.T) {
 	jsonl := `not json at all`
 	_, err := ParseChanges([]byte(jsonl))
@@ -305,6 +493,17 @@ func TestChange_Body_BlankLineSeparator(t *testing.T) {
 	}
 }
 
+func TestChange_Body_ExcludesJipTrailers(t *testing.T) {
+	c := Change{Description: "fix: bug\n\ndetails here\n\nJip-Stack: 1/2\nJip-PR: #4"}
+	if c.Body() != "details here" {
+		t.Errorf("expected body 'details here', got %q", c.Body())
+	}
+	c = Change{Description: "fix: bug\n\nJip-PR: #4"}
+	if c.Body() != "" {
+		t.Errorf("expected empty body, got %q", c.Body())
+	}
+}
+
 func TestChange_Title_Empty(t *testing.T) {
 	c := Change{Description: ""}
 	if c.Title() != "" {
@@ -366,3 +565,21 @@ func assertBefore(t *testing.T, dag *ChangeDAG, before, after string) {
 		t.Errorf("expected %q (pos %d) before %q (pos %d)", before, posB, after, posA)
 	}
 }
+
+func TestChange_Short(t *testing.T) {
+	changes, err := ParseChanges([]byte(`{"change_id":"qpvuntsmwlqtpsluzzsnyyzlmlwvmlnu","short_id":"qpv","commit_id":"abc","description":"x","conflict":false,"parent_ids":[],"bookmarks":[]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := changes[0].Short(); got != "qpv" {
+		t.Errorf("Short() = %q, want jj's short form", got)
+	}
+	// Changes that did not come from jj log fall back to a fixed length.
+	c := Change{ChangeID: "qpvuntsmwlqtpsluzzsnyyzlmlwvmlnu"}
+	if got := c.Short(); got != "qpvuntsmwlqt" {
+		t.Errorf("Short() = %q, want the first 12 characters", got)
+	}
+	if got := (&Change{ChangeID: "abc"}).Short(); got != "abc" {
+		t.Errorf("Short() = %q", got)
+	}
+}
@@ -0,0 +1,26 @@
+package jj
+
+import (
+	"regexp"
+	"strings"
+)
+
+// conflictDescription matches the description jj resolve --list prints
+// after each path, e.g. "2-sided conflict" or "2-sided conflict including 1
+// deletion".
+var conflictDescription = regexp.MustCompile(`\s+\d+-sided conflict.*$`)
+
+// ParseResolveList returns the paths listed by jj resolve --list.
+func ParseResolveList(data []byte) []string {
+	var paths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		path := strings.TrimSpace(conflictDescription.ReplaceAllString(line, ""))
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,19 @@
+package jj
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseResolveList(t *testing.T) {
+	data := []byte("README.md    2-sided conflict\n" +
+		"docs/a file.md    2-sided conflict including 1 deletion\n" +
+		"a-very-long-path/that/overflows/the/column.go 3-sided conflict\n")
+	want := []string{"README.md", "docs/a file.md", "a-very-long-path/that/overflows/the/column.go"}
+	if got := ParseResolveList(data); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := ParseResolveList(nil); got != nil {
+		t.Errorf("got %q for no output, want nil", got)
+	}
+}
@@ -0,0 +1,85 @@
+package jj
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// DiffStat summarizes the size of a diff.
+type DiffStat struct {
+	Files     int
+	Additions int
+	Deletions int
+}
+
+// Lines returns the number of changed lines, added plus deleted.
+func (s DiffStat) Lines() int { return s.Additions + s.Deletions }
+
+// String renders the stat like "+120 −30, 4 files".
+func (s DiffStat) String() string {
+	files := "files"
+	if s.Files == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("+%d −%d, %d %s", s.Additions, s.Deletions, s.Files, files)
+}
+
+// ParseDiffStat counts the files and the added and deleted lines of a
+// git-style diff, as git diff --stat would.
+func ParseDiffStat(diff string) DiffStat {
+	var s DiffStat
+	// Only lines inside hunks are content; "--- " and "+++ " in a file
+	// header name paths.
+	inHunk := false
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			s.Files++
+			inHunk = false
+		case strings.HasPrefix(line, "@@ "):
+			inHunk = true
+		case !inHunk:
+		case strings.HasPrefix(line, "+"):
+			s.Additions++
+		case strings.HasPrefix(line, "-"):
+			s.Deletions++
+		}
+	}
+	return s
+}
+
+// PatchID identifies the change a git-style diff makes, like git patch-id:
+// diffs that add and remove the same lines of the same files have the same
+// ID, whatever their line numbers, context and whitespace. A diff without
+// changed lines has the ID "".
+func PatchID(diff string) string {
+	h := sha1.New()
+	changed := false
+	inHunk := false
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			_, _ = fmt.Fprintln(h, line)
+			inHunk = false
+		case strings.HasPrefix(line, "@@ "):
+			inHunk = true
+		case !inHunk:
+		case strings.HasPrefix(line, "+"), strings.HasPrefix(line, "-"):
+			_, _ = fmt.Fprintln(h, line[:1]+strings.Join(strings.Fields(line[1:]), ""))
+			changed = true
+		}
+	}
+	if !changed {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
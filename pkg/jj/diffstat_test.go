@@ -0,0 +1,74 @@
+package jj
+
+import "testing"
+
+func TestParseDiffStat(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,5 @@
+ package main
+-// old
++// new
++// added
+--- not a header
+diff --git a/new.txt b/new.txt
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++one
++two
+diff --git a/image.png b/image.png
+Binary files a/image.png and b/image.png differ
+`
+	got := ParseDiffStat(diff)
+	want := DiffStat{Files: 3, Additions: 4, Deletions: 2}
+	if got != want {
+		t.Errorf("ParseDiffStat() = %+v, want %+v", got, want)
+	}
+	if s := got.String(); s != "+4 −2, 3 files" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestPatchID(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,5 @@
+ package main
+-// old
++// new
+`
+	// The same change elsewhere in the file, with other context and
+	// indentation.
+	moved := `diff --git a/main.go b/main.go
+index 3333333..4444444 100644
+--- a/main.go
++++ b/main.go
+@@ -10,3 +10,3 @@
+ func f() {}
+-	// old
++	// new
+`
+	other := `diff --git a/other.go b/other.go
+--- a/other.go
++++ b/other.go
+@@ -1,1 +1,1 @@
+-// old
++// new
+`
+	if PatchID(diff) == "" || PatchID(diff) != PatchID(moved) {
+		t.Errorf("PatchID(diff) = %q, PatchID(moved) = %q, want equal", PatchID(diff), PatchID(moved))
+	}
+	if PatchID(diff) == PatchID(other) {
+		t.Error("diffs of different files have the same patch ID")
+	}
+	if got := PatchID(""); got != "" {
+		t.Errorf("PatchID of an empty diff = %q, want empty", got)
+	}
+}
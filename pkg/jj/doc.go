@@ -0,0 +1,34 @@
+// Package jj runs jj (Jujutsu) and parses its output into stacks of changes
+// and bookmarks. It is what jip builds its commands on, and is supported for
+// use by other tools.
+//
+// A Runner executes jj in one repository. ResolveStacks turns revsets into
+// ChangeDAGs, the stacks of changes between a base and the revsets' heads;
+// ParseBookmarkList and MatchBookmarksToChanges relate bookmarks to those
+// changes; EnsureBookmarks gives every change of a stack a bookmark, named by
+// a BookmarkNamer.
+//
+//	runner := jj.NewRunner(repoDir)
+//	dags, err := jj.ResolveStacks(runner, []string{"@-"}, "trunk()")
+//	if err != nil {
+//		return err
+//	}
+//	for _, dag := range dags {
+//		for _, c := range dag.Changes { // parents before children
+//			fmt.Println(c.Short(), c.Title())
+//		}
+//	}
+//
+// # Compatibility
+//
+// The exported API follows semantic versioning with jip's releases: within
+// a major version, exported identifiers are not removed or changed
+// incompatibly. Methods may be added to the Runner interface, so
+// implementations outside this package should embed a Runner (e.g. one from
+// NewRunner) to keep compiling. Struct fields may be added, so use keyed
+// struct literals. api_test.go pins the supported signatures.
+//
+// Failed jj invocations return an *Error, which callers can match against
+// the Err* kinds with errors.Is. jj must be MinVersion or newer; Probe checks
+// it and Locate finds the executable.
+package jj
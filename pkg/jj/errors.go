@@ -0,0 +1,78 @@
+package jj
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kinds of jj failures callers can tell apart with errors.Is. They are
+// recognized from jj's error output, so they only cover the messages jj
+// prints today; anything else is a plain *Error.
+var (
+	// ErrNotARepo means the directory is not inside a jj repository.
+	ErrNotARepo = errors.New("not in a jj repository")
+
+	// ErrRevsetEmpty means a revision or revset matched nothing.
+	ErrRevsetEmpty = errors.New("revision not found")
+
+	// ErrPushRejected means the remote refused the push, typically because
+	// a branch moved there since the last fetch.
+	ErrPushRejected = errors.New("push rejected")
+
+	// ErrImmutableChange means jj refused to rewrite an immutable commit.
+	ErrImmutableChange = errors.New("change is immutable")
+
+	// ErrNotGitBacked means the repository does not use the git backend, so
+	// it has no git remotes to push to.
+	ErrNotGitBacked = errors.New("repository is not backed by git")
+)
+
+// Error is a failed jj invocation.
+type Error struct {
+	Command string // what ran, e.g. "jj git push"
+	Err     error  // the exec error, usually an *exec.ExitError
+	Output  string // jj's error output, trimmed
+	Kind    error  // one of the Err* kinds above, or nil
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v\n%s", e.Command, e.Err, e.Output)
+}
+
+// Unwrap exposes both the kind and the underlying exec error to errors.Is
+// and errors.As.
+func (e *Error) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// newError builds the error for a failed jj command from its output.
+func newError(command string, err error, output string) *Error {
+	output = strings.TrimSpace(output)
+	return &Error{Command: command, Err: err, Output: output, Kind: classify(output)}
+}
+
+// classify maps jj's error output to an error kind, or nil when the failure
+// is not one callers distinguish.
+func classify(output string) error {
+	switch {
+	case strings.Contains(output, "no jj repo"):
+		return ErrNotARepo
+	case strings.Contains(strings.ToLower(output), "not backed by a git repo"):
+		return ErrNotGitBacked
+	case strings.Contains(output, "is immutable"):
+		return ErrImmutableChange
+	case strings.Contains(output, "doesn't exist"),
+		strings.Contains(output, "didn't resolve to any revisions"),
+		strings.Contains(output, "No commit"):
+		return ErrRevsetEmpty
+	case strings.Contains(output, "unexpectedly moved on the remote"),
+		strings.Contains(output, "rejected"),
+		strings.Contains(output, "Failed to push"):
+		return ErrPushRejected
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package jj
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestNewError_Kinds(t *testing.T) {
+	tests := []struct {
+		output string
+		want   error
+	}{
+		{"Error: There is no jj repo in \".\"", ErrNotARepo},
+		{"Error: Revision `zzz` doesn't exist", ErrRevsetEmpty},
+		{`Error: Revset "none()" didn't resolve to any revisions`, ErrRevsetEmpty},
+		{"Error: Commit 3a4b5c6d7e8f is immutable\nHint: Could not modify commit", ErrImmutableChange},
+		{"Error: Refusing to push a bookmark that unexpectedly moved on the remote", ErrPushRejected},
+		{"Error: Failed to push some bookmarks", ErrPushRejected},
+		{"Error: The repo is not backed by a Git repo", ErrNotGitBacked},
+	}
+	for _, tt := range tests {
+		err := newError("jj test", errors.New("exit status 1"), tt.output)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%q: kind = %v, want %v", tt.output, err.Kind, tt.want)
+		}
+	}
+}
+
+func TestNewError_Unclassified(t *testing.T) {
+	err := newError("jj log", errors.New("exit status 1"), "Error: something else\n")
+	if err.Kind != nil {
+		t.Errorf("Kind = %v, want nil", err.Kind)
+	}
+	if got, want := err.Error(), "jj log: exit status 1\nError: something else"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestNewError_UnwrapsExecError(t *testing.T) {
+	exitErr := &exec.ExitError{}
+	err := newError("jj rebase", exitErr, "Error: Commit abc is immutable")
+	var target *exec.ExitError
+	if !errors.As(err, &target) {
+		t.Error("errors.As did not find the *exec.ExitError")
+	}
+	var jerr *Error
+	if !errors.As(err, &jerr) || !strings.HasPrefix(jerr.Command, "jj rebase") {
+		t.Errorf("errors.As(*Error) = %v", jerr)
+	}
+}
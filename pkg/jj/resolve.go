@@ -0,0 +1,134 @@
+package jj
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ResolveBaseBranch resolves a jj revset (e.g. "trunk()", "main" or
+// "main@upstream") to a remote bookmark name suitable for use as a GitHub PR
+// base branch. Among the bookmarks pointing at the resolved commit it prefers
+// one the revset names, directly or through a revset alias such as trunk(),
+// then one on preferredRemote, then any remote, then any local bookmark.
+func ResolveBaseBranch(runner Runner, revset string, bookmarks []BookmarkInfo, preferredRemote string) (string, error) {
+	out, err := runner.Log(revset)
+	if err != nil {
+		return "", fmt.Errorf("resolving base %q: %w", revset, err)
+	}
+	changes, err := ParseChanges(out)
+	if err != nil {
+		return "", fmt.Errorf("parsing base %q: %w", revset, err)
+	}
+	if len(changes) == 0 {
+		return "", fmt.Errorf("base %q resolved to no commits", revset)
+	}
+	if len(changes) > 1 {
+		return "", fmt.Errorf("base %q resolved to %d commits, expected 1", revset, len(changes))
+	}
+	commitID := changes[0].CommitID
+
+	var candidates []string
+	add := func(name string) {
+		if !slices.Contains(candidates, name) {
+			candidates = append(candidates, name)
+		}
+	}
+	for _, b := range bookmarks {
+		if rs, ok := b.Remotes[preferredRemote]; ok && rs.Target == commitID {
+			add(b.Name)
+		}
+	}
+	for _, b := range bookmarks {
+		for _, rs := range b.Remotes {
+			if rs.Target == commitID {
+				add(b.Name)
+			}
+		}
+	}
+	for _, b := range bookmarks {
+		if b.Present && b.Target == commitID {
+			add(b.Name)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("base %q does not match any bookmark — push one to %s or pass --base", revset, preferredRemote)
+	}
+	if len(candidates) > 1 {
+		for _, name := range revsetNames(runner, revset) {
+			if slices.Contains(candidates, name) {
+				return name, nil
+			}
+		}
+	}
+	return candidates[0], nil
+}
+
+// revsetSymbolRe matches the symbols of a revset: bookmark names, optionally
+// followed by @remote, and function names. Quoted strings are matched whole
+// so that names in patterns like exact:"main" are found too.
+var revsetSymbolRe = regexp.MustCompile(`"[^"]*"|[A-Za-z0-9_][A-Za-z0-9_./+-]*(@[A-Za-z0-9_.-]+)?(\(\))?`)
+
+// revsetNames returns the names a revset mentions, in order, with the
+// definitions of the parameterless revset aliases it calls (trunk() and the
+// like) expanded in place. The names are only hints: they need not be
+// bookmarks at all.
+func revsetNames(runner Runner, revset string) []string {
+	return expandRevsetNames(revset, func(alias string) (string, error) {
+		return runner.ConfigGet(fmt.Sprintf("revset-aliases.%q", alias))
+	}, 0)
+}
+
+// maxAliasDepth bounds the expansion of aliases that refer to other aliases.
+const maxAliasDepth = 4
+
+func expandRevsetNames(revset string, alias func(string) (string, error), depth int) []string {
+	var names []string
+	for _, sym := range revsetSymbolRe.FindAllString(revset, -1) {
+		if strings.HasPrefix(sym, `"`) {
+			names = append(names, strings.Trim(sym, `"`))
+			continue
+		}
+		if strings.HasSuffix(sym, "()") {
+			if depth >= maxAliasDepth {
+				continue
+			}
+			if def, err := alias(sym); err == nil {
+				names = append(names, expandRevsetNames(def, alias, depth+1)...)
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(sym, "@")
+		names = append(names, name)
+	}
+	return names
+}
+
+// ResolveStacks resolves one or more revsets against a base branch and returns
+// the changes organized into connected DAGs. Each DAG represents an independent
+// stack of changes between the base and the given revsets.
+func ResolveStacks(runner Runner, revsets []string, base string) ([]*ChangeDAG, error) {
+	if len(revsets) == 0 {
+		return nil, fmt.Errorf("no revsets provided")
+	}
+	if base == "" {
+		return nil, fmt.Errorf("no base revset provided")
+	}
+
+	// Build combined revset: base..(rev1 | rev2 | ...)
+	heads := strings.Join(revsets, " | ")
+	revset := fmt.Sprintf("(%s)..(%s)", base, heads)
+
+	out, err := runner.Log(revset)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := ParseChanges(out)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildDAGs(changes)
+}
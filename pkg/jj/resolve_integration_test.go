@@ -377,3 +377,37 @@ func TestIntegration_CommitExists_BadRepo(t *testing.T) {
 		t.Error("CommitExists with bad repo dir: expected error, got nil")
 	}
 }
+
+func TestIntegration_ResolveBaseBranch_NamedBookmark(t *testing.T) {
+	dir, _ := initJJRepoWithRemote(t)
+	runner := NewRunner(dir)
+
+	// develop and main point at the same commit on the remote.
+	jjRun(t, dir, "bookmark", "create", "develop", "-r", "main")
+	jjRun(t, dir, "git", "push", "--bookmark", "develop")
+	jjRun(t, dir, "config", "set", "--repo", `revset-aliases."trunk()"`, "develop@origin")
+
+	data, err := runner.BookmarkList()
+	if err != nil {
+		t.Fatalf("BookmarkList: %v", err)
+	}
+	bookmarks, err := ParseBookmarkList(data)
+	if err != nil {
+		t.Fatalf("ParseBookmarkList: %v", err)
+	}
+
+	for revset, want := range map[string]string{
+		"main":           "main",
+		"main@origin":    "main",
+		"develop@origin": "develop",
+		"trunk()":        "develop",
+	} {
+		got, err := ResolveBaseBranch(runner, revset, bookmarks, "origin")
+		if err != nil {
+			t.Fatalf("ResolveBaseBranch(%q): %v", revset, err)
+		}
+		if got != want {
+			t.Errorf("ResolveBaseBranch(%q) = %q, want %q", revset, got, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package jj
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestExpandRevsetNames(t *testing.T) {
+	aliases := map[string]string{
+		"trunk()":  "main@upstream",
+		"loop()":   "loop() | develop",
+		"stable()": `latest(present(exact:"release") | trunk())`,
+	}
+	alias := func(name string) (string, error) {
+		if def, ok := aliases[name]; ok {
+			return def, nil
+		}
+		return "", fmt.Errorf("no alias %s", name)
+	}
+	tests := []struct {
+		revset string
+		want   []string
+	}{
+		{"main", []string{"main"}},
+		{"main@upstream", []string{"main"}},
+		{"release/2026@origin", []string{"release/2026"}},
+		{"trunk()", []string{"main"}},
+		{"stable()", []string{"latest", "present", "exact", "release", "main"}},
+		{"unknown()", nil},
+		{"loop()", []string{"develop", "develop", "develop", "develop"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.revset, func(t *testing.T) {
+			got := expandRevsetNames(tt.revset, alias, 0)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expandRevsetNames(%q) = %q, want %q", tt.revset, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,863 @@
+package jj
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omarkohl/jip/internal/retry"
+)
+
+// logTemplate is the jj template that outputs one JSON object per line.
+const logTemplate = "" +
+	`"{" ++` +
+	`"\"change_id\":" ++ json(change_id) ++` +
+	`",\"short_id\":" ++ json(stringify(format_short_change_id(change_id))) ++` +
+	`",\"commit_id\":" ++ json(commit_id) ++` +
+	`",\"description\":" ++ json(description) ++` +
+	`",\"conflict\":" ++ if(conflict, "true", "false") ++` +
+	`",\"empty\":" ++ if(empty, "true", "false") ++` +
+	`",\"immutable\":" ++ if(immutable, "true", "false") ++` +
+	`",\"working_copy\":" ++ if(current_working_copy, "true", "false") ++` +
+	`",\"parent_ids\":[" ++ parents.map(|c| json(c.change_id())).join(",") ++ "]" ++` +
+	`",\"bookmarks\":[" ++ local_bookmarks.map(|r| json(r.name())).join(",") ++ "]" ++` +
+	`",\"author\":{\"name\":" ++ json(author.name()) ++` +
+	`",\"email\":" ++ json(stringify(author.email())) ++` +
+	`",\"timestamp\":" ++ json(author.timestamp().format("%Y-%m-%dT%H:%M:%S%:z")) ++ "}" ++` +
+	`"}\n"`
+
+// bookmarkListTemplate outputs one JSON object per bookmark entry (local or remote).
+// Local entries have remote=null; remote entries have the remote name.
+// The "git" internal remote is filtered out during parsing.
+const bookmarkListTemplate = "" +
+	`"{" ++` +
+	`"\"name\":" ++ json(name) ++` +
+	`",\"remote\":" ++ if(remote, json(remote), "null") ++` +
+	`",\"present\":" ++ if(present, "true", "false") ++` +
+	`",\"conflict\":" ++ if(conflict, "true", "false") ++` +
+	`",\"target\":" ++ if(present && !conflict, json(normal_target.commit_id()), "\"\"") ++` +
+	`",\"change_id\":" ++ if(present && !conflict, json(normal_target.change_id()), "\"\"") ++` +
+	`",\"tracked\":" ++ if(remote && tracked, "true", "false") ++` +
+	`",\"synced\":" ++ if(remote && tracked, if(synced, "true", "false"), "false") ++` +
+	`"}\n"`
+
+// Runner executes jj commands and returns their output.
+type Runner interface {
+	// Log runs jj log with the given revset and returns raw JSONL output.
+	Log(revset string) ([]byte, error)
+
+	// BookmarkList runs jj bookmark list --all-remotes and returns raw JSONL output.
+	BookmarkList() ([]byte, error)
+
+	// BookmarkSet creates or moves a bookmark to the given revision.
+	BookmarkSet(name, rev string) error
+
+	// BookmarkSetEach creates or moves each bookmark of targets to its
+	// revision. jj sets bookmarks on a single revision per invocation, so
+	// this runs jj once per distinct revision, but only the first
+	// invocation snapshots the working copy and the others run
+	// concurrently: a stack of any size takes about as long as two.
+	BookmarkSetEach(targets []BookmarkTarget) error
+
+	// BookmarkSetAll is BookmarkSetEach under its former name.
+	//
+	// Deprecated: use BookmarkSetEach.
+	BookmarkSetAll(targets []BookmarkTarget) error
+
+	// BookmarkReset moves a bookmark to the given revision, also backwards
+	// or sideways.
+	BookmarkReset(name, rev string) error
+
+	// GitRemoteList returns the output of jj git remote list. The runner
+	// returned by NewRunner lists the remotes once and then answers from
+	// that list: jip never changes the remotes.
+	GitRemoteList() ([]byte, error)
+
+	// GitFetch fetches from the given remote: only the branches matching
+	// one of the jj string patterns (e.g. "exact:main", "glob:jip/*") when
+	// any are given, everything otherwise.
+	GitFetch(remote string, branches ...string) error
+
+	// GitPush pushes the given bookmarks. remote optionally specifies the
+	// push target (empty = jj default).
+	GitPush(bookmarks []string, remote string) error
+
+	// Interdiff returns the diff between two revisions using jj interdiff --git.
+	Interdiff(from, to string) (string, error)
+
+	// CommitExists reports whether the given commit/revision is present in the
+	// local repository.
+	CommitExists(rev string) (bool, error)
+
+	// Rebase rebases the given revsets onto the destination revision.
+	// Changes the rebase leaves empty, e.g. because destination already has
+	// them, are abandoned.
+	Rebase(revsets []string, destination string) error
+
+	// Abandon abandons the given revisions; their descendants are rebased
+	// onto their parents.
+	Abandon(revsets []string) error
+
+	// ResolveList returns the output of jj resolve --list for the given
+	// revision: one line per conflicted file. It is empty when the revision
+	// has no conflicts.
+	ResolveList(rev string) ([]byte, error)
+
+	// OpHead returns the ID of the current jj operation.
+	OpHead() (string, error)
+
+	// OpRestore restores the repository to the given jj operation.
+	OpRestore(id string) error
+
+	// Describe replaces the description of the given revision. jj rebases
+	// its descendants onto the rewritten commit.
+	Describe(rev, message string) error
+
+	// Squash moves the changes in the from revisions into the into revision
+	// and sets its description to message. Emptied sources are abandoned.
+	Squash(from []string, into, message string) error
+
+	// Split moves the changes rev makes to paths (relative to the repository
+	// root) into a new commit described by message, which becomes the parent
+	// of the rest of rev.
+	Split(rev string, paths []string, message string) error
+
+	// Duplicate creates copies of the changes in revsets on top of
+	// destination, keeping their descriptions and their order.
+	Duplicate(revsets []string, destination string) error
+
+	// BookmarkDelete deletes the given local bookmarks. The deletion reaches
+	// the remote with the next push of those bookmarks.
+	BookmarkDelete(names []string) error
+
+	// BookmarkTrack starts tracking the given bookmarks on remote, creating
+	// local bookmarks at the remote targets.
+	BookmarkTrack(names []string, remote string) error
+
+	// New creates an empty change on top of rev and makes it the working
+	// copy.
+	New(rev string) error
+
+	// Diff returns the changes of the given revision as a git-style diff.
+	Diff(rev string) (string, error)
+
+	// Annotate runs jj file annotate on path (relative to the repository
+	// root) at the given revision and returns one "<commit id> <line
+	// number>" line per line of the file.
+	Annotate(rev, path string) ([]byte, error)
+
+	// ConfigGet returns the value of a jj configuration key.
+	// Returns an error if the key is not set.
+	ConfigGet(key string) (string, error)
+
+	// ConfigSetRepo sets a jj configuration key in the repository's config.
+	ConfigSetRepo(key, value string) error
+}
+
+// NewRunner creates a Runner that executes jj in the given repository directory.
+func NewRunner(repoDir string) Runner {
+	return NewRunnerContext(context.Background(), repoDir)
+}
+
+// NewRunnerContext is NewRunner with jj commands that ctx cancels: a running
+// jj is interrupted, and retries stop waiting.
+func NewRunnerContext(ctx context.Context, repoDir string) Runner {
+	return &realRunner{repoDir: repoDir, ctx: ctx, remotes: new(remoteList)}
+}
+
+// WithoutSnapshot returns a runner whose jj commands pass
+// --ignore-working-copy, for read-only commands such as Log and BookmarkList
+// run alongside others once the working copy has been snapshotted: jj
+// commands that snapshot it must not run at the same time. Runners not made
+// by NewRunner are returned as they are.
+func WithoutSnapshot(r Runner) Runner {
+	rr, ok := r.(*realRunner)
+	if !ok {
+		return r
+	}
+	copied := *rr
+	copied.ignoreWorkingCopy = true
+	return &copied
+}
+
+// WorkspaceRoot returns the root directory of the jj workspace containing
+// dir, or "" if dir is not inside a jj workspace. It runs `jj root` with dir
+// as the working directory because -R does not search parent directories.
+func WorkspaceRoot(dir string) (string, error) {
+	args := []string{"root"}
+	logCmd("jj", args)
+	cmd := command(args)
+	cmd.Dir = dir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := output(cmd)
+	if err != nil {
+		stderrStr := strings.TrimSpace(stderr.String())
+		if strings.Contains(stderrStr, "no jj repo") {
+			return "", nil
+		}
+		return "", newError("jj root", err, stderrStr)
+	}
+	return filepath.Clean(strings.TrimSpace(string(out))), nil
+}
+
+// A realRunner is safe for concurrent use, but jj commands that snapshot the
+// working copy, which all do unless ignoreWorkingCopy is set, must not run at
+// the same time.
+type realRunner struct {
+	repoDir           string
+	ctx               context.Context
+	ignoreWorkingCopy bool        // see WithoutSnapshot
+	remotes           *remoteList // shared with the runners WithoutSnapshot makes
+}
+
+// remoteList caches the output of GitRemoteList.
+type remoteList struct {
+	once sync.Once
+	out  []byte
+	err  error
+}
+
+func (r *realRunner) Log(revset string) ([]byte, error) {
+	args := []string{
+		"log",
+		"--no-graph",
+		"-R", r.repoDir,
+		"-r", revset,
+		"-T", logTemplate,
+	}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := output(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)), "stderr", strings.TrimSpace(stderr.String()))
+		return nil, newError("jj log", err, stderr.String())
+	}
+	if s := strings.TrimSpace(stderr.String()); s != "" {
+		slog.Debug("jj log stderr", "stderr", s)
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return out, nil
+}
+
+func (r *realRunner) BookmarkList() ([]byte, error) {
+	args := []string{
+		"bookmark", "list",
+		"--all-remotes",
+		"--quiet",
+		"-R", r.repoDir,
+		"-T", bookmarkListTemplate,
+	}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := output(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)), "stderr", strings.TrimSpace(stderr.String()))
+		return nil, newError("jj bookmark list", err, stderr.String())
+	}
+	if s := strings.TrimSpace(stderr.String()); s != "" {
+		slog.Debug("jj bookmark list stderr", "stderr", s)
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return out, nil
+}
+
+func (r *realRunner) BookmarkSet(name, rev string) error {
+	args := []string{
+		"bookmark", "set",
+		"-R", r.repoDir,
+		name,
+		"-r", rev,
+	}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return newError("jj bookmark set", err, string(out))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return nil
+}
+
+// bookmarkSetParallel bounds the jj bookmark set invocations
+// BookmarkSetEach runs at once.
+const bookmarkSetParallel = 8
+
+func (r *realRunner) BookmarkSetEach(targets []BookmarkTarget) error {
+	groups := groupBookmarkTargets(targets)
+	if len(groups) == 0 {
+		return nil
+	}
+	// The first invocation snapshots the working copy. Setting bookmarks
+	// rewrites no commit, so the others need not, and jj merges the
+	// operations they record concurrently.
+	if err := r.bookmarkSetGroup(groups[0]); err != nil {
+		return err
+	}
+	rest := WithoutSnapshot(r).(*realRunner)
+	errs := make([]error, len(groups))
+	sem := make(chan struct{}, bookmarkSetParallel)
+	var wg sync.WaitGroup
+	for i, g := range groups[1:] {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i+1] = rest.bookmarkSetGroup(g)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (r *realRunner) BookmarkSetAll(targets []BookmarkTarget) error {
+	return r.BookmarkSetEach(targets)
+}
+
+// bookmarkSetGroup sets the bookmarks of g in one jj bookmark set.
+func (r *realRunner) bookmarkSetGroup(g bookmarkGroup) error {
+	args := []string{"bookmark", "set", "-R", r.repoDir}
+	args = append(args, g.names...)
+	args = append(args, "-r", g.rev)
+	logCmd("jj", args)
+	cmd := r.command(args)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return fmt.Errorf("setting %s: %w", strings.Join(g.names, ", "), newError("jj bookmark set", err, string(out)))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return nil
+}
+
+// bookmarkGroup is the bookmarks one jj bookmark set invocation sets.
+type bookmarkGroup struct {
+	names []string
+	rev   string
+}
+
+// groupBookmarkTargets groups targets by revision, in the order each
+// revision first appears. Bookmarks on the same revision share an
+// invocation.
+func groupBookmarkTargets(targets []BookmarkTarget) []bookmarkGroup {
+	var groups []bookmarkGroup
+	index := make(map[string]int)
+	for _, t := range targets {
+		i, ok := index[t.Rev]
+		if !ok {
+			i = len(groups)
+			index[t.Rev] = i
+			groups = append(groups, bookmarkGroup{rev: t.Rev})
+		}
+		groups[i].names = append(groups[i].names, t.Name)
+	}
+	return groups
+}
+
+func (r *realRunner) BookmarkReset(name, rev string) error {
+	args := []string{
+		"bookmark", "set",
+		"-R", r.repoDir,
+		"--allow-backwards",
+		name,
+		"-r", rev,
+	}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return newError("jj bookmark set", err, string(out))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return nil
+}
+
+func (r *realRunner) GitRemoteList() ([]byte, error) {
+	r.remotes.once.Do(func() {
+		args := []string{"git", "remote", "list", "-R", r.repoDir}
+		logCmd("jj", args)
+		cmd := r.command(args)
+		out, err := combinedOutput(cmd)
+		if err != nil {
+			slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+			r.remotes.err = newError("jj git remote list", err, string(out))
+			return
+		}
+		slog.Debug("jj exec ok", "bytes", len(out))
+		r.remotes.out = out
+	})
+	return r.remotes.out, r.remotes.err
+}
+
+func (r *realRunner) GitFetch(remote string, branches ...string) error {
+	return retry.DoContext(r.ctx, func(context.Context) error {
+		args := []string{"git", "fetch", "-R", r.repoDir, "--remote", remote}
+		for _, b := range branches {
+			args = append(args, "--branch", b)
+		}
+		logCmd("jj", args)
+		cmd := r.command(args)
+		out, err := combinedOutput(cmd)
+		if err != nil {
+			slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+			return newError("jj git fetch", err, string(out))
+		}
+		slog.Debug("jj exec ok", "bytes", len(out))
+		return nil
+	})
+}
+
+func (r *realRunner) GitPush(bookmarks []string, remote string) error {
+	// A rejected push fails the same way on every attempt, so it is
+	// returned right away instead of being retried.
+	var rejected error
+	err := retry.DoContext(r.ctx, func(context.Context) error {
+		args := []string{"git", "push", "-R", r.repoDir}
+		if remote != "" {
+			args = append(args, "--remote", remote)
+		}
+		for _, b := range bookmarks {
+			args = append(args, "-b", b)
+		}
+		logCmd("jj", args)
+		cmd := r.command(args)
+		out, err := combinedOutput(cmd)
+		if err != nil {
+			slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+			jerr := newError("jj git push", err, string(out))
+			if errors.Is(jerr, ErrPushRejected) {
+				rejected = jerr
+				return nil
+			}
+			return jerr
+		}
+		slog.Debug("jj exec ok", "bytes", len(out))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return rejected
+}
+
+func (r *realRunner) Interdiff(from, to string) (string, error) {
+	args := []string{
+		"interdiff", "--git",
+		"-R", r.repoDir,
+		"--from", from,
+		"--to", to,
+	}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return "", newError("jj interdiff", err, string(out))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return string(out), nil
+}
+
+func (r *realRunner) CommitExists(rev string) (bool, error) {
+	// Resolve the revision to a single commit. A well-formed hash that isn't in
+	// the repo makes jj exit non-zero with "doesn't exist" / "No commit".
+	args := []string{
+		"log", "--no-graph", "--quiet",
+		"-R", r.repoDir,
+		"-r", rev,
+		"-T", `commit_id ++ "\n"`,
+	}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := output(cmd)
+	if err != nil {
+		stderrStr := strings.TrimSpace(stderr.String())
+		if isCommitNotFoundError(stderrStr) {
+			slog.Debug("CommitExists: not present locally", "rev", rev, "stderr", stderrStr)
+			return false, nil
+		}
+		slog.Debug("CommitExists: jj failed", "rev", rev, "stderr", stderrStr)
+		return false, newError("jj log "+rev, err, stderrStr)
+	}
+	return strings.TrimSpace(string(out)) != "", nil
+}
+
+// isCommitNotFoundError reports whether stderr text from a failed jj log
+// command indicates the revision simply isn't present in the repo (as opposed
+// to an operational failure like a corrupt repo or jj binary issue).
+func isCommitNotFoundError(stderr string) bool {
+	return classify(stderr) == ErrRevsetEmpty
+}
+
+func (r *realRunner) ConfigGet(key string) (string, error) {
+	args := []string{"config", "get", "-R", r.repoDir, key}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := output(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "stderr", strings.TrimSpace(stderr.String()))
+		return "", newError("jj config get "+key, err, stderr.String())
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (r *realRunner) ConfigSetRepo(key, value string) error {
+	args := []string{"config", "set", "--repo", "-R", r.repoDir, key, value}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return newError("jj config set "+key, err, string(out))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return nil
+}
+
+func (r *realRunner) Rebase(revsets []string, destination string) error {
+	args := []string{"rebase", "-R", r.repoDir, "--skip-emptied", "-d", destination}
+	for _, rev := range revsets {
+		args = append(args, "-b", rev)
+	}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return newError("jj rebase", err, string(out))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return nil
+}
+
+func (r *realRunner) Abandon(revsets []string) error {
+	args := append([]string{"abandon", "-R", r.repoDir}, revsets...)
+	logCmd("jj", args)
+	cmd := r.command(args)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return newError("jj abandon", err, string(out))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return nil
+}
+
+func (r *realRunner) ResolveList(rev string) ([]byte, error) {
+	args := []string{"resolve", "--list", "-R", r.repoDir, "-r", rev}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := output(cmd)
+	if err != nil {
+		stderrStr := strings.TrimSpace(stderr.String())
+		if strings.Contains(stderrStr, "No conflicts found") {
+			return nil, nil
+		}
+		slog.Debug("jj exec failed", "err", err, "stderr", stderrStr)
+		return nil, newError("jj resolve --list", err, stderrStr)
+	}
+	return out, nil
+}
+
+func (r *realRunner) OpHead() (string, error) {
+	args := []string{"op", "log", "--no-graph", "-R", r.repoDir, "-n", "1", "-T", "id"}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := output(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "stderr", strings.TrimSpace(stderr.String()))
+		return "", newError("jj op log", err, stderr.String())
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (r *realRunner) OpRestore(id string) error {
+	args := []string{"op", "restore", "-R", r.repoDir, id}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return newError("jj op restore", err, string(out))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return nil
+}
+
+// command returns the exec.Cmd that runs jj with args.
+func command(args []string) *exec.Cmd {
+	return exec.Command(jjBin, args...)
+}
+
+// command returns the exec.Cmd that runs jj with args until the runner's
+// context is cancelled. jj is then interrupted like by Ctrl-C, so it can
+// finish its current operation, and killed if it has not exited after a
+// few seconds.
+func (r *realRunner) command(args []string) *exec.Cmd {
+	if r.ignoreWorkingCopy {
+		args = append([]string{"--ignore-working-copy"}, args...)
+	}
+	cmd := exec.CommandContext(r.ctx, jjBin, args...)
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = 5 * time.Second
+	return cmd
+}
+
+// output runs cmd like cmd.Output and traces it.
+func output(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	out, err := cmd.Output()
+	traceCmd(cmd, start, err)
+	return out, err
+}
+
+// combinedOutput runs cmd like cmd.CombinedOutput and traces it.
+func combinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	traceCmd(cmd, start, err)
+	return out, err
+}
+
+// traceCmd logs a finished jj invocation at info level, which --verbose
+// turns on: its arguments, how long it took and its exit code (-1 when jj
+// could not be started).
+func traceCmd(cmd *exec.Cmd, start time.Time, err error) {
+	exit := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exit = exitErr.ExitCode()
+	} else if err != nil {
+		exit = -1
+	}
+	slog.Info("jj", "args", strings.Join(cmd.Args[1:], " "),
+		"duration", time.Since(start).Round(time.Millisecond), "exit", exit)
+}
+
+// cmdLog receives logCmd's output.
+var cmdLog io.Writer = os.Stderr
+
+// SetCommandLog redirects the copy-pasteable commands printed in debug mode,
+// e.g. to the --log-file.
+func SetCommandLog(w io.Writer) {
+	cmdLog = w
+}
+
+// debugEnabled reports whether debug-level logging is active.
+func debugEnabled() bool {
+	return slog.Default().Handler().Enabled(context.Background(), slog.LevelDebug)
+}
+
+// logCmd prints a command, copy-pasteable into the platform's shell, to
+// stderr when debug logging is enabled. It writes directly to stderr
+// (bypassing slog) because slog.TextHandler escapes backslashes and quotes
+// inside values, which makes the output uncopyable.
+func logCmd(prog string, args []string) {
+	if !debugEnabled() {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(prog)
+	for _, a := range args {
+		b.WriteByte(' ')
+		b.WriteString(quoteArg(a, runtime.GOOS))
+	}
+	_, _ = fmt.Fprintf(cmdLog, "DEBUG $ %s\n", b.String())
+}
+
+// quoteArg quotes a for a POSIX shell, or for PowerShell on Windows, where
+// single quotes are literal too but are escaped by doubling them.
+func quoteArg(a, goos string) string {
+	if goos == "windows" {
+		if a == "" || strings.ContainsAny(a, " \t\n\"'|&;()<>$`{}@,#") {
+			return "'" + strings.ReplaceAll(a, "'", "''") + "'"
+		}
+		return a
+	}
+	if a == "" || strings.ContainsAny(a, " \t\n\"'\\|&;()<>$`!{}*?[]#~") {
+		return "'" + strings.ReplaceAll(a, "'", "'\\''") + "'"
+	}
+	return a
+}
+
+// ParseRemoteList parses the output of jj git remote list into a map
+// of remote name → URL.
+func ParseRemoteList(data []byte) map[string]string {
+	remotes := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) == 2 {
+			remotes[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	return remotes
+}
+
+func (r *realRunner) Describe(rev, message string) error {
+	args := []string{"describe", "-R", r.repoDir, "--stdin", rev}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	cmd.Stdin = strings.NewReader(message)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return newError("jj describe", err, string(out))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return nil
+}
+
+func (r *realRunner) Squash(from []string, into, message string) error {
+	args := []string{
+		"squash", "-R", r.repoDir,
+		"--from", strings.Join(from, " | "),
+		"--into", into,
+		"--message", message,
+	}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return newError("jj squash", err, string(out))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return nil
+}
+
+func (r *realRunner) Split(rev string, paths []string, message string) error {
+	args := []string{"split", "-R", r.repoDir, "-r", rev, "--message", message, "--"}
+	for _, p := range paths {
+		args = append(args, "root-file:"+strconv.Quote(p))
+	}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return newError("jj split", err, string(out))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return nil
+}
+
+func (r *realRunner) Duplicate(revsets []string, destination string) error {
+	args := append([]string{"duplicate", "-R", r.repoDir, "-d", destination}, revsets...)
+	logCmd("jj", args)
+	cmd := r.command(args)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return newError("jj duplicate", err, string(out))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return nil
+}
+
+func (r *realRunner) BookmarkDelete(names []string) error {
+	args := append([]string{"bookmark", "delete", "-R", r.repoDir}, names...)
+	logCmd("jj", args)
+	cmd := r.command(args)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return newError("jj bookmark delete", err, string(out))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return nil
+}
+
+func (r *realRunner) BookmarkTrack(names []string, remote string) error {
+	args := []string{"bookmark", "track", "-R", r.repoDir}
+	for _, name := range names {
+		args = append(args, name+"@"+remote)
+	}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return newError("jj bookmark track", err, string(out))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return nil
+}
+
+func (r *realRunner) New(rev string) error {
+	args := []string{"new", "-R", r.repoDir, rev}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	out, err := combinedOutput(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "output", strings.TrimSpace(string(out)))
+		return newError("jj new", err, string(out))
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return nil
+}
+
+func (r *realRunner) Diff(rev string) (string, error) {
+	args := []string{"diff", "--git", "-R", r.repoDir, "-r", rev}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := output(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "stderr", strings.TrimSpace(stderr.String()))
+		return "", newError("jj diff", err, stderr.String())
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return string(out), nil
+}
+
+func (r *realRunner) Annotate(rev, path string) ([]byte, error) {
+	args := []string{
+		"file", "annotate",
+		"-R", r.repoDir,
+		"-r", rev,
+		"-T", annotateTemplate,
+		path,
+	}
+	logCmd("jj", args)
+	cmd := r.command(args)
+	cmd.Dir = r.repoDir // the path is relative to the repository root
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := output(cmd)
+	if err != nil {
+		slog.Debug("jj exec failed", "err", err, "stderr", strings.TrimSpace(stderr.String()))
+		return nil, newError("jj file annotate", err, stderr.String())
+	}
+	slog.Debug("jj exec ok", "bytes", len(out))
+	return out, nil
+}
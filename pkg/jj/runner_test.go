@@ -0,0 +1,254 @@
+package jj
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseRemoteList_Basic(t *testing.T) {
+	data := "origin https://github.com/owner/repo.git\n"
+	remotes := ParseRemoteList([]byte(data))
+	if len(remotes) != 1 {
+		t.Fatalf("expected 1 remote, got %d", len(remotes))
+	}
+	if remotes["origin"] != "https://github.com/owner/repo.git" {
+		t.Errorf("expected origin URL, got %q", remotes["origin"])
+	}
+}
+
+func TestParseRemoteList_MultipleRemotes(t *testing.T) {
+	data := "origin git@github.com:owner/repo.git\nupstream https://github.com/upstream/repo.git\n"
+	remotes := ParseRemoteList([]byte(data))
+	if len(remotes) != 2 {
+		t.Fatalf("expected 2 remotes, got %d", len(remotes))
+	}
+	if remotes["origin"] != "git@github.com:owner/repo.git" {
+		t.Errorf("origin: got %q", remotes["origin"])
+	}
+	if remotes["upstream"] != "https://github.com/upstream/repo.git" {
+		t.Errorf("upstream: got %q", remotes["upstream"])
+	}
+}
+
+func TestParseRemoteList_Empty(t *testing.T) {
+	remotes := ParseRemoteList([]byte(""))
+	if len(remotes) != 0 {
+		t.Errorf("expected 0 remotes, got %d", len(remotes))
+	}
+}
+
+func TestParseRemoteList_BlankLines(t *testing.T) {
+	data := "origin https://example.com/repo.git\n\n\n"
+	remotes := ParseRemoteList([]byte(data))
+	if len(remotes) != 1 {
+		t.Fatalf("expected 1 remote, got %d", len(remotes))
+	}
+}
+
+func TestTraceCmd(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(old *slog.Logger) { slog.SetDefault(old) }(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	cmd, args := exec.Command("sh", "-c", "exit 3"), `args="-c exit 3"`
+	if runtime.GOOS == "windows" {
+		cmd, args = exec.Command("cmd", "/c", "exit 3"), `args="/c exit 3"`
+	}
+	_, err := combinedOutput(cmd)
+	if err == nil {
+		t.Fatal("expected exit error")
+	}
+	out := buf.String()
+	for _, want := range []string{"msg=jj", args, "exit=3", "duration="} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestQuoteArg(t *testing.T) {
+	tests := []struct {
+		arg, goos, want string
+	}{
+		{"log", "linux", "log"},
+		{"", "linux", "''"},
+		{"trunk()..@", "linux", "'trunk()..@'"},
+		{"it's", "darwin", `'it'\''s'`},
+		{`C:\repo`, "linux", `'C:\repo'`},
+		{"log", "windows", "log"},
+		{"", "windows", "''"},
+		{"trunk()..@", "windows", "'trunk()..@'"},
+		{"it's", "windows", "'it''s'"},
+		{`C:\repo`, "windows", `C:\repo`},
+		{`C:\My Repo`, "windows", `'C:\My Repo'`},
+	}
+	for _, tt := range tests {
+		if got := quoteArg(tt.arg, tt.goos); got != tt.want {
+			t.Errorf("quoteArg(%q, %s) = %s, want %s", tt.arg, tt.goos, got, tt.want)
+		}
+	}
+}
+
+func TestGitRemoteListIsCached(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake jj is a shell script")
+	}
+	defer func(old string) { jjBin = old }(jjBin)
+
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	jjBin = filepath.Join(dir, "jj")
+	script := "#!/bin/sh\necho x >> " + calls + "\necho 'origin https://github.com/owner/repo.git'\n"
+	if err := os.WriteFile(jjBin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := NewRunner(dir)
+	for range 3 {
+		out, err := runner.GitRemoteList()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ParseRemoteList(out)["origin"] != "https://github.com/owner/repo.git" {
+			t.Errorf("GitRemoteList = %q", out)
+		}
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "x"); n != 1 {
+		t.Errorf("jj git remote list ran %d times, want 1", n)
+	}
+}
+
+func TestGroupBookmarkTargets(t *testing.T) {
+	got := groupBookmarkTargets([]BookmarkTarget{
+		{Name: "a", Rev: "x"},
+		{Name: "b", Rev: "y"},
+		{Name: "c", Rev: "x"},
+	})
+	want := []bookmarkGroup{{names: []string{"a", "c"}, rev: "x"}, {names: []string{"b"}, rev: "y"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupBookmarkTargets = %+v, want %+v", got, want)
+	}
+}
+
+// fakeJJ points jjBin at a script that records each invocation's arguments
+// in the returned file, until the test ends.
+func fakeJJ(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake jj is a shell script")
+	}
+	old := jjBin
+	t.Cleanup(func() { jjBin = old })
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	jjBin = filepath.Join(dir, "jj")
+	if err := os.WriteFile(jjBin, []byte("#!/bin/sh\necho \"$*\" >> "+calls+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return calls
+}
+
+func TestBookmarkSetEach(t *testing.T) {
+	calls := fakeJJ(t)
+	err := NewRunner("/repo").BookmarkSetEach([]BookmarkTarget{
+		{Name: "jip/a", Rev: "aaaa"},
+		{Name: "jip/b", Rev: "bbbb"},
+		{Name: "other", Rev: "aaaa"},
+		{Name: "jip/c", Rev: "cccc"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One invocation per revision. Only the first snapshots; the others
+	// run concurrently, in no particular order.
+	got := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	slices.Sort(got[1:])
+	want := []string{
+		"bookmark set -R /repo jip/a other -r aaaa",
+		"--ignore-working-copy bookmark set -R /repo jip/b -r bbbb",
+		"--ignore-working-copy bookmark set -R /repo jip/c -r cccc",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("jj ran with\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestEnsureBookmarksInvocations(t *testing.T) {
+	calls := fakeJJ(t)
+	dag := &ChangeDAG{ByID: make(map[string]*Change)}
+	for i := range 30 {
+		id := fmt.Sprintf("change%02d", i)
+		c := &Change{ChangeID: id, ShortID: id, Description: "feat: " + id}
+		dag.Changes = append(dag.Changes, c)
+		dag.ByID[id] = c
+	}
+	results, err := EnsureBookmarks(NewRunner("/repo"), dag, nil, "origin", nil, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 30 {
+		t.Fatalf("got %d bookmarks, want 30", len(results))
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	snapshots := 0
+	for _, l := range lines {
+		if !strings.HasPrefix(l, "--ignore-working-copy ") {
+			snapshots++
+		}
+	}
+	if len(lines) != 30 || snapshots != 1 {
+		t.Errorf("jj ran %d times, %d of them snapshotting; want 30 and 1:\n%s", len(lines), snapshots, data)
+	}
+}
+
+func TestWithoutSnapshot(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake jj is a shell script")
+	}
+	defer func(old string) { jjBin = old }(jjBin)
+
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	jjBin = filepath.Join(dir, "jj")
+	script := "#!/bin/sh\necho \"$1 $2\" >> " + calls + "\n"
+	if err := os.WriteFile(jjBin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := NewRunner(dir)
+	if _, err := runner.BookmarkList(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WithoutSnapshot(runner).BookmarkList(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || strings.Contains(lines[0], "--ignore-working-copy") || lines[1] != "--ignore-working-copy bookmark" {
+		t.Errorf("jj ran with:\n%s", data)
+	}
+}
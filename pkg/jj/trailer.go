@@ -0,0 +1,197 @@
+package jj
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TrailerPrefix starts the key of every git trailer jip writes into commit
+// descriptions (Jip-Stack, Jip-PR, …).
+const TrailerPrefix = "Jip-"
+
+// OverridePrefix starts the keys of the trailers users write to customize a
+// change's PR (PR-Title, PR-Draft, PR-Reviewers). Like git, jip matches
+// trailer keys case-insensitively.
+const OverridePrefix = "PR-"
+
+// Trailer is a single "Key: value" git trailer.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// WithTrailers returns desc with jip's trailers replaced by trailers. Existing
+// trailers with the TrailerPrefix are removed first; other trailers (e.g.
+// Signed-off-by) are kept, and the new ones are appended to the same block so
+// `git interpret-trailers` sees a single trailer paragraph. With no trailers
+// the result is desc with jip's trailers stripped.
+func WithTrailers(desc string, trailers []Trailer) string {
+	desc = StripTrailers(desc)
+	if len(trailers) == 0 {
+		return desc
+	}
+	lines := make([]string, len(trailers))
+	for i, t := range trailers {
+		lines[i] = t.Key + ": " + t.Value
+	}
+	block := strings.Join(lines, "\n")
+
+	if desc == "" {
+		return block
+	}
+	// Join the existing trailer paragraph, if the description ends with one.
+	// The title alone is never a trailer block.
+	if i := strings.LastIndex(desc, "\n\n"); i >= 0 && isTrailerBlock(desc[i+2:]) {
+		return desc + "\n" + block
+	}
+	return desc + "\n\n" + block
+}
+
+// StripTrailers removes jip's trailers (keys starting with TrailerPrefix)
+// from the last paragraph of desc, along with the paragraph itself when
+// nothing else is left in it.
+func StripTrailers(desc string) string {
+	return stripTrailers(desc, func(line string) bool { return strings.HasPrefix(line, TrailerPrefix) })
+}
+
+// StripOverrides removes the PR override trailers (keys starting with
+// OverridePrefix) like StripTrailers removes jip's own.
+func StripOverrides(desc string) string {
+	return stripTrailers(desc, isOverride)
+}
+
+func isOverride(line string) bool {
+	return len(line) >= len(OverridePrefix) && strings.EqualFold(line[:len(OverridePrefix)], OverridePrefix)
+}
+
+func stripTrailers(desc string, drop func(line string) bool) string {
+	desc = strings.TrimRight(desc, "\n")
+	i := strings.LastIndex(desc, "\n\n")
+	if i < 0 || !isTrailerBlock(desc[i+2:]) {
+		return desc
+	}
+	var kept []string
+	for _, line := range strings.Split(desc[i+2:], "\n") {
+		if !drop(line) {
+			kept = append(kept, line)
+		}
+	}
+	if len(kept) == 0 {
+		return strings.TrimRight(desc[:i], "\n")
+	}
+	return desc[:i+2] + strings.Join(kept, "\n")
+}
+
+// WithOverride returns desc with the PR override trailer key (e.g. PR-Title)
+// set to value, replacing the one desc has.
+func WithOverride(desc, key, value string) string {
+	desc = stripTrailers(desc, func(line string) bool {
+		k, _, _ := strings.Cut(line, ":")
+		return strings.EqualFold(strings.TrimSpace(k), key)
+	})
+	line := key + ": " + value
+	if i := strings.LastIndex(desc, "\n\n"); i >= 0 && isTrailerBlock(desc[i+2:]) {
+		return desc + "\n" + line
+	}
+	return desc + "\n\n" + line
+}
+
+// Overrides are the per-change PR settings from PR-* trailers. Zero values
+// mean the trailer is absent and the flags decide.
+type Overrides struct {
+	Title     string   // PR-Title: the PR title instead of the commit subject
+	Draft     *bool    // PR-Draft: true or false, overriding --draft
+	Reviewers []string // PR-Reviewers: comma-separated, added to --reviewer
+}
+
+// ParseOverrides reads the PR-* trailers from the last paragraph of desc.
+// Unknown PR-* keys and invalid values are reported in the error; the valid
+// ones are still returned.
+func ParseOverrides(desc string) (Overrides, error) {
+	var o Overrides
+	desc = strings.TrimRight(desc, "\n")
+	i := strings.LastIndex(desc, "\n\n")
+	if i < 0 || !isTrailerBlock(desc[i+2:]) {
+		return o, nil
+	}
+	var errs []string
+	for _, line := range strings.Split(desc[i+2:], "\n") {
+		if !isOverride(line) {
+			continue
+		}
+		key, value, _ := strings.Cut(line, ": ")
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(key) {
+		case "pr-title":
+			o.Title = value
+		case "pr-draft":
+			draft, err := strconv.ParseBool(value)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %q is not true or false", key, value))
+				continue
+			}
+			o.Draft = &draft
+		case "pr-reviewers":
+			for _, r := range strings.Split(value, ",") {
+				if r = strings.TrimSpace(r); r != "" {
+					o.Reviewers = append(o.Reviewers, r)
+				}
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("unknown trailer %s", key))
+		}
+	}
+	if len(errs) > 0 {
+		return o, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return o, nil
+}
+
+// SkipTrailer is the trailer that keeps a change from getting a PR of its
+// own ("Jip-Skip: true"); SkipMarker does the same anywhere in the
+// description.
+const (
+	SkipTrailer = "Jip-Skip"
+	SkipMarker  = "[skip pr]"
+)
+
+// SkipsPR reports whether desc asks for its change to get no PR of its own,
+// with a true SkipTrailer in its last paragraph or SkipMarker anywhere. Both
+// are matched case-insensitively.
+func SkipsPR(desc string) bool {
+	if strings.Contains(strings.ToLower(desc), SkipMarker) {
+		return true
+	}
+	desc = strings.TrimRight(desc, "\n")
+	i := strings.LastIndex(desc, "\n\n")
+	if i < 0 || !isTrailerBlock(desc[i+2:]) {
+		return false
+	}
+	for _, line := range strings.Split(desc[i+2:], "\n") {
+		key, value, _ := strings.Cut(line, ": ")
+		if strings.EqualFold(key, SkipTrailer) {
+			skip, err := strconv.ParseBool(strings.TrimSpace(value))
+			return err == nil && skip
+		}
+	}
+	return false
+}
+
+// isTrailerBlock reports whether every line of para looks like a git trailer
+// ("Token: value", where the token is letters, digits and dashes).
+func isTrailerBlock(para string) bool {
+	if strings.TrimSpace(para) == "" {
+		return false
+	}
+	for _, line := range strings.Split(para, "\n") {
+		key, _, ok := strings.Cut(line, ": ")
+		if !ok || key == "" || strings.IndexFunc(key, func(r rune) bool {
+			return r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) >= 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+package jj
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithTrailers(t *testing.T) {
+	trailers := []Trailer{{Key: "Jip-Stack", Value: "2/3"}, {Key: "Jip-PR", Value: "#12"}}
+	tests := []struct {
+		name string
+		desc string
+		want string
+	}{
+		{
+			name: "title only",
+			desc: "feat: add X",
+			want: "feat: add X\n\nJip-Stack: 2/3\nJip-PR: #12",
+		},
+		{
+			name: "title and body",
+			desc: "feat: add X\n\nSome explanation.",
+			want: "feat: add X\n\nSome explanation.\n\nJip-Stack: 2/3\nJip-PR: #12",
+		},
+		{
+			name: "joins existing trailer block",
+			desc: "feat: add X\n\nBody.\n\nSigned-off-by: A <a@example.com>",
+			want: "feat: add X\n\nBody.\n\nSigned-off-by: A <a@example.com>\nJip-Stack: 2/3\nJip-PR: #12",
+		},
+		{
+			name: "replaces old jip trailers",
+			desc: "feat: add X\n\nBody.\n\nJip-Stack: 1/2\nJip-PR: #9",
+			want: "feat: add X\n\nBody.\n\nJip-Stack: 2/3\nJip-PR: #12",
+		},
+		{
+			name: "prose with a colon is not a trailer block",
+			desc: "feat: add X\n\nThe cause was simple: a missing nil check.",
+			want: "feat: add X\n\nThe cause was simple: a missing nil check.\n\nJip-Stack: 2/3\nJip-PR: #12",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WithTrailers(tt.desc, trailers); got != tt.want {
+				t.Errorf("WithTrailers() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTrailers_Idempotent(t *testing.T) {
+	trailers := []Trailer{{Key: "Jip-PR", Value: "#3"}}
+	once := WithTrailers("fix: y\n\nBody.", trailers)
+	if twice := WithTrailers(once, trailers); twice != once {
+		t.Errorf("second application changed the description:\n%q\n%q", once, twice)
+	}
+}
+
+func TestStripTrailers(t *testing.T) {
+	tests := []struct {
+		name string
+		desc string
+		want string
+	}{
+		{"no trailers", "feat: x\n\nBody.", "feat: x\n\nBody."},
+		{"only jip trailers", "feat: x\n\nJip-PR: #1", "feat: x"},
+		{"keeps foreign trailers", "feat: x\n\nSigned-off-by: A\nJip-PR: #1", "feat: x\n\nSigned-off-by: A"},
+		{"title is never stripped", "Jip-PR: #1", "Jip-PR: #1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripTrailers(tt.desc); got != tt.want {
+				t.Errorf("StripTrailers(%q) = %q, want %q", tt.desc, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripOverrides_KeepsJipTrailers(t *testing.T) {
+	desc := "feat: x\n\nBody.\n\nPR-Draft: true\nJip-PR: #1"
+	if got := StripOverrides(desc); got != "feat: x\n\nBody.\n\nJip-PR: #1" {
+		t.Errorf("StripOverrides = %q", got)
+	}
+	// jip rewriting its own trailers leaves the overrides alone.
+	if got := WithTrailers(desc, []Trailer{{"Jip-PR", "#2"}}); got != "feat: x\n\nBody.\n\nPR-Draft: true\nJip-PR: #2" {
+		t.Errorf("WithTrailers = %q", got)
+	}
+}
+
+func TestWithOverride(t *testing.T) {
+	tests := []struct {
+		desc string
+		want string
+	}{
+		{"feat: x", "feat: x\n\nPR-Title: [backport 1.2] feat: x"},
+		{"feat: x\n\nBody.", "feat: x\n\nBody.\n\nPR-Title: [backport 1.2] feat: x"},
+		{"feat: x\n\nBody.\n\npr-title: Old\nPR-Draft: true", "feat: x\n\nBody.\n\nPR-Draft: true\nPR-Title: [backport 1.2] feat: x"},
+	}
+	for _, tt := range tests {
+		if got := WithOverride(tt.desc, "PR-Title", "[backport 1.2] feat: x"); got != tt.want {
+			t.Errorf("WithOverride(%q) = %q, want %q", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	desc := "feat: add auth\n\nBody.\n\nPR-Title: Add authentication\npr-draft: true\nPR-Reviewers: alice, bob,\nSigned-off-by: A\nJip-PR: #1"
+	o, err := ParseOverrides(desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Title != "Add authentication" {
+		t.Errorf("Title = %q", o.Title)
+	}
+	if o.Draft == nil || !*o.Draft {
+		t.Errorf("Draft = %v, want true", o.Draft)
+	}
+	if strings.Join(o.Reviewers, ",") != "alice,bob" {
+		t.Errorf("Reviewers = %q", o.Reviewers)
+	}
+
+	c := Change{Description: desc}
+	if c.PRTitle() != "Add authentication" || c.Title() != "feat: add auth" {
+		t.Errorf("PRTitle = %q, Title = %q", c.PRTitle(), c.Title())
+	}
+	if c.Body() != "Body.\n\nSigned-off-by: A" {
+		t.Errorf("Body = %q", c.Body())
+	}
+}
+
+func TestParseOverrides_Invalid(t *testing.T) {
+	o, err := ParseOverrides("feat: x\n\nPR-Draft: maybe\nPR-Titel: typo\nPR-Reviewers: carol")
+	if err == nil || !strings.Contains(err.Error(), "PR-Draft") || !strings.Contains(err.Error(), "PR-Titel") {
+		t.Errorf("expected errors naming both trailers, got %v", err)
+	}
+	if o.Draft != nil || len(o.Reviewers) != 1 {
+		t.Errorf("valid trailers should still apply: %+v", o)
+	}
+}
+
+func TestParseOverrides_BodyIsNotTrailers(t *testing.T) {
+	o, err := ParseOverrides("feat: x\n\nPR-Title: mentioned in prose\nand more text")
+	if err != nil || o.Title != "" {
+		t.Errorf("got %+v, %v; a paragraph that is not all trailers is ignored", o, err)
+	}
+}
+
+func TestSkipsPR(t *testing.T) {
+	for _, tt := range []struct {
+		desc string
+		want bool
+	}{
+		{"feat: x", false},
+		{"feat: x\n\nJip-Skip: true", true},
+		{"feat: x\n\nBody.\n\nSigned-off-by: A\njip-skip: yes", false},
+		{"feat: x\n\nSigned-off-by: A\njip-skip: 1", true},
+		{"feat: x\n\nJip-Skip: false", false},
+		{"local debugging [Skip PR]", true},
+		{"feat: x\n\nJip-Skip: true\nis mentioned in prose", false},
+	} {
+		if got := SkipsPR(tt.desc); got != tt.want {
+			t.Errorf("SkipsPR(%q) = %v, want %v", tt.desc, got, tt.want)
+		}
+	}
+}